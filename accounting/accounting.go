@@ -221,12 +221,15 @@ const (
 	REQUEST_TIMER = "request_timer"
 
 	PREPARED = "prepared"
+
+	ADHOC_HITS   = "adhoc_hits"
+	ADHOC_MISSES = "adhoc_misses"
 )
 
 var metricNames = []string{REQUESTS, CANCELLED, SELECTS, UPDATES, INSERTS, DELETES, ACTIVE_REQUESTS, QUEUED_REQUESTS, INVALID_REQUESTS,
 	UNBOUNDED, AT_PLUS, SCAN_PLUS,
 	REQUEST_TIME, SERVICE_TIME, RESULT_COUNT, RESULT_SIZE, ERRORS, REQUESTS_250MS, REQUESTS_500MS, REQUESTS_1000MS,
-	REQUESTS_5000MS, WARNINGS, MUTATIONS}
+	REQUESTS_5000MS, WARNINGS, MUTATIONS, ADHOC_HITS, ADHOC_MISSES}
 
 // Map each duration to its metrics
 var slowMetricsMap = map[time.Duration][]string{
@@ -0,0 +1,142 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function ARRAY_AGG(expr ORDER BY ...).
+It returns an array of the non-MISSING values in the group, including
+NULLs, in the order given by the ORDER BY clause, where ARRAY_AGG(expr)
+returns them in collation order. Type OrderedArrayAgg is a struct
+that inherits from AggregateBase.
+*/
+type OrderedArrayAgg struct {
+	AggregateBase
+	order aggOrder
+}
+
+/*
+The function NewOrderedArrayAgg calls newAggregateBase to create
+an aggregate function named ARRAY_AGG with the expression and the
+sort expressions as input.
+*/
+func NewOrderedArrayAgg(operand expression.Expression, order SortTerms) Aggregate {
+	rv := &OrderedArrayAgg{
+		*newAggregateBase("array_agg", orderOperands(expression.Expressions{operand}, order)...),
+		newAggOrder(1, order),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *OrderedArrayAgg) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type ARRAY.
+*/
+func (this *OrderedArrayAgg) Type() value.Type { return value.ARRAY }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *OrderedArrayAgg) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewOrderedArrayAgg with the input operands
+cast to a Function as the FunctionConstructor. The operands after
+the first are the sort expressions.
+*/
+func (this *OrderedArrayAgg) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewOrderedArrayAgg(operands[0], this.order.terms(operands))
+	}
+}
+
+/*
+Returns the name, the argument and the ORDER BY clause.
+*/
+func (this *OrderedArrayAgg) Text(stringer *expression.Stringer) string {
+	return this.order.text(this.Name(), this.Operands(), stringer)
+}
+
+func (this *OrderedArrayAgg) EquivalentTo(other expression.Expression) bool {
+	otherAgg, ok := other.(*OrderedArrayAgg)
+	return ok && this.order.equivalentTo(&otherAgg.order) &&
+		expression.Equivalents(this.Children(), otherAgg.Children())
+}
+
+/*
+If no input to the ARRAY_AGG function, then the default value
+returned is a null.
+*/
+func (this *OrderedArrayAgg) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. For missing
+item values, return the input value itself. The values are
+collected in an array along with their sort keys.
+*/
+func (this *OrderedArrayAgg) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	val, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if val.Type() <= value.MISSING || val.Type() == value.BINARY {
+		return cumulative, nil
+	}
+
+	entry, e := this.order.entry(val, item, this.Operands(), context)
+	if e != nil {
+		return nil, e
+	}
+
+	return cumulateArrays("ARRAY_AGG", value.NewValue([]interface{}{entry}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *OrderedArrayAgg) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateArrays("ARRAY_AGG", part, cumulative)
+}
+
+/*
+Compute the Final result by sorting the values on their keys.
+*/
+func (this *OrderedArrayAgg) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	entries, _ := cumulative.Actual().([]interface{})
+	return value.NewValue(this.order.sortEntries(entries)), nil
+}
+
+/*
+Returns the ORDER BY terms.
+*/
+func (this *OrderedArrayAgg) Order() SortTerms {
+	return this.order.terms(this.Operands())
+}
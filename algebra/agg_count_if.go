@@ -0,0 +1,128 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function COUNT_IF(cond). It returns
+the number of items in the group for which the condition is TRUE.
+Type CountIf is a struct that inherits from AggregateBase.
+*/
+type CountIf struct {
+	AggregateBase
+}
+
+/*
+The function NewCountIf calls NewAggregateBase to
+create an aggregate function named COUNT_IF with
+one expression as input.
+*/
+func NewCountIf(operand expression.Expression) Aggregate {
+	rv := &CountIf{
+		*NewAggregateBase("count_if", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *CountIf) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *CountIf) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *CountIf) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewCountIf with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *CountIf) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewCountIf(operands[0])
+	}
+}
+
+/*
+If no input to the COUNT_IF function, then the default value
+returned is a zero value.
+*/
+func (this *CountIf) Default() value.Value { return value.ZERO_VALUE }
+
+/*
+Aggregates input data by evaluating operands. For values other
+than TRUE return the input value itself. Call cumulatePart to
+compute the intermediate aggregate value and return it.
+*/
+func (this *CountIf) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.BOOLEAN || !item.Truth() {
+		return cumulative, nil
+	}
+
+	return this.cumulatePart(value.ONE_VALUE, cumulative, context)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *CountIf) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Returns input cumulative value as the Final result.
+*/
+func (this *CountIf) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return cumulative, nil
+}
+
+/*
+Aggregate input partial values into cumulative result number value.
+If the partial and current cumulative result are both numbers, add
+them and return.
+*/
+func (this *CountIf) cumulatePart(part, cumulative value.Value, context Context) (value.Value, error) {
+	switch part := part.(type) {
+	case value.NumberValue:
+		switch cumulative := cumulative.(type) {
+		case value.NumberValue:
+			return cumulative.Add(part), nil
+		default:
+			return nil, fmt.Errorf("Invalid COUNT_IF %v of type %T.", cumulative.Actual(), cumulative.Actual())
+		}
+	default:
+		return nil, fmt.Errorf("Invalid partial COUNT_IF %v of type %T.", part.Actual(), part.Actual())
+	}
+}
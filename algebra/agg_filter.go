@@ -0,0 +1,182 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents an aggregate function with a FILTER (WHERE cond)
+clause, such as SUM(expr) FILTER (WHERE cond). Only the items for
+which the condition is TRUE are aggregated. The operands are those
+of the filtered aggregate followed by the condition, and the
+filtered aggregate is rebuilt whenever they are mapped. Type
+FilteredAggregate is a struct that inherits from AggregateBase.
+*/
+type FilteredAggregate struct {
+	AggregateBase
+	agg Aggregate
+}
+
+/*
+The function NewFilteredAggregate creates the aggregate function
+agg FILTER (WHERE cond).
+*/
+func NewFilteredAggregate(agg Aggregate, cond expression.Expression) Aggregate {
+	operands := make(expression.Expressions, 0, len(agg.Operands())+1)
+	operands = append(operands, agg.Operands()...)
+	operands = append(operands, cond)
+
+	rv := &FilteredAggregate{
+		*newAggregateBase(agg.Name(), operands...),
+		agg,
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *FilteredAggregate) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns the type of the filtered aggregate.
+*/
+func (this *FilteredAggregate) Type() value.Type { return this.agg.Type() }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *FilteredAggregate) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Returns true if the filtered aggregate has DISTINCT.
+*/
+func (this *FilteredAggregate) Distinct() bool { return this.agg.Distinct() }
+
+/*
+The constructor rebuilds the filtered aggregate from all but the
+last operand, and returns a NewFilteredAggregate with the last
+operand as the condition.
+*/
+func (this *FilteredAggregate) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		n := len(operands) - 1
+		agg := this.agg.Constructor()(operands[0:n]...).(Aggregate)
+		return NewFilteredAggregate(agg, operands[n])
+	}
+}
+
+/*
+Returns the filtered aggregate followed by the FILTER clause.
+*/
+func (this *FilteredAggregate) Text(stringer *expression.Stringer) string {
+	return stringer.Visit(this.agg) + " filter (where " + stringer.Visit(this.Condition()) + ")"
+}
+
+func (this *FilteredAggregate) EquivalentTo(other expression.Expression) bool {
+	otherAgg, ok := other.(*FilteredAggregate)
+	return ok && this.agg.EquivalentTo(otherAgg.agg) &&
+		this.Condition().EquivalentTo(otherAgg.Condition())
+}
+
+/*
+Return the operands other than the nil operand of COUNT(*).
+*/
+func (this *FilteredAggregate) Children() expression.Expressions {
+	operands := this.Operands()
+	if operands[0] == nil {
+		return operands[1:]
+	}
+
+	return operands
+}
+
+/*
+Map the children and rebuild the filtered aggregate from them.
+*/
+func (this *FilteredAggregate) MapChildren(mapper expression.Mapper) error {
+	operands := this.Operands()
+	for i, op := range operands {
+		if op == nil {
+			continue
+		}
+
+		expr, err := mapper.Map(op)
+		if err != nil {
+			return err
+		}
+
+		operands[i] = expr
+	}
+
+	this.agg = this.agg.Constructor()(operands[0 : len(operands)-1]...).(Aggregate)
+	return nil
+}
+
+/*
+Returns the default value of the filtered aggregate.
+*/
+func (this *FilteredAggregate) Default() value.Value { return this.agg.Default() }
+
+/*
+Evaluate the condition, and aggregate the item only if it is TRUE.
+*/
+func (this *FilteredAggregate) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	cond, e := this.Condition().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if cond.Type() != value.BOOLEAN || !cond.Truth() {
+		return cumulative, nil
+	}
+
+	return this.agg.CumulateInitial(item, cumulative, context)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *FilteredAggregate) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.agg.CumulateIntermediate(part, cumulative, context)
+}
+
+/*
+Compute the Final result of the filtered aggregate.
+*/
+func (this *FilteredAggregate) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return this.agg.ComputeFinal(cumulative, context)
+}
+
+/*
+Returns the filtered aggregate.
+*/
+func (this *FilteredAggregate) Aggregate() Aggregate {
+	return this.agg
+}
+
+/*
+Returns the FILTER condition.
+*/
+func (this *FilteredAggregate) Condition() expression.Expression {
+	operands := this.Operands()
+	return operands[len(operands)-1]
+}
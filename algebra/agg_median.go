@@ -0,0 +1,113 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function MEDIAN(expr). It returns
+the median of all the number values in the group, interpolating
+between the two middle values when their count is even. Type
+Median is a struct that inherits from AggregateBase.
+*/
+type Median struct {
+	AggregateBase
+}
+
+/*
+The function NewMedian calls NewAggregateBase to
+create an aggregate function named MEDIAN with
+one expression as input.
+*/
+func NewMedian(operand expression.Expression) Aggregate {
+	rv := &Median{
+		*NewAggregateBase("median", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Median) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *Median) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Median) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewMedian with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *Median) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewMedian(operands[0])
+	}
+}
+
+/*
+If no input to the MEDIAN function, then the default value
+returned is a null.
+*/
+func (this *Median) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. For all values
+other than Number, return the input value itself. The numbers
+are collected in an array.
+*/
+func (this *Median) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateArrays("MEDIAN", value.NewValue([]interface{}{item}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Median) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateArrays("MEDIAN", part, cumulative)
+}
+
+/*
+Compute the Final. Sort the numbers and interpolate at the middle.
+*/
+func (this *Median) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	values, _ := cumulative.Actual().([]interface{})
+	return interpolate(sortedNumbers(values), 0.5), nil
+}
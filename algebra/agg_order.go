@@ -0,0 +1,165 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+The ORDER BY clause of an ordered aggregate, such as ARRAY_AGG(expr
+ORDER BY ...) or STRING_AGG(expr, sep ORDER BY ...). The sort
+expressions follow the arguments among the operands of the
+aggregate, so that they are formalized, mapped and copied along
+with them; only the sort terms as given are kept here, for their
+directions, NULLS positions and collations.
+*/
+type aggOrder struct {
+	nargs int
+	order SortTerms
+}
+
+func newAggOrder(nargs int, terms SortTerms) aggOrder {
+	return aggOrder{
+		nargs: nargs,
+		order: terms,
+	}
+}
+
+/*
+Returns the arguments followed by the sort expressions.
+*/
+func orderOperands(args expression.Expressions, terms SortTerms) expression.Expressions {
+	operands := make(expression.Expressions, 0, len(args)+len(terms))
+	operands = append(operands, args...)
+	for _, term := range terms {
+		operands = append(operands, term.Expression())
+	}
+
+	return operands
+}
+
+/*
+Returns the sort terms rebuilt from the operands of the aggregate.
+*/
+func (this *aggOrder) terms(operands expression.Expressions) SortTerms {
+	terms := make(SortTerms, len(this.order))
+	for i, term := range this.order {
+		terms[i] = term.WithExpression(operands[this.nargs+i])
+	}
+
+	return terms
+}
+
+func (this *aggOrder) ordered() bool {
+	return len(this.order) > 0
+}
+
+func (this *aggOrder) equivalentTo(other *aggOrder) bool {
+	if this.nargs != other.nargs || len(this.order) != len(other.order) {
+		return false
+	}
+
+	for i, term := range this.order {
+		if term.modifiers() != other.order[i].modifiers() {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Writes name(args ORDER BY terms).
+*/
+func (this *aggOrder) text(name string, operands expression.Expressions, stringer *expression.Stringer) string {
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	buf.WriteString("(")
+
+	for i, op := range operands[0:this.nargs] {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(stringer.Visit(op))
+	}
+
+	if this.ordered() {
+		buf.WriteString(" order by ")
+		this.writeTerms(&buf, operands, stringer)
+	}
+
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func (this *aggOrder) writeTerms(buf *bytes.Buffer, operands expression.Expressions, stringer *expression.Stringer) {
+	for i, term := range this.order {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(stringer.Visit(operands[this.nargs+i]))
+		buf.WriteString(term.modifiers())
+	}
+}
+
+/*
+Returns the entry [val, key, ...] that carries the value to be
+aggregated with its sort keys, evaluated against the item.
+*/
+func (this *aggOrder) entry(val, item value.Value, operands expression.Expressions,
+	context Context) (value.Value, error) {
+	entry := make([]interface{}, 1, 1+len(this.order))
+	entry[0] = val
+
+	for _, op := range operands[this.nargs:] {
+		key, err := op.Evaluate(item, context)
+		if err != nil {
+			return nil, err
+		}
+		entry = append(entry, key)
+	}
+
+	return value.NewValue(entry), nil
+}
+
+/*
+Sorts the entries on their keys and returns their values. The sort
+is stable, so that entries with equal keys keep their input order.
+*/
+func (this *aggOrder) sortEntries(entries []interface{}) []interface{} {
+	rows := make([][]interface{}, len(entries))
+	for i, e := range entries {
+		rows[i], _ = value.NewValue(e).Actual().([]interface{})
+	}
+
+	if this.ordered() {
+		sort.SliceStable(rows, func(i, j int) bool {
+			for k, term := range this.order {
+				c := term.Compare(value.NewValue(rows[i][k+1]), value.NewValue(rows[j][k+1]))
+				if c != 0 {
+					return c < 0
+				}
+			}
+			return false
+		})
+	}
+
+	rv := make([]interface{}, len(rows))
+	for i, row := range rows {
+		rv[i] = row[0]
+	}
+
+	return rv
+}
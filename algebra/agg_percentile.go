@@ -0,0 +1,228 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate functions PERCENTILE_CONT(fraction)
+WITHIN GROUP (ORDER BY expr) and PERCENTILE_DISC(fraction) WITHIN
+GROUP (ORDER BY expr). PERCENTILE_CONT returns the value at the
+fraction of the ordered number values in the group, interpolating
+between the two closest values. PERCENTILE_DISC returns the first
+value whose position in the ordered values is at least the fraction.
+The operands are the ordered expression and the fraction, so that
+Operand() returns the aggregated expression. Type Percentile is a
+struct that inherits from AggregateBase.
+*/
+type Percentile struct {
+	AggregateBase
+	descending bool
+}
+
+/*
+The function NewPercentile calls newAggregateBase to create the
+aggregate function of the given name, percentile_cont or
+percentile_disc, ordered on the operand by the direction given.
+*/
+func NewPercentile(name string, operand, fraction expression.Expression, descending bool) Aggregate {
+	rv := &Percentile{
+		*newAggregateBase(name, operand, fraction),
+		descending,
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Percentile) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+PERCENTILE_CONT returns a NUMBER, and PERCENTILE_DISC returns one
+of the values in the group.
+*/
+func (this *Percentile) Type() value.Type {
+	if this.discrete() {
+		return value.JSON
+	}
+
+	return value.NUMBER
+}
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Percentile) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Minimum input arguments required is 2.
+*/
+func (this *Percentile) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed is 2.
+*/
+func (this *Percentile) MaxArgs() int { return 2 }
+
+/*
+The constructor returns a NewPercentile of the same name and
+direction with the input operands cast to a Function as the
+FunctionConstructor.
+*/
+func (this *Percentile) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewPercentile(this.Name(), operands[0], operands[1], this.descending)
+	}
+}
+
+/*
+Returns the name, the fraction and the WITHIN GROUP clause.
+*/
+func (this *Percentile) Text(stringer *expression.Stringer) string {
+	var buf bytes.Buffer
+	buf.WriteString(this.Name())
+	buf.WriteString("(")
+	buf.WriteString(stringer.Visit(this.Fraction()))
+	buf.WriteString(") within group (order by ")
+	buf.WriteString(stringer.Visit(this.Operand()))
+	if this.descending {
+		buf.WriteString(" desc")
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func (this *Percentile) EquivalentTo(other expression.Expression) bool {
+	otherPercentile, ok := other.(*Percentile)
+	return ok && this.Name() == otherPercentile.Name() &&
+		this.descending == otherPercentile.descending &&
+		expression.Equivalents(this.Children(), otherPercentile.Children())
+}
+
+/*
+If no input to the function, then the default value returned
+is a null.
+*/
+func (this *Percentile) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. PERCENTILE_CONT
+collects the numbers, and PERCENTILE_DISC the values other than
+NULL and MISSING, in an array.
+*/
+func (this *Percentile) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if this.discrete() {
+		if item.Type() <= value.NULL || item.Type() == value.BINARY {
+			return cumulative, nil
+		}
+	} else if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateArrays(strings.ToUpper(this.Name()), value.NewValue([]interface{}{item}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Percentile) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateArrays(strings.ToUpper(this.Name()), part, cumulative)
+}
+
+/*
+Compute the Final. Evaluate the fraction, which must be a number
+between 0 and 1, and find the corresponding value among the
+ordered values.
+*/
+func (this *Percentile) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	fraction, e := this.Fraction().Evaluate(value.NULL_VALUE, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if fraction.Type() != value.NUMBER ||
+		fraction.Actual().(float64) < 0.0 || fraction.Actual().(float64) > 1.0 {
+		return nil, fmt.Errorf("The fraction of %s must be a number between 0 and 1: %v.",
+			strings.ToUpper(this.Name()), fraction.Actual())
+	}
+
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	f := fraction.Actual().(float64)
+	values, _ := cumulative.Actual().([]interface{})
+
+	if !this.discrete() {
+		if this.descending {
+			f = 1.0 - f
+		}
+
+		return interpolate(sortedNumbers(values), f), nil
+	}
+
+	if len(values) == 0 {
+		return value.NULL_VALUE, nil
+	}
+
+	sort.Sort(value.NewSorter(cumulative))
+
+	i := int(math.Ceil(f*float64(len(values)))) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	if this.descending {
+		i = len(values) - 1 - i
+	}
+
+	return value.NewValue(values[i]), nil
+}
+
+/*
+Returns the fraction.
+*/
+func (this *Percentile) Fraction() expression.Expression {
+	return this.Operands()[1]
+}
+
+/*
+Returns true if the result is ordered descending.
+*/
+func (this *Percentile) Descending() bool {
+	return this.descending
+}
+
+func (this *Percentile) discrete() bool {
+	return this.Name() == "percentile_disc"
+}
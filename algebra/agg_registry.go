@@ -10,7 +10,10 @@
 package algebra
 
 import (
+	"fmt"
 	"strings"
+
+	"github.com/couchbase/query/expression"
 )
 
 /*
@@ -31,32 +34,102 @@ func GetAggregate(name string, distinct bool) (Aggregate, bool) {
 	}
 }
 
+/*
+This method is used by the parser to create an aggregate function
+with an ORDER BY clause, which is allowed in ARRAY_AGG(expr ORDER BY
+...) and STRING_AGG(expr, separator ORDER BY ...).
+*/
+func NewOrderedAggregate(name string, args expression.Expressions, order SortTerms) (Aggregate, error) {
+	switch strings.ToLower(name) {
+	case "array_agg":
+		if len(args) == 1 {
+			return NewOrderedArrayAgg(args[0], order), nil
+		}
+	case "string_agg":
+		if len(args) == 2 {
+			return NewStringAgg(args[0], args[1], order), nil
+		}
+	default:
+		return nil, fmt.Errorf("ORDER BY is not allowed in function %s.", name)
+	}
+
+	return nil, fmt.Errorf("Wrong number of arguments to function %s.", name)
+}
+
+/*
+This method is used by the parser to create an aggregate function
+with a WITHIN GROUP (ORDER BY expr) clause, which is required by
+PERCENTILE_CONT(fraction) and PERCENTILE_DISC(fraction).
+*/
+func NewWithinGroupAggregate(name string, args expression.Expressions, term *SortTerm) (Aggregate, error) {
+	lname := strings.ToLower(name)
+	switch lname {
+	case "percentile_cont", "percentile_disc":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Wrong number of arguments to function %s.", name)
+		}
+
+		if term.Collation() != nil {
+			return nil, fmt.Errorf("COLLATE is not allowed in WITHIN GROUP of function %s.", name)
+		}
+
+		return NewPercentile(lname, term.Expression(), args[0], term.Descending()), nil
+	default:
+		return nil, fmt.Errorf("WITHIN GROUP is not allowed in function %s.", name)
+	}
+}
+
 /*
 Aggregate functions with a DISTINCT specified. The variable
 represents a map from string to Aggregate Function. The
-aggregate functions ARRAY_AGG, AVG, COUNT and SUM  are
-defined by _DISTINCT_AGGREGATES. They map to the corresponding
-distinct methods.
+aggregate functions ARRAY_AGG, AVG, COUNT, MEDIAN, SUM and the
+variance and standard deviation functions are defined by
+_DISTINCT_AGGREGATES. They map to the corresponding distinct
+methods. MEAN is a synonym for AVG.
 */
 var _DISTINCT_AGGREGATES = map[string]Aggregate{
-	"array_agg": &ArrayAggDistinct{},
-	"avg":       &AvgDistinct{},
-	"count":     &CountDistinct{},
-	"countn":    &CountnDistinct{},
-	"sum":       &SumDistinct{},
+	"array_agg":     &ArrayAggDistinct{},
+	"avg":           &AvgDistinct{},
+	"count":         &CountDistinct{},
+	"countn":        &CountnDistinct{},
+	"mean":          &AvgDistinct{},
+	"median":        &MedianDistinct{},
+	"stddev":        NewVarianceDistinct("stddev", nil),
+	"stddev_pop":    NewVarianceDistinct("stddev_pop", nil),
+	"stddev_samp":   NewVarianceDistinct("stddev_samp", nil),
+	"sum":           &SumDistinct{},
+	"var_pop":       NewVarianceDistinct("var_pop", nil),
+	"var_samp":      NewVarianceDistinct("var_samp", nil),
+	"variance":      NewVarianceDistinct("variance", nil),
+	"variance_pop":  NewVarianceDistinct("var_pop", nil),
+	"variance_samp": NewVarianceDistinct("var_samp", nil),
 }
 
 /*
 Non Distinct Aggregate functions. The variable represents a
 map from string to Aggregate Function. Contains aggregate
-functions ARRAY_AGG, AVG, COUNT, MAX, MIN and SUM.
+functions ARRAY_AGG, AVG, COUNT, COUNT_IF, MAX, MEDIAN, MIN,
+STRING_AGG, SUM and the variance and standard deviation functions.
+MEAN is a synonym for AVG.
 */
 var _OTHER_AGGREGATES = map[string]Aggregate{
-	"array_agg": &ArrayAgg{},
-	"avg":       &Avg{},
-	"count":     &Count{},
-	"countn":    &Countn{},
-	"max":       &Max{},
-	"min":       &Min{},
-	"sum":       &Sum{},
+	"array_agg":     &ArrayAgg{},
+	"avg":           &Avg{},
+	"count":         &Count{},
+	"count_if":      &CountIf{},
+	"countn":        &Countn{},
+	"max":           &Max{},
+	"mean":          &Avg{},
+	"median":        &Median{},
+	"min":           &Min{},
+	"stddev":        NewVariance("stddev", nil),
+	"stddev_pop":    NewVariance("stddev_pop", nil),
+	"stddev_samp":   NewVariance("stddev_samp", nil),
+	"string_agg":    &StringAgg{},
+	"sum":           &Sum{},
+	"var_pop":       NewVariance("var_pop", nil),
+	"var_samp":      NewVariance("var_samp", nil),
+	"variance":      NewVariance("variance", nil),
+	"variance_pop":  NewVariance("var_pop", nil),
+	"variance_samp": NewVariance("var_samp", nil),
 }
@@ -0,0 +1,183 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function STRING_AGG(expr, separator
+[ORDER BY ...]). It returns the concatenation of the string values
+in the group, separated by the separator, in the order given by the
+ORDER BY clause if any. Type StringAgg is a struct that inherits
+from AggregateBase.
+*/
+type StringAgg struct {
+	AggregateBase
+	order aggOrder
+}
+
+/*
+The function NewStringAgg calls newAggregateBase to create an
+aggregate function named STRING_AGG with the expression, the
+separator and the sort expressions as input.
+*/
+func NewStringAgg(operand, separator expression.Expression, order SortTerms) Aggregate {
+	rv := &StringAgg{
+		*newAggregateBase("string_agg",
+			orderOperands(expression.Expressions{operand, separator}, order)...),
+		newAggOrder(2, order),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *StringAgg) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type STRING.
+*/
+func (this *StringAgg) Type() value.Type { return value.STRING }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *StringAgg) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Minimum input arguments required is 2.
+*/
+func (this *StringAgg) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed is 2.
+*/
+func (this *StringAgg) MaxArgs() int { return 2 }
+
+/*
+The constructor returns a NewStringAgg with the input operands
+cast to a Function as the FunctionConstructor. The operands after
+the separator are the sort expressions.
+*/
+func (this *StringAgg) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStringAgg(operands[0], operands[1], this.order.terms(operands))
+	}
+}
+
+/*
+Returns the name, the arguments and the ORDER BY clause.
+*/
+func (this *StringAgg) Text(stringer *expression.Stringer) string {
+	return this.order.text(this.Name(), this.Operands(), stringer)
+}
+
+func (this *StringAgg) EquivalentTo(other expression.Expression) bool {
+	otherAgg, ok := other.(*StringAgg)
+	return ok && this.order.equivalentTo(&otherAgg.order) &&
+		expression.Equivalents(this.Children(), otherAgg.Children())
+}
+
+/*
+If no input to the STRING_AGG function, then the default value
+returned is a null.
+*/
+func (this *StringAgg) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. For all values
+other than String, return the input value itself. The strings
+are collected in an array along with their sort keys.
+*/
+func (this *StringAgg) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	val, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if val.Type() != value.STRING {
+		return cumulative, nil
+	}
+
+	entry, e := this.order.entry(val, item, this.Operands(), context)
+	if e != nil {
+		return nil, e
+	}
+
+	return cumulateArrays("STRING_AGG", value.NewValue([]interface{}{entry}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *StringAgg) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateArrays("STRING_AGG", part, cumulative)
+}
+
+/*
+Compute the Final. Evaluate the separator, which must be a
+string, sort the strings and concatenate them.
+*/
+func (this *StringAgg) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	separator, e := this.Separator().Evaluate(value.NULL_VALUE, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if separator.Type() != value.STRING {
+		return nil, fmt.Errorf("The separator of STRING_AGG must be a string: %v.", separator.Actual())
+	}
+
+	sep := separator.Actual().(string)
+	entries, _ := cumulative.Actual().([]interface{})
+
+	var buf bytes.Buffer
+	for i, s := range this.order.sortEntries(entries) {
+		if i > 0 {
+			buf.WriteString(sep)
+		}
+		buf.WriteString(value.NewValue(s).Actual().(string))
+	}
+
+	return value.NewValue(buf.String()), nil
+}
+
+/*
+Returns the separator.
+*/
+func (this *StringAgg) Separator() expression.Expression {
+	return this.Operands()[1]
+}
+
+/*
+Returns the ORDER BY terms.
+*/
+func (this *StringAgg) Order() SortTerms {
+	return this.order.terms(this.Operands())
+}
@@ -11,6 +11,8 @@ package algebra
 
 import (
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/couchbase/query/value"
 )
@@ -99,3 +101,62 @@ func getSet(item value.Value) (*value.Set, error) {
 		return nil, fmt.Errorf("Invalid DISTINCT %v of type %T.", item, item)
 	}
 }
+
+/*
+Aggregate partial arrays of values by appending the partial array
+to the cumulative one. Either may be NULL if it has no values yet.
+*/
+func cumulateArrays(name string, part, cumulative value.Value) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	actual, ok := part.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid partial %s %v of type %T.", name, part.Actual(), part.Actual())
+	}
+
+	array, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s %v of type %T.", name, cumulative.Actual(), cumulative.Actual())
+	}
+
+	return value.NewValue(append(array, actual...)), nil
+}
+
+/*
+Return the numbers among the values as a sorted slice.
+*/
+func sortedNumbers(values []interface{}) []float64 {
+	numbers := make([]float64, 0, len(values))
+	for _, v := range values {
+		val := value.NewValue(v)
+		if val.Type() == value.NUMBER {
+			numbers = append(numbers, val.Actual().(float64))
+		}
+	}
+
+	sort.Float64s(numbers)
+	return numbers
+}
+
+/*
+Linear interpolation between the two numbers closest to the
+fraction of the sorted numbers, as in PERCENTILE_CONT() and MEDIAN().
+*/
+func interpolate(numbers []float64, fraction float64) value.Value {
+	if len(numbers) == 0 {
+		return value.NULL_VALUE
+	}
+
+	rn := fraction * float64(len(numbers)-1)
+	lo := math.Floor(rn)
+	hi := math.Ceil(rn)
+	if lo == hi {
+		return value.NewValue(numbers[int(lo)])
+	}
+
+	return value.NewValue(numbers[int(lo)] + (rn-lo)*(numbers[int(hi)]-numbers[int(lo)]))
+}
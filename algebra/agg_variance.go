@@ -0,0 +1,207 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate functions VARIANCE(expr), VAR_POP(expr),
+VAR_SAMP(expr), STDDEV(expr), STDDEV_POP(expr) and STDDEV_SAMP(expr).
+They return the variance or the standard deviation of all the number
+values in the group. The _POP functions use the population formula,
+and the _SAMP functions the sample formula. VARIANCE and STDDEV use
+the sample formula, except that they return 0 for a single value.
+Type Variance is a struct that inherits from AggregateBase.
+*/
+type Variance struct {
+	AggregateBase
+}
+
+/*
+The function NewVariance calls NewAggregateBase to create
+the aggregate function of the given name with one expression
+as input.
+*/
+func NewVariance(name string, operand expression.Expression) Aggregate {
+	rv := &Variance{
+		*NewAggregateBase(name, operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Variance) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *Variance) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Variance) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewVariance of the same name with the
+input operand cast to a Function as the FunctionConstructor.
+*/
+func (this *Variance) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVariance(this.Name(), operands[0])
+	}
+}
+
+/*
+If no input to the function, then the default value returned
+is a null.
+*/
+func (this *Variance) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. For all values
+other than Number, return the input value itself. Each number
+is a partial result of count 1 and mean equal to the number.
+*/
+func (this *Variance) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	part := value.NewValue(map[string]interface{}{
+		"count": value.ONE_VALUE,
+		"mean":  item,
+		"m2":    value.ZERO_VALUE,
+	})
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Variance) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Compute the Final. The variance is computed from the count and
+the sum of squared deviations from the mean.
+*/
+func (this *Variance) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	count, _ := cumulative.Field("count")
+	m2, _ := cumulative.Field("m2")
+
+	if count.Type() != value.NUMBER || m2.Type() != value.NUMBER {
+		return nil, fmt.Errorf("Missing or invalid count or m2 in %s: %v, %v.",
+			strings.ToUpper(this.Name()), count.Actual(), m2.Actual())
+	}
+
+	return varianceOf(this.Name(), count.Actual().(float64), m2.Actual().(float64)), nil
+}
+
+/*
+Aggregate input partial values into the cumulative count, mean
+and sum of squared deviations from the mean. The partial results
+are combined pairwise, so that they can be computed in parallel.
+*/
+func (this *Variance) cumulatePart(part, cumulative value.Value, context Context) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	pcount, _ := part.Field("count")
+	pmean, _ := part.Field("mean")
+	pm2, _ := part.Field("m2")
+	ccount, _ := cumulative.Field("count")
+	cmean, _ := cumulative.Field("mean")
+	cm2, _ := cumulative.Field("m2")
+
+	if pcount.Type() != value.NUMBER || pmean.Type() != value.NUMBER || pm2.Type() != value.NUMBER ||
+		ccount.Type() != value.NUMBER || cmean.Type() != value.NUMBER || cm2.Type() != value.NUMBER {
+		return nil, fmt.Errorf("Missing or invalid partial count, mean or m2 in %s: %v, %v.",
+			strings.ToUpper(this.Name()), part.Actual(), cumulative.Actual())
+	}
+
+	pn := pcount.Actual().(float64)
+	cn := ccount.Actual().(float64)
+	n := pn + cn
+	if n == 0 {
+		return cumulative, nil
+	}
+
+	delta := pmean.Actual().(float64) - cmean.Actual().(float64)
+	mean := cmean.Actual().(float64) + delta*pn/n
+	m2 := cm2.Actual().(float64) + pm2.Actual().(float64) + delta*delta*pn*cn/n
+
+	cumulative.SetField("count", n)
+	cumulative.SetField("mean", mean)
+	cumulative.SetField("m2", m2)
+	return cumulative, nil
+}
+
+/*
+Return the variance or standard deviation of the given name from
+the count of values and their sum of squared deviations from the
+mean.
+*/
+func varianceOf(name string, count, m2 float64) value.Value {
+	if count <= 0 {
+		return value.NULL_VALUE
+	}
+
+	var variance float64
+	switch name {
+	case "var_pop", "stddev_pop":
+		variance = m2 / count
+	case "var_samp", "stddev_samp":
+		if count < 2 {
+			return value.NULL_VALUE
+		}
+		variance = m2 / (count - 1)
+	default:
+		if count > 1 {
+			variance = m2 / (count - 1)
+		}
+	}
+
+	if strings.HasPrefix(name, "stddev") {
+		return value.NewValue(math.Sqrt(variance))
+	}
+
+	return value.NewValue(variance)
+}
@@ -0,0 +1,145 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate functions VARIANCE(DISTINCT expr),
+VAR_POP(DISTINCT expr), VAR_SAMP(DISTINCT expr), STDDEV(DISTINCT
+expr), STDDEV_POP(DISTINCT expr) and STDDEV_SAMP(DISTINCT expr).
+They return the variance or the standard deviation of all the
+distinct number values in the group. Type VarianceDistinct is a
+struct that inherits from DistinctAggregateBase.
+*/
+type VarianceDistinct struct {
+	DistinctAggregateBase
+}
+
+/*
+The function NewVarianceDistinct calls NewDistinctAggregateBase
+to create the aggregate function of the given name with one
+expression as input.
+*/
+func NewVarianceDistinct(name string, operand expression.Expression) Aggregate {
+	rv := &VarianceDistinct{
+		*NewDistinctAggregateBase(name, operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *VarianceDistinct) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *VarianceDistinct) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *VarianceDistinct) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewVarianceDistinct of the same name
+with the input operand cast to a Function as the FunctionConstructor.
+*/
+func (this *VarianceDistinct) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVarianceDistinct(this.Name(), operands[0])
+	}
+}
+
+/*
+If no input to the function with DISTINCT, then the default
+value returned is a null.
+*/
+func (this *VarianceDistinct) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. For all
+values other than Number, return the input value itself.
+Call setAdd to compute the intermediate aggregate value
+and return it.
+*/
+func (this *VarianceDistinct) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return setAdd(item, cumulative)
+}
+
+/*
+Aggregates distinct intermediate results and return them.
+*/
+func (this *VarianceDistinct) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateSets(part, cumulative)
+}
+
+/*
+Compute the Final result. If input cumulative value is null return
+it. Get the attachment, compute the mean of the values in the set
+and then the sum of their squared deviations from the mean.
+*/
+func (this *VarianceDistinct) ComputeFinal(cumulative value.Value, context Context) (c value.Value, e error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	av := cumulative.(value.AnnotatedValue)
+	set := av.GetAttachment("set").(*value.Set)
+	if set.Len() == 0 {
+		return value.NULL_VALUE, nil
+	}
+
+	values := set.Values()
+	sum := 0.0
+	for _, v := range values {
+		if v.Type() != value.NUMBER {
+			return nil, fmt.Errorf("Invalid partial %s %v of type %T.",
+				strings.ToUpper(this.Name()), v.Actual(), v.Actual())
+		}
+
+		sum += v.Actual().(float64)
+	}
+
+	count := float64(len(values))
+	mean := sum / count
+	m2 := 0.0
+	for _, v := range values {
+		delta := v.Actual().(float64) - mean
+		m2 += delta * delta
+	}
+
+	return varianceOf(this.Name(), count, m2), nil
+}
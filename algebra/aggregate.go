@@ -87,6 +87,18 @@ func NewAggregateBase(name string, operand expression.Expression) *AggregateBase
 	}
 }
 
+/*
+This method creates an aggregate function with several operands,
+such as STRING_AGG() or an aggregate with an ORDER BY clause. The
+first operand is returned by Operand().
+*/
+func newAggregateBase(name string, operands ...expression.Expression) *AggregateBase {
+	return &AggregateBase{
+		expression.UnaryFunctionBase{*expression.NewFunctionBase(name, operands...)},
+		"",
+	}
+}
+
 /*
 This method evaluates the input aggregate, by retrieving the
 aggregates map from the attachments and performing a lookup
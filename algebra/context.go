@@ -21,4 +21,5 @@ type Context interface {
 	NamedArg(name string) (value.Value, bool)
 	PositionalArg(position int) (value.Value, bool)
 	EvaluateSubquery(query *Select, parent value.Value) (value.Value, error)
+	SequenceValue(namespace, name string, next bool) (value.Value, error)
 }
@@ -20,19 +20,22 @@ import (
 Represents the delete DML statement. Type Delete is a
 struct that contains fields mapping to each clause in
 the delete stmt.  Keyspace is the keyspace-ref, keys
-expression represents the use keys clause, the where
+expression represents the use keys clause, using is the
+from term of the using clause, the where
 and limit expression map to the where and limit clause
 and returning represents the returning clause.
 */
 type Delete struct {
 	statementBase
 
-	keyspace  *KeyspaceRef          `json:"keyspace"`
-	keys      expression.Expression `json:"keys"`
-	indexes   IndexRefs             `json:"indexes"`
-	where     expression.Expression `json:"where"`
-	limit     expression.Expression `json:"limit"`
-	returning *Projection           `json:"returning"`
+	keyspace   *KeyspaceRef          `json:"keyspace"`
+	keys       expression.Expression `json:"keys"`
+	indexes    IndexRefs             `json:"indexes"`
+	using      FromTerm              `json:"using"`
+	where      expression.Expression `json:"where"`
+	limit      expression.Expression `json:"limit"`
+	returning  *Projection           `json:"returning"`
+	optimHints *OptimHints
 }
 
 /*
@@ -41,11 +44,12 @@ struct by assigning the input attributes to the fields
 of the struct
 */
 func NewDelete(keyspace *KeyspaceRef, keys expression.Expression, indexes IndexRefs,
-	where, limit expression.Expression, returning *Projection) *Delete {
+	using FromTerm, where, limit expression.Expression, returning *Projection) *Delete {
 	rv := &Delete{
 		keyspace:  keyspace,
 		keys:      keys,
 		indexes:   indexes,
+		using:     using,
 		where:     where,
 		limit:     limit,
 		returning: returning,
@@ -93,6 +97,13 @@ func (this *Delete) MapExpressions(mapper expression.Mapper) (err error) {
 		}
 	}
 
+	if this.using != nil {
+		err = this.using.MapExpressions(mapper)
+		if err != nil {
+			return err
+		}
+	}
+
 	if this.where != nil {
 		this.where, err = mapper.Map(this.where)
 		if err != nil {
@@ -124,6 +135,10 @@ func (this *Delete) Expressions() expression.Expressions {
 		exprs = append(exprs, this.keys)
 	}
 
+	if this.using != nil {
+		exprs = append(exprs, this.using.Expressions()...)
+	}
+
 	if this.where != nil {
 		exprs = append(exprs, this.where)
 	}
@@ -154,10 +169,18 @@ func (this *Delete) Privileges() (*auth.Privileges, errors.Error) {
 	} else {
 		privs.Add(fullKeyspace, auth.PRIV_QUERY_DELETE)
 	}
-	if this.returning != nil {
+	if this.returning != nil || this.using != nil {
 		privs.Add(fullKeyspace, auth.PRIV_QUERY_SELECT)
 	}
 
+	if this.using != nil {
+		usingPrivs, err := this.using.Privileges()
+		if err != nil {
+			return nil, err
+		}
+		privs.AddAll(usingPrivs)
+	}
+
 	exprs := this.Expressions()
 	for _, expr := range exprs {
 		privs.AddAll(expr.Privileges())
@@ -181,6 +204,13 @@ func (this *Delete) Formalize() (err error) {
 		return err
 	}
 
+	if this.using != nil {
+		f, err = formalizeMutateFrom(f, this.using)
+		if err != nil {
+			return err
+		}
+	}
+
 	empty := expression.NewFormalizer("", nil)
 	if this.keys != nil {
 		_, err = this.keys.Accept(empty)
@@ -232,6 +262,14 @@ func (this *Delete) Indexes() IndexRefs {
 	return this.indexes
 }
 
+/*
+Returns the from term of the using clause in the
+delete statement.
+*/
+func (this *Delete) Using() FromTerm {
+	return this.using
+}
+
 /*
 Returns the expression for the where clause in the
 delete statement.
@@ -255,3 +293,17 @@ delete statement.
 func (this *Delete) Returning() *Projection {
 	return this.returning
 }
+
+/*
+Returns the optimizer hints of the delete statement, or nil.
+*/
+func (this *Delete) OptimHints() *OptimHints {
+	return this.optimHints
+}
+
+/*
+Sets the optimizer hints of the delete statement.
+*/
+func (this *Delete) SetOptimHints(optimHints *OptimHints) {
+	this.optimHints = optimHints
+}
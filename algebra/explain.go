@@ -23,8 +23,10 @@ a struct that represents the explain json statement.
 type Explain struct {
 	statementBase
 
-	stmt Statement `json:"stmt"`
-	text string    `json:"text"`
+	stmt    Statement `json:"stmt"`
+	text    string    `json:"text"`
+	analyze bool
+	results bool
 }
 
 /*
@@ -41,6 +43,19 @@ func NewExplain(stmt Statement, text string) *Explain {
 	return rv
 }
 
+/*
+The function NewExplainAnalyze returns an Explain that executes
+the input Statement and annotates its plan with the actual
+statistics of each operator. If results is true, the results of
+the statement are returned along with the plan.
+*/
+func NewExplainAnalyze(stmt Statement, text string, results bool) *Explain {
+	rv := NewExplain(stmt, text)
+	rv.analyze = true
+	rv.results = results
+	return rv
+}
+
 /*
 It calls the VisitExplain method by passing in the receiver to
 and returns the interface. It is a visitor pattern.
@@ -99,6 +114,21 @@ func (this *Explain) Text() string {
 	return this.text
 }
 
+/*
+Returns true for EXPLAIN ANALYZE, which executes the statement.
+*/
+func (this *Explain) Analyze() bool {
+	return this.analyze
+}
+
+/*
+Returns true if EXPLAIN ANALYZE also returns the results of the
+statement.
+*/
+func (this *Explain) Results() bool {
+	return this.results
+}
+
 func (this *Explain) Type() string {
 	return "EXPLAIN"
 }
@@ -143,6 +143,13 @@ func (this *Join) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the JOIN.
+*/
+func (this *Join) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Returns the right source object of the JOIN.
 */
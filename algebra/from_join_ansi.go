@@ -22,14 +22,15 @@ Represents the ANSI JOIN clause. AnsiJoins create new input objects by
 combining two or more source objects.  They can be chained.
 */
 type AnsiJoin struct {
-	left     FromTerm
-	right    FromTerm
-	outer    bool
-	onclause expression.Expression
+	left       FromTerm
+	right      FromTerm
+	outer      bool
+	rightOuter bool
+	onclause   expression.Expression
 }
 
 func NewAnsiJoin(left FromTerm, outer bool, right FromTerm, onclause expression.Expression) *AnsiJoin {
-	return &AnsiJoin{left, right, outer, onclause}
+	return &AnsiJoin{left, right, outer, false, onclause}
 }
 
 func (this *AnsiJoin) Accept(visitor NodeVisitor) (interface{}, error) {
@@ -89,7 +90,11 @@ func (this *AnsiJoin) Privileges() (*auth.Privileges, errors.Error) {
 func (this *AnsiJoin) String() string {
 	s := this.left.String()
 
-	if this.outer {
+	if this.outer && this.rightOuter {
+		s += " full outer join "
+	} else if this.rightOuter {
+		s += " right outer join "
+	} else if this.outer {
 		s += " left outer join "
 	} else {
 		s += " join "
@@ -116,6 +121,10 @@ func (this *AnsiJoin) Formalize(parent *expression.Formalizer) (f *expression.Fo
 		return
 	}
 
+	// subquery and expression terms set up their own formalizer:
+	// past the JOIN there is no default keyspace
+	f.SetKeyspace("")
+
 	this.onclause, err = f.Map(this.onclause)
 	if err != nil {
 		return
@@ -146,6 +155,13 @@ func (this *AnsiJoin) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the JOIN.
+*/
+func (this *AnsiJoin) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Returns the right source object of the JOIN.
 */
@@ -153,6 +169,13 @@ func (this *AnsiJoin) Right() FromTerm {
 	return this.right
 }
 
+/*
+Set the right source object of the JOIN.
+*/
+func (this *AnsiJoin) SetRight(right FromTerm) {
+	this.right = right
+}
+
 /*
 Returns boolean value based on if it is
 an outer or inner JOIN.
@@ -161,6 +184,21 @@ func (this *AnsiJoin) Outer() bool {
 	return this.outer
 }
 
+/*
+Returns boolean value based on if the unmatched objects of the right
+source are preserved, as in a RIGHT or FULL OUTER JOIN.
+*/
+func (this *AnsiJoin) RightOuter() bool {
+	return this.rightOuter
+}
+
+/*
+Preserve the unmatched objects of the right source.
+*/
+func (this *AnsiJoin) SetRightOuter() {
+	this.rightOuter = true
+}
+
 /*
 Returns ON-clause of ANSI JOIN
 */
@@ -183,6 +221,9 @@ func (this *AnsiJoin) MarshalJSON() ([]byte, error) {
 	r["left"] = this.left
 	r["right"] = this.right
 	r["outer"] = this.outer
+	if this.rightOuter {
+		r["right_outer"] = this.rightOuter
+	}
 	r["onclause"] = this.onclause
 	return json.Marshal(r)
 }
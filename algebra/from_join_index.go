@@ -155,6 +155,13 @@ func (this *IndexJoin) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the JOIN.
+*/
+func (this *IndexJoin) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Returns the right source object of the JOIN.
 */
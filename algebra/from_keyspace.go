@@ -22,6 +22,7 @@ const (
 	KS_ANSI_NEST                // right-hand side of ANSI NEST
 	KS_PRIMARY_JOIN             // join on primary key (meta().id)
 	KS_UNDER_NL                 // inner side of nested-loop join
+	KS_MUTATE_TARGET            // target of UPDATE ... FROM or DELETE ... USING
 )
 
 /*
@@ -316,6 +317,14 @@ func (this *KeyspaceTerm) IsUnderNL() bool {
 	return (this.property & KS_UNDER_NL) != 0
 }
 
+/*
+Returns whether this keyspace is the target of UPDATE ... FROM
+or DELETE ... USING
+*/
+func (this *KeyspaceTerm) IsMutateTarget() bool {
+	return (this.property & KS_MUTATE_TARGET) != 0
+}
+
 /*
 Set join keys
 */
@@ -356,6 +365,13 @@ func (this *KeyspaceTerm) SetPrimaryJoin() {
 	}
 }
 
+/*
+Set MUTATE TARGET property
+*/
+func (this *KeyspaceTerm) SetMutateTarget() {
+	this.property |= KS_MUTATE_TARGET
+}
+
 /*
 Set UNDER NL property
 */
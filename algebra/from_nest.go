@@ -147,6 +147,13 @@ func (this *Nest) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the NEST.
+*/
+func (this *Nest) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Returns the right term in the NEST clause.
 */
@@ -121,6 +121,10 @@ func (this *AnsiNest) Formalize(parent *expression.Formalizer) (f *expression.Fo
 		return
 	}
 
+	// subquery and expression terms set up their own formalizer:
+	// past the NEST there is no default keyspace
+	f.SetKeyspace("")
+
 	this.onclause, err = f.Map(this.onclause)
 	if err != nil {
 		return
@@ -150,6 +154,13 @@ func (this *AnsiNest) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the NEST.
+*/
+func (this *AnsiNest) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Returns the right term in the NEST clause.
 */
@@ -157,6 +168,13 @@ func (this *AnsiNest) Right() FromTerm {
 	return this.right
 }
 
+/*
+Set the right source object of the NEST.
+*/
+func (this *AnsiNest) SetRight(right FromTerm) {
+	this.right = right
+}
+
 /*
 Returns boolean value based on if it is
 an outer or inner NEST.
@@ -158,6 +158,13 @@ func (this *IndexNest) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the NEST.
+*/
+func (this *IndexNest) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Returns the right term in the NEST clause.
 */
@@ -0,0 +1,225 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+PIVOT (agg(expr) FOR key IN (values)) AS alias turns the rows of
+each group into a single object, bound to the alias, with a field
+for each of the constant values holding the aggregate of the rows
+whose key is equal to that value. The fields are named after the
+values. The object is computed by grouping: it is bound by the
+LETTING clause of the subselect, as if for each value the query
+had LETTING alias = {value: agg(expr) FILTER (WHERE key = value)},
+so that the subselect has a single group unless it is grouped by.
+*/
+type Pivot struct {
+	left   FromTerm
+	agg    Aggregate
+	key    expression.Expression
+	values expression.Expressions
+	as     string
+}
+
+func NewPivot(left FromTerm, agg Aggregate, key expression.Expression,
+	values expression.Expressions, as string) *Pivot {
+	return &Pivot{left, agg, key, values, as}
+}
+
+func (this *Pivot) Accept(visitor NodeVisitor) (interface{}, error) {
+	return visitor.VisitPivot(this)
+}
+
+/*
+Maps the left term. The pivoted aggregates are mapped as part of
+the LETTING clause.
+*/
+func (this *Pivot) MapExpressions(mapper expression.Mapper) error {
+	return this.left.MapExpressions(mapper)
+}
+
+/*
+   Returns all contained Expressions.
+*/
+func (this *Pivot) Expressions() expression.Expressions {
+	return this.left.Expressions()
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *Pivot) Privileges() (*auth.Privileges, errors.Error) {
+	return this.left.Privileges()
+}
+
+/*
+   Representation as a N1QL string.
+*/
+func (this *Pivot) String() string {
+	s := this.left.String() + " pivot (" + this.agg.String() + " for " + this.key.String() + " in ("
+
+	for i, v := range this.values {
+		if i > 0 {
+			s += ", "
+		}
+
+		s += v.String()
+	}
+
+	return s + ")) as `" + this.as + "`"
+}
+
+/*
+Qualify all identifiers for the parent expression. The alias is
+bound by the LETTING clause, and must not duplicate that of a
+term.
+*/
+func (this *Pivot) Formalize(parent *expression.Formalizer) (f *expression.Formalizer, err error) {
+	f, err = this.left.Formalize(parent)
+	if err != nil {
+		return
+	}
+
+	if this.as == "" {
+		err = errors.NewNoTermNameError("PIVOT", "plan.pivot.requires_name_or_alias")
+		return nil, err
+	}
+
+	_, ok := f.Allowed().Field(this.as)
+	if ok {
+		err = errors.NewDuplicateAliasError("PIVOT", this.as, "plan.pivot.duplicate_alias")
+		return nil, err
+	}
+
+	return
+}
+
+/*
+Return the primary term of the left term.
+*/
+func (this *Pivot) PrimaryTerm() FromTerm {
+	return this.left.PrimaryTerm()
+}
+
+/*
+Returns the PIVOT alias.
+*/
+func (this *Pivot) Alias() string {
+	return this.as
+}
+
+/*
+Returns the left term, whose rows are pivoted.
+*/
+func (this *Pivot) Left() FromTerm {
+	return this.left
+}
+
+/*
+Set the left term, as when views are inlined.
+*/
+func (this *Pivot) SetLeft(left FromTerm) {
+	this.left = left
+}
+
+/*
+Implements JoinTerm interface. Returns nil for PIVOT.
+*/
+func (this *Pivot) Right() *KeyspaceTerm {
+	return nil
+}
+
+/*
+Implements JoinTerm interface. Returns false for PIVOT.
+*/
+func (this *Pivot) Outer() bool {
+	return false
+}
+
+/*
+Returns the pivoted aggregate.
+*/
+func (this *Pivot) Aggregate() Aggregate {
+	return this.agg
+}
+
+/*
+Returns the key compared with the values.
+*/
+func (this *Pivot) Key() expression.Expression {
+	return this.key
+}
+
+/*
+Returns the values of the key that are turned into fields.
+*/
+func (this *Pivot) Values() expression.Expressions {
+	return this.values
+}
+
+/*
+Returns the LETTING binding of the alias to an object of the
+aggregate of each value, filtered by the key.
+*/
+func (this *Pivot) Binding() *expression.Binding {
+	mapping := make(map[expression.Expression]expression.Expression, len(this.values))
+
+	for _, v := range this.values {
+		cond := expression.NewEq(this.key.Copy(), v.Copy())
+		agg := NewFilteredAggregate(this.agg.Copy().(Aggregate), cond)
+		mapping[expression.NewConstant(pivotName(v))] = agg
+	}
+
+	return expression.NewSimpleBinding(this.as, expression.NewObjectConstruct(mapping))
+}
+
+/*
+Returns the name of the field of a PIVOT value: the value itself
+if it is a string, and its JSON representation otherwise.
+*/
+func pivotName(v expression.Expression) string {
+	val := v.Value()
+	if val == nil {
+		return v.String()
+	}
+
+	if val.Type() == value.STRING {
+		return val.Actual().(string)
+	}
+
+	return val.String()
+}
+
+/*
+Marshals input pivot terms into byte array.
+*/
+func (this *Pivot) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "pivot"}
+	r["left"] = this.left
+	r["aggregate"] = expression.NewStringer().Visit(this.agg)
+	r["key"] = expression.NewStringer().Visit(this.key)
+
+	values := make([]string, len(this.values))
+	for i, v := range this.values {
+		values[i] = expression.NewStringer().Visit(v)
+	}
+
+	r["values"] = values
+	r["as"] = this.as
+	return json.Marshal(r)
+}
@@ -152,6 +152,13 @@ func (this *Unnest) Left() FromTerm {
 	return this.left
 }
 
+/*
+Set the left source object of the UNNEST.
+*/
+func (this *Unnest) SetLeft(left FromTerm) {
+	this.left = left
+}
+
 /*
 Implements JoinTerm interface. Returns nil for UNNEST.
 */
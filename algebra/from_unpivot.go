@@ -0,0 +1,243 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+)
+
+/*
+UNPIVOT (value FOR name IN (fields)) turns each row of the left
+term into a row for each of the fields, with value bound to the
+value of the field and name to its name. As in SQL, rows whose
+value is NULL or MISSING are left out. The fields are unnested as
+an array of their values, and the name is the one at the UNNEST
+position of the value.
+*/
+type Unpivot struct {
+	left   FromTerm
+	value  string
+	name   string
+	fields expression.Expressions
+	names  []string
+}
+
+func NewUnpivot(left FromTerm, value, name string, fields expression.Expressions) *Unpivot {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Alias()
+	}
+
+	return &Unpivot{left, value, name, fields, names}
+}
+
+func (this *Unpivot) Accept(visitor NodeVisitor) (interface{}, error) {
+	return visitor.VisitUnpivot(this)
+}
+
+/*
+Maps the fields if the left term is mapped successfully.
+*/
+func (this *Unpivot) MapExpressions(mapper expression.Mapper) (err error) {
+	err = this.left.MapExpressions(mapper)
+	if err != nil {
+		return
+	}
+
+	return this.fields.MapExpressions(mapper)
+}
+
+/*
+   Returns all contained Expressions.
+*/
+func (this *Unpivot) Expressions() expression.Expressions {
+	return append(this.left.Expressions(), this.fields...)
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *Unpivot) Privileges() (*auth.Privileges, errors.Error) {
+	privs, err := this.left.Privileges()
+	if err != nil {
+		return privs, err
+	}
+
+	for _, field := range this.fields {
+		privs.AddAll(field.Privileges())
+	}
+
+	return privs, nil
+}
+
+/*
+   Representation as a N1QL string.
+*/
+func (this *Unpivot) String() string {
+	s := this.left.String() + " unpivot (`" + this.value + "` for `" + this.name + "` in ("
+
+	for i, field := range this.fields {
+		if i > 0 {
+			s += ", "
+		}
+
+		s += field.String()
+	}
+
+	return s + "))"
+}
+
+/*
+Qualify all identifiers for the parent expression. Checks that
+the value and name are distinct and not duplicate aliases.
+*/
+func (this *Unpivot) Formalize(parent *expression.Formalizer) (f *expression.Formalizer, err error) {
+	f, err = this.left.Formalize(parent)
+	if err != nil {
+		return
+	}
+
+	for i, field := range this.fields {
+		this.fields[i], err = f.Map(field)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, alias := range []string{this.value, this.name} {
+		_, ok := f.Allowed().Field(alias)
+		if ok || this.value == this.name {
+			err = errors.NewDuplicateAliasError("UNPIVOT", alias, "plan.unpivot.duplicate_alias")
+			return nil, err
+		}
+	}
+
+	f.SetKeyspace("")
+	for _, alias := range []string{this.value, this.name} {
+		f.SetAllowedAlias(alias, true)
+		f.SetAlias(alias)
+	}
+
+	return
+}
+
+/*
+Return the primary term of the left term.
+*/
+func (this *Unpivot) PrimaryTerm() FromTerm {
+	return this.left.PrimaryTerm()
+}
+
+/*
+Returns the alias of the value.
+*/
+func (this *Unpivot) Alias() string {
+	return this.value
+}
+
+/*
+Returns the left term, whose rows are unpivoted.
+*/
+func (this *Unpivot) Left() FromTerm {
+	return this.left
+}
+
+/*
+Set the left term, as when views are inlined.
+*/
+func (this *Unpivot) SetLeft(left FromTerm) {
+	this.left = left
+}
+
+/*
+Implements JoinTerm interface. Returns nil for UNPIVOT.
+*/
+func (this *Unpivot) Right() *KeyspaceTerm {
+	return nil
+}
+
+/*
+Implements JoinTerm interface. Returns false for UNPIVOT.
+*/
+func (this *Unpivot) Outer() bool {
+	return false
+}
+
+/*
+Returns the alias of the value.
+*/
+func (this *Unpivot) ValueAlias() string {
+	return this.value
+}
+
+/*
+Returns the alias of the name.
+*/
+func (this *Unpivot) NameAlias() string {
+	return this.name
+}
+
+/*
+Returns the unpivoted fields.
+*/
+func (this *Unpivot) Fields() expression.Expressions {
+	return this.fields
+}
+
+/*
+Returns the UNNEST of the values of the fields.
+*/
+func (this *Unpivot) Unnest() *Unnest {
+	return NewUnnest(this.left, false, expression.NewArrayConstruct(this.fields...), this.value)
+}
+
+/*
+Returns the binding of the name to the name of the field at the
+UNNEST position of the value.
+*/
+func (this *Unpivot) NameBinding() *expression.Binding {
+	names := make(expression.Expressions, len(this.names))
+	for i, name := range this.names {
+		names[i] = expression.NewConstant(name)
+	}
+
+	position := expression.NewUnnestPosition(expression.NewIdentifier(this.value))
+	return expression.NewSimpleBinding(this.name,
+		expression.NewElement(expression.NewArrayConstruct(names...), position))
+}
+
+/*
+Returns the condition that leaves out NULL and MISSING values.
+*/
+func (this *Unpivot) Filter() expression.Expression {
+	return expression.NewIsNotNull(expression.NewIdentifier(this.value))
+}
+
+/*
+Marshals input unpivot terms into byte array.
+*/
+func (this *Unpivot) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "unpivot"}
+	r["left"] = this.left
+
+	fields := make([]string, len(this.fields))
+	for i, field := range this.fields {
+		fields[i] = expression.NewStringer().Visit(field)
+	}
+
+	r["value"] = this.value
+	r["name"] = this.name
+	r["fields"] = fields
+	return json.Marshal(r)
+}
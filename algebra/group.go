@@ -10,6 +10,8 @@
 package algebra
 
 import (
+	"math/bits"
+
 	"github.com/couchbase/query/expression"
 )
 
@@ -20,12 +22,18 @@ letting clause and the having clause represented by
 expression bindings and expressions respectively.
 Aliases in the LETTING clause create new names that
 may be referred to in the HAVING, SELECT, and ORDER
-BY clauses. Having specifies a condition.
+BY clauses. Having specifies a condition. With ROLLUP,
+CUBE or GROUPING SETS, by holds every grouping expression
+once, and sets holds the positions in by of the expressions
+of each grouping set. With PIVOT, the first LETTING binding
+is that of the PIVOT alias.
 */
 type Group struct {
 	by      expression.Expressions `json:by`
+	sets    [][]int                `json:"grouping_sets"`
 	letting expression.Bindings    `json:"letting"`
 	having  expression.Expression  `json:"having"`
+	pivoted bool                   `json:"pivoted"`
 }
 
 /*
@@ -41,6 +49,70 @@ func NewGroup(by expression.Expressions, letting expression.Bindings, having exp
 	}
 }
 
+/*
+The function NewGroupingSets returns a pointer to the Group
+struct that groups by each of the input grouping sets.
+*/
+func NewGroupingSets(sets GroupingSets, letting expression.Bindings, having expression.Expression) *Group {
+	by := make(expression.Expressions, 0, 8)
+	positions := make([][]int, len(sets))
+
+	for i, set := range sets {
+		positions[i] = make([]int, 0, len(set))
+
+	exprs:
+		for _, expr := range set {
+			pos := len(by)
+			for j, b := range by {
+				if b.EquivalentTo(expr) {
+					pos = j
+					break
+				}
+			}
+
+			if pos == len(by) {
+				by = append(by, expr)
+			}
+
+			for _, p := range positions[i] {
+				if p == pos {
+					continue exprs
+				}
+			}
+
+			positions[i] = append(positions[i], pos)
+		}
+	}
+
+	return &Group{
+		by:      by,
+		sets:    positions,
+		letting: letting,
+		having:  having,
+	}
+}
+
+/*
+The function newPivotGroup returns a copy of the group, or a
+group of all the rows if there is none, with the binding of
+a PIVOT alias ahead of its LETTING clause.
+*/
+func newPivotGroup(group *Group, binding *expression.Binding) *Group {
+	rv := &Group{
+		letting: expression.Bindings{binding},
+		pivoted: true,
+	}
+
+	if group != nil {
+		rv.by = group.by
+		rv.sets = group.sets
+		rv.letting = append(rv.letting, group.letting...)
+		rv.having = group.having
+	}
+
+	return rv
+}
+
 /*
 This method qualifies identifiers for all the constituent clauses,
 namely the by, letting and having expressions by mapping them.
@@ -100,6 +172,25 @@ func (this *Group) MapExpressions(mapper expression.Mapper) (err error) {
 	return
 }
 
+/*
+This method maps the letting and having expressions, which
+are evaluated after grouping.
+*/
+func (this *Group) MapGroupedExpressions(mapper expression.Mapper) (err error) {
+	if this.letting != nil {
+		err = this.letting.MapExpressions(mapper)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.having != nil {
+		this.having, err = mapper.Map(this.having)
+	}
+
+	return
+}
+
 /*
    Returns all contained Expressions.
 */
@@ -127,7 +218,27 @@ func (this *Group) Expressions() expression.Expressions {
 func (this *Group) String() string {
 	s := ""
 
-	if this.by != nil {
+	if this.sets != nil {
+		s += " group by grouping sets ("
+
+		for i, set := range this.sets {
+			if i > 0 {
+				s += ", "
+			}
+
+			s += "("
+			for j, pos := range set {
+				if j > 0 {
+					s += ", "
+				}
+
+				s += this.by[pos].String()
+			}
+			s += ")"
+		}
+
+		s += ")"
+	} else if this.by != nil {
 		s += " group by "
 
 		for i, b := range this.by {
@@ -139,8 +250,13 @@ func (this *Group) String() string {
 		}
 	}
 
-	if this.letting != nil {
-		s += " letting " + stringBindings(this.letting)
+	letting := this.letting
+	if this.pivoted {
+		letting = letting[1:]
+	}
+
+	if len(letting) > 0 {
+		s += " letting " + stringBindings(letting)
 	}
 
 	if this.having != nil {
@@ -157,6 +273,14 @@ func (this *Group) By() expression.Expressions {
 	return this.by
 }
 
+/*
+Returns the grouping sets as positions in the Group by
+expressions, or nil if there is a single grouping set.
+*/
+func (this *Group) Sets() [][]int {
+	return this.sets
+}
+
 /*
 Returns the letting expression bindings.
 */
@@ -170,3 +294,69 @@ Returns the having condition expression.
 func (this *Group) Having() expression.Expression {
 	return this.having
 }
+
+/*
+Grouping sets, each a list of grouping expressions, as given by
+ROLLUP, CUBE and GROUPING SETS in the GROUP BY clause. A plain
+grouping expression is the single grouping set of itself.
+*/
+type GroupingSets []expression.Expressions
+
+/*
+ROLLUP(a, b, c) is the grouping sets (a, b, c), (a, b), (a), ().
+*/
+func NewRollup(exprs expression.Expressions) GroupingSets {
+	sets := make(GroupingSets, 0, len(exprs)+1)
+	for i := len(exprs); i >= 0; i-- {
+		sets = append(sets, exprs[0:i])
+	}
+
+	return sets
+}
+
+/*
+CUBE(a, b) is the grouping sets of all the subsets of the
+expressions, from the largest to the smallest: (a, b), (a),
+(b), ().
+*/
+func NewCube(exprs expression.Expressions) GroupingSets {
+	n := uint(len(exprs))
+	sets := make(GroupingSets, 0, 1<<n)
+	for size := int(n); size >= 0; size-- {
+		for mask := uint(1<<n) - 1; ; mask-- {
+			if bits.OnesCount(mask) == size {
+				set := make(expression.Expressions, 0, size)
+				for i := uint(0); i < n; i++ {
+					if mask&(1<<(n-1-i)) != 0 {
+						set = append(set, exprs[i])
+					}
+				}
+				sets = append(sets, set)
+			}
+
+			if mask == 0 {
+				break
+			}
+		}
+	}
+
+	return sets
+}
+
+/*
+Returns the grouping sets that concatenate each of the receiver
+sets with each of the other sets, as for GROUP BY a, ROLLUP(b, c).
+*/
+func (this GroupingSets) Product(other GroupingSets) GroupingSets {
+	rv := make(GroupingSets, 0, len(this)*len(other))
+	for _, set := range this {
+		for _, oset := range other {
+			s := make(expression.Expressions, 0, len(set)+len(oset))
+			s = append(s, set...)
+			s = append(s, oset...)
+			rv = append(rv, s)
+		}
+	}
+
+	return rv
+}
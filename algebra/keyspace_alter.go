@@ -0,0 +1,134 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the ALTER KEYSPACE ddl statement, which sets the JSON
+schema that documents written to the keyspace must match, along with
+the options of its WITH clause, or unsets it if the schema is nil.
+*/
+type AlterKeyspace struct {
+	statementBase
+
+	keyspace   *KeyspaceRef
+	validation value.Value
+	options    value.Value
+}
+
+/*
+The function NewAlterKeyspace returns a pointer to the
+AlterKeyspace struct with the input argument values as fields.
+*/
+func NewAlterKeyspace(keyspace *KeyspaceRef, validation, options value.Value) *AlterKeyspace {
+	rv := &AlterKeyspace{
+		keyspace:   keyspace,
+		validation: validation,
+		options:    options,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitAlterKeyspace method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *AlterKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAlterKeyspace(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *AlterKeyspace) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *AlterKeyspace) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *AlterKeyspace) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *AlterKeyspace) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges. Changing the documents that may be
+written to a keyspace requires the right to write them.
+*/
+func (this *AlterKeyspace) Privileges() (*auth.Privileges, errors.Error) {
+	privs := auth.NewPrivileges()
+	privs.Add(this.keyspace.FullName(), auth.PRIV_WRITE)
+	return privs, nil
+}
+
+/*
+Return the keyspace.
+*/
+func (this *AlterKeyspace) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Return the validation schema, or nil to unset it.
+*/
+func (this *AlterKeyspace) Validation() value.Value {
+	return this.validation
+}
+
+/*
+Return the options of the validation.
+*/
+func (this *AlterKeyspace) Options() value.Value {
+	return this.options
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *AlterKeyspace) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "alterKeyspace"}
+	r["keyspaceRef"] = this.keyspace
+	if this.validation != nil {
+		r["validation"] = this.validation
+	}
+	if this.options != nil {
+		r["options"] = this.options
+	}
+	return json.Marshal(r)
+}
+
+func (this *AlterKeyspace) Type() string {
+	return "ALTER_KEYSPACE"
+}
@@ -24,21 +24,25 @@ struct that contains fields mapping to each clause in
 the statement.  Keyspace is the keyspace-ref for
 the merge stmt. Merge source represents the path or a
 select statement with an alias, the key expression
-represents the keys clause. Merge actions can have
-three possible statements, the merge update, merge
-delete or the merge insert statement. Limit represents
+represents the ON KEY clause, and the on expression an
+ANSI ON clause that is used instead of it. Merge actions
+can have the merge update, merge delete or merge insert
+statements, and for an ANSI ON clause the update or
+delete of unmatched target documents. Limit represents
 the limit clause and Returning represents the returning
 clause.
 */
 type Merge struct {
 	statementBase
 
-	keyspace  *KeyspaceRef          `json:"keyspace"`
-	source    *MergeSource          `json:"source"`
-	key       expression.Expression `json:"key"`
-	actions   *MergeActions         `json:"actions"`
-	limit     expression.Expression `json:"limit"`
-	returning *Projection           `json:"returning"`
+	keyspace   *KeyspaceRef          `json:"keyspace"`
+	source     *MergeSource          `json:"source"`
+	key        expression.Expression `json:"key"`
+	on         expression.Expression `json:"on"`
+	actions    *MergeActions         `json:"actions"`
+	limit      expression.Expression `json:"limit"`
+	returning  *Projection           `json:"returning"`
+	optimHints *OptimHints
 }
 
 /*
@@ -46,12 +50,13 @@ The function NewMerge returns a pointer to the Merge
 struct by assigning the input attributes to the fields
 of the struct.
 */
-func NewMerge(keyspace *KeyspaceRef, source *MergeSource, key expression.Expression,
+func NewMerge(keyspace *KeyspaceRef, source *MergeSource, key, on expression.Expression,
 	actions *MergeActions, limit expression.Expression, returning *Projection) *Merge {
 	rv := &Merge{
 		keyspace:  keyspace,
 		source:    source,
 		key:       key,
+		on:        on,
 		actions:   actions,
 		limit:     limit,
 		returning: returning,
@@ -90,9 +95,18 @@ func (this *Merge) MapExpressions(mapper expression.Mapper) (err error) {
 		return
 	}
 
-	this.key, err = mapper.Map(this.key)
-	if err != nil {
-		return
+	if this.key != nil {
+		this.key, err = mapper.Map(this.key)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.on != nil {
+		this.on, err = mapper.Map(this.on)
+		if err != nil {
+			return
+		}
 	}
 
 	err = this.actions.MapExpressions(mapper)
@@ -121,7 +135,14 @@ func (this *Merge) Expressions() expression.Expressions {
 	exprs := make(expression.Expressions, 0, 64)
 
 	exprs = append(exprs, this.source.Expressions()...)
-	exprs = append(exprs, this.key)
+	if this.key != nil {
+		exprs = append(exprs, this.key)
+	}
+
+	if this.on != nil {
+		exprs = append(exprs, this.on)
+	}
+
 	exprs = append(exprs, this.actions.Expressions()...)
 
 	if this.limit != nil {
@@ -141,7 +162,7 @@ Returns all required privileges.
 func (this *Merge) Privileges() (*auth.Privileges, errors.Error) {
 	privs := auth.NewPrivileges()
 	fullKeyspace := this.keyspace.FullName()
-	if this.returning != nil {
+	if this.returning != nil || this.on != nil {
 		privs.Add(fullKeyspace, auth.PRIV_QUERY_SELECT)
 	}
 
@@ -184,9 +205,11 @@ func (this *Merge) Formalize() (err error) {
 		return err
 	}
 
-	this.key, err = sf.Map(this.key)
-	if err != nil {
-		return err
+	if this.key != nil {
+		this.key, err = sf.Map(this.key)
+		if err != nil {
+			return err
+		}
 	}
 
 	if kf.Keyspace() != "" &&
@@ -204,6 +227,13 @@ func (this *Merge) Formalize() (err error) {
 		f.SetAllowedAlias(sf.Keyspace(), true)
 	}
 
+	if this.on != nil {
+		this.on, err = f.Map(this.on)
+		if err != nil {
+			return
+		}
+	}
+
 	err = this.actions.MapExpressions(f)
 	if err != nil {
 		return
@@ -245,6 +275,14 @@ func (this *Merge) Key() expression.Expression {
 	return this.key
 }
 
+/*
+Returns the ANSI ON clause expression of the merge
+statement, or nil if it uses ON KEY.
+*/
+func (this *Merge) On() expression.Expression {
+	return this.on
+}
+
 /*
 Returns the merge actions for the merge statement.
 */
@@ -268,6 +306,20 @@ func (this *Merge) Returning() *Projection {
 	return this.returning
 }
 
+/*
+Returns the optimizer hints of the MERGE statement, or nil.
+*/
+func (this *Merge) OptimHints() *OptimHints {
+	return this.optimHints
+}
+
+/*
+Sets the optimizer hints of the MERGE statement.
+*/
+func (this *Merge) SetOptimHints(optimHints *OptimHints) {
+	this.optimHints = optimHints
+}
+
 func (this *Merge) Type() string {
 	return "MERGE"
 }
@@ -456,12 +508,16 @@ func (this *MergeSource) Alias() string {
 
 /*
 Represents the merge actions in a merge statement. They
-can be merge update, merge delete and merge insert.
+can be merge update, merge delete and merge insert, and
+the merge update and merge delete of target documents
+that are not matched by any source object.
 */
 type MergeActions struct {
-	update *MergeUpdate `json:"update"`
-	delete *MergeDelete `json:"delete"`
-	insert *MergeInsert `json:"insert"`
+	update       *MergeUpdate `json:"update"`
+	delete       *MergeDelete `json:"delete"`
+	insert       *MergeInsert `json:"insert"`
+	sourceUpdate *MergeUpdate `json:"source_update"`
+	sourceDelete *MergeDelete `json:"source_delete"`
 }
 
 /*
@@ -469,11 +525,14 @@ The function NewMergeActions returns a pointer to the
 MergeActions struct by assigning the input attributes
 to the fields of the struct.
 */
-func NewMergeActions(update *MergeUpdate, delete *MergeDelete, insert *MergeInsert) *MergeActions {
+func NewMergeActions(update *MergeUpdate, delete *MergeDelete, insert *MergeInsert,
+	sourceUpdate *MergeUpdate, sourceDelete *MergeDelete) *MergeActions {
 	return &MergeActions{
-		update: update,
-		delete: delete,
-		insert: insert,
+		update:       update,
+		delete:       delete,
+		insert:       insert,
+		sourceUpdate: sourceUpdate,
+		sourceDelete: sourceDelete,
 	}
 }
 
@@ -495,6 +554,14 @@ func (this *MergeActions) Expressions() expression.Expressions {
 		exprs = append(exprs, this.insert.Expressions()...)
 	}
 
+	if this.sourceUpdate != nil {
+		exprs = append(exprs, this.sourceUpdate.Expressions()...)
+	}
+
+	if this.sourceDelete != nil {
+		exprs = append(exprs, this.sourceDelete.Expressions()...)
+	}
+
 	return exprs
 }
 
@@ -504,11 +571,11 @@ and add them to 'privs' (which will not be nil).
 The keyspace being acted on is passed down as 'keyspace'.
 */
 func (this *MergeActions) AddPrivilegesFor(privs *auth.Privileges, keyspace string) {
-	if this.update != nil {
+	if this.update != nil || this.sourceUpdate != nil {
 		privs.Add(keyspace, auth.PRIV_QUERY_UPDATE)
 	}
 
-	if this.delete != nil {
+	if this.delete != nil || this.sourceDelete != nil {
 		privs.Add(keyspace, auth.PRIV_QUERY_DELETE)
 	}
 
@@ -538,6 +605,20 @@ func (this *MergeActions) MapExpressions(mapper expression.Mapper) (err error) {
 
 	if this.insert != nil {
 		err = this.insert.MapExpressions(mapper)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.sourceUpdate != nil {
+		err = this.sourceUpdate.MapExpressions(mapper)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.sourceDelete != nil {
+		err = this.sourceDelete.MapExpressions(mapper)
 	}
 
 	return
@@ -564,16 +645,33 @@ func (this *MergeActions) Insert() *MergeInsert {
 	return this.insert
 }
 
+/*
+Returns the merge update merge action statement for
+target documents not matched by the source.
+*/
+func (this *MergeActions) SourceUpdate() *MergeUpdate {
+	return this.sourceUpdate
+}
+
+/*
+Returns the merge delete merge action statement for
+target documents not matched by the source.
+*/
+func (this *MergeActions) SourceDelete() *MergeDelete {
+	return this.sourceDelete
+}
+
 /*
 Represents the merge update merge-actions statement.
 Type MergeUpdate is a struct that contains the where
 condition expression along with the set and unset
-clause.
+clause, and the AND condition of its WHEN clause.
 */
 type MergeUpdate struct {
-	set   *Set                  `json:"set"`
-	unset *Unset                `json:"unset"`
-	where expression.Expression `json:"where"`
+	set       *Set                  `json:"set"`
+	unset     *Unset                `json:"unset"`
+	where     expression.Expression `json:"where"`
+	condition expression.Expression `json:"condition"`
 }
 
 /*
@@ -582,7 +680,7 @@ MergeUpdate struct by assigning the input attributes
 to the fields of the struct.
 */
 func NewMergeUpdate(set *Set, unset *Unset, where expression.Expression) *MergeUpdate {
-	return &MergeUpdate{set, unset, where}
+	return &MergeUpdate{set, unset, where, nil}
 }
 
 /*
@@ -605,6 +703,13 @@ func (this *MergeUpdate) MapExpressions(mapper expression.Mapper) (err error) {
 
 	if this.where != nil {
 		this.where, err = mapper.Map(this.where)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.condition != nil {
+		this.condition, err = mapper.Map(this.condition)
 	}
 
 	return
@@ -628,6 +733,10 @@ func (this *MergeUpdate) Expressions() expression.Expressions {
 		exprs = append(exprs, this.where)
 	}
 
+	if this.condition != nil {
+		exprs = append(exprs, this.condition)
+	}
+
 	return exprs
 }
 
@@ -654,13 +763,29 @@ func (this *MergeUpdate) Where() expression.Expression {
 	return this.where
 }
 
+/*
+Return the AND condition of the WHEN clause.
+*/
+func (this *MergeUpdate) Condition() expression.Expression {
+	return this.condition
+}
+
+/*
+Set the AND condition of the WHEN clause.
+*/
+func (this *MergeUpdate) SetCondition(condition expression.Expression) {
+	this.condition = condition
+}
+
 /*
 Represents the merge delete merge actions statement.
 Type MergeDelete is a struct that contains the where
-condition expression.
+condition expression and the AND condition of its
+WHEN clause.
 */
 type MergeDelete struct {
-	where expression.Expression `json:"where"`
+	where     expression.Expression `json:"where"`
+	condition expression.Expression `json:"condition"`
 }
 
 /*
@@ -669,7 +794,7 @@ MergeDelete struct by assigning the input attributes
 to the fields of the struct.
 */
 func NewMergeDelete(where expression.Expression) *MergeDelete {
-	return &MergeDelete{where}
+	return &MergeDelete{where, nil}
 }
 
 /*
@@ -678,6 +803,13 @@ Apply mapper to where condition expressions.
 func (this *MergeDelete) MapExpressions(mapper expression.Mapper) (err error) {
 	if this.where != nil {
 		this.where, err = mapper.Map(this.where)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.condition != nil {
+		this.condition, err = mapper.Map(this.condition)
 	}
 
 	return
@@ -687,12 +819,16 @@ func (this *MergeDelete) MapExpressions(mapper expression.Mapper) (err error) {
 Returns all contained Expressions.
 */
 func (this *MergeDelete) Expressions() expression.Expressions {
-	exprs := make(expression.Expressions, 0, 1)
+	exprs := make(expression.Expressions, 0, 2)
 
 	if this.where != nil {
 		exprs = append(exprs, this.where)
 	}
 
+	if this.condition != nil {
+		exprs = append(exprs, this.condition)
+	}
+
 	return exprs
 }
 
@@ -703,14 +839,32 @@ func (this *MergeDelete) Where() expression.Expression {
 	return this.where
 }
 
+/*
+Return the AND condition of the WHEN clause.
+*/
+func (this *MergeDelete) Condition() expression.Expression {
+	return this.condition
+}
+
+/*
+Set the AND condition of the WHEN clause.
+*/
+func (this *MergeDelete) SetCondition(condition expression.Expression) {
+	this.condition = condition
+}
+
 /*
 Represents the merge insert merge actions statement.
-Type MergeInsert is a struct that contains the value
-and where condition expressions.
+Type MergeInsert is a struct that contains the key,
+value and where condition expressions, and the AND
+condition of its WHEN clause. The key is only given
+with an ANSI ON clause.
 */
 type MergeInsert struct {
-	value expression.Expression `json:"value"`
-	where expression.Expression `json:"where"`
+	key       expression.Expression `json:"key"`
+	value     expression.Expression `json:"value"`
+	where     expression.Expression `json:"where"`
+	condition expression.Expression `json:"condition"`
 }
 
 /*
@@ -718,14 +872,21 @@ The function NewMergeInsert returns a pointer to the MergeInsert
 struct by assigning the input attributes to the fields of the
 struct.
 */
-func NewMergeInsert(value, where expression.Expression) *MergeInsert {
-	return &MergeInsert{value, where}
+func NewMergeInsert(key, value, where expression.Expression) *MergeInsert {
+	return &MergeInsert{key, value, where, nil}
 }
 
 /*
-Apply mapper to value and where expressions.
+Apply mapper to key, value and where expressions.
 */
 func (this *MergeInsert) MapExpressions(mapper expression.Mapper) (err error) {
+	if this.key != nil {
+		this.key, err = mapper.Map(this.key)
+		if err != nil {
+			return
+		}
+	}
+
 	if this.value != nil {
 		this.value, err = mapper.Map(this.value)
 		if err != nil {
@@ -735,6 +896,13 @@ func (this *MergeInsert) MapExpressions(mapper expression.Mapper) (err error) {
 
 	if this.where != nil {
 		this.where, err = mapper.Map(this.where)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.condition != nil {
+		this.condition, err = mapper.Map(this.condition)
 	}
 
 	return
@@ -744,7 +912,11 @@ func (this *MergeInsert) MapExpressions(mapper expression.Mapper) (err error) {
 Returns all contained Expressions.
 */
 func (this *MergeInsert) Expressions() expression.Expressions {
-	exprs := make(expression.Expressions, 0, 2)
+	exprs := make(expression.Expressions, 0, 4)
+
+	if this.key != nil {
+		exprs = append(exprs, this.key)
+	}
 
 	if this.value != nil {
 		exprs = append(exprs, this.value)
@@ -754,9 +926,20 @@ func (this *MergeInsert) Expressions() expression.Expressions {
 		exprs = append(exprs, this.where)
 	}
 
+	if this.condition != nil {
+		exprs = append(exprs, this.condition)
+	}
+
 	return exprs
 }
 
+/*
+Return the merge insert key expression.
+*/
+func (this *MergeInsert) Key() expression.Expression {
+	return this.key
+}
+
 /*
 Return the merge insert value expression.
 */
@@ -770,3 +953,17 @@ Return the where clause exppression condition.
 func (this *MergeInsert) Where() expression.Expression {
 	return this.where
 }
+
+/*
+Return the AND condition of the WHEN clause.
+*/
+func (this *MergeInsert) Condition() expression.Expression {
+	return this.condition
+}
+
+/*
+Set the AND condition of the WHEN clause.
+*/
+func (this *MergeInsert) SetCondition(condition expression.Expression) {
+	this.condition = condition
+}
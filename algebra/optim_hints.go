@@ -0,0 +1,256 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Names of the optimizer hints.
+*/
+const (
+	HINT_INDEX     = "INDEX"     // INDEX(alias index ...): use one of the indexes
+	HINT_NO_INDEX  = "NO_INDEX"  // NO_INDEX(alias [index ...]): avoid the indexes, or all secondary indexes
+	HINT_INDEX_ALL = "INDEX_ALL" // INDEX_ALL(alias index index ...): intersect scan of all the indexes
+	HINT_USE_NL    = "USE_NL"    // USE_NL(alias ...): nested-loop join
+	HINT_USE_HASH  = "USE_HASH"  // USE_HASH(alias ...): hash join
+	HINT_ORDERED   = "ORDERED"   // ORDERED: join in the order of the FROM clause
+	HINT_NO_COVER  = "NO_COVER"  // NO_COVER(alias ...): do not use covering index scans
+)
+
+/*
+Hints that exclude each other for the same keyspace.
+*/
+var _HINT_GROUPS = map[string]string{
+	HINT_INDEX:     "index",
+	HINT_NO_INDEX:  "index",
+	HINT_INDEX_ALL: "index",
+	HINT_USE_NL:    "join",
+	HINT_USE_HASH:  "join",
+	HINT_ORDERED:   "ordered",
+	HINT_NO_COVER:  "cover",
+}
+
+/*
+Represents the optimizer hints of a query block, given in a
+comment starting with /*+ after SELECT, UPDATE, DELETE or MERGE. Hints
+are advisory: invalid hints, and hints the planner cannot follow,
+do not cause errors, but are reported by EXPLAIN.
+*/
+type OptimHints struct {
+	text  string
+	hints []*OptimHint
+}
+
+/*
+Parses the text of the hint comment, including its delimiters.
+*/
+func NewOptimHints(text string) *OptimHints {
+	rv := &OptimHints{
+		text: text,
+	}
+
+	s := strings.TrimPrefix(text, "/*+")
+	s = strings.TrimSuffix(s, "*/")
+	rv.hints = parseOptimHints(s)
+
+	seen := make(map[string]*OptimHint, len(rv.hints))
+	for _, hint := range rv.hints {
+		if hint.invalid != "" {
+			continue
+		}
+
+		group := _HINT_GROUPS[hint.name] + ":" + hint.keyspace
+		if other, ok := seen[group]; ok {
+			hint.invalid = fmt.Sprintf("Conflicts with the hint %s.", other)
+		} else {
+			seen[group] = hint
+		}
+	}
+
+	return rv
+}
+
+/*
+Returns all the hints, including the invalid ones.
+*/
+func (this *OptimHints) Hints() []*OptimHint {
+	return this.hints
+}
+
+/*
+Returns the valid hint with the name for the keyspace alias, if
+any. Hints without a keyspace, such as ORDERED, are looked up with
+an empty alias.
+*/
+func (this *OptimHints) Hint(name, keyspace string) *OptimHint {
+	if this == nil {
+		return nil
+	}
+
+	for _, hint := range this.hints {
+		if hint.invalid == "" && hint.name == name && hint.keyspace == keyspace {
+			return hint
+		}
+	}
+
+	return nil
+}
+
+/*
+Returns the hint comment.
+*/
+func (this *OptimHints) String() string {
+	return this.text
+}
+
+/*
+Represents a single optimizer hint. Hints listing several
+keyspaces, such as USE_NL(a b), are split into one hint per
+keyspace.
+*/
+type OptimHint struct {
+	name     string
+	keyspace string
+	indexes  []string
+	text     string
+	invalid  string
+}
+
+func (this *OptimHint) Name() string {
+	return this.name
+}
+
+/*
+Returns the keyspace alias the hint applies to, or the empty
+string.
+*/
+func (this *OptimHint) Keyspace() string {
+	return this.keyspace
+}
+
+func (this *OptimHint) Indexes() []string {
+	return this.indexes
+}
+
+/*
+Returns the reason the hint is invalid, or the empty string.
+*/
+func (this *OptimHint) Invalid() string {
+	return this.invalid
+}
+
+func (this *OptimHint) String() string {
+	if this.text != "" {
+		return this.text
+	}
+
+	if this.keyspace == "" {
+		return this.name
+	}
+
+	return this.name + "(" + strings.Join(append([]string{this.keyspace}, this.indexes...), " ") + ")"
+}
+
+/*
+Parses the hints, each a name optionally followed by a list of
+arguments in parentheses, separated by spaces or commas.
+*/
+func parseOptimHints(s string) []*OptimHint {
+	var hints []*OptimHint
+
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,")
+		if s == "" {
+			return hints
+		}
+
+		n := strings.IndexFunc(s, func(r rune) bool { return !isHintChar(r) })
+		if n < 0 {
+			n = len(s)
+		}
+
+		if n == 0 {
+			return append(hints, &OptimHint{text: s, invalid: fmt.Sprintf("Unexpected %c.", s[0])})
+		}
+
+		name := s[:n]
+		s = strings.TrimLeft(s[n:], " \t\r\n")
+
+		var args []string
+		text := name
+		if strings.HasPrefix(s, "(") {
+			end := strings.IndexByte(s, ')')
+			if end < 0 {
+				return append(hints, &OptimHint{text: name + s, invalid: "Missing ) after the arguments."})
+			}
+
+			text = name + s[:end+1]
+			args = strings.FieldsFunc(s[1:end], func(r rune) bool {
+				return r == ' ' || r == ',' || r == '\t' || r == '\r' || r == '\n'
+			})
+			for i, arg := range args {
+				args[i] = strings.Trim(arg, "`")
+			}
+			s = s[end+1:]
+		}
+
+		hints = append(hints, newOptimHints(strings.ToUpper(name), args, text)...)
+	}
+}
+
+func isHintChar(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func newOptimHints(name string, args []string, text string) []*OptimHint {
+	invalid := func(reason string) []*OptimHint {
+		return []*OptimHint{&OptimHint{name: name, text: text, invalid: reason}}
+	}
+
+	switch name {
+	case HINT_INDEX, HINT_NO_INDEX, HINT_INDEX_ALL:
+		min := 1
+		switch name {
+		case HINT_INDEX:
+			min = 2
+		case HINT_INDEX_ALL:
+			min = 3
+		}
+
+		if len(args) == 0 {
+			return invalid(fmt.Sprintf("%s requires a keyspace alias.", name))
+		} else if len(args) < min {
+			return invalid(fmt.Sprintf("%s requires at least %d index name(s) after the keyspace alias.", name, min-1))
+		}
+
+		return []*OptimHint{&OptimHint{name: name, keyspace: args[0], indexes: args[1:]}}
+	case HINT_USE_NL, HINT_USE_HASH, HINT_NO_COVER:
+		if len(args) == 0 {
+			return invalid(fmt.Sprintf("%s requires a keyspace alias.", name))
+		}
+
+		hints := make([]*OptimHint, len(args))
+		for i, arg := range args {
+			hints[i] = &OptimHint{name: name, keyspace: arg}
+		}
+		return hints
+	case HINT_ORDERED:
+		if len(args) > 0 {
+			return invalid(fmt.Sprintf("%s does not take arguments.", name))
+		}
+
+		return []*OptimHint{&OptimHint{name: name}}
+	default:
+		return invalid(fmt.Sprintf("Unknown hint %s.", name))
+	}
+}
@@ -10,7 +10,10 @@
 package algebra
 
 import (
+	"strconv"
+
 	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
 )
 
 /*
@@ -69,14 +72,31 @@ type SortTerms []*SortTerm
 
 /*
 Represents the ordering term in an order by clause. Type
-SortTerm is a struct containing the expression and a bool
-value that decides the sort order (ASC or DESC).
+SortTerm is a struct containing the expression, a bool
+value that decides the sort order (ASC or DESC), the
+position of NULL and MISSING values (NULLS FIRST or NULLS
+LAST), and the collation of strings (COLLATE).
 */
 type SortTerm struct {
 	expr       expression.Expression `json:"expr"`
 	descending bool                  `json:"desc"`
+	nulls      NullsPos              `json:"nulls"`
+	collation  value.Collation       `json:"collation"`
 }
 
+/*
+The position of NULL and MISSING values in the sort order. By
+default, they sort first in ascending order and last in
+descending order.
+*/
+type NullsPos int
+
+const (
+	NULLS_DEFAULT NullsPos = iota
+	NULLS_FIRST
+	NULLS_LAST
+)
+
 /*
 The function NewSortTerm returns a pointer to the SortTerm
 struct that has its fields set to the input arguments.
@@ -88,16 +108,50 @@ func NewSortTerm(expr expression.Expression, descending bool) *SortTerm {
 	}
 }
 
+/*
+The function NewCollatedSortTerm returns a pointer to the
+SortTerm struct with the given position of NULL and MISSING
+values and collation. A nil collation is the binary collation.
+*/
+func NewCollatedSortTerm(expr expression.Expression, descending bool,
+	nulls NullsPos, collation value.Collation) *SortTerm {
+	return &SortTerm{
+		expr:       expr,
+		descending: descending,
+		nulls:      nulls,
+		collation:  collation,
+	}
+}
+
 /*
    Representation as a N1QL string.
 */
 func (this *SortTerm) String() string {
-	s := this.expr.String()
+	return this.expr.String() + this.modifiers()
+}
+
+/*
+Representation of the collation, direction and position of
+NULL and MISSING values.
+*/
+func (this *SortTerm) modifiers() string {
+	s := ""
+
+	if this.collation != nil {
+		s += " collate " + strconv.Quote(this.collation.Name())
+	}
 
 	if this.descending {
 		s += " desc"
 	}
 
+	switch this.nulls {
+	case NULLS_FIRST:
+		s += " nulls first"
+	case NULLS_LAST:
+		s += " nulls last"
+	}
+
 	return s
 }
 
@@ -116,6 +170,76 @@ func (this *SortTerm) Descending() bool {
 	return this.descending
 }
 
+/*
+Return the position of NULL and MISSING values as given
+by NULLS FIRST or NULLS LAST.
+*/
+func (this *SortTerm) Nulls() NullsPos {
+	return this.nulls
+}
+
+/*
+Return true if NULL and MISSING values sort first, either
+by NULLS FIRST or by default in ascending order.
+*/
+func (this *SortTerm) NullsFirst() bool {
+	switch this.nulls {
+	case NULLS_FIRST:
+		return true
+	case NULLS_LAST:
+		return false
+	default:
+		return !this.descending
+	}
+}
+
+/*
+Return the collation, or nil for the binary collation.
+*/
+func (this *SortTerm) Collation() value.Collation {
+	return this.collation
+}
+
+/*
+Compares the values of the sort term. The result is negative
+if v1 sorts before v2, zero if they are equal, and positive if
+v1 sorts after v2.
+*/
+func (this *SortTerm) Compare(v1, v2 value.Value) int {
+	if this.nulls != NULLS_DEFAULT {
+		n1 := v1.Type() <= value.NULL
+		n2 := v2.Type() <= value.NULL
+		if n1 != n2 {
+			if n1 == this.NullsFirst() {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	var c int
+	if this.collation != nil {
+		c = this.collation.Collate(v1, v2)
+	} else {
+		c = v1.Collate(v2)
+	}
+
+	if this.descending {
+		return -c
+	}
+
+	return c
+}
+
+/*
+Return a sort term for the given expression with the same
+direction, position of NULL and MISSING values, and collation.
+*/
+func (this *SortTerm) WithExpression(expr expression.Expression) *SortTerm {
+	return NewCollatedSortTerm(expr, this.descending, this.nulls, this.collation)
+}
+
 /*
 Map Expressions for all sort terms in the receiver.
 */
@@ -13,6 +13,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/value"
@@ -115,6 +116,12 @@ func (this *Projection) Expressions() expression.Expressions {
 		if term.expr != nil {
 			exprs = append(exprs, term.expr)
 		}
+
+		// Excluded fields are paths, not expressions. Documents are
+		// still fetched whole, so EXCLUDE does not make a scan covering.
+		if term.modifiers != nil {
+			exprs = append(exprs, term.modifiers.replace.Expressions()...)
+		}
 	}
 
 	return exprs
@@ -254,13 +261,15 @@ and alias represent the result expression alias. The
 alias string is the path (a.b, alias = b) if no AS clause
 is present, and if an alias is defined using the AS
 clause in the result expr both alias and as are the
-defined alias.
+defined alias. The modifiers are the EXCLUDE and REPLACE
+clauses of a star term, if any.
 */
 type ResultTerm struct {
-	expr  expression.Expression `json:"expr"`
-	star  bool                  `json:"star"`
-	as    string                `json:"as"`
-	alias string                `json:"_"`
+	expr      expression.Expression `json:"expr"`
+	star      bool                  `json:"star"`
+	as        string                `json:"as"`
+	alias     string                `json:"_"`
+	modifiers *StarModifiers
 }
 
 /*
@@ -282,11 +291,30 @@ func NewResultTerm(expr expression.Expression, star bool, as string) *ResultTerm
 }
 
 /*
-Map the input expression of the result expr.
+The function NewStarResultTerm returns a pointer to the
+ResultTerm struct for a star term with the given EXCLUDE
+and REPLACE modifiers, which may be nil.
+*/
+func NewStarResultTerm(expr expression.Expression, modifiers *StarModifiers) *ResultTerm {
+	rv := NewResultTerm(expr, true, "")
+	rv.modifiers = modifiers
+	return rv
+}
+
+/*
+Map the input expression of the result expr, and the
+expressions of its REPLACE clause.
 */
 func (this *ResultTerm) MapExpression(mapper expression.Mapper) (err error) {
 	if this.expr != nil {
 		this.expr, err = mapper.Map(this.expr)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.modifiers != nil {
+		err = this.modifiers.replace.MapExpressions(mapper)
 	}
 
 	return
@@ -308,6 +336,10 @@ func (this *ResultTerm) String() string {
 		} else {
 			s += ".*"
 		}
+
+		if this.modifiers != nil {
+			s += this.modifiers.String()
+		}
 	}
 
 	if this.as != "" {
@@ -332,6 +364,14 @@ func (this *ResultTerm) Star() bool {
 	return this.star
 }
 
+/*
+Return the EXCLUDE and REPLACE modifiers of a star
+term, or nil.
+*/
+func (this *ResultTerm) Modifiers() *StarModifiers {
+	return this.modifiers
+}
+
 /*
 Return the alias string defined by AS if present.
 */
@@ -386,5 +426,195 @@ func (this *ResultTerm) MarshalJSON() ([]byte, error) {
 		r["expr"] = expression.NewStringer().Visit(this.expr)
 	}
 	r["star"] = this.star
+	if this.modifiers != nil {
+		r["modifiers"] = this.modifiers
+	}
+	return json.Marshal(r)
+}
+
+/*
+Represents the EXCLUDE (path, ...) and REPLACE (expr AS
+path, ...) clauses of a star result term. The paths are
+relative to the value of the star term; for an unprefixed
+star, they start with a keyspace alias. EXCLUDE removes the
+fields at the paths, and REPLACE sets them to the values of
+the expressions, provided the parent of the field is an
+object.
+*/
+type StarModifiers struct {
+	exclude []FieldPath
+	replace StarReplaces
+}
+
+/*
+The function NewStarModifiers returns a pointer to the
+StarModifiers struct by assigning the input attributes
+to the fields of the struct.
+*/
+func NewStarModifiers(exclude []FieldPath, replace StarReplaces) *StarModifiers {
+	return &StarModifiers{
+		exclude: exclude,
+		replace: replace,
+	}
+}
+
+/*
+Return the paths of the EXCLUDE clause.
+*/
+func (this *StarModifiers) Exclude() []FieldPath {
+	return this.exclude
+}
+
+/*
+Return the terms of the REPLACE clause.
+*/
+func (this *StarModifiers) Replace() StarReplaces {
+	return this.replace
+}
+
+/*
+   Representation as a N1QL string.
+*/
+func (this *StarModifiers) String() string {
+	s := ""
+
+	if len(this.exclude) > 0 {
+		s += " exclude ("
+		for i, path := range this.exclude {
+			if i > 0 {
+				s += ", "
+			}
+			s += path.String()
+		}
+		s += ")"
+	}
+
+	if len(this.replace) > 0 {
+		s += " replace ("
+		for i, term := range this.replace {
+			if i > 0 {
+				s += ", "
+			}
+			s += term.String()
+		}
+		s += ")"
+	}
+
+	return s
+}
+
+/*
+Marshal input StarModifiers into byte array.
+*/
+func (this *StarModifiers) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{}
+	if len(this.exclude) > 0 {
+		r["exclude"] = this.exclude
+	}
+	if len(this.replace) > 0 {
+		r["replace"] = this.replace
+	}
+	return json.Marshal(r)
+}
+
+/*
+Type FieldPath represents a field path, such as a.b.c, given
+by the names of its fields.
+*/
+type FieldPath []string
+
+/*
+   Representation as a N1QL string, with each field name
+   escaped.
+*/
+func (this FieldPath) String() string {
+	fields := make([]string, len(this))
+	for i, field := range this {
+		fields[i] = "`" + field + "`"
+	}
+
+	return strings.Join(fields, ".")
+}
+
+/*
+Type StarReplaces represents multiple StarReplace terms.
+*/
+type StarReplaces []*StarReplace
+
+/*
+Returns the expressions of the terms.
+*/
+func (this StarReplaces) Expressions() expression.Expressions {
+	exprs := make(expression.Expressions, len(this))
+	for i, term := range this {
+		exprs[i] = term.expr
+	}
+
+	return exprs
+}
+
+/*
+Map the expressions of the terms.
+*/
+func (this StarReplaces) MapExpressions(mapper expression.Mapper) (err error) {
+	for _, term := range this {
+		term.expr, err = mapper.Map(term.expr)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+/*
+Represents a term expr AS path of a REPLACE clause.
+*/
+type StarReplace struct {
+	expr expression.Expression
+	path FieldPath
+}
+
+/*
+The function NewStarReplace returns a pointer to the
+StarReplace struct by assigning the input attributes
+to the fields of the struct.
+*/
+func NewStarReplace(expr expression.Expression, path FieldPath) *StarReplace {
+	return &StarReplace{
+		expr: expr,
+		path: path,
+	}
+}
+
+/*
+Return the replacing expression.
+*/
+func (this *StarReplace) Expression() expression.Expression {
+	return this.expr
+}
+
+/*
+Return the path of the replaced field.
+*/
+func (this *StarReplace) Path() FieldPath {
+	return this.path
+}
+
+/*
+   Representation as a N1QL string.
+*/
+func (this *StarReplace) String() string {
+	return this.expr.String() + " as " + this.path.String()
+}
+
+/*
+Marshal input StarReplace into byte array.
+*/
+func (this *StarReplace) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{
+		"expr": expression.NewStringer().Visit(this.expr),
+		"path": this.path,
+	}
 	return json.Marshal(r)
 }
@@ -30,14 +30,19 @@ type Subselect struct {
 	group      *Group                `json:"group"`
 	projection *Projection           `json:"projection"`
 	correlated bool                  `json:"correlated"`
+	optimHints *OptimHints
 }
 
 /*
-Constructor.
+Constructor. The aggregates of a PIVOT are computed by grouping.
 */
 func NewSubselect(from FromTerm, let expression.Bindings, where expression.Expression,
 	group *Group, projection *Projection) *Subselect {
-	return &Subselect{from, let, where, group, projection, false}
+	if pivot, ok := from.(*Pivot); ok {
+		group = newPivotGroup(group, pivot.Binding())
+	}
+
+	return &Subselect{from, let, where, group, projection, false, nil}
 }
 
 /*
@@ -230,7 +235,11 @@ func (this *Subselect) Privileges() (*auth.Privileges, errors.Error) {
    Representation as a N1QL string.
 */
 func (this *Subselect) String() string {
-	s := "select " + this.projection.String()
+	s := "select "
+	if this.optimHints != nil {
+		s += this.optimHints.String() + " "
+	}
+	s += this.projection.String()
 
 	if this.from != nil {
 		s += " from " + this.from.String()
@@ -245,7 +254,9 @@ func (this *Subselect) String() string {
 	}
 
 	if this.group != nil {
-		s += " " + this.group.String()
+		if g := this.group.String(); g != "" {
+			s += " " + g
+		}
 	}
 
 	return s
@@ -255,6 +266,20 @@ func (this *Subselect) IsCorrelated() bool {
 	return this.correlated
 }
 
+/*
+Returns the optimizer hints of the subselect, or nil.
+*/
+func (this *Subselect) OptimHints() *OptimHints {
+	return this.optimHints
+}
+
+/*
+Sets the optimizer hints of the subselect.
+*/
+func (this *Subselect) SetOptimHints(optimHints *OptimHints) {
+	this.optimHints = optimHints
+}
+
 /*
 Returns a FromTerm that represents the From clause
 in the subselect statement.
@@ -263,6 +288,13 @@ func (this *Subselect) From() FromTerm {
 	return this.from
 }
 
+/*
+Sets the From clause, as when views are inlined.
+*/
+func (this *Subselect) SetFrom(from FromTerm) {
+	this.from = from
+}
+
 /*
 Returns the let field that represents the Let
 clause in the subselect statement.
@@ -279,6 +311,13 @@ func (this *Subselect) Where() expression.Expression {
 	return this.where
 }
 
+/*
+Sets the Where clause, as when views are inlined.
+*/
+func (this *Subselect) SetWhere(where expression.Expression) {
+	this.where = where
+}
+
 /*
 Returns the group field that represents the group by
 clause in the subselect statement.
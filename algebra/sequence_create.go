@@ -0,0 +1,119 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the CREATE SEQUENCE ddl statement. The options are an
+object holding the START WITH, INCREMENT BY, MINVALUE, MAXVALUE,
+CACHE and CYCLE clauses, if given.
+*/
+type CreateSequence struct {
+	statementBase
+
+	sequence *KeyspaceRef
+	options  value.Value
+}
+
+/*
+The function NewCreateSequence returns a pointer to the
+CreateSequence struct with the input argument values as fields.
+*/
+func NewCreateSequence(sequence *KeyspaceRef, options value.Value) *CreateSequence {
+	rv := &CreateSequence{
+		sequence: sequence,
+		options:  options,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCreateSequence method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *CreateSequence) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateSequence(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateSequence) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateSequence) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateSequence) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *CreateSequence) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *CreateSequence) Privileges() (*auth.Privileges, errors.Error) {
+	return auth.NewPrivileges(), nil
+}
+
+/*
+Return the sequence name.
+*/
+func (this *CreateSequence) Sequence() *KeyspaceRef {
+	return this.sequence
+}
+
+/*
+Return the options of the sequence.
+*/
+func (this *CreateSequence) Options() value.Value {
+	return this.options
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CreateSequence) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "createSequence"}
+	r["sequenceRef"] = this.sequence
+	if this.options != nil {
+		r["options"] = this.options
+	}
+	return json.Marshal(r)
+}
+
+func (this *CreateSequence) Type() string {
+	return "CREATE_SEQUENCE"
+}
@@ -0,0 +1,105 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the DROP SEQUENCE ddl statement.
+*/
+type DropSequence struct {
+	statementBase
+
+	sequence *KeyspaceRef
+}
+
+/*
+The function NewDropSequence returns a pointer to the
+DropSequence struct with the input argument values as fields.
+*/
+func NewDropSequence(sequence *KeyspaceRef) *DropSequence {
+	rv := &DropSequence{
+		sequence: sequence,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitDropSequence method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *DropSequence) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropSequence(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *DropSequence) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropSequence) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropSequence) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *DropSequence) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *DropSequence) Privileges() (*auth.Privileges, errors.Error) {
+	return auth.NewPrivileges(), nil
+}
+
+/*
+Return the sequence name.
+*/
+func (this *DropSequence) Sequence() *KeyspaceRef {
+	return this.sequence
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *DropSequence) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "dropSequence"}
+	r["sequenceRef"] = this.sequence
+	return json.Marshal(r)
+}
+
+func (this *DropSequence) Type() string {
+	return "DROP_SEQUENCE"
+}
@@ -0,0 +1,106 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents NEXT VALUE FOR sequence and PREV VALUE FOR sequence.
+NEXT VALUE hands out the next number of the sequence, and PREV
+VALUE returns the number last handed out by NEXT VALUE, in the
+same request if it did so, and otherwise on this node. The
+namespace defaults to that of the request.
+*/
+type SequenceValue struct {
+	expression.NullaryFunctionBase
+	sequence *KeyspaceRef
+	next     bool
+}
+
+func NewSequenceValue(sequence *KeyspaceRef, next bool) expression.Function {
+	name := "prevval"
+	if next {
+		name = "nextval"
+	}
+
+	rv := &SequenceValue{
+		*expression.NewNullaryFunctionBase(name),
+		sequence,
+		next,
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *SequenceValue) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *SequenceValue) Type() value.Type { return value.NUMBER }
+
+func (this *SequenceValue) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	return context.(Context).SequenceValue(this.sequence.Namespace(), this.sequence.Keyspace(), this.next)
+}
+
+/*
+Every evaluation of NEXT VALUE returns a different number.
+*/
+func (this *SequenceValue) Volatile() bool {
+	return true
+}
+
+func (this *SequenceValue) EquivalentTo(other expression.Expression) bool {
+	return false
+}
+
+/*
+Factory method pattern.
+*/
+func (this *SequenceValue) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewSequenceValue(this.sequence, this.next)
+	}
+}
+
+/*
+Returns NEXT VALUE FOR or PREV VALUE FOR, and the sequence.
+*/
+func (this *SequenceValue) Text(stringer *expression.Stringer) string {
+	text := "prev value for "
+	if this.next {
+		text = "next value for "
+	}
+
+	if this.sequence.Namespace() != "" {
+		text += "`" + this.sequence.Namespace() + "`:"
+	}
+	return text + "`" + this.sequence.Keyspace() + "`"
+}
+
+/*
+Returns the sequence.
+*/
+func (this *SequenceValue) Sequence() *KeyspaceRef {
+	return this.sequence
+}
+
+/*
+Returns true for NEXT VALUE FOR.
+*/
+func (this *SequenceValue) Next() bool {
+	return this.next
+}
@@ -0,0 +1,200 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the CREATE TRIGGER ddl statement. A trigger runs a DML
+statement, its body, for each document that an INSERT, UPDATE or
+DELETE of the keyspace mutates. The text of the body is stored, and
+the body itself is only used to validate the definition. In the WHEN
+condition and the body, OLD and NEW are the named parameters $old and
+$new.
+*/
+type CreateTrigger struct {
+	statementBase
+
+	name     string
+	timing   string
+	event    string
+	keyspace *KeyspaceRef
+	when     expression.Expression
+	body     Statement
+	text     string
+}
+
+/*
+The function NewCreateTrigger returns a pointer to the
+CreateTrigger struct with the input argument values as fields.
+*/
+func NewCreateTrigger(name, timing, event string, keyspace *KeyspaceRef,
+	when expression.Expression, body Statement, text string) *CreateTrigger {
+	rv := &CreateTrigger{
+		name:     name,
+		timing:   timing,
+		event:    event,
+		keyspace: keyspace,
+		when:     when,
+		body:     body,
+		text:     strings.TrimRight(text, "; \t\r\n"),
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCreateTrigger method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *CreateTrigger) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateTrigger(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateTrigger) Signature() value.Value {
+	return nil
+}
+
+/*
+Formalize the body, and the WHEN condition, which may only
+reference OLD and NEW.
+*/
+func (this *CreateTrigger) Formalize() (err error) {
+	if this.when != nil {
+		this.when, err = expression.NewFormalizer("", nil).Map(this.when)
+		if err != nil {
+			return
+		}
+	}
+
+	return this.body.Formalize()
+}
+
+/*
+Map the expressions of the WHEN condition and the body.
+*/
+func (this *CreateTrigger) MapExpressions(mapper expression.Mapper) (err error) {
+	if this.when != nil {
+		this.when, err = mapper.Map(this.when)
+		if err != nil {
+			return
+		}
+	}
+
+	return this.body.MapExpressions(mapper)
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *CreateTrigger) Expressions() expression.Expressions {
+	exprs := this.body.Expressions()
+	if this.when != nil {
+		exprs = append(exprs, this.when)
+	}
+	return exprs
+}
+
+/*
+Returns all required privileges: those of the body, which runs without
+further authorization, and the right to write the keyspace, since the
+trigger changes what writing it does.
+*/
+func (this *CreateTrigger) Privileges() (*auth.Privileges, errors.Error) {
+	privs, err := this.body.Privileges()
+	if err != nil {
+		return nil, err
+	}
+
+	privs.Add(this.keyspace.FullName(), auth.PRIV_WRITE)
+	return privs, nil
+}
+
+/*
+Return the trigger name.
+*/
+func (this *CreateTrigger) Name() string {
+	return this.name
+}
+
+/*
+Return BEFORE or AFTER, in lower case.
+*/
+func (this *CreateTrigger) Timing() string {
+	return this.timing
+}
+
+/*
+Return INSERT, UPDATE or DELETE, in lower case.
+*/
+func (this *CreateTrigger) Event() string {
+	return this.event
+}
+
+/*
+Return the keyspace the trigger is defined on.
+*/
+func (this *CreateTrigger) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Return the WHEN condition, or nil.
+*/
+func (this *CreateTrigger) When() expression.Expression {
+	return this.when
+}
+
+/*
+Return the body.
+*/
+func (this *CreateTrigger) Body() Statement {
+	return this.body
+}
+
+/*
+Return the text of the body.
+*/
+func (this *CreateTrigger) Text() string {
+	return this.text
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CreateTrigger) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "createTrigger"}
+	r["name"] = this.name
+	r["timing"] = this.timing
+	r["event"] = this.event
+	r["keyspaceRef"] = this.keyspace
+	if this.when != nil {
+		r["when"] = expression.NewStringer().Visit(this.when)
+	}
+	r["body"] = this.text
+	return json.Marshal(r)
+}
+
+func (this *CreateTrigger) Type() string {
+	return "CREATE_TRIGGER"
+}
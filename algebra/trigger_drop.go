@@ -0,0 +1,117 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the DROP TRIGGER ddl statement.
+*/
+type DropTrigger struct {
+	statementBase
+
+	name     string
+	keyspace *KeyspaceRef
+}
+
+/*
+The function NewDropTrigger returns a pointer to the
+DropTrigger struct with the input argument values as fields.
+*/
+func NewDropTrigger(name string, keyspace *KeyspaceRef) *DropTrigger {
+	rv := &DropTrigger{
+		name:     name,
+		keyspace: keyspace,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitDropTrigger method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *DropTrigger) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropTrigger(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *DropTrigger) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropTrigger) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropTrigger) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *DropTrigger) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *DropTrigger) Privileges() (*auth.Privileges, errors.Error) {
+	privs := auth.NewPrivileges()
+	privs.Add(this.keyspace.FullName(), auth.PRIV_WRITE)
+	return privs, nil
+}
+
+/*
+Return the trigger name.
+*/
+func (this *DropTrigger) Name() string {
+	return this.name
+}
+
+/*
+Return the keyspace the trigger is defined on.
+*/
+func (this *DropTrigger) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *DropTrigger) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "dropTrigger"}
+	r["name"] = this.name
+	r["keyspaceRef"] = this.keyspace
+	return json.Marshal(r)
+}
+
+func (this *DropTrigger) Type() string {
+	return "DROP_TRIGGER"
+}
@@ -22,24 +22,27 @@ Represents the UPDATE statement.
 type Update struct {
 	statementBase
 
-	keyspace  *KeyspaceRef          `json:"keyspace"`
-	keys      expression.Expression `json:"keys"`
-	indexes   IndexRefs             `json:"indexes"`
-	set       *Set                  `json:"set"`
-	unset     *Unset                `json:"unset"`
-	where     expression.Expression `json:"where"`
-	limit     expression.Expression `json:"limit"`
-	returning *Projection           `json:"returning"`
+	keyspace   *KeyspaceRef          `json:"keyspace"`
+	keys       expression.Expression `json:"keys"`
+	indexes    IndexRefs             `json:"indexes"`
+	set        *Set                  `json:"set"`
+	unset      *Unset                `json:"unset"`
+	from       FromTerm              `json:"from"`
+	where      expression.Expression `json:"where"`
+	limit      expression.Expression `json:"limit"`
+	returning  *Projection           `json:"returning"`
+	optimHints *OptimHints
 }
 
 func NewUpdate(keyspace *KeyspaceRef, keys expression.Expression, indexes IndexRefs,
-	set *Set, unset *Unset, where, limit expression.Expression, returning *Projection) *Update {
+	set *Set, unset *Unset, from FromTerm, where, limit expression.Expression, returning *Projection) *Update {
 	rv := &Update{
 		keyspace:  keyspace,
 		keys:      keys,
 		indexes:   indexes,
 		set:       set,
 		unset:     unset,
+		from:      from,
 		where:     where,
 		limit:     limit,
 		returning: returning,
@@ -100,6 +103,13 @@ func (this *Update) MapExpressions(mapper expression.Mapper) (err error) {
 		}
 	}
 
+	if this.from != nil {
+		err = this.from.MapExpressions(mapper)
+		if err != nil {
+			return
+		}
+	}
+
 	if this.where != nil {
 		this.where, err = mapper.Map(this.where)
 		if err != nil {
@@ -139,6 +149,10 @@ func (this *Update) Expressions() expression.Expressions {
 		exprs = append(exprs, this.unset.Expressions()...)
 	}
 
+	if this.from != nil {
+		exprs = append(exprs, this.from.Expressions()...)
+	}
+
 	if this.where != nil {
 		exprs = append(exprs, this.where)
 	}
@@ -161,10 +175,18 @@ func (this *Update) Privileges() (*auth.Privileges, errors.Error) {
 	privs := auth.NewPrivileges()
 	fullKeyspace := this.keyspace.FullName()
 	privs.Add(fullKeyspace, auth.PRIV_QUERY_UPDATE)
-	if this.returning != nil {
+	if this.returning != nil || this.from != nil {
 		privs.Add(fullKeyspace, auth.PRIV_QUERY_SELECT)
 	}
 
+	if this.from != nil {
+		fromPrivs, err := this.from.Privileges()
+		if err != nil {
+			return nil, err
+		}
+		privs.AddAll(fromPrivs)
+	}
+
 	exprs := this.Expressions()
 	subprivs, err := subqueryPrivileges(exprs)
 	if err != nil {
@@ -189,6 +211,13 @@ func (this *Update) Formalize() (err error) {
 		return err
 	}
 
+	if this.from != nil {
+		f, err = formalizeMutateFrom(f, this.from)
+		if err != nil {
+			return err
+		}
+	}
+
 	empty := expression.NewFormalizer("", nil)
 
 	if this.keys != nil {
@@ -233,6 +262,21 @@ func (this *Update) Formalize() (err error) {
 	return
 }
 
+/*
+Qualify identifiers against the target keyspace and the terms of the
+FROM clause of an UPDATE, or the USING clause of a DELETE. Unqualified
+identifiers still refer to the target keyspace.
+*/
+func formalizeMutateFrom(kf *expression.Formalizer, from FromTerm) (*expression.Formalizer, error) {
+	f, err := from.Formalize(kf)
+	if err != nil {
+		return nil, err
+	}
+
+	f.SetKeyspace(kf.Keyspace())
+	return f, nil
+}
+
 /*
 Returns the keyspace-ref for the UPDATE statement.
 */
@@ -271,6 +315,13 @@ func (this *Update) Unset() *Unset {
 	return this.unset
 }
 
+/*
+Returns the FROM clause term in an UPDATE statement.
+*/
+func (this *Update) From() FromTerm {
+	return this.from
+}
+
 /*
 Returns the WHERE clause expression in an UPDATE
 statement.
@@ -294,3 +345,17 @@ UPDATE statement.
 func (this *Update) Returning() *Projection {
 	return this.returning
 }
+
+/*
+Returns the optimizer hints of the UPDATE statement, or nil.
+*/
+func (this *Update) OptimHints() *OptimHints {
+	return this.optimHints
+}
+
+/*
+Sets the optimizer hints of the UPDATE statement.
+*/
+func (this *Update) SetOptimHints(optimHints *OptimHints) {
+	this.optimHints = optimHints
+}
@@ -0,0 +1,224 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+The LET variable holding the violations of each document in the query
+of VALIDATE. It cannot be written in a statement, and so cannot hide
+a field of the documents.
+*/
+const _VIOLATIONS = "#violations"
+
+/*
+Represents the VALIDATE statement, which checks the documents of a
+keyspace, optionally filtered by a WHERE clause, against the schema of
+its WITH clause or else the validation schema of the keyspace, and
+returns the key and the violations of each document that does not
+match, up to the LIMIT.
+*/
+type ValidateKeyspace struct {
+	statementBase
+
+	keyspace *KeyspaceRef
+	schema   value.Value
+	where    expression.Expression
+	limit    expression.Expression
+}
+
+func NewValidateKeyspace(keyspace *KeyspaceRef, schema value.Value,
+	where, limit expression.Expression) *ValidateKeyspace {
+	rv := &ValidateKeyspace{
+		keyspace: keyspace,
+		schema:   schema,
+		where:    where,
+		limit:    limit,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+func (this *ValidateKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitValidateKeyspace(this)
+}
+
+/*
+Each row holds the key of a document and its violations.
+*/
+func (this *ValidateKeyspace) Signature() value.Value {
+	return value.NewValue(map[string]interface{}{
+		"id":         value.STRING.String(),
+		"violations": value.ARRAY.String(),
+	})
+}
+
+/*
+Fully qualify identifiers in the WHERE and LIMIT clauses.
+*/
+func (this *ValidateKeyspace) Formalize() (err error) {
+	f, err := this.keyspace.Formalize()
+	if err != nil {
+		return err
+	}
+
+	if this.where != nil {
+		this.where, err = f.Map(this.where)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.limit != nil {
+		_, err = this.limit.Accept(expression.NewFormalizer("", nil))
+	}
+
+	return
+}
+
+func (this *ValidateKeyspace) MapExpressions(mapper expression.Mapper) (err error) {
+	if this.where != nil {
+		this.where, err = mapper.Map(this.where)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.limit != nil {
+		this.limit, err = mapper.Map(this.limit)
+	}
+
+	return
+}
+
+func (this *ValidateKeyspace) Expressions() expression.Expressions {
+	exprs := make(expression.Expressions, 0, 2)
+
+	if this.where != nil {
+		exprs = append(exprs, this.where)
+	}
+
+	if this.limit != nil {
+		exprs = append(exprs, this.limit)
+	}
+
+	return exprs
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *ValidateKeyspace) Privileges() (*auth.Privileges, errors.Error) {
+	privs, err := privilegesFromKeyspace(this.keyspace.Namespace(), this.keyspace.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	exprs := this.Expressions()
+	for _, expr := range exprs {
+		privs.AddAll(expr.Privileges())
+	}
+
+	subprivs, err := subqueryPrivileges(exprs)
+	if err != nil {
+		return nil, err
+	}
+	privs.AddAll(subprivs)
+	return privs, nil
+}
+
+func (this *ValidateKeyspace) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns the schema of the WITH clause, or nil to use the validation
+schema of the keyspace.
+*/
+func (this *ValidateKeyspace) Schema() value.Value {
+	return this.schema
+}
+
+func (this *ValidateKeyspace) Where() expression.Expression {
+	return this.where
+}
+
+func (this *ValidateKeyspace) Limit() expression.Expression {
+	return this.limit
+}
+
+/*
+Returns the query run by the statement, given the second operand of
+VALIDATE(): the schema, or the name of the keyspace whose validation
+schema is used.
+
+SELECT META(k).id AS id, `#violations` AS violations
+FROM keyspace AS k LET `#violations` = VALIDATE(k, schema)
+WHERE where AND ARRAY_LENGTH(`#violations`) > 0 LIMIT limit
+*/
+func (this *ValidateKeyspace) Query(schema expression.Expression) (*Select, error) {
+	alias := this.keyspace.Alias()
+	doc := expression.NewIdentifier(alias)
+	doc.SetKeyspaceAlias(true)
+	violations := expression.NewIdentifier(_VIOLATIONS)
+
+	let := expression.Bindings{
+		expression.NewSimpleBinding(_VIOLATIONS, expression.NewValidate(doc, schema)),
+	}
+
+	var where expression.Expression = expression.NewGT(
+		expression.NewArrayLength(violations), expression.NewConstant(0))
+	if this.where != nil {
+		where = expression.NewAnd(this.where, where)
+	}
+
+	projection := NewProjection(false, ResultTerms{
+		NewResultTerm(expression.NewField(expression.NewMeta(doc),
+			expression.NewFieldName("id", false)), false, "id"),
+		NewResultTerm(violations, false, "violations"),
+	})
+
+	from := NewKeyspaceTerm(this.keyspace.Namespace(), this.keyspace.Keyspace(),
+		this.keyspace.As(), nil, nil)
+	rv := NewSelect(NewSubselect(from, let, where, nil, projection), nil, nil, this.limit)
+
+	err := rv.Formalize()
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (this *ValidateKeyspace) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "validateKeyspace"}
+	r["keyspaceRef"] = this.keyspace
+	if this.schema != nil {
+		r["schema"] = this.schema
+	}
+	if this.where != nil {
+		r["where"] = expression.NewStringer().Visit(this.where)
+	}
+	if this.limit != nil {
+		r["limit"] = expression.NewStringer().Visit(this.limit)
+	}
+	return json.Marshal(r)
+}
+
+func (this *ValidateKeyspace) Type() string {
+	return "VALIDATE"
+}
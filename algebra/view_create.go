@@ -0,0 +1,150 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the CREATE VIEW and CREATE MATERIALIZED VIEW ddl
+statements. A view is a named query: the text of the query is
+stored, and the query itself is only used to validate the
+definition. A materialized view is backed by the keyspace of the
+same name.
+*/
+type CreateView struct {
+	statementBase
+
+	keyspace     *KeyspaceRef
+	materialized bool
+	query        *Select
+	text         string
+}
+
+/*
+The function NewCreateView returns a pointer to the CreateView
+struct with the input argument values as fields.
+*/
+func NewCreateView(keyspace *KeyspaceRef, materialized bool, query *Select, text string) *CreateView {
+	rv := &CreateView{
+		keyspace:     keyspace,
+		materialized: materialized,
+		query:        query,
+		text:         strings.TrimRight(text, "; \t\r\n"),
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCreateView method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *CreateView) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateView(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateView) Signature() value.Value {
+	return nil
+}
+
+/*
+Formalize the definition.
+*/
+func (this *CreateView) Formalize() error {
+	return this.query.Formalize()
+}
+
+/*
+Map the expressions of the definition.
+*/
+func (this *CreateView) MapExpressions(mapper expression.Mapper) error {
+	return this.query.MapExpressions(mapper)
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *CreateView) Expressions() expression.Expressions {
+	return this.query.Expressions()
+}
+
+/*
+Returns all required privileges: those of the definition, and for a
+materialized view, those required to populate the keyspace.
+*/
+func (this *CreateView) Privileges() (*auth.Privileges, errors.Error) {
+	privs, err := this.query.Privileges()
+	if err != nil {
+		return nil, err
+	}
+
+	if this.materialized {
+		fullName := this.keyspace.FullName()
+		privs.Add(fullName, auth.PRIV_QUERY_INSERT)
+		privs.Add(fullName, auth.PRIV_QUERY_DELETE)
+	}
+	return privs, nil
+}
+
+/*
+Return the view name.
+*/
+func (this *CreateView) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns true for CREATE MATERIALIZED VIEW.
+*/
+func (this *CreateView) Materialized() bool {
+	return this.materialized
+}
+
+/*
+Return the definition.
+*/
+func (this *CreateView) Query() *Select {
+	return this.query
+}
+
+/*
+Return the text of the definition.
+*/
+func (this *CreateView) Text() string {
+	return this.text
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CreateView) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "createView"}
+	r["keyspaceRef"] = this.keyspace
+	r["materialized"] = this.materialized
+	r["definition"] = this.text
+	return json.Marshal(r)
+}
+
+func (this *CreateView) Type() string {
+	return "CREATE_VIEW"
+}
@@ -0,0 +1,157 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Refresh modes. By default, a materialized view is refreshed
+incrementally if its definition allows it, and in full otherwise.
+*/
+const (
+	REFRESH_DEFAULT     = ""
+	REFRESH_FULL        = "full"
+	REFRESH_INCREMENTAL = "incremental"
+)
+
+/*
+Represents the REFRESH MATERIALIZED VIEW statement, which runs the
+definition of the view and stores the results in its keyspace.
+*/
+type RefreshView struct {
+	statementBase
+
+	keyspace   *KeyspaceRef
+	mode       string
+	definition *Select
+}
+
+/*
+The function NewRefreshView returns a pointer to the RefreshView
+struct with the input argument values as fields.
+*/
+func NewRefreshView(keyspace *KeyspaceRef, mode string) *RefreshView {
+	rv := &RefreshView{
+		keyspace: keyspace,
+		mode:     mode,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitRefreshView method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *RefreshView) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitRefreshView(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *RefreshView) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *RefreshView) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *RefreshView) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *RefreshView) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges: those of the definition of the
+view, once the planner has found it, and those required to populate
+the keyspace.
+*/
+func (this *RefreshView) Privileges() (*auth.Privileges, errors.Error) {
+	privs := auth.NewPrivileges()
+	if this.definition != nil {
+		defPrivs, err := this.definition.Privileges()
+		if err != nil {
+			return nil, err
+		}
+		privs.AddAll(defPrivs)
+	}
+
+	fullName := this.keyspace.FullName()
+	privs.Add(fullName, auth.PRIV_QUERY_INSERT)
+	privs.Add(fullName, auth.PRIV_QUERY_DELETE)
+	return privs, nil
+}
+
+/*
+Return the view name.
+*/
+func (this *RefreshView) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Return the refresh mode.
+*/
+func (this *RefreshView) Mode() string {
+	return this.mode
+}
+
+/*
+Return the definition of the view, if known.
+*/
+func (this *RefreshView) Definition() *Select {
+	return this.definition
+}
+
+/*
+Set the definition of the view.
+*/
+func (this *RefreshView) SetDefinition(definition *Select) {
+	this.definition = definition
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *RefreshView) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "refreshView"}
+	r["keyspaceRef"] = this.keyspace
+	if this.mode != REFRESH_DEFAULT {
+		r["mode"] = this.mode
+	}
+	return json.Marshal(r)
+}
+
+func (this *RefreshView) Type() string {
+	return "REFRESH_VIEW"
+}
@@ -37,6 +37,31 @@ type Visitor interface {
 	VisitAlterIndex(stmt *AlterIndex) (interface{}, error)
 	VisitBuildIndexes(stmt *BuildIndexes) (interface{}, error)
 
+	/*
+	   Visitor for VIEW statements.
+	*/
+	VisitCreateView(stmt *CreateView) (interface{}, error)
+	VisitDropView(stmt *DropView) (interface{}, error)
+	VisitRefreshView(stmt *RefreshView) (interface{}, error)
+
+	/*
+	   Visitor for SEQUENCE statements.
+	*/
+	VisitCreateSequence(stmt *CreateSequence) (interface{}, error)
+	VisitAlterSequence(stmt *AlterSequence) (interface{}, error)
+	VisitDropSequence(stmt *DropSequence) (interface{}, error)
+
+	/*
+	   Visitor for KEYSPACE statements.
+	*/
+	VisitAlterKeyspace(stmt *AlterKeyspace) (interface{}, error)
+
+	/*
+	   Visitor for TRIGGER statements.
+	*/
+	VisitCreateTrigger(stmt *CreateTrigger) (interface{}, error)
+	VisitDropTrigger(stmt *DropTrigger) (interface{}, error)
+
 	/*
 	   Visitor for ROLES statements.
 	*/
@@ -62,6 +87,11 @@ type Visitor interface {
 	   Visitor for INFER statements.
 	*/
 	VisitInferKeyspace(stmt *InferKeyspace) (interface{}, error)
+
+	/*
+	   Visitor for VALIDATE statements.
+	*/
+	VisitValidateKeyspace(stmt *ValidateKeyspace) (interface{}, error)
 }
 
 type NodeVisitor interface {
@@ -77,6 +107,8 @@ type NodeVisitor interface {
 	VisitIndexNest(node *IndexNest) (interface{}, error)
 	VisitAnsiNest(node *AnsiNest) (interface{}, error)
 	VisitUnnest(node *Unnest) (interface{}, error)
+	VisitPivot(node *Pivot) (interface{}, error)
+	VisitUnpivot(node *Unpivot) (interface{}, error)
 	VisitUnion(node *Union) (interface{}, error)
 	VisitUnionAll(node *UnionAll) (interface{}, error)
 	VisitIntersect(node *Intersect) (interface{}, error)
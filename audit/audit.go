@@ -228,6 +228,7 @@ const (
 	API_ADMIN_SETTINGS                   = 28700
 	API_ADMIN_CLUSTERS                   = 28701
 	API_ADMIN_COMPLETED_REQUESTS         = 28702
+	API_ADMIN_METADATA                   = 28703
 )
 
 func SubmitApiRequest(event *ApiAuditFields) {
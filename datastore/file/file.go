@@ -282,6 +282,8 @@ type keyspace struct {
 	namespace *namespace
 	name      string
 	fi        datastore.Indexer
+	fts       *ftsIndexer
+	vi        *vectorIndexer
 	fileLock  sync.Mutex
 }
 
@@ -310,11 +312,17 @@ func (b *keyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
 }
 
 func (b *keyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	switch name {
+	case datastore.FTS:
+		return b.fts, nil
+	case datastore.VECTOR:
+		return b.vi, nil
+	}
 	return b.fi, nil
 }
 
 func (b *keyspace) Indexers() ([]datastore.Indexer, errors.Error) {
-	return []datastore.Indexer{b.fi}, nil
+	return []datastore.Indexer{b.fi, b.fts, b.vi}, nil
 }
 
 func (b *keyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
@@ -436,6 +444,8 @@ func (b *keyspace) performOp(op int, kvPairs []value.Pair) ([]value.Pair, errors
 			returnErr = errors.NewFileDMLError(returnErr, opToString(op)+" Failed "+err.Error())
 		} else {
 			insertedKeys = append(insertedKeys, kv)
+			b.fts.update(key, kv.Value)
+			b.vi.update(key, kv.Value)
 		}
 	}
 
@@ -467,6 +477,8 @@ func (b *keyspace) Delete(deletes []string, context datastore.QueryContext) ([]s
 			}
 		} else {
 			deleted = append(deleted, key)
+			b.fts.remove(key)
+			b.vi.remove(key)
 		}
 	}
 
@@ -502,6 +514,8 @@ func newKeyspace(p *namespace, dir string) (b *keyspace, e errors.Error) {
 
 	b.fi = newFileIndexer(b)
 	b.fi.CreatePrimaryIndex("", "#primary", nil)
+	b.fts = newFtsIndexer(b)
+	b.vi = newVectorIndexer(b)
 
 	return
 }
@@ -670,6 +684,8 @@ func (pi *primaryIndex) Scan(requestId string, span *datastore.Span, distinct bo
 		switch a := a.(type) {
 		case string:
 			low = a
+		case nil:
+			// null sorts before any key, as in whole range scans
 		default:
 			conn.Error(errors.NewFileDatastoreError(nil, fmt.Sprintf("Invalid lower bound %v of type %T.", a, a)))
 			return
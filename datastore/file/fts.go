@@ -0,0 +1,305 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package file
+
+import (
+	"io/ioutil"
+	"sync"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/search"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+// ftsIndexer maintains the full text indexes of a keyspace. The
+// indexes are held in memory: they are built when created, kept up to
+// date by the writes to the keyspace, and not kept across restarts.
+type ftsIndexer struct {
+	sync.RWMutex
+	keyspace *keyspace
+	indexes  map[string]*ftsIndex
+}
+
+func newFtsIndexer(keyspace *keyspace) *ftsIndexer {
+	return &ftsIndexer{
+		keyspace: keyspace,
+		indexes:  make(map[string]*ftsIndex),
+	}
+}
+
+func (fi *ftsIndexer) KeyspaceId() string {
+	return fi.keyspace.Id()
+}
+
+func (fi *ftsIndexer) Name() datastore.IndexType {
+	return datastore.FTS
+}
+
+func (fi *ftsIndexer) IndexIds() ([]string, errors.Error) {
+	return fi.IndexNames()
+}
+
+func (fi *ftsIndexer) IndexNames() ([]string, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	rv := make([]string, 0, len(fi.indexes))
+	for name, _ := range fi.indexes {
+		rv = append(rv, name)
+	}
+	return rv, nil
+}
+
+func (fi *ftsIndexer) IndexById(id string) (datastore.Index, errors.Error) {
+	return fi.IndexByName(id)
+}
+
+func (fi *ftsIndexer) IndexByName(name string) (datastore.Index, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	index, ok := fi.indexes[name]
+	if !ok {
+		return nil, errors.NewFileIdxNotFound(nil, name)
+	}
+	return index, nil
+}
+
+func (fi *ftsIndexer) PrimaryIndexes() ([]datastore.PrimaryIndex, errors.Error) {
+	return nil, nil
+}
+
+func (fi *ftsIndexer) Indexes() ([]datastore.Index, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	rv := make([]datastore.Index, 0, len(fi.indexes))
+	for _, index := range fi.indexes {
+		rv = append(rv, index)
+	}
+	return rv, nil
+}
+
+func (fi *ftsIndexer) CreatePrimaryIndex(requestId, name string, with value.Value) (
+	datastore.PrimaryIndex, errors.Error) {
+	return nil, errors.NewFileNotSupported(nil, "Full text indexes cannot be primary indexes.")
+}
+
+func (fi *ftsIndexer) CreateIndex(requestId, name string, seekKey, rangeKey expression.Expressions,
+	where expression.Expression, with value.Value) (datastore.Index, errors.Error) {
+	if where != nil {
+		return nil, errors.NewFileNotSupported(nil, "Full text indexes cannot have a WHERE clause.")
+	}
+
+	if len(rangeKey) == 0 {
+		return nil, errors.NewFileNotSupported(nil, "Full text indexes must have a key.")
+	}
+
+	index := &ftsIndex{
+		name:    name,
+		keys:    rangeKey,
+		indexer: fi,
+		texts:   make([]*search.Index, len(rangeKey)),
+	}
+	for i, _ := range index.texts {
+		index.texts[i] = search.NewIndex()
+	}
+
+	// no writes while the index is built
+	fi.keyspace.fileLock.Lock()
+	defer fi.keyspace.fileLock.Unlock()
+
+	fi.Lock()
+	defer fi.Unlock()
+
+	if _, ok := fi.indexes[name]; ok {
+		return nil, errors.NewIndexAlreadyExistsError(name)
+	}
+
+	dirEntries, er := ioutil.ReadDir(fi.keyspace.path())
+	if er != nil {
+		return nil, errors.NewFileDatastoreError(er, "")
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		key := documentPathToId(dirEntry.Name())
+		doc, err := fi.keyspace.fetchOne(key)
+		if err != nil {
+			return nil, err
+		}
+		index.add(key, doc)
+	}
+
+	fi.indexes[name] = index
+	return index, nil
+}
+
+func (fi *ftsIndexer) BuildIndexes(requestId string, names ...string) errors.Error {
+	return errors.NewFileNotSupported(nil, "BUILD INDEXES is not supported for file-based datastore.")
+}
+
+func (fi *ftsIndexer) Refresh() errors.Error {
+	return nil
+}
+
+func (fi *ftsIndexer) MetadataVersion() uint64 {
+	return 0
+}
+
+func (fi *ftsIndexer) SetLogLevel(level logging.Level) {
+	// No-op, uses query engine logger
+}
+
+// update indexes a written document
+func (fi *ftsIndexer) update(key string, doc value.Value) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	for _, index := range fi.indexes {
+		index.add(key, doc)
+	}
+}
+
+// remove drops a deleted document from the indexes
+func (fi *ftsIndexer) remove(key string) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	for _, index := range fi.indexes {
+		for _, text := range index.texts {
+			text.Remove(key)
+		}
+	}
+}
+
+// ftsIndex is a full text index, with a text index for each key.
+type ftsIndex struct {
+	name    string
+	keys    expression.Expressions
+	indexer *ftsIndexer
+	texts   []*search.Index
+}
+
+func (index *ftsIndex) add(key string, doc value.Value) {
+	context := expression.NewIndexContext()
+	for i, k := range index.keys {
+		v, err := k.Evaluate(doc, context)
+		if err != nil || v.Type() <= value.NULL {
+			index.texts[i].Remove(key)
+			continue
+		}
+		index.texts[i].Add(key, search.NewDocument(v))
+	}
+}
+
+func (index *ftsIndex) KeyspaceId() string {
+	return index.indexer.KeyspaceId()
+}
+
+func (index *ftsIndex) Id() string {
+	return index.Name()
+}
+
+func (index *ftsIndex) Name() string {
+	return index.name
+}
+
+func (index *ftsIndex) Type() datastore.IndexType {
+	return datastore.FTS
+}
+
+func (index *ftsIndex) Indexer() datastore.Indexer {
+	return index.indexer
+}
+
+func (index *ftsIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (index *ftsIndex) RangeKey() expression.Expressions {
+	return index.keys
+}
+
+func (index *ftsIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (index *ftsIndex) IsPrimary() bool {
+	return false
+}
+
+func (index *ftsIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (index *ftsIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (index *ftsIndex) Drop(requestId string) errors.Error {
+	index.indexer.Lock()
+	defer index.indexer.Unlock()
+
+	delete(index.indexer.indexes, index.name)
+	return nil
+}
+
+func (index *ftsIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	conn.Error(errors.NewFileNotSupported(nil, "Full text indexes can only be searched."))
+}
+
+func (index *ftsIndex) Search(requestId string, key int, query, options value.Value, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	if key < 0 || key >= len(index.texts) {
+		conn.Error(errors.NewFileIdxNotFound(nil, index.name))
+		return
+	}
+
+	text, ok := query.Actual().(string)
+	if !ok {
+		return
+	}
+
+	q, err := search.Parse(text)
+	if err != nil {
+		conn.Error(errors.NewSearchQueryError(err, text))
+		return
+	}
+
+	for i, hit := range index.texts[key].Search(q) {
+		if limit > 0 && int64(i) >= limit {
+			break
+		}
+
+		entry := datastore.IndexEntry{
+			PrimaryKey: hit.Key,
+			MetaData:   value.NewValue(map[string]interface{}{"score": hit.Score}),
+		}
+		select {
+		case conn.EntryChannel() <- &entry:
+		case <-conn.StopChannel():
+			return
+		}
+	}
+}
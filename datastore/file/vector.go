@@ -0,0 +1,331 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+	"github.com/couchbase/query/vector"
+)
+
+// vectorIndexer maintains the nearest neighbour indexes of a keyspace.
+// Like full text indexes, they are held in memory: they are built when
+// created, kept up to date by the writes to the keyspace, and not kept
+// across restarts.
+type vectorIndexer struct {
+	sync.RWMutex
+	keyspace *keyspace
+	indexes  map[string]*vectorIndex
+}
+
+func newVectorIndexer(keyspace *keyspace) *vectorIndexer {
+	return &vectorIndexer{
+		keyspace: keyspace,
+		indexes:  make(map[string]*vectorIndex),
+	}
+}
+
+func (vi *vectorIndexer) KeyspaceId() string {
+	return vi.keyspace.Id()
+}
+
+func (vi *vectorIndexer) Name() datastore.IndexType {
+	return datastore.VECTOR
+}
+
+func (vi *vectorIndexer) IndexIds() ([]string, errors.Error) {
+	return vi.IndexNames()
+}
+
+func (vi *vectorIndexer) IndexNames() ([]string, errors.Error) {
+	vi.RLock()
+	defer vi.RUnlock()
+
+	rv := make([]string, 0, len(vi.indexes))
+	for name, _ := range vi.indexes {
+		rv = append(rv, name)
+	}
+	return rv, nil
+}
+
+func (vi *vectorIndexer) IndexById(id string) (datastore.Index, errors.Error) {
+	return vi.IndexByName(id)
+}
+
+func (vi *vectorIndexer) IndexByName(name string) (datastore.Index, errors.Error) {
+	vi.RLock()
+	defer vi.RUnlock()
+
+	index, ok := vi.indexes[name]
+	if !ok {
+		return nil, errors.NewFileIdxNotFound(nil, name)
+	}
+	return index, nil
+}
+
+func (vi *vectorIndexer) PrimaryIndexes() ([]datastore.PrimaryIndex, errors.Error) {
+	return nil, nil
+}
+
+func (vi *vectorIndexer) Indexes() ([]datastore.Index, errors.Error) {
+	vi.RLock()
+	defer vi.RUnlock()
+
+	rv := make([]datastore.Index, 0, len(vi.indexes))
+	for _, index := range vi.indexes {
+		rv = append(rv, index)
+	}
+	return rv, nil
+}
+
+func (vi *vectorIndexer) CreatePrimaryIndex(requestId, name string, with value.Value) (
+	datastore.PrimaryIndex, errors.Error) {
+	return nil, errors.NewFileNotSupported(nil, "Vector indexes cannot be primary indexes.")
+}
+
+// The options of an index are the "metric", "l2" by default, and the
+// "m", "ef_construction" and "ef_search" sizes of the graph.
+func (vi *vectorIndexer) CreateIndex(requestId, name string, seekKey, rangeKey expression.Expressions,
+	where expression.Expression, with value.Value) (datastore.Index, errors.Error) {
+	if where != nil {
+		return nil, errors.NewFileNotSupported(nil, "Vector indexes cannot have a WHERE clause.")
+	}
+
+	if len(rangeKey) != 1 {
+		return nil, errors.NewFileNotSupported(nil, "Vector indexes must have a single key.")
+	}
+
+	metric := vector.DEFAULT_METRIC
+	sizes := map[string]int{"m": 0, "ef_construction": 0, "ef_search": 0}
+	if with != nil {
+		if with.Type() != value.OBJECT {
+			return nil, errors.NewFileIndexOptionError(nil, with.String())
+		}
+
+		for option, v := range with.Fields() {
+			v := value.NewValue(v)
+			if option == "metric" {
+				m, ok := v.Actual().(string)
+				if ok {
+					metric, ok = vector.ParseMetric(m)
+				}
+				if !ok {
+					return nil, errors.NewFileIndexOptionError(nil, fmt.Sprintf("metric %v", v))
+				}
+				continue
+			}
+
+			if _, ok := sizes[option]; !ok {
+				return nil, errors.NewFileIndexOptionError(nil, option)
+			}
+
+			n, ok := v.Actual().(float64)
+			if !ok || n < 1 || !value.IsInt(n) {
+				return nil, errors.NewFileIndexOptionError(nil, fmt.Sprintf("%s %v", option, v))
+			}
+			sizes[option] = int(n)
+		}
+	}
+
+	index := &vectorIndex{
+		name:    name,
+		key:     rangeKey[0],
+		indexer: vi,
+		graph:   vector.NewIndex(metric, sizes["m"], sizes["ef_construction"], sizes["ef_search"]),
+	}
+
+	// no writes while the index is built
+	vi.keyspace.fileLock.Lock()
+	defer vi.keyspace.fileLock.Unlock()
+
+	vi.Lock()
+	defer vi.Unlock()
+
+	if _, ok := vi.indexes[name]; ok {
+		return nil, errors.NewIndexAlreadyExistsError(name)
+	}
+
+	dirEntries, er := ioutil.ReadDir(vi.keyspace.path())
+	if er != nil {
+		return nil, errors.NewFileDatastoreError(er, "")
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		key := documentPathToId(dirEntry.Name())
+		doc, err := vi.keyspace.fetchOne(key)
+		if err != nil {
+			return nil, err
+		}
+		index.add(key, doc)
+	}
+
+	vi.indexes[name] = index
+	return index, nil
+}
+
+func (vi *vectorIndexer) BuildIndexes(requestId string, names ...string) errors.Error {
+	return errors.NewFileNotSupported(nil, "BUILD INDEXES is not supported for file-based datastore.")
+}
+
+func (vi *vectorIndexer) Refresh() errors.Error {
+	return nil
+}
+
+func (vi *vectorIndexer) MetadataVersion() uint64 {
+	return 0
+}
+
+func (vi *vectorIndexer) SetLogLevel(level logging.Level) {
+	// No-op, uses query engine logger
+}
+
+// update indexes a written document
+func (vi *vectorIndexer) update(key string, doc value.Value) {
+	vi.RLock()
+	defer vi.RUnlock()
+
+	for _, index := range vi.indexes {
+		index.add(key, doc)
+	}
+}
+
+// remove drops a deleted document from the indexes
+func (vi *vectorIndexer) remove(key string) {
+	vi.RLock()
+	defer vi.RUnlock()
+
+	for _, index := range vi.indexes {
+		index.graph.Remove(key)
+	}
+}
+
+// vectorIndex is a nearest neighbour index of its key. Documents whose
+// key is not an array of numbers of the dimension of the index are
+// not indexed.
+type vectorIndex struct {
+	name    string
+	key     expression.Expression
+	indexer *vectorIndexer
+	graph   *vector.Index
+}
+
+func (index *vectorIndex) add(key string, doc value.Value) {
+	v, err := index.key.Evaluate(doc, expression.NewIndexContext())
+	if err == nil {
+		if vec, ok := vector.FromValue(v); ok && index.graph.Add(key, vec) {
+			return
+		}
+	}
+	index.graph.Remove(key)
+}
+
+func (index *vectorIndex) KeyspaceId() string {
+	return index.indexer.KeyspaceId()
+}
+
+func (index *vectorIndex) Id() string {
+	return index.Name()
+}
+
+func (index *vectorIndex) Name() string {
+	return index.name
+}
+
+func (index *vectorIndex) Type() datastore.IndexType {
+	return datastore.VECTOR
+}
+
+func (index *vectorIndex) Indexer() datastore.Indexer {
+	return index.indexer
+}
+
+func (index *vectorIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (index *vectorIndex) RangeKey() expression.Expressions {
+	return expression.Expressions{index.key}
+}
+
+func (index *vectorIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (index *vectorIndex) IsPrimary() bool {
+	return false
+}
+
+func (index *vectorIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (index *vectorIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (index *vectorIndex) Drop(requestId string) errors.Error {
+	index.indexer.Lock()
+	defer index.indexer.Unlock()
+
+	delete(index.indexer.indexes, index.name)
+	return nil
+}
+
+func (index *vectorIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	conn.Error(errors.NewFileNotSupported(nil, "Vector indexes can only be searched for nearest neighbours."))
+}
+
+func (index *vectorIndex) Metric() string {
+	return index.graph.Metric().String()
+}
+
+func (index *vectorIndex) Nearest(requestId string, key int, target value.Value, k int64,
+	cons datastore.ScanConsistency, scanVector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	if key != 0 {
+		conn.Error(errors.NewFileIdxNotFound(nil, index.name))
+		return
+	}
+
+	query, ok := vector.FromValue(target)
+	if !ok || k <= 0 {
+		return
+	}
+
+	for _, hit := range index.graph.Search(query, int(k)) {
+		entry := datastore.IndexEntry{
+			PrimaryKey: hit.Key,
+			MetaData:   value.NewValue(map[string]interface{}{"distance": hit.Distance}),
+		}
+
+		select {
+		case conn.EntryChannel() <- &entry:
+		case <-conn.StopChannel():
+			return
+		}
+	}
+}
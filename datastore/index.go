@@ -26,6 +26,7 @@ const (
 	GSI     IndexType = "gsi"     // global secondary index
 	FTS     IndexType = "fts"     // full text index
 	SYSTEM  IndexType = "system"  // system keyspace indexes
+	VECTOR  IndexType = "vector"  // approximate nearest neighbour index
 )
 
 const (
@@ -137,6 +138,33 @@ type CountIndex interface {
 	Count(span *Span, cons ScanConsistency, vector timestamp.Vector) (int64, errors.Error)
 }
 
+/*
+FTSIndex is a full text index. A search returns the documents whose
+key at position key of RangeKey() matches the query, by descending
+score. The entries carry the score as MetaData {"score": score}.
+*/
+type FTSIndex interface {
+	Index
+
+	Search(requestId string, key int, query, options value.Value, limit int64,
+		cons ScanConsistency, vector timestamp.Vector, conn *IndexConnection)
+}
+
+/*
+VectorIndex is an approximate nearest neighbour index of arrays of
+numbers. A search returns the keys of the k documents whose key at
+position key of RangeKey() is nearest to the target by the metric of
+the index, by ascending distance. The entries carry the distance as
+MetaData {"distance": distance}.
+*/
+type VectorIndex interface {
+	Index
+
+	Metric() string // "l2", "cosine" or "dot"
+	Nearest(requestId string, key int, target value.Value, k int64,
+		cons ScanConsistency, vector timestamp.Vector, conn *IndexConnection)
+}
+
 /*
 PrimaryIndex represents primary key indexes.
 */
@@ -351,6 +379,7 @@ type Indexer3 interface {
 type IndexEntry struct {
 	EntryKey   value.Values
 	PrimaryKey string
+	MetaData   value.Value // index specific data, such as the score of a full text search
 }
 
 type EntryChannel chan *IndexEntry
@@ -54,7 +54,7 @@ func (b *preparedsKeyspace) Count(context datastore.QueryContext) (int64, errors
 	}, func(warn errors.Error) {
 		context.Warning(warn)
 	})
-	return int64(prepareds.CountPrepareds() + count), nil
+	return int64(prepareds.CountPrepareds() + prepareds.CountAdhocs() + count), nil
 }
 
 func (b *preparedsKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
@@ -101,45 +101,62 @@ func (b *preparedsKeyspace) Fetch(keys []string, context datastore.QueryContext,
 		} else {
 
 			// local entry
+			var item value.AnnotatedValue
+
 			prepareds.PreparedDo(localKey, func(entry *prepareds.CacheEntry) {
-				itemMap := map[string]interface{}{
-					"name":            localKey,
-					"uses":            entry.Uses,
-					"statement":       entry.Prepared.Text(),
-					"encoded_plan":    entry.Prepared.EncodedPlan(),
-					"indexApiVersion": entry.Prepared.IndexApiVersion(),
-					"featuresControl": entry.Prepared.FeatureControls(),
-				}
-				if node != "" {
-					itemMap["node"] = node
-				}
-				if entry.Uses > 0 {
-					itemMap["lastUse"] = entry.LastUse.String()
-					itemMap["avgElapsedTime"] = (time.Duration(entry.RequestTime) /
-						time.Duration(entry.Uses)).String()
-					itemMap["avgServiceTime"] = (time.Duration(entry.ServiceTime) /
-						time.Duration(entry.Uses)).String()
-					itemMap["minElapsedTime"] = time.Duration(entry.MinRequestTime).String()
-					itemMap["minServiceTime"] = time.Duration(entry.MinServiceTime).String()
-					itemMap["maxElapsedTime"] = time.Duration(entry.MaxRequestTime).String()
-					itemMap["maxServiceTime"] = time.Duration(entry.MaxServiceTime).String()
-				}
-				item := value.NewAnnotatedValue(itemMap)
-				bytes, _ := json.Marshal(entry.Prepared.Operator)
-				item.SetAttachment("meta", map[string]interface{}{
-					"id":   key,
-					"plan": bytes,
+				item = preparedItem(key, localKey, node, entry)
+			})
+			if item == nil {
+				prepareds.AdhocDo(localKey, func(entry *prepareds.AdhocEntry) {
+					item = preparedItem(key, localKey, node, &entry.CacheEntry)
+					item.SetField("adhoc", true)
+					item.SetField("hits", entry.Hits)
+					item.SetField("misses", entry.Misses)
 				})
+			}
+			if item != nil {
 				rv = append(rv, value.AnnotatedPair{
 					Name:  key,
 					Value: item,
 				})
-			})
+			}
 		}
 	}
 	return rv, errs
 }
 
+func preparedItem(key, localKey, node string, entry *prepareds.CacheEntry) value.AnnotatedValue {
+	itemMap := map[string]interface{}{
+		"name":            localKey,
+		"uses":            entry.Uses,
+		"statement":       entry.Prepared.Text(),
+		"encoded_plan":    entry.Prepared.EncodedPlan(),
+		"indexApiVersion": entry.Prepared.IndexApiVersion(),
+		"featuresControl": entry.Prepared.FeatureControls(),
+	}
+	if node != "" {
+		itemMap["node"] = node
+	}
+	if entry.Uses > 0 {
+		itemMap["lastUse"] = entry.LastUse.String()
+		itemMap["avgElapsedTime"] = (time.Duration(entry.RequestTime) /
+			time.Duration(entry.Uses)).String()
+		itemMap["avgServiceTime"] = (time.Duration(entry.ServiceTime) /
+			time.Duration(entry.Uses)).String()
+		itemMap["minElapsedTime"] = time.Duration(entry.MinRequestTime).String()
+		itemMap["minServiceTime"] = time.Duration(entry.MinServiceTime).String()
+		itemMap["maxElapsedTime"] = time.Duration(entry.MaxRequestTime).String()
+		itemMap["maxServiceTime"] = time.Duration(entry.MaxServiceTime).String()
+	}
+	item := value.NewAnnotatedValue(itemMap)
+	bytes, _ := json.Marshal(entry.Prepared.Operator)
+	item.SetAttachment("meta", map[string]interface{}{
+		"id":   key,
+		"plan": bytes,
+	})
+	return item
+}
+
 func (b *preparedsKeyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
 	// FIXME
 	return nil, errors.NewSystemNotImplementedError(nil, "")
@@ -178,6 +195,9 @@ func (b *preparedsKeyspace) Delete(deletes []string, context datastore.QueryCont
 			// local entry
 		} else {
 			err = prepareds.DeletePrepared(localKey)
+			if err != nil && prepareds.DeleteAdhoc(localKey) == nil {
+				err = nil
+			}
 		}
 		if err != nil {
 			deleted := make([]string, i)
@@ -299,7 +319,7 @@ func (pi *preparedsIndex) Scan(requestId string, span *datastore.Span, distinct
 			// now that the node name can change in flight, use a consistent one across the scan
 			whoAmI := distributed.RemoteAccess().WhoAmI()
 			if spanEvaluator.key() == whoAmI {
-				prepareds.PreparedsForeach(func(name string, prepared *prepareds.CacheEntry) bool {
+				preparedsForeach(func(name string) bool {
 					entry = &datastore.IndexEntry{
 						PrimaryKey: distributed.RemoteAccess().MakeKey(whoAmI, name),
 						EntryKey:   value.Values{value.NewValue(whoAmI)},
@@ -331,7 +351,7 @@ func (pi *preparedsIndex) Scan(requestId string, span *datastore.Span, distinct
 				if spanEvaluator.evaluate(node) {
 					if node == whoAmI {
 
-						prepareds.PreparedsForeach(func(name string, prepared *prepareds.CacheEntry) bool {
+						preparedsForeach(func(name string) bool {
 							entry = &datastore.IndexEntry{
 								PrimaryKey: distributed.RemoteAccess().MakeKey(whoAmI, name),
 								EntryKey:   value.Values{value.NewValue(whoAmI)},
@@ -369,7 +389,7 @@ func (pi *preparedsIndex) ScanEntries(requestId string, limit int64, cons datast
 
 	// now that the node name can change in flight, use a consistent one across the scan
 	whoAmI := distributed.RemoteAccess().WhoAmI()
-	prepareds.PreparedsForeach(func(name string, prepared *prepareds.CacheEntry) bool {
+	preparedsForeach(func(name string) bool {
 		entry = &datastore.IndexEntry{PrimaryKey: distributed.RemoteAccess().MakeKey(whoAmI, name)}
 		return true
 	}, func() bool {
@@ -382,3 +402,19 @@ func (pi *preparedsIndex) ScanEntries(requestId string, limit int64, cons datast
 		conn.Warning(warn)
 	})
 }
+
+// scan both prepared statements and cached ad-hoc plans
+func preparedsForeach(nonBlocking func(string) bool, blocking func() bool) {
+	cont := true
+	prepareds.PreparedsForeach(func(name string, prepared *prepareds.CacheEntry) bool {
+		return nonBlocking(name)
+	}, func() bool {
+		cont = blocking()
+		return cont
+	})
+	if cont {
+		prepareds.AdhocsForeach(func(name string, adhoc *prepareds.AdhocEntry) bool {
+			return nonBlocking(name)
+		}, blocking)
+	}
+}
@@ -0,0 +1,201 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/sequences"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+type sequencesKeyspace struct {
+	keyspaceBase
+	name    string
+	indexer datastore.Indexer
+}
+
+func (b *sequencesKeyspace) Release() {
+}
+
+func (b *sequencesKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *sequencesKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *sequencesKeyspace) Name() string {
+	return b.name
+}
+
+func (b *sequencesKeyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	return int64(sequences.CountSequences()), nil
+}
+
+func (b *sequencesKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *sequencesKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *sequencesKeyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	wanted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		wanted[k] = true
+	}
+
+	rv := make([]value.AnnotatedPair, 0, len(keys))
+	sequences.SequencesForeach(func(key string, sequence *sequences.Sequence) bool {
+		if !wanted[key] {
+			return true
+		}
+
+		itemMap := map[string]interface{}{
+			"name":      sequence.Name,
+			"namespace": sequence.Namespace,
+			"start":     sequence.Start,
+			"increment": sequence.Increment,
+			"min":       sequence.Min,
+			"max":       sequence.Max,
+			"cache":     sequence.Cache,
+			"cycle":     sequence.Cycle,
+		}
+
+		// the first number of the next block to be reserved
+		if sequence.Exhausted {
+			itemMap["exhausted"] = true
+		} else {
+			itemMap["nextBlock"] = sequence.Base
+		}
+
+		item := value.NewAnnotatedValue(itemMap)
+		item.SetAttachment("meta", map[string]interface{}{
+			"id": key,
+		})
+
+		rv = append(rv, value.AnnotatedPair{
+			Name:  key,
+			Value: item,
+		})
+		return true
+	})
+
+	return rv, nil
+}
+
+func (b *sequencesKeyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *sequencesKeyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *sequencesKeyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *sequencesKeyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func newSequencesKeyspace(p *namespace) (*sequencesKeyspace, errors.Error) {
+	b := new(sequencesKeyspace)
+	setKeyspaceBase(&b.keyspaceBase, p)
+	b.name = KEYSPACE_NAME_SEQUENCES
+
+	primary := &sequencesIndex{name: "#primary", keyspace: b}
+	b.indexer = newSystemIndexer(b, primary)
+	setIndexBase(&primary.indexBase, b.indexer)
+
+	return b, nil
+}
+
+type sequencesIndex struct {
+	indexBase
+	name     string
+	keyspace *sequencesKeyspace
+}
+
+func (pi *sequencesIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *sequencesIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *sequencesIndex) Name() string {
+	return pi.name
+}
+
+func (pi *sequencesIndex) Type() datastore.IndexType {
+	return datastore.SYSTEM
+}
+
+func (pi *sequencesIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *sequencesIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *sequencesIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *sequencesIndex) IsPrimary() bool {
+	return true
+}
+
+func (pi *sequencesIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *sequencesIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *sequencesIndex) Drop(requestId string) errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *sequencesIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+
+	pi.ScanEntries(requestId, limit, cons, vector, conn)
+}
+
+func (pi *sequencesIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	var numProduced int64
+	sequences.SequencesForeach(func(key string, sequence *sequences.Sequence) bool {
+		if limit > 0 && numProduced >= limit {
+			return false
+		}
+
+		entry := datastore.IndexEntry{PrimaryKey: key}
+		if !sendSystemKey(conn, &entry) {
+			return false
+		}
+		numProduced++
+		return true
+	})
+}
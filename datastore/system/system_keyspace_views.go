@@ -0,0 +1,198 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"time"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+	"github.com/couchbase/query/views"
+)
+
+type viewsKeyspace struct {
+	keyspaceBase
+	name    string
+	indexer datastore.Indexer
+}
+
+func (b *viewsKeyspace) Release() {
+}
+
+func (b *viewsKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *viewsKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *viewsKeyspace) Name() string {
+	return b.name
+}
+
+func (b *viewsKeyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	return int64(views.CountViews()), nil
+}
+
+func (b *viewsKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *viewsKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *viewsKeyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	wanted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		wanted[k] = true
+	}
+
+	rv := make([]value.AnnotatedPair, 0, len(keys))
+	views.ViewsForeach(func(key string, view *views.View) bool {
+		if !wanted[key] {
+			return true
+		}
+
+		itemMap := map[string]interface{}{
+			"name":         view.Name,
+			"namespace":    view.Namespace,
+			"definition":   view.Text,
+			"materialized": view.Materialized,
+		}
+		if view.Materialized {
+			itemMap["incremental"] = view.Incremental
+			if !view.LastRefresh.IsZero() {
+				itemMap["lastRefresh"] = view.LastRefresh.Format(time.RFC3339Nano)
+			}
+		}
+
+		item := value.NewAnnotatedValue(itemMap)
+		item.SetAttachment("meta", map[string]interface{}{
+			"id": key,
+		})
+
+		rv = append(rv, value.AnnotatedPair{
+			Name:  key,
+			Value: item,
+		})
+		return true
+	})
+
+	return rv, nil
+}
+
+func (b *viewsKeyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *viewsKeyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *viewsKeyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *viewsKeyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func newViewsKeyspace(p *namespace) (*viewsKeyspace, errors.Error) {
+	b := new(viewsKeyspace)
+	setKeyspaceBase(&b.keyspaceBase, p)
+	b.name = KEYSPACE_NAME_VIEWS
+
+	primary := &viewsIndex{name: "#primary", keyspace: b}
+	b.indexer = newSystemIndexer(b, primary)
+	setIndexBase(&primary.indexBase, b.indexer)
+
+	return b, nil
+}
+
+type viewsIndex struct {
+	indexBase
+	name     string
+	keyspace *viewsKeyspace
+}
+
+func (pi *viewsIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *viewsIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *viewsIndex) Name() string {
+	return pi.name
+}
+
+func (pi *viewsIndex) Type() datastore.IndexType {
+	return datastore.SYSTEM
+}
+
+func (pi *viewsIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *viewsIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *viewsIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *viewsIndex) IsPrimary() bool {
+	return true
+}
+
+func (pi *viewsIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *viewsIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *viewsIndex) Drop(requestId string) errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *viewsIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+
+	pi.ScanEntries(requestId, limit, cons, vector, conn)
+}
+
+func (pi *viewsIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	var numProduced int64
+	views.ViewsForeach(func(key string, view *views.View) bool {
+		if limit > 0 && numProduced >= limit {
+			return false
+		}
+
+		entry := datastore.IndexEntry{PrimaryKey: key}
+		if !sendSystemKey(conn, &entry) {
+			return false
+		}
+		numProduced++
+		return true
+	})
+}
@@ -154,5 +154,17 @@ func (p *namespace) loadKeyspaces() (e errors.Error) {
 	}
 	p.keyspaces[applicableRoles.Name()] = applicableRoles
 
+	views, e := newViewsKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[views.Name()] = views
+
+	sequences, e := newSequencesKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[sequences.Name()] = sequences
+
 	return nil
 }
@@ -149,6 +149,11 @@ func NewCompletedQualifierInvalidArgument(what string, cond interface{}) Error {
 		InternalMsg: "Completed requests qualifier " + what + " cannot accept argument " + condString, InternalCaller: CallerN(1)}
 }
 
+func NewCompletedLogError(e error, path string) Error {
+	return &err{level: EXCEPTION, ICode: 2230, IKey: "admin.accounting.completed_log", ICause: e,
+		InternalMsg: "Cannot open completed requests log " + path, InternalCaller: CallerN(1)}
+}
+
 func NewAdminBadServicePort(port string) Error {
 	return &err{level: EXCEPTION, ICode: 2210, IKey: "admin.clustering.bad_port",
 		InternalMsg: "Invalid service port: " + port, InternalCaller: CallerN(1)}
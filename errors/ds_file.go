@@ -72,3 +72,8 @@ func NewFilePrimaryIdxNoDropError(e error, msg string) Error {
 	return &err{level: EXCEPTION, ICode: 15011, IKey: "datastore.file.primary_idx_no_drop", ICause: e,
 		InternalMsg: "Primary Index cannot be dropped " + msg, InternalCaller: CallerN(1)}
 }
+
+func NewFileIndexOptionError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 15012, IKey: "datastore.file.index_option", ICause: e,
+		InternalMsg: "Invalid index option " + msg, InternalCaller: CallerN(1)}
+}
@@ -118,6 +118,12 @@ func NewUpdateMissingClone() Error {
 		InternalMsg: "Missing UPDATE clone.", InternalCaller: CallerN(1)}
 }
 
+func NewMergeMultiUpdateError(key string) Error {
+	return &err{level: EXCEPTION, ICode: 5130, IKey: "execution.merge_multiple_update",
+		InternalMsg:    fmt.Sprintf("MERGE matched document %s with more than one source object.", key),
+		InternalCaller: CallerN(1)}
+}
+
 func NewUnnestInvalidPosition(pos interface{}) Error {
 	return &err{level: EXCEPTION, ICode: 5180, IKey: "execution.unnest_invalid_position",
 		InternalMsg: fmt.Sprintf("Invalid UNNEST position of type %T.", pos), InternalCaller: CallerN(1)}
@@ -186,3 +192,32 @@ func NewUserWithNoRoles(user string) Error {
 		InternalMsg:    fmt.Sprintf("User %s has no roles. Connecting with this user may not be possible", user),
 		InternalCaller: CallerN(1)}
 }
+
+func NewSchemaViolationError(key, keyspace, violations string) Error {
+	return &err{level: EXCEPTION, ICode: 5290, IKey: "execution.schema_violation",
+		InternalMsg:    fmt.Sprintf("Document %s violates the validation schema of %s: %s", key, keyspace, violations),
+		InternalCaller: CallerN(1)}
+}
+
+func NewSchemaViolationWarning(key, keyspace, violations string) Error {
+	return &err{level: WARNING, ICode: 5300, IKey: "execution.schema_violation_warning",
+		InternalMsg:    fmt.Sprintf("Document %s violates the validation schema of %s: %s", key, keyspace, violations),
+		InternalCaller: CallerN(1)}
+}
+
+func NewTriggerExecutionError(e error, trigger, key string) Error {
+	return &err{level: EXCEPTION, ICode: 5310, IKey: "execution.trigger_failed", ICause: e,
+		InternalMsg:    fmt.Sprintf("Trigger %s failed for document %s", trigger, key),
+		InternalCaller: CallerN(1)}
+}
+
+func NewTriggerDepthError(trigger string, depth int) Error {
+	return &err{level: EXCEPTION, ICode: 5320, IKey: "execution.trigger_depth",
+		InternalMsg:    fmt.Sprintf("Trigger %s exceeds the maximum trigger depth of %d", trigger, depth),
+		InternalCaller: CallerN(1)}
+}
+
+func NewSearchQueryError(e error, query string) Error {
+	return &err{level: EXCEPTION, ICode: 5330, IKey: "execution.search_query", ICause: e,
+		InternalMsg: fmt.Sprintf("Invalid search query %s", query), InternalCaller: CallerN(1)}
+}
@@ -102,6 +102,13 @@ func NewUseKeysError(termType string, iKey string) Error {
 		InternalMsg: fmt.Sprintf("%s term should not have USE KEYS", termType), InternalCaller: CallerN(1)}
 }
 
+const PREPARED_STORE_ERROR = 4120
+
+func NewPreparedStoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: PREPARED_STORE_ERROR, IKey: "plan.build_prepared.store",
+		ICause: e, InternalMsg: fmt.Sprintf("Prepared statement store error: %s", msg), InternalCaller: CallerN(1)}
+}
+
 const NOT_GROUP_KEY_OR_AGG = 4210
 
 func NewNotGroupKeyOrAggError(expr string) Error {
@@ -153,9 +160,93 @@ func NewNoAnsiJoinError(alias, op string) Error {
 		InternalMsg: fmt.Sprintf("No index available for ANSI join term %s", alias), InternalCaller: CallerN(1)}
 }
 
+const OUTER_JOIN_CORRELATED = 4331
+
+func NewOuterJoinCorrelatedError(alias string) Error {
+	return &err{level: EXCEPTION, ICode: OUTER_JOIN_CORRELATED, IKey: "plan.ansi_join.outer_correlated",
+		InternalMsg: fmt.Sprintf("Right-hand side %s of RIGHT or FULL OUTER JOIN cannot refer to the left-hand side", alias), InternalCaller: CallerN(1)}
+}
+
 const PARTITION_INDEX_NOT_SUPPORTED = 4340
 
 func NewPartitionIndexNotSupportedError() Error {
 	return &err{level: EXCEPTION, ICode: PARTITION_INDEX_NOT_SUPPORTED, IKey: "plan.partition_index_not_supported",
 		InternalMsg: fmt.Sprintf("PARTITION index is not supported by indexer."), InternalCaller: CallerN(1)}
 }
+
+const VIEW_ALREADY_EXISTS = 4350
+
+func NewViewAlreadyExistsError(name string) Error {
+	return &err{level: EXCEPTION, ICode: VIEW_ALREADY_EXISTS, IKey: "plan.view.already_exists",
+		InternalMsg: fmt.Sprintf("View %s already exists.", name), InternalCaller: CallerN(1)}
+}
+
+const NO_SUCH_VIEW = 4351
+
+func NewNoSuchViewError(name string) Error {
+	return &err{level: EXCEPTION, ICode: NO_SUCH_VIEW, IKey: "plan.view.not_found",
+		InternalMsg: fmt.Sprintf("View %s does not exist.", name), InternalCaller: CallerN(1)}
+}
+
+const VIEW_ERROR = 4352
+
+func NewViewError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: VIEW_ERROR, IKey: "plan.view.error", ICause: e,
+		InternalMsg: fmt.Sprintf("View error: %s", msg), InternalCaller: CallerN(1)}
+}
+
+const SEQUENCE_ALREADY_EXISTS = 4360
+
+func NewSequenceAlreadyExistsError(name string) Error {
+	return &err{level: EXCEPTION, ICode: SEQUENCE_ALREADY_EXISTS, IKey: "plan.sequence.already_exists",
+		InternalMsg: fmt.Sprintf("Sequence %s already exists.", name), InternalCaller: CallerN(1)}
+}
+
+const NO_SUCH_SEQUENCE = 4361
+
+func NewNoSuchSequenceError(name string) Error {
+	return &err{level: EXCEPTION, ICode: NO_SUCH_SEQUENCE, IKey: "plan.sequence.not_found",
+		InternalMsg: fmt.Sprintf("Sequence %s does not exist.", name), InternalCaller: CallerN(1)}
+}
+
+const SEQUENCE_ERROR = 4362
+
+func NewSequenceError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: SEQUENCE_ERROR, IKey: "plan.sequence.error", ICause: e,
+		InternalMsg: fmt.Sprintf("Sequence error: %s", msg), InternalCaller: CallerN(1)}
+}
+
+const NO_SUCH_VALIDATION = 4370
+
+func NewNoSuchValidationError(name string) Error {
+	return &err{level: EXCEPTION, ICode: NO_SUCH_VALIDATION, IKey: "plan.validation.not_found",
+		InternalMsg: fmt.Sprintf("Keyspace %s has no validation schema.", name), InternalCaller: CallerN(1)}
+}
+
+const VALIDATION_ERROR = 4371
+
+func NewValidationError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: VALIDATION_ERROR, IKey: "plan.validation.error", ICause: e,
+		InternalMsg: fmt.Sprintf("Validation error: %s", msg), InternalCaller: CallerN(1)}
+}
+
+const TRIGGER_ALREADY_EXISTS = 4380
+
+func NewTriggerAlreadyExistsError(name string) Error {
+	return &err{level: EXCEPTION, ICode: TRIGGER_ALREADY_EXISTS, IKey: "plan.trigger.already_exists",
+		InternalMsg: fmt.Sprintf("Trigger %s already exists.", name), InternalCaller: CallerN(1)}
+}
+
+const NO_SUCH_TRIGGER = 4381
+
+func NewNoSuchTriggerError(name string) Error {
+	return &err{level: EXCEPTION, ICode: NO_SUCH_TRIGGER, IKey: "plan.trigger.not_found",
+		InternalMsg: fmt.Sprintf("Trigger %s does not exist.", name), InternalCaller: CallerN(1)}
+}
+
+const TRIGGER_ERROR = 4382
+
+func NewTriggerError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: TRIGGER_ERROR, IKey: "plan.trigger.error", ICause: e,
+		InternalMsg: fmt.Sprintf("Trigger error: %s", msg), InternalCaller: CallerN(1)}
+}
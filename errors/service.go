@@ -108,3 +108,13 @@ func NewServiceErrorHttpMethod(method string) Error {
 	return &err{level: EXCEPTION, ICode: 1170, IKey: "service.io.request.method",
 		InternalMsg: fmt.Sprintf("Unsupported method %s", method), InternalCaller: CallerN(1)}
 }
+
+func NewServiceErrorProtocol(msg string) Error {
+	return &err{level: EXCEPTION, ICode: 1180, IKey: "service.io.protocol",
+		InternalMsg: "Protocol error: " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewServiceErrorQueueFull() Error {
+	return &err{level: EXCEPTION, ICode: 1190, IKey: "service.io.queue_full",
+		InternalMsg: "The request queue is full", InternalCaller: CallerN(1)}
+}
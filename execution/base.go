@@ -70,6 +70,12 @@ type base struct {
 	inDocs         int64
 	outDocs        int64
 	phaseSwitches  int64
+	itemsScanned   int64 // index entries read
+	itemsFetched   int64 // documents fetched
+	heldItems      int64 // items held in memory
+	heldSize       int64 // estimated size of the items held, when analyzed
+	maxHeldItems   int64
+	maxHeldSize    int64
 	stopped        bool
 	isRoot         bool
 	bit            uint8
@@ -192,6 +198,7 @@ func (this *base) baseReopen(context *Context) {
 	this.contextTracked = nil
 	this.childrenLeft = 0
 	this.primed = false
+	this.releaseItems()
 	this.stopped = false
 	this.serialized = false
 	this.doSend = parallelSend
@@ -425,7 +432,10 @@ func (this *base) getItemEntry(channel datastore.EntryChannel) (*datastore.Index
 		if ok {
 
 			// getItemEntry does not keep track of
-			// incoming documents
+			// incoming documents, only of index entries
+			if item != nil {
+				this.addItemsScanned(1)
+			}
 			return item, true
 		}
 
@@ -892,10 +902,46 @@ func (this *base) addOutDocs(d int64) {
 	go_atomic.AddInt64((*int64)(&this.outDocs), d)
 }
 
+func (this *base) addItemsScanned(d int64) {
+	go_atomic.AddInt64((*int64)(&this.itemsScanned), d)
+}
+
+func (this *base) addItemsFetched(d int64) {
+	go_atomic.AddInt64((*int64)(&this.itemsFetched), d)
+}
+
+// blocking operators account for the items they keep in memory:
+// their number, and their estimated size when the statement is run
+// by EXPLAIN ANALYZE
+func (this *base) holdItem(item value.Value, context *Context) {
+	this.heldItems++
+	if this.heldItems > this.maxHeldItems {
+		this.maxHeldItems = this.heldItems
+	}
+	if context.analyze {
+		this.heldSize += estimatedSize(item)
+		if this.heldSize > this.maxHeldSize {
+			this.maxHeldSize = this.heldSize
+		}
+	}
+}
+
+func (this *base) releaseItem(item value.Value, context *Context) {
+	this.heldItems--
+	if context.analyze {
+		this.heldSize -= estimatedSize(item)
+	}
+}
+
+func (this *base) releaseItems() {
+	this.heldItems = 0
+	this.heldSize = 0
+}
+
 // profile marshaller
 func (this *base) marshalTimes(r map[string]interface{}) {
 	var d time.Duration
-	stats := make(map[string]interface{}, 6)
+	stats := make(map[string]interface{}, 10)
 
 	if this.inDocs != 0 {
 		stats["#itemsIn"] = this.inDocs
@@ -906,6 +952,18 @@ func (this *base) marshalTimes(r map[string]interface{}) {
 	if this.phaseSwitches != 0 {
 		stats["#phaseSwitches"] = this.phaseSwitches
 	}
+	if this.itemsScanned != 0 {
+		stats["#itemsScanned"] = this.itemsScanned
+	}
+	if this.itemsFetched != 0 {
+		stats["#itemsFetched"] = this.itemsFetched
+	}
+	if this.maxHeldItems != 0 {
+		stats["#itemsHeld"] = this.maxHeldItems
+	}
+	if this.maxHeldSize != 0 {
+		stats["usedMemory"] = this.maxHeldSize
+	}
 
 	execTime := this.execTime
 	chanTime := this.chanTime
@@ -955,6 +1013,10 @@ func (this *base) accrueTime(copy *base) {
 	this.inDocs += copy.inDocs
 	this.outDocs += copy.outDocs
 	this.phaseSwitches += copy.phaseSwitches
+	this.itemsScanned += copy.itemsScanned
+	this.itemsFetched += copy.itemsFetched
+	this.maxHeldItems += copy.maxHeldItems
+	this.maxHeldSize += copy.maxHeldSize
 	this.execTime += copy.execTime
 	this.chanTime += copy.chanTime
 	this.servTime += copy.servTime
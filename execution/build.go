@@ -114,6 +114,28 @@ func (this *builder) VisitIndexScan3(plan *plan.IndexScan3) (interface{}, error)
 	return NewIndexScan3(plan, this.context), nil
 }
 
+func (this *builder) VisitIndexFtsSearch(plan *plan.IndexFtsSearch) (interface{}, error) {
+	// Remember the bucket of the scanned index.
+	if this.scannedIndexes != nil {
+		keyspaceTerm := plan.Term()
+		scannedIndex := scannedIndex{keyspaceTerm.Namespace(), keyspaceTerm.Keyspace()}
+		this.scannedIndexes[scannedIndex] = true
+	}
+
+	return NewIndexFtsSearch(plan, this.context), nil
+}
+
+func (this *builder) VisitIndexVectorScan(plan *plan.IndexVectorScan) (interface{}, error) {
+	// Remember the bucket of the scanned index.
+	if this.scannedIndexes != nil {
+		keyspaceTerm := plan.Term()
+		scannedIndex := scannedIndex{keyspaceTerm.Namespace(), keyspaceTerm.Keyspace()}
+		this.scannedIndexes[scannedIndex] = true
+	}
+
+	return NewIndexVectorScan(plan, this.context), nil
+}
+
 func (this *builder) VisitIndexCountScan(plan *plan.IndexCountScan) (interface{}, error) {
 	// Remember the bucket of the scanned index.
 	if this.scannedIndexes != nil {
@@ -250,6 +272,16 @@ func (this *builder) VisitNLJoin(plan *plan.NLJoin) (interface{}, error) {
 	return NewNLJoin(plan, this.context, c.(Operator)), nil
 }
 
+func (this *builder) VisitHashJoin(plan *plan.HashJoin) (interface{}, error) {
+	child := plan.Child()
+	c, e := child.Accept(this)
+	if e != nil {
+		return nil, e
+	}
+
+	return NewHashJoin(plan, this.context, c.(Operator)), nil
+}
+
 func (this *builder) VisitNest(plan *plan.Nest) (interface{}, error) {
 	return NewNest(plan, this.context), nil
 }
@@ -409,7 +441,7 @@ func (this *builder) VisitSendUpdate(plan *plan.SendUpdate) (interface{}, error)
 
 // Merge
 func (this *builder) VisitMerge(plan *plan.Merge) (interface{}, error) {
-	var update, delete, insert Operator
+	var update, delete, insert, sourceUpdate, sourceDelete Operator
 
 	if plan.Update() != nil {
 		op, e := plan.Update().Accept(this)
@@ -435,7 +467,23 @@ func (this *builder) VisitMerge(plan *plan.Merge) (interface{}, error) {
 		insert = op.(Operator)
 	}
 
-	return NewMerge(plan, this.context, update, delete, insert), nil
+	if plan.SourceUpdate() != nil {
+		op, e := plan.SourceUpdate().Accept(this)
+		if e != nil {
+			return nil, e
+		}
+		sourceUpdate = op.(Operator)
+	}
+
+	if plan.SourceDelete() != nil {
+		op, e := plan.SourceDelete().Accept(this)
+		if e != nil {
+			return nil, e
+		}
+		sourceDelete = op.(Operator)
+	}
+
+	return NewMerge(plan, this.context, update, delete, insert, sourceUpdate, sourceDelete), nil
 }
 
 // Alias
@@ -540,6 +588,51 @@ func (this *builder) VisitBuildIndexes(plan *plan.BuildIndexes) (interface{}, er
 	return NewBuildIndexes(plan, this.context), nil
 }
 
+// CreateView
+func (this *builder) VisitCreateView(plan *plan.CreateView) (interface{}, error) {
+	return NewCreateView(plan, this.context), nil
+}
+
+// DropView
+func (this *builder) VisitDropView(plan *plan.DropView) (interface{}, error) {
+	return NewDropView(plan, this.context), nil
+}
+
+// RefreshView
+func (this *builder) VisitRefreshView(plan *plan.RefreshView) (interface{}, error) {
+	return NewRefreshView(plan, this.context), nil
+}
+
+// CreateSequence
+func (this *builder) VisitCreateSequence(plan *plan.CreateSequence) (interface{}, error) {
+	return NewCreateSequence(plan, this.context), nil
+}
+
+// AlterSequence
+func (this *builder) VisitAlterSequence(plan *plan.AlterSequence) (interface{}, error) {
+	return NewAlterSequence(plan, this.context), nil
+}
+
+// DropSequence
+func (this *builder) VisitDropSequence(plan *plan.DropSequence) (interface{}, error) {
+	return NewDropSequence(plan, this.context), nil
+}
+
+// AlterKeyspace
+func (this *builder) VisitAlterKeyspace(plan *plan.AlterKeyspace) (interface{}, error) {
+	return NewAlterKeyspace(plan, this.context), nil
+}
+
+// CreateTrigger
+func (this *builder) VisitCreateTrigger(plan *plan.CreateTrigger) (interface{}, error) {
+	return NewCreateTrigger(plan, this.context), nil
+}
+
+// DropTrigger
+func (this *builder) VisitDropTrigger(plan *plan.DropTrigger) (interface{}, error) {
+	return NewDropTrigger(plan, this.context), nil
+}
+
 // Prepare
 func (this *builder) VisitPrepare(plan *plan.Prepare) (interface{}, error) {
 	return NewPrepare(plan, this.context, plan.Prepared()), nil
@@ -547,7 +640,16 @@ func (this *builder) VisitPrepare(plan *plan.Prepare) (interface{}, error) {
 
 // Explain
 func (this *builder) VisitExplain(plan *plan.Explain) (interface{}, error) {
-	return NewExplain(plan, this.context), nil
+	if !plan.Analyze() {
+		return NewExplain(plan, this.context, nil), nil
+	}
+
+	child, err := plan.Operator().Accept(this)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExplain(plan, this.context, child.(Operator)), nil
 }
 
 // Infer
@@ -24,6 +24,7 @@ import (
 	"github.com/couchbase/query/logging"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/planner"
+	"github.com/couchbase/query/sequences"
 	"github.com/couchbase/query/timestamp"
 	"github.com/couchbase/query/value"
 )
@@ -39,6 +40,7 @@ const (
 	JOIN
 	INDEX_JOIN
 	NL_JOIN
+	HASH_JOIN
 	NEST
 	INDEX_NEST
 	NL_NEST
@@ -73,6 +75,7 @@ var _PHASE_NAMES = []string{
 	JOIN:         "join",
 	INDEX_JOIN:   "indexJoin",
 	NL_JOIN:      "nestedLoopJoin",
+	HASH_JOIN:    "hashJoin",
 	NEST:         "nest",
 	INDEX_NEST:   "indexNest",
 	NL_NEST:      "nestedLoopNest",
@@ -136,8 +139,12 @@ type Context struct {
 	prepared           *plan.Prepared
 	subplans           *subqueryMap
 	subresults         *subqueryMap
+	sequenceValues     map[string]value.Value
+	triggerDepth       int
+	triggerPlans       *triggerPlans
 	httpRequest        *http.Request
 	authenticatedUsers auth.AuthenticatedUsers
+	analyze            bool // run by EXPLAIN ANALYZE
 	mutex              sync.RWMutex
 }
 
@@ -401,6 +408,45 @@ func (this *Context) EvaluateSubquery(query *algebra.Select, parent value.Value)
 	return results, nil
 }
 
+// NEXT VALUE and PREV VALUE FOR a sequence
+// PREV VALUE returns the number last handed out to this request, if
+// any, so that concurrent requests do not see each other's numbers
+func (this *Context) SequenceValue(namespace, name string, next bool) (value.Value, error) {
+	if namespace == "" {
+		namespace = this.namespace
+	}
+	key := namespace + ":" + name
+
+	if !next {
+		this.mutex.RLock()
+		val, ok := this.sequenceValues[key]
+		this.mutex.RUnlock()
+		if ok {
+			return val, nil
+		}
+
+		n, err := sequences.PrevValue(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		return value.NewValue(n), nil
+	}
+
+	n, err := sequences.NextValue(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	val := value.NewValue(n)
+	this.mutex.Lock()
+	if this.sequenceValues == nil {
+		this.sequenceValues = make(map[string]value.Value)
+	}
+	this.sequenceValues[key] = val
+	this.mutex.Unlock()
+	return val, nil
+}
+
 func (this *Context) getSubplans() *subqueryMap {
 	if this.contextSubplans() == nil {
 		this.initSubplans()
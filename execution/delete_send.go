@@ -15,13 +15,15 @@ import (
 
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/triggers"
 	"github.com/couchbase/query/value"
 )
 
 type SendDelete struct {
 	base
-	plan  *plan.SendDelete
-	limit int64
+	plan    *plan.SendDelete
+	limit   int64
+	mutated *mutatedKeys
 }
 
 func NewSendDelete(plan *plan.SendDelete, context *Context) *SendDelete {
@@ -30,6 +32,10 @@ func NewSendDelete(plan *plan.SendDelete, context *Context) *SendDelete {
 		limit: -1,
 	}
 
+	if plan.DistinctKeys() {
+		rv.mutated = newMutatedKeys()
+	}
+
 	newBase(&rv.base, context)
 	rv.execPhase = DELETE
 	rv.output = rv
@@ -41,7 +47,7 @@ func (this *SendDelete) Accept(visitor Visitor) (interface{}, error) {
 }
 
 func (this *SendDelete) Copy() Operator {
-	rv := &SendDelete{plan: this.plan, limit: this.limit}
+	rv := &SendDelete{plan: this.plan, limit: this.limit, mutated: this.mutated}
 	this.base.copy(&rv.base)
 	return rv
 }
@@ -51,6 +57,30 @@ func (this *SendDelete) RunOnce(context *Context, parent value.Value) {
 }
 
 func (this *SendDelete) processItem(item value.AnnotatedValue, context *Context) bool {
+	if this.mutated != nil {
+		dv, ok := item.Field(this.plan.Alias())
+		if !ok {
+			context.Error(errors.NewDeleteAliasMissingError(this.plan.Alias()))
+			return false
+		}
+
+		av, ok := dv.(value.AnnotatedValue)
+		if !ok {
+			context.Error(errors.NewDeleteAliasMetadataError(this.plan.Alias()))
+			return false
+		}
+
+		key, ok := this.requireKey(av, context)
+		if !ok {
+			return false
+		}
+
+		// the join found this key already
+		if !this.mutated.add(key) {
+			return true
+		}
+	}
+
 	rv := this.limit != 0 && this.enbatch(item, this, context)
 
 	if this.limit > 0 {
@@ -96,6 +126,10 @@ func (this *SendDelete) flushBatch(context *Context) bool {
 	keys := _STRING_POOL.Get()
 	defer _STRING_POOL.Put(keys)
 
+	before := keyspaceTriggers(this.plan.Keyspace(), triggers.BEFORE, triggers.DELETE)
+	after := keyspaceTriggers(this.plan.Keyspace(), triggers.AFTER, triggers.DELETE)
+	var olds map[string]value.Value
+
 	for _, item := range this.batch {
 		dv, ok := item.Field(this.plan.Alias())
 		if !ok {
@@ -114,6 +148,17 @@ func (this *SendDelete) flushBatch(context *Context) bool {
 			return false
 		}
 
+		if before != nil && !fireTriggers(before, key, av, value.MISSING_VALUE, context) {
+			return false
+		}
+
+		if after != nil {
+			if olds == nil {
+				olds = make(map[string]value.Value, len(this.batch))
+			}
+			olds[key] = av
+		}
+
 		keys = append(keys, key)
 	}
 
@@ -130,6 +175,12 @@ func (this *SendDelete) flushBatch(context *Context) bool {
 		context.Error(e)
 	}
 
+	for _, key := range deleted_keys {
+		if after != nil && !fireTriggers(after, key, olds[key], value.MISSING_VALUE, context) {
+			return false
+		}
+	}
+
 	for _, item := range this.batch {
 		if !this.sendItem(item) {
 			return false
@@ -65,6 +65,7 @@ func (this *Distinct) processItem(item value.AnnotatedValue, context *Context) b
 
 	if !this.set.Has(p.(value.Value)) {
 		this.set.Put(p.(value.Value), item)
+		this.holdItem(item, context)
 		return this.collect || this.sendItem(item)
 	}
 	return true
@@ -11,6 +11,7 @@ package execution
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
@@ -19,12 +20,15 @@ import (
 
 type Explain struct {
 	base
-	plan plan.Operator
+	plan     *plan.Explain
+	child    Operator
+	sequence *Sequence
 }
 
-func NewExplain(plan plan.Operator, context *Context) *Explain {
+func NewExplain(plan *plan.Explain, context *Context, child Operator) *Explain {
 	rv := &Explain{
-		plan: plan,
+		plan:  plan,
+		child: child,
 	}
 
 	newRedirectBase(&rv.base)
@@ -38,6 +42,9 @@ func (this *Explain) Accept(visitor Visitor) (interface{}, error) {
 
 func (this *Explain) Copy() Operator {
 	rv := &Explain{plan: this.plan}
+	if this.child != nil {
+		rv.child = this.child.Copy()
+	}
 	this.base.copy(&rv.base)
 	return rv
 }
@@ -54,7 +61,14 @@ func (this *Explain) RunOnce(context *Context, parent value.Value) {
 			return
 		}
 
-		bytes, err := this.plan.MarshalJSON()
+		var bytes []byte
+		var err error
+		if this.child != nil {
+			bytes, err = this.analyze(context, parent)
+		} else {
+			bytes, err = this.plan.MarshalJSON()
+		}
+
 		if err != nil {
 			context.Fatal(errors.NewExplainError(err, "EXPLAIN: Error marshaling JSON."))
 			return
@@ -66,15 +80,88 @@ func (this *Explain) RunOnce(context *Context, parent value.Value) {
 	})
 }
 
+/*
+EXPLAIN ANALYZE runs the statement to completion, discarding or
+collecting its results, and returns the execution tree, which is
+the plan annotated with the statistics of each operator: items in
+and out, times, index entries scanned and documents fetched, and
+the items held in memory by blocking operators, with their
+estimated size. Operators do not spill to disk, so there are no
+spill statistics.
+*/
+func (this *Explain) analyze(context *Context, parent value.Value) ([]byte, error) {
+	var collect *Collect
+	var sink Operator
+
+	if this.plan.Results() {
+		collect = NewCollect(plan.NewCollect(), context)
+		sink = collect
+	} else {
+		sink = NewDiscard(plan.NewDiscard(), context)
+	}
+
+	this.sequence = NewSequence(plan.NewSequence(), context, this.child, sink)
+	context.analyze = true
+
+	start := time.Now()
+	this.switchPhase(_CHANTIME)
+	this.sequence.RunOnce(context, parent)
+	sink.getBase().waitComplete()
+	this.switchPhase(_EXECTIME)
+
+	stats := map[string]interface{}{
+		"#resultCount": sink.getBase().inDocs,
+		"elapsedTime":  time.Since(start).String(),
+	}
+
+	if mutations := context.MutationCount(); mutations > 0 {
+		stats["#mutationCount"] = mutations
+	}
+
+	if phaseCounts := context.output.FmtPhaseCounts(); phaseCounts != nil {
+		stats["phaseCounts"] = phaseCounts
+	}
+
+	if phaseTimes := context.output.FmtPhaseTimes(); phaseTimes != nil {
+		stats["phaseTimes"] = phaseTimes
+	}
+
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		r["plan"] = this.child
+		r["#stats"] = stats
+		if collect != nil {
+			r["results"] = collect.ValuesOnce()
+		}
+	})
+	return json.Marshal(r)
+}
+
 func (this *Explain) MarshalJSON() ([]byte, error) {
 	r := this.plan.MarshalBase(func(r map[string]interface{}) {
 		this.marshalTimes(r)
 		r["plan"] = this.plan
 	})
+	if this.child != nil {
+		r["~child"] = this.child
+	}
 	return json.Marshal(r)
 }
 
+func (this *Explain) SendStop() {
+	this.baseSendStop()
+	if this.child != nil {
+		this.child.SendStop()
+	}
+}
+
 func (this *Explain) Done() {
 	this.baseDone()
+	if this.sequence != nil {
+		this.sequence.Done()
+	} else if this.child != nil {
+		this.child.Done()
+	}
 	this.plan = nil
+	this.child = nil
+	this.sequence = nil
 }
@@ -117,6 +117,7 @@ func (this *Fetch) flushBatch(context *Context) bool {
 	pairs, errs := this.plan.Keyspace().Fetch(keys, context, this.plan.SubPaths())
 
 	this.switchPhase(_EXECTIME)
+	this.addItemsFetched(int64(len(pairs)))
 
 	fetchOk := true
 	for _, err := range errs {
@@ -14,6 +14,7 @@ import (
 	"fmt"
 
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/value"
 )
@@ -55,7 +56,9 @@ func (this *FinalGroup) RunOnce(context *Context, parent value.Value) {
 func (this *FinalGroup) processItem(item value.AnnotatedValue, context *Context) bool {
 	// Generate the group key
 	var gk string
-	if len(this.plan.Keys()) > 0 {
+	if this.plan.Sets() != nil {
+		gk, _ = item.GetAttachment("grouping_key").(string)
+	} else if len(this.plan.Keys()) > 0 {
 		var e error
 		gk, e = groupKey(item, this.plan.Keys(), context)
 		if e != nil {
@@ -73,6 +76,7 @@ func (this *FinalGroup) processItem(item value.AnnotatedValue, context *Context)
 
 	gv = item
 	this.groups[gk] = gv
+	this.holdItem(gv, context)
 
 	// Compute final aggregates
 	aggregates := gv.GetAttachment("aggregates")
@@ -105,16 +109,51 @@ func (this *FinalGroup) afterItems(context *Context) {
 	}
 
 	// Mo matching inputs, so send default values
-	if len(this.plan.Keys()) == 0 && len(this.groups) == 0 {
-		av := value.NewAnnotatedValue(nil)
-		aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
-		av.SetAttachment("aggregates", aggregates)
-		for _, agg := range this.plan.Aggregates() {
-			aggregates[agg.String()] = agg.Default()
+	if this.plan.Sets() == nil {
+		if len(this.plan.Keys()) == 0 && len(this.groups) == 0 {
+			this.sendItem(this.defaultValue())
+		}
+
+		return
+	}
+
+	// Each empty grouping set, such as the grand total of ROLLUP and
+	// CUBE, has a group even without matching inputs
+	keys := this.plan.Keys()
+	for i, set := range this.plan.Sets() {
+		if len(set) > 0 {
+			continue
+		}
+
+		gk := groupingSetKey(i, set, nil)
+		if this.groups[gk] != nil {
+			continue
+		}
+
+		av := this.defaultValue()
+		rolledUp := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			text := expression.NewCover(key).Text()
+			rolledUp[text] = true
+			av.SetCover(text, value.NULL_VALUE)
+		}
+
+		av.SetAttachment("grouping", rolledUp)
+		if !this.sendItem(av) {
+			return
 		}
+	}
+}
 
-		this.sendItem(av)
+func (this *FinalGroup) defaultValue() value.AnnotatedValue {
+	av := value.NewAnnotatedValue(nil)
+	aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
+	av.SetAttachment("aggregates", aggregates)
+	for _, agg := range this.plan.Aggregates() {
+		aggregates[agg.String()] = agg.Default()
 	}
+
+	return av
 }
 
 func (this *FinalGroup) MarshalJSON() ([]byte, error) {
@@ -54,6 +54,10 @@ func (this *InitialGroup) RunOnce(context *Context, parent value.Value) {
 }
 
 func (this *InitialGroup) processItem(item value.AnnotatedValue, context *Context) bool {
+	if this.plan.Sets() != nil {
+		return this.processGroupingSets(item, context)
+	}
+
 	// Generate the group key
 	var gk string
 	if len(this.plan.Keys()) > 0 {
@@ -70,14 +74,52 @@ func (this *InitialGroup) processItem(item value.AnnotatedValue, context *Contex
 	if gv == nil {
 		gv = item
 		this.groups[gk] = gv
+		this.seedAggregates(gv)
+		this.holdItem(gv, context)
+	}
+
+	return this.cumulate(item, gv, context)
+}
 
-		aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
-		gv.SetAttachment("aggregates", aggregates)
-		for _, agg := range this.plan.Aggregates() {
-			aggregates[agg.String()] = agg.Default()
+// Cumulates the item into the group of each grouping set, so that all
+// the grouping sets are computed in one pass over the input.
+func (this *InitialGroup) processGroupingSets(item value.AnnotatedValue, context *Context) bool {
+	keys := this.plan.Keys()
+	vals, e := groupKeyValues(item, keys, context)
+	if e != nil {
+		context.Fatal(errors.NewEvaluationError(e, "GROUP key"))
+		return false
+	}
+
+	for i, set := range this.plan.Sets() {
+		gk := groupingSetKey(i, set, vals)
+
+		// Get or seed the group value
+		gv := this.groups[gk]
+		if gv == nil {
+			gv = groupingSetValue(item, keys, set, vals, gk)
+			this.groups[gk] = gv
+			this.seedAggregates(gv)
+			this.holdItem(gv, context)
+		}
+
+		if !this.cumulate(item, gv, context) {
+			return false
 		}
 	}
 
+	return true
+}
+
+func (this *InitialGroup) seedAggregates(gv value.AnnotatedValue) {
+	aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
+	gv.SetAttachment("aggregates", aggregates)
+	for _, agg := range this.plan.Aggregates() {
+		aggregates[agg.String()] = agg.Default()
+	}
+}
+
+func (this *InitialGroup) cumulate(item, gv value.AnnotatedValue, context *Context) bool {
 	// Cumulate aggregates
 	aggregates, ok := gv.GetAttachment("aggregates").(map[string]value.Value)
 	if !ok {
@@ -56,7 +56,9 @@ func (this *IntermediateGroup) RunOnce(context *Context, parent value.Value) {
 func (this *IntermediateGroup) processItem(item value.AnnotatedValue, context *Context) bool {
 	// Generate the group key
 	var gk string
-	if len(this.plan.Keys()) > 0 {
+	if this.plan.Sets() != nil {
+		gk, _ = item.GetAttachment("grouping_key").(string)
+	} else if len(this.plan.Keys()) > 0 {
 		var e error
 		gk, e = groupKey(item, this.plan.Keys(), context)
 		if e != nil {
@@ -70,6 +72,7 @@ func (this *IntermediateGroup) processItem(item value.AnnotatedValue, context *C
 	if gv == nil {
 		gv = item
 		this.groups[gk] = gv
+		this.holdItem(gv, context)
 		return true
 	}
 
@@ -10,6 +10,8 @@
 package execution
 
 import (
+	"strconv"
+
 	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/util"
 	"github.com/couchbase/query/value"
@@ -34,4 +36,61 @@ func groupKey(item value.Value, keys expression.Expressions, context *Context) (
 	return string(bytes), nil
 }
 
+// Evaluates every key once, for all the grouping sets.
+func groupKeyValues(item value.Value, keys expression.Expressions, context *Context) (value.Values, error) {
+	vals := make(value.Values, len(keys))
+	for i, key := range keys {
+		v, e := key.Evaluate(item, context)
+		if e != nil {
+			return nil, e
+		}
+
+		vals[i] = v
+	}
+
+	return vals, nil
+}
+
+// The group key of a grouping set is prefixed by the position of the
+// set, so that the groups of different sets are kept apart.
+func groupingSetKey(pos int, set []int, vals value.Values) string {
+	kvs := _GROUP_KEY_POOL.GetCapped(len(set))
+	defer _GROUP_KEY_POOL.Put(kvs)
+
+	for _, i := range set {
+		if vals[i].Type() != value.MISSING {
+			kvs[strconv.Itoa(i)] = vals[i]
+		}
+	}
+
+	bytes, _ := value.NewValue(kvs).MarshalJSON()
+	return strconv.Itoa(pos) + ":" + string(bytes)
+}
+
+// Seeds the group value of a grouping set. The keys of the set are
+// covered by their values, and the other keys by NULL. The texts of
+// the other keys are attached for GROUPING(), and the group key for
+// the subsequent group operators.
+func groupingSetValue(item value.AnnotatedValue, keys expression.Expressions, set []int,
+	vals value.Values, gk string) value.AnnotatedValue {
+	gv := item.Copy().(value.AnnotatedValue)
+	rolledUp := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		text := expression.NewCover(key).Text()
+		rolledUp[text] = true
+		gv.SetCover(text, value.NULL_VALUE)
+	}
+
+	for _, i := range set {
+		text := expression.NewCover(keys[i]).Text()
+		delete(rolledUp, text)
+		gv.SetCover(text, vals[i])
+	}
+
+	gv.SetAttachment("grouping", rolledUp)
+	gv.SetAttachment("grouping_key", gk)
+	return gv
+}
+
 var _GROUP_KEY_POOL = util.NewStringInterfacePool(16)
@@ -15,6 +15,7 @@ import (
 
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/triggers"
 	"github.com/couchbase/query/value"
 )
 
@@ -103,6 +104,9 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 
 	keyExpr := this.plan.Key()
 	valExpr := this.plan.Value()
+	validation := keyspaceValidation(this.plan.Keyspace())
+	before := keyspaceTriggers(this.plan.Keyspace(), triggers.BEFORE, triggers.INSERT)
+	after := keyspaceTriggers(this.plan.Keyspace(), triggers.AFTER, triggers.INSERT)
 	var key, val value.Value
 	var err error
 	var ok bool
@@ -152,6 +156,14 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 			continue
 		}
 
+		if !validateDocument(validation, dpair.Name, val, context) {
+			continue
+		}
+
+		if before != nil && !fireTriggers(before, dpair.Name, value.MISSING_VALUE, val, context) {
+			return false
+		}
+
 		dpair.Value = val
 		i++
 	}
@@ -173,8 +185,13 @@ func (this *SendInsert) flushBatch(context *Context) bool {
 		context.Error(er)
 	}
 
-	// Capture the inserted keys in case there is a RETURNING clause
+	// Fire the AFTER triggers, and capture the inserted keys in case
+	// there is a RETURNING clause
 	for _, dp := range dpairs {
+		if after != nil && !fireTriggers(after, dp.Name, value.MISSING_VALUE, dp.Value, context) {
+			return false
+		}
+
 		dv := value.NewAnnotatedValue(dp.Value)
 		dv.SetAttachment("meta", map[string]interface{}{"id": dp.Name})
 		av := value.NewAnnotatedValue(make(map[string]interface{}, 1))
@@ -79,6 +79,7 @@ func (this *joinBase) joinFetch(keyspace datastore.Keyspace, keyCount map[string
 	this.switchPhase(_SERVTIME)
 	pairs, errs := keyspace.Fetch(fetchKeys, context, nil)
 	this.switchPhase(_EXECTIME)
+	this.addItemsFetched(int64(len(pairs)))
 
 	fetchOk := true
 	for _, err := range errs {
@@ -0,0 +1,263 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// The child produces the right-hand side objects, which are all read and
+// hashed before the first left-hand side object is processed.
+// Right-hand side objects that have not been matched by the time the input
+// is exhausted are sent on their own, for RIGHT and FULL OUTER JOINs.
+type HashJoin struct {
+	base
+	plan      *plan.HashJoin
+	child     Operator
+	ansiFlags uint32
+	parent    value.Value
+	values    []value.AnnotatedValue // right-hand side objects
+	matched   []bool
+	buckets   map[string][]int // positions in values, by hash key
+}
+
+func NewHashJoin(plan *plan.HashJoin, context *Context, child Operator) *HashJoin {
+	rv := &HashJoin{
+		plan:  plan,
+		child: child,
+	}
+
+	newBase(&rv.base, context)
+	rv.trackChildren(1)
+	rv.execPhase = HASH_JOIN
+	rv.output = rv
+	return rv
+}
+
+func (this *HashJoin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitHashJoin(this)
+}
+
+func (this *HashJoin) Copy() Operator {
+	rv := &HashJoin{
+		plan:  this.plan,
+		child: this.child.Copy(),
+	}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *HashJoin) RunOnce(context *Context, parent value.Value) {
+	this.runConsumer(this, context, parent)
+}
+
+func (this *HashJoin) beforeItems(context *Context, parent value.Value) bool {
+	if !context.assert(this.child != nil, "Hash Join has no child") {
+		return false
+	}
+	if !context.assert(this.plan.Onclause() != nil, "ANSI JOIN does not have onclause") {
+		return false
+	}
+
+	// check for constant TRUE or FALSE onclause
+	cpred := this.plan.Onclause().Value()
+	if cpred != nil {
+		if cpred.Truth() {
+			this.ansiFlags |= ANSI_ONCLAUSE_TRUE
+		} else {
+			this.ansiFlags |= ANSI_ONCLAUSE_FALSE
+		}
+	}
+
+	this.parent = parent
+	this.values = nil
+	this.matched = nil
+	this.buckets = make(map[string][]int)
+
+	return this.build(context, parent)
+}
+
+// read and hash the right-hand side
+func (this *HashJoin) build(context *Context, parent value.Value) bool {
+	this.child.SetOutput(this.child)
+	this.child.SetInput(nil)
+	this.child.SetParent(this)
+	this.child.SetStop(nil)
+
+	go this.child.RunOnce(context, parent)
+
+	ok := true
+	stopped := false
+	n := 1
+
+loop:
+	for ok {
+		right_item, child, cont := this.getItemChildrenOp(this.child)
+		if cont {
+			if right_item != nil {
+				var key string
+				var valid bool
+
+				key, valid, ok = this.hashKey(right_item, this.plan.BuildExprs(), context)
+				if ok && valid {
+					this.buckets[key] = append(this.buckets[key], len(this.values))
+				}
+				this.values = append(this.values, right_item)
+				this.holdItem(right_item, context)
+			} else if child >= 0 {
+				n--
+			} else {
+				break loop
+			}
+		} else {
+			stopped = true
+			break loop
+		}
+	}
+
+	if n > 0 {
+		notifyChildren(this.child)
+		this.childrenWaitNoStop(n)
+	}
+
+	this.matched = make([]bool, len(this.values))
+	return ok && !stopped
+}
+
+// the hash key is not valid if any of the expressions is MISSING or NULL,
+// since such objects cannot be matched by equality
+func (this *HashJoin) hashKey(item value.AnnotatedValue, exprs expression.Expressions, context *Context) (
+	string, bool, bool) {
+
+	if len(exprs) == 0 {
+		return "", true, true
+	}
+
+	var buf bytes.Buffer
+
+	for _, expr := range exprs {
+		val, err := expr.Evaluate(item, context)
+		if err != nil {
+			context.Error(errors.NewEvaluationError(err, "hash join key"))
+			return "", false, false
+		}
+		if val.Type() <= value.NULL {
+			return "", false, true
+		}
+		err = val.WriteJSON(&buf, "", "")
+		if err != nil {
+			context.Error(errors.NewEvaluationError(err, "hash join key"))
+			return "", false, false
+		}
+		buf.WriteByte(0)
+	}
+	return buf.String(), true, true
+}
+
+func (this *HashJoin) processItem(item value.AnnotatedValue, context *Context) bool {
+	key, valid, ok := this.hashKey(item, this.plan.ProbeExprs(), context)
+	if !ok {
+		return false
+	}
+
+	matched := false
+	if valid {
+		for _, i := range this.buckets[key] {
+			var match bool
+			var joined value.AnnotatedValue
+			match, ok, joined = processAnsiExec(item, this.values[i], this.plan.Onclause(),
+				this.plan.Alias(), this.ansiFlags, context, "join")
+			if !ok {
+				return false
+			}
+			if match {
+				matched = true
+				this.matched[i] = true
+				if !this.sendItem(joined) {
+					return false
+				}
+			}
+		}
+	}
+
+	if this.plan.Outer() && !matched {
+		return this.sendItem(item)
+	}
+
+	return true
+}
+
+func (this *HashJoin) afterItems(context *Context) {
+	defer func() {
+		this.values = nil
+		this.matched = nil
+		this.buckets = nil
+	}()
+
+	if this.stopped || !this.plan.RightOuter() {
+		return
+	}
+
+	alias := this.plan.Alias()
+	for i, right_item := range this.values {
+		if this.matched[i] {
+			continue
+		}
+
+		val, _ := right_item.Field(alias)
+		av := value.NewAnnotatedValue(value.NewScopeValue(map[string]interface{}{alias: val}, this.parent))
+		covers := right_item.Covers()
+		if covers != nil {
+			for key, _ := range covers.Fields() {
+				value, _ := covers.Field(key)
+				av.SetCover(key, value)
+			}
+		}
+		if !this.sendItem(av) {
+			return
+		}
+	}
+}
+
+func (this *HashJoin) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+		r["~child"] = this.child
+	})
+	return json.Marshal(r)
+}
+
+func (this *HashJoin) SendStop() {
+	this.baseSendStop()
+	if this.child != nil {
+		this.child.SendStop()
+	}
+}
+
+func (this *HashJoin) reopen(context *Context) {
+	this.baseReopen(context)
+	if this.child != nil {
+		this.child.reopen(context)
+	}
+}
+
+func (this *HashJoin) Done() {
+	this.baseDone()
+	if this.child != nil {
+		this.child.Done()
+	}
+	this.child = nil
+}
@@ -0,0 +1,84 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/validation"
+	"github.com/couchbase/query/value"
+)
+
+type AlterKeyspace struct {
+	base
+	plan *plan.AlterKeyspace
+}
+
+func NewAlterKeyspace(plan *plan.AlterKeyspace, context *Context) *AlterKeyspace {
+	rv := &AlterKeyspace{
+		plan: plan,
+	}
+
+	newRedirectBase(&rv.base)
+	rv.output = rv
+	return rv
+}
+
+func (this *AlterKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAlterKeyspace(this)
+}
+
+func (this *AlterKeyspace) Copy() Operator {
+	rv := &AlterKeyspace{plan: this.plan}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *AlterKeyspace) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover() // Recover from any panic
+		this.active()
+		defer this.close(context)
+		this.switchPhase(_EXECTIME)
+		defer this.switchPhase(_NOTIME)
+		defer this.notify() // Notify that I have stopped
+
+		if context.Readonly() {
+			return
+		}
+
+		// Actually alter keyspace
+		this.switchPhase(_SERVTIME)
+		keyspace := this.plan.Keyspace()
+		var err errors.Error
+		if this.plan.Validation() == nil {
+			err = validation.UnsetValidation(keyspace.NamespaceId(), keyspace.Name())
+		} else {
+			var v *validation.Validation
+			v, err = validation.NewValidation(keyspace.NamespaceId(), keyspace.Name(),
+				this.plan.Validation(), this.plan.Options())
+			if err == nil {
+				err = validation.SetValidation(v)
+			}
+		}
+		if err != nil {
+			context.Error(err)
+		}
+	})
+}
+
+func (this *AlterKeyspace) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+	})
+	return json.Marshal(r)
+}
@@ -14,29 +14,37 @@ import (
 	"fmt"
 
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/plan"
 	"github.com/couchbase/query/value"
 )
 
 type Merge struct {
 	base
-	plan     *plan.Merge
-	update   Operator
-	delete   Operator
-	insert   Operator
-	children []Operator
+	plan         *plan.Merge
+	update       Operator
+	delete       Operator
+	insert       Operator
+	sourceUpdate Operator
+	sourceDelete Operator
+	children     []Operator
+	selected     []Operator      // WHEN clauses the current item is sent to
+	matched      map[string]bool // keys of the target documents changed so far
 }
 
-func NewMerge(plan *plan.Merge, context *Context, update, delete, insert Operator) *Merge {
+func NewMerge(plan *plan.Merge, context *Context, update, delete, insert,
+	sourceUpdate, sourceDelete Operator) *Merge {
 	rv := &Merge{
-		plan:   plan,
-		update: update,
-		delete: delete,
-		insert: insert,
+		plan:         plan,
+		update:       update,
+		delete:       delete,
+		insert:       insert,
+		sourceUpdate: sourceUpdate,
+		sourceDelete: sourceDelete,
 	}
 
 	newBase(&rv.base, context)
-	rv.trackChildren(3)
+	rv.trackChildren(5)
 	rv.output = rv
 	return rv
 }
@@ -47,10 +55,12 @@ func (this *Merge) Accept(visitor Visitor) (interface{}, error) {
 
 func (this *Merge) Copy() Operator {
 	rv := &Merge{
-		plan:   this.plan,
-		update: copyOperator(this.update),
-		delete: copyOperator(this.delete),
-		insert: copyOperator(this.insert),
+		plan:         this.plan,
+		update:       copyOperator(this.update),
+		delete:       copyOperator(this.delete),
+		insert:       copyOperator(this.insert),
+		sourceUpdate: copyOperator(this.sourceUpdate),
+		sourceDelete: copyOperator(this.sourceDelete),
 	}
 	this.base.copy(&rv.base)
 	return rv
@@ -75,6 +85,8 @@ func (this *Merge) RunOnce(context *Context, parent value.Value) {
 		update, updateInput := this.wrapChild(this.update, context)
 		delete, deleteInput := this.wrapChild(this.delete, context)
 		insert, insertInput := this.wrapChild(this.insert, context)
+		sourceUpdate, sourceUpdateInput := this.wrapChild(this.sourceUpdate, context)
+		sourceDelete, sourceDeleteInput := this.wrapChild(this.sourceDelete, context)
 
 		this.children = _MERGE_OPERATOR_POOL.Get()
 		inputs := _MERGE_CHANNEL_POOL.Get()
@@ -95,6 +107,21 @@ func (this *Merge) RunOnce(context *Context, parent value.Value) {
 			inputs = append(inputs, insertInput)
 		}
 
+		if sourceUpdate != nil {
+			this.children = append(this.children, sourceUpdate)
+			inputs = append(inputs, sourceUpdateInput)
+		}
+
+		if sourceDelete != nil {
+			this.children = append(this.children, sourceDelete)
+			inputs = append(inputs, sourceDeleteInput)
+		}
+
+		this.selected = make([]Operator, 0, 2)
+		if this.plan.Key() == nil {
+			this.matched = make(map[string]bool)
+		}
+
 		for _, child := range this.children {
 			go child.RunOnce(context, parent)
 		}
@@ -108,8 +135,14 @@ func (this *Merge) RunOnce(context *Context, parent value.Value) {
 				break
 			}
 			this.addInDocs(1)
-			ok = this.processMatch(item, context, update, delete, insert)
+			if this.plan.Key() != nil {
+				ok = this.processMatch(item, context, update, delete, insert)
+			} else {
+				ok = this.processJoined(item, context, update, delete, insert,
+					sourceUpdate, sourceDelete)
+			}
 		}
+		this.matched = nil
 
 		// Close child input Channels, which will signal children
 		for _, input := range inputs {
@@ -143,6 +176,7 @@ func (this *Merge) processMatch(item value.AnnotatedValue,
 	bvs, errs := this.plan.Keyspace().Fetch([]string{k}, context, nil)
 
 	this.switchPhase(_EXECTIME)
+	this.addItemsFetched(int64(len(bvs)))
 
 	for _, err := range errs {
 		context.Error(err)
@@ -160,21 +194,113 @@ func (this *Merge) processMatch(item value.AnnotatedValue,
 		item.SetField(this.plan.KeyspaceRef().Alias(), bv.Value)
 
 		// Perform UPDATE and/or DELETE
-		if update != nil {
-			ok = this.sendItemOp(update.Input(), item)
-		}
+		ok = this.selectClauses(item, context, update, this.plan.UpdateCondition(),
+			delete, this.plan.DeleteCondition())
+	} else {
+		// Not matched; INSERT
+		ok = this.selectClauses(item, context, insert, this.plan.InsertCondition(), nil, nil)
+	}
 
-		if ok && delete != nil {
-			ok = this.sendItemOp(delete.Input(), item)
+	return ok && this.sendSelected(item)
+}
+
+/*
+The item is the source joined to a target document matched by the ON clause,
+the source alone if it matched nothing, or, for WHEN NOT MATCHED BY SOURCE,
+a target document that no source object has matched.
+*/
+func (this *Merge) processJoined(item value.AnnotatedValue, context *Context,
+	update, delete, insert, sourceUpdate, sourceDelete Operator) bool {
+	alias := this.plan.KeyspaceRef().Alias()
+	target, matched := item.Field(alias)
+	_, fromSource := item.Field(this.plan.Source())
+
+	if !matched {
+		// Not matched; INSERT
+		if !this.selectClauses(item, context, insert, this.plan.InsertCondition(), nil, nil) {
+			return false
+		}
+	} else if !fromSource {
+		// Not matched by source; UPDATE and/or DELETE
+		if !this.selectClauses(item, context, sourceUpdate, this.plan.SourceUpdateCondition(),
+			sourceDelete, this.plan.SourceDeleteCondition()) {
+			return false
 		}
 	} else {
-		// Not matched; INSERT
-		if insert != nil {
-			ok = this.sendItemOp(insert.Input(), item)
+		// Perform UPDATE and/or DELETE
+		if !this.selectClauses(item, context, update, this.plan.UpdateCondition(),
+			delete, this.plan.DeleteCondition()) {
+			return false
+		}
+
+		if len(this.selected) > 0 {
+			av, ok := target.(value.AnnotatedValue)
+			if !ok {
+				context.Error(errors.NewUpdateAliasMetadataError(alias))
+				return false
+			}
+
+			key, ok := this.requireKey(av, context)
+			if !ok {
+				return false
+			}
+
+			// a target document can only be changed by one source object
+			if this.matched[key] {
+				context.Error(errors.NewMergeMultiUpdateError(key))
+				return false
+			}
+			this.matched[key] = true
 		}
 	}
 
-	return ok
+	return this.sendSelected(item)
+}
+
+/*
+Select the WHEN clauses that an item goes to: the first one whose
+condition it satisfies, and any clauses without a condition before it.
+*/
+func (this *Merge) selectClauses(item value.AnnotatedValue, context *Context,
+	op1 Operator, cond1 expression.Expression, op2 Operator, cond2 expression.Expression) bool {
+	this.selected = this.selected[:0]
+
+	ops := [2]Operator{op1, op2}
+	conds := [2]expression.Expression{cond1, cond2}
+
+	for i, op := range ops {
+		if op == nil {
+			continue
+		}
+
+		if conds[i] == nil {
+			this.selected = append(this.selected, op)
+			continue
+		}
+
+		cv, err := conds[i].Evaluate(item, context)
+		if err != nil {
+			context.Error(errors.NewEvaluationError(err, "MERGE WHEN condition"))
+			return false
+		}
+
+		if cv.Truth() {
+			this.selected = append(this.selected, op)
+			break
+		}
+	}
+
+	return true
+}
+
+func (this *Merge) sendSelected(item value.AnnotatedValue) bool {
+	for _, op := range this.selected {
+		if !this.sendItemOp(op.Input(), item) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (this *Merge) wrapChild(op Operator, context *Context) (Operator, *Channel) {
@@ -201,6 +327,12 @@ func (this *Merge) MarshalJSON() ([]byte, error) {
 		if this.insert != nil {
 			r["insert"] = this.insert
 		}
+		if this.sourceUpdate != nil {
+			r["source_update"] = this.sourceUpdate
+		}
+		if this.sourceDelete != nil {
+			r["source_delete"] = this.sourceDelete
+		}
 	})
 	return json.Marshal(r)
 }
@@ -211,14 +343,20 @@ func (this *Merge) accrueTimes(o Operator) {
 	}
 	copy, _ := o.(*Merge)
 	if this.update != nil {
-		this.insert.accrueTimes(copy.insert)
+		this.update.accrueTimes(copy.update)
 	}
 	if this.delete != nil {
-		this.update.accrueTimes(copy.update)
+		this.delete.accrueTimes(copy.delete)
 	}
 	if this.insert != nil {
 		this.insert.accrueTimes(copy.insert)
 	}
+	if this.sourceUpdate != nil {
+		this.sourceUpdate.accrueTimes(copy.sourceUpdate)
+	}
+	if this.sourceDelete != nil {
+		this.sourceDelete.accrueTimes(copy.sourceDelete)
+	}
 }
 
 func (this *Merge) SendStop() {
@@ -232,6 +370,12 @@ func (this *Merge) SendStop() {
 	if this.insert != nil {
 		this.insert.SendStop()
 	}
+	if this.sourceUpdate != nil {
+		this.sourceUpdate.SendStop()
+	}
+	if this.sourceDelete != nil {
+		this.sourceDelete.SendStop()
+	}
 }
 
 func (this *Merge) reopen(context *Context) {
@@ -245,6 +389,12 @@ func (this *Merge) reopen(context *Context) {
 	if this.insert != nil {
 		this.insert.reopen(context)
 	}
+	if this.sourceUpdate != nil {
+		this.sourceUpdate.reopen(context)
+	}
+	if this.sourceDelete != nil {
+		this.sourceDelete.reopen(context)
+	}
 }
 
 func (this *Merge) Done() {
@@ -261,9 +411,17 @@ func (this *Merge) Done() {
 		this.insert.Done()
 		this.insert = nil
 	}
+	if this.sourceUpdate != nil {
+		this.sourceUpdate.Done()
+		this.sourceUpdate = nil
+	}
+	if this.sourceDelete != nil {
+		this.sourceDelete.Done()
+		this.sourceDelete = nil
+	}
 	_MERGE_OPERATOR_POOL.Put(this.children)
 	this.children = nil
 }
 
-var _MERGE_OPERATOR_POOL = NewOperatorPool(3)
-var _MERGE_CHANNEL_POOL = NewChannelPool(3)
+var _MERGE_OPERATOR_POOL = NewOperatorPool(5)
+var _MERGE_CHANNEL_POOL = NewChannelPool(5)
@@ -69,6 +69,7 @@ func (this *Order) processItem(item value.AnnotatedValue, context *Context) bool
 	}
 
 	this.values = append(this.values, item)
+	this.holdItem(item, context)
 	return true
 }
 
@@ -154,13 +155,9 @@ func (this *Order) lessThan(v1 value.AnnotatedValue, v2 value.AnnotatedValue) bo
 			v2.SetAttachment(s, ev2)
 		}
 
-		c = ev1.Collate(ev2)
+		c = term.Compare(ev1, ev2)
 
-		if c == 0 {
-			continue
-		} else if term.Descending() {
-			return c > 0
-		} else {
+		if c != 0 {
 			return c < 0
 		}
 	}
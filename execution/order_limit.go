@@ -157,9 +157,10 @@ func (this *OrderLimit) processItem(item value.AnnotatedValue, context *Context)
 
 	// Push the current item into the maximum heap.
 	heap.Push(this, item)
+	this.holdItem(item, context)
 	if len(this.values) > this.numReturnedRows {
 		// Pop and discard the largest item out of the maximum heap.
-		heap.Pop(this)
+		this.releaseItem(heap.Pop(this).(value.AnnotatedValue), context)
 	}
 	return true
 }
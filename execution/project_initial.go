@@ -12,6 +12,7 @@ package execution
 import (
 	"encoding/json"
 
+	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/plan"
@@ -66,7 +67,7 @@ func (this *InitialProject) processItem(item value.AnnotatedValue, context *Cont
 	result := terms[0].Result()
 	expr := result.Expression()
 
-	if result.Star() && (expr == expression.SELF || expr == nil) {
+	if result.Star() && (expr == expression.SELF || expr == nil) && result.Modifiers() == nil {
 		// Unprefixed star
 		if item.Type() == value.OBJECT {
 			return this.sendItem(item)
@@ -130,6 +131,15 @@ func (this *InitialProject) processTerms(item value.AnnotatedValue, context *Con
 				}
 			}
 
+			if modifiers := term.Result().Modifiers(); modifiers != nil {
+				var err error
+				starval, err = modifyStar(starval, modifiers, item, context)
+				if err != nil {
+					context.Error(errors.NewEvaluationError(err, "projection"))
+					return false
+				}
+			}
+
 			// Latest star overwrites previous star
 			switch sa := starval.Actual().(type) {
 			case map[string]interface{}:
@@ -143,6 +153,55 @@ func (this *InitialProject) processTerms(item value.AnnotatedValue, context *Con
 	return this.sendItem(pv)
 }
 
+/*
+Apply the EXCLUDE and REPLACE clauses of a star term to a copy of
+its value. Objects along the paths are copied before they are
+modified, so that the data is left intact.
+*/
+func modifyStar(starval value.Value, modifiers *algebra.StarModifiers,
+	item value.AnnotatedValue, context *Context) (value.Value, error) {
+	sa, ok := starval.Actual().(map[string]interface{})
+	if !ok {
+		return starval, nil
+	}
+
+	rv := value.NewValue(sa).Copy()
+
+	for _, path := range modifiers.Exclude() {
+		setStarField(rv, path, value.MISSING_VALUE)
+	}
+
+	for _, replace := range modifiers.Replace() {
+		v, err := replace.Expression().Evaluate(item, context)
+		if err != nil {
+			return nil, err
+		}
+
+		setStarField(rv, replace.Path(), v)
+	}
+
+	return rv, nil
+}
+
+/*
+Set the field at the path, or unset it if the value is MISSING.
+Nothing is done if the parent of the field is not an object.
+*/
+func setStarField(obj value.Value, path algebra.FieldPath, v value.Value) {
+	for _, field := range path[0 : len(path)-1] {
+		child, ok := obj.Field(field)
+		if !ok || child.Type() != value.OBJECT {
+			return
+		}
+
+		child = child.Copy()
+		obj.SetField(field, child)
+		obj = child
+	}
+
+	obj.SetField(path[len(path)-1], v)
+}
+
 func (this *InitialProject) MarshalJSON() ([]byte, error) {
 	r := this.plan.MarshalBase(func(r map[string]interface{}) {
 		this.marshalTimes(r)
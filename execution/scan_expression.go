@@ -20,7 +20,9 @@ import (
 
 type ExpressionScan struct {
 	base
-	plan *plan.ExpressionScan
+	plan      *plan.ExpressionScan
+	values    []interface{}
+	evaluated bool // values hold the result of an uncorrelated expression
 }
 
 func NewExpressionScan(plan *plan.ExpressionScan, context *Context) *ExpressionScan {
@@ -52,27 +54,32 @@ func (this *ExpressionScan) RunOnce(context *Context, parent value.Value) {
 		defer this.switchPhase(_NOTIME)
 		defer this.notify() // Notify that I have stopped
 
-		ev, e := this.plan.FromExpr().Evaluate(parent, context)
-		if e != nil {
-			context.Error(errors.NewEvaluationError(e, "ExpressionScan"))
-			return
-		}
+		// the inner side of a join is reopened for each outer value,
+		// but uncorrelated expressions need only be evaluated once
+		if !this.evaluated {
+			ev, e := this.plan.FromExpr().Evaluate(parent, context)
+			if e != nil {
+				context.Error(errors.NewEvaluationError(e, "ExpressionScan"))
+				return
+			}
 
-		actuals := ev.Actual()
-		switch actuals.(type) {
-		case []interface{}:
-		case nil:
-			if ev.Type() == value.NULL {
-				actuals = _ARRAY_NULL_VALUE
-			} else {
-				actuals = _ARRAY_MISSING_VALUE
+			actuals := ev.Actual()
+			switch actuals.(type) {
+			case []interface{}:
+			case nil:
+				if ev.Type() == value.NULL {
+					actuals = _ARRAY_NULL_VALUE
+				} else {
+					actuals = _ARRAY_MISSING_VALUE
+				}
+			default:
+				actuals = []interface{}{actuals}
 			}
-		default:
-			actuals = []interface{}{actuals}
+			this.values = actuals.([]interface{})
+			this.evaluated = !this.plan.IsCorrelated()
 		}
 
-		acts := actuals.([]interface{})
-		for _, act := range acts {
+		for _, act := range this.values {
 			actv := value.NewScopeValue(make(map[string]interface{}), parent)
 			actv.SetField(this.plan.Alias(), act)
 			av := value.NewAnnotatedValue(actv)
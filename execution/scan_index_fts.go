@@ -0,0 +1,144 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+type IndexFtsSearch struct {
+	base
+	plan *plan.IndexFtsSearch
+}
+
+func NewIndexFtsSearch(plan *plan.IndexFtsSearch, context *Context) *IndexFtsSearch {
+	rv := &IndexFtsSearch{
+		plan: plan,
+	}
+
+	newBase(&rv.base, context)
+	rv.newStopChannel()
+	rv.output = rv
+	return rv
+}
+
+func (this *IndexFtsSearch) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexFtsSearch(this)
+}
+
+func (this *IndexFtsSearch) Copy() Operator {
+	rv := &IndexFtsSearch{plan: this.plan}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *IndexFtsSearch) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover() // Recover from any panic
+		this.active()
+		defer this.close(context)
+		this.switchPhase(_EXECTIME)
+		this.setExecPhase(INDEX_SCAN, context)
+		defer func() { this.switchPhase(_NOTIME) }() // accrue current phase's time
+		defer this.notify()                          // Notify that I have stopped
+
+		conn := datastore.NewIndexConnection(context)
+		defer notifyConn(conn.StopChannel()) // Notify index that I have stopped
+
+		go this.search(context, conn, parent)
+
+		var docs uint64 = 0
+		defer func() {
+			if docs > 0 {
+				context.AddPhaseCount(INDEX_SCAN, docs)
+			}
+		}()
+
+		alias := this.plan.Term().Alias()
+		for {
+			entry, ok := this.getItemEntry(conn.EntryChannel())
+			if !ok {
+				return
+			}
+
+			if entry == nil {
+				break
+			}
+
+			cv := value.NewScopeValue(make(map[string]interface{}), parent)
+			av := value.NewAnnotatedValue(cv)
+
+			// For downstream Fetch
+			av.SetAttachment("meta", map[string]interface{}{"id": entry.PrimaryKey})
+
+			// For SEARCH_META() and SEARCH_SCORE()
+			smeta := map[string]interface{}{"id": entry.PrimaryKey}
+			if entry.MetaData != nil {
+				if score, ok := entry.MetaData.Field("score"); ok {
+					smeta["score"] = score.Actual()
+				}
+			}
+			av.SetAttachment("smeta", map[string]interface{}{alias: smeta})
+
+			if !this.sendItem(av) {
+				break
+			}
+
+			docs++
+			if docs > _PHASE_UPDATE_COUNT {
+				context.AddPhaseCount(INDEX_SCAN, docs)
+				docs = 0
+			}
+		}
+	})
+}
+
+func (this *IndexFtsSearch) search(context *Context, conn *datastore.IndexConnection, parent value.Value) {
+	defer context.Recover() // Recover from any panic
+
+	query, err := this.plan.Query().Evaluate(parent, context)
+	if err != nil {
+		context.Error(errors.NewEvaluationError(err, "search query"))
+		close(conn.EntryChannel())
+		return
+	}
+
+	var options value.Value
+	if this.plan.Options() != nil {
+		options, err = this.plan.Options().Evaluate(parent, context)
+		if err != nil {
+			context.Error(errors.NewEvaluationError(err, "search options"))
+			close(conn.EntryChannel())
+			return
+		}
+	}
+
+	keyspaceTerm := this.plan.Term()
+	scanVector := context.ScanVectorSource().ScanVector(keyspaceTerm.Namespace(), keyspaceTerm.Keyspace())
+	this.plan.Index().Search(context.RequestId(), this.plan.Key(), query, options, 0,
+		context.ScanConsistency(), scanVector, conn)
+}
+
+func (this *IndexFtsSearch) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+	})
+	return json.Marshal(r)
+}
+
+// send a stop
+func (this *IndexFtsSearch) SendStop() {
+	this.chanSendStop()
+}
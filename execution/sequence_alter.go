@@ -0,0 +1,72 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/sequences"
+	"github.com/couchbase/query/value"
+)
+
+type AlterSequence struct {
+	base
+	plan *plan.AlterSequence
+}
+
+func NewAlterSequence(plan *plan.AlterSequence, context *Context) *AlterSequence {
+	rv := &AlterSequence{
+		plan: plan,
+	}
+
+	newRedirectBase(&rv.base)
+	rv.output = rv
+	return rv
+}
+
+func (this *AlterSequence) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAlterSequence(this)
+}
+
+func (this *AlterSequence) Copy() Operator {
+	rv := &AlterSequence{plan: this.plan}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *AlterSequence) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover() // Recover from any panic
+		this.active()
+		defer this.close(context)
+		this.switchPhase(_EXECTIME)
+		defer this.switchPhase(_NOTIME)
+		defer this.notify() // Notify that I have stopped
+
+		if context.Readonly() {
+			return
+		}
+
+		// Actually alter sequence
+		this.switchPhase(_SERVTIME)
+		err := sequences.AlterSequence(this.plan.Namespace(), this.plan.Name(), this.plan.Options())
+		if err != nil {
+			context.Error(err)
+		}
+	})
+}
+
+func (this *AlterSequence) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+	})
+	return json.Marshal(r)
+}
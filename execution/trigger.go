@@ -0,0 +1,193 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"sync"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/planner"
+	"github.com/couchbase/query/triggers"
+	"github.com/couchbase/query/value"
+)
+
+// The triggers of the keyspace for an event, looked up once per batch by
+// SendInsert, SendUpsert, SendUpdate and SendDelete, which also carry
+// out the actions of MERGE.
+func keyspaceTriggers(keyspace datastore.Keyspace, timing, event string) []*triggers.Trigger {
+	return triggers.KeyspaceTriggers(keyspace.NamespaceId(), keyspace.Name(), timing, event)
+}
+
+// Runs the triggers for a document, with oldDoc and newDoc as OLD and
+// NEW, either of which may be MISSING. The first error of a trigger is
+// reported, and the statement must stop.
+func fireTriggers(list []*triggers.Trigger, key string, oldDoc, newDoc value.Value, context *Context) bool {
+	if oldDoc.Type() != value.MISSING {
+		oldDoc = triggerDocument(key, oldDoc)
+	}
+	if newDoc.Type() != value.MISSING {
+		newDoc = triggerDocument(key, newDoc)
+	}
+
+	for _, trigger := range list {
+		err := context.fireTrigger(trigger, oldDoc, newDoc)
+		if err != nil {
+			context.Error(errors.NewTriggerExecutionError(err, trigger.Key(), key))
+			return false
+		}
+	}
+	return true
+}
+
+// A document with its key, as seen by triggers through META().
+// Covering scans make the item a field of itself, under the alias of
+// the keyspace: that field is not part of the document.
+func triggerDocument(key string, doc value.Value) value.Value {
+	if av, ok := doc.(value.AnnotatedValue); ok {
+		doc = av.GetValue()
+		if fields, ok := doc.Actual().(map[string]interface{}); ok {
+			for name, field := range fields {
+				if field == value.Value(av) {
+					doc = doc.Copy()
+					doc.UnsetField(name)
+				}
+			}
+		}
+	}
+	rv := value.NewAnnotatedValue(doc)
+	rv.SetAttachment("meta", map[string]interface{}{"id": key})
+	return rv
+}
+
+// The body of a trigger runs in a context of its own, in the namespace
+// of the trigger, with OLD and NEW as named arguments and its output
+// captured, one level deeper than the statement that fired it.
+func (this *Context) fireTrigger(trigger *triggers.Trigger, oldDoc, newDoc value.Value) error {
+	if this.triggerDepth >= triggers.MAX_DEPTH {
+		return errors.NewTriggerDepthError(trigger.Key(), triggers.MAX_DEPTH)
+	}
+
+	namedArgs := map[string]value.Value{"old": oldDoc, "new": newDoc}
+	output := &triggerOutput{Output: this.output}
+	context := NewContext(this.requestId, this.datastore, this.systemstore, trigger.Namespace,
+		this.readonly, this.maxParallelism, this.scanCap, this.pipelineCap, this.pipelineBatch,
+		namedArgs, nil, this.credentials, this.consistency, this.scanVectorSource, output,
+		this.httpRequest, this.prepared, this.indexApiVersion, this.featureControls)
+	context.reqDeadline = this.reqDeadline
+	context.triggerDepth = this.triggerDepth + 1
+	context.triggerPlans = this.getTriggerPlans()
+
+	if condition := trigger.Condition(); condition != nil {
+		cond, err := condition.Evaluate(value.NULL_VALUE, context)
+		if err != nil {
+			return err
+		}
+		if !cond.Truth() {
+			return nil
+		}
+	}
+
+	op, err := context.triggerPlan(trigger)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := Build(op, context)
+	if err != nil {
+		return err
+	}
+
+	collect := NewCollect(plan.NewCollect(), context)
+	sequence := NewSequence(plan.NewSequence(), context, pipeline, collect)
+	sequence.RunOnce(context, nil)
+	collect.waitComplete()
+	sequence.Done()
+
+	return output.firstError()
+}
+
+// The bodies of triggers are planned once per request, on first use.
+type triggerPlans struct {
+	sync.Mutex
+	plans map[*triggers.Trigger]plan.Operator
+}
+
+func (this *Context) getTriggerPlans() *triggerPlans {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.triggerPlans == nil {
+		this.triggerPlans = &triggerPlans{plans: make(map[*triggers.Trigger]plan.Operator)}
+	}
+	return this.triggerPlans
+}
+
+func (this *Context) triggerPlan(trigger *triggers.Trigger) (plan.Operator, error) {
+	plans := this.getTriggerPlans()
+	plans.Lock()
+	defer plans.Unlock()
+
+	op, ok := plans.plans[trigger]
+	if ok {
+		return op, nil
+	}
+
+	stmt, err := trigger.Statement()
+	if err != nil {
+		return nil, err
+	}
+
+	op, er := planner.Build(stmt, this.datastore, this.systemstore, trigger.Namespace, true,
+		nil, nil, this.indexApiVersion, this.featureControls)
+	if er != nil {
+		return nil, errors.NewTriggerError(er, "cannot plan the body of "+trigger.Key())
+	}
+
+	plans.plans[trigger] = op
+	return op, nil
+}
+
+// The output of the body of a trigger: any results are discarded, and
+// the first error is kept for the statement that fired the trigger to
+// report. Warnings, mutation counts and metrics go to the statement.
+type triggerOutput struct {
+	Output
+	sync.Mutex
+	err errors.Error
+}
+
+func (this *triggerOutput) Result(item value.Value) bool {
+	return true
+}
+
+func (this *triggerOutput) CloseResults() {
+}
+
+func (this *triggerOutput) Error(err errors.Error) {
+	this.Lock()
+	defer this.Unlock()
+	if this.err == nil {
+		this.err = err
+	}
+}
+
+func (this *triggerOutput) Fatal(err errors.Error) {
+	this.Error(err)
+}
+
+func (this *triggerOutput) firstError() error {
+	this.Lock()
+	defer this.Unlock()
+	if this.err == nil {
+		return nil
+	}
+	return this.err
+}
@@ -13,17 +13,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/triggers"
 	"github.com/couchbase/query/value"
 )
 
 // Send to keyspace
 type SendUpdate struct {
 	base
-	plan  *plan.SendUpdate
-	limit int64
+	plan    *plan.SendUpdate
+	limit   int64
+	mutated *mutatedKeys
 }
 
 func NewSendUpdate(plan *plan.SendUpdate, context *Context) *SendUpdate {
@@ -32,6 +35,10 @@ func NewSendUpdate(plan *plan.SendUpdate, context *Context) *SendUpdate {
 		limit: -1,
 	}
 
+	if plan.DistinctKeys() {
+		rv.mutated = newMutatedKeys()
+	}
+
 	newBase(&rv.base, context)
 	rv.execPhase = UPDATE
 	rv.output = rv
@@ -43,7 +50,7 @@ func (this *SendUpdate) Accept(visitor Visitor) (interface{}, error) {
 }
 
 func (this *SendUpdate) Copy() Operator {
-	rv := &SendUpdate{plan: this.plan, limit: this.limit}
+	rv := &SendUpdate{plan: this.plan, limit: this.limit, mutated: this.mutated}
 	this.base.copy(&rv.base)
 	return rv
 }
@@ -53,6 +60,30 @@ func (this *SendUpdate) RunOnce(context *Context, parent value.Value) {
 }
 
 func (this *SendUpdate) processItem(item value.AnnotatedValue, context *Context) bool {
+	if this.mutated != nil {
+		uv, ok := item.Field(this.plan.Alias())
+		if !ok {
+			context.Error(errors.NewUpdateAliasMissingError(this.plan.Alias()))
+			return false
+		}
+
+		av, ok := uv.(value.AnnotatedValue)
+		if !ok {
+			context.Error(errors.NewUpdateAliasMetadataError(this.plan.Alias()))
+			return false
+		}
+
+		key, ok := this.requireKey(av, context)
+		if !ok {
+			return false
+		}
+
+		// the join found this key already
+		if !this.mutated.add(key) {
+			return true
+		}
+	}
+
 	rv := this.limit != 0 && this.enbatch(item, this, context)
 
 	if this.limit > 0 {
@@ -108,6 +139,12 @@ func (this *SendUpdate) flushBatch(context *Context) bool {
 		pairs = make([]value.Pair, 0, len(this.batch))
 	}
 
+	validation := keyspaceValidation(this.plan.Keyspace())
+	before := keyspaceTriggers(this.plan.Keyspace(), triggers.BEFORE, triggers.UPDATE)
+	after := keyspaceTriggers(this.plan.Keyspace(), triggers.AFTER, triggers.UPDATE)
+	var rejected map[int]bool
+	var olds map[string]value.Value
+
 	for i, item := range this.batch {
 		uv, ok := item.Field(this.plan.Alias())
 		if !ok {
@@ -126,9 +163,6 @@ func (this *SendUpdate) flushBatch(context *Context) bool {
 			return false
 		}
 
-		pairs = pairs[0 : i+1]
-		pairs[i].Name = key
-
 		clone := item.GetAttachment("clone")
 		switch clone := clone.(type) {
 		case value.AnnotatedValue:
@@ -140,8 +174,28 @@ func (this *SendUpdate) flushBatch(context *Context) bool {
 
 			cav := value.NewAnnotatedValue(cv)
 			cav.SetAnnotations(av)
-			pairs[i].Value = cav
 			item.SetField(this.plan.Alias(), cav)
+
+			if !validateDocument(validation, key, cav, context) {
+				if rejected == nil {
+					rejected = make(map[int]bool)
+				}
+				rejected[i] = true
+				continue
+			}
+
+			if before != nil && !fireTriggers(before, key, av, cav, context) {
+				return false
+			}
+
+			if after != nil {
+				if olds == nil {
+					olds = make(map[string]value.Value, len(this.batch))
+				}
+				olds[key] = av
+			}
+
+			pairs = append(pairs, value.Pair{Name: key, Value: cav})
 		default:
 			context.Error(errors.NewInvalidValueError(fmt.Sprintf(
 				"Invalid UPDATE value of type %T.", clone)))
@@ -162,7 +216,17 @@ func (this *SendUpdate) flushBatch(context *Context) bool {
 		context.Error(e)
 	}
 
-	for _, item := range this.batch {
+	for _, pair := range pairs {
+		if after != nil && !fireTriggers(after, pair.Name, olds[pair.Name], pair.Value, context) {
+			return false
+		}
+	}
+
+	for i, item := range this.batch {
+		if rejected[i] {
+			continue
+		}
+
 		if !this.sendItem(item) {
 			return false
 		}
@@ -182,4 +246,30 @@ func (this *SendUpdate) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r)
 }
 
+// Keys sent so far, shared by all the copies of a send operator, so that
+// a document joined to several objects is mutated only once
+type mutatedKeys struct {
+	sync.Mutex
+	keys map[string]bool
+}
+
+func newMutatedKeys() *mutatedKeys {
+	return &mutatedKeys{
+		keys: make(map[string]bool),
+	}
+}
+
+// Returns false if the key has already been added
+func (this *mutatedKeys) add(key string) bool {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.keys[key] {
+		return false
+	}
+
+	this.keys[key] = true
+	return true
+}
+
 var _UPDATE_POOL = value.NewPairPool(_BATCH_SIZE)
@@ -15,6 +15,7 @@ import (
 
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/triggers"
 	"github.com/couchbase/query/value"
 )
 
@@ -73,6 +74,13 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 
 	keyExpr := this.plan.Key()
 	valExpr := this.plan.Value()
+	validation := keyspaceValidation(this.plan.Keyspace())
+	beforeInsert := keyspaceTriggers(this.plan.Keyspace(), triggers.BEFORE, triggers.INSERT)
+	afterInsert := keyspaceTriggers(this.plan.Keyspace(), triggers.AFTER, triggers.INSERT)
+	beforeUpdate := keyspaceTriggers(this.plan.Keyspace(), triggers.BEFORE, triggers.UPDATE)
+	afterUpdate := keyspaceTriggers(this.plan.Keyspace(), triggers.AFTER, triggers.UPDATE)
+	fire := beforeInsert != nil || afterInsert != nil || beforeUpdate != nil || afterUpdate != nil
+	update := beforeUpdate != nil || afterUpdate != nil
 	var key, val value.Value
 	var err error
 	var ok bool
@@ -122,12 +130,42 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 			continue
 		}
 
+		if !validateDocument(validation, dpair.Name, val, context) {
+			continue
+		}
+
 		dpair.Value = val
 		i++
 	}
 
 	dpairs = dpairs[0:i]
 
+	// Documents that already exist are updated rather than inserted,
+	// and their UPDATE triggers see them as OLD: they are only looked
+	// up if there are any
+	var oldDocs map[string]value.Value
+	if update {
+		oldDocs = this.fetchOld(dpairs, context)
+		if oldDocs == nil {
+			return false
+		}
+	}
+	if fire {
+		for _, dp := range dpairs {
+			oldDoc, ok := oldDocs[dp.Name]
+			if !ok {
+				oldDoc = value.MISSING_VALUE
+			}
+			before := beforeInsert
+			if ok {
+				before = beforeUpdate
+			}
+			if before != nil && !fireTriggers(before, dp.Name, oldDoc, dp.Value, context) {
+				return false
+			}
+		}
+	}
+
 	this.switchPhase(_SERVTIME)
 
 	// Perform the actual UPSERT
@@ -143,8 +181,23 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 		context.Error(er)
 	}
 
-	// Capture the upserted keys in case there is a RETURNING clause
+	// Fire the AFTER triggers, and capture the upserted keys in case
+	// there is a RETURNING clause
 	for _, dp := range dpairs {
+		if fire {
+			oldDoc, ok := oldDocs[dp.Name]
+			if !ok {
+				oldDoc = value.MISSING_VALUE
+			}
+			after := afterInsert
+			if ok {
+				after = afterUpdate
+			}
+			if after != nil && !fireTriggers(after, dp.Name, oldDoc, dp.Value, context) {
+				return false
+			}
+		}
+
 		dv := value.NewAnnotatedValue(dp.Value)
 		dv.SetAttachment("meta", map[string]interface{}{"id": dp.Name})
 		av := value.NewAnnotatedValue(make(map[string]interface{}, 1))
@@ -158,6 +211,36 @@ func (this *SendUpsert) flushBatch(context *Context) bool {
 	return true
 }
 
+// The documents about to be replaced, by key. Returns nil if they
+// cannot be fetched, as the triggers to fire would not be known.
+func (this *SendUpsert) fetchOld(dpairs []value.Pair, context *Context) map[string]value.Value {
+	rv := make(map[string]value.Value, len(dpairs))
+	if len(dpairs) == 0 {
+		return rv
+	}
+
+	keys := make([]string, len(dpairs))
+	for i, dp := range dpairs {
+		keys[i] = dp.Name
+	}
+
+	this.switchPhase(_SERVTIME)
+	pairs, errs := this.plan.Keyspace().Fetch(keys, context, nil)
+	this.switchPhase(_EXECTIME)
+	this.addItemsFetched(int64(len(pairs)))
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			context.Error(err)
+		}
+		return nil
+	}
+	for _, pair := range pairs {
+		rv[pair.Name] = pair.Value
+	}
+	return rv
+}
+
 func (this *SendUpsert) readonly() bool {
 	return false
 }
@@ -32,3 +32,42 @@ func copyOperator(op Operator) Operator {
 
 var _STRING_POOL = util.NewStringPool(_BATCH_SIZE)
 var _STRING_ANNOTATED_POOL = value.NewStringAnnotatedPool(_BATCH_SIZE)
+
+/*
+Estimates the memory held by a value, for EXPLAIN ANALYZE.
+*/
+func estimatedSize(v value.Value) int64 {
+	return actualSize(v.Actual())
+}
+
+const (
+	_SCALAR_SIZE = 16
+	_STRING_SIZE = 16
+	_SLICE_SIZE  = 24
+	_MAP_SIZE    = 48
+)
+
+func actualSize(a interface{}) int64 {
+	switch a := a.(type) {
+	case string:
+		return _STRING_SIZE + int64(len(a))
+	case []byte:
+		return _SLICE_SIZE + int64(len(a))
+	case []interface{}:
+		size := int64(_SLICE_SIZE)
+		for _, e := range a {
+			size += actualSize(e)
+		}
+		return size
+	case map[string]interface{}:
+		size := int64(_MAP_SIZE)
+		for k, e := range a {
+			size += _STRING_SIZE + int64(len(k)) + actualSize(e)
+		}
+		return size
+	case value.Value:
+		return actualSize(a.Actual())
+	default:
+		return _SCALAR_SIZE
+	}
+}
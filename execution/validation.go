@@ -0,0 +1,46 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/validation"
+	"github.com/couchbase/query/value"
+)
+
+// The validation of the keyspace, if any, looked up once per batch by
+// SendInsert, SendUpsert and SendUpdate, which also carry out the
+// actions of MERGE.
+func keyspaceValidation(keyspace datastore.Keyspace) *validation.Validation {
+	return validation.GetValidation(keyspace.NamespaceId(), keyspace.Name())
+}
+
+// Checks a document about to be written against the validation, if
+// any. Violations are reported as an error, and the document must not
+// be written, in strict mode; and as a warning otherwise.
+func validateDocument(v *validation.Validation, key string, doc value.Value, context *Context) bool {
+	if v == nil {
+		return true
+	}
+
+	violations := v.Validate(doc)
+	if len(violations) == 0 {
+		return true
+	}
+
+	if v.Strict() {
+		context.Error(errors.NewSchemaViolationError(key, v.Key(), violations.String()))
+		return false
+	}
+
+	context.Warning(errors.NewSchemaViolationWarning(key, v.Key(), violations.String()))
+	return true
+}
@@ -0,0 +1,186 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+	"github.com/couchbase/query/views"
+)
+
+type RefreshView struct {
+	base
+	plan *plan.RefreshView
+}
+
+func NewRefreshView(plan *plan.RefreshView, context *Context) *RefreshView {
+	rv := &RefreshView{
+		plan: plan,
+	}
+
+	newRedirectBase(&rv.base)
+	rv.output = rv
+	return rv
+}
+
+func (this *RefreshView) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitRefreshView(this)
+}
+
+func (this *RefreshView) Copy() Operator {
+	rv := &RefreshView{plan: this.plan}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *RefreshView) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover() // Recover from any panic
+		this.active()
+		defer this.close(context)
+		this.switchPhase(_EXECTIME)
+		defer this.switchPhase(_NOTIME)
+		defer this.notify() // Notify that I have stopped
+
+		if context.Readonly() {
+			return
+		}
+
+		// Actually refresh view
+		this.switchPhase(_SERVTIME)
+		keyspace := this.plan.Keyspace()
+		view := views.GetView(keyspace.NamespaceId(), keyspace.Name())
+		if view == nil || !view.Materialized {
+			context.Error(errors.NewNoSuchViewError(keyspace.NamespaceId() + ":" + keyspace.Name()))
+			return
+		}
+
+		err := refreshView(view, keyspace, this.plan.Mode(), context)
+		if err != nil {
+			context.Error(err)
+		}
+	})
+}
+
+func (this *RefreshView) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+	})
+	return json.Marshal(r)
+}
+
+/*
+Run the definition of a materialized view, and store the rows in the
+keyspace of the view.
+
+The rows of an incremental definition are stored under the key of
+their source document, and an incremental refresh only writes the rows
+that have changed; any other definition is refreshed in full, with
+the rows stored under their position. Either way, documents of the
+keyspace that no longer match a row are deleted.
+*/
+func refreshView(view *views.View, keyspace datastore.Keyspace, mode string, context *Context) errors.Error {
+	var def *algebra.Select
+	var err errors.Error
+	if view.Incremental {
+		def, err = view.KeyedDefinition()
+	} else {
+		def, err = view.Definition()
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, er := context.EvaluateSubquery(def, nil)
+	if er != nil {
+		return errors.NewViewError(er, "cannot refresh "+view.Key())
+	}
+
+	existing, err := viewContents(view, context)
+	if err != nil {
+		return err
+	}
+
+	incremental := view.Incremental && mode != algebra.REFRESH_FULL
+	actuals, _ := rows.Actual().([]interface{})
+	pairs := make([]value.Pair, 0, len(actuals))
+	for i, row := range actuals {
+		val := value.NewValue(row)
+		key := strconv.Itoa(i)
+		if view.Incremental {
+			k, _ := val.Field(views.KEY_FIELD)
+			key, _ = k.Actual().(string)
+			val.UnsetField(views.KEY_FIELD)
+		}
+
+		old, ok := existing[key]
+		delete(existing, key)
+		if incremental && ok && old.EquivalentTo(val) {
+			continue
+		}
+		pairs = append(pairs, value.Pair{Name: key, Value: val})
+	}
+
+	deletes := make([]string, 0, len(existing))
+	for key := range existing {
+		deletes = append(deletes, key)
+	}
+
+	if len(pairs) > 0 {
+		_, err = keyspace.Upsert(pairs)
+		if err != nil {
+			return errors.NewViewError(err, "cannot refresh "+view.Key())
+		}
+	}
+
+	if len(deletes) > 0 {
+		_, err = keyspace.Delete(deletes, context)
+		if err != nil {
+			return errors.NewViewError(err, "cannot refresh "+view.Key())
+		}
+	}
+
+	context.AddMutationCount(uint64(len(pairs) + len(deletes)))
+	views.SetRefreshed(view.Namespace, view.Name, time.Now())
+	return nil
+}
+
+// the documents of the keyspace of a materialized view, by key
+func viewContents(view *views.View, context *Context) (map[string]value.Value, errors.Error) {
+	query, err := view.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	docs, er := context.EvaluateSubquery(query, nil)
+	if er != nil {
+		return nil, errors.NewViewError(er, "cannot read the contents of "+view.Key())
+	}
+
+	actuals, _ := docs.Actual().([]interface{})
+	rv := make(map[string]value.Value, len(actuals))
+	for _, doc := range actuals {
+		val := value.NewValue(doc)
+		id, _ := val.Field("id")
+		key, ok := id.Actual().(string)
+		if !ok {
+			continue
+		}
+		rv[key], _ = val.Field("doc")
+	}
+	return rv, nil
+}
@@ -17,6 +17,8 @@ type Visitor interface {
 	VisitIndexScan(op *IndexScan) (interface{}, error)
 	VisitIndexScan2(op *IndexScan2) (interface{}, error)
 	VisitIndexScan3(op *IndexScan3) (interface{}, error)
+	VisitIndexFtsSearch(op *IndexFtsSearch) (interface{}, error)
+	VisitIndexVectorScan(op *IndexVectorScan) (interface{}, error)
 	VisitKeyScan(op *KeyScan) (interface{}, error)
 	VisitValueScan(op *ValueScan) (interface{}, error)
 	VisitDummyScan(op *DummyScan) (interface{}, error)
@@ -41,6 +43,7 @@ type Visitor interface {
 	VisitIndexNest(op *IndexNest) (interface{}, error)
 	VisitUnnest(op *Unnest) (interface{}, error)
 	VisitNLJoin(op *NLJoin) (interface{}, error)
+	VisitHashJoin(op *HashJoin) (interface{}, error)
 	VisitNLNest(op *NLNest) (interface{}, error)
 
 	// Let + Letting
@@ -109,6 +112,23 @@ type Visitor interface {
 	VisitAlterIndex(op *AlterIndex) (interface{}, error)
 	VisitBuildIndexes(op *BuildIndexes) (interface{}, error)
 
+	// View DDL
+	VisitCreateView(op *CreateView) (interface{}, error)
+	VisitDropView(op *DropView) (interface{}, error)
+	VisitRefreshView(op *RefreshView) (interface{}, error)
+
+	// Sequence DDL
+	VisitCreateSequence(op *CreateSequence) (interface{}, error)
+	VisitAlterSequence(op *AlterSequence) (interface{}, error)
+	VisitDropSequence(op *DropSequence) (interface{}, error)
+
+	// Keyspace DDL
+	VisitAlterKeyspace(op *AlterKeyspace) (interface{}, error)
+
+	// Trigger DDL
+	VisitCreateTrigger(op *CreateTrigger) (interface{}, error)
+	VisitDropTrigger(op *DropTrigger) (interface{}, error)
+
 	// Roles
 	VisitGrantRole(op *GrantRole) (interface{}, error)
 	VisitRevokeRole(op *RevokeRole) (interface{}, error)
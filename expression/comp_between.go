@@ -56,12 +56,12 @@ func (this *Between) FilterCovers(covers map[string]value.Value) map[string]valu
 }
 
 func (this *Between) Apply(context Context, item, low, high value.Value) (value.Value, error) {
-	lowCmp := item.Compare(low)
+	lowCmp := collatedCompare(this, item, low)
 	if lowCmp.Type() == value.MISSING {
 		return lowCmp, nil
 	}
 
-	highCmp := item.Compare(high)
+	highCmp := collatedCompare(this, item, high)
 	if highCmp.Type() == value.MISSING {
 		return highCmp, nil
 	}
@@ -0,0 +1,124 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"strconv"
+
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents expr COLLATE collation. It evaluates to the value
+of expr, and gives the collation of the comparisons it is an
+operand of: =, !=, <, <=, >, >= and BETWEEN compare strings by the
+collation, as ORDER BY does. See value.NewCollation() for the
+collations.
+*/
+type Collate struct {
+	UnaryFunctionBase
+	collation value.Collation
+}
+
+func NewCollate(operand Expression, collation value.Collation) Function {
+	rv := &Collate{
+		*NewUnaryFunctionBase("collate", operand),
+		collation,
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Collate) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Collate) Type() value.Type { return this.Operand().Type() }
+
+func (this *Collate) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *Collate) Apply(context Context, arg value.Value) (value.Value, error) {
+	return arg, nil
+}
+
+/*
+A collated constant is not folded, so that it is never compared
+as if it had the default collation, as in index spans.
+*/
+func (this *Collate) Value() value.Value {
+	return nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Collate) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewCollate(operands[0], this.collation)
+	}
+}
+
+/*
+Returns the operand and the COLLATE clause.
+*/
+func (this *Collate) Text(stringer *Stringer) string {
+	return "(" + stringer.Visit(this.Operand()) + " collate " +
+		strconv.Quote(this.collation.Name()) + ")"
+}
+
+/*
+Different collations are not equivalent.
+*/
+func (this *Collate) EquivalentTo(other Expression) bool {
+	otherCollate, ok := other.(*Collate)
+	return ok && this.collation.Name() == otherCollate.collation.Name() &&
+		this.UnaryFunctionBase.EquivalentTo(other)
+}
+
+/*
+Returns the collation.
+*/
+func (this *Collate) Collation() value.Collation {
+	return this.collation
+}
+
+/*
+Returns the collation of the first operand of the comparison that
+has a COLLATE clause, or nil for the default collation.
+*/
+func OperandCollation(comparison Expression) value.Collation {
+	for _, child := range comparison.Children() {
+		if collate, ok := child.(*Collate); ok {
+			return collate.collation
+		}
+	}
+
+	return nil
+}
+
+/*
+Compares the operands of the comparison as Value.Compare() does,
+except for strings, which are compared by the collation of the
+operands.
+*/
+func collatedCompare(comparison Expression, first, second value.Value) value.Value {
+	collation := OperandCollation(comparison)
+	if collation == nil || first.Type() <= value.NULL || second.Type() <= value.NULL {
+		return first.Compare(second)
+	}
+
+	return value.NewValue(float64(collation.Collate(first, second)))
+}
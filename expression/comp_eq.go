@@ -52,9 +52,15 @@ func (this *Eq) Evaluate(item value.Value, context Context) (value.Value, error)
 If this expression is in the WHERE clause of a partial index, lists
 the Expressions that are implicitly covered.
 
-For Eq, list either a static value, or this expression.
+For Eq, list either a static value, or this expression. Values
+compared by a collation are not implied by it.
 */
 func (this *Eq) FilterCovers(covers map[string]value.Value) map[string]value.Value {
+	if OperandCollation(this) != nil {
+		covers[this.String()] = value.TRUE_VALUE
+		return covers
+	}
+
 	var static, other Expression
 	if this.Second().Value() != nil {
 		static = this.Second()
@@ -74,7 +80,17 @@ func (this *Eq) FilterCovers(covers map[string]value.Value) map[string]value.Val
 }
 
 func (this *Eq) Apply(context Context, first, second value.Value) (value.Value, error) {
-	return first.Equals(second), nil
+	if OperandCollation(this) == nil {
+		return first.Equals(second), nil
+	}
+
+	cmp := collatedCompare(this, first, second)
+	switch actual := cmp.Actual().(type) {
+	case float64:
+		return value.NewValue(actual == 0), nil
+	}
+
+	return cmp, nil
 }
 
 /*
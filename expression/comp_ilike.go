@@ -0,0 +1,182 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the case-insensitive LIKE, expr ILIKE pattern
+[ESCAPE char]. It is true when LOWER(expr) LIKE LOWER(pattern),
+so that it can use an index on LOWER(expr), or on UPPER(expr).
+*/
+type ILike struct {
+	FunctionBase
+	re *regexp.Regexp
+}
+
+func NewILike(first, second, escape Expression) Function {
+	operands := Expressions{first, second}
+	if escape != nil {
+		operands = append(operands, escape)
+	}
+
+	rv := &ILike{
+		*NewFunctionBase("ilike", operands...),
+		nil,
+	}
+
+	if pv := second.Value(); pv != nil && pv.Type() == value.STRING {
+		pattern := value.NewValue(strings.ToLower(pv.Actual().(string)))
+		if escape == nil {
+			rv.re, _, _ = precompileLike(pattern)
+		} else {
+			rv.re, _, _ = precompileLikeEscape(pattern, escape.Value())
+		}
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *ILike) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *ILike) Type() value.Type { return value.BOOLEAN }
+
+func (this *ILike) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+/*
+If this expression is in the WHERE clause of a partial index, lists
+the Expressions that are implicitly covered.
+
+For ILIKE, simply list this expression.
+*/
+func (this *ILike) FilterCovers(covers map[string]value.Value) map[string]value.Value {
+	covers[this.String()] = value.TRUE_VALUE
+	return covers
+}
+
+func (this *ILike) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	for _, arg := range args {
+		if arg.Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	re := this.re
+	if re == nil {
+		pattern := strings.ToLower(args[1].Actual().(string))
+
+		var err error
+		if len(args) < 3 {
+			re, _, err = likeCompile(pattern)
+		} else {
+			var escape rune
+			escape, err = escapeChar(args[2])
+			if err == nil {
+				re, _, err = likeEscapeCompile(pattern, escape)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value.NewValue(re.MatchString(strings.ToLower(args[0].Actual().(string)))), nil
+}
+
+func (this *ILike) MinArgs() int { return 2 }
+
+func (this *ILike) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *ILike) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		var escape Expression
+		if len(operands) > 2 {
+			escape = operands[2]
+		}
+
+		return NewILike(operands[0], operands[1], escape)
+	}
+}
+
+/*
+Returns the operands and the ESCAPE clause.
+*/
+func (this *ILike) Text(stringer *Stringer) string {
+	return patternText(stringer, this.operands, "ilike")
+}
+
+/*
+Returns the matched expression.
+*/
+func (this *ILike) First() Expression {
+	return this.operands[0]
+}
+
+/*
+Returns the pattern.
+*/
+func (this *ILike) Second() Expression {
+	return this.operands[1]
+}
+
+/*
+Returns the escape character, or nil.
+*/
+func (this *ILike) Escape() Expression {
+	if len(this.operands) > 2 {
+		return this.operands[2]
+	}
+
+	return nil
+}
+
+/*
+Returns the LIKE of the case folded expression, such as
+LOWER(expr) or UPPER(expr), and of the pattern folded in
+the same way.
+*/
+func (this *ILike) FoldedLike(folded Function) Function {
+	pattern := folded.Constructor()(this.Second())
+	if escape := this.Escape(); escape != nil {
+		return NewLikeEscape(folded, pattern, escape)
+	}
+
+	return NewLike(folded, pattern)
+}
+
+/*
+This function implements the NOT ILIKE operation.
+*/
+func NewNotILike(first, second, escape Expression) Expression {
+	return NewNot(NewILike(first, second, escape))
+}
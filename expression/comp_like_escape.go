@@ -0,0 +1,235 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents LIKE with an ESCAPE clause, expr LIKE pattern
+ESCAPE char. The escape character, instead of the backslash,
+makes the following '%', '_' or escape character match itself.
+*/
+type LikeEscape struct {
+	FunctionBase
+	re   *regexp.Regexp
+	part *regexp.Regexp
+}
+
+func NewLikeEscape(first, second, escape Expression) Function {
+	rv := &LikeEscape{
+		*NewFunctionBase("like_escape", first, second, escape),
+		nil,
+		nil,
+	}
+
+	rv.re, rv.part, _ = precompileLikeEscape(second.Value(), escape.Value())
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *LikeEscape) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *LikeEscape) Type() value.Type { return value.BOOLEAN }
+
+func (this *LikeEscape) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+/*
+If this expression is in the WHERE clause of a partial index, lists
+the Expressions that are implicitly covered.
+
+For LIKE, simply list this expression.
+*/
+func (this *LikeEscape) FilterCovers(covers map[string]value.Value) map[string]value.Value {
+	covers[this.String()] = value.TRUE_VALUE
+	return covers
+}
+
+func (this *LikeEscape) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	for _, arg := range args {
+		if arg.Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	re := this.re
+	if re == nil {
+		escape, err := escapeChar(args[2])
+		if err != nil {
+			return nil, err
+		}
+
+		re, _, err = likeEscapeCompile(args[1].Actual().(string), escape)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value.NewValue(re.MatchString(args[0].Actual().(string))), nil
+}
+
+func (this *LikeEscape) MinArgs() int { return 3 }
+
+func (this *LikeEscape) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *LikeEscape) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewLikeEscape(operands[0], operands[1], operands[2])
+	}
+}
+
+/*
+Returns the operands and the ESCAPE clause.
+*/
+func (this *LikeEscape) Text(stringer *Stringer) string {
+	return patternText(stringer, this.operands, "like")
+}
+
+/*
+Returns the matched expression.
+*/
+func (this *LikeEscape) First() Expression {
+	return this.operands[0]
+}
+
+/*
+Returns the pattern.
+*/
+func (this *LikeEscape) Second() Expression {
+	return this.operands[1]
+}
+
+/*
+Returns the escape character.
+*/
+func (this *LikeEscape) Escape() Expression {
+	return this.operands[2]
+}
+
+/*
+Return the regular expression without delimiters.
+*/
+func (this *LikeEscape) Regexp() *regexp.Regexp {
+	return this.part
+}
+
+/*
+This function implements the NOT LIKE ... ESCAPE operation.
+*/
+func NewNotLikeEscape(first, second, escape Expression) Expression {
+	return NewNot(NewLikeEscape(first, second, escape))
+}
+
+func precompileLikeEscape(sv, ev value.Value) (re, part *regexp.Regexp, err error) {
+	if sv == nil || sv.Type() != value.STRING || ev == nil {
+		return
+	}
+
+	escape, err := escapeChar(ev)
+	if err != nil {
+		return
+	}
+
+	return likeEscapeCompile(sv.Actual().(string), escape)
+}
+
+/*
+Returns the single character of the escape value.
+*/
+func escapeChar(ev value.Value) (rune, error) {
+	if ev.Type() == value.STRING {
+		s := ev.Actual().(string)
+		if r, n := utf8.DecodeRuneInString(s); n > 0 && n == len(s) {
+			return r, nil
+		}
+	}
+
+	return 0, fmt.Errorf("The ESCAPE clause must be a single character: %v.", ev.Actual())
+}
+
+/*
+Compiles the LIKE pattern with the escape character into regular
+expressions, as likeCompile() does for the backslash. The full
+expression is anchored at both ends.
+*/
+func likeEscapeCompile(s string, escape rune) (re, part *regexp.Regexp, err error) {
+	var buf bytes.Buffer
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == escape:
+			escaped = true
+		case r == '%':
+			buf.WriteString("(.*)")
+		case r == '_':
+			buf.WriteString("(.)")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	if escaped {
+		return nil, nil, fmt.Errorf("The pattern %s ends with the escape character.", s)
+	}
+
+	part, err = regexp.Compile(buf.String())
+	if err != nil {
+		return
+	}
+
+	re, err = regexp.Compile("(?s)^" + buf.String() + "$")
+	return
+}
+
+/*
+Returns the text of LIKE, ILIKE and SIMILAR TO, with the ESCAPE
+clause if any.
+*/
+func patternText(stringer *Stringer, operands Expressions, op string) string {
+	var buf bytes.Buffer
+	buf.WriteString("(")
+	buf.WriteString(stringer.Visit(operands[0]))
+	buf.WriteString(" ")
+	buf.WriteString(op)
+	buf.WriteString(" ")
+	buf.WriteString(stringer.Visit(operands[1]))
+	if len(operands) > 2 {
+		buf.WriteString(" escape ")
+		buf.WriteString(stringer.Visit(operands[2]))
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
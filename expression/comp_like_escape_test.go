@@ -0,0 +1,73 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func testPattern(expr Expression, expected bool, t *testing.T) {
+	rv, err := expr.Evaluate(nil, nil)
+	if err != nil {
+		t.Errorf("%v: received error %v", expr, err)
+	} else if rv.Type() != value.BOOLEAN || rv.Truth() != expected {
+		t.Errorf("%v: expected %v, received %v", expr, expected, rv)
+	}
+}
+
+func TestLikeEscape(t *testing.T) {
+	c := func(v interface{}) Expression { return NewConstant(v) }
+
+	testPattern(NewLikeEscape(c("10%"), c("10!%"), c("!")), true, t)
+	testPattern(NewLikeEscape(c("100"), c("10!%"), c("!")), false, t)
+	testPattern(NewLikeEscape(c("a!b"), c("a!!b"), c("!")), true, t)
+	testPattern(NewLikeEscape(c(`a\b`), c(`a\b`), c("!")), true, t)
+	testPattern(NewLikeEscape(c("a\nb"), c("a_b"), c("!")), true, t)
+
+	_, err := NewLikeEscape(c("ab"), c("a%"), c("xy")).Evaluate(nil, nil)
+	if err == nil {
+		t.Errorf("expected an error for a long ESCAPE")
+	}
+
+	_, err = NewLikeEscape(c("ab"), c("a!"), c("!")).Evaluate(nil, nil)
+	if err == nil {
+		t.Errorf("expected an error for a trailing escape character")
+	}
+}
+
+func TestILike(t *testing.T) {
+	c := func(v interface{}) Expression { return NewConstant(v) }
+
+	testPattern(NewILike(c("DaMien"), c("dam%"), nil), true, t)
+	testPattern(NewILike(c("ÄBC"), c("äb_"), nil), true, t)
+	testPattern(NewILike(c("A_B"), c("a#_b"), c("#")), true, t)
+	testPattern(NewILike(c("AxB"), c("a#_b"), c("#")), false, t)
+
+	like := NewILike(NewIdentifier("x"), c("Ab%"), nil).(*ILike).FoldedLike(NewLower(NewIdentifier("x")))
+	if s := like.String(); s != `(lower(`+"`x`"+`) like lower("Ab%"))` {
+		t.Errorf("unexpected folded LIKE %s", s)
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	c := func(v interface{}) Expression { return NewConstant(v) }
+
+	testPattern(NewSimilar(c("marty"), c("(d|m)%"), nil), true, t)
+	testPattern(NewSimilar(c("steve"), c("(d|m)%"), nil), false, t)
+	testPattern(NewSimilar(c("abab"), c("(ab){2}"), nil), true, t)
+	testPattern(NewSimilar(c("abc"), c("[a-c]+"), nil), true, t)
+	testPattern(NewSimilar(c("a.b"), c("a.b"), nil), true, t)
+	testPattern(NewSimilar(c("axb"), c("a.b"), nil), false, t)
+	testPattern(NewSimilar(c("a+b"), c(`a\+b`), nil), true, t)
+	testPattern(NewSimilar(c("a%b"), c("a#%b"), c("#")), true, t)
+	testPattern(NewSimilar(c("ab\nc"), c("ab"), nil), false, t)
+}
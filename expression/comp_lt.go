@@ -55,7 +55,7 @@ func (this *LT) FilterCovers(covers map[string]value.Value) map[string]value.Val
 }
 
 func (this *LT) Apply(context Context, first, second value.Value) (value.Value, error) {
-	cmp := first.Compare(second)
+	cmp := collatedCompare(this, first, second)
 	switch actual := cmp.Actual().(type) {
 	case float64:
 		return value.NewValue(actual < 0), nil
@@ -0,0 +1,250 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents expr SIMILAR TO pattern [ESCAPE char], which
+matches the whole string against a SQL regular expression. The
+pattern has the LIKE wildcards '%' and '_', and the regular
+expression operators | * + ? {m,n} ( ) and [...]. Other
+characters match themselves, as do the characters following
+the escape character, which is the backslash by default.
+*/
+type Similar struct {
+	FunctionBase
+	re   *regexp.Regexp
+	part *regexp.Regexp
+}
+
+func NewSimilar(first, second, escape Expression) Function {
+	operands := Expressions{first, second}
+	if escape != nil {
+		operands = append(operands, escape)
+	}
+
+	rv := &Similar{
+		*NewFunctionBase("similar_to", operands...),
+		nil,
+		nil,
+	}
+
+	if pv := second.Value(); pv != nil && pv.Type() == value.STRING {
+		escape, err := rv.escape(nil)
+		if err == nil {
+			rv.re, rv.part, _ = similarCompile(pv.Actual().(string), escape)
+		}
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Similar) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Similar) Type() value.Type { return value.BOOLEAN }
+
+func (this *Similar) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+/*
+If this expression is in the WHERE clause of a partial index, lists
+the Expressions that are implicitly covered.
+
+For SIMILAR TO, simply list this expression.
+*/
+func (this *Similar) FilterCovers(covers map[string]value.Value) map[string]value.Value {
+	covers[this.String()] = value.TRUE_VALUE
+	return covers
+}
+
+func (this *Similar) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	for _, arg := range args {
+		if arg.Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	re := this.re
+	if re == nil {
+		escape, err := this.escape(args)
+		if err != nil {
+			return nil, err
+		}
+
+		re, _, err = similarCompile(args[1].Actual().(string), escape)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value.NewValue(re.MatchString(args[0].Actual().(string))), nil
+}
+
+/*
+Returns the escape character, from the evaluated arguments or,
+if they are nil, from the constant ESCAPE clause.
+*/
+func (this *Similar) escape(args []value.Value) (rune, error) {
+	if len(this.operands) < 3 {
+		return '\\', nil
+	}
+
+	if args != nil {
+		return escapeChar(args[2])
+	}
+
+	ev := this.operands[2].Value()
+	if ev == nil {
+		return 0, fmt.Errorf("The ESCAPE clause is not a constant.")
+	}
+
+	return escapeChar(ev)
+}
+
+func (this *Similar) MinArgs() int { return 2 }
+
+func (this *Similar) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *Similar) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		var escape Expression
+		if len(operands) > 2 {
+			escape = operands[2]
+		}
+
+		return NewSimilar(operands[0], operands[1], escape)
+	}
+}
+
+/*
+Returns the operands and the ESCAPE clause.
+*/
+func (this *Similar) Text(stringer *Stringer) string {
+	return patternText(stringer, this.operands, "similar to")
+}
+
+/*
+Returns the matched expression.
+*/
+func (this *Similar) First() Expression {
+	return this.operands[0]
+}
+
+/*
+Returns the pattern.
+*/
+func (this *Similar) Second() Expression {
+	return this.operands[1]
+}
+
+/*
+Returns the escape character, or nil.
+*/
+func (this *Similar) Escape() Expression {
+	if len(this.operands) > 2 {
+		return this.operands[2]
+	}
+
+	return nil
+}
+
+/*
+Return the regular expression without delimiters.
+*/
+func (this *Similar) Regexp() *regexp.Regexp {
+	return this.part
+}
+
+/*
+This function implements the NOT SIMILAR TO operation.
+*/
+func NewNotSimilar(first, second, escape Expression) Expression {
+	return NewNot(NewSimilar(first, second, escape))
+}
+
+/*
+Compiles the SQL regular expression into regular expressions,
+with the wildcards as capturing groups like likeCompile() and
+the parentheses as non-capturing groups. The full expression is
+anchored at both ends.
+*/
+func similarCompile(s string, escape rune) (re, part *regexp.Regexp, err error) {
+	var buf bytes.Buffer
+	escaped := false
+	class := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == escape:
+			escaped = true
+		case class:
+			if r == ']' {
+				class = false
+				buf.WriteRune(r)
+			} else if r == '\\' || r == '[' {
+				buf.WriteRune('\\')
+				buf.WriteRune(r)
+			} else {
+				buf.WriteRune(r)
+			}
+		case r == '%':
+			buf.WriteString("(.*)")
+		case r == '_':
+			buf.WriteString("(.)")
+		case r == '(':
+			buf.WriteString("(?:")
+		case r == '[':
+			class = true
+			buf.WriteRune(r)
+		case r == '|' || r == '*' || r == '+' || r == '?' || r == '{' || r == '}' || r == ')':
+			buf.WriteRune(r)
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	if escaped {
+		return nil, nil, fmt.Errorf("The pattern %s ends with the escape character.", s)
+	}
+
+	part, err = regexp.Compile(buf.String())
+	if err != nil {
+		return
+	}
+
+	re, err = regexp.Compile("(?s)^(?:" + buf.String() + ")$")
+	return
+}
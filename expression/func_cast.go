@@ -0,0 +1,699 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// Cast
+//
+///////////////////////////////////////////////////
+
+/*
+This represents CAST(expr AS type [FORMAT fmt]) and
+TRY_CAST(expr AS type [FORMAT fmt]). Unlike the TO_ type
+conversion functions, CAST raises an error when the value
+cannot be converted to the type; TRY_CAST returns NULL
+instead. Missing and null map to themselves. The types are:
+
+	string     strings, and numbers, booleans, arrays and
+	           objects in their JSON representation
+	number     numbers, false as 0, true as 1, and strings
+	           that parse as numbers
+	integer    as number, rounded to the nearest integer
+	boolean    booleans, numbers as true unless 0, and the
+	           strings true, false, t, f, yes, no, on, off,
+	           1 and 0
+	array      arrays, and strings holding JSON arrays
+	object     objects, and strings holding JSON objects
+	date       dates as "2006-01-02", from date strings and
+	           from milliseconds since the epoch
+	timestamp  as date, in the default date format
+
+FORMAT applies to the conversion of strings to number,
+integer, date and timestamp, and of numbers to string. See
+parseNumberFormat() and dateLayout() for the formats.
+*/
+type Cast struct {
+	FunctionBase
+	castType string
+	try      bool
+	re       *regexp.Regexp
+}
+
+func NewCast(operand Expression, castType string, format Expression, try bool) Function {
+	name := "cast"
+	if try {
+		name = "try_cast"
+	}
+
+	operands := Expressions{operand}
+	if format != nil {
+		operands = append(operands, format)
+	}
+
+	rv := &Cast{
+		*NewFunctionBase(name, operands...),
+		strings.ToLower(castType),
+		try,
+		nil,
+	}
+
+	if format != nil && rv.numeric() {
+		if fv := format.Value(); fv != nil && fv.Type() == value.STRING {
+			rv.re, _ = numberFormatRegexp(fv.Actual().(string))
+		}
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Returns true if the name is one of the CAST types.
+*/
+func IsCastType(name string) bool {
+	_, ok := _CAST_TYPES[strings.ToLower(name)]
+	return ok
+}
+
+var _CAST_TYPES = map[string]value.Type{
+	"string":    value.STRING,
+	"number":    value.NUMBER,
+	"integer":   value.NUMBER,
+	"boolean":   value.BOOLEAN,
+	"array":     value.ARRAY,
+	"object":    value.OBJECT,
+	"date":      value.STRING,
+	"timestamp": value.STRING,
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Cast) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Cast) Type() value.Type { return _CAST_TYPES[this.castType] }
+
+func (this *Cast) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *Cast) Apply(context Context, args ...value.Value) (value.Value, error) {
+	arg := args[0]
+	if arg.Type() <= value.NULL {
+		return arg, nil
+	}
+
+	format := ""
+	if len(args) > 1 {
+		switch args[1].Type() {
+		case value.MISSING, value.NULL:
+			return args[1], nil
+		case value.STRING:
+			format = args[1].Actual().(string)
+		default:
+			return nil, fmt.Errorf("The FORMAT of %s must be a string: %v.",
+				strings.ToUpper(this.Name()), args[1].Actual())
+		}
+	}
+
+	rv, err := this.convert(arg, format, len(args) > 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if rv == nil {
+		if this.try {
+			return value.NULL_VALUE, nil
+		}
+
+		return nil, fmt.Errorf("Cannot cast %s %s to %s.", arg.Type().String(),
+			arg.String(), strings.ToUpper(this.castType))
+	}
+
+	return rv, nil
+}
+
+/*
+Returns the converted value, or nil if the value cannot be
+converted. Errors are returned for invalid formats.
+*/
+func (this *Cast) convert(arg value.Value, format string, hasFormat bool) (value.Value, error) {
+	if hasFormat && !this.numeric() && this.castType != "date" && this.castType != "timestamp" &&
+		(this.castType != "string" || arg.Type() != value.NUMBER) {
+		return nil, fmt.Errorf("FORMAT is not supported in %s from %s to %s.",
+			strings.ToUpper(this.Name()), arg.Type().String(), strings.ToUpper(this.castType))
+	}
+
+	switch this.castType {
+	case "string":
+		return castString(arg, format, hasFormat)
+	case "number", "integer":
+		return this.castNumber(arg, format, hasFormat)
+	case "boolean":
+		return castBoolean(arg), nil
+	case "array", "object":
+		return castJSON(arg, _CAST_TYPES[this.castType]), nil
+	default:
+		return castDate(arg, format, hasFormat, this.castType == "date")
+	}
+}
+
+func (this *Cast) numeric() bool {
+	return this.castType == "number" || this.castType == "integer"
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Cast) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		var format Expression
+		if len(operands) > 1 {
+			format = operands[1]
+		}
+
+		return NewCast(operands[0], this.castType, format, this.try)
+	}
+}
+
+/*
+Returns the name, the operand, the type and the FORMAT clause.
+*/
+func (this *Cast) Text(stringer *Stringer) string {
+	var buf bytes.Buffer
+	buf.WriteString(this.Name())
+	buf.WriteString("(")
+	buf.WriteString(stringer.Visit(this.operands[0]))
+	buf.WriteString(" as ")
+	buf.WriteString(this.castType)
+	if format := this.Format(); format != nil {
+		buf.WriteString(" format ")
+		buf.WriteString(stringer.Visit(format))
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+/*
+Casts to different types are not equivalent, so that an index
+key CAST(x AS NUMBER) is only used for the same cast.
+*/
+func (this *Cast) EquivalentTo(other Expression) bool {
+	otherCast, ok := other.(*Cast)
+	return ok && this.castType == otherCast.castType && this.try == otherCast.try &&
+		this.FunctionBase.EquivalentTo(other)
+}
+
+func (this *Cast) MinArgs() int { return 1 }
+
+func (this *Cast) MaxArgs() int { return 2 }
+
+/*
+Returns the type of the cast.
+*/
+func (this *Cast) CastType() string {
+	return this.castType
+}
+
+/*
+Returns true for TRY_CAST.
+*/
+func (this *Cast) Try() bool {
+	return this.try
+}
+
+/*
+Returns the FORMAT expression, or nil.
+*/
+func (this *Cast) Format() Expression {
+	if len(this.operands) > 1 {
+		return this.operands[1]
+	}
+
+	return nil
+}
+
+func castString(arg value.Value, format string, hasFormat bool) (value.Value, error) {
+	switch arg.Type() {
+	case value.STRING:
+		return arg, nil
+	case value.BOOLEAN:
+		return value.NewValue(fmt.Sprint(arg.Actual())), nil
+	case value.NUMBER:
+		if hasFormat {
+			return formatNumber(arg.Actual().(float64), format)
+		}
+
+		switch actual := arg.ActualForIndex().(type) {
+		case int64:
+			return value.NewValue(strconv.FormatInt(actual, 10)), nil
+		case float64:
+			return value.NewValue(strconv.FormatFloat(actual, 'f', -1, 64)), nil
+		}
+	case value.ARRAY, value.OBJECT:
+		bytes, err := arg.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewValue(string(bytes)), nil
+	}
+
+	return nil, nil
+}
+
+func (this *Cast) castNumber(arg value.Value, format string, hasFormat bool) (value.Value, error) {
+	var f float64
+
+	switch arg.Type() {
+	case value.NUMBER:
+		if _, ok := arg.ActualForIndex().(int64); ok || this.castType == "number" {
+			return arg, nil
+		}
+
+		f = arg.Actual().(float64)
+	case value.BOOLEAN:
+		if arg.Actual().(bool) {
+			f = 1.0
+		}
+	case value.STRING:
+		s := strings.TrimSpace(arg.Actual().(string))
+		if hasFormat {
+			re := this.re
+			if re == nil {
+				var err error
+				re, err = numberFormatRegexp(format)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			var ok bool
+			f, ok = parseNumber(s, re)
+			if !ok {
+				return nil, nil
+			}
+		} else {
+			var err error
+			f, err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, nil
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, nil
+	}
+
+	if this.castType == "number" {
+		return value.NewValue(f), nil
+	}
+
+	f = math.Round(f)
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return nil, nil
+	}
+
+	return value.NewValue(int64(f)), nil
+}
+
+func castBoolean(arg value.Value) value.Value {
+	switch arg.Type() {
+	case value.BOOLEAN:
+		return arg
+	case value.NUMBER:
+		return value.NewValue(arg.Actual().(float64) != 0.0)
+	case value.STRING:
+		switch strings.ToLower(strings.TrimSpace(arg.Actual().(string))) {
+		case "true", "t", "yes", "on", "1":
+			return value.TRUE_VALUE
+		case "false", "f", "no", "off", "0":
+			return value.FALSE_VALUE
+		}
+	}
+
+	return nil
+}
+
+func castJSON(arg value.Value, typ value.Type) value.Value {
+	switch arg.Type() {
+	case typ:
+		return arg
+	case value.STRING:
+		var actual interface{}
+		err := json.Unmarshal([]byte(arg.Actual().(string)), &actual)
+		if err != nil {
+			return nil
+		}
+
+		rv := value.NewValue(actual)
+		if rv.Type() == typ {
+			return rv
+		}
+	}
+
+	return nil
+}
+
+func castDate(arg value.Value, format string, hasFormat, date bool) (value.Value, error) {
+	var t time.Time
+
+	switch arg.Type() {
+	case value.STRING:
+		s := strings.TrimSpace(arg.Actual().(string))
+		var err error
+		if hasFormat {
+			var layout string
+			layout, err = dateLayout(format)
+			if err != nil {
+				return nil, err
+			}
+
+			t, err = time.ParseInLocation(layout, s, time.Local)
+		} else {
+			t, err = strToTime(s)
+		}
+
+		if err != nil {
+			return nil, nil
+		}
+	case value.NUMBER:
+		t = millisToTime(arg.Actual().(float64))
+	default:
+		return nil, nil
+	}
+
+	if date {
+		return value.NewValue(t.Format("2006-01-02")), nil
+	}
+
+	return value.NewValue(t.Format(_DEFAULT_FORMAT)), nil
+}
+
+/*
+Number formats are made of the following elements:
+
+	9    a digit, which may be omitted if leading or trailing
+	0    a digit
+	.    the decimal point
+	,    a group separator, which may be omitted
+	S    a sign, + or -, at the start or end of the format
+	$    a dollar sign
+	%    a percent sign, which does not scale the number
+
+Spaces are allowed. A number without a sign in the format
+may be preceded by a minus sign.
+*/
+type numberFormat struct {
+	intPart  string
+	fracPart string
+	point    bool
+	prefix   string
+	suffix   string
+	sign     int // -1 at the start, 1 at the end, 0 none
+}
+
+func parseNumberFormat(format string) (*numberFormat, error) {
+	rv := &numberFormat{}
+	f := format
+
+	if strings.HasPrefix(f, "S") {
+		rv.sign = -1
+		f = f[1:]
+	} else if strings.HasSuffix(f, "S") {
+		rv.sign = 1
+		f = f[0 : len(f)-1]
+	}
+
+	start := strings.IndexAny(f, "09,.")
+	end := strings.LastIndexAny(f, "09,.")
+	if start < 0 {
+		return nil, fmt.Errorf("Invalid number format %s.", format)
+	}
+
+	rv.prefix = f[0:start]
+	rv.suffix = f[end+1:]
+	body := f[start : end+1]
+
+	if strings.Trim(rv.prefix+rv.suffix, "$% ") != "" ||
+		strings.Trim(body, "09,.") != "" || strings.Count(body, ".") > 1 {
+		return nil, fmt.Errorf("Invalid number format %s.", format)
+	}
+
+	rv.intPart = body
+	if i := strings.Index(body, "."); i >= 0 {
+		rv.intPart = body[0:i]
+		rv.fracPart = body[i+1:]
+		rv.point = true
+	}
+
+	if strings.Contains(rv.fracPart, ",") {
+		return nil, fmt.Errorf("Invalid number format %s.", format)
+	}
+
+	return rv, nil
+}
+
+/*
+Returns a regular expression matching the numbers of the format,
+with the leading sign, the digits and the trailing sign as its
+capturing groups.
+*/
+func numberFormatRegexp(format string) (*regexp.Regexp, error) {
+	nf, err := parseNumberFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("^")
+
+	switch nf.sign {
+	case -1:
+		buf.WriteString("([+-])")
+	case 0:
+		buf.WriteString("(-?)")
+	default:
+		buf.WriteString("()")
+	}
+
+	buf.WriteString(regexp.QuoteMeta(nf.prefix))
+	buf.WriteString("(")
+	for _, c := range nf.intPart {
+		switch c {
+		case '9':
+			buf.WriteString(`\d?`)
+		case '0':
+			buf.WriteString(`\d`)
+		case ',':
+			buf.WriteString(`,?`)
+		}
+	}
+
+	if nf.point {
+		buf.WriteString(`(?:\.`)
+		for _, c := range nf.fracPart {
+			if c == '0' {
+				buf.WriteString(`\d`)
+			} else {
+				buf.WriteString(`\d?`)
+			}
+		}
+		buf.WriteString(")?")
+	}
+
+	buf.WriteString(")")
+	buf.WriteString(regexp.QuoteMeta(nf.suffix))
+
+	if nf.sign == 1 {
+		buf.WriteString("([+-])")
+	} else {
+		buf.WriteString("()")
+	}
+
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+func parseNumber(s string, re *regexp.Regexp) (float64, bool) {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0.0, false
+	}
+
+	sign := m[1] + m[3]
+	digits := strings.Replace(m[2], ",", "", -1)
+	if strings.Trim(digits, ".") == "" {
+		return 0.0, false
+	}
+
+	f, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0.0, false
+	}
+
+	if sign == "-" {
+		f = -f
+	}
+
+	return f, true
+}
+
+/*
+Formats the number by the format, rounding it to the digits of
+the fraction. Returns nil if the integer part does not fit.
+*/
+func formatNumber(f float64, format string) (value.Value, error) {
+	nf, err := parseNumberFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	fracDigits := strings.Count(nf.fracPart, "9") + strings.Count(nf.fracPart, "0")
+	s := strconv.FormatFloat(math.Abs(f), 'f', fracDigits, 64)
+	ip, fp := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		ip, fp = s[0:i], s[i+1:]
+	}
+
+	if strings.Count(nf.intPart, "9")+strings.Count(nf.intPart, "0") < len(ip) {
+		return nil, nil
+	}
+
+	// Fill the integer digits from the right
+	out := make([]byte, 0, len(nf.intPart)+len(ip))
+	d := len(ip) - 1
+	for i := len(nf.intPart) - 1; i >= 0; i-- {
+		switch c := nf.intPart[i]; c {
+		case '9', '0':
+			if d >= 0 {
+				out = append(out, ip[d])
+				d--
+			} else if c == '0' {
+				out = append(out, '0')
+			}
+		case ',':
+			if d >= 0 || strings.Contains(nf.intPart[0:i], "0") {
+				out = append(out, ',')
+			}
+		}
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case nf.sign == -1 && f < 0:
+		buf.WriteString("-")
+	case nf.sign == -1:
+		buf.WriteString("+")
+	case nf.sign == 0 && f < 0:
+		buf.WriteString("-")
+	}
+
+	buf.WriteString(nf.prefix)
+	buf.Write(out)
+	if nf.point {
+		buf.WriteString(".")
+		buf.WriteString(fp)
+	}
+	buf.WriteString(nf.suffix)
+
+	switch {
+	case nf.sign == 1 && f < 0:
+		buf.WriteString("-")
+	case nf.sign == 1:
+		buf.WriteString("+")
+	}
+
+	return value.NewValue(buf.String()), nil
+}
+
+/*
+Date formats are made of the following elements, and of spaces,
+punctuation and the letter T, which are matched as they are:
+
+	YYYY  year              HH24  hour, 00-23
+	YY    year, 2 digits    HH12  hour, 01-12
+	MONTH month name        HH    hour, 00-23
+	MON   month, 3 letters  MI    minute
+	MM    month, 01-12      SS    second
+	DD    day, 01-31        AM    AM or PM
+	TZ    zone as Z or +07:00
+
+Fractions of seconds are accepted after the seconds.
+*/
+func dateLayout(format string) (string, error) {
+	var buf bytes.Buffer
+	f := strings.ToUpper(format)
+
+outer:
+	for len(f) > 0 {
+		for _, elem := range _DATE_ELEMENTS {
+			if strings.HasPrefix(f, elem[0]) {
+				buf.WriteString(elem[1])
+				f = f[len(elem[0]):]
+				continue outer
+			}
+		}
+
+		c := f[0]
+		if c == 'T' || (c < 0x80 && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') && c != '_') {
+			buf.WriteByte(c)
+			f = f[1:]
+			continue
+		}
+
+		return "", fmt.Errorf("Invalid date format %s.", format)
+	}
+
+	return buf.String(), nil
+}
+
+/*
+Longer elements before their prefixes.
+*/
+var _DATE_ELEMENTS = [][2]string{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MONTH", "January"},
+	{"MON", "Jan"},
+	{"MM", "01"},
+	{"DD", "02"},
+	{"HH24", "15"},
+	{"HH12", "03"},
+	{"HH", "15"},
+	{"MI", "04"},
+	{"SS", "05"},
+	{"AM", "PM"},
+	{"PM", "PM"},
+	{"TZ", "Z07:00"},
+}
@@ -0,0 +1,114 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func testCast(operand interface{}, castType string, format interface{}, expected interface{}, t *testing.T) {
+	var f Expression
+	if format != nil {
+		f = NewConstant(format)
+	}
+
+	c := NewCast(NewConstant(operand), castType, f, false)
+	rv, err := c.Evaluate(nil, nil)
+	if expected == nil {
+		if err == nil {
+			t.Errorf("%v: expected an error, received %v", c, rv)
+		}
+
+		tc := NewCast(NewConstant(operand), castType, f, true)
+		rv, err = tc.Evaluate(nil, nil)
+		if err == nil && rv.Type() != value.NULL {
+			t.Errorf("%v: expected NULL, received %v", tc, rv)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Errorf("%v: received error %v", c, err)
+	} else if !value.NewValue(expected).Equals(rv).Truth() {
+		t.Errorf("%v: expected %v, received %v", c, expected, rv)
+	}
+}
+
+func TestCast(t *testing.T) {
+	testCast(12.5, "string", nil, "12.5", t)
+	testCast(true, "string", nil, "true", t)
+	testCast([]interface{}{1, "a"}, "string", nil, `[1,"a"]`, t)
+	testCast(" 12.5 ", "number", nil, 12.5, t)
+	testCast("12a", "number", nil, nil, t)
+	testCast(true, "number", nil, 1, t)
+	testCast(2.5, "integer", nil, 3, t)
+	testCast("-7.4", "integer", nil, -7, t)
+	testCast("Yes", "boolean", nil, true, t)
+	testCast(0, "boolean", nil, false, t)
+	testCast("maybe", "boolean", nil, nil, t)
+	testCast(`[1, 2]`, "array", nil, []interface{}{1, 2}, t)
+	testCast(`{"a": 1}`, "array", nil, nil, t)
+	testCast(`{"a": 1}`, "object", nil, map[string]interface{}{"a": 1}, t)
+	testCast(5, "object", nil, nil, t)
+	testCast("2019-03-04T05:06:07Z", "date", nil, "2019-03-04", t)
+	testCast("2019-03-04T05:06:07.5+01:00", "timestamp", nil, "2019-03-04T05:06:07.5+01:00", t)
+	testCast("March", "date", nil, nil, t)
+}
+
+func TestCastFormat(t *testing.T) {
+	testCast("$1,234.50", "number", "$9,999.99", 1234.5, t)
+	testCast("1234", "number", "9,999", 1234, t)
+	testCast("12345", "number", "9,999", nil, t)
+	testCast("12-", "integer", "99S", -12, t)
+	testCast("-12", "number", "999", -12, t)
+	testCast(1234.5, "string", "$9,999.00", "$1,234.50", t)
+	testCast(-5, "string", "S000", "-005", t)
+	testCast(12345, "string", "9,999", nil, t)
+	testCast("04/03/2019", "date", "DD/MM/YYYY", "2019-03-04", t)
+	testCast("4 Mar 2019", "date", "DD MON YYYY", nil, t)
+	testCast("04 Mar 2019", "date", "DD MON YYYY", "2019-03-04", t)
+	testCast("2019-13-01", "date", "YYYY-MM-DD", nil, t)
+
+	c := NewCast(NewConstant("1"), "boolean", NewConstant("9"), true)
+	if _, err := c.Evaluate(nil, nil); err == nil {
+		t.Errorf("%v: expected an error for FORMAT", c)
+	}
+
+	c = NewCast(NewConstant("1"), "number", NewConstant("9x9"), true)
+	if _, err := c.Evaluate(nil, nil); err == nil {
+		t.Errorf("%v: expected an error for the invalid format", c)
+	}
+}
+
+func TestCastEquivalence(t *testing.T) {
+	x := NewIdentifier("x")
+	c := NewCast(x, "number", nil, false)
+
+	if !c.EquivalentTo(NewCast(NewIdentifier("x"), "NUMBER", nil, false)) {
+		t.Errorf("%v is not equivalent to itself", c)
+	}
+
+	for _, other := range []Expression{
+		NewCast(x, "integer", nil, false),
+		NewCast(x, "number", nil, true),
+		NewCast(x, "number", NewConstant("999"), false),
+		NewToNumber(x),
+	} {
+		if c.EquivalentTo(other) {
+			t.Errorf("%v is equivalent to %v", c, other)
+		}
+	}
+
+	if s := c.String(); s != "cast(`x` as number)" {
+		t.Errorf("unexpected text %s", s)
+	}
+}
@@ -0,0 +1,1269 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+/*
+The geospatial functions take GeoJSON Point and Polygon objects, arrays
+of two numbers as points, [ longitude, latitude ], and arrays of four
+numbers as bounding boxes, [ west, south, east, north ]. Polygon edges
+are straight lines in longitude and latitude, as in GeoJSON; boxes do
+not cross the antimeridian. Distances are great circle distances on a
+spherical earth, in meters unless stated otherwise.
+*/
+
+/*
+GeoPredicate is implemented by the spatial predicates that only hold
+when a geometry lies within a region. Such a predicate can use an
+index on the geohash of the geometry, when the region is constant.
+*/
+type GeoPredicate interface {
+	Function
+
+	/*
+	   The geometry that must lie within the region.
+	*/
+	Geometry() Expression
+
+	/*
+	   The bounding box of the region, as [ west, south, east, north ],
+	   if the region is constant and valid.
+	*/
+	SearchBox() ([]float64, bool)
+}
+
+///////////////////////////////////////////////////
+//
+// StPoint
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_POINT(lon, lat). It
+returns a GeoJSON Point, or NULL if the coordinates are out of
+range.
+*/
+type StPoint struct {
+	BinaryFunctionBase
+}
+
+func NewStPoint(first, second Expression) Function {
+	rv := &StPoint{
+		*NewBinaryFunctionBase("st_point", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StPoint) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StPoint) Type() value.Type { return value.OBJECT }
+
+func (this *StPoint) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *StPoint) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if first.Type() != value.NUMBER || second.Type() != value.NUMBER {
+		return value.NULL_VALUE, nil
+	}
+
+	p := geoPoint{first.Actual().(float64), second.Actual().(float64)}
+	if !p.valid() {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(p.object()), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *StPoint) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewStPoint(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// StDistance
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_DISTANCE(point1, point2
+[, unit ]). It returns the haversine distance between the points, in
+"m" (the default), "km" or "mi".
+*/
+type StDistance struct {
+	FunctionBase
+}
+
+func NewStDistance(operands ...Expression) Function {
+	rv := &StDistance{
+		*NewFunctionBase("st_distance", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StDistance) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StDistance) Type() value.Type { return value.NUMBER }
+
+func (this *StDistance) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *StDistance) Apply(context Context, args ...value.Value) (value.Value, error) {
+	null := false
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		} else if arg.Type() == value.NULL {
+			null = true
+		}
+	}
+
+	if null {
+		return value.NULL_VALUE, nil
+	}
+
+	unit := 1.0
+	if len(args) > 2 {
+		if args[2].Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+
+		u, ok := geoUnits[strings.ToLower(args[2].Actual().(string))]
+		if !ok {
+			return value.NULL_VALUE, nil
+		}
+		unit = u
+	}
+
+	g1 := newGeometry(args[0])
+	g2 := newGeometry(args[1])
+	if g1 == nil || g2 == nil || g1.point == nil || g2.point == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(geoDistance(*g1.point, *g2.point) / unit), nil
+}
+
+/*
+Minimum input arguments required is 2.
+*/
+func (this *StDistance) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed is 3.
+*/
+func (this *StDistance) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *StDistance) Constructor() FunctionConstructor {
+	return NewStDistance
+}
+
+var geoUnits = map[string]float64{
+	"m":  1.0,
+	"km": 1000.0,
+	"mi": 1609.344,
+}
+
+///////////////////////////////////////////////////
+//
+// StDWithin
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_DWITHIN(point, center,
+meters). It returns true if the point is within the given distance
+of the center.
+*/
+type StDWithin struct {
+	FunctionBase
+}
+
+func NewStDWithin(operands ...Expression) Function {
+	rv := &StDWithin{
+		*NewFunctionBase("st_dwithin", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StDWithin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StDWithin) Type() value.Type { return value.BOOLEAN }
+
+func (this *StDWithin) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *StDWithin) Apply(context Context, args ...value.Value) (value.Value, error) {
+	null := false
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		} else if arg.Type() == value.NULL {
+			null = true
+		}
+	}
+
+	if null || args[2].Type() != value.NUMBER {
+		return value.NULL_VALUE, nil
+	}
+
+	g := newGeometry(args[0])
+	center := newGeometry(args[1])
+	if g == nil || center == nil || g.point == nil || center.point == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(geoDistance(*g.point, *center.point) <= args[2].Actual().(float64)), nil
+}
+
+/*
+Minimum input arguments required is 3.
+*/
+func (this *StDWithin) MinArgs() int { return 3 }
+
+/*
+Maximum input arguments allowed is 3.
+*/
+func (this *StDWithin) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *StDWithin) Constructor() FunctionConstructor {
+	return NewStDWithin
+}
+
+func (this *StDWithin) Geometry() Expression {
+	return this.operands[0]
+}
+
+/*
+The bounding box of the circle.
+*/
+func (this *StDWithin) SearchBox() ([]float64, bool) {
+	center := newGeometry(this.operands[1].Value())
+	distance := this.operands[2].Value()
+	if center == nil || center.point == nil ||
+		distance == nil || distance.Type() != value.NUMBER {
+		return nil, false
+	}
+
+	meters := distance.Actual().(float64)
+	if meters < 0 {
+		return nil, false
+	}
+
+	return geoCircleBox(*center.point, meters), true
+}
+
+///////////////////////////////////////////////////
+//
+// StWithin
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_WITHIN(geometry, region).
+It returns true if the geometry lies within the region, boundary
+included.
+*/
+type StWithin struct {
+	BinaryFunctionBase
+}
+
+func NewStWithin(first, second Expression) Function {
+	rv := &StWithin{
+		*NewBinaryFunctionBase("st_within", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StWithin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StWithin) Type() value.Type { return value.BOOLEAN }
+
+func (this *StWithin) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *StWithin) Apply(context Context, first, second value.Value) (value.Value, error) {
+	return geoApply(first, second, geoWithin)
+}
+
+/*
+Factory method pattern.
+*/
+func (this *StWithin) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewStWithin(operands[0], operands[1])
+	}
+}
+
+func (this *StWithin) Geometry() Expression {
+	return this.First()
+}
+
+func (this *StWithin) SearchBox() ([]float64, bool) {
+	return geoSearchBox(this.Second())
+}
+
+///////////////////////////////////////////////////
+//
+// StContains
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_CONTAINS(region, geometry).
+It returns true if the region contains the geometry, boundary
+included.
+*/
+type StContains struct {
+	BinaryFunctionBase
+}
+
+func NewStContains(first, second Expression) Function {
+	rv := &StContains{
+		*NewBinaryFunctionBase("st_contains", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StContains) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StContains) Type() value.Type { return value.BOOLEAN }
+
+func (this *StContains) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *StContains) Apply(context Context, first, second value.Value) (value.Value, error) {
+	return geoApply(second, first, geoWithin)
+}
+
+/*
+Factory method pattern.
+*/
+func (this *StContains) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewStContains(operands[0], operands[1])
+	}
+}
+
+func (this *StContains) Geometry() Expression {
+	return this.Second()
+}
+
+func (this *StContains) SearchBox() ([]float64, bool) {
+	return geoSearchBox(this.First())
+}
+
+///////////////////////////////////////////////////
+//
+// StIntersects
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_INTERSECTS(geometry1,
+geometry2). It returns true if the geometries share at least one
+point.
+*/
+type StIntersects struct {
+	BinaryFunctionBase
+}
+
+func NewStIntersects(first, second Expression) Function {
+	rv := &StIntersects{
+		*NewBinaryFunctionBase("st_intersects", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StIntersects) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StIntersects) Type() value.Type { return value.BOOLEAN }
+
+func (this *StIntersects) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *StIntersects) Apply(context Context, first, second value.Value) (value.Value, error) {
+	return geoApply(first, second, geoIntersects)
+}
+
+/*
+Factory method pattern.
+*/
+func (this *StIntersects) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewStIntersects(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// StBBox
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_BBOX(geometry). It
+returns the bounding box of the geometry, as [ west, south, east,
+north ].
+*/
+type StBBox struct {
+	UnaryFunctionBase
+}
+
+func NewStBBox(operand Expression) Function {
+	rv := &StBBox{
+		*NewUnaryFunctionBase("st_bbox", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *StBBox) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *StBBox) Type() value.Type { return value.ARRAY }
+
+func (this *StBBox) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *StBBox) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	g := newGeometry(arg)
+	if g == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(geoBoxValue(g.bbox())), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *StBBox) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewStBBox(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// GeoHash
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_GEOHASH(geometry
+[, precision ]). It returns the geohash of a point, or of the center
+of the bounding box of a polygon, with the given number of characters,
+from 1 to 12. precision is 12 if not given. An index on the geohash
+of a geometry serves the spatial predicates on the geometry.
+*/
+type GeoHash struct {
+	FunctionBase
+}
+
+func NewGeoHash(operands ...Expression) Function {
+	rv := &GeoHash{
+		*NewFunctionBase("st_geohash", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoHash) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoHash) Type() value.Type { return value.STRING }
+
+func (this *GeoHash) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *GeoHash) Apply(context Context, args ...value.Value) (value.Value, error) {
+	arg := args[0]
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	precision := GEOHASH_PRECISION
+	if len(args) > 1 {
+		if args[1].Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+
+		var ok bool
+		precision, ok = geoPrecision(args[1])
+		if !ok {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	g := newGeometry(arg)
+	if g == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	p := g.center()
+	return value.NewValue(geohashEncode(p[0], p[1], precision)), nil
+}
+
+/*
+Minimum input arguments required is 1.
+*/
+func (this *GeoHash) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed is 2.
+*/
+func (this *GeoHash) MaxArgs() int { return 2 }
+
+/*
+Factory method pattern.
+*/
+func (this *GeoHash) Constructor() FunctionConstructor {
+	return NewGeoHash
+}
+
+func (this *GeoHash) Operand() Expression {
+	return this.operands[0]
+}
+
+/*
+The precision, if it is constant and valid.
+*/
+func (this *GeoHash) Precision() (int, bool) {
+	if len(this.operands) == 1 {
+		return GEOHASH_PRECISION, true
+	}
+
+	precision := this.operands[1].Value()
+	if precision == nil {
+		return 0, false
+	}
+
+	return geoPrecision(precision)
+}
+
+const GEOHASH_PRECISION = 12
+
+func geoPrecision(arg value.Value) (int, bool) {
+	if arg.Type() != value.NUMBER {
+		return 0, false
+	}
+
+	p := arg.Actual().(float64)
+	if p != math.Trunc(p) || p < 1 || p > GEOHASH_PRECISION {
+		return 0, false
+	}
+
+	return int(p), true
+}
+
+///////////////////////////////////////////////////
+//
+// GeoHashDecode
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function ST_GEOHASH_DECODE(geohash).
+It returns the GeoJSON Point at the center of the geohash cell, with
+the cell as its bbox.
+*/
+type GeoHashDecode struct {
+	UnaryFunctionBase
+}
+
+func NewGeoHashDecode(operand Expression) Function {
+	rv := &GeoHashDecode{
+		*NewUnaryFunctionBase("st_geohash_decode", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoHashDecode) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoHashDecode) Type() value.Type { return value.OBJECT }
+
+func (this *GeoHashDecode) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *GeoHashDecode) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if arg.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	box, ok := geohashDecode(arg.Actual().(string))
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	p := geoPoint{(box[0] + box[2]) / 2, (box[1] + box[3]) / 2}
+	rv := p.object()
+	rv["bbox"] = geoBoxValue(box)
+	return value.NewValue(rv), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *GeoHashDecode) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewGeoHashDecode(operands[0])
+	}
+}
+
+/*
+GeoHashCover returns the geohash cells, of at most the given
+precision, that cover a bounding box. It uses the longest cells
+whose number does not exceed maxCells, and single character cells
+if there is no such precision. The cells are in geohash order.
+*/
+func GeoHashCover(box []float64, precision, maxCells int) []string {
+	if len(box) != 4 || precision < 1 {
+		return nil
+	}
+
+	if precision > GEOHASH_PRECISION {
+		precision = GEOHASH_PRECISION
+	}
+
+	cover := 1
+	for p := 2; p <= precision; p++ {
+		x0, x1, y0, y1 := geohashCells(box, p)
+		if (x1-x0+1)*(y1-y0+1) > int64(maxCells) {
+			break
+		}
+		cover = p
+	}
+
+	x0, x1, y0, y1 := geohashCells(box, cover)
+	lonStep, latStep := geohashSteps(cover)
+	rv := make([]string, 0, (x1-x0+1)*(y1-y0+1))
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			lon := -180.0 + (float64(x)+0.5)*lonStep
+			lat := -90.0 + (float64(y)+0.5)*latStep
+			rv = append(rv, geohashEncode(lon, lat, cover))
+		}
+	}
+
+	sort.Strings(rv)
+	return rv
+}
+
+/*
+The first and last column and row of the geohash cells of the given
+precision that overlap the box.
+*/
+func geohashCells(box []float64, precision int) (x0, x1, y0, y1 int64) {
+	lonStep, latStep := geohashSteps(precision)
+	lonCells := int64(math.Round(360.0 / lonStep))
+	latCells := int64(math.Round(180.0 / latStep))
+
+	cell := func(v, origin, step float64, cells int64) int64 {
+		c := int64(math.Floor((v - origin) / step))
+		if c < 0 {
+			return 0
+		} else if c >= cells {
+			return cells - 1
+		}
+		return c
+	}
+
+	x0 = cell(box[0], -180.0, lonStep, lonCells)
+	x1 = cell(box[2], -180.0, lonStep, lonCells)
+	y0 = cell(box[1], -90.0, latStep, latCells)
+	y1 = cell(box[3], -90.0, latStep, latCells)
+	return
+}
+
+/*
+The width and height of the geohash cells of the given precision.
+Longitude takes the even bits, starting with the first.
+*/
+func geohashSteps(precision int) (lonStep, latStep float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 360.0 / math.Exp2(float64(lonBits)), 180.0 / math.Exp2(float64(latBits))
+}
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+func geohashEncode(lon, lat float64, precision int) string {
+	minLon, maxLon := -180.0, 180.0
+	minLat, maxLat := -90.0, 90.0
+	buf := make([]byte, precision)
+	even := true
+	bit := 0
+	ch := 0
+
+	for i := 0; i < precision; {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+
+		even = !even
+		bit++
+		if bit == 5 {
+			buf[i] = geohashBase32[ch]
+			i++
+			bit = 0
+			ch = 0
+		}
+	}
+
+	return string(buf)
+}
+
+/*
+The cell of a geohash, as [ west, south, east, north ].
+*/
+func geohashDecode(hash string) ([]float64, bool) {
+	if hash == "" {
+		return nil, false
+	}
+
+	minLon, maxLon := -180.0, 180.0
+	minLat, maxLat := -90.0, 90.0
+	even := true
+
+	for _, c := range strings.ToLower(hash) {
+		ch := strings.IndexRune(geohashBase32, c)
+		if ch < 0 {
+			return nil, false
+		}
+
+		for bit := 4; bit >= 0; bit-- {
+			set := ch&(1<<uint(bit)) != 0
+			if even {
+				mid := (minLon + maxLon) / 2
+				if set {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if set {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+
+	return []float64{minLon, minLat, maxLon, maxLat}, true
+}
+
+/*
+Geometries.
+*/
+
+type geoPoint [2]float64
+
+func (this geoPoint) valid() bool {
+	return this[0] >= -180.0 && this[0] <= 180.0 && this[1] >= -90.0 && this[1] <= 90.0
+}
+
+func (this geoPoint) object() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "Point",
+		"coordinates": []interface{}{this[0], this[1]},
+	}
+}
+
+/*
+Either a point, or a polygon as its outer ring followed by its holes.
+Rings are not closed: the last vertex connects to the first.
+*/
+type geometry struct {
+	point *geoPoint
+	rings [][]geoPoint
+}
+
+/*
+Returns nil if the value is not a valid geometry.
+*/
+func newGeometry(v value.Value) *geometry {
+	if v == nil {
+		return nil
+	}
+
+	switch v.Type() {
+	case value.ARRAY:
+		n := len(v.Actual().([]interface{}))
+		switch n {
+		case 2:
+			p, ok := geoPosition(v)
+			if !ok {
+				return nil
+			}
+			return &geometry{point: &p}
+		case 4:
+			var box [4]float64
+			for i := range box {
+				c, _ := v.Index(i)
+				if c.Type() != value.NUMBER {
+					return nil
+				}
+				box[i] = c.Actual().(float64)
+			}
+
+			sw := geoPoint{box[0], box[1]}
+			ne := geoPoint{box[2], box[3]}
+			if !sw.valid() || !ne.valid() || box[0] > box[2] || box[1] > box[3] {
+				return nil
+			}
+			return &geometry{rings: [][]geoPoint{
+				{sw, {box[2], box[1]}, ne, {box[0], box[3]}},
+			}}
+		}
+	case value.OBJECT:
+		t, ok := v.Field("type")
+		if !ok || t.Type() != value.STRING {
+			return nil
+		}
+
+		coordinates, ok := v.Field("coordinates")
+		if !ok || coordinates.Type() != value.ARRAY {
+			return nil
+		}
+
+		switch t.Actual().(string) {
+		case "Point":
+			p, ok := geoPosition(coordinates)
+			if !ok {
+				return nil
+			}
+			return &geometry{point: &p}
+		case "Polygon":
+			n := len(coordinates.Actual().([]interface{}))
+			if n == 0 {
+				return nil
+			}
+
+			rings := make([][]geoPoint, 0, n)
+			for i := 0; i < n; i++ {
+				r, _ := coordinates.Index(i)
+				ring, ok := geoRing(r)
+				if !ok {
+					return nil
+				}
+				rings = append(rings, ring)
+			}
+			return &geometry{rings: rings}
+		}
+	}
+
+	return nil
+}
+
+/*
+A GeoJSON position: longitude, latitude and an optional altitude,
+which is ignored.
+*/
+func geoPosition(v value.Value) (geoPoint, bool) {
+	var p geoPoint
+	if v.Type() != value.ARRAY {
+		return p, false
+	}
+
+	n := len(v.Actual().([]interface{}))
+	if n < 2 || n > 3 {
+		return p, false
+	}
+
+	for i := range p {
+		c, _ := v.Index(i)
+		if c.Type() != value.NUMBER {
+			return p, false
+		}
+		p[i] = c.Actual().(float64)
+	}
+
+	return p, p.valid()
+}
+
+/*
+A linear ring, with or without its closing position.
+*/
+func geoRing(v value.Value) ([]geoPoint, bool) {
+	if v.Type() != value.ARRAY {
+		return nil, false
+	}
+
+	n := len(v.Actual().([]interface{}))
+	ring := make([]geoPoint, 0, n)
+	for i := 0; i < n; i++ {
+		c, _ := v.Index(i)
+		p, ok := geoPosition(c)
+		if !ok {
+			return nil, false
+		}
+		ring = append(ring, p)
+	}
+
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+
+	return ring, len(ring) >= 3
+}
+
+func (this *geometry) bbox() []float64 {
+	if this.point != nil {
+		return []float64{this.point[0], this.point[1], this.point[0], this.point[1]}
+	}
+
+	box := []float64{180.0, 90.0, -180.0, -90.0}
+	for _, p := range this.rings[0] {
+		box[0] = math.Min(box[0], p[0])
+		box[1] = math.Min(box[1], p[1])
+		box[2] = math.Max(box[2], p[0])
+		box[3] = math.Max(box[3], p[1])
+	}
+	return box
+}
+
+/*
+The point itself, or the center of the bounding box.
+*/
+func (this *geometry) center() geoPoint {
+	if this.point != nil {
+		return *this.point
+	}
+
+	box := this.bbox()
+	return geoPoint{(box[0] + box[2]) / 2, (box[1] + box[3]) / 2}
+}
+
+func geoBoxValue(box []float64) []interface{} {
+	return []interface{}{box[0], box[1], box[2], box[3]}
+}
+
+func geoApply(first, second value.Value, pred func(a, b *geometry) bool) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	a := newGeometry(first)
+	b := newGeometry(second)
+	if a == nil || b == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(pred(a, b)), nil
+}
+
+func geoSearchBox(region Expression) ([]float64, bool) {
+	g := newGeometry(region.Value())
+	if g == nil {
+		return nil, false
+	}
+
+	return g.bbox(), true
+}
+
+/*
+Spatial relations.
+*/
+
+func geoWithin(a, b *geometry) bool {
+	if b.point != nil {
+		return a.point != nil && *a.point == *b.point
+	}
+
+	if a.point != nil {
+		return geoPointInPolygon(*a.point, b.rings) >= 0
+	}
+
+	// every vertex of a is in b, no edges cross, and no hole of b is
+	// inside a
+	outer := a.rings[0]
+	for _, p := range outer {
+		if geoPointInPolygon(p, b.rings) < 0 {
+			return false
+		}
+	}
+
+	for _, ring := range b.rings {
+		for i := range outer {
+			for j := range ring {
+				if geoSegmentsCross(outer[i], outer[(i+1)%len(outer)], ring[j], ring[(j+1)%len(ring)]) {
+					return false
+				}
+			}
+		}
+	}
+
+	for _, hole := range b.rings[1:] {
+		for _, p := range hole {
+			if geoPointInRing(p, outer) > 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func geoIntersects(a, b *geometry) bool {
+	if a.point != nil && b.point != nil {
+		return *a.point == *b.point
+	} else if a.point != nil {
+		return geoPointInPolygon(*a.point, b.rings) >= 0
+	} else if b.point != nil {
+		return geoPointInPolygon(*b.point, a.rings) >= 0
+	}
+
+	for _, r1 := range a.rings {
+		for _, r2 := range b.rings {
+			for i := range r1 {
+				for j := range r2 {
+					if geoSegmentsIntersect(r1[i], r1[(i+1)%len(r1)], r2[j], r2[(j+1)%len(r2)]) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	// no boundaries meet: one is inside the other, or they are apart
+	return geoPointInPolygon(a.rings[0][0], b.rings) >= 0 ||
+		geoPointInPolygon(b.rings[0][0], a.rings) >= 0
+}
+
+/*
+1 if the point is inside the polygon, 0 if it is on its boundary, and
+-1 if it is outside, or inside a hole.
+*/
+func geoPointInPolygon(p geoPoint, rings [][]geoPoint) int {
+	rv := geoPointInRing(p, rings[0])
+	if rv < 0 {
+		return rv
+	}
+
+	for _, hole := range rings[1:] {
+		switch geoPointInRing(p, hole) {
+		case 1:
+			return -1
+		case 0:
+			return 0
+		}
+	}
+
+	return rv
+}
+
+func geoPointInRing(p geoPoint, ring []geoPoint) int {
+	in := false
+	n := len(ring)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := ring[j], ring[i]
+		if geoOnSegment(p, a, b) {
+			return 0
+		}
+
+		if (a[1] > p[1]) != (b[1] > p[1]) {
+			x := a[0] + (p[1]-a[1])*(b[0]-a[0])/(b[1]-a[1])
+			if p[0] < x {
+				in = !in
+			}
+		}
+	}
+
+	if in {
+		return 1
+	}
+	return -1
+}
+
+func geoOrientation(a, b, c geoPoint) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func geoOnSegment(p, a, b geoPoint) bool {
+	return geoOrientation(a, b, p) == 0 &&
+		p[0] >= math.Min(a[0], b[0]) && p[0] <= math.Max(a[0], b[0]) &&
+		p[1] >= math.Min(a[1], b[1]) && p[1] <= math.Max(a[1], b[1])
+}
+
+/*
+True if the segments cross at a point interior to both.
+*/
+func geoSegmentsCross(a1, a2, b1, b2 geoPoint) bool {
+	d1 := geoOrientation(b1, b2, a1)
+	d2 := geoOrientation(b1, b2, a2)
+	d3 := geoOrientation(a1, a2, b1)
+	d4 := geoOrientation(a1, a2, b2)
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) &&
+		((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
+
+/*
+True if the segments share at least one point.
+*/
+func geoSegmentsIntersect(a1, a2, b1, b2 geoPoint) bool {
+	return geoSegmentsCross(a1, a2, b1, b2) ||
+		geoOnSegment(a1, b1, b2) || geoOnSegment(a2, b1, b2) ||
+		geoOnSegment(b1, a1, a2) || geoOnSegment(b2, a1, a2)
+}
+
+/*
+Distances.
+*/
+
+// mean earth radius, in meters
+const geoEarthRadius = 6371008.8
+
+func geoDistance(p, q geoPoint) float64 {
+	lat1 := p[1] * math.Pi / 180.0
+	lat2 := q[1] * math.Pi / 180.0
+	dLat := lat2 - lat1
+	dLon := (q[0] - p[0]) * math.Pi / 180.0
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * geoEarthRadius * math.Asin(math.Min(1.0, math.Sqrt(h)))
+}
+
+/*
+The bounding box of the points within the distance of the center. It
+spans all longitudes if it reaches a pole or the antimeridian.
+*/
+func geoCircleBox(center geoPoint, meters float64) []float64 {
+	r := meters / geoEarthRadius
+	dLat := r * 180.0 / math.Pi
+	south := center[1] - dLat
+	north := center[1] + dLat
+	if south <= -90.0 || north >= 90.0 {
+		return []float64{-180.0, math.Max(south, -90.0), 180.0, math.Min(north, 90.0)}
+	}
+
+	dLon := math.Asin(math.Min(1.0, math.Sin(r)/math.Cos(center[1]*math.Pi/180.0))) * 180.0 / math.Pi
+	west := center[0] - dLon
+	east := center[0] + dLon
+	if west < -180.0 || east > 180.0 {
+		return []float64{-180.0, south, 180.0, north}
+	}
+
+	return []float64{west, south, east, north}
+}
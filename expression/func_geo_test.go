@@ -0,0 +1,98 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"math"
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+var geoSquare = map[string]interface{}{
+	"type": "Polygon",
+	"coordinates": []interface{}{
+		[]interface{}{
+			[]interface{}{0.0, 0.0}, []interface{}{10.0, 0.0},
+			[]interface{}{10.0, 10.0}, []interface{}{0.0, 10.0}, []interface{}{0.0, 0.0},
+		},
+		[]interface{}{
+			[]interface{}{4.0, 4.0}, []interface{}{6.0, 4.0},
+			[]interface{}{6.0, 6.0}, []interface{}{4.0, 6.0}, []interface{}{4.0, 4.0},
+		},
+	},
+}
+
+func testGeo(f Function, expected interface{}, t *testing.T) {
+	rv, err := f.Evaluate(nil, nil)
+	if err != nil {
+		t.Errorf("%v: received error %v", f, err)
+		return
+	}
+
+	if er := value.NewValue(expected); er.Collate(rv) != 0 {
+		t.Errorf("%v: expected %v, received %v", f, er.Actual(), rv.Actual())
+	}
+}
+
+func TestGeoPredicates(t *testing.T) {
+	square := NewConstant(geoSquare)
+	point := func(lon, lat float64) Expression {
+		return NewStPoint(NewConstant(lon), NewConstant(lat))
+	}
+
+	testGeo(NewStWithin(point(1.0, 1.0), square), true, t)
+	testGeo(NewStWithin(point(10.0, 5.0), square), true, t)
+	testGeo(NewStWithin(point(5.0, 5.0), square), false, t)
+	testGeo(NewStWithin(point(11.0, 5.0), square), false, t)
+	testGeo(NewStWithin(point(1.0, 1.0), NewConstant([]interface{}{0.0, 0.0, 2.0, 2.0})), true, t)
+	testGeo(NewStContains(square, NewConstant([]interface{}{1.0, 1.0, 2.0, 2.0})), true, t)
+	testGeo(NewStContains(square, NewConstant([]interface{}{1.0, 1.0, 12.0, 2.0})), false, t)
+	testGeo(NewStContains(square, NewConstant([]interface{}{1.0, 1.0, 8.0, 8.0})), false, t)
+	testGeo(NewStIntersects(square, NewConstant([]interface{}{9.0, 9.0, 12.0, 12.0})), true, t)
+	testGeo(NewStIntersects(square, NewConstant([]interface{}{4.5, 4.5, 5.5, 5.5})), false, t)
+	testGeo(NewStIntersects(square, NewConstant([]interface{}{20.0, 20.0, 30.0, 30.0})), false, t)
+	testGeo(NewStWithin(NewConstant("paris"), square), nil, t)
+	testGeo(NewStPoint(NewConstant(190.0), NewConstant(0.0)), nil, t)
+	testGeo(NewStBBox(square), []interface{}{0.0, 0.0, 10.0, 10.0}, t)
+}
+
+func TestGeoDistance(t *testing.T) {
+	paris := NewStPoint(NewConstant(2.3522), NewConstant(48.8566))
+	london := NewConstant([]interface{}{-0.1276, 51.5072})
+
+	rv, _ := NewStDistance(paris, london, NewConstant("km")).Evaluate(nil, nil)
+	if d, ok := rv.Actual().(float64); !ok || math.Abs(d-343.5) > 0.1 {
+		t.Errorf("expected 343.5 km, received %v", rv.Actual())
+	}
+
+	testGeo(NewStDWithin(paris, london, NewConstant(350000.0)), true, t)
+	testGeo(NewStDWithin(paris, london, NewConstant(300000.0)), false, t)
+	testGeo(NewStDistance(paris, london, NewConstant("parsecs")), nil, t)
+}
+
+func TestGeoHash(t *testing.T) {
+	point := NewConstant([]interface{}{-5.6, 42.6})
+	testGeo(NewGeoHash(point, NewConstant(5.0)), "ezs42", t)
+	testGeo(NewGeoHash(point, NewConstant(13.0)), nil, t)
+
+	decoded := map[string]interface{}{
+		"type":        "Point",
+		"coordinates": []interface{}{-5.60302734375, 42.60498046875},
+		"bbox":        []interface{}{-5.625, 42.5830078125, -5.5810546875, 42.626953125},
+	}
+	testGeo(NewGeoHashDecode(NewConstant("ezs42")), decoded, t)
+	testGeo(NewGeoHashDecode(NewConstant("ezs4a")), nil, t)
+
+	cells := GeoHashCover([]float64{2.0, 48.0, 2.5, 49.0}, 12, 32)
+	if len(cells) != 18 || cells[0] != "u093" || cells[17] != "u09y" {
+		t.Errorf("unexpected cover %v", cells)
+	}
+}
@@ -331,6 +331,124 @@ func (this *PolyLength) Constructor() FunctionConstructor {
 	}
 }
 
+///////////////////////////////////////////////////
+//
+// Validate
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function VALIDATE(expr, schema). It returns
+the violations of the JSON schema by the value, as an array of objects
+holding the path and a message for each; the array is empty if the
+value matches the schema. The schema is either an object, or a string
+naming a keyspace as namespace:keyspace, whose validation schema is
+used. It returns NULL if the keyspace has no validation schema.
+*/
+type Validate struct {
+	BinaryFunctionBase
+	schema Schema
+}
+
+/*
+A compiled JSON schema, as used by VALIDATE. Violations returns the
+violations of the schema by the value, as an array.
+*/
+type Schema interface {
+	Violations(item value.Value) value.Value
+}
+
+/*
+Schemas compiles JSON schemas, and finds the validation schema of
+keyspaces, for VALIDATE. Keyspace returns nil if the keyspace has no
+validation schema.
+*/
+type Schemas interface {
+	Compile(schema value.Value) (Schema, error)
+	Keyspace(namespace, keyspace string) Schema
+}
+
+/*
+Schemas are provided by the validation package, which registers them
+when it is loaded: it cannot be imported here, as it depends on the
+datastore, which depends on expressions.
+*/
+var schemas Schemas
+
+func SetSchemas(s Schemas) {
+	schemas = s
+}
+
+func NewValidate(first, second Expression) Function {
+	rv := &Validate{
+		*NewBinaryFunctionBase("validate", first, second),
+		nil,
+	}
+
+	if schema := second.Value(); schema != nil && schema.Type() == value.OBJECT && schemas != nil {
+		rv.schema, _ = schemas.Compile(schema)
+	}
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Validate) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Validate) Type() value.Type { return value.ARRAY }
+
+func (this *Validate) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *Validate) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+	if schemas == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	schema := this.schema
+	switch second.Type() {
+	case value.OBJECT:
+		if schema == nil {
+			var err error
+			schema, err = schemas.Compile(second)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case value.STRING:
+		name := second.Actual().(string)
+		i := strings.IndexByte(name, ':')
+		if i < 0 {
+			return value.NULL_VALUE, nil
+		}
+		schema = schemas.Keyspace(name[:i], name[i+1:])
+		if schema == nil {
+			return value.NULL_VALUE, nil
+		}
+	default:
+		return value.NULL_VALUE, nil
+	}
+
+	return schema.Violations(first), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Validate) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewValidate(operands[0], operands[1])
+	}
+}
+
 func traversePairs(actual interface{}, buffer []interface{}) []interface{} {
 	length := 0
 
@@ -16,8 +16,15 @@ import (
 	"github.com/couchbase/query/value"
 )
 
+/*
+Implemented by LIKE, REGEXP_LIKE, LIKE with an ESCAPE clause and
+SIMILAR TO, which match the first operand against the pattern in
+the second.
+*/
 type LikeFunction interface {
-	BinaryFunction
+	Function
+	First() Expression
+	Second() Expression
 	Regexp() *regexp.Regexp
 }
 
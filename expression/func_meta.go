@@ -11,6 +11,7 @@ package expression
 
 import (
 	"encoding/base64"
+	"math"
 
 	"github.com/couchbase/query/util"
 	"github.com/couchbase/query/value"
@@ -568,3 +569,98 @@ func (this *DsVersion) Constructor() FunctionConstructor {
 		return NewDsVersion()
 	}
 }
+
+///////////////////////////////////////////////////
+//
+// Grouping
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the Meta function GROUPING(expr, ...). Each expr
+must be a GROUP BY expression. For each expr, from the most to the
+least significant bit, the bit of the result is 1 if expr is not in
+the grouping set of the current group, as for the super-aggregate
+rows of ROLLUP and CUBE, and 0 otherwise. The group operators
+attach the expressions that are not in the grouping set of a group
+to its value.
+*/
+type Grouping struct {
+	FunctionBase
+}
+
+func NewGrouping(operands ...Expression) Function {
+	rv := &Grouping{
+		*NewFunctionBase("grouping", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Grouping) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Grouping) Type() value.Type { return value.NUMBER }
+
+func (this *Grouping) Evaluate(item value.Value, context Context) (value.Value, error) {
+	var rolledUp map[string]bool
+	switch item := item.(type) {
+	case value.AnnotatedValue:
+		rolledUp, _ = item.GetAttachment("grouping").(map[string]bool)
+	}
+
+	rv := int64(0)
+	for _, op := range this.operands {
+		rv <<= 1
+		if rolledUp[op.String()] {
+			rv |= 1
+		}
+	}
+
+	return value.NewValue(rv), nil
+}
+
+/*
+Not constant.
+*/
+func (this *Grouping) Value() value.Value {
+	return nil
+}
+
+func (this *Grouping) Indexable() bool {
+	return false
+}
+
+/*
+Each operand must be a GROUP BY expression.
+*/
+func (this *Grouping) SurvivesGrouping(groupKeys Expressions, allowed *value.ScopeValue) (bool, Expression) {
+outer:
+	for _, op := range this.operands {
+		for _, key := range groupKeys {
+			if op.EquivalentTo(key) {
+				continue outer
+			}
+		}
+
+		return false, op
+	}
+
+	return true, nil
+}
+
+func (this *Grouping) MinArgs() int { return 1 }
+
+func (this *Grouping) MaxArgs() int { return math.MaxInt16 }
+
+/*
+Factory method pattern.
+*/
+func (this *Grouping) Constructor() FunctionConstructor {
+	return NewGrouping
+}
@@ -291,6 +291,30 @@ var _FUNCTIONS = map[string]Function{
 	"json_encode":  &JSONEncode{},
 	"pairs":        &Pairs{},
 	"poly_length":  &PolyLength{},
+	"validate":     &Validate{},
+
+	// Geospatial
+	"geohash":           &GeoHash{},
+	"geohash_decode":    &GeoHashDecode{},
+	"st_bbox":           &StBBox{},
+	"st_contains":       &StContains{},
+	"st_distance":       &StDistance{},
+	"st_dwithin":        &StDWithin{},
+	"st_geohash":        &GeoHash{},
+	"st_geohash_decode": &GeoHashDecode{},
+	"st_intersects":     &StIntersects{},
+	"st_point":          &StPoint{},
+	"st_within":         &StWithin{},
+
+	// Search
+	"search":       &Search{},
+	"search_meta":  &SearchMeta{},
+	"search_score": &SearchScore{},
+
+	// Vector
+	"vector_distance":  &VectorDistance{},
+	"vector_magnitude": &VectorMagnitude{},
+	"vector_normalize": &VectorNormalize{},
 
 	// Base64
 	"base64":        &Base64Encode{},
@@ -339,6 +363,7 @@ var _FUNCTIONS = map[string]Function{
 	"posinfif":      &PosInfIf{},
 
 	// Meta
+	"grouping":      &Grouping{},
 	"meta":          &Meta{},
 	"min_version":   &MinVersion{},
 	"self":          &Self{},
@@ -0,0 +1,290 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/search"
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// Search
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the search function SEARCH(expr, query [, options ]).
+It returns true if the text of the strings in expr matches the query.
+The query is made of words, "phrases", word~ fuzzy words, prefix*
+words and field:word clauses, each of which can be required with + or
+excluded with -. The options are an object; its "index" field names
+the full text index to search, if any.
+*/
+type Search struct {
+	FunctionBase
+	query *search.Query
+}
+
+func NewSearch(operands ...Expression) Function {
+	rv := &Search{
+		*NewFunctionBase("search", operands...),
+		nil,
+	}
+
+	if query := operands[1].Value(); query != nil && query.Type() == value.STRING {
+		rv.query, _ = search.Parse(query.Actual().(string))
+	}
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Search) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Search) Type() value.Type { return value.BOOLEAN }
+
+func (this *Search) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *Search) Apply(context Context, args ...value.Value) (value.Value, error) {
+	if args[0].Type() == value.MISSING || args[1].Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if args[1].Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	} else if len(args) > 2 && args[2].Type() != value.OBJECT {
+		return value.NULL_VALUE, nil
+	}
+
+	query := this.query
+	if query == nil {
+		var err error
+		text := args[1].Actual().(string)
+		query, err = search.Parse(text)
+		if err != nil {
+			return nil, errors.NewSearchQueryError(err, text)
+		}
+	}
+
+	return value.NewValue(query.Matches(search.NewDocument(args[0]))), nil
+}
+
+/*
+The text that is searched.
+*/
+func (this *Search) Field() Expression {
+	return this.operands[0]
+}
+
+func (this *Search) Query() Expression {
+	return this.operands[1]
+}
+
+func (this *Search) Options() Expression {
+	if len(this.operands) > 2 {
+		return this.operands[2]
+	}
+	return nil
+}
+
+/*
+The name of the index to search, if the options name one.
+*/
+func (this *Search) IndexName() string {
+	options := this.Options()
+	if options == nil {
+		return ""
+	}
+
+	v := options.Value()
+	if v == nil {
+		return ""
+	}
+
+	name, ok := v.Field("index")
+	if !ok || name.Type() != value.STRING {
+		return ""
+	}
+	return name.Actual().(string)
+}
+
+/*
+Minimum input arguments required is 2.
+*/
+func (this *Search) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed is 3.
+*/
+func (this *Search) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *Search) Constructor() FunctionConstructor {
+	return NewSearch
+}
+
+///////////////////////////////////////////////////
+//
+// SearchMeta
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the search function SEARCH_META([ keyspace ]). It
+returns the id and the score of the document found by a full text
+index search of the keyspace, or MISSING if the keyspace was not
+searched with an index. The keyspace can be omitted if only one is
+searched.
+*/
+type SearchMeta struct {
+	FunctionBase
+}
+
+func NewSearchMeta(operands ...Expression) Function {
+	rv := &SearchMeta{
+		*NewFunctionBase("search_meta", operands...),
+	}
+
+	rv.volatile = true
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *SearchMeta) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *SearchMeta) Type() value.Type { return value.OBJECT }
+
+func (this *SearchMeta) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return searchMeta(item, this.operands), nil
+}
+
+func (this *SearchMeta) Apply(context Context, args ...value.Value) (value.Value, error) {
+	return value.MISSING_VALUE, nil
+}
+
+/*
+Minimum input arguments required is 0.
+*/
+func (this *SearchMeta) MinArgs() int { return 0 }
+
+/*
+Maximum input arguments allowed is 1.
+*/
+func (this *SearchMeta) MaxArgs() int { return 1 }
+
+/*
+Factory method pattern.
+*/
+func (this *SearchMeta) Constructor() FunctionConstructor {
+	return NewSearchMeta
+}
+
+///////////////////////////////////////////////////
+//
+// SearchScore
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the search function SEARCH_SCORE([ keyspace ]). It
+returns the score of the document found by a full text index search
+of the keyspace, or MISSING if the keyspace was not searched with an
+index. Higher scores are better matches.
+*/
+type SearchScore struct {
+	FunctionBase
+}
+
+func NewSearchScore(operands ...Expression) Function {
+	rv := &SearchScore{
+		*NewFunctionBase("search_score", operands...),
+	}
+
+	rv.volatile = true
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *SearchScore) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *SearchScore) Type() value.Type { return value.NUMBER }
+
+func (this *SearchScore) Evaluate(item value.Value, context Context) (value.Value, error) {
+	meta := searchMeta(item, this.operands)
+	score, _ := meta.Field("score")
+	return score, nil
+}
+
+func (this *SearchScore) Apply(context Context, args ...value.Value) (value.Value, error) {
+	return value.MISSING_VALUE, nil
+}
+
+/*
+Minimum input arguments required is 0.
+*/
+func (this *SearchScore) MinArgs() int { return 0 }
+
+/*
+Maximum input arguments allowed is 1.
+*/
+func (this *SearchScore) MaxArgs() int { return 1 }
+
+/*
+Factory method pattern.
+*/
+func (this *SearchScore) Constructor() FunctionConstructor {
+	return NewSearchScore
+}
+
+/*
+The search metadata of a keyspace, which index searches attach to the
+items they produce, by keyspace alias.
+*/
+func searchMeta(item value.Value, operands Expressions) value.Value {
+	av, ok := item.(value.AnnotatedValue)
+	if !ok {
+		return value.MISSING_VALUE
+	}
+
+	smeta, ok := av.GetAttachment("smeta").(map[string]interface{})
+	if !ok {
+		return value.MISSING_VALUE
+	}
+
+	if len(operands) > 0 {
+		if meta, ok := smeta[operands[0].Alias()]; ok {
+			return value.NewValue(meta)
+		}
+	} else if len(smeta) == 1 {
+		for _, meta := range smeta {
+			return value.NewValue(meta)
+		}
+	}
+
+	return value.MISSING_VALUE
+}
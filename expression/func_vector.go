@@ -0,0 +1,250 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"github.com/couchbase/query/value"
+	"github.com/couchbase/query/vector"
+)
+
+///////////////////////////////////////////////////
+//
+// VectorDistance
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the vector function VECTOR_DISTANCE(vector1, vector2
+[, metric ]). It returns the distance between two arrays of numbers
+of the same length, by the "l2" (euclidean, the default), "cosine" or
+"dot" metric. The smaller the distance, the more similar the vectors;
+the dot distance is the negated dot product.
+*/
+type VectorDistance struct {
+	FunctionBase
+}
+
+func NewVectorDistance(operands ...Expression) Function {
+	rv := &VectorDistance{
+		*NewFunctionBase("vector_distance", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *VectorDistance) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *VectorDistance) Type() value.Type { return value.NUMBER }
+
+func (this *VectorDistance) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *VectorDistance) Apply(context Context, args ...value.Value) (value.Value, error) {
+	null := false
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		} else if arg.Type() == value.NULL {
+			null = true
+		}
+	}
+
+	if null {
+		return value.NULL_VALUE, nil
+	}
+
+	metric := vector.DEFAULT_METRIC
+	if len(args) > 2 {
+		if args[2].Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+
+		var ok bool
+		metric, ok = vector.ParseMetric(args[2].Actual().(string))
+		if !ok {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	v1, ok1 := vector.FromValue(args[0])
+	v2, ok2 := vector.FromValue(args[1])
+	if !ok1 || !ok2 || len(v1) != len(v2) {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(metric.Distance(v1, v2)), nil
+}
+
+/*
+The metric of the distance, if it is constant.
+*/
+func (this *VectorDistance) Metric() (vector.Metric, bool) {
+	if len(this.operands) < 3 {
+		return vector.DEFAULT_METRIC, true
+	}
+
+	name := this.operands[2].Value()
+	if name == nil || name.Type() != value.STRING {
+		return vector.DEFAULT_METRIC, false
+	}
+	return vector.ParseMetric(name.Actual().(string))
+}
+
+/*
+Minimum input arguments required is 2.
+*/
+func (this *VectorDistance) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed is 3.
+*/
+func (this *VectorDistance) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *VectorDistance) Constructor() FunctionConstructor {
+	return NewVectorDistance
+}
+
+///////////////////////////////////////////////////
+//
+// VectorMagnitude
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the vector function VECTOR_MAGNITUDE(vector). It
+returns the euclidean length of an array of numbers.
+*/
+type VectorMagnitude struct {
+	UnaryFunctionBase
+}
+
+func NewVectorMagnitude(operand Expression) Function {
+	rv := &VectorMagnitude{
+		*NewUnaryFunctionBase("vector_magnitude", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *VectorMagnitude) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *VectorMagnitude) Type() value.Type { return value.NUMBER }
+
+func (this *VectorMagnitude) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *VectorMagnitude) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	v, ok := vector.FromValue(arg)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(vector.Magnitude(v)), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *VectorMagnitude) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewVectorMagnitude(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// VectorNormalize
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the vector function VECTOR_NORMALIZE(vector). It
+returns the array of numbers scaled to a magnitude of 1, which has
+the same direction; the l2 and dot distances of normalized vectors
+rank them as the cosine distance does. It returns NULL for a zero
+vector.
+*/
+type VectorNormalize struct {
+	UnaryFunctionBase
+}
+
+func NewVectorNormalize(operand Expression) Function {
+	rv := &VectorNormalize{
+		*NewUnaryFunctionBase("vector_normalize", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *VectorNormalize) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *VectorNormalize) Type() value.Type { return value.ARRAY }
+
+func (this *VectorNormalize) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *VectorNormalize) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	v, ok := vector.FromValue(arg)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	n := vector.Normalize(v)
+	if n == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	rv := make([]interface{}, len(n))
+	for i, x := range n {
+		rv[i] = x
+	}
+	return value.NewValue(rv), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *VectorNormalize) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewVectorNormalize(operands[0])
+	}
+}
@@ -577,8 +577,20 @@ func (this *Stringer) VisitSelf(expr *Self) (interface{}, error) {
 	return "self", nil
 }
 
+/*
+Implemented by functions whose text has clauses besides the name and
+the operands, such as aggregates with ORDER BY, WITHIN GROUP or FILTER.
+*/
+type FunctionText interface {
+	Text(stringer *Stringer) string
+}
+
 // Function
 func (this *Stringer) VisitFunction(expr Function) (interface{}, error) {
+	if text, ok := expr.(FunctionText); ok {
+		return text.Text(this), nil
+	}
+
 	var buf bytes.Buffer
 	buf.WriteString(expr.Name())
 	buf.WriteString("(")
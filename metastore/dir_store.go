@@ -0,0 +1,119 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metastore
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+)
+
+const _FILE_SUFFIX = ".json"
+
+// local directory store, one file per object
+
+type dirStore struct {
+	store *Store
+	dir   string
+}
+
+func newDirStore(store *Store, dir string) (*dirStore, errors.Error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, store.newError(err, "cannot create "+dir)
+	}
+	return &dirStore{store: store, dir: dir}, nil
+}
+
+func (this *dirStore) shared() bool {
+	return false
+}
+
+func (this *dirStore) path(key string) string {
+	return filepath.Join(this.dir, url.PathEscape(key)+_FILE_SUFFIX)
+}
+
+func (this *dirStore) save(key string, data []byte) errors.Error {
+
+	// write and rename, so that a crash never leaves a partial file behind
+	path := this.path(key)
+	tmp, err := ioutil.TempFile(this.dir, ".tmp")
+	if err == nil {
+		_, err = tmp.Write(data)
+		err1 := tmp.Close()
+		if err == nil {
+			err = err1
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		return this.store.newError(err, "cannot write "+path)
+	}
+	return nil
+}
+
+func (this *dirStore) remove(key string) errors.Error {
+	err := os.Remove(this.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return this.store.newError(err, "cannot remove "+key)
+	}
+	return nil
+}
+
+func (this *dirStore) modify(key string, f ModifyFunc) errors.Error {
+	path := this.path(key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return this.store.newError(err, "cannot read "+path)
+		}
+		data = nil
+	}
+
+	data, err1 := f(data)
+	if err1 != nil {
+		return err1
+	}
+	return this.save(key, data)
+}
+
+func (this *dirStore) load(f func(key string, data []byte)) errors.Error {
+	files, err := ioutil.ReadDir(this.dir)
+	if err != nil {
+		return this.store.newError(err, "cannot read "+this.dir)
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), _FILE_SUFFIX) {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(file.Name(), _FILE_SUFFIX))
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(this.dir, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			logging.Infof("cannot read %v file %v: %v", this.store.kind, path, err)
+			continue
+		}
+		f(key, data)
+	}
+	return nil
+}
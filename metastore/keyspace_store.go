@@ -0,0 +1,156 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metastore
+
+import (
+	"math"
+	"strings"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/value"
+)
+
+// keyspace store, one document per object
+// kinds can share a keyspace: document keys are prefixed by the kind
+
+const (
+	_KEY_SEPARATOR  = "::"
+	_MODIFY_RETRIES = 16
+)
+
+type keyspaceStore struct {
+	store    *Store
+	keyspace datastore.Keyspace
+	prefix   string
+}
+
+func newKeyspaceStore(store *Store, name string, ds datastore.Datastore, ns string) (*keyspaceStore, errors.Error) {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		ns = name[:i]
+		name = name[i+1:]
+	}
+	namespace, err := ds.NamespaceByName(ns)
+	if err != nil {
+		return nil, store.newError(err, "cannot find namespace "+ns)
+	}
+	keyspace, err := namespace.KeyspaceByName(name)
+	if err != nil {
+		return nil, store.newError(err, "cannot find keyspace "+name)
+	}
+	return &keyspaceStore{
+		store:    store,
+		keyspace: keyspace,
+		prefix:   store.kind + _KEY_SEPARATOR,
+	}, nil
+}
+
+func (this *keyspaceStore) shared() bool {
+	return true
+}
+
+func (this *keyspaceStore) save(key string, data []byte) errors.Error {
+	_, err := this.keyspace.Upsert([]value.Pair{value.Pair{Name: this.prefix + key, Value: value.NewValue(data)}})
+	if err != nil {
+		return this.store.newError(err, "cannot write "+key)
+	}
+	return nil
+}
+
+func (this *keyspaceStore) remove(key string) errors.Error {
+	_, err := this.keyspace.Delete([]string{this.prefix + key}, datastore.NULL_QUERY_CONTEXT)
+	if err != nil {
+		return this.store.newError(err, "cannot remove "+key)
+	}
+	return nil
+}
+
+func (this *keyspaceStore) modify(key string, f ModifyFunc) errors.Error {
+	id := this.prefix + key
+
+	var err errors.Error
+	for i := 0; i < _MODIFY_RETRIES; i++ {
+		var current value.AnnotatedValue
+		var data []byte
+
+		docs, errs := this.keyspace.Fetch([]string{id}, datastore.NULL_QUERY_CONTEXT, nil)
+		if len(errs) > 0 {
+			return this.store.newError(errs[0], "cannot read "+key)
+		}
+		if len(docs) > 0 {
+			var err1 error
+
+			current = docs[0].Value
+			data, err1 = current.MarshalJSON()
+			if err1 != nil {
+				return this.store.newError(err1, "cannot decode "+key)
+			}
+		}
+
+		data, err = f(data)
+		if err != nil {
+			return err
+		}
+
+		// the insert fails if the document has been created since it was
+		// fetched, and so does the update if it changed since, provided
+		// the datastore checks the CAS found in the meta data
+		next := value.NewAnnotatedValue(value.NewValue(data))
+		if current != nil {
+			next.SetAttachment("meta", current.GetAttachment("meta"))
+			_, err = this.keyspace.Update([]value.Pair{value.Pair{Name: id, Value: next}})
+		} else {
+			_, err = this.keyspace.Insert([]value.Pair{value.Pair{Name: id, Value: next}})
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return this.store.newError(err, "cannot update "+key)
+}
+
+func (this *keyspaceStore) load(f func(key string, data []byte)) errors.Error {
+	indexer, err := this.keyspace.Indexer(datastore.DEFAULT)
+	if err != nil {
+		return this.store.newError(err, "cannot scan "+this.keyspace.Name())
+	}
+	primaries, err := indexer.PrimaryIndexes()
+	if err != nil || len(primaries) == 0 {
+		return this.store.newError(err, "no primary index on "+this.keyspace.Name())
+	}
+
+	conn := datastore.NewIndexConnection(datastore.NULL_CONTEXT)
+	go primaries[0].ScanEntries(this.store.kind, math.MaxInt64, datastore.UNBOUNDED, nil, conn)
+
+	keys := make([]string, 0, 64)
+	for entry := range conn.EntryChannel() {
+		if strings.HasPrefix(entry.PrimaryKey, this.prefix) {
+			keys = append(keys, entry.PrimaryKey)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	docs, errs := this.keyspace.Fetch(keys, datastore.NULL_QUERY_CONTEXT, nil)
+	if len(errs) > 0 {
+		logging.Infof("cannot fetch %v from %v: %v", this.store.kind, this.keyspace.Name(), errs)
+	}
+	for _, doc := range docs {
+		data, err := doc.Value.MarshalJSON()
+		if err != nil {
+			logging.Infof("cannot decode %v %v: %v", this.store.kind, doc.Name, err)
+			continue
+		}
+		f(strings.TrimPrefix(doc.Name, this.prefix), data)
+	}
+	return nil
+}
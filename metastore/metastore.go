@@ -0,0 +1,240 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package metastore persists the metadata the query service creates,
+such as prepared statements, views, sequences, validation schemas and
+triggers, so that it survives restarts.
+
+Each kind of metadata has its own store, which is either a local
+directory, one file per object, or a keyspace, one document per
+object, which is shared by all the query nodes of the cluster. The
+store is given by a spec, one of dir:<path>,
+keyspace:[<namespace>:]<keyspace> or none.
+
+Stores that have an apply function also send every change to the
+other query nodes, which apply it to their own cache, and persist it
+too if their store is a local directory.
+*/
+package metastore
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/distributed"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+)
+
+const (
+	_STORE_NONE     = "none"
+	_STORE_DIR      = "dir:"
+	_STORE_KEYSPACE = "keyspace:"
+
+	_ENDPOINT = "metadata"
+)
+
+// applies a change made on another node to the local cache
+// data is nil if the object was removed
+
+type ApplyFunc func(key string, data []byte) errors.Error
+
+// computes the new encoded form of an object from the current one
+// data is nil if the object is not in the store
+
+type ModifyFunc func(data []byte) ([]byte, errors.Error)
+
+type backend interface {
+	save(key string, data []byte) errors.Error
+	remove(key string) errors.Error
+	load(f func(key string, data []byte)) errors.Error
+	modify(key string, f ModifyFunc) errors.Error
+
+	// whether all the nodes of the cluster use the same store
+	shared() bool
+}
+
+type Store struct {
+	sync.Mutex
+	kind     string
+	newError func(e error, msg string) errors.Error
+	apply    ApplyFunc
+	backend  backend
+}
+
+var stores = struct {
+	sync.RWMutex
+	kinds map[string]*Store
+}{kinds: make(map[string]*Store)}
+
+// create the store for a kind of metadata
+// errors are reported with newError
+// changes are only sent to the other nodes if apply is not nil
+
+func NewStore(kind string, newError func(e error, msg string) errors.Error, apply ApplyFunc) *Store {
+	rv := &Store{
+		kind:     kind,
+		newError: newError,
+		apply:    apply,
+	}
+
+	stores.Lock()
+	stores.kinds[kind] = rv
+	stores.Unlock()
+	return rv
+}
+
+func (this *Store) Init(spec string, ds datastore.Datastore, ns string) errors.Error {
+	switch {
+	case spec == "" || spec == _STORE_NONE:
+		this.backend = nil
+	case strings.HasPrefix(spec, _STORE_DIR):
+		dir, err := newDirStore(this, spec[len(_STORE_DIR):])
+		if err != nil {
+			return err
+		}
+		this.backend = dir
+	case strings.HasPrefix(spec, _STORE_KEYSPACE):
+		keyspace, err := newKeyspaceStore(this, spec[len(_STORE_KEYSPACE):], ds, ns)
+		if err != nil {
+			return err
+		}
+		this.backend = keyspace
+	default:
+		return this.newError(nil, "invalid store "+spec)
+	}
+	return nil
+}
+
+// whether objects are persisted at all
+
+func (this *Store) Persisted() bool {
+	return this.backend != nil
+}
+
+// persist an object, and send it to the other nodes
+
+func (this *Store) Save(key string, object interface{}) errors.Error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return this.newError(err, "cannot encode "+key)
+	}
+	if this.backend != nil {
+		err := this.backend.save(key, data)
+		if err != nil {
+			return err
+		}
+	}
+	this.distribute("PUT", key, string(data))
+	return nil
+}
+
+// remove a persisted object, here and on the other nodes
+
+func (this *Store) Remove(key string) errors.Error {
+	if this.backend != nil {
+		err := this.backend.remove(key)
+		if err != nil {
+			return err
+		}
+	}
+	this.distribute("DELETE", key, "")
+	return nil
+}
+
+// replace an object by f applied to its current encoded form
+// changes are serialized within this node; a keyspace store also
+// passes the CAS of the fetched document to the update, and retries
+// if the datastore rejects it because another node modified the
+// object in between, so f may be called more than once
+// datastores that ignore CAS, such as the file datastore, give no
+// guarantee across nodes
+// the result is only sent to the other nodes if distribute is set
+
+func (this *Store) Modify(key string, distribute bool, f ModifyFunc) errors.Error {
+	var data []byte
+
+	modify := func(current []byte) ([]byte, errors.Error) {
+		var err errors.Error
+
+		data, err = f(current)
+		return data, err
+	}
+
+	this.Lock()
+	var err errors.Error
+	if this.backend != nil {
+		err = this.backend.modify(key, modify)
+	} else {
+		_, err = modify(nil)
+	}
+	this.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if distribute {
+		this.distribute("PUT", key, string(data))
+	}
+	return nil
+}
+
+// apply f to the key and the encoded form of each persisted object
+
+func (this *Store) Load(f func(key string, data []byte)) errors.Error {
+	if this.backend == nil {
+		return nil
+	}
+	return this.backend.load(f)
+}
+
+func (this *Store) distribute(method, key, data string) {
+	if this.apply == nil {
+		return
+	}
+	go distributed.RemoteAccess().DoRemoteOps([]string{}, _ENDPOINT+"/"+this.kind, method,
+		url.PathEscape(key), data,
+		func(warn errors.Error) {
+			if warn != nil {
+				logging.Infof("failed to distribute %v %v: %v", this.kind, key, warn)
+			}
+		}, distributed.NO_CREDS, "")
+}
+
+// apply a change sent by another node
+// data is nil if the object was removed
+
+func Apply(kind, key string, data []byte) errors.Error {
+	stores.RLock()
+	store := stores.kinds[kind]
+	stores.RUnlock()
+
+	if store == nil || store.apply == nil {
+		return errors.NewAdminEndpointError(nil, "unknown metadata "+kind)
+	}
+
+	// the sender has already written to a shared store
+	if store.backend != nil && !store.backend.shared() {
+		var err errors.Error
+
+		if data == nil {
+			err = store.backend.remove(key)
+		} else {
+			err = store.backend.save(key, data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return store.apply(key, data)
+}
@@ -10,6 +10,7 @@
 package n1ql
 
 import (
+	"bytes"
 	"fmt"
 	"runtime"
 	"strings"
@@ -20,10 +21,23 @@ import (
 )
 
 func ParseStatement(input string) (algebra.Statement, error) {
+	return parseStatement(input, false)
+}
+
+/*
+ParseTriggerStatement parses the body of a trigger, in which OLD and NEW
+stand for the named parameters $old and $new.
+*/
+func ParseTriggerStatement(input string) (algebra.Statement, error) {
+	return parseStatement(input, true)
+}
+
+func parseStatement(input string, trigger bool) (algebra.Statement, error) {
 	input = strings.TrimSpace(input)
 	reader := strings.NewReader(input)
 	lex := newLexer(NewLexer(reader))
 	lex.parsingStmt = true
+	lex.triggerBody = trigger
 	lex.text = input
 	lex.nex.ResetOffset()
 	lex.nex.ReportError(lex.ScannerError)
@@ -43,10 +57,89 @@ func ParseStatement(input string) (algebra.Statement, error) {
 	}
 }
 
+/*
+ParseNormalizedStatement parses the input like ParseStatement, and also
+returns the statement text with every string and numeric literal replaced
+by a ? marker, together with the number of literals so replaced, their
+kinds, and whether the statement references any parameters. Statements
+that only differ in their literal values share the same normalized text.
+*/
+func ParseNormalizedStatement(input string) (algebra.Statement, *NormalizedText, error) {
+	input = strings.TrimSpace(input)
+	reader := strings.NewReader(input)
+	lex := newLexer(NewLexer(reader))
+	lex.parsingStmt = true
+	lex.text = input
+	lex.normalized = &NormalizedText{}
+	lex.nex.ResetOffset()
+	lex.nex.ReportError(lex.ScannerError)
+	doParse(lex)
+
+	if len(lex.errs) > 0 {
+		return nil, nil, fmt.Errorf(strings.Join(lex.errs, " \n "))
+	} else if lex.stmt == nil {
+		return nil, nil, fmt.Errorf("Input was not a statement.")
+	} else {
+		err := lex.stmt.Formalize()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lex.normalized.Text = lex.normalized.buf.String()
+		lex.normalized.Kinds = string(lex.normalized.kinds)
+		return lex.stmt, lex.normalized, nil
+	}
+}
+
+type NormalizedText struct {
+	Text      string
+	Literals  int
+	Kinds     string // one letter per literal: s string, i integer, n other number
+	HasParams bool
+	buf       bytes.Buffer
+	kinds     []byte
+}
+
+func (this *NormalizedText) add(token int, text string) {
+	switch token {
+	case STR, INT, NUM:
+		text = "?"
+		this.Literals++
+		switch token {
+		case STR:
+			this.kinds = append(this.kinds, 's')
+		case INT:
+			this.kinds = append(this.kinds, 'i')
+		default:
+			this.kinds = append(this.kinds, 'n')
+		}
+	case NAMED_PARAM, POSITIONAL_PARAM, NEXT_PARAM:
+		this.HasParams = true
+	}
+
+	if this.buf.Len() > 0 {
+		this.buf.WriteByte(' ')
+	}
+	this.buf.WriteString(text)
+}
+
 func ParseExpression(input string) (expression.Expression, error) {
+	return parseExpression(input, false)
+}
+
+/*
+ParseTriggerExpression parses the WHEN condition of a trigger, in which
+OLD and NEW stand for the named parameters $old and $new.
+*/
+func ParseTriggerExpression(input string) (expression.Expression, error) {
+	return parseExpression(input, true)
+}
+
+func parseExpression(input string, trigger bool) (expression.Expression, error) {
 	input = strings.TrimSpace(input)
 	reader := strings.NewReader(input)
 	lex := newLexer(NewLexer(reader))
+	lex.triggerBody = trigger
 	lex.nex.ResetOffset()
 	lex.nex.ReportError(lex.ScannerError)
 	doParse(lex)
@@ -86,6 +179,18 @@ type lexer struct {
 	parsingStmt      bool
 	lastScannerError string
 	text             string
+	normalized       *NormalizedText
+	lastToken        int
+	hintsAllowed     bool
+	sequenceStmt     bool
+	triggerStmt      bool
+	triggerBody      bool
+	indexStmt        bool
+	peeked           bool
+	peekToken        int
+	peekText         string
+	peekOffset       int
+	peekLval         yySymType
 }
 
 func newLexer(nex *Lexer) *lexer {
@@ -96,7 +201,175 @@ func newLexer(nex *Lexer) *lexer {
 }
 
 func (this *lexer) Lex(lval *yySymType) int {
-	return this.nex.Lex(lval)
+	var token, offset int
+	var text string
+	for {
+		if this.peeked {
+			this.peeked = false
+			token, text, offset = this.peekToken, this.peekText, this.peekOffset
+			*lval = this.peekLval
+		} else {
+			token = this.nex.Lex(lval)
+			if token != 0 {
+				text = this.nex.Text()
+			}
+			offset = this.nex.curOffset
+		}
+
+		// optimizer hints follow SELECT, UPDATE, DELETE or MERGE;
+		// anywhere else they are plain comments
+		if token != OPTIM_HINTS || this.hintsAllowed {
+			break
+		}
+	}
+
+	if this.normalized != nil && token != 0 {
+		this.normalized.add(token, text)
+	}
+
+	// FILTER and WITHIN GROUP following an aggregate, ROLLUP, CUBE,
+	// GROUPING SETS, NULLS FIRST or LAST, TRY_CAST, SIMILAR TO,
+	// ESCAPE, EXPLAIN ANALYZE WITH RESULTS, REFRESH MATERIALIZED,
+	// SEQUENCE, NO in sequence options, NEXT VALUE and PREV VALUE,
+	// USING VECTOR in index statements and hints, and PIVOT and
+	// UNPIVOT followed by ( are recognized by looking ahead one
+	// token, or back, so that they need not be reserved. OLD and NEW
+	// in the WHEN condition and the body of a trigger stand for the
+	// named parameters $old and $new.
+	switch {
+	case token == WITHIN:
+		if this.peek() == GROUP {
+			token = AGG_WITHIN
+		}
+	case token == IDENT && this.lastToken == RPAREN && strings.EqualFold(text, "filter"):
+		if this.peek() == LPAREN {
+			token = AGG_FILTER
+		}
+	case token == IDENT && (strings.EqualFold(text, "rollup") || strings.EqualFold(text, "cube")):
+		if this.peek() == LPAREN {
+			if strings.EqualFold(text, "rollup") {
+				token = ROLLUP
+			} else {
+				token = CUBE
+			}
+		}
+	case token == IDENT && strings.EqualFold(text, "try_cast"):
+		if this.peek() == LPAREN {
+			token = TRY_CAST
+		}
+	case token == IDENT && strings.EqualFold(text, "similar"):
+		if this.peek() == TO {
+			this.peeked = false
+			if this.normalized != nil {
+				this.normalized.add(TO, this.peekText)
+			}
+			token = SIMILAR_TO
+		}
+	case token == IDENT && strings.EqualFold(text, "escape"):
+		switch this.peek() {
+		case STR, NAMED_PARAM, POSITIONAL_PARAM, NEXT_PARAM:
+			token = ESCAPE
+		}
+	case token == IDENT && strings.EqualFold(text, "nulls"):
+		if next := this.peek(); next == FIRST || next == LAST {
+			token = NULLS
+		}
+	case token == IDENT && strings.EqualFold(text, "grouping"):
+		if this.peek() == IDENT && strings.EqualFold(this.peekText, "sets") {
+			this.peeked = false
+			if this.normalized != nil {
+				this.normalized.add(IDENT, this.peekText)
+			}
+			token = GROUPING_SETS
+		}
+	case token == IDENT && strings.EqualFold(text, "refresh"):
+		if this.peek() == MATERIALIZED {
+			token = REFRESH
+		}
+	case token == IDENT && strings.EqualFold(text, "sequence"):
+		switch this.lastToken {
+		case CREATE, ALTER, DROP:
+			token = SEQUENCE
+			this.sequenceStmt = true
+		}
+	case token == IDENT && this.sequenceStmt && strings.EqualFold(text, "no"):
+		if this.peek() == IDENT {
+			token = NO
+		}
+	case token == IDENT && (strings.EqualFold(text, "next") || strings.EqualFold(text, "prev")):
+		if this.peek() == VALUE {
+			this.peeked = false
+			if this.normalized != nil {
+				this.normalized.add(VALUE, this.peekText)
+			}
+			if strings.EqualFold(text, "next") {
+				token = NEXT_VALUE
+			} else {
+				token = PREV_VALUE
+			}
+		}
+	case token == IDENT && (strings.EqualFold(text, "pivot") || strings.EqualFold(text, "unpivot")):
+		if this.lastToken != DOT && this.peek() == LPAREN {
+			if strings.EqualFold(text, "pivot") {
+				token = PIVOT
+			} else {
+				token = UNPIVOT
+			}
+		}
+	case token == INDEX && this.lastToken != USE:
+		this.indexStmt = true
+	case token == IDENT && this.lastToken == USING && strings.EqualFold(text, "vector"):
+		if next := this.peek(); this.indexStmt || next == RPAREN || next == COMMA {
+			token = VECTOR
+		}
+	case token == TRIGGER && this.lastToken == CREATE:
+		this.triggerStmt = true
+	case token == WHEN && this.triggerStmt:
+		this.triggerBody = true
+	case token == EXECUTE && this.triggerStmt:
+		this.triggerBody = true
+		lval.tokOffset = offset
+	case token == IDENT && this.triggerBody && this.lastToken != DOT &&
+		(strings.EqualFold(text, "old") || strings.EqualFold(text, "new")):
+		token = NAMED_PARAM
+		lval.s = strings.ToLower(text)
+	case token == WITH && this.lastToken == ANALYZE:
+		if this.peek() == IDENT && strings.EqualFold(this.peekText, "results") {
+			this.peeked = false
+			if this.normalized != nil {
+				this.normalized.add(IDENT, this.peekText)
+			}
+			lval.tokOffset = this.nex.curOffset
+			token = WITH_RESULTS
+		}
+	}
+
+	// UPDATE and DELETE actions of MERGE follow THEN
+	this.hintsAllowed = token == SELECT || token == MERGE ||
+		((token == UPDATE || token == DELETE) && this.lastToken != THEN)
+	this.lastToken = token
+	return token
+}
+
+func (this *lexer) peek() int {
+	if !this.peeked {
+		this.peekToken = this.nex.Lex(&this.peekLval)
+
+		// tokens are only peeked after tokens that cannot be
+		// followed by optimizer hints
+		for this.peekToken == OPTIM_HINTS {
+			this.peekToken = this.nex.Lex(&this.peekLval)
+		}
+		if this.peekToken != 0 {
+			this.peekText = this.nex.Text()
+		} else {
+			this.peekText = ""
+		}
+		this.peekOffset = this.nex.curOffset
+		this.peeked = true
+	}
+
+	return this.peekToken
 }
 
 func (this *lexer) Remainder(offset int) string {
@@ -2,6 +2,7 @@
 package n1ql
 
 import "fmt"
+import "math"
 import "strings"
 import "github.com/couchbase/clog"
 import "github.com/couchbase/query/algebra"
@@ -12,6 +13,98 @@ import "github.com/couchbase/query/value"
 func logDebugGrammar(format string, v ...interface{}) {
     clog.To("PARSER", format, v...)
 }
+
+// MERGE WHEN clauses, in the order in which they must appear
+const (
+    _MERGE_UPDATE = iota + 1
+    _MERGE_DELETE
+    _MERGE_INSERT
+    _MERGE_SOURCE_UPDATE
+    _MERGE_SOURCE_DELETE
+)
+
+func checkMergeAction(yylex yyLexer, actions *algebra.MergeActions, action int) {
+    last := 0
+    switch {
+    case actions.SourceDelete() != nil:
+        last = _MERGE_SOURCE_DELETE
+    case actions.SourceUpdate() != nil:
+        last = _MERGE_SOURCE_UPDATE
+    case actions.Insert() != nil:
+        last = _MERGE_INSERT
+    case actions.Delete() != nil:
+        last = _MERGE_DELETE
+    case actions.Update() != nil:
+        last = _MERGE_UPDATE
+    }
+    if last >= action {
+        yylex.Error("MERGE WHEN clauses must appear at most once, in the order MATCHED UPDATE, MATCHED DELETE, " +
+            "NOT MATCHED INSERT, NOT MATCHED BY SOURCE UPDATE, NOT MATCHED BY SOURCE DELETE.")
+    }
+}
+
+func filterAggregate(yylex yyLexer, name string, expr, cond expression.Expression) expression.Expression {
+    if expr == nil || cond == nil {
+        return expr
+    }
+    agg, ok := expr.(algebra.Aggregate)
+    if !ok {
+        yylex.Error(fmt.Sprintf("FILTER is not allowed in non-aggregate function %s.", name))
+        return nil
+    }
+    return algebra.NewFilteredAggregate(agg, cond)
+}
+
+func newSortTerm(expr expression.Expression, desc bool, nulls int64) *algebra.SortTerm {
+    if collate, ok := expr.(*expression.Collate); ok {
+        return algebra.NewCollatedSortTerm(collate.Operand(), desc, algebra.NullsPos(nulls), collate.Collation())
+    }
+    return algebra.NewCollatedSortTerm(expr, desc, algebra.NullsPos(nulls), nil)
+}
+
+func newCollate(yylex yyLexer, expr expression.Expression, name string) expression.Expression {
+    collation, err := value.NewCollation(name)
+    if err != nil {
+        yylex.Error(err.Error())
+    }
+    if collation == nil {
+        return expr
+    }
+    return expression.NewCollate(expr, collation)
+}
+
+func newPivot(yylex yyLexer, left algebra.FromTerm, expr, key expression.Expression,
+    values expression.Expressions, as string) algebra.FromTerm {
+    agg, ok := expr.(algebra.Aggregate)
+    if !ok {
+        yylex.Error("PIVOT requires an aggregate.")
+        return nil
+    }
+    for _, v := range values {
+        if v.Value() == nil {
+            yylex.Error(fmt.Sprintf("PIVOT value %s must be a constant.", v.String()))
+        }
+    }
+    return algebra.NewPivot(left, agg, key, values, as)
+}
+
+// the options of CREATE and ALTER SEQUENCE are collected in an object
+func sequenceOption(yylex yyLexer, clause, name string, val interface{}) value.Value {
+    if name == "" {
+        yylex.Error(fmt.Sprintf("Unexpected %s in sequence options.", clause))
+    }
+    return value.NewValue(map[string]interface{}{name: val})
+}
+
+func addSequenceOption(yylex yyLexer, options, option value.Value) value.Value {
+    for name, val := range option.Fields() {
+        if _, ok := options.Field(name); ok {
+            yylex.Error(fmt.Sprintf("Duplicate sequence option %s.", strings.ToUpper(name)))
+        }
+        options.SetField(name, val)
+    }
+    return options
+}
 %}
 
 %union {
@@ -45,9 +138,16 @@ indexRef         *algebra.IndexRef
 subqueryTerm     *algebra.SubqueryTerm
 path             expression.Path
 group            *algebra.Group
+groupingSets     algebra.GroupingSets
 resultTerm       *algebra.ResultTerm
 resultTerms      algebra.ResultTerms
+starModifiers    *algebra.StarModifiers
+fieldPath        algebra.FieldPath
+fieldPaths       []algebra.FieldPath
+starReplace      *algebra.StarReplace
+starReplaces     algebra.StarReplaces
 projection       *algebra.Projection
+optimHints       *algebra.OptimHints
 order            *algebra.Order
 sortTerm         *algebra.SortTerm
 sortTerms        algebra.SortTerms
@@ -66,6 +166,7 @@ setTerms         algebra.SetTerms
 unsetTerm        *algebra.UnsetTerm
 unsetTerms       algebra.UnsetTerms
 updateFor        *algebra.UpdateFor
+mergeSource      *algebra.MergeSource
 mergeActions     *algebra.MergeActions
 mergeUpdate      *algebra.MergeUpdate
 mergeDelete      *algebra.MergeDelete
@@ -138,6 +239,7 @@ tokOffset	 int
 %token FORCE
 %token FROM
 %token FTS
+%token FULL
 %token FUNCTION
 %token GRANT
 %token GROUP
@@ -245,6 +347,7 @@ tokOffset	 int
 %token VALUE
 %token VALUED
 %token VALUES
+%token VECTOR
 %token VIA
 %token VIEW
 %token WHEN
@@ -260,24 +363,57 @@ tokOffset	 int
 %token LBRACE RBRACE LBRACKET RBRACKET RBRACKET_ICASE
 %token COMMA COLON
 
+/* Returned by the lexer for FILTER and WITHIN followed by ( and GROUP */
+%token AGG_FILTER AGG_WITHIN
+
+/* Returned by the lexer for ROLLUP and CUBE followed by (, and GROUPING SETS */
+%token ROLLUP CUBE GROUPING_SETS
+
+/* Returned by the lexer for NULLS followed by FIRST or LAST */
+%token NULLS
+
+/* Returned by the lexer for TRY_CAST followed by ( */
+%token TRY_CAST
+
+/* Returned by the lexer for SIMILAR TO, and ESCAPE followed by a string or parameter */
+%token SIMILAR_TO ESCAPE
+%token WITH_RESULTS
+%token OPTIM_HINTS
+
+/* Returned by the lexer for REFRESH followed by MATERIALIZED */
+%token REFRESH
+
+/* Returned by the lexer for SEQUENCE following CREATE, ALTER or DROP, for
+   NO in sequence options, and for NEXT VALUE and PREV VALUE */
+%token SEQUENCE NO NEXT_VALUE PREV_VALUE
+
+/* Returned by the lexer for PIVOT and UNPIVOT followed by ( */
+%token PIVOT UNPIVOT
+
 /* Precedence: lowest to highest */
 %left           ORDER
 %left           UNION INTERESECT EXCEPT
-%left           JOIN NEST UNNEST FLATTEN INNER LEFT RIGHT
+%left           JOIN NEST UNNEST FLATTEN INNER LEFT RIGHT FULL
 %left           OR
 %left           AND
 %right          NOT
 %nonassoc       EQ DEQ NE
 %nonassoc       LT GT LE GE
-%nonassoc       LIKE
+%nonassoc       LIKE ILIKE SIMILAR_TO
+%nonassoc       ESCAPE
 %nonassoc       BETWEEN
 %nonassoc       IN WITHIN
 %nonassoc       EXISTS
 %nonassoc       IS                              /* IS NULL, IS MISSING, IS VALUED, IS NOT NULL, etc. */
+%left           COLLATE
 %left           CONCAT
 %left           PLUS MINUS
 %left           STAR DIV MOD
 
+/* NEXT VALUE FOR namespace:sequence, rather than a sequence followed by : */
+%nonassoc       SEQUENCE
+%nonassoc       COLON
+
 /* Unary operators */
 %right          COVER
 %left           ALL
@@ -290,6 +426,7 @@ tokOffset	 int
 /* Types */
 %type <s>                STR
 %type <s>                IDENT IDENT_ICASE
+%type <s>                OPTIM_HINTS
 %type <s>                NAMED_PARAM
 %type <f>                NUM
 %type <n>                INT
@@ -317,6 +454,9 @@ tokOffset	 int
 
 %type <expr>             function_expr
 %type <s>                function_name
+%type <expr>             opt_agg_filter
+%type <s>                cast_type
+%type <expr>             opt_cast_format
 
 %type <expr>             paren_expr
 %type <subquery>         subquery_expr
@@ -326,7 +466,7 @@ tokOffset	 int
 %type <subselect>        subselect
 %type <subselect>        select_from
 %type <subselect>        from_select
-%type <fromTerm>         from_term from opt_from simple_from_term simple_from_join_term
+%type <fromTerm>         from_term from opt_from simple_from_term simple_from_join_term opt_delete_using
 %type <keyspaceTerm>     keyspace_term
 %type <b>                opt_join_type
 %type <path>             path
@@ -338,23 +478,42 @@ tokOffset	 int
 %type <bindings>         opt_let let
 %type <expr>             opt_where where
 %type <group>            opt_group group
+%type <groupingSets>     group_terms group_term grouping_sets grouping_set
 %type <bindings>         opt_letting letting
 %type <expr>             opt_having having
 %type <resultTerm>       project
 %type <resultTerms>      projects
-%type <projection>       projection select_clause
+%type <starModifiers>    opt_star_modifiers
+%type <fieldPath>        field_path
+%type <fieldPaths>       opt_exclude field_paths
+%type <starReplace>      replace_term
+%type <starReplaces>     opt_replace replace_terms
+%type <projection>       projection
+%type <optimHints>       opt_optim_hints
 %type <order>            order_by opt_order_by
 %type <sortTerm>         sort_term
 %type <sortTerms>        sort_terms
 %type <expr>             limit opt_limit
 %type <expr>             offset opt_offset
 %type <b>                dir opt_dir
+%type <n>                opt_nulls
 
 %type <statement>        stmt explain prepare execute select_stmt dml_stmt ddl_stmt
 %type <statement>        infer infer_keyspace
 %type <statement>        insert upsert delete update merge
 %type <statement>        index_stmt create_index drop_index alter_index build_index
 %type <statement>        role_stmt grant_role revoke_role
+%type <statement>        view_stmt create_view drop_view refresh_view
+%type <s>                opt_refresh_mode
+%type <statement>        sequence_stmt create_sequence alter_sequence drop_sequence
+%type <val>              opt_sequence_options sequence_options sequence_option
+%type <n>                sequence_int
+%type <keyspaceRef>      sequence_ref
+%type <statement>        keyspace_stmt alter_keyspace
+%type <statement>        validate validate_keyspace
+%type <statement>        trigger_stmt create_trigger drop_trigger
+%type <s>                trigger_timing trigger_event
+%type <expr>             opt_trigger_when
 
 %type <keyspaceRef>      keyspace_ref
 %type <pairs>            values values_list next_values
@@ -370,10 +529,13 @@ tokOffset	 int
 %type <binding>          update_binding
 %type <bindings>         update_dimension
 %type <dimensions>       update_dimensions
-%type <mergeActions>     merge_actions opt_merge_delete_insert
+%type <mergeSource>      merge_source
+%type <mergeActions>     merge_actions
 %type <mergeUpdate>      merge_update
 %type <mergeDelete>      merge_delete
-%type <mergeInsert>      merge_insert opt_merge_insert
+%type <mergeInsert>      merge_insert
+%type <expr>             opt_merge_condition
+%type <s>                opt_merge_by
 
 %type <s>                index_name opt_primary_name
 %type <ss>               index_names
@@ -434,6 +596,8 @@ execute
 |
 infer
 |
+validate
+|
 role_stmt
 ;
 
@@ -442,6 +606,16 @@ EXPLAIN stmt
 {
     $$ = algebra.NewExplain($2, yylex.(*lexer).Remainder($<tokOffset>1))
 }
+|
+EXPLAIN ANALYZE stmt
+{
+    $$ = algebra.NewExplainAnalyze($3, yylex.(*lexer).Remainder($<tokOffset>2), false)
+}
+|
+EXPLAIN ANALYZE WITH_RESULTS stmt
+{
+    $$ = algebra.NewExplainAnalyze($4, yylex.(*lexer).Remainder($<tokOffset>3), true)
+}
 ;
 
 prepare:
@@ -492,6 +666,20 @@ INFER opt_keyspace keyspace_ref opt_infer_using opt_infer_with
 }
 ;
 
+validate:
+validate_keyspace
+;
+
+validate_keyspace:
+VALIDATE opt_keyspace keyspace_ref opt_index_with opt_where opt_limit
+{
+    if $4 != nil && $4.Type() != value.OBJECT {
+        yylex.Error("VALIDATE schema must be an object.")
+    }
+    $$ = algebra.NewValidateKeyspace($3, $4, $5, $6)
+}
+;
+
 opt_keyspace:
 /* empty */
 {
@@ -547,6 +735,14 @@ merge
 
 ddl_stmt:
 index_stmt
+|
+view_stmt
+|
+sequence_stmt
+|
+keyspace_stmt
+|
+trigger_stmt
 ;
 
 role_stmt:
@@ -674,16 +870,18 @@ select_from
 ;
 
 from_select:
-from opt_let opt_where opt_group select_clause
+from opt_let opt_where opt_group SELECT opt_optim_hints projection
 {
-    $$ = algebra.NewSubselect($1, $2, $3, $4, $5)
+    $$ = algebra.NewSubselect($1, $2, $3, $4, $7)
+    $$.SetOptimHints($6)
 }
 ;
 
 select_from:
-select_clause opt_from opt_let opt_where opt_group
+SELECT opt_optim_hints projection opt_from opt_let opt_where opt_group
 {
-    $$ = algebra.NewSubselect($2, $3, $4, $5, $1)
+    $$ = algebra.NewSubselect($4, $5, $6, $7, $3)
+    $$.SetOptimHints($2)
 }
 ;
 
@@ -694,11 +892,15 @@ select_clause opt_from opt_let opt_where opt_group
  *
  *************************************************/
 
-select_clause:
-SELECT
-projection
+opt_optim_hints:
+/* empty */
 {
-    $$ = $2
+    $$ = nil
+}
+|
+OPTIM_HINTS
+{
+    $$ = algebra.NewOptimHints($1)
 }
 ;
 
@@ -750,14 +952,14 @@ projects COMMA project
 ;
 
 project:
-STAR
+STAR opt_star_modifiers
 {
-    $$ = algebra.NewResultTerm(expression.SELF, true, "")
+    $$ = algebra.NewStarResultTerm(expression.SELF, $2)
 }
 |
-expr DOT STAR
+expr DOT STAR opt_star_modifiers
 {
-    $$ = algebra.NewResultTerm($1, true, "")
+    $$ = algebra.NewStarResultTerm($1, $4)
 }
 |
 expr opt_as_alias
@@ -766,6 +968,87 @@ expr opt_as_alias
 }
 ;
 
+opt_star_modifiers:
+opt_exclude opt_replace
+{
+    if $1 == nil && $2 == nil {
+        $$ = nil
+    } else {
+        $$ = algebra.NewStarModifiers($1, $2)
+    }
+}
+;
+
+opt_exclude:
+/* empty */
+{
+    $$ = nil
+}
+|
+EXCLUDE LPAREN field_paths RPAREN
+{
+    $$ = $3
+}
+;
+
+field_paths:
+field_path
+{
+    $$ = []algebra.FieldPath{$1}
+}
+|
+field_paths COMMA field_path
+{
+    $$ = append($1, $3)
+}
+;
+
+field_path:
+IDENT
+{
+    $$ = algebra.FieldPath{$1}
+}
+|
+field_path DOT IDENT
+{
+    $$ = append($1, $3)
+}
+;
+
+opt_replace:
+/* empty */
+{
+    $$ = nil
+}
+|
+IDENT LPAREN replace_terms RPAREN
+{
+    if !strings.EqualFold($1, "replace") {
+        yylex.Error(fmt.Sprintf("Unexpected %s after *.", $1))
+    }
+    $$ = $3
+}
+;
+
+replace_terms:
+replace_term
+{
+    $$ = algebra.StarReplaces{$1}
+}
+|
+replace_terms COMMA replace_term
+{
+    $$ = append($1, $3)
+}
+;
+
+replace_term:
+expr AS field_path
+{
+    $$ = algebra.NewStarReplace($1, $3)
+}
+;
+
 opt_as_alias:
 /* empty */
 {
@@ -809,6 +1092,11 @@ FROM from_term
 {
     $$ = $2
 }
+|
+FROM from_term PIVOT LPAREN expr FOR b_expr IN LPAREN exprs RPAREN RPAREN as_alias
+{
+    $$ = newPivot(yylex, $2, $5, $7, $10, $13)
+}
 ;
 
 from_term:
@@ -895,6 +1183,16 @@ from_term opt_join_type unnest expr opt_as_alias
     $$ = algebra.NewUnnest($1, $2, $4, $5)
 }
 |
+from_term UNPIVOT LPAREN IDENT FOR IDENT IN LPAREN exprs RPAREN RPAREN
+{
+    for _, field := range $9 {
+        if field.Alias() == "" {
+            yylex.Error(fmt.Sprintf("UNPIVOT field %s must have a name.", field.String()))
+        }
+    }
+    $$ = algebra.NewUnpivot($1, $4, $6, $9)
+}
+|
 from_term opt_join_type JOIN simple_from_join_term ON expr
 {
     switch first := $1.(type) {
@@ -903,15 +1201,18 @@ from_term opt_join_type JOIN simple_from_join_term ON expr
         case *algebra.Nest, *algebra.IndexNest:
              yylex.Error(fmt.Sprintf("Cannot mix non ANSI NEST on %s and ANSI JOIN on %s.", first.Alias(), $4.Alias()))
     }
-    if second, ok := $4.(*algebra.KeyspaceTerm); ok {
-        if second.Keys() != nil {
-            yylex.Error(fmt.Sprintf("ANSI JOIN on %s cannot have USE KEYS.", second.Alias()))
-        }
-	second.SetAnsiJoin()
-        $$ = algebra.NewAnsiJoin($1, $2, second, $6)
-    } else {
-        yylex.Error("ANSI JOIN must be done on a keyspace.")
+    switch second := $4.(type) {
+        case *algebra.KeyspaceTerm:
+            if second.Keys() != nil {
+                yylex.Error(fmt.Sprintf("ANSI JOIN on %s cannot have USE KEYS.", second.Alias()))
+            }
+            second.SetAnsiJoin()
+        case *algebra.ExpressionTerm:
+            if second.KeyspaceTerm() != nil {
+                second.KeyspaceTerm().SetAnsiJoin()
+            }
     }
+    $$ = algebra.NewAnsiJoin($1, $2, $4, $6)
 }
 |
 from_term opt_join_type NEST simple_from_join_term ON expr
@@ -922,28 +1223,60 @@ from_term opt_join_type NEST simple_from_join_term ON expr
         case *algebra.Nest, *algebra.IndexNest:
              yylex.Error(fmt.Sprintf("Cannot mix non ANSI NEST on %s and ANSI NEST on %s.", first.Alias(), $4.Alias()))
     }
-    if second, ok := $4.(*algebra.KeyspaceTerm); ok {
-        if second.Keys() != nil {
-            yylex.Error(fmt.Sprintf("ANSI NEST on %s cannot have USE KEYS.", second.Alias()))
-        }
-	second.SetAnsiNest()
-        $$ = algebra.NewAnsiNest($1, $2, second, $6)
-    } else {
-        yylex.Error("ANSI NEST must be done on a keyspace.")
+    switch second := $4.(type) {
+        case *algebra.KeyspaceTerm:
+            if second.Keys() != nil {
+                yylex.Error(fmt.Sprintf("ANSI NEST on %s cannot have USE KEYS.", second.Alias()))
+            }
+            second.SetAnsiNest()
+        case *algebra.ExpressionTerm:
+            if second.KeyspaceTerm() != nil {
+                second.KeyspaceTerm().SetAnsiNest()
+            }
     }
+    $$ = algebra.NewAnsiNest($1, $2, $4, $6)
 }
 |
-simple_from_join_term RIGHT opt_outer JOIN simple_from_term ON expr
+from_term RIGHT opt_outer JOIN simple_from_join_term ON expr
 {
-    if second, ok := $1.(*algebra.KeyspaceTerm); ok {
-        if second.Keys() != nil {
-            yylex.Error(fmt.Sprintf("ANSI JOIN on %s cannot have USE KEYS.", second.Alias()))
-        }
-	second.SetAnsiJoin()
-        $$ = algebra.NewAnsiJoin($5, true, second, $7)
+    switch first := $1.(type) {
+        case *algebra.Join, *algebra.IndexJoin:
+             yylex.Error(fmt.Sprintf("Cannot mix non ANSI JOIN on %s and ANSI JOIN on %s.", first.Alias(), $5.Alias()))
+        case *algebra.Nest, *algebra.IndexNest:
+             yylex.Error(fmt.Sprintf("Cannot mix non ANSI NEST on %s and ANSI JOIN on %s.", first.Alias(), $5.Alias()))
+    }
+
+    // a single keyspace on the left is joined as the inner side of a LEFT OUTER JOIN
+    var first *algebra.KeyspaceTerm
+    switch term := $1.(type) {
+        case *algebra.KeyspaceTerm:
+            first = term
+        case *algebra.ExpressionTerm:
+            if term.IsKeyspace() {
+                first = term.KeyspaceTerm()
+            }
+    }
+    if first != nil && first.Keys() == nil {
+        first.SetAnsiJoin()
+        $$ = algebra.NewAnsiJoin($5, true, first, $7)
     } else {
-        yylex.Error("Left hand side of an ANSI RIGHT OUTER JOIN must be a keyspace.")
+        join := algebra.NewAnsiJoin($1, false, $5, $7)
+        join.SetRightOuter()
+        $$ = join
+    }
+}
+|
+from_term FULL opt_outer JOIN simple_from_join_term ON expr
+{
+    switch first := $1.(type) {
+        case *algebra.Join, *algebra.IndexJoin:
+             yylex.Error(fmt.Sprintf("Cannot mix non ANSI JOIN on %s and ANSI JOIN on %s.", first.Alias(), $5.Alias()))
+        case *algebra.Nest, *algebra.IndexNest:
+             yylex.Error(fmt.Sprintf("Cannot mix non ANSI NEST on %s and ANSI JOIN on %s.", first.Alias(), $5.Alias()))
     }
+    join := algebra.NewAnsiJoin($1, true, $5, $7)
+    join.SetRightOuter()
+    $$ = join
 }
 ;
 
@@ -1203,9 +1536,13 @@ group
 ;
 
 group:
-GROUP BY exprs opt_letting opt_having
+GROUP BY group_terms opt_letting opt_having
 {
-    $$ = algebra.NewGroup($3, $4, $5)
+    if len($3) == 1 && len($3[0]) > 0 {
+        $$ = algebra.NewGroup($3[0], $4, $5)
+    } else {
+        $$ = algebra.NewGroupingSets($3, $4, $5)
+    }
 }
 |
 letting
@@ -1214,6 +1551,73 @@ letting
 }
 ;
 
+group_terms:
+group_term
+|
+group_terms COMMA group_term
+{
+    $$ = $1.Product($3)
+}
+;
+
+group_term:
+expr
+{
+    $$ = algebra.GroupingSets{expression.Expressions{$1}}
+}
+|
+ROLLUP LPAREN exprs RPAREN
+{
+    $$ = algebra.NewRollup($3)
+}
+|
+CUBE LPAREN exprs RPAREN
+{
+    $$ = algebra.NewCube($3)
+}
+|
+GROUPING_SETS LPAREN grouping_sets RPAREN
+{
+    $$ = $3
+}
+;
+
+grouping_sets:
+grouping_set
+|
+grouping_sets COMMA grouping_set
+{
+    $$ = append($1, $3...)
+}
+;
+
+grouping_set:
+expr
+{
+    $$ = algebra.GroupingSets{expression.Expressions{$1}}
+}
+|
+LPAREN RPAREN
+{
+    $$ = algebra.GroupingSets{expression.Expressions{}}
+}
+|
+LPAREN expr COMMA exprs RPAREN
+{
+    $$ = algebra.GroupingSets{append(expression.Expressions{$2}, $4...)}
+}
+|
+ROLLUP LPAREN exprs RPAREN
+{
+    $$ = algebra.NewRollup($3)
+}
+|
+CUBE LPAREN exprs RPAREN
+{
+    $$ = algebra.NewCube($3)
+}
+;
+
 exprs:
 expr
 {
@@ -1294,9 +1698,26 @@ sort_terms COMMA sort_term
 ;
 
 sort_term:
-expr opt_dir
+expr opt_dir opt_nulls
+{
+    $$ = newSortTerm($1, $2, $3)
+}
+;
+
+opt_nulls:
+/* empty */
+{
+    $$ = int64(algebra.NULLS_DEFAULT)
+}
+|
+NULLS FIRST
+{
+    $$ = int64(algebra.NULLS_FIRST)
+}
+|
+NULLS LAST
 {
-    $$ = algebra.NewSortTerm($1, $2)
+    $$ = int64(algebra.NULLS_LAST)
 }
 ;
 
@@ -1516,9 +1937,26 @@ UPSERT INTO keyspace_ref LPAREN key_expr opt_value_expr RPAREN fullselect opt_re
  *************************************************/
 
 delete:
-DELETE FROM keyspace_ref opt_use opt_where opt_limit opt_returning
+DELETE opt_optim_hints FROM keyspace_ref opt_use opt_delete_using opt_where opt_limit opt_returning
+{
+    if $5.Keys() != nil && $6 != nil {
+        yylex.Error("DELETE cannot have both USE KEYS and USING.")
+    }
+    delete := algebra.NewDelete($4, $5.Keys(), $5.Indexes(), $6, $7, $8, $9)
+    delete.SetOptimHints($2)
+    $$ = delete
+}
+;
+
+opt_delete_using:
+/* empty */
 {
-    $$ = algebra.NewDelete($3, $4.Keys(), $4.Indexes(), $5, $6, $7)
+    $$ = nil
+}
+|
+USING from_term
+{
+    $$ = $2
 }
 ;
 
@@ -1530,19 +1968,43 @@ DELETE FROM keyspace_ref opt_use opt_where opt_limit opt_returning
  *************************************************/
 
 update:
-UPDATE keyspace_ref opt_use set unset opt_where opt_limit opt_returning
+UPDATE opt_optim_hints keyspace_ref opt_use set unset opt_from opt_where opt_limit opt_returning
 {
-    $$ = algebra.NewUpdate($2, $3.Keys(), $3.Indexes(), $4, $5, $6, $7, $8)
+    if $4.Keys() != nil && $7 != nil {
+        yylex.Error("UPDATE cannot have both USE KEYS and FROM.")
+    }
+    if _, ok := $7.(*algebra.Pivot); ok {
+        yylex.Error("UPDATE FROM cannot have PIVOT.")
+    }
+    update := algebra.NewUpdate($3, $4.Keys(), $4.Indexes(), $5, $6, $7, $8, $9, $10)
+    update.SetOptimHints($2)
+    $$ = update
 }
 |
-UPDATE keyspace_ref opt_use set opt_where opt_limit opt_returning
+UPDATE opt_optim_hints keyspace_ref opt_use set opt_from opt_where opt_limit opt_returning
 {
-    $$ = algebra.NewUpdate($2, $3.Keys(), $3.Indexes(), $4, nil, $5, $6, $7)
+    if $4.Keys() != nil && $6 != nil {
+        yylex.Error("UPDATE cannot have both USE KEYS and FROM.")
+    }
+    if _, ok := $6.(*algebra.Pivot); ok {
+        yylex.Error("UPDATE FROM cannot have PIVOT.")
+    }
+    update := algebra.NewUpdate($3, $4.Keys(), $4.Indexes(), $5, nil, $6, $7, $8, $9)
+    update.SetOptimHints($2)
+    $$ = update
 }
 |
-UPDATE keyspace_ref opt_use unset opt_where opt_limit opt_returning
+UPDATE opt_optim_hints keyspace_ref opt_use unset opt_from opt_where opt_limit opt_returning
 {
-    $$ = algebra.NewUpdate($2, $3.Keys(), $3.Indexes(), nil, $4, $5, $6, $7)
+    if $4.Keys() != nil && $6 != nil {
+        yylex.Error("UPDATE cannot have both USE KEYS and FROM.")
+    }
+    if _, ok := $6.(*algebra.Pivot); ok {
+        yylex.Error("UPDATE FROM cannot have PIVOT.")
+    }
+    update := algebra.NewUpdate($3, $4.Keys(), $4.Indexes(), nil, $5, $6, $7, $8, $9)
+    update.SetOptimHints($2)
+    $$ = update
 }
 ;
 
@@ -1686,18 +2148,40 @@ path opt_update_for
  *************************************************/
 
 merge:
-MERGE INTO keyspace_ref USING simple_from_term ON key_expr merge_actions opt_limit opt_returning
+MERGE opt_optim_hints INTO keyspace_ref USING merge_source ON key_expr merge_actions opt_limit opt_returning
+{
+    if $9.SourceUpdate() != nil || $9.SourceDelete() != nil {
+        yylex.Error("MERGE with ON KEY cannot have WHEN NOT MATCHED BY SOURCE.")
+    }
+    if $9.Insert() != nil && $9.Insert().Key() != nil {
+        yylex.Error("MERGE with ON KEY cannot have an INSERT KEY.")
+    }
+    merge := algebra.NewMerge($4, $6, $8, nil, $9, $10, $11)
+    merge.SetOptimHints($2)
+    $$ = merge
+}
+|
+MERGE opt_optim_hints INTO keyspace_ref USING merge_source ON expr merge_actions opt_limit opt_returning
 {
-     switch other := $5.(type) {
+    if $9.Insert() != nil && $9.Insert().Key() == nil {
+        yylex.Error("MERGE with an ON clause must INSERT (KEY key, VALUE value).")
+    }
+    merge := algebra.NewMerge($4, $6, nil, $8, $9, $10, $11)
+    merge.SetOptimHints($2)
+    $$ = merge
+}
+;
+
+merge_source:
+simple_from_term
+{
+     switch other := $1.(type) {
          case *algebra.SubqueryTerm:
-              source := algebra.NewMergeSourceSelect(other.Subquery(), other.Alias())
-              $$ = algebra.NewMerge($3, source, $7, $8, $9, $10)
+              $$ = algebra.NewMergeSourceSelect(other.Subquery(), other.Alias())
          case *algebra.ExpressionTerm:
-              source := algebra.NewMergeSourceExpression(other, "")
-              $$ = algebra.NewMerge($3, source, $7, $8, $9, $10)
+              $$ = algebra.NewMergeSourceExpression(other, "")
          case *algebra.KeyspaceTerm:
-              source := algebra.NewMergeSourceFrom(other, "")
-              $$ = algebra.NewMerge($3, source, $7, $8, $9, $10)
+              $$ = algebra.NewMergeSourceFrom(other, "")
          default:
 	      yylex.Error("MERGE source term is UNKNOWN.")
      }
@@ -1707,51 +2191,78 @@ MERGE INTO keyspace_ref USING simple_from_term ON key_expr merge_actions opt_lim
 merge_actions:
 /* empty */
 {
-    $$ = algebra.NewMergeActions(nil, nil, nil)
+    $$ = algebra.NewMergeActions(nil, nil, nil, nil, nil)
+}
+|
+merge_actions WHEN MATCHED opt_merge_condition THEN UPDATE merge_update
+{
+    checkMergeAction(yylex, $1, _MERGE_UPDATE)
+    $7.SetCondition($4)
+    $$ = algebra.NewMergeActions($7, nil, nil, nil, nil)
+}
+|
+merge_actions WHEN MATCHED opt_merge_condition THEN DELETE merge_delete
+{
+    checkMergeAction(yylex, $1, _MERGE_DELETE)
+    $7.SetCondition($4)
+    $$ = algebra.NewMergeActions($1.Update(), $7, nil, nil, nil)
 }
 |
-WHEN MATCHED THEN UPDATE merge_update opt_merge_delete_insert
+merge_actions WHEN NOT MATCHED opt_merge_by opt_merge_condition THEN INSERT merge_insert
 {
-    $$ = algebra.NewMergeActions($5, $6.Delete(), $6.Insert())
+    if $5 != "" && $5 != "target" {
+        yylex.Error("WHEN NOT MATCHED BY SOURCE cannot INSERT.")
+    }
+    checkMergeAction(yylex, $1, _MERGE_INSERT)
+    $9.SetCondition($6)
+    $$ = algebra.NewMergeActions($1.Update(), $1.Delete(), $9, nil, nil)
 }
 |
-WHEN MATCHED THEN DELETE merge_delete opt_merge_insert
+merge_actions WHEN NOT MATCHED opt_merge_by opt_merge_condition THEN UPDATE merge_update
 {
-    $$ = algebra.NewMergeActions(nil, $5, $6)
+    if $5 != "source" {
+        yylex.Error("WHEN NOT MATCHED can only UPDATE BY SOURCE.")
+    }
+    checkMergeAction(yylex, $1, _MERGE_SOURCE_UPDATE)
+    $9.SetCondition($6)
+    $$ = algebra.NewMergeActions($1.Update(), $1.Delete(), $1.Insert(), $9, nil)
 }
 |
-WHEN NOT MATCHED THEN INSERT merge_insert
+merge_actions WHEN NOT MATCHED opt_merge_by opt_merge_condition THEN DELETE merge_delete
 {
-    $$ = algebra.NewMergeActions(nil, nil, $6)
+    if $5 != "source" {
+        yylex.Error("WHEN NOT MATCHED can only DELETE BY SOURCE.")
+    }
+    checkMergeAction(yylex, $1, _MERGE_SOURCE_DELETE)
+    $9.SetCondition($6)
+    $$ = algebra.NewMergeActions($1.Update(), $1.Delete(), $1.Insert(), $1.SourceUpdate(), $9)
 }
 ;
 
-opt_merge_delete_insert:
+opt_merge_by:
 /* empty */
 {
-    $$ = algebra.NewMergeActions(nil, nil, nil)
-}
-|
-WHEN MATCHED THEN DELETE merge_delete opt_merge_insert
-{
-    $$ = algebra.NewMergeActions(nil, $5, $6)
+    $$ = ""
 }
 |
-WHEN NOT MATCHED THEN INSERT merge_insert
+BY IDENT
 {
-    $$ = algebra.NewMergeActions(nil, nil, $6)
+    $$ = strings.ToLower($2)
+    if $$ != "source" && $$ != "target" {
+        yylex.Error(fmt.Sprintf("WHEN NOT MATCHED BY %s must be BY SOURCE or BY TARGET.", $2))
+    }
 }
 ;
 
-opt_merge_insert:
+opt_merge_condition:
 /* empty */
 {
     $$ = nil
 }
 |
-WHEN NOT MATCHED THEN INSERT merge_insert
+AND expr
 {
-    $$ = $6
+    $$ = $2
 }
 ;
 
@@ -1782,10 +2293,15 @@ opt_where
 merge_insert:
 expr opt_where
 {
-    $$ = algebra.NewMergeInsert($1, $2)
+    $$ = algebra.NewMergeInsert(nil, $1, $2)
 }
-;
-
+|
+LPAREN KEY expr COMMA VALUE expr RPAREN opt_where
+{
+    $$ = algebra.NewMergeInsert($3, $6, $8)
+}
+;
+
 /*************************************************
  *
  * GRANT ROLE
@@ -1927,6 +2443,316 @@ index_name:
 IDENT
 ;
 
+/*************************************************
+ *
+ * CREATE VIEW, DROP VIEW, REFRESH MATERIALIZED VIEW
+ *
+ *************************************************/
+
+view_stmt:
+create_view
+|
+drop_view
+|
+refresh_view
+;
+
+create_view:
+CREATE VIEW named_keyspace_ref AS fullselect
+{
+    $$ = algebra.NewCreateView($3, false, $5, yylex.(*lexer).Remainder($<tokOffset>4))
+}
+|
+CREATE MATERIALIZED VIEW named_keyspace_ref AS fullselect
+{
+    $$ = algebra.NewCreateView($4, true, $6, yylex.(*lexer).Remainder($<tokOffset>5))
+}
+;
+
+drop_view:
+DROP VIEW named_keyspace_ref
+{
+    $$ = algebra.NewDropView($3, false)
+}
+|
+DROP MATERIALIZED VIEW named_keyspace_ref
+{
+    $$ = algebra.NewDropView($4, true)
+}
+;
+
+refresh_view:
+REFRESH MATERIALIZED VIEW named_keyspace_ref opt_refresh_mode
+{
+    $$ = algebra.NewRefreshView($4, $5)
+}
+;
+
+opt_refresh_mode:
+/* empty */
+{
+    $$ = algebra.REFRESH_DEFAULT
+}
+|
+FULL
+{
+    $$ = algebra.REFRESH_FULL
+}
+|
+IDENT
+{
+    if !strings.EqualFold($1, algebra.REFRESH_INCREMENTAL) {
+        yylex.Error(fmt.Sprintf("Unexpected %s after REFRESH MATERIALIZED VIEW.", $1))
+    }
+    $$ = algebra.REFRESH_INCREMENTAL
+}
+;
+
+/*************************************************
+ *
+ * CREATE SEQUENCE, ALTER SEQUENCE, DROP SEQUENCE
+ *
+ *************************************************/
+
+sequence_stmt:
+create_sequence
+|
+alter_sequence
+|
+drop_sequence
+;
+
+create_sequence:
+CREATE SEQUENCE named_keyspace_ref opt_sequence_options
+{
+    $$ = algebra.NewCreateSequence($3, $4)
+}
+;
+
+alter_sequence:
+ALTER SEQUENCE named_keyspace_ref sequence_options
+{
+    $$ = algebra.NewAlterSequence($3, $4)
+}
+;
+
+drop_sequence:
+DROP SEQUENCE named_keyspace_ref
+{
+    $$ = algebra.NewDropSequence($3)
+}
+;
+
+opt_sequence_options:
+/* empty */
+{
+    $$ = nil
+}
+|
+sequence_options
+;
+
+sequence_options:
+sequence_option
+|
+sequence_options sequence_option
+{
+    $$ = addSequenceOption(yylex, $1, $2)
+}
+;
+
+sequence_option:
+START WITH sequence_int
+{
+    $$ = sequenceOption(yylex, "START WITH", "start", $3)
+}
+|
+INCREMENT BY sequence_int
+{
+    $$ = sequenceOption(yylex, "INCREMENT BY", "increment", $3)
+}
+|
+/* RESTART WITH */
+IDENT WITH sequence_int
+{
+    name := ""
+    if strings.EqualFold($1, "restart") {
+        name = "restart"
+    }
+    $$ = sequenceOption(yylex, $1+" WITH", name, $3)
+}
+|
+/* MINVALUE, MAXVALUE, CACHE, RESTART */
+IDENT sequence_int
+{
+    name := ""
+    switch strings.ToLower($1) {
+    case "minvalue":
+        name = "min"
+    case "maxvalue":
+        name = "max"
+    case "cache", "restart":
+        name = strings.ToLower($1)
+    }
+    $$ = sequenceOption(yylex, $1, name, $2)
+}
+|
+/* CYCLE, RESTART */
+IDENT
+{
+    name := ""
+    switch strings.ToLower($1) {
+    case "cycle", "restart":
+        name = strings.ToLower($1)
+    }
+    $$ = sequenceOption(yylex, $1, name, true)
+}
+|
+/* NO CYCLE, NO CACHE, NO MINVALUE, NO MAXVALUE */
+NO IDENT
+{
+    name := ""
+    var val interface{}
+    switch strings.ToLower($2) {
+    case "cycle":
+        name, val = "cycle", false
+    case "cache":
+        name, val = "cache", int64(1)
+    case "minvalue":
+        name, val = "min", int64(math.MinInt64)
+    case "maxvalue":
+        name, val = "max", int64(math.MaxInt64)
+    }
+    $$ = sequenceOption(yylex, "NO "+$2, name, val)
+}
+;
+
+sequence_ref:
+IDENT %prec SEQUENCE
+{
+    $$ = algebra.NewKeyspaceRef("", $1, "")
+}
+|
+IDENT COLON IDENT
+{
+    $$ = algebra.NewKeyspaceRef($1, $3, "")
+}
+;
+
+sequence_int:
+INT
+|
+MINUS INT
+{
+    $$ = -$2
+}
+;
+
+/*************************************************
+ *
+ * CREATE TRIGGER, DROP TRIGGER
+ *
+ *************************************************/
+
+trigger_stmt:
+create_trigger
+|
+drop_trigger
+;
+
+create_trigger:
+CREATE TRIGGER IDENT trigger_timing trigger_event ON named_keyspace_ref FOR EACH IDENT opt_trigger_when EXECUTE dml_stmt
+{
+    if !strings.EqualFold($10, "row") {
+        yylex.Error(fmt.Sprintf("Unexpected FOR EACH %s: triggers are FOR EACH ROW.", $10))
+    }
+    $$ = algebra.NewCreateTrigger($3, $4, $5, $7, $11, $13, yylex.(*lexer).Remainder($<tokOffset>12))
+}
+;
+
+/* BEFORE, AFTER */
+trigger_timing:
+IDENT
+{
+    switch strings.ToLower($1) {
+    case "before", "after":
+        $$ = strings.ToLower($1)
+    default:
+        yylex.Error(fmt.Sprintf("Unexpected %s: triggers are BEFORE or AFTER.", $1))
+    }
+}
+;
+
+trigger_event:
+INSERT
+{
+    $$ = "insert"
+}
+|
+UPDATE
+{
+    $$ = "update"
+}
+|
+DELETE
+{
+    $$ = "delete"
+}
+;
+
+opt_trigger_when:
+/* empty */
+{
+    $$ = nil
+}
+|
+WHEN expr
+{
+    $$ = $2
+}
+;
+
+drop_trigger:
+DROP TRIGGER IDENT ON named_keyspace_ref
+{
+    $$ = algebra.NewDropTrigger($3, $5)
+}
+;
+
+/*************************************************
+ *
+ * ALTER KEYSPACE
+ *
+ *************************************************/
+
+keyspace_stmt:
+alter_keyspace
+;
+
+alter_keyspace:
+/* SET VALIDATION */
+ALTER KEYSPACE named_keyspace_ref SET IDENT expr opt_index_with
+{
+    if !strings.EqualFold($5, "validation") {
+        yylex.Error(fmt.Sprintf("Unexpected %s after ALTER KEYSPACE ... SET.", $5))
+    }
+    schema := $6.Value()
+    if schema == nil || schema.Type() != value.OBJECT {
+        yylex.Error("VALIDATION schema must be a static object.")
+    }
+    $$ = algebra.NewAlterKeyspace($3, schema, $7)
+}
+|
+/* UNSET VALIDATION */
+ALTER KEYSPACE named_keyspace_ref UNSET IDENT
+{
+    if !strings.EqualFold($5, "validation") {
+        yylex.Error(fmt.Sprintf("Unexpected %s after ALTER KEYSPACE ... UNSET.", $5))
+    }
+    $$ = algebra.NewAlterKeyspace($3, nil, nil)
+}
+;
+
 named_keyspace_ref:
 keyspace_name
 {
@@ -1975,6 +2801,11 @@ USING FTS
 {
     $$ = datastore.FTS
 }
+|
+USING VECTOR
+{
+    $$ = datastore.VECTOR
+}
 ;
 
 opt_index_with:
@@ -2249,6 +3080,17 @@ expr CONCAT expr
     $$ = expression.NewConcat($1, $3)
 }
 |
+/* Collation */
+expr COLLATE STR
+{
+    $$ = newCollate(yylex, $1, $3)
+}
+|
+expr COLLATE IDENT
+{
+    $$ = newCollate(yylex, $1, $3)
+}
+|
 /* Logical */
 expr AND expr
 {
@@ -2321,6 +3163,56 @@ expr NOT LIKE expr
     $$ = expression.NewNotLike($1, $4)
 }
 |
+expr LIKE expr ESCAPE expr
+{
+    $$ = expression.NewLikeEscape($1, $3, $5)
+}
+|
+expr NOT LIKE expr ESCAPE expr
+{
+    $$ = expression.NewNotLikeEscape($1, $4, $6)
+}
+|
+expr ILIKE expr
+{
+    $$ = expression.NewILike($1, $3, nil)
+}
+|
+expr NOT ILIKE expr
+{
+    $$ = expression.NewNotILike($1, $4, nil)
+}
+|
+expr ILIKE expr ESCAPE expr
+{
+    $$ = expression.NewILike($1, $3, $5)
+}
+|
+expr NOT ILIKE expr ESCAPE expr
+{
+    $$ = expression.NewNotILike($1, $4, $6)
+}
+|
+expr SIMILAR_TO expr
+{
+    $$ = expression.NewSimilar($1, $3, nil)
+}
+|
+expr NOT SIMILAR_TO expr
+{
+    $$ = expression.NewNotSimilar($1, $4, nil)
+}
+|
+expr SIMILAR_TO expr ESCAPE expr
+{
+    $$ = expression.NewSimilar($1, $3, $5)
+}
+|
+expr NOT SIMILAR_TO expr ESCAPE expr
+{
+    $$ = expression.NewNotSimilar($1, $4, $6)
+}
+|
 expr IN expr
 {
     $$ = expression.NewIn($1, $3)
@@ -2517,6 +3409,17 @@ b_expr CONCAT b_expr
 {
     $$ = expression.NewConcat($1, $3)
 }
+|
+/* Collation */
+b_expr COLLATE STR
+{
+    $$ = newCollate(yylex, $1, $3)
+}
+|
+b_expr COLLATE IDENT
+{
+    $$ = newCollate(yylex, $1, $3)
+}
 ;
 
 
@@ -2734,7 +3637,17 @@ ELSE expr
  *************************************************/
 
 function_expr:
-function_name LPAREN opt_exprs RPAREN
+NEXT_VALUE FOR sequence_ref
+{
+    $$ = algebra.NewSequenceValue($3, true)
+}
+|
+PREV_VALUE FOR sequence_ref
+{
+    $$ = algebra.NewSequenceValue($3, false)
+}
+|
+function_name LPAREN opt_exprs RPAREN opt_agg_filter
 {
     $$ = nil;
     f, ok := expression.GetFunction($1);
@@ -2746,40 +3659,138 @@ function_name LPAREN opt_exprs RPAREN
         if len($3) < f.MinArgs() || len($3) > f.MaxArgs() {
             yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", $1));
         } else {
-            $$ = f.Constructor()($3...);
+            $$ = filterAggregate(yylex, $1, f.Constructor()($3...), $5);
         }
     } else {
         yylex.Error(fmt.Sprintf("Invalid function %s.", $1));
     }
 }
 |
-function_name LPAREN DISTINCT expr RPAREN
+function_name LPAREN DISTINCT expr RPAREN opt_agg_filter
 {
     agg, ok := algebra.GetAggregate($1, true);
     if ok {
-        $$ = agg.Constructor()($4);
+        $$ = filterAggregate(yylex, $1, agg.Constructor()($4), $6);
     } else {
         yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", $1));
     }
 }
 |
-function_name LPAREN STAR RPAREN
+function_name LPAREN STAR RPAREN opt_agg_filter
 {
     if strings.ToLower($1) != "count" {
         yylex.Error(fmt.Sprintf("Invalid aggregate function %s(*).", $1));
     } else {
         agg, ok := algebra.GetAggregate($1, false);
         if ok {
-            $$ = agg.Constructor()(nil);
+            $$ = filterAggregate(yylex, $1, agg.Constructor()(nil), $5);
         } else {
             yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", $1));
         }
     }
 }
+|
+function_name LPAREN exprs ORDER BY sort_terms RPAREN opt_agg_filter
+{
+    agg, err := algebra.NewOrderedAggregate($1, $3, $6);
+    if err != nil {
+        yylex.Error(err.Error());
+    } else {
+        $$ = filterAggregate(yylex, $1, agg, $8);
+    }
+}
+|
+function_name LPAREN opt_exprs RPAREN AGG_WITHIN GROUP LPAREN ORDER BY sort_term RPAREN opt_agg_filter
+{
+    agg, err := algebra.NewWithinGroupAggregate($1, $3, $10);
+    if err != nil {
+        yylex.Error(err.Error());
+    } else {
+        $$ = filterAggregate(yylex, $1, agg, $12);
+    }
+}
+|
+CAST LPAREN expr AS cast_type opt_cast_format RPAREN
+{
+    $$ = expression.NewCast($3, $5, $6, false)
+}
+|
+TRY_CAST LPAREN expr AS cast_type opt_cast_format RPAREN
+{
+    $$ = expression.NewCast($3, $5, $6, true)
+}
+;
+
+opt_agg_filter:
+/* empty */
+{
+    $$ = nil
+}
+|
+AGG_FILTER LPAREN WHERE expr RPAREN
+{
+    $$ = $4
+}
 ;
 
 function_name:
 IDENT
+|
+/* VALIDATE(expr, schema) */
+VALIDATE
+{
+    $$ = "validate"
+}
+;
+
+cast_type:
+IDENT
+{
+    if !expression.IsCastType($1) {
+        yylex.Error(fmt.Sprintf("Invalid CAST type %s.", $1))
+    }
+    $$ = $1
+}
+|
+STRING
+{
+    $$ = "string"
+}
+|
+NUMBER
+{
+    $$ = "number"
+}
+|
+BOOLEAN
+{
+    $$ = "boolean"
+}
+|
+ARRAY
+{
+    $$ = "array"
+}
+|
+OBJECT
+{
+    $$ = "object"
+}
+;
+
+opt_cast_format:
+/* empty */
+{
+    $$ = nil
+}
+|
+IDENT expr
+{
+    if !strings.EqualFold($1, "format") {
+        yylex.Error(fmt.Sprintf("Unexpected %s in CAST.", $1))
+    }
+    $$ = $2
+}
 ;
 
 
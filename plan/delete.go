@@ -19,16 +19,19 @@ import (
 
 type SendDelete struct {
 	readwrite
-	keyspace datastore.Keyspace
-	alias    string
-	limit    expression.Expression
+	keyspace     datastore.Keyspace
+	alias        string
+	limit        expression.Expression
+	distinctKeys bool
 }
 
-func NewSendDelete(keyspace datastore.Keyspace, alias string, limit expression.Expression) *SendDelete {
+func NewSendDelete(keyspace datastore.Keyspace, alias string, limit expression.Expression,
+	distinctKeys bool) *SendDelete {
 	return &SendDelete{
-		keyspace: keyspace,
-		alias:    alias,
-		limit:    limit,
+		keyspace:     keyspace,
+		alias:        alias,
+		limit:        limit,
+		distinctKeys: distinctKeys,
 	}
 }
 
@@ -52,6 +55,11 @@ func (this *SendDelete) Limit() expression.Expression {
 	return this.limit
 }
 
+// Whether each key is mutated only once, when the keys come from a join
+func (this *SendDelete) DistinctKeys() bool {
+	return this.distinctKeys
+}
+
 func (this *SendDelete) MarshalJSON() ([]byte, error) {
 	return json.Marshal(this.MarshalBase(nil))
 }
@@ -66,6 +74,10 @@ func (this *SendDelete) MarshalBase(f func(map[string]interface{})) map[string]i
 		r["limit"] = this.limit
 	}
 
+	if this.distinctKeys {
+		r["distinct_keys"] = this.distinctKeys
+	}
+
 	if f != nil {
 		f(r)
 	}
@@ -74,11 +86,12 @@ func (this *SendDelete) MarshalBase(f func(map[string]interface{})) map[string]i
 
 func (this *SendDelete) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		_     string `json:"#operator"`
-		Names string `json:"namespace"`
-		Keys  string `json:"keyspace"`
-		Alias string `json:"alias"`
-		Limit string `json:"limit"`
+		_            string `json:"#operator"`
+		Names        string `json:"namespace"`
+		Keys         string `json:"keyspace"`
+		Alias        string `json:"alias"`
+		Limit        string `json:"limit"`
+		DistinctKeys bool   `json:"distinct_keys"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -87,6 +100,7 @@ func (this *SendDelete) UnmarshalJSON(body []byte) error {
 	}
 
 	this.alias = _unmarshalled.Alias
+	this.distinctKeys = _unmarshalled.DistinctKeys
 
 	if _unmarshalled.Limit != "" {
 		this.limit, err = parser.Parse(_unmarshalled.Limit)
@@ -14,15 +14,28 @@ import (
 )
 
 type Explain struct {
-	readonly
-	op   Operator
-	text string
+	op         Operator
+	text       string
+	analyze    bool
+	results    bool
+	optimHints map[string]interface{}
 }
 
-func NewExplain(op Operator, text string) *Explain {
+func NewExplain(op Operator, text string, optimHints map[string]interface{}) *Explain {
 	return &Explain{
-		op:   op,
-		text: text,
+		op:         op,
+		text:       text,
+		optimHints: optimHints,
+	}
+}
+
+func NewExplainAnalyze(op Operator, text string, results bool, optimHints map[string]interface{}) *Explain {
+	return &Explain{
+		op:         op,
+		text:       text,
+		analyze:    true,
+		results:    results,
+		optimHints: optimHints,
 	}
 }
 
@@ -34,10 +47,34 @@ func (this *Explain) New() Operator {
 	return &Explain{}
 }
 
+/*
+EXPLAIN ANALYZE executes the statement, and so is only read-only
+if the statement is.
+*/
+func (this *Explain) Readonly() bool {
+	return !this.analyze || this.op.Readonly()
+}
+
 func (this *Explain) Operator() Operator {
 	return this.op
 }
 
+func (this *Explain) Analyze() bool {
+	return this.analyze
+}
+
+func (this *Explain) Results() bool {
+	return this.results
+}
+
+/*
+The optimizer hints followed, not followed, and invalid, if the
+statement has hints.
+*/
+func (this *Explain) OptimHints() map[string]interface{} {
+	return this.optimHints
+}
+
 func (this *Explain) MarshalJSON() ([]byte, error) {
 	return json.Marshal(this.MarshalBase(nil))
 }
@@ -46,6 +83,15 @@ func (this *Explain) MarshalBase(f func(map[string]interface{})) map[string]inte
 	r := make(map[string]interface{}, 2)
 	r["plan"] = this.op
 	r["text"] = this.text
+	if this.analyze {
+		r["analyze"] = this.analyze
+	}
+	if this.results {
+		r["withResults"] = this.results
+	}
+	if this.optimHints != nil {
+		r["optimizer_hints"] = this.optimHints
+	}
 	if f != nil {
 		f(r)
 	} else {
@@ -56,8 +102,11 @@ func (this *Explain) MarshalBase(f func(map[string]interface{})) map[string]inte
 
 func (this *Explain) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		Op   json.RawMessage `json:"plan"`
-		Text string          `json:"text"`
+		Op         json.RawMessage        `json:"plan"`
+		Text       string                 `json:"text"`
+		Analyze    bool                   `json:"analyze"`
+		Results    bool                   `json:"withResults"`
+		OptimHints map[string]interface{} `json:"optimizer_hints"`
 	}
 
 	var op_type struct {
@@ -75,6 +124,9 @@ func (this *Explain) UnmarshalJSON(body []byte) error {
 	}
 
 	this.text = _unmarshalled.Text
+	this.analyze = _unmarshalled.Analyze
+	this.results = _unmarshalled.Results
+	this.optimHints = _unmarshalled.OptimHints
 
 	err = json.Unmarshal(_unmarshalled.Op, &op_type)
 	if err != nil {
@@ -84,3 +136,7 @@ func (this *Explain) UnmarshalJSON(body []byte) error {
 	this.op, err = MakeOperator(op_type.Operator, _unmarshalled.Op)
 	return err
 }
+
+func (this *Explain) verify(prepared *Prepared) bool {
+	return !this.analyze || this.op.verify(prepared)
+}
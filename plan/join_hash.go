@@ -0,0 +1,199 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+)
+
+// HashJoin joins its input to the objects produced by its child, which are
+// hashed on the build expressions and probed with the probe expressions
+// evaluated against each input object.
+// It is used for RIGHT and FULL OUTER JOINs, which need to track
+// the unmatched objects on both sides.
+type HashJoin struct {
+	readonly
+	outer      bool
+	rightOuter bool
+	alias      string
+	onclause   expression.Expression
+	buildExprs expression.Expressions
+	probeExprs expression.Expressions
+	child      Operator
+}
+
+func NewHashJoin(join *algebra.AnsiJoin, buildExprs, probeExprs expression.Expressions, child Operator) *HashJoin {
+	rv := &HashJoin{
+		outer:      join.Outer(),
+		rightOuter: join.RightOuter(),
+		alias:      join.Alias(),
+		onclause:   join.Onclause(),
+		buildExprs: buildExprs,
+		probeExprs: probeExprs,
+		child:      child,
+	}
+
+	return rv
+}
+
+func (this *HashJoin) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitHashJoin(this)
+}
+
+func (this *HashJoin) New() Operator {
+	return &HashJoin{}
+}
+
+func (this *HashJoin) Outer() bool {
+	return this.outer
+}
+
+func (this *HashJoin) RightOuter() bool {
+	return this.rightOuter
+}
+
+func (this *HashJoin) Alias() string {
+	return this.alias
+}
+
+func (this *HashJoin) Onclause() expression.Expression {
+	return this.onclause
+}
+
+func (this *HashJoin) BuildExprs() expression.Expressions {
+	return this.buildExprs
+}
+
+func (this *HashJoin) ProbeExprs() expression.Expressions {
+	return this.probeExprs
+}
+
+func (this *HashJoin) Child() Operator {
+	return this.child
+}
+
+func (this *HashJoin) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *HashJoin) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "HashJoin"}
+	r["alias"] = this.alias
+	r["on_clause"] = expression.NewStringer().Visit(this.onclause)
+
+	if this.outer {
+		r["outer"] = this.outer
+	}
+
+	if this.rightOuter {
+		r["right_outer"] = this.rightOuter
+	}
+
+	if len(this.buildExprs) > 0 {
+		r["build_exprs"] = marshalExpressions(this.buildExprs)
+		r["probe_exprs"] = marshalExpressions(this.probeExprs)
+	}
+
+	r["~child"] = this.child
+
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func marshalExpressions(exprs expression.Expressions) []string {
+	rv := make([]string, len(exprs))
+	for i, expr := range exprs {
+		rv[i] = expression.NewStringer().Visit(expr)
+	}
+	return rv
+}
+
+func unmarshalExpressions(exprs []string) (expression.Expressions, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+
+	var err error
+
+	rv := make(expression.Expressions, len(exprs))
+	for i, expr := range exprs {
+		rv[i], err = parser.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+func (this *HashJoin) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_          string          `json:"#operator"`
+		Onclause   string          `json:"on_clause"`
+		Outer      bool            `json:"outer"`
+		RightOuter bool            `json:"right_outer"`
+		Alias      string          `json:"alias"`
+		BuildExprs []string        `json:"build_exprs"`
+		ProbeExprs []string        `json:"probe_exprs"`
+		Child      json.RawMessage `json:"~child"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	if _unmarshalled.Onclause != "" {
+		this.onclause, err = parser.Parse(_unmarshalled.Onclause)
+		if err != nil {
+			return err
+		}
+	}
+
+	this.outer = _unmarshalled.Outer
+	this.rightOuter = _unmarshalled.RightOuter
+	this.alias = _unmarshalled.Alias
+
+	this.buildExprs, err = unmarshalExpressions(_unmarshalled.BuildExprs)
+	if err != nil {
+		return err
+	}
+
+	this.probeExprs, err = unmarshalExpressions(_unmarshalled.ProbeExprs)
+	if err != nil {
+		return err
+	}
+
+	raw_child := _unmarshalled.Child
+	var child_type struct {
+		Op_name string `json:"#operator"`
+	}
+
+	err = json.Unmarshal(raw_child, &child_type)
+	if err != nil {
+		return err
+	}
+
+	this.child, err = MakeOperator(child_type.Op_name, raw_child)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (this *HashJoin) verify(prepared *Prepared) bool {
+	return this.child.verify(prepared)
+}
@@ -0,0 +1,103 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/value"
+)
+
+// Alter keyspace
+type AlterKeyspace struct {
+	readwrite
+	keyspace   datastore.Keyspace
+	validation value.Value
+	options    value.Value
+}
+
+func NewAlterKeyspace(keyspace datastore.Keyspace, validation, options value.Value) *AlterKeyspace {
+	return &AlterKeyspace{
+		keyspace:   keyspace,
+		validation: validation,
+		options:    options,
+	}
+}
+
+func (this *AlterKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAlterKeyspace(this)
+}
+
+func (this *AlterKeyspace) New() Operator {
+	return &AlterKeyspace{}
+}
+
+func (this *AlterKeyspace) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
+func (this *AlterKeyspace) Validation() value.Value {
+	return this.validation
+}
+
+func (this *AlterKeyspace) Options() value.Value {
+	return this.options
+}
+
+func (this *AlterKeyspace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *AlterKeyspace) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "AlterKeyspace"}
+	r["namespace"] = this.keyspace.NamespaceId()
+	r["keyspace"] = this.keyspace.Name()
+	if this.validation != nil {
+		r["validation"] = this.validation
+	}
+	if this.options != nil {
+		r["options"] = this.options
+	}
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *AlterKeyspace) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_          string          `json:"#operator"`
+		Namespace  string          `json:"namespace"`
+		Keyspace   string          `json:"keyspace"`
+		Validation json.RawMessage `json:"validation"`
+		Options    json.RawMessage `json:"options"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.validation = nil
+	if len(_unmarshalled.Validation) > 0 {
+		this.validation = value.NewValue([]byte(_unmarshalled.Validation))
+	}
+	this.options = nil
+	if len(_unmarshalled.Options) > 0 {
+		this.options = value.NewValue([]byte(_unmarshalled.Options))
+	}
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Namespace, _unmarshalled.Keyspace)
+	return err
+}
+
+func (this *AlterKeyspace) verify(prepared *Prepared) bool {
+	return verifyKeyspace(this.keyspace, prepared)
+}
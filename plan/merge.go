@@ -18,26 +18,65 @@ import (
 	"github.com/couchbase/query/expression/parser"
 )
 
+// Merge either fetches the target document by key, or, without a key,
+// receives the source joined to the target documents matched by the ON clause.
+// Each WHEN clause has an optional condition; a matched object goes to
+// the first clause whose condition it satisfies, and also to the following
+// ones while it only passes clauses without a condition.
 type Merge struct {
 	readwrite
-	keyspace datastore.Keyspace
-	ref      *algebra.KeyspaceRef
-	key      expression.Expression
-	update   Operator
-	delete   Operator
-	insert   Operator
+	keyspace         datastore.Keyspace
+	ref              *algebra.KeyspaceRef
+	source           string
+	key              expression.Expression
+	update           Operator
+	updateCond       expression.Expression
+	delete           Operator
+	deleteCond       expression.Expression
+	insert           Operator
+	insertCond       expression.Expression
+	sourceUpdate     Operator
+	sourceUpdateCond expression.Expression
+	sourceDelete     Operator
+	sourceDeleteCond expression.Expression
 }
 
-func NewMerge(keyspace datastore.Keyspace, ref *algebra.KeyspaceRef,
-	key expression.Expression, update, delete, insert Operator) *Merge {
-	return &Merge{
-		keyspace: keyspace,
-		ref:      ref,
-		key:      key,
-		update:   update,
-		delete:   delete,
-		insert:   insert,
+func NewMerge(keyspace datastore.Keyspace, ref *algebra.KeyspaceRef, source string,
+	key expression.Expression, actions *algebra.MergeActions,
+	update, delete, insert, sourceUpdate, sourceDelete Operator) *Merge {
+	rv := &Merge{
+		keyspace:     keyspace,
+		ref:          ref,
+		source:       source,
+		key:          key,
+		update:       update,
+		delete:       delete,
+		insert:       insert,
+		sourceUpdate: sourceUpdate,
+		sourceDelete: sourceDelete,
 	}
+
+	if actions.Update() != nil {
+		rv.updateCond = actions.Update().Condition()
+	}
+
+	if actions.Delete() != nil {
+		rv.deleteCond = actions.Delete().Condition()
+	}
+
+	if actions.Insert() != nil {
+		rv.insertCond = actions.Insert().Condition()
+	}
+
+	if actions.SourceUpdate() != nil {
+		rv.sourceUpdateCond = actions.SourceUpdate().Condition()
+	}
+
+	if actions.SourceDelete() != nil {
+		rv.sourceDeleteCond = actions.SourceDelete().Condition()
+	}
+
+	return rv
 }
 
 func (this *Merge) Accept(visitor Visitor) (interface{}, error) {
@@ -56,6 +95,11 @@ func (this *Merge) KeyspaceRef() *algebra.KeyspaceRef {
 	return this.ref
 }
 
+// alias of the source objects, when the target documents come from an ON clause
+func (this *Merge) Source() string {
+	return this.source
+}
+
 func (this *Merge) Key() expression.Expression {
 	return this.key
 }
@@ -64,14 +108,42 @@ func (this *Merge) Update() Operator {
 	return this.update
 }
 
+func (this *Merge) UpdateCondition() expression.Expression {
+	return this.updateCond
+}
+
 func (this *Merge) Delete() Operator {
 	return this.delete
 }
 
+func (this *Merge) DeleteCondition() expression.Expression {
+	return this.deleteCond
+}
+
 func (this *Merge) Insert() Operator {
 	return this.insert
 }
 
+func (this *Merge) InsertCondition() expression.Expression {
+	return this.insertCond
+}
+
+func (this *Merge) SourceUpdate() Operator {
+	return this.sourceUpdate
+}
+
+func (this *Merge) SourceUpdateCondition() expression.Expression {
+	return this.sourceUpdateCond
+}
+
+func (this *Merge) SourceDelete() Operator {
+	return this.sourceDelete
+}
+
+func (this *Merge) SourceDeleteCondition() expression.Expression {
+	return this.sourceDeleteCond
+}
+
 func (this *Merge) MarshalJSON() ([]byte, error) {
 	return json.Marshal(this.MarshalBase(nil))
 }
@@ -80,12 +152,30 @@ func (this *Merge) MarshalBase(f func(map[string]interface{})) map[string]interf
 	r := map[string]interface{}{"#operator": "Merge"}
 	r["keyspace"] = this.keyspace.Name()
 	r["namespace"] = this.keyspace.NamespaceId()
-	r["key"] = expression.NewStringer().Visit(this.key)
+	if this.key != nil {
+		r["key"] = expression.NewStringer().Visit(this.key)
+	} else {
+		r["source"] = this.source
+	}
 
 	if this.ref.As() != "" {
 		r["as"] = this.ref.As()
 	}
 
+	conds := map[string]expression.Expression{
+		"update_condition":        this.updateCond,
+		"delete_condition":        this.deleteCond,
+		"insert_condition":        this.insertCond,
+		"source_update_condition": this.sourceUpdateCond,
+		"source_delete_condition": this.sourceDeleteCond,
+	}
+
+	for name, cond := range conds {
+		if cond != nil {
+			r[name] = expression.NewStringer().Visit(cond)
+		}
+	}
+
 	if f != nil {
 		f(r)
 	} else {
@@ -98,20 +188,34 @@ func (this *Merge) MarshalBase(f func(map[string]interface{})) map[string]interf
 		if this.insert != nil {
 			r["insert"] = this.insert
 		}
+		if this.sourceUpdate != nil {
+			r["source_update"] = this.sourceUpdate
+		}
+		if this.sourceDelete != nil {
+			r["source_delete"] = this.sourceDelete
+		}
 	}
 	return r
 }
 
 func (this *Merge) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		_      string          `json:"#operator"`
-		Keys   string          `json:"keyspace"`
-		Names  string          `json:"namespace"`
-		As     string          `json:"as"`
-		Key    string          `json:"key"`
-		Update json.RawMessage `json:"update"`
-		Delete json.RawMessage `json:"delete"`
-		Insert json.RawMessage `json:"insert"`
+		_                string          `json:"#operator"`
+		Keys             string          `json:"keyspace"`
+		Names            string          `json:"namespace"`
+		As               string          `json:"as"`
+		Source           string          `json:"source"`
+		Key              string          `json:"key"`
+		Update           json.RawMessage `json:"update"`
+		UpdateCond       string          `json:"update_condition"`
+		Delete           json.RawMessage `json:"delete"`
+		DeleteCond       string          `json:"delete_condition"`
+		Insert           json.RawMessage `json:"insert"`
+		InsertCond       string          `json:"insert_condition"`
+		SourceUpdate     json.RawMessage `json:"source_update"`
+		SourceUpdateCond string          `json:"source_update_condition"`
+		SourceDelete     json.RawMessage `json:"source_delete"`
+		SourceDeleteCond string          `json:"source_delete_condition"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -125,11 +229,26 @@ func (this *Merge) UnmarshalJSON(body []byte) error {
 	}
 
 	this.ref = algebra.NewKeyspaceRef(_unmarshalled.Names, _unmarshalled.Keys, _unmarshalled.As)
+	this.source = _unmarshalled.Source
 
-	if _unmarshalled.Key != "" {
-		this.key, err = parser.Parse(_unmarshalled.Key)
-		if err != nil {
-			return err
+	exprs := []struct {
+		text string
+		expr *expression.Expression
+	}{
+		{_unmarshalled.Key, &this.key},
+		{_unmarshalled.UpdateCond, &this.updateCond},
+		{_unmarshalled.DeleteCond, &this.deleteCond},
+		{_unmarshalled.InsertCond, &this.insertCond},
+		{_unmarshalled.SourceUpdateCond, &this.sourceUpdateCond},
+		{_unmarshalled.SourceDeleteCond, &this.sourceDeleteCond},
+	}
+
+	for _, e := range exprs {
+		if e.text != "" {
+			*e.expr, err = parser.Parse(e.text)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -137,6 +256,8 @@ func (this *Merge) UnmarshalJSON(body []byte) error {
 		_unmarshalled.Update,
 		_unmarshalled.Delete,
 		_unmarshalled.Insert,
+		_unmarshalled.SourceUpdate,
+		_unmarshalled.SourceDelete,
 	}
 
 	for i, child := range ops {
@@ -160,6 +281,10 @@ func (this *Merge) UnmarshalJSON(body []byte) error {
 			this.delete, err = MakeOperator(op_type.Operator, child)
 		case 2:
 			this.insert, err = MakeOperator(op_type.Operator, child)
+		case 3:
+			this.sourceUpdate, err = MakeOperator(op_type.Operator, child)
+		case 4:
+			this.sourceDelete, err = MakeOperator(op_type.Operator, child)
 		}
 
 		if err != nil {
@@ -181,5 +306,11 @@ func (this *Merge) verify(prepared *Prepared) bool {
 	if result && this.update != nil {
 		result = this.update.verify(prepared)
 	}
+	if result && this.sourceUpdate != nil {
+		result = this.sourceUpdate.verify(prepared)
+	}
+	if result && this.sourceDelete != nil {
+		result = this.sourceDelete.verify(prepared)
+	}
 	return result
 }
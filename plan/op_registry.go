@@ -46,6 +46,8 @@ var _OPERATORS = map[string]Operator{
 	"IndexScan":               &IndexScan{},
 	"IndexScan2":              &IndexScan2{},
 	"IndexScan3":              &IndexScan3{},
+	"IndexFtsSearch":          &IndexFtsSearch{},
+	"IndexVectorScan":         &IndexVectorScan{},
 	"KeyScan":                 &KeyScan{},
 	"ValueScan":               &ValueScan{},
 	"DummyScan":               &DummyScan{},
@@ -67,6 +69,7 @@ var _OPERATORS = map[string]Operator{
 	"Join":           &Join{},
 	"IndexJoin":      &IndexJoin{},
 	"NestedLoopJoin": &NLJoin{},
+	"HashJoin":       &HashJoin{},
 	"Nest":           &Nest{},
 	"IndexNest":      &IndexNest{},
 	"NestedLoopNest": &NLNest{},
@@ -140,6 +143,23 @@ var _OPERATORS = map[string]Operator{
 	"AlterIndex":         &AlterIndex{},
 	"BuildIndexes":       &BuildIndexes{},
 
+	// View DDL
+	"CreateView":  &CreateView{},
+	"DropView":    &DropView{},
+	"RefreshView": &RefreshView{},
+
+	// Sequence DDL
+	"CreateSequence": &CreateSequence{},
+	"AlterSequence":  &AlterSequence{},
+	"DropSequence":   &DropSequence{},
+
+	// Keyspace DDL
+	"AlterKeyspace": &AlterKeyspace{},
+
+	// Trigger DDL
+	"CreateTrigger": &CreateTrigger{},
+	"DropTrigger":   &DropTrigger{},
+
 	// Roles
 	"GrantRole":  &GrantRole{},
 	"RevokeRole": &RevokeRole{},
@@ -14,6 +14,7 @@ import (
 
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/expression/parser"
+	"github.com/couchbase/query/value"
 )
 
 type Order struct {
@@ -62,6 +63,17 @@ func (this *Order) MarshalBase(f func(map[string]interface{})) map[string]interf
 			q["desc"] = term.Descending()
 		}
 
+		switch term.Nulls() {
+		case algebra.NULLS_FIRST:
+			q["nulls"] = "first"
+		case algebra.NULLS_LAST:
+			q["nulls"] = "last"
+		}
+
+		if term.Collation() != nil {
+			q["collation"] = term.Collation().Name()
+		}
+
 		s = append(s, q)
 	}
 	r["sort_terms"] = s
@@ -81,8 +93,10 @@ func (this *Order) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
 		_     string `json:"#operator"`
 		Terms []struct {
-			Expr string `json:"expr"`
-			Desc bool   `json:"desc"`
+			Expr      string `json:"expr"`
+			Desc      bool   `json:"desc"`
+			Nulls     string `json:"nulls"`
+			Collation string `json:"collation"`
 		} `json:"sort_terms"`
 		offsetExpr string `json:"offset"`
 		limitExpr  string `json:"limit"`
@@ -99,7 +113,24 @@ func (this *Order) UnmarshalJSON(body []byte) error {
 		if err != nil {
 			return err
 		}
-		this.terms[i] = algebra.NewSortTerm(expr, term.Desc)
+
+		nulls := algebra.NULLS_DEFAULT
+		switch term.Nulls {
+		case "first":
+			nulls = algebra.NULLS_FIRST
+		case "last":
+			nulls = algebra.NULLS_LAST
+		}
+
+		var collation value.Collation
+		if term.Collation != "" {
+			collation, err = value.NewCollation(term.Collation)
+			if err != nil {
+				return err
+			}
+		}
+
+		this.terms[i] = algebra.NewCollatedSortTerm(expr, term.Desc, nulls, collation)
 	}
 	if offsetExprStr := _unmarshalled.offsetExpr; offsetExprStr != "" {
 		offsetExpr, err := parser.Parse(offsetExprStr)
@@ -98,6 +98,16 @@ func (this *InitialProject) MarshalBase(f func(map[string]interface{})) map[stri
 			t["expr"] = expression.NewStringer().Visit(expr)
 		}
 
+		if modifiers := term.Result().Modifiers(); modifiers != nil {
+			if len(modifiers.Exclude()) > 0 {
+				t["exclude"] = modifiers.Exclude()
+			}
+
+			if len(modifiers.Replace()) > 0 {
+				t["replace"] = modifiers.Replace()
+			}
+		}
+
 		s = append(s, t)
 	}
 	r["result_terms"] = s
@@ -111,9 +121,14 @@ func (this *InitialProject) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
 		_     string `json:"#operator"`
 		Terms []*struct {
-			Expr string `json:"expr"`
-			As   string `json:"as"`
-			Star bool   `json:"star"`
+			Expr    string              `json:"expr"`
+			As      string              `json:"as"`
+			Star    bool                `json:"star"`
+			Exclude []algebra.FieldPath `json:"exclude"`
+			Replace []*struct {
+				Expr string            `json:"expr"`
+				Path algebra.FieldPath `json:"path"`
+			} `json:"replace"`
 		} `json:"result_terms"`
 		Distinct bool `json:"distinct"`
 		Raw      bool `json:"raw"`
@@ -133,7 +148,22 @@ func (this *InitialProject) UnmarshalJSON(body []byte) error {
 				return err
 			}
 		}
-		terms[i] = algebra.NewResultTerm(expr, term_data.Star, term_data.As)
+
+		if len(term_data.Exclude) == 0 && len(term_data.Replace) == 0 {
+			terms[i] = algebra.NewResultTerm(expr, term_data.Star, term_data.As)
+			continue
+		}
+
+		replace := make(algebra.StarReplaces, len(term_data.Replace))
+		for j, replace_data := range term_data.Replace {
+			replaceExpr, err := parser.Parse(replace_data.Expr)
+			if err != nil {
+				return err
+			}
+			replace[j] = algebra.NewStarReplace(replaceExpr, replace_data.Path)
+		}
+		terms[i] = algebra.NewStarResultTerm(expr,
+			algebra.NewStarModifiers(term_data.Exclude, replace))
 	}
 	projection := algebra.NewProjection(_unmarshalled.Distinct, terms)
 	projection.SetRaw(_unmarshalled.Raw)
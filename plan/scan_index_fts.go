@@ -0,0 +1,167 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+)
+
+// IndexFtsSearch searches a full text index for the documents that
+// match the query of a SEARCH() predicate.
+type IndexFtsSearch struct {
+	readonly
+	index   datastore.FTSIndex
+	indexer datastore.Indexer
+	term    *algebra.KeyspaceTerm
+	key     int
+	query   expression.Expression
+	options expression.Expression
+}
+
+func NewIndexFtsSearch(index datastore.FTSIndex, term *algebra.KeyspaceTerm, key int,
+	query, options expression.Expression) *IndexFtsSearch {
+	return &IndexFtsSearch{
+		index:   index,
+		indexer: getIndexer(term.Namespace(), term.Keyspace(), index.Type()),
+		term:    term,
+		key:     key,
+		query:   query,
+		options: options,
+	}
+}
+
+func (this *IndexFtsSearch) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexFtsSearch(this)
+}
+
+func (this *IndexFtsSearch) New() Operator {
+	return &IndexFtsSearch{}
+}
+
+func (this *IndexFtsSearch) Index() datastore.FTSIndex {
+	return this.index
+}
+
+func (this *IndexFtsSearch) Term() *algebra.KeyspaceTerm {
+	return this.term
+}
+
+// the position of the searched key in the index keys
+func (this *IndexFtsSearch) Key() int {
+	return this.key
+}
+
+func (this *IndexFtsSearch) Query() expression.Expression {
+	return this.query
+}
+
+func (this *IndexFtsSearch) Options() expression.Expression {
+	return this.options
+}
+
+func (this *IndexFtsSearch) String() string {
+	bytes, _ := this.MarshalJSON()
+	return string(bytes)
+}
+
+func (this *IndexFtsSearch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *IndexFtsSearch) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "IndexFtsSearch"}
+	r["index"] = this.index.Name()
+	r["index_id"] = this.index.Id()
+	r["namespace"] = this.term.Namespace()
+	r["keyspace"] = this.term.Keyspace()
+	r["using"] = this.index.Type()
+	r["key"] = this.key
+	r["query"] = expression.NewStringer().Visit(this.query)
+
+	if this.term.As() != "" {
+		r["as"] = this.term.As()
+	}
+
+	if this.options != nil {
+		r["options"] = expression.NewStringer().Visit(this.options)
+	}
+
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *IndexFtsSearch) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string              `json:"#operator"`
+		Index     string              `json:"index"`
+		IndexId   string              `json:"index_id"`
+		Namespace string              `json:"namespace"`
+		Keyspace  string              `json:"keyspace"`
+		As        string              `json:"as"`
+		Using     datastore.IndexType `json:"using"`
+		Key       int                 `json:"key"`
+		Query     string              `json:"query"`
+		Options   string              `json:"options"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.key = _unmarshalled.Key
+	this.query, err = parser.Parse(_unmarshalled.Query)
+	if err != nil {
+		return err
+	}
+
+	if _unmarshalled.Options != "" {
+		this.options, err = parser.Parse(_unmarshalled.Options)
+		if err != nil {
+			return err
+		}
+	}
+
+	k, err := datastore.GetKeyspace(_unmarshalled.Namespace, _unmarshalled.Keyspace)
+	if err != nil {
+		return err
+	}
+
+	this.term = algebra.NewKeyspaceTerm(_unmarshalled.Namespace, _unmarshalled.Keyspace, _unmarshalled.As, nil, nil)
+	this.indexer, err = k.Indexer(_unmarshalled.Using)
+	if err != nil {
+		return err
+	}
+
+	index, err := this.indexer.IndexById(_unmarshalled.IndexId)
+	if err != nil {
+		return err
+	}
+
+	fts, ok := index.(datastore.FTSIndex)
+	if ok {
+		this.index = fts
+		return nil
+	}
+
+	return fmt.Errorf("Unable to unmarshal %s as full text index.", _unmarshalled.Index)
+}
+
+func (this *IndexFtsSearch) verify(prepared *Prepared) bool {
+	return verifyIndex(this.index, this.indexer, prepared)
+}
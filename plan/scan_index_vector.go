@@ -0,0 +1,164 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+)
+
+// IndexVectorScan finds the documents whose key is nearest to a
+// target in a vector index, for ORDER BY VECTOR_DISTANCE() LIMIT k.
+type IndexVectorScan struct {
+	readonly
+	index   datastore.VectorIndex
+	indexer datastore.Indexer
+	term    *algebra.KeyspaceTerm
+	key     int
+	target  expression.Expression
+	limit   expression.Expression
+}
+
+func NewIndexVectorScan(index datastore.VectorIndex, term *algebra.KeyspaceTerm, key int,
+	target, limit expression.Expression) *IndexVectorScan {
+	return &IndexVectorScan{
+		index:   index,
+		indexer: getIndexer(term.Namespace(), term.Keyspace(), index.Type()),
+		term:    term,
+		key:     key,
+		target:  target,
+		limit:   limit,
+	}
+}
+
+func (this *IndexVectorScan) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexVectorScan(this)
+}
+
+func (this *IndexVectorScan) New() Operator {
+	return &IndexVectorScan{}
+}
+
+func (this *IndexVectorScan) Index() datastore.VectorIndex {
+	return this.index
+}
+
+func (this *IndexVectorScan) Term() *algebra.KeyspaceTerm {
+	return this.term
+}
+
+// the position of the searched key in the index keys
+func (this *IndexVectorScan) Key() int {
+	return this.key
+}
+
+// the vector whose nearest neighbours are found
+func (this *IndexVectorScan) Target() expression.Expression {
+	return this.target
+}
+
+// the number of neighbours
+func (this *IndexVectorScan) Limit() expression.Expression {
+	return this.limit
+}
+
+func (this *IndexVectorScan) String() string {
+	bytes, _ := this.MarshalJSON()
+	return string(bytes)
+}
+
+func (this *IndexVectorScan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *IndexVectorScan) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "IndexVectorScan"}
+	r["index"] = this.index.Name()
+	r["index_id"] = this.index.Id()
+	r["namespace"] = this.term.Namespace()
+	r["keyspace"] = this.term.Keyspace()
+	r["using"] = this.index.Type()
+	r["key"] = this.key
+	r["target"] = expression.NewStringer().Visit(this.target)
+	r["limit"] = expression.NewStringer().Visit(this.limit)
+
+	if this.term.As() != "" {
+		r["as"] = this.term.As()
+	}
+
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *IndexVectorScan) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string              `json:"#operator"`
+		Index     string              `json:"index"`
+		IndexId   string              `json:"index_id"`
+		Namespace string              `json:"namespace"`
+		Keyspace  string              `json:"keyspace"`
+		As        string              `json:"as"`
+		Using     datastore.IndexType `json:"using"`
+		Key       int                 `json:"key"`
+		Target    string              `json:"target"`
+		Limit     string              `json:"limit"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.key = _unmarshalled.Key
+	this.target, err = parser.Parse(_unmarshalled.Target)
+	if err != nil {
+		return err
+	}
+
+	this.limit, err = parser.Parse(_unmarshalled.Limit)
+	if err != nil {
+		return err
+	}
+
+	k, err := datastore.GetKeyspace(_unmarshalled.Namespace, _unmarshalled.Keyspace)
+	if err != nil {
+		return err
+	}
+
+	this.term = algebra.NewKeyspaceTerm(_unmarshalled.Namespace, _unmarshalled.Keyspace, _unmarshalled.As, nil, nil)
+	this.indexer, err = k.Indexer(_unmarshalled.Using)
+	if err != nil {
+		return err
+	}
+
+	index, err := this.indexer.IndexById(_unmarshalled.IndexId)
+	if err != nil {
+		return err
+	}
+
+	vector, ok := index.(datastore.VectorIndex)
+	if ok {
+		this.index = vector
+		return nil
+	}
+
+	return fmt.Errorf("Unable to unmarshal %s as vector index.", _unmarshalled.Index)
+}
+
+func (this *IndexVectorScan) verify(prepared *Prepared) bool {
+	return verifyIndex(this.index, this.indexer, prepared)
+}
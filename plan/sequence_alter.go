@@ -0,0 +1,91 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/value"
+)
+
+// Alter sequence
+type AlterSequence struct {
+	readwrite
+	namespace string
+	name      string
+	options   value.Value
+}
+
+func NewAlterSequence(namespace, name string, options value.Value) *AlterSequence {
+	return &AlterSequence{
+		namespace: namespace,
+		name:      name,
+		options:   options,
+	}
+}
+
+func (this *AlterSequence) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAlterSequence(this)
+}
+
+func (this *AlterSequence) New() Operator {
+	return &AlterSequence{}
+}
+
+func (this *AlterSequence) Namespace() string {
+	return this.namespace
+}
+
+func (this *AlterSequence) Name() string {
+	return this.name
+}
+
+func (this *AlterSequence) Options() value.Value {
+	return this.options
+}
+
+func (this *AlterSequence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *AlterSequence) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "AlterSequence"}
+	r["namespace"] = this.namespace
+	r["name"] = this.name
+	if this.options != nil {
+		r["options"] = this.options
+	}
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *AlterSequence) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string          `json:"#operator"`
+		Namespace string          `json:"namespace"`
+		Name      string          `json:"name"`
+		Options   json.RawMessage `json:"options"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace = _unmarshalled.Namespace
+	this.name = _unmarshalled.Name
+	this.options = nil
+	if len(_unmarshalled.Options) > 0 {
+		this.options = value.NewValue([]byte(_unmarshalled.Options))
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+)
+
+// Drop sequence
+type DropSequence struct {
+	readwrite
+	namespace string
+	name      string
+}
+
+func NewDropSequence(namespace, name string) *DropSequence {
+	return &DropSequence{
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (this *DropSequence) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropSequence(this)
+}
+
+func (this *DropSequence) New() Operator {
+	return &DropSequence{}
+}
+
+func (this *DropSequence) Namespace() string {
+	return this.namespace
+}
+
+func (this *DropSequence) Name() string {
+	return this.name
+}
+
+func (this *DropSequence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *DropSequence) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "DropSequence"}
+	r["namespace"] = this.namespace
+	r["name"] = this.name
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *DropSequence) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string `json:"#operator"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace = _unmarshalled.Namespace
+	this.name = _unmarshalled.Name
+	return nil
+}
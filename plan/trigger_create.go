@@ -0,0 +1,121 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+)
+
+// Create trigger
+type CreateTrigger struct {
+	readwrite
+	keyspace datastore.Keyspace
+	name     string
+	timing   string
+	event    string
+	when     string
+	body     string
+}
+
+func NewCreateTrigger(keyspace datastore.Keyspace, name, timing, event, when, body string) *CreateTrigger {
+	return &CreateTrigger{
+		keyspace: keyspace,
+		name:     name,
+		timing:   timing,
+		event:    event,
+		when:     when,
+		body:     body,
+	}
+}
+
+func (this *CreateTrigger) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateTrigger(this)
+}
+
+func (this *CreateTrigger) New() Operator {
+	return &CreateTrigger{}
+}
+
+func (this *CreateTrigger) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
+func (this *CreateTrigger) Name() string {
+	return this.name
+}
+
+func (this *CreateTrigger) Timing() string {
+	return this.timing
+}
+
+func (this *CreateTrigger) Event() string {
+	return this.event
+}
+
+func (this *CreateTrigger) When() string {
+	return this.when
+}
+
+func (this *CreateTrigger) Body() string {
+	return this.body
+}
+
+func (this *CreateTrigger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *CreateTrigger) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "CreateTrigger"}
+	r["namespace"] = this.keyspace.NamespaceId()
+	r["keyspace"] = this.keyspace.Name()
+	r["name"] = this.name
+	r["timing"] = this.timing
+	r["event"] = this.event
+	if this.when != "" {
+		r["when"] = this.when
+	}
+	r["body"] = this.body
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *CreateTrigger) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string `json:"#operator"`
+		Namespace string `json:"namespace"`
+		Keyspace  string `json:"keyspace"`
+		Name      string `json:"name"`
+		Timing    string `json:"timing"`
+		Event     string `json:"event"`
+		When      string `json:"when"`
+		Body      string `json:"body"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.name = _unmarshalled.Name
+	this.timing = _unmarshalled.Timing
+	this.event = _unmarshalled.Event
+	this.when = _unmarshalled.When
+	this.body = _unmarshalled.Body
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Namespace, _unmarshalled.Keyspace)
+	return err
+}
+
+func (this *CreateTrigger) verify(prepared *Prepared) bool {
+	return verifyKeyspace(this.keyspace, prepared)
+}
@@ -0,0 +1,84 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+)
+
+// Drop trigger
+type DropTrigger struct {
+	readwrite
+	namespace string
+	keyspace  string
+	name      string
+}
+
+func NewDropTrigger(namespace, keyspace, name string) *DropTrigger {
+	return &DropTrigger{
+		namespace: namespace,
+		keyspace:  keyspace,
+		name:      name,
+	}
+}
+
+func (this *DropTrigger) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropTrigger(this)
+}
+
+func (this *DropTrigger) New() Operator {
+	return &DropTrigger{}
+}
+
+func (this *DropTrigger) Namespace() string {
+	return this.namespace
+}
+
+func (this *DropTrigger) Keyspace() string {
+	return this.keyspace
+}
+
+func (this *DropTrigger) Name() string {
+	return this.name
+}
+
+func (this *DropTrigger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *DropTrigger) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "DropTrigger"}
+	r["namespace"] = this.namespace
+	r["keyspace"] = this.keyspace
+	r["name"] = this.name
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *DropTrigger) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string `json:"#operator"`
+		Namespace string `json:"namespace"`
+		Keyspace  string `json:"keyspace"`
+		Name      string `json:"name"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace = _unmarshalled.Namespace
+	this.keyspace = _unmarshalled.Keyspace
+	this.name = _unmarshalled.Name
+	return nil
+}
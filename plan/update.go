@@ -177,16 +177,19 @@ func (this *Unset) UnmarshalJSON(body []byte) error {
 // Send to keyspace
 type SendUpdate struct {
 	readwrite
-	keyspace datastore.Keyspace
-	alias    string
-	limit    expression.Expression
+	keyspace     datastore.Keyspace
+	alias        string
+	limit        expression.Expression
+	distinctKeys bool
 }
 
-func NewSendUpdate(keyspace datastore.Keyspace, alias string, limit expression.Expression) *SendUpdate {
+func NewSendUpdate(keyspace datastore.Keyspace, alias string, limit expression.Expression,
+	distinctKeys bool) *SendUpdate {
 	return &SendUpdate{
-		keyspace: keyspace,
-		alias:    alias,
-		limit:    limit,
+		keyspace:     keyspace,
+		alias:        alias,
+		limit:        limit,
+		distinctKeys: distinctKeys,
 	}
 }
 
@@ -210,6 +213,11 @@ func (this *SendUpdate) Limit() expression.Expression {
 	return this.limit
 }
 
+// Whether each key is mutated only once, when the keys come from a join
+func (this *SendUpdate) DistinctKeys() bool {
+	return this.distinctKeys
+}
+
 func (this *SendUpdate) MarshalJSON() ([]byte, error) {
 	return json.Marshal(this.MarshalBase(nil))
 }
@@ -224,6 +232,10 @@ func (this *SendUpdate) MarshalBase(f func(map[string]interface{})) map[string]i
 		r["limit"] = this.limit
 	}
 
+	if this.distinctKeys {
+		r["distinct_keys"] = this.distinctKeys
+	}
+
 	if f != nil {
 		f(r)
 	}
@@ -232,11 +244,12 @@ func (this *SendUpdate) MarshalBase(f func(map[string]interface{})) map[string]i
 
 func (this *SendUpdate) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
-		_     string `json:"#operator"`
-		Keys  string `json:"keyspace"`
-		Names string `json:"namespace"`
-		Alias string `json:"alias"`
-		Limit string `json:"limit"`
+		_            string `json:"#operator"`
+		Keys         string `json:"keyspace"`
+		Names        string `json:"namespace"`
+		Alias        string `json:"alias"`
+		Limit        string `json:"limit"`
+		DistinctKeys bool   `json:"distinct_keys"`
 	}
 
 	err := json.Unmarshal(body, &_unmarshalled)
@@ -245,6 +258,7 @@ func (this *SendUpdate) UnmarshalJSON(body []byte) error {
 	}
 
 	this.alias = _unmarshalled.Alias
+	this.distinctKeys = _unmarshalled.DistinctKeys
 
 	if _unmarshalled.Limit != "" {
 		this.limit, err = parser.Parse(_unmarshalled.Limit)
@@ -0,0 +1,104 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+)
+
+// Create view
+type CreateView struct {
+	readwrite
+	namespace    string
+	name         string
+	text         string
+	materialized bool
+	incremental  bool
+}
+
+func NewCreateView(namespace, name, text string, materialized, incremental bool) *CreateView {
+	return &CreateView{
+		namespace:    namespace,
+		name:         name,
+		text:         text,
+		materialized: materialized,
+		incremental:  incremental,
+	}
+}
+
+func (this *CreateView) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateView(this)
+}
+
+func (this *CreateView) New() Operator {
+	return &CreateView{}
+}
+
+func (this *CreateView) Namespace() string {
+	return this.namespace
+}
+
+func (this *CreateView) Name() string {
+	return this.name
+}
+
+func (this *CreateView) Text() string {
+	return this.text
+}
+
+func (this *CreateView) Materialized() bool {
+	return this.materialized
+}
+
+func (this *CreateView) Incremental() bool {
+	return this.incremental
+}
+
+func (this *CreateView) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *CreateView) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "CreateView"}
+	r["namespace"] = this.namespace
+	r["name"] = this.name
+	r["definition"] = this.text
+	if this.materialized {
+		r["materialized"] = this.materialized
+		r["incremental"] = this.incremental
+	}
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *CreateView) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_            string `json:"#operator"`
+		Namespace    string `json:"namespace"`
+		Name         string `json:"name"`
+		Text         string `json:"definition"`
+		Materialized bool   `json:"materialized"`
+		Incremental  bool   `json:"incremental"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace = _unmarshalled.Namespace
+	this.name = _unmarshalled.Name
+	this.text = _unmarshalled.Text
+	this.materialized = _unmarshalled.Materialized
+	this.incremental = _unmarshalled.Incremental
+	return nil
+}
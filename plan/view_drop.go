@@ -0,0 +1,86 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+)
+
+// Drop view
+type DropView struct {
+	readwrite
+	namespace    string
+	name         string
+	materialized bool
+}
+
+func NewDropView(namespace, name string, materialized bool) *DropView {
+	return &DropView{
+		namespace:    namespace,
+		name:         name,
+		materialized: materialized,
+	}
+}
+
+func (this *DropView) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropView(this)
+}
+
+func (this *DropView) New() Operator {
+	return &DropView{}
+}
+
+func (this *DropView) Namespace() string {
+	return this.namespace
+}
+
+func (this *DropView) Name() string {
+	return this.name
+}
+
+func (this *DropView) Materialized() bool {
+	return this.materialized
+}
+
+func (this *DropView) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *DropView) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "DropView"}
+	r["namespace"] = this.namespace
+	r["name"] = this.name
+	if this.materialized {
+		r["materialized"] = this.materialized
+	}
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *DropView) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_            string `json:"#operator"`
+		Namespace    string `json:"namespace"`
+		Name         string `json:"name"`
+		Materialized bool   `json:"materialized"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace = _unmarshalled.Namespace
+	this.name = _unmarshalled.Name
+	this.materialized = _unmarshalled.Materialized
+	return nil
+}
@@ -0,0 +1,85 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+)
+
+// Refresh materialized view
+type RefreshView struct {
+	readwrite
+	keyspace datastore.Keyspace
+	mode     string
+}
+
+func NewRefreshView(keyspace datastore.Keyspace, mode string) *RefreshView {
+	return &RefreshView{
+		keyspace: keyspace,
+		mode:     mode,
+	}
+}
+
+func (this *RefreshView) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitRefreshView(this)
+}
+
+func (this *RefreshView) New() Operator {
+	return &RefreshView{}
+}
+
+func (this *RefreshView) Keyspace() datastore.Keyspace {
+	return this.keyspace
+}
+
+func (this *RefreshView) Mode() string {
+	return this.mode
+}
+
+func (this *RefreshView) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *RefreshView) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "RefreshView"}
+	r["namespace"] = this.keyspace.NamespaceId()
+	r["keyspace"] = this.keyspace.Name()
+	if this.mode != "" {
+		r["mode"] = this.mode
+	}
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *RefreshView) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string `json:"#operator"`
+		Namespace string `json:"namespace"`
+		Keyspace  string `json:"keyspace"`
+		Mode      string `json:"mode"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.mode = _unmarshalled.Mode
+	this.keyspace, err = datastore.GetKeyspace(_unmarshalled.Namespace, _unmarshalled.Keyspace)
+	return err
+}
+
+func (this *RefreshView) verify(prepared *Prepared) bool {
+	return verifyKeyspace(this.keyspace, prepared)
+}
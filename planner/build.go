@@ -93,6 +93,11 @@ type builder struct {
 	baseKeyspaces     map[string]*baseKeyspace
 	pushableOnclause  expression.Expression // combined ON-clause from all inner joins
 	builderFlags      uint32
+	indexAll          bool                              // Used for the INDEX_ALL hint
+	optimHints        *algebra.OptimHints               // Optimizer hints of the current query block
+	hintAliases       map[string]bool                   // Keyspaces of the current query block
+	hintBlocks        []*algebra.OptimHints             // Optimizer hints of all the query blocks
+	hintStates        map[*algebra.OptimHint]*hintState // Whether each hint was followed
 }
 
 type indexPushDowns struct {
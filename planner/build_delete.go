@@ -12,12 +12,13 @@ package planner
 import (
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/triggers"
 )
 
 func (this *builder) VisitDelete(stmt *algebra.Delete) (interface{}, error) {
-	this.cover = stmt
 	this.node = stmt
 	this.where = stmt.Where()
+	defer this.restoreOptimHints(this.setOptimHints(stmt.OptimHints()))
 
 	ksref := stmt.KeyspaceRef()
 	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
@@ -25,7 +26,19 @@ func (this *builder) VisitDelete(stmt *algebra.Delete) (interface{}, error) {
 		return nil, err
 	}
 
-	err = this.beginMutate(keyspace, ksref, stmt.Keys(), stmt.Indexes(), stmt.Limit(), stmt.Returning() != nil)
+	// DELETE triggers see the whole document as OLD, so it is fetched,
+	// as for RETURNING, rather than covered
+	fire := triggers.KeyspaceTriggers(keyspace.NamespaceId(), keyspace.Name(), triggers.BEFORE, triggers.DELETE) != nil ||
+		triggers.KeyspaceTriggers(keyspace.NamespaceId(), keyspace.Name(), triggers.AFTER, triggers.DELETE) != nil
+	if stmt.Using() == nil && !fire {
+		this.cover = stmt
+	}
+
+	if stmt.Using() != nil {
+		err = this.beginMutateFrom(ksref, stmt.Using(), stmt.Indexes(), stmt.Where())
+	} else {
+		err = this.beginMutate(keyspace, ksref, stmt.Keys(), stmt.Indexes(), stmt.Limit(), stmt.Returning() != nil || fire)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -33,13 +46,19 @@ func (this *builder) VisitDelete(stmt *algebra.Delete) (interface{}, error) {
 	subChildren := this.subChildren
 	deleteSubChildren := make([]plan.Operator, 0, 4)
 
-	deleteSubChildren = append(deleteSubChildren, plan.NewSendDelete(keyspace, ksref.Alias(), stmt.Limit()))
+	deleteSubChildren = append(deleteSubChildren, plan.NewSendDelete(keyspace, ksref.Alias(), stmt.Limit(),
+		stmt.Using() != nil))
 
 	if stmt.Returning() != nil {
 		deleteSubChildren = append(deleteSubChildren, plan.NewInitialProject(stmt.Returning()), plan.NewFinalProject())
 	}
 
-	if stmt.Limit() != nil {
+	if stmt.Limit() != nil && stmt.Using() != nil {
+		// a document joined to several objects counts once towards the LIMIT,
+		// which only a serial SendDelete can enforce
+		this.children = append(this.children, plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism))
+		this.children = append(this.children, plan.NewSequence(deleteSubChildren...))
+	} else if stmt.Limit() != nil {
 		seqChildren := make([]plan.Operator, 0, 3)
 		if len(subChildren) > 0 {
 			seqChildren = append(seqChildren, plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism))
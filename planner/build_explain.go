@@ -20,5 +20,10 @@ func (this *builder) VisitExplain(stmt *algebra.Explain) (interface{}, error) {
 		return nil, err
 	}
 
-	return plan.NewExplain(op.(plan.Operator), stmt.Text()), nil
+	if stmt.Analyze() {
+		return plan.NewExplainAnalyze(op.(plan.Operator), stmt.Text(), stmt.Results(),
+			this.optimHintsReport()), nil
+	}
+
+	return plan.NewExplain(op.(plan.Operator), stmt.Text(), this.optimHintsReport()), nil
 }
@@ -0,0 +1,80 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+)
+
+/*
+Maps the GROUP BY expressions in the expressions evaluated after
+grouping sets to covers, which the group operators set to the
+values of the grouping set of each group, or to NULL for the
+expressions that are not in the grouping set.
+*/
+type GroupingCoverer struct {
+	expression.MapperBase
+
+	keys expression.Expressions
+}
+
+func NewGroupingCoverer(keys expression.Expressions) *GroupingCoverer {
+	rv := &GroupingCoverer{
+		keys: keys,
+	}
+
+	rv.SetMapper(rv)
+	rv.SetMapFunc(func(expr expression.Expression) (expression.Expression, error) {
+		for _, key := range keys {
+			if expr.EquivalentTo(key) {
+				return expression.NewCover(key), nil
+			}
+		}
+
+		switch expr.(type) {
+		case *expression.Cover, algebra.Aggregate, *expression.Grouping:
+			return expr, nil
+		}
+
+		return expr, expr.MapChildren(rv)
+	})
+
+	return rv
+}
+
+func (this *GroupingCoverer) VisitNamedParameter(expr expression.NamedParameter) (interface{}, error) {
+	return expr, nil
+}
+
+func (this *GroupingCoverer) VisitPositionalParameter(expr expression.PositionalParameter) (interface{}, error) {
+	return expr, nil
+}
+
+func (this *builder) coverGroupingSets(node *algebra.Subselect, group *algebra.Group) error {
+	coverer := NewGroupingCoverer(group.By())
+
+	err := node.Projection().MapExpressions(coverer)
+	if err != nil {
+		return err
+	}
+
+	err = group.MapGroupedExpressions(coverer)
+	if err != nil {
+		return err
+	}
+
+	// Only the ORDER BY of this subselect, and not of a set operation
+	if sel, ok := this.cover.(*algebra.Select); ok && sel.Subresult() == node && sel.Order() != nil {
+		return sel.Order().MapExpressions(coverer)
+	}
+
+	return nil
+}
@@ -19,16 +19,60 @@ import (
 )
 
 func (this *builder) buildAnsiJoin(node *algebra.AnsiJoin) (op plan.Operator, err error) {
+	useNL := this.optimHint(algebra.HINT_USE_NL, node.Alias())
+	useHash := this.optimHint(algebra.HINT_USE_HASH, node.Alias())
+
+	if node.RightOuter() {
+		this.hintNotFollowed(useNL, fmt.Sprintf("RIGHT OUTER JOIN on %s requires a hash join.", node.Alias()))
+		this.hintFollowed(useHash)
+		return this.buildAnsiHashJoin(node)
+	}
+
 	right := node.Right()
 
+	if term, ok := right.(*algebra.ExpressionTerm); ok && term.IsKeyspace() {
+		right = term.KeyspaceTerm()
+	}
+
+	if useHash != nil {
+		hashable, err := this.hasHashJoinKeys(node)
+		if err != nil {
+			return nil, err
+		}
+
+		if hashable {
+			this.hintFollowed(useHash)
+			if term, ok := right.(*algebra.KeyspaceTerm); ok {
+				term.SetProperty(term.Property() &^ (algebra.KS_ANSI_JOIN | algebra.KS_UNDER_NL))
+			}
+			return this.buildAnsiHashJoin(node)
+		}
+
+		this.hintNotFollowed(useHash, fmt.Sprintf("No equality predicate in the ON clause on %s for a hash join.",
+			node.Alias()))
+	}
+
 	switch right := right.(type) {
 	case *algebra.KeyspaceTerm:
 		right.SetUnderNL()
 		scans, primaryJoinKeys, newOnclause, err := this.buildAnsiJoinScan(right, node.Onclause(), node.Outer())
 		if err != nil {
+
+			// no index to look up the right-hand side of an outer join with
+			// (including RIGHT OUTER JOINs rewritten as LEFT OUTER JOINs),
+			// or the target of UPDATE ... FROM or DELETE ... USING:
+			// scan and hash it instead
+			if e, ok := err.(errors.Error); ok && e.Code() == errors.NO_ANSI_JOIN &&
+				(node.Outer() || right.IsMutateTarget()) {
+				right.SetProperty(right.Property() &^ (algebra.KS_ANSI_JOIN | algebra.KS_UNDER_NL))
+				this.hintNotFollowed(useNL, fmt.Sprintf("No index on %s for a nested-loop join.", node.Alias()))
+				return this.buildAnsiHashJoin(node)
+			}
 			return nil, err
 		}
 
+		this.hintFollowed(useNL)
+
 		if newOnclause != nil {
 			node.SetOnclause(newOnclause)
 		}
@@ -55,14 +99,29 @@ func (this *builder) buildAnsiJoin(node *algebra.AnsiJoin) (op plan.Operator, er
 		newKeyspaceTerm := algebra.NewKeyspaceTerm(right.Namespace(), right.Keyspace(), right.As(), primaryJoinKeys, right.Indexes())
 		newKeyspaceTerm.SetProperty(right.Property())
 		return plan.NewJoinFromAnsi(keyspace, newKeyspaceTerm, node.Outer()), nil
+	case *algebra.ExpressionTerm, *algebra.SubqueryTerm:
+		scan, err := this.buildAnsiJoinExpressionScan(right)
+		if err != nil {
+			return nil, err
+		}
+		this.hintFollowed(useNL)
+		return plan.NewNLJoin(node, plan.NewSequence(scan)), nil
 	default:
-		return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiJoin: ANSI JOIN on %s must be a keyspace", node.Alias()))
+		return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiJoin: unexpected right-hand side of ANSI JOIN on %s", node.Alias()))
 	}
 }
 
 func (this *builder) buildAnsiNest(node *algebra.AnsiNest) (op plan.Operator, err error) {
+	this.hintFollowed(this.optimHint(algebra.HINT_USE_NL, node.Alias()))
+	this.hintNotFollowed(this.optimHint(algebra.HINT_USE_HASH, node.Alias()),
+		fmt.Sprintf("NEST on %s is always a nested-loop nest.", node.Alias()))
+
 	right := node.Right()
 
+	if term, ok := right.(*algebra.ExpressionTerm); ok && term.IsKeyspace() {
+		right = term.KeyspaceTerm()
+	}
+
 	switch right := right.(type) {
 	case *algebra.KeyspaceTerm:
 		right.SetUnderNL()
@@ -97,9 +156,242 @@ func (this *builder) buildAnsiNest(node *algebra.AnsiNest) (op plan.Operator, er
 		newKeyspaceTerm := algebra.NewKeyspaceTerm(right.Namespace(), right.Keyspace(), right.As(), primaryJoinKeys, right.Indexes())
 		newKeyspaceTerm.SetProperty(right.Property())
 		return plan.NewNestFromAnsi(keyspace, newKeyspaceTerm, node.Outer()), nil
+	case *algebra.ExpressionTerm, *algebra.SubqueryTerm:
+		scan, err := this.buildAnsiJoinExpressionScan(right)
+		if err != nil {
+			return nil, err
+		}
+		return plan.NewNLNest(node, plan.NewSequence(scan)), nil
+	default:
+		return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiNest: unexpected right-hand side of ANSI NEST on %s", node.Alias()))
+	}
+}
+
+// Subqueries and expressions on the right-hand side of an ANSI JOIN or NEST
+// have no index to drive a scan: the inner side is produced by an
+// ExpressionScan, materialized once per request unless it refers to terms
+// to its left, and joined through a nested loop.
+func (this *builder) buildAnsiJoinExpressionScan(right algebra.FromTerm) (plan.Operator, error) {
+	var expr expression.Expression
+
+	switch right := right.(type) {
+	case *algebra.SubqueryTerm:
+		expr = algebra.NewSubquery(right.Subquery())
+	case *algebra.ExpressionTerm:
+		expr = right.ExpressionTerm()
+	default:
+		return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiJoinExpressionScan: unexpected term %s", right.Alias()))
+	}
+
+	keyspaceNames := make(map[string]bool, len(this.baseKeyspaces))
+	for name, _ := range this.baseKeyspaces {
+		if name != right.Alias() {
+			keyspaceNames[name] = true
+		}
+	}
+	keyspaces, err := expression.CountKeySpaces(expr, keyspaceNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewExpressionScan(expr, right.Alias(), len(keyspaces) > 0), nil
+}
+
+// The unmatched objects on the right-hand side of RIGHT and FULL OUTER JOINs
+// must be produced even if no left-hand side object refers to them, so the
+// right-hand side is scanned on its own, and hashed on the equality
+// predicates in the ON-clause, rather than probed once per left-hand side object.
+// The same applies to LEFT OUTER JOINs when no index can be used for the probe.
+func (this *builder) buildAnsiHashJoin(node *algebra.AnsiJoin) (op plan.Operator, err error) {
+	right := node.Right()
+	if term, ok := right.(*algebra.ExpressionTerm); ok && term.IsKeyspace() {
+		right = term.KeyspaceTerm()
+	}
+
+	children := this.children
+	subChildren := this.subChildren
+	coveringScans := this.coveringScans
+	countScan := this.countScan
+	order := this.order
+	orderScan := this.orderScan
+	limit := this.limit
+	offset := this.offset
+	maxParallelism := this.maxParallelism
+	defer func() {
+		this.children = children
+		this.subChildren = subChildren
+		this.countScan = countScan
+		this.order = order
+		this.orderScan = orderScan
+		this.limit = limit
+		this.offset = offset
+		this.maxParallelism = maxParallelism
+
+		if len(this.coveringScans) > 0 {
+			this.coveringScans = append(coveringScans, this.coveringScans...)
+		} else {
+			this.coveringScans = coveringScans
+		}
+	}()
+
+	this.children = make([]plan.Operator, 0, 16)
+	this.subChildren = make([]plan.Operator, 0, 16)
+	this.coveringScans = nil
+	this.countScan = nil
+	this.order = nil
+	this.orderScan = nil
+	this.limit = nil
+	this.offset = nil
+
+	keyspaceNames := make(map[string]bool, len(this.baseKeyspaces))
+	for name, _ := range this.baseKeyspaces {
+		keyspaceNames[name] = true
+	}
+
+	switch right := right.(type) {
+	case *algebra.KeyspaceTerm:
+
+		// only filters on the right-hand side alone can be applied to the scan
+		baseKeyspace, ok := this.baseKeyspaces[right.Alias()]
+		if !ok {
+			return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiHashJoin: missing baseKeyspace %s", right.Alias()))
+		}
+		filters := baseKeyspace.filters
+		defer func() { baseKeyspace.filters = filters }()
+		baseKeyspace.filters = make(Filters, 0, len(filters))
+		for _, fl := range filters {
+			if !fl.isJoin() && !fl.isOnclause() {
+				baseKeyspace.filters = append(baseKeyspace.filters, fl)
+			}
+		}
+	case *algebra.ExpressionTerm:
+		keyspaces, err := expression.CountKeySpaces(right.ExpressionTerm(), keyspaceNames)
+		if err != nil {
+			return nil, err
+		}
+		if len(keyspaces) > 0 {
+			return nil, errors.NewOuterJoinCorrelatedError(right.Alias())
+		}
+	case *algebra.SubqueryTerm:
 	default:
-		return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiNest: ANSI NEST on %s must be a keyspace", node.Alias()))
+		return nil, errors.NewPlanInternalError(fmt.Sprintf("buildAnsiHashJoin: unexpected right-hand side of ANSI JOIN on %s", node.Alias()))
+	}
+
+	_, err = right.Accept(this)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(this.subChildren) > 0 {
+		this.children = append(this.children,
+			plan.NewParallel(plan.NewSequence(this.subChildren...), this.maxParallelism))
+	}
+
+	// equality predicates between the two sides are the hash keys
+	var buildExprs, probeExprs expression.Expressions
+
+	for _, term := range conjuncts(node.Onclause()) {
+		// values are hashed by the default collation
+		eq, ok := term.(*expression.Eq)
+		if !ok || expression.OperandCollation(eq) != nil {
+			continue
+		}
+
+		first, err := expression.CountKeySpaces(eq.First(), keyspaceNames)
+		if err != nil {
+			return nil, err
+		}
+		second, err := expression.CountKeySpaces(eq.Second(), keyspaceNames)
+		if err != nil {
+			return nil, err
+		}
+
+		if isOnlyKeyspace(first, right.Alias()) && len(second) > 0 && !second[right.Alias()] {
+			buildExprs = append(buildExprs, eq.First())
+			probeExprs = append(probeExprs, eq.Second())
+		} else if isOnlyKeyspace(second, right.Alias()) && len(first) > 0 && !first[right.Alias()] {
+			buildExprs = append(buildExprs, eq.Second())
+			probeExprs = append(probeExprs, eq.First())
+		}
+	}
+
+	// both sides may be covered
+	onclause := node.Onclause()
+	for _, ops := range [][]plan.CoveringOperator{coveringScans, this.coveringScans} {
+		for _, op := range ops {
+			coverer := expression.NewCoverer(op.Covers(), op.FilterCovers())
+
+			onclause, err = coverer.Map(onclause)
+			if err != nil {
+				return nil, err
+			}
+
+			err = buildExprs.MapExpressions(coverer)
+			if err != nil {
+				return nil, err
+			}
+
+			err = probeExprs.MapExpressions(coverer)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	node.SetOnclause(onclause)
+
+	return plan.NewHashJoin(node, buildExprs, probeExprs, plan.NewSequence(this.children...)), nil
+}
+
+// USE_HASH is only followed when the ON-clause has an equality
+// predicate between the right-hand side and the terms to its left.
+func (this *builder) hasHashJoinKeys(node *algebra.AnsiJoin) (bool, error) {
+	keyspaceNames := make(map[string]bool, len(this.baseKeyspaces))
+	for name, _ := range this.baseKeyspaces {
+		keyspaceNames[name] = true
+	}
+
+	alias := node.Alias()
+	for _, term := range conjuncts(node.Onclause()) {
+		// values are hashed by the default collation
+		eq, ok := term.(*expression.Eq)
+		if !ok || expression.OperandCollation(eq) != nil {
+			continue
+		}
+
+		first, err := expression.CountKeySpaces(eq.First(), keyspaceNames)
+		if err != nil {
+			return false, err
+		}
+		second, err := expression.CountKeySpaces(eq.Second(), keyspaceNames)
+		if err != nil {
+			return false, err
+		}
+
+		if (isOnlyKeyspace(first, alias) && len(second) > 0 && !second[alias]) ||
+			(isOnlyKeyspace(second, alias) && len(first) > 0 && !first[alias]) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func isOnlyKeyspace(keyspaces map[string]bool, alias string) bool {
+	return len(keyspaces) == 1 && keyspaces[alias]
+}
+
+// the terms of an AND, or the expression itself
+func conjuncts(expr expression.Expression) expression.Expressions {
+	and, ok := expr.(*expression.And)
+	if !ok {
+		return expression.Expressions{expr}
+	}
+
+	var rv expression.Expressions
+	for _, op := range and.Operands() {
+		rv = append(rv, conjuncts(op)...)
 	}
+	return rv
 }
 
 func (this *builder) buildAnsiJoinScan(node *algebra.KeyspaceTerm, onclause expression.Expression, outer bool) (
@@ -176,7 +468,7 @@ func (this *builder) buildAnsiJoinScan(node *algebra.KeyspaceTerm, onclause expr
 
 	for _, fltr := range baseKeyspace.filters {
 		if fltr.isOnclause() {
-			if eqFltr, ok := fltr.fltrExpr.(*expression.Eq); ok {
+			if eqFltr, ok := fltr.fltrExpr.(*expression.Eq); ok && expression.OperandCollation(eqFltr) == nil {
 				if eqFltr.First().EquivalentTo(id) {
 					node.SetPrimaryJoin()
 					primaryJoinKeys = eqFltr.Second()
@@ -13,6 +13,8 @@ import (
 	"fmt"
 
 	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/plan"
 )
 
@@ -20,87 +22,72 @@ func (this *builder) VisitMerge(stmt *algebra.Merge) (interface{}, error) {
 	children := make([]plan.Operator, 0, 8)
 	subChildren := make([]plan.Operator, 0, 8)
 	source := stmt.Source()
+	defer this.restoreOptimHints(this.setOptimHints(stmt.OptimHints()))
 
-	this.baseKeyspaces = make(map[string]*baseKeyspace, _MAP_KEYSPACE_CAP)
-	sourceKeyspace := newBaseKeyspace(source.Alias())
-	this.baseKeyspaces[sourceKeyspace.name] = sourceKeyspace
+	ksref := stmt.KeyspaceRef()
+	ksref.SetDefaultNamespace(this.namespace)
 
-	if source.Select() != nil {
-		sel, err := source.Select().Accept(this)
+	if stmt.On() != nil {
+		err := this.buildMergeJoin(stmt)
 		if err != nil {
 			return nil, err
 		}
 
-		children = append(children, sel.(plan.Operator))
-	} else if source.ExpressionTerm() != nil {
-		_, err := source.ExpressionTerm().Accept(this)
-		if err != nil {
-			return nil, err
-		}
 		children = append(children, this.children...)
 		subChildren = append(subChildren, this.subChildren...)
 	} else {
-		if source.From() == nil {
-			return nil, fmt.Errorf("MERGE missing source.")
+		this.baseKeyspaces = make(map[string]*baseKeyspace, _MAP_KEYSPACE_CAP)
+		sourceKeyspace := newBaseKeyspace(source.Alias())
+		this.baseKeyspaces[sourceKeyspace.name] = sourceKeyspace
+
+		if source.Select() != nil {
+			sel, err := source.Select().Accept(this)
+			if err != nil {
+				return nil, err
+			}
+
+			children = append(children, sel.(plan.Operator))
+		} else if source.ExpressionTerm() != nil {
+			_, err := source.ExpressionTerm().Accept(this)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, this.children...)
+			subChildren = append(subChildren, this.subChildren...)
+		} else {
+			if source.From() == nil {
+				return nil, fmt.Errorf("MERGE missing source.")
+			}
+
+			_, err := source.From().Accept(this)
+			if err != nil {
+				return nil, err
+			}
+
+			// Update local operator slices with results of building From:
+			children = append(children, this.children...)
+			subChildren = append(subChildren, this.subChildren...)
 		}
 
-		_, err := source.From().Accept(this)
-		if err != nil {
-			return nil, err
+		if source.As() != "" {
+			subChildren = append(subChildren, plan.NewAlias(source.As()))
 		}
-
-		// Update local operator slices with results of building From:
-		children = append(children, this.children...)
-		subChildren = append(subChildren, this.subChildren...)
-	}
-
-	if source.As() != "" {
-		subChildren = append(subChildren, plan.NewAlias(source.As()))
 	}
 
-	ksref := stmt.KeyspaceRef()
-	ksref.SetDefaultNamespace(this.namespace)
-
 	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
 	if err != nil {
 		return nil, err
 	}
 
 	actions := stmt.Actions()
-	var update, delete, insert plan.Operator
+	var update, delete, insert, sourceUpdate, sourceDelete plan.Operator
 
 	if actions.Update() != nil {
-		act := actions.Update()
-		ops := make([]plan.Operator, 0, 5)
-
-		if act.Where() != nil {
-			ops = append(ops, plan.NewFilter(act.Where()))
-		}
-
-		ops = append(ops, plan.NewClone(ksref.Alias()))
-
-		if act.Set() != nil {
-			ops = append(ops, plan.NewSet(act.Set()))
-		}
-
-		if act.Unset() != nil {
-			ops = append(ops, plan.NewUnset(act.Unset()))
-		}
-
-		ops = append(ops, plan.NewSendUpdate(keyspace, ksref.Alias(), stmt.Limit()))
-		update = plan.NewSequence(ops...)
+		update = buildMergeUpdate(actions.Update(), keyspace, ksref, stmt.Limit())
 	}
 
 	if actions.Delete() != nil {
-		act := actions.Delete()
-		ops := make([]plan.Operator, 0, 4)
-
-		if act.Where() != nil {
-			ops = append(ops, plan.NewFilter(act.Where()))
-		}
-
-		ops = append(ops, plan.NewSendDelete(keyspace, ksref.Alias(), stmt.Limit()))
-		delete = plan.NewSequence(ops...)
+		delete = buildMergeDelete(actions.Delete(), keyspace, ksref, stmt.Limit())
 	}
 
 	if actions.Insert() != nil {
@@ -111,19 +98,44 @@ func (this *builder) VisitMerge(stmt *algebra.Merge) (interface{}, error) {
 			ops = append(ops, plan.NewFilter(act.Where()))
 		}
 
-		ops = append(ops, plan.NewSendInsert(keyspace, ksref.Alias(), stmt.Key(), act.Value(), stmt.Limit()))
+		key := stmt.Key()
+		if key == nil {
+			key = act.Key()
+		}
+
+		ops = append(ops, plan.NewSendInsert(keyspace, ksref.Alias(), key, act.Value(), stmt.Limit()))
 		insert = plan.NewSequence(ops...)
 	}
 
-	merge := plan.NewMerge(keyspace, ksref, stmt.Key(), update, delete, insert)
-	subChildren = append(subChildren, merge)
+	if actions.SourceUpdate() != nil {
+		sourceUpdate = buildMergeUpdate(actions.SourceUpdate(), keyspace, ksref, stmt.Limit())
+	}
+
+	if actions.SourceDelete() != nil {
+		sourceDelete = buildMergeDelete(actions.SourceDelete(), keyspace, ksref, stmt.Limit())
+	}
+
+	merge := plan.NewMerge(keyspace, ksref, source.Alias(), stmt.Key(), actions,
+		update, delete, insert, sourceUpdate, sourceDelete)
+
+	mergeChildren := make([]plan.Operator, 0, 3)
+	mergeChildren = append(mergeChildren, merge)
 
 	if stmt.Returning() != nil {
-		subChildren = append(subChildren, plan.NewInitialProject(stmt.Returning()), plan.NewFinalProject())
+		mergeChildren = append(mergeChildren, plan.NewInitialProject(stmt.Returning()), plan.NewFinalProject())
 	}
 
-	parallel := plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism)
-	children = append(children, parallel)
+	if stmt.On() != nil {
+		// a single MERGE sees every match, to detect a target document matched twice
+		if len(subChildren) > 0 {
+			children = append(children, plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism))
+		}
+		children = append(children, mergeChildren...)
+	} else {
+		subChildren = append(subChildren, mergeChildren...)
+		parallel := plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism)
+		children = append(children, parallel)
+	}
 
 	if stmt.Limit() != nil {
 		children = append(children, plan.NewLimit(stmt.Limit()))
@@ -135,3 +147,86 @@ func (this *builder) VisitMerge(stmt *algebra.Merge) (interface{}, error) {
 
 	return plan.NewSequence(children...), nil
 }
+
+/*
+The target documents of a MERGE with an ON clause are found by an
+ANSI JOIN of the source to the target keyspace, which selects indexes
+on the target like any other join. The join is outer so that unmatched
+source objects reach the INSERT, and full outer when the target documents
+that are not matched by the source are updated or deleted.
+*/
+func (this *builder) buildMergeJoin(stmt *algebra.Merge) error {
+	source := stmt.Source()
+
+	var left algebra.FromTerm
+	if source.Select() != nil {
+		left = algebra.NewSubqueryTerm(source.Select(), source.Alias())
+	} else if source.ExpressionTerm() != nil {
+		left = source.ExpressionTerm()
+	} else if source.From() != nil {
+		left = source.From()
+	} else {
+		return fmt.Errorf("MERGE missing source.")
+	}
+
+	ksref := stmt.KeyspaceRef()
+	right := algebra.NewKeyspaceTerm(ksref.Namespace(), ksref.Keyspace(), ksref.As(), nil, nil)
+	join := algebra.NewAnsiJoin(left, true, right, stmt.On())
+
+	actions := stmt.Actions()
+	if actions.SourceUpdate() != nil || actions.SourceDelete() != nil {
+		join.SetRightOuter()
+	} else {
+		right.SetAnsiJoin()
+	}
+
+	prevFrom := this.from
+	this.from = join
+	defer func() { this.from = prevFrom }()
+
+	this.baseKeyspaces = make(map[string]*baseKeyspace, _MAP_KEYSPACE_CAP)
+	keyspaceFinder := newKeyspaceFinder(this.baseKeyspaces)
+	_, err := join.Accept(keyspaceFinder)
+	if err != nil {
+		return err
+	}
+
+	this.children = make([]plan.Operator, 0, 16)
+	this.subChildren = make([]plan.Operator, 0, 16)
+	_, err = join.Accept(this)
+	return err
+}
+
+func buildMergeUpdate(act *algebra.MergeUpdate, keyspace datastore.Keyspace,
+	ksref *algebra.KeyspaceRef, limit expression.Expression) plan.Operator {
+	ops := make([]plan.Operator, 0, 5)
+
+	if act.Where() != nil {
+		ops = append(ops, plan.NewFilter(act.Where()))
+	}
+
+	ops = append(ops, plan.NewClone(ksref.Alias()))
+
+	if act.Set() != nil {
+		ops = append(ops, plan.NewSet(act.Set()))
+	}
+
+	if act.Unset() != nil {
+		ops = append(ops, plan.NewUnset(act.Unset()))
+	}
+
+	ops = append(ops, plan.NewSendUpdate(keyspace, ksref.Alias(), limit, false))
+	return plan.NewSequence(ops...)
+}
+
+func buildMergeDelete(act *algebra.MergeDelete, keyspace datastore.Keyspace,
+	ksref *algebra.KeyspaceRef, limit expression.Expression) plan.Operator {
+	ops := make([]plan.Operator, 0, 4)
+
+	if act.Where() != nil {
+		ops = append(ops, plan.NewFilter(act.Where()))
+	}
+
+	ops = append(ops, plan.NewSendDelete(keyspace, ksref.Alias(), limit, false))
+	return plan.NewSequence(ops...)
+}
@@ -84,6 +84,74 @@ func (this *builder) beginMutate(keyspace datastore.Keyspace, ksref *algebra.Key
 	return nil
 }
 
+// UPDATE ... FROM and DELETE ... USING find the target documents by joining
+// the target keyspace, on the WHERE clause, to the right of the FROM or USING
+// term, which is planned like the FROM clause of a SELECT.
+func (this *builder) beginMutateFrom(ksref *algebra.KeyspaceRef, from algebra.FromTerm,
+	indexes algebra.IndexRefs, where expression.Expression) error {
+	ksref.SetDefaultNamespace(this.namespace)
+	right := algebra.NewKeyspaceTerm(ksref.Namespace(), ksref.Keyspace(), ksref.As(), nil, indexes)
+	right.SetAnsiJoin()
+	right.SetMutateTarget()
+
+	onclause := where
+	if onclause == nil {
+		onclause = expression.TRUE_EXPR
+	}
+	join := algebra.NewAnsiJoin(from, false, right, onclause)
+
+	this.children = make([]plan.Operator, 0, 16)
+	this.subChildren = make([]plan.Operator, 0, 16)
+
+	prevFrom := this.from
+	prevWhere := this.where
+	prevLimit := this.limit
+	prevOffset := this.offset
+	prevBasekeyspaces := this.baseKeyspaces
+	prevPushableOnclause := this.pushableOnclause
+
+	defer func() {
+		this.from = prevFrom
+		this.where = prevWhere
+		this.limit = prevLimit
+		this.offset = prevOffset
+		this.baseKeyspaces = prevBasekeyspaces
+		this.pushableOnclause = prevPushableOnclause
+	}()
+
+	// the WHERE clause is planned as the ON clause of the join
+	this.from = join
+	this.where = nil
+	this.limit = nil
+	this.offset = nil
+
+	this.baseKeyspaces = make(map[string]*baseKeyspace, _MAP_KEYSPACE_CAP)
+	keyspaceFinder := newKeyspaceFinder(this.baseKeyspaces)
+	_, err := join.Accept(keyspaceFinder)
+	if err != nil {
+		return err
+	}
+	this.pushableOnclause = keyspaceFinder.pushableOnclause
+
+	if this.pushableOnclause != nil {
+		err = this.processPredicate(this.pushableOnclause, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = join.Accept(this)
+	if err != nil {
+		return err
+	}
+
+	if where != nil {
+		this.subChildren = append(this.subChildren, plan.NewFilter(where))
+	}
+
+	return nil
+}
+
 func isKeyScan(scan plan.Operator) bool {
 	_, rv := scan.(*plan.KeyScan)
 	return rv
@@ -32,6 +32,12 @@ func (this *builder) selectScan(keyspace datastore.Keyspace, node *algebra.Keysp
 			this.maxParallelism = 1
 		}
 
+		for _, name := range []string{algebra.HINT_INDEX, algebra.HINT_INDEX_ALL} {
+			this.hintNotFollowed(this.optimHint(name, node.Alias()),
+				fmt.Sprintf("USE KEYS is specified for keyspace %s.", node.Alias()))
+		}
+		this.hintFollowed(this.optimHint(algebra.HINT_NO_INDEX, node.Alias()))
+
 		return plan.NewKeyScan(keys), nil
 	}
 
@@ -64,11 +70,33 @@ func (this *builder) buildScan(keyspace datastore.Keyspace, node *algebra.Keyspa
 		}
 	}
 
+	indexHint, hinted, err := this.indexOptimHint(keyspace, node)
+	if err != nil {
+		return
+	}
+
+	var avoid []datastore.Index
+	if indexHint != nil {
+		if indexHint.Name() == algebra.HINT_NO_INDEX {
+			avoid = hinted
+		} else {
+			hints = hinted
+		}
+	}
+
 	baseKeyspace, ok := this.baseKeyspaces[node.Alias()]
 	if !ok {
 		return nil, nil, errors.NewPlanInternalError(fmt.Sprintf("buildScan: cannot find keyspace %s", node.Alias()))
 	}
 
+	// Prefer nearest neighbour search
+	if !join && indexHint == nil {
+		secondary, err = this.buildVectorScan(keyspace, node, hints)
+		if secondary != nil || err != nil {
+			return
+		}
+	}
+
 	var pred, pred2 expression.Expression
 	if join {
 		pred = baseKeyspace.dnfPred
@@ -106,7 +134,17 @@ func (this *builder) buildScan(keyspace datastore.Keyspace, node *algebra.Keyspa
 			if baseKeyspace.origPred == nil {
 				return nil, nil, errors.NewPlanInternalError("buildScan: NULL origPred")
 			}
-			return this.buildPredicateScan(keyspace, node, baseKeyspace, id, hints)
+			return this.buildPredicateScan(keyspace, node, baseKeyspace, id, hints, indexHint, avoid)
+		}
+	}
+
+	// without a predicate, only the primary index can be hinted or avoided
+	if indexHint != nil {
+		if indexHint.Name() == algebra.HINT_NO_INDEX && hasPrimaryIndex(avoid) ||
+			indexHint.Name() != algebra.HINT_NO_INDEX && !hasPrimaryIndex(hints) {
+			this.hintNotFollowed(indexHint, fmt.Sprintf("No predicate on keyspace %s for a secondary index.", node.Alias()))
+		} else {
+			this.hintFollowed(indexHint)
 		}
 	}
 
@@ -116,7 +154,7 @@ func (this *builder) buildScan(keyspace datastore.Keyspace, node *algebra.Keyspa
 			op = "nest"
 		}
 		return nil, nil, errors.NewNoAnsiJoinError(node.Alias(), op)
-	} else if this.cover != nil && baseKeyspace.dnfPred == nil {
+	} else if this.cover != nil && baseKeyspace.dnfPred == nil && !this.noCover(node) {
 		// Handle covering primary scan
 		scan, err := this.buildCoveringPrimaryScan(keyspace, node, id, hints)
 		if scan != nil || err != nil {
@@ -129,7 +167,8 @@ func (this *builder) buildScan(keyspace datastore.Keyspace, node *algebra.Keyspa
 }
 
 func (this *builder) buildPredicateScan(keyspace datastore.Keyspace, node *algebra.KeyspaceTerm,
-	baseKeyspace *baseKeyspace, id expression.Expression, hints []datastore.Index) (
+	baseKeyspace *baseKeyspace, id expression.Expression, hints []datastore.Index,
+	indexHint *algebra.OptimHint, avoid []datastore.Index) (
 	secondary plan.Operator, primary plan.Operator, err error) {
 
 	// Handle constant FALSE predicate
@@ -147,23 +186,70 @@ func (this *builder) buildPredicateScan(keyspace datastore.Keyspace, node *algeb
 	formalizer := expression.NewSelfFormalizer(node.Alias(), nil)
 
 	if len(hints) > 0 {
+		this.indexAll = indexHint != nil && indexHint.Name() == algebra.HINT_INDEX_ALL
 		secondary, primary, err = this.buildSubsetScan(
 			keyspace, node, baseKeyspace, id, hints, primaryKey, formalizer, true)
-		if secondary != nil || primary != nil || err != nil {
+		indexAll := this.indexAll
+		this.indexAll = false
+		if err != nil {
+			return
+		}
+
+		if indexHint != nil {
+			if indexHint.Name() == algebra.HINT_INDEX_ALL && !indexAll {
+				this.hintNotFollowed(indexHint, fmt.Sprintf("Not all the hinted indexes are sargable for keyspace %s.",
+					node.Alias()))
+			} else if secondary == nil && primary == nil {
+				this.hintNotFollowed(indexHint, fmt.Sprintf("No hinted index is sargable for keyspace %s.", node.Alias()))
+			} else {
+				this.hintFollowed(indexHint)
+			}
+		}
+
+		if secondary != nil || primary != nil {
 			return
 		}
 	}
 
+	skip := hints
+	if len(avoid) > 0 {
+		skip = make([]datastore.Index, 0, len(hints)+len(avoid))
+		skip = append(append(skip, hints...), avoid...)
+	}
+
 	others := _INDEX_POOL.Get()
 	defer _INDEX_POOL.Put(others)
-	others, err = allIndexes(keyspace, hints, others, this.indexApiVersion)
+	others, err = allIndexes(keyspace, skip, others, this.indexApiVersion)
 	if err != nil {
 		return
 	}
 
 	secondary, primary, err = this.buildSubsetScan(keyspace, node, baseKeyspace, id, others, primaryKey, formalizer, false)
+	if err != nil {
+		return
+	}
+
+	if indexHint != nil && indexHint.Name() == algebra.HINT_NO_INDEX {
+		if secondary == nil && primary == nil && len(avoid) > 0 {
+			// no plan without the avoided indexes
+			others, err = allIndexes(keyspace, hints, others[:0], this.indexApiVersion)
+			if err != nil {
+				return
+			}
+
+			secondary, primary, err = this.buildSubsetScan(keyspace, node, baseKeyspace, id, others, primaryKey, formalizer, false)
+			if err != nil {
+				return
+			}
+			this.hintNotFollowed(indexHint, fmt.Sprintf("No other index is available for keyspace %s.", node.Alias()))
+		} else if primary != nil && hasPrimaryIndex(avoid) {
+			this.hintNotFollowed(indexHint, fmt.Sprintf("No other index is available for keyspace %s.", node.Alias()))
+		} else {
+			this.hintFollowed(indexHint)
+		}
+	}
 
-	if secondary != nil || primary != nil || err != nil {
+	if secondary != nil || primary != nil {
 		return
 	}
 
@@ -193,6 +279,14 @@ func (this *builder) buildSubsetScan(keyspace datastore.Keyspace, node *algebra.
 	}
 	order := this.order
 
+	// Prefer full text search
+	if !join {
+		scan, err := this.buildSearchScan(node, baseKeyspace, indexes, formalizer)
+		if scan != nil || err != nil {
+			return scan, nil, err
+		}
+	}
+
 	// Prefer OR scan
 	pred := baseKeyspace.dnfPred
 	if join && baseKeyspace.OnclauseOnly() {
@@ -253,7 +347,14 @@ func (this *builder) buildTermScan(node *algebra.KeyspaceTerm,
 		return nil, 0, err
 	}
 
-	minimals := minimalIndexes(sargables, false)
+	var minimals map[datastore.Index]*indexEntry
+	if this.indexAll && len(sargables) == len(indexes) {
+		minimals = sargables
+	} else {
+		// INDEX_ALL cannot be followed unless all the hinted indexes are sargable
+		this.indexAll = false
+		minimals = minimalIndexes(sargables, false)
+	}
 
 	indexPushDowns := this.storeIndexPushDowns()
 	defer func() {
@@ -55,6 +55,8 @@ func checkAndAdd(ids []int, id int) []int {
 func aggToIndexAgg(agg algebra.Aggregate) *indexGroupAggProperties {
 	name := agg.Name()
 	switch agg.(type) {
+	case *algebra.FilteredAggregate, *algebra.OrderedArrayAgg:
+		return nil
 	case *algebra.ArrayAggDistinct, *algebra.CountDistinct, *algebra.CountnDistinct, *algebra.AvgDistinct, *algebra.SumDistinct:
 		name = name + "_distinct"
 	}
@@ -430,9 +430,14 @@ outer:
 			return false, nil
 		}
 
+		// The index orders strings by code point, with NULL and MISSING
+		// values first in ascending order and last in descending order
+		indexCollation := orderTerm.Collation() == nil &&
+			orderTerm.NullsFirst() != orderTerm.Descending()
+
 		for {
 			projexpr, projalias := hashProj[orderTerm.Expression().Alias()]
-			if indexKeyIsDescCollation(i, indexKeys) == orderTerm.Descending() &&
+			if indexCollation && indexKeyIsDescCollation(i, indexKeys) == orderTerm.Descending() &&
 				(orderTerm.Expression().EquivalentTo(keys[i]) ||
 					(projalias && expression.Equivalent(projexpr, keys[i]))) {
 				// orderTerm matched with index key
@@ -0,0 +1,68 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+)
+
+/*
+Search a full text index for a SEARCH() term of the predicate, if an
+index has the searched expression as a key. The predicate is still
+applied to the documents that are found.
+*/
+func (this *builder) buildSearchScan(node *algebra.KeyspaceTerm, baseKeyspace *baseKeyspace,
+	indexes []datastore.Index, formalizer *expression.Formalizer) (plan.Operator, error) {
+
+	var terms expression.Expressions
+	if and, ok := baseKeyspace.dnfPred.(*expression.And); ok {
+		terms = and.Operands()
+	} else {
+		terms = expression.Expressions{baseKeyspace.dnfPred}
+	}
+
+	for _, term := range terms {
+		search, ok := term.(*expression.Search)
+		if !ok || search.Query().Static() == nil ||
+			(search.Options() != nil && search.Options().Static() == nil) {
+			continue
+		}
+
+		name := search.IndexName()
+		for _, index := range indexes {
+			fts, ok := index.(datastore.FTSIndex)
+			if !ok || (name != "" && index.Name() != name) {
+				continue
+			}
+
+			for i, key := range index.RangeKey() {
+				var err error
+				key = key.Copy()
+
+				formalizer.SetIndexScope()
+				key, err = formalizer.Map(key)
+				formalizer.ClearIndexScope()
+				if err != nil {
+					return nil, err
+				}
+
+				if key.EquivalentTo(search.Field()) {
+					this.resetPushDowns()
+					return plan.NewIndexFtsSearch(fts, node, i, search.Query(), search.Options()), nil
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -25,7 +25,7 @@ func (this *builder) buildSecondaryScan(indexes map[datastore.Index]*indexEntry,
 	node *algebra.KeyspaceTerm, baseKeyspace *baseKeyspace, id expression.Expression) (
 	plan.SecondaryScan, int, error) {
 
-	if this.cover != nil && !node.IsAnsiNest() {
+	if this.cover != nil && !node.IsAnsiNest() && !this.indexAll && !this.noCover(node) {
 		scan, sargLength, err := this.buildCoveringScan(indexes, node, baseKeyspace, id)
 		if scan != nil || err != nil {
 			return scan, sargLength, err
@@ -36,7 +36,10 @@ func (this *builder) buildSecondaryScan(indexes map[datastore.Index]*indexEntry,
 
 	pred := baseKeyspace.dnfPred
 
-	indexes = minimalIndexes(indexes, true)
+	// INDEX_ALL intersects all the hinted indexes
+	if !this.indexAll {
+		indexes = minimalIndexes(indexes, true)
+	}
 
 	var err error
 	err = this.sargIndexes(baseKeyspace, indexes)
@@ -167,6 +170,11 @@ func (this *builder) sargableIndexes(indexes []datastore.Index, pred, subset exp
 	for _, index := range indexes {
 		isArray := false
 
+		// full text and vector indexes are only searched
+		if index.Type() == datastore.FTS || index.Type() == datastore.VECTOR {
+			continue
+		}
+
 		if index.IsPrimary() {
 			if primaryKey != nil {
 				keys = primaryKey
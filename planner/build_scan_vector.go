@@ -0,0 +1,94 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+)
+
+/*
+For ORDER BY VECTOR_DISTANCE(key, target) LIMIT k, find the k nearest
+neighbours of the target in a vector index of the key with the metric
+of the distance. The search is approximate, and documents without a
+vector of the dimension of the index are not found. The ORDER BY,
+OFFSET and LIMIT are still applied to the neighbours that are found.
+
+The search is only used when there is no other predicate: filtering
+the k nearest neighbours could leave fewer than k documents, while
+other documents that satisfy the predicate would have qualified.
+*/
+func (this *builder) buildVectorScan(keyspace datastore.Keyspace, node *algebra.KeyspaceTerm,
+	hints []datastore.Index) (plan.Operator, error) {
+
+	if this.order == nil || this.limit == nil || len(this.order.Terms()) == 0 {
+		return nil, nil
+	}
+
+	if (this.where != nil && !this.trueWhereClause()) || this.pushableOnclause != nil {
+		return nil, nil
+	}
+
+	term := this.order.Terms()[0]
+	distance, ok := term.Expression().(*expression.VectorDistance)
+	if !ok || term.Descending() {
+		return nil, nil
+	}
+
+	metric, ok := distance.Metric()
+	if !ok {
+		return nil, nil
+	}
+
+	indexes := hints
+	if len(indexes) == 0 {
+		var err error
+		indexes = _INDEX_POOL.Get()
+		defer _INDEX_POOL.Put(indexes)
+		indexes, err = allIndexes(keyspace, nil, indexes, this.indexApiVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	formalizer := expression.NewSelfFormalizer(node.Alias(), nil)
+	operands := distance.Operands()
+
+	for _, index := range indexes {
+		vi, ok := index.(datastore.VectorIndex)
+		if !ok || vi.Metric() != metric.String() {
+			continue
+		}
+
+		for i, key := range index.RangeKey() {
+			var err error
+			key = key.Copy()
+
+			formalizer.SetIndexScope()
+			key, err = formalizer.Map(key)
+			formalizer.ClearIndexScope()
+			if err != nil {
+				return nil, err
+			}
+
+			for j, target := range []expression.Expression{operands[1], operands[0]} {
+				if key.EquivalentTo(operands[j]) && target.Static() != nil {
+					limit := offsetPlusLimit(this.offset, this.limit)
+					this.resetPushDowns()
+					return plan.NewIndexVectorScan(vi, node, i, target, limit), nil
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
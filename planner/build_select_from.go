@@ -48,6 +48,13 @@ func (this *builder) visitFrom(node *algebra.Subselect, group *algebra.Group) er
 			if err != nil {
 				return err
 			}
+
+			// keyspaces that may be null extended by a join are filtered after it
+			for alias, _ := range keyspaceFinder.nullExtended {
+				baseKeyspace := this.baseKeyspaces[alias]
+				baseKeyspace.filters = nil
+				baseKeyspace.joinfilters = nil
+			}
 		}
 
 		if this.pushableOnclause != nil {
@@ -164,7 +171,7 @@ func (this *builder) VisitExpressionTerm(node *algebra.ExpressionTerm) (interfac
 	this.children = make([]plan.Operator, 0, 16)    // top-level children, executed sequentially
 	this.subChildren = make([]plan.Operator, 0, 16) // sub-children, executed across data-parallel streams
 
-	scan := plan.NewExpressionScan(node.ExpressionTerm(), node.Alias())
+	scan := plan.NewExpressionScan(node.ExpressionTerm(), node.Alias(), false)
 	this.children = append(this.children, scan)
 
 	err := this.processKeyspaceDone(node.Alias())
@@ -263,7 +270,7 @@ func (this *builder) VisitAnsiJoin(node *algebra.AnsiJoin) (interface{}, error)
 	this.requirePrimaryKey = true
 	this.resetIndexGroupAggs()
 	this.resetProjection()
-	if term, ok := node.PrimaryTerm().(*algebra.ExpressionTerm); ok && term.IsKeyspace() {
+	if term, ok := node.PrimaryTerm().(*algebra.ExpressionTerm); ok && term.IsKeyspace() && !node.RightOuter() {
 		this.resetOffsetLimit()
 	} else {
 		this.resetOrderOffsetLimit()
@@ -279,14 +286,17 @@ func (this *builder) VisitAnsiJoin(node *algebra.AnsiJoin) (interface{}, error)
 		return nil, err
 	}
 
-	if njoin, ok := join.(*plan.Join); ok {
+	switch join.(type) {
+	case *plan.Join, *plan.HashJoin:
+		// a hash join needs to see every left-hand side object
+		// before it knows which right-hand side objects are unmatched
 		if len(this.subChildren) > 0 {
 			parallel := plan.NewParallel(plan.NewSequence(this.subChildren...), this.maxParallelism)
 			this.children = append(this.children, parallel)
 			this.subChildren = make([]plan.Operator, 0, 16)
 		}
-		this.children = append(this.children, njoin)
-	} else {
+		this.children = append(this.children, join)
+	default:
 		this.subChildren = append(this.subChildren, join)
 	}
 
@@ -448,6 +458,47 @@ func (this *builder) VisitUnnest(node *algebra.Unnest) (interface{}, error) {
 	return nil, nil
 }
 
+/*
+The rows of a PIVOT are grouped, and its aggregates computed, by
+the group operators of the subselect.
+*/
+func (this *builder) VisitPivot(node *algebra.Pivot) (interface{}, error) {
+	this.resetPushDowns()
+	return node.Left().Accept(this)
+}
+
+/*
+UNPIVOT unnests the values of the fields, binds the name of each
+and filters out NULL and MISSING values.
+*/
+func (this *builder) VisitUnpivot(node *algebra.Unpivot) (interface{}, error) {
+	this.resetPushDowns()
+
+	_, err := node.Left().Accept(this)
+	if err != nil {
+		return nil, err
+	}
+
+	this.subChildren = append(this.subChildren, plan.NewUnnest(node.Unnest()))
+	this.subChildren = append(this.subChildren, plan.NewLet(expression.Bindings{node.NameBinding()}))
+	this.subChildren = append(this.subChildren, plan.NewFilter(node.Filter()))
+	parallel := plan.NewParallel(plan.NewSequence(this.subChildren...), this.maxParallelism)
+	this.children = append(this.children, parallel)
+	this.subChildren = make([]plan.Operator, 0, 16)
+
+	err = this.processKeyspaceDone(node.ValueAlias())
+	if err != nil {
+		return nil, err
+	}
+
+	err = this.processKeyspaceDone(node.NameAlias())
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
 func (this *builder) fastCount(node *algebra.Subselect) (bool, error) {
 	if node.From() == nil ||
 		(node.Where() != nil && (node.Where().Value() == nil || !node.Where().Value().Truth())) ||
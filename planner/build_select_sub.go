@@ -31,6 +31,7 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 	prevPushableOnclause := this.pushableOnclause
 	prevBuilderFlags := this.builderFlags
 	prevMaxParallelism := this.maxParallelism
+	prevOptimHints := this.setOptimHints(node.OptimHints())
 
 	indexPushDowns := this.storeIndexPushDowns()
 
@@ -46,6 +47,7 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 		this.builderFlags = prevBuilderFlags
 		this.maxParallelism = prevMaxParallelism
 		this.restoreIndexPushDowns(indexPushDowns, false)
+		this.restoreOptimHints(prevOptimHints)
 	}()
 
 	this.coveringScans = make([]plan.CoveringOperator, 0, 4)
@@ -66,9 +68,14 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 
 	this.node = node
 
+	// Inline views in the FROM clause
+	err := this.inlineViews(node)
+	if err != nil {
+		return nil, err
+	}
+
 	// Inline LET expressions for index selection
 	if node.Let() != nil && node.Where() != nil {
-		var err error
 		inliner := expression.NewInliner(node.Let().Mappings())
 		this.where, err = inliner.Map(node.Where().Copy())
 		if err != nil {
@@ -214,6 +221,13 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 		}
 
 		if group != nil {
+			if group.Sets() != nil {
+				err = this.coverGroupingSets(node, group)
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			this.visitGroup(group, aggs)
 		}
 
@@ -287,11 +301,11 @@ func (this *builder) visitGroup(group *algebra.Group, aggs algebra.Aggregates) {
 
 	if partial {
 		aggv := sortAggregatesSlice(aggs)
-		this.subChildren = append(this.subChildren, plan.NewInitialGroup(group.By(), aggv))
+		this.subChildren = append(this.subChildren, plan.NewInitialGroup(group.By(), group.Sets(), aggv))
 		this.children = append(this.children,
 			plan.NewParallel(plan.NewSequence(this.subChildren...), this.maxParallelism))
-		this.children = append(this.children, plan.NewIntermediateGroup(group.By(), aggv))
-		this.children = append(this.children, plan.NewFinalGroup(group.By(), aggv))
+		this.children = append(this.children, plan.NewIntermediateGroup(group.By(), group.Sets(), aggv))
+		this.children = append(this.children, plan.NewFinalGroup(group.By(), group.Sets(), aggv))
 		this.subChildren = make([]plan.Operator, 0, 8)
 	}
 
@@ -526,7 +540,7 @@ func allAggregates(node *algebra.Subselect, order *algebra.Order) (algebra.Aggre
 		// Disallow nested aggregates
 		subAggs := make(map[string]algebra.Aggregate)
 		for _, agg := range aggs {
-			collectAggregates(subAggs, agg.Operand())
+			collectAggregates(subAggs, agg.Children()...)
 			if len(subAggs) > 0 {
 				return nil, fmt.Errorf("Nested aggregates are not allowed.")
 			}
@@ -677,6 +691,12 @@ func skipFixedOrderTerms(order *algebra.Order, pred expression.Expression) *alge
 func (this *builder) setIndexGroupAggs(group *algebra.Group, aggs algebra.Aggregates, let expression.Bindings) {
 
 	if group != nil {
+		// Grouping sets are not pushed to the index
+		if group.Sets() != nil {
+			this.resetIndexGroupAggs()
+			return
+		}
+
 		// Group or Aggregates Depends on LET disable pushdowns
 		for _, expr := range group.By() {
 			if !expr.IndexAggregatable() || dependsOnLet(expr, let) {
@@ -687,7 +707,7 @@ func (this *builder) setIndexGroupAggs(group *algebra.Group, aggs algebra.Aggreg
 
 		for _, agg := range aggs {
 			aggIndexProperties := aggToIndexAgg(agg)
-			if !aggIndexProperties.supported {
+			if aggIndexProperties == nil || !aggIndexProperties.supported {
 				this.resetIndexGroupAggs()
 				return
 			}
@@ -0,0 +1,66 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/sequences"
+)
+
+func (this *builder) VisitCreateSequence(stmt *algebra.CreateSequence) (interface{}, error) {
+	ref := stmt.Sequence()
+	ref.SetDefaultNamespace(this.namespace)
+	if strings.ToLower(ref.Namespace()) == "#system" {
+		return nil, errors.NewSequenceError(nil, "sequences cannot be created in the system namespace")
+	}
+
+	_, err := this.datastore.NamespaceByName(ref.Namespace())
+	if err != nil {
+		return nil, err
+	}
+
+	if sequences.GetSequence(ref.Namespace(), ref.Keyspace()) != nil {
+		return nil, errors.NewSequenceAlreadyExistsError(ref.FullName())
+	}
+
+	// report invalid options before execution
+	_, err = sequences.NewSequence(ref.Namespace(), ref.Keyspace(), stmt.Options())
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewCreateSequence(ref.Namespace(), ref.Keyspace(), stmt.Options()), nil
+}
+
+func (this *builder) VisitAlterSequence(stmt *algebra.AlterSequence) (interface{}, error) {
+	ref := stmt.Sequence()
+	ref.SetDefaultNamespace(this.namespace)
+
+	if sequences.GetSequence(ref.Namespace(), ref.Keyspace()) == nil {
+		return nil, errors.NewNoSuchSequenceError(ref.FullName())
+	}
+
+	return plan.NewAlterSequence(ref.Namespace(), ref.Keyspace(), stmt.Options()), nil
+}
+
+func (this *builder) VisitDropSequence(stmt *algebra.DropSequence) (interface{}, error) {
+	ref := stmt.Sequence()
+	ref.SetDefaultNamespace(this.namespace)
+
+	if sequences.GetSequence(ref.Namespace(), ref.Keyspace()) == nil {
+		return nil, errors.NewNoSuchSequenceError(ref.FullName())
+	}
+
+	return plan.NewDropSequence(ref.Namespace(), ref.Keyspace()), nil
+}
@@ -0,0 +1,69 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/triggers"
+)
+
+func (this *builder) VisitCreateTrigger(stmt *algebra.CreateTrigger) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+	if strings.ToLower(ksref.Namespace()) == "#system" {
+		return nil, errors.NewTriggerError(nil, "keyspaces of the system namespace cannot have triggers")
+	}
+
+	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	if triggers.GetTrigger(ksref.Namespace(), ksref.Keyspace(), stmt.Name()) != nil {
+		return nil, errors.NewTriggerAlreadyExistsError(ksref.FullName() + "." + stmt.Name())
+	}
+
+	when := ""
+	if stmt.When() != nil {
+		when = stmt.When().String()
+	}
+
+	// report bodies that do not parse back before execution
+	_, err = triggers.NewTrigger(ksref.Namespace(), ksref.Keyspace(), stmt.Name(),
+		stmt.Timing(), stmt.Event(), when, stmt.Text())
+	if err != nil {
+		return nil, err
+	}
+
+	// the body is planned, in the namespace of the trigger, for
+	// validation only: the text is what is stored
+	_, err = Build(stmt.Body(), this.datastore, this.systemstore, ksref.Namespace(), true,
+		nil, nil, this.indexApiVersion, this.featureControls)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewCreateTrigger(keyspace, stmt.Name(), stmt.Timing(), stmt.Event(), when, stmt.Text()), nil
+}
+
+func (this *builder) VisitDropTrigger(stmt *algebra.DropTrigger) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+
+	if triggers.GetTrigger(ksref.Namespace(), ksref.Keyspace(), stmt.Name()) == nil {
+		return nil, errors.NewNoSuchTriggerError(ksref.FullName() + "." + stmt.Name())
+	}
+
+	return plan.NewDropTrigger(ksref.Namespace(), ksref.Keyspace(), stmt.Name()), nil
+}
@@ -17,6 +17,7 @@ import (
 func (this *builder) VisitUpdate(stmt *algebra.Update) (interface{}, error) {
 	this.where = stmt.Where()
 	this.node = stmt
+	defer this.restoreOptimHints(this.setOptimHints(stmt.OptimHints()))
 
 	ksref := stmt.KeyspaceRef()
 	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
@@ -24,7 +25,11 @@ func (this *builder) VisitUpdate(stmt *algebra.Update) (interface{}, error) {
 		return nil, err
 	}
 
-	err = this.beginMutate(keyspace, ksref, stmt.Keys(), stmt.Indexes(), stmt.Limit(), true)
+	if stmt.From() != nil {
+		err = this.beginMutateFrom(ksref, stmt.From(), stmt.Indexes(), stmt.Where())
+	} else {
+		err = this.beginMutate(keyspace, ksref, stmt.Keys(), stmt.Indexes(), stmt.Limit(), true)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -41,13 +46,19 @@ func (this *builder) VisitUpdate(stmt *algebra.Update) (interface{}, error) {
 		updateSubChildren = append(updateSubChildren, plan.NewUnset(stmt.Unset()))
 	}
 
-	updateSubChildren = append(updateSubChildren, plan.NewSendUpdate(keyspace, ksref.Alias(), stmt.Limit()))
+	updateSubChildren = append(updateSubChildren, plan.NewSendUpdate(keyspace, ksref.Alias(), stmt.Limit(),
+		stmt.From() != nil))
 
 	if stmt.Returning() != nil {
 		updateSubChildren = append(updateSubChildren, plan.NewInitialProject(stmt.Returning()), plan.NewFinalProject())
 	}
 
-	if stmt.Limit() != nil {
+	if stmt.Limit() != nil && stmt.From() != nil {
+		// a document joined to several objects counts once towards the LIMIT,
+		// which only a serial SendUpdate can enforce
+		this.children = append(this.children, plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism))
+		this.children = append(this.children, plan.NewSequence(updateSubChildren...))
+	} else if stmt.Limit() != nil {
 		seqChildren := make([]plan.Operator, 0, 3)
 		seqChildren = append(seqChildren, plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism))
 		seqChildren = append(seqChildren, plan.NewLimit(stmt.Limit()))
@@ -0,0 +1,81 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/validation"
+)
+
+func (this *builder) VisitAlterKeyspace(stmt *algebra.AlterKeyspace) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+	if strings.ToLower(ksref.Namespace()) == "#system" {
+		return nil, errors.NewValidationError(nil, "keyspaces of the system namespace cannot be validated")
+	}
+
+	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	// report invalid schemas and options before execution
+	if stmt.Validation() != nil {
+		_, err = validation.NewValidation(ksref.Namespace(), ksref.Keyspace(), stmt.Validation(), stmt.Options())
+		if err != nil {
+			return nil, err
+		}
+	} else if validation.GetValidation(ksref.Namespace(), ksref.Keyspace()) == nil {
+		return nil, errors.NewNoSuchValidationError(ksref.FullName())
+	}
+
+	return plan.NewAlterKeyspace(keyspace, stmt.Validation(), stmt.Options()), nil
+}
+
+/*
+VALIDATE is planned as a query over the keyspace, applying VALIDATE()
+to each document; see algebra.ValidateKeyspace.Query().
+*/
+func (this *builder) VisitValidateKeyspace(stmt *algebra.ValidateKeyspace) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+
+	_, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	// the validation schema of the keyspace is looked up by name at
+	// execution, so that prepared statements see changes to it
+	var schema expression.Expression
+	if stmt.Schema() != nil {
+		_, err = validation.NewSchema(stmt.Schema())
+		if err != nil {
+			return nil, err
+		}
+		schema = expression.NewConstant(stmt.Schema())
+	} else if validation.GetValidation(ksref.Namespace(), ksref.Keyspace()) != nil {
+		schema = expression.NewConstant(ksref.FullName())
+	} else {
+		return nil, errors.NewNoSuchValidationError(ksref.FullName())
+	}
+
+	query, err := stmt.Query(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Accept(this)
+}
@@ -0,0 +1,391 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/views"
+)
+
+func (this *builder) VisitCreateView(stmt *algebra.CreateView) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+	if strings.ToLower(ksref.Namespace()) == "#system" {
+		return nil, errors.NewViewError(nil, "views cannot be created in the system namespace")
+	}
+
+	namespace, err := this.datastore.NamespaceByName(ksref.Namespace())
+	if err != nil {
+		return nil, err
+	}
+
+	if views.GetView(ksref.Namespace(), ksref.Keyspace()) != nil {
+		return nil, errors.NewViewAlreadyExistsError(ksref.FullName())
+	}
+
+	// a materialized view is stored in its own keyspace; a plain view
+	// would hide the keyspace of the same name
+	_, err = namespace.KeyspaceByName(ksref.Keyspace())
+	if stmt.Materialized() && err != nil {
+		return nil, err
+	} else if !stmt.Materialized() && err == nil {
+		return nil, errors.NewViewError(nil, "keyspace "+ksref.FullName()+" already exists")
+	}
+
+	// the definition is planned, and views it refers to are inlined,
+	// for validation and privileges only: the text is what is stored
+	incremental := stmt.Materialized() && views.Incremental(stmt.Query(), ksref.Namespace())
+	_, er := stmt.Query().Accept(this)
+	if er != nil {
+		return nil, er
+	}
+
+	return plan.NewCreateView(ksref.Namespace(), ksref.Keyspace(), stmt.Text(),
+		stmt.Materialized(), incremental), nil
+}
+
+func (this *builder) VisitDropView(stmt *algebra.DropView) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+
+	view := views.GetView(ksref.Namespace(), ksref.Keyspace())
+	if view == nil {
+		return nil, errors.NewNoSuchViewError(ksref.FullName())
+	}
+
+	if view.Materialized && !stmt.Materialized() {
+		return nil, errors.NewViewError(nil, "view "+ksref.FullName()+" is materialized: use DROP MATERIALIZED VIEW")
+	} else if !view.Materialized && stmt.Materialized() {
+		return nil, errors.NewViewError(nil, "view "+ksref.FullName()+" is not materialized: use DROP VIEW")
+	}
+
+	// the privileges of the definition are required; a definition
+	// that no longer plans must still be possible to drop
+	def, err := view.Definition()
+	if err == nil {
+		def.Accept(this)
+		stmt.SetDefinition(def)
+	}
+
+	return plan.NewDropView(ksref.Namespace(), ksref.Keyspace(), view.Materialized), nil
+}
+
+func (this *builder) VisitRefreshView(stmt *algebra.RefreshView) (interface{}, error) {
+	ksref := stmt.Keyspace()
+	ksref.SetDefaultNamespace(this.namespace)
+
+	view := views.GetView(ksref.Namespace(), ksref.Keyspace())
+	if view == nil {
+		return nil, errors.NewNoSuchViewError(ksref.FullName())
+	}
+
+	if !view.Materialized {
+		return nil, errors.NewViewError(nil, "view "+ksref.FullName()+" is not materialized")
+	}
+
+	if stmt.Mode() == algebra.REFRESH_INCREMENTAL && !view.Incremental {
+		return nil, errors.NewViewError(nil, "view "+ksref.FullName()+" cannot be refreshed incrementally")
+	}
+
+	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := view.Definition()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = def.Accept(this)
+	if err != nil {
+		return nil, err
+	}
+	stmt.SetDefinition(def)
+
+	return plan.NewRefreshView(keyspace, stmt.Mode()), nil
+}
+
+/*
+Replace the views referenced in the FROM clause of the subselect.
+
+A view that selects the documents of a single keyspace, filtered, is
+merged into the query: the view is replaced by the keyspace and its
+filter is added to the WHERE clause, or to the ON clause of an ANSI
+JOIN or NEST, so that it can be used for index selection along with
+the predicates of the query. Where the rows of the view must be
+preserved by a RIGHT or FULL OUTER JOIN, and for any other view, the
+view is replaced by its definition, as a subquery term.
+
+Materialized views are read from their keyspace, like any other.
+*/
+func (this *builder) inlineViews(node *algebra.Subselect) error {
+	if node.From() == nil || views.CountViews() == 0 {
+		return nil
+	}
+
+	from, filter, err := this.inlineFromTerm(node.From(), true)
+	if err != nil {
+		return err
+	}
+
+	node.SetFrom(from)
+	if filter != nil {
+		node.SetWhere(andFilters(node.Where(), filter))
+	}
+	return nil
+}
+
+// Returns the term with views replaced, and the filters of the views
+// merged on the way, if merge is set.
+func (this *builder) inlineFromTerm(term algebra.FromTerm, merge bool) (
+	algebra.FromTerm, expression.Expression, error) {
+
+	switch term := term.(type) {
+	case *algebra.KeyspaceTerm:
+		return this.inlineKeyspaceTerm(term, merge)
+	case *algebra.ExpressionTerm:
+		if !term.IsKeyspace() {
+			return term, nil, nil
+		}
+
+		rv, filter, err := this.inlineKeyspaceTerm(term.KeyspaceTerm(), merge)
+		if err != nil || rv == term.KeyspaceTerm() {
+			return term, nil, err
+		}
+		return rv, filter, nil
+	case *algebra.AnsiJoin:
+		left, filter, err := this.inlineFromTerm(term.Left(), merge && !term.RightOuter())
+		if err != nil {
+			return nil, nil, err
+		}
+		term.SetLeft(left)
+
+		right, onFilter, err := this.inlineFromTerm(term.Right(), !term.RightOuter())
+		if err != nil {
+			return nil, nil, err
+		}
+		term.SetRight(right)
+		if onFilter != nil {
+			term.SetOnclause(andFilters(term.Onclause(), onFilter))
+		}
+		return term, filter, nil
+	case *algebra.AnsiNest:
+		left, filter, err := this.inlineFromTerm(term.Left(), merge)
+		if err != nil {
+			return nil, nil, err
+		}
+		term.SetLeft(left)
+
+		right, onFilter, err := this.inlineFromTerm(term.Right(), true)
+		if err != nil {
+			return nil, nil, err
+		}
+		term.SetRight(right)
+		if onFilter != nil {
+			term.SetOnclause(andFilters(term.Onclause(), onFilter))
+		}
+		return term, filter, nil
+	case *algebra.Join:
+		left, filter, err := this.inlineLookupTerm(term.Left(), term.Right(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	case *algebra.IndexJoin:
+		left, filter, err := this.inlineLookupTerm(term.Left(), term.Right(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	case *algebra.Nest:
+		left, filter, err := this.inlineLookupTerm(term.Left(), term.Right(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	case *algebra.IndexNest:
+		left, filter, err := this.inlineLookupTerm(term.Left(), term.Right(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	case *algebra.Unnest:
+		left, filter, err := this.inlineFromTerm(term.Left(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	case *algebra.Pivot:
+		left, filter, err := this.inlineFromTerm(term.Left(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	case *algebra.Unpivot:
+		left, filter, err := this.inlineFromTerm(term.Left(), merge)
+		if err == nil {
+			term.SetLeft(left)
+		}
+		return term, filter, err
+	}
+
+	return term, nil, nil
+}
+
+// The right-hand side of lookup and index joins and nests is accessed
+// by key, and cannot be a view.
+func (this *builder) inlineLookupTerm(left algebra.FromTerm, right *algebra.KeyspaceTerm, merge bool) (
+	algebra.FromTerm, expression.Expression, error) {
+
+	view := this.termView(right)
+	if view != nil && !view.Materialized {
+		return nil, nil, errors.NewViewError(nil, "view "+view.Key()+
+			" cannot be the right-hand side of a lookup or index JOIN or NEST")
+	}
+
+	return this.inlineFromTerm(left, merge)
+}
+
+func (this *builder) inlineKeyspaceTerm(term *algebra.KeyspaceTerm, merge bool) (
+	algebra.FromTerm, expression.Expression, error) {
+
+	view := this.termView(term)
+	if view == nil || view.Materialized {
+		return term, nil, nil
+	}
+
+	def, err := view.Definition()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if merge {
+		base, filter, err := mergeView(def, term, view)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the view may itself select from a view
+		if base != nil {
+			rv, baseFilter, err := this.inlineKeyspaceTerm(base, true)
+			if err != nil {
+				return nil, nil, err
+			}
+			return rv, andFilters(filter, baseFilter), nil
+		}
+	}
+
+	if term.Keys() != nil || len(term.Indexes()) > 0 {
+		return nil, nil, errors.NewViewError(nil, "USE KEYS and USE INDEX cannot be applied to view "+view.Key())
+	}
+
+	return algebra.NewSubqueryTerm(def, term.Alias()), nil, nil
+}
+
+// Returns the view referenced by the keyspace term, if any.
+func (this *builder) termView(node *algebra.KeyspaceTerm) *views.View {
+	ns := node.Namespace()
+	if ns == "" {
+		ns = this.namespace
+	}
+
+	if strings.ToLower(ns) == "#system" {
+		return nil
+	}
+	return views.GetView(ns, node.Keyspace())
+}
+
+/*
+If the view selects whole documents of a single keyspace, with no
+other clause than WHERE, returns the term of that keyspace aliased as
+the view term, and the filter of the view on that alias. Otherwise,
+returns nil.
+*/
+func mergeView(def *algebra.Select, term *algebra.KeyspaceTerm, view *views.View) (
+	*algebra.KeyspaceTerm, expression.Expression, error) {
+
+	sub, ok := def.Subresult().(*algebra.Subselect)
+	if !ok || def.Order() != nil || def.Limit() != nil || def.Offset() != nil ||
+		sub.Let() != nil || sub.Group() != nil || sub.Projection().Distinct() {
+		return nil, nil, nil
+	}
+
+	var base *algebra.KeyspaceTerm
+	switch from := sub.From().(type) {
+	case *algebra.KeyspaceTerm:
+		base = from
+	case *algebra.ExpressionTerm:
+		if from.IsKeyspace() {
+			base = from.KeyspaceTerm()
+		}
+	}
+
+	if base == nil || base.Keys() != nil || len(base.Indexes()) > 0 ||
+		!wholeDocument(sub.Projection(), base.Alias()) {
+		return nil, nil, nil
+	}
+
+	var filter expression.Expression
+	if sub.Where() != nil {
+		subqueries, err := expression.ListSubqueries(expression.Expressions{sub.Where()}, false)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(subqueries) > 0 {
+			return nil, nil, nil
+		}
+
+		alias := expression.NewIdentifier(term.Alias())
+		alias.SetKeyspaceAlias(true)
+		filter, err = expression.NewInliner(map[string]expression.Expression{base.Alias(): alias}).Map(sub.Where())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ns := base.Namespace()
+	if ns == "" {
+		ns = view.Namespace
+	}
+
+	rv := algebra.NewKeyspaceTerm(ns, base.Keyspace(), term.Alias(), term.Keys(), term.Indexes())
+	rv.SetProperty(term.Property())
+	return rv, filter, nil
+}
+
+// SELECT a.* or SELECT RAW a
+func wholeDocument(projection *algebra.Projection, alias string) bool {
+	terms := projection.Terms()
+	if len(terms) != 1 || terms[0].Modifiers() != nil {
+		return false
+	}
+
+	id, ok := terms[0].Expression().(*expression.Identifier)
+	if !ok || id.Identifier() != alias {
+		return false
+	}
+	return terms[0].Star() != projection.Raw()
+}
+
+func andFilters(first, second expression.Expression) expression.Expression {
+	if first == nil {
+		return second
+	} else if second == nil {
+		return first
+	}
+	return expression.NewAnd(first, second)
+}
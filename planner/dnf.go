@@ -202,6 +202,10 @@ func (this *DNF) VisitFunction(expr expression.Function) (interface{}, error) {
 	switch expr := expr.(type) {
 	case *expression.RegexpLike:
 		return this.visitLike(expr)
+	case *expression.LikeEscape:
+		return this.visitLike(expr)
+	case *expression.Similar:
+		return this.visitLike(expr)
 	case *expression.IsBoolean:
 		exp = expression.NewLE(expr.Operand(), expression.TRUE_EXPR)
 	case *expression.IsNumber:
@@ -21,6 +21,8 @@ import (
 type keyspaceFinder struct {
 	baseKeyspaces    map[string]*baseKeyspace
 	pushableOnclause expression.Expression
+	aliases          []string        // in the order they are found
+	nullExtended     map[string]bool // keyspaces null extended by RIGHT or FULL OUTER JOINs
 }
 
 func newKeyspaceFinder(baseKeyspaces map[string]*baseKeyspace) *keyspaceFinder {
@@ -36,9 +38,19 @@ func (this *keyspaceFinder) addKeyspaceAlias(alias string) error {
 	}
 	newBaseKeyspace := newBaseKeyspace(alias)
 	this.baseKeyspaces[alias] = newBaseKeyspace
+	this.aliases = append(this.aliases, alias)
 	return nil
 }
 
+func (this *keyspaceFinder) addNullExtended(aliases []string) {
+	if this.nullExtended == nil {
+		this.nullExtended = make(map[string]bool, len(aliases))
+	}
+	for _, alias := range aliases {
+		this.nullExtended[alias] = true
+	}
+}
+
 func (this *keyspaceFinder) addOnclause(onclause expression.Expression) {
 	if onclause != nil {
 		if this.pushableOnclause != nil {
@@ -104,10 +116,32 @@ func (this *keyspaceFinder) VisitIndexJoin(node *algebra.IndexJoin) (interface{}
 
 func (this *keyspaceFinder) VisitAnsiJoin(node *algebra.AnsiJoin) (interface{}, error) {
 	// if this is inner join, gather ON-clause
-	if !node.Outer() {
+	if !node.Outer() && !node.RightOuter() {
 		this.addOnclause(node.Onclause())
 	}
-	return nil, this.visitJoin(node.Left(), node.Right())
+	if !node.RightOuter() {
+		return nil, this.visitJoin(node.Left(), node.Right())
+	}
+
+	// the unmatched objects of either side of a FULL OUTER JOIN, and of the right
+	// side of a RIGHT OUTER JOIN, are preserved: WHERE clause filters on the other
+	// side must not be applied before the join
+	n := len(this.aliases)
+	_, err := node.Left().Accept(this)
+	if err != nil {
+		return nil, err
+	}
+	this.addNullExtended(this.aliases[n:])
+
+	n = len(this.aliases)
+	_, err = node.Right().Accept(this)
+	if err != nil {
+		return nil, err
+	}
+	if node.Outer() {
+		this.addNullExtended(this.aliases[n:])
+	}
+	return nil, nil
 }
 
 func (this *keyspaceFinder) VisitNest(node *algebra.Nest) (interface{}, error) {
@@ -134,6 +168,23 @@ func (this *keyspaceFinder) VisitUnnest(node *algebra.Unnest) (interface{}, erro
 	return nil, this.addKeyspaceAlias(node.Alias())
 }
 
+func (this *keyspaceFinder) VisitPivot(node *algebra.Pivot) (interface{}, error) {
+	return node.Left().Accept(this)
+}
+
+func (this *keyspaceFinder) VisitUnpivot(node *algebra.Unpivot) (interface{}, error) {
+	_, err := node.Left().Accept(this)
+	if err != nil {
+		return nil, err
+	}
+
+	err = this.addKeyspaceAlias(node.ValueAlias())
+	if err != nil {
+		return nil, err
+	}
+	return nil, this.addKeyspaceAlias(node.NameAlias())
+}
+
 func (this *keyspaceFinder) VisitUnion(node *algebra.Union) (interface{}, error) {
 	return nil, this.visitSetop(node.First(), node.Second())
 }
@@ -0,0 +1,276 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/logging"
+)
+
+// Optimizer hints are advisory. Each hint of a query block is
+// followed, or not followed for a reason, and both are reported by
+// EXPLAIN; a hint that cannot be followed never fails the statement.
+
+type hintState struct {
+	followed bool
+	reason   string
+}
+
+type optimHintsBlock struct {
+	hints   *algebra.OptimHints
+	aliases map[string]bool
+}
+
+// Makes the hints of a query block current, returning those of the
+// enclosing block, to be passed to restoreOptimHints().
+func (this *builder) setOptimHints(hints *algebra.OptimHints) *optimHintsBlock {
+	prev := &optimHintsBlock{this.optimHints, this.hintAliases}
+
+	this.optimHints = hints
+	this.hintAliases = nil
+	if hints == nil {
+		return prev
+	}
+
+	this.hintAliases = make(map[string]bool, _MAP_KEYSPACE_CAP)
+	for _, block := range this.hintBlocks {
+		if block == hints {
+			return prev
+		}
+	}
+	this.hintBlocks = append(this.hintBlocks, hints)
+	return prev
+}
+
+// Decides the hints of the current query block that the planner did
+// not consider, and restores those of the enclosing block.
+func (this *builder) restoreOptimHints(prev *optimHintsBlock) {
+	if this.optimHints != nil {
+		for _, hint := range this.optimHints.Hints() {
+			if hint.Invalid() != "" || this.hintStates[hint] != nil {
+				continue
+			}
+
+			switch {
+			case hint.Name() == algebra.HINT_ORDERED:
+				// joins are always planned in the order of the FROM clause
+				this.hintFollowed(hint)
+			case !this.hintAliases[hint.Keyspace()]:
+				this.hintNotFollowed(hint, fmt.Sprintf("Keyspace %s is not in the query block.", hint.Keyspace()))
+			case hint.Name() == algebra.HINT_NO_COVER:
+				// no covering scan was considered for the keyspace
+				this.hintFollowed(hint)
+			case hint.Name() == algebra.HINT_USE_NL || hint.Name() == algebra.HINT_USE_HASH:
+				this.hintNotFollowed(hint, fmt.Sprintf("Keyspace %s is not the right-hand side of an ANSI JOIN.",
+					hint.Keyspace()))
+			default:
+				this.hintNotFollowed(hint, fmt.Sprintf("The hint does not apply to keyspace %s.", hint.Keyspace()))
+			}
+		}
+	}
+
+	this.optimHints = prev.hints
+	this.hintAliases = prev.aliases
+}
+
+// Returns the valid hint of the current query block with the name
+// for the keyspace alias, if any.
+func (this *builder) optimHint(name, alias string) *algebra.OptimHint {
+	if this.optimHints == nil {
+		return nil
+	}
+
+	if alias != "" {
+		this.hintAliases[alias] = true
+	}
+	return this.optimHints.Hint(name, alias)
+}
+
+func (this *builder) hintFollowed(hint *algebra.OptimHint) {
+	this.setHintState(hint, true, "")
+}
+
+func (this *builder) hintNotFollowed(hint *algebra.OptimHint, reason string) {
+	this.setHintState(hint, false, reason)
+}
+
+// A keyspace may be planned more than once, as when a nested-loop
+// join falls back to a hash join: the last decision stands.
+func (this *builder) setHintState(hint *algebra.OptimHint, followed bool, reason string) {
+	if hint == nil {
+		return
+	}
+
+	if this.hintStates == nil {
+		this.hintStates = make(map[*algebra.OptimHint]*hintState, 8)
+	}
+	this.hintStates[hint] = &hintState{followed, reason}
+}
+
+// Returns the INDEX, INDEX_ALL or NO_INDEX hint of the keyspace, if
+// any, and the online indexes it names; for NO_INDEX without index
+// names, all the secondary indexes. INDEX and INDEX_ALL hints that
+// cannot be applied are not followed, and not returned.
+func (this *builder) indexOptimHint(keyspace datastore.Keyspace, node *algebra.KeyspaceTerm) (
+	hint *algebra.OptimHint, indexes []datastore.Index, err error) {
+
+	alias := node.Alias()
+	for _, name := range []string{algebra.HINT_INDEX, algebra.HINT_INDEX_ALL, algebra.HINT_NO_INDEX} {
+		hint = this.optimHint(name, alias)
+		if hint != nil {
+			break
+		}
+	}
+
+	if hint == nil {
+		return nil, nil, nil
+	}
+
+	if len(node.Indexes()) > 0 {
+		this.hintNotFollowed(hint, fmt.Sprintf("USE INDEX is specified for keyspace %s.", alias))
+		return nil, nil, nil
+	}
+
+	if hint.Name() == algebra.HINT_NO_INDEX && len(hint.Indexes()) == 0 {
+		all, err := allIndexes(keyspace, nil, nil, this.indexApiVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, index := range all {
+			if !index.IsPrimary() {
+				indexes = append(indexes, index)
+			}
+		}
+		return hint, indexes, nil
+	}
+
+	for _, name := range hint.Indexes() {
+		index, err := onlineIndexByName(keyspace, name, this.indexApiVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if index != nil {
+			indexes = append(indexes, index)
+		} else if hint.Name() == algebra.HINT_INDEX_ALL {
+			this.hintNotFollowed(hint, fmt.Sprintf("Index %s is not online for keyspace %s.", name, alias))
+			return nil, nil, nil
+		}
+	}
+
+	if len(indexes) == 0 && hint.Name() == algebra.HINT_INDEX {
+		this.hintNotFollowed(hint, fmt.Sprintf("No hinted index is online for keyspace %s.", alias))
+		return nil, nil, nil
+	}
+
+	return hint, indexes, nil
+}
+
+// Returns the online index with the name, in any indexer of the
+// keyspace, or nil.
+func onlineIndexByName(keyspace datastore.Keyspace, name string, indexApiVersion int) (
+	datastore.Index, error) {
+
+	indexers, err := keyspace.Indexers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, indexer := range indexers {
+		indexes, err := indexer.Indexes()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, index := range indexes {
+			if index.Name() != name {
+				continue
+			}
+
+			state, _, er := index.State()
+			if er != nil {
+				logging.Errorp("Index selection", logging.Pair{"error", er.Error()})
+			}
+
+			if er != nil || state != datastore.ONLINE {
+				return nil, nil
+			}
+
+			if !useIndex2API(index, indexApiVersion) && indexHasDesc(index) {
+				return nil, nil
+			}
+
+			return index, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func hasPrimaryIndex(indexes []datastore.Index) bool {
+	for _, index := range indexes {
+		if index.IsPrimary() {
+			return true
+		}
+	}
+	return false
+}
+
+// NO_COVER hint of the keyspace.
+func (this *builder) noCover(node *algebra.KeyspaceTerm) bool {
+	hint := this.optimHint(algebra.HINT_NO_COVER, node.Alias())
+	this.hintFollowed(hint)
+	return hint != nil
+}
+
+// Returns the hints followed, not followed, and invalid, for EXPLAIN.
+func (this *builder) optimHintsReport() map[string]interface{} {
+	if len(this.hintBlocks) == 0 {
+		return nil
+	}
+
+	followed := make([]interface{}, 0, 8)
+	notFollowed := make([]interface{}, 0, 8)
+	invalid := make([]interface{}, 0, 8)
+
+	for _, block := range this.hintBlocks {
+		for _, hint := range block.Hints() {
+			if hint.Invalid() != "" {
+				invalid = append(invalid, map[string]interface{}{
+					"hint":   hint.String(),
+					"reason": hint.Invalid(),
+				})
+			} else if state := this.hintStates[hint]; state != nil && state.followed {
+				followed = append(followed, hint.String())
+			} else if state != nil {
+				notFollowed = append(notFollowed, map[string]interface{}{
+					"hint":   hint.String(),
+					"reason": state.reason,
+				})
+			}
+		}
+	}
+
+	rv := make(map[string]interface{}, 3)
+	if len(followed) > 0 {
+		rv["hints_followed"] = followed
+	}
+	if len(notFollowed) > 0 {
+		rv["hints_not_followed"] = notFollowed
+	}
+	if len(invalid) > 0 {
+		rv["invalid_hints"] = invalid
+	}
+	return rv
+}
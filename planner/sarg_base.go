@@ -177,6 +177,14 @@ func (this *sarg) VisitFunction(pred expression.Function) (interface{}, error) {
 	switch pred := pred.(type) {
 	case *expression.RegexpLike:
 		return this.visitLike(pred)
+	case *expression.LikeEscape:
+		return this.visitLike(pred)
+	case *expression.Similar:
+		return this.visitLike(pred)
+	case *expression.ILike:
+		return this.visitILike(pred)
+	case expression.GeoPredicate:
+		return this.visitGeo(pred)
 	}
 
 	return this.visitDefault(pred)
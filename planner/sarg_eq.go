@@ -20,6 +20,11 @@ func (this *sarg) VisitEq(pred *expression.Eq) (interface{}, error) {
 		return _SELF_SPANS, nil
 	}
 
+	// index order is that of the default collation
+	if expression.OperandCollation(pred) != nil {
+		return this.visitDefault(pred)
+	}
+
 	var expr expression.Expression
 
 	if pred.First().EquivalentTo(this.key) {
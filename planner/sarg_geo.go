@@ -0,0 +1,69 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+)
+
+// the most geohash cells scanned for a spatial predicate
+const _GEO_MAX_CELLS = 32
+
+func (this *sarg) visitGeo(pred expression.GeoPredicate) (interface{}, error) {
+	if spans := geoSpans(pred, this.key); spans != nil {
+		return spans, nil
+	}
+
+	return this.visitDefault(pred)
+}
+
+/*
+A spatial predicate uses an index on ST_GEOHASH(geometry) as a scan
+of the geohash cells that cover the bounding box of its region. Every
+geometry within the region hashes to one of the cells, through its
+point or the center of its bounding box; the spans are inexact, and
+the predicate filters the keys they return.
+*/
+func geoSpans(pred expression.GeoPredicate, key expression.Expression) SargSpans {
+	geohash, ok := key.(*expression.GeoHash)
+	if !ok || !geohash.Operand().EquivalentTo(pred.Geometry()) {
+		return nil
+	}
+
+	precision, ok := geohash.Precision()
+	if !ok {
+		return nil
+	}
+
+	box, ok := pred.SearchBox()
+	if !ok {
+		return nil
+	}
+
+	cells := expression.GeoHashCover(box, precision, _GEO_MAX_CELLS)
+	if len(cells) == 0 {
+		return nil
+	}
+
+	spans := make([]*plan.Span2, 0, len(cells))
+	for _, cell := range cells {
+		// geohash characters are below the maximum byte value
+		high := []byte(cell)
+		high[len(high)-1]++
+
+		range2 := plan.NewRange2(expression.NewConstant(cell),
+			expression.NewConstant(string(high)), datastore.LOW)
+		spans = append(spans, plan.NewSpan2(nil, plan.Ranges2{range2}, false))
+	}
+
+	return NewTermSpans(spans...)
+}
@@ -0,0 +1,84 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+)
+
+func TestSargGeo(t *testing.T) {
+	u09 := `[{"range":[{"high":"\"u0:\"","inclusion":1,"low":"\"u09\""}]}]`
+
+	cases := []struct {
+		pred  string
+		key   string
+		spans string
+	}{
+		{`st_within(loc, [2.0, 48.0, 2.5, 49.0])`, `st_geohash(loc, 3)`, u09},
+		{`st_contains([2.0, 48.0, 2.5, 49.0], loc)`, `geohash(loc, 3)`, u09},
+		{`st_dwithin(loc, st_point(2.3522, 48.8566), 10000)`, `st_geohash(loc, 3)`, u09},
+		{`st_within(loc, [2.0, 48.0, 2.5, 49.0])`, `st_geohash(loc, 4)`,
+			`[{"range":[{"high":"\"u094\"","inclusion":1,"low":"\"u093\""}]},` +
+				`{"range":[{"high":"\"u097\"","inclusion":1,"low":"\"u096\""}]},` +
+				`{"range":[{"high":"\"u098\"","inclusion":1,"low":"\"u097\""}]},` +
+				`{"range":[{"high":"\"u09:\"","inclusion":1,"low":"\"u099\""}]},` +
+				`{"range":[{"high":"\"u09d\"","inclusion":1,"low":"\"u09c\""}]},` +
+				`{"range":[{"high":"\"u09e\"","inclusion":1,"low":"\"u09d\""}]},` +
+				`{"range":[{"high":"\"u09f\"","inclusion":1,"low":"\"u09e\""}]},` +
+				`{"range":[{"high":"\"u09g\"","inclusion":1,"low":"\"u09f\""}]},` +
+				`{"range":[{"high":"\"u09h\"","inclusion":1,"low":"\"u09g\""}]},` +
+				`{"range":[{"high":"\"u09l\"","inclusion":1,"low":"\"u09k\""}]},` +
+				`{"range":[{"high":"\"u09n\"","inclusion":1,"low":"\"u09m\""}]},` +
+				`{"range":[{"high":"\"u09r\"","inclusion":1,"low":"\"u09q\""}]},` +
+				`{"range":[{"high":"\"u09t\"","inclusion":1,"low":"\"u09s\""}]},` +
+				`{"range":[{"high":"\"u09u\"","inclusion":1,"low":"\"u09t\""}]},` +
+				`{"range":[{"high":"\"u09v\"","inclusion":1,"low":"\"u09u\""}]},` +
+				`{"range":[{"high":"\"u09w\"","inclusion":1,"low":"\"u09v\""}]},` +
+				`{"range":[{"high":"\"u09x\"","inclusion":1,"low":"\"u09w\""}]},` +
+				`{"range":[{"high":"\"u09z\"","inclusion":1,"low":"\"u09y\""}]}]`},
+		{`st_within(loc, [2.0, 48.0, 2.5, 49.0])`, `st_geohash(other, 3)`, ``},
+		{`st_within(loc, region)`, `st_geohash(loc, 3)`, ``},
+		{`st_intersects(loc, [2.0, 48.0, 2.5, 49.0])`, `st_geohash(loc, 3)`, ``},
+	}
+
+	for _, c := range cases {
+		pred, err := parser.Parse(c.pred)
+		if err != nil {
+			t.Fatalf("%s: %v", c.pred, err)
+		}
+
+		key, err := parser.Parse(c.key)
+		if err != nil {
+			t.Fatalf("%s: %v", c.key, err)
+		}
+
+		keys := expression.Expressions{key}
+		n, _ := SargableFor(pred, keys)
+		if c.spans == "" {
+			if n != 0 {
+				t.Errorf("%s on %s: expected not sargable", c.pred, c.key)
+			}
+			continue
+		}
+
+		spans, _, err := SargFor(pred, keys, n, false, "")
+		if err != nil {
+			t.Fatalf("%s: %v", c.pred, err)
+		}
+
+		expected := `{"#":"TermSpans","spans":` + c.spans + `}`
+		if spans == nil || spans.String() != expected {
+			t.Errorf("%s on %s: expected spans %s, received %v", c.pred, c.key, expected, spans)
+		}
+	}
+}
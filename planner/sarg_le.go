@@ -20,6 +20,11 @@ func (this *sarg) VisitLE(pred *expression.LE) (interface{}, error) {
 		return _SELF_SPANS, nil
 	}
 
+	// index order is that of the default collation
+	if expression.OperandCollation(pred) != nil {
+		return this.visitDefault(pred)
+	}
+
 	var expr expression.Expression
 	range2 := &plan.Range2{}
 
@@ -67,6 +67,41 @@ func (this *sarg) visitLike(pred expression.LikeFunction) (interface{}, error) {
 	return NewTermSpans(span), nil
 }
 
+/*
+ILIKE uses an index on LOWER(expr) or UPPER(expr) as the LIKE of
+the index key and the pattern folded in the same way.
+*/
+func (this *sarg) visitILike(pred *expression.ILike) (interface{}, error) {
+	if like := foldedLike(pred, this.key); like != nil {
+		return like.Accept(this)
+	}
+
+	return this.visitDefault(pred)
+}
+
+/*
+Returns the LIKE equivalent to the ILIKE on the key, if the key
+is LOWER() or UPPER() of the matched expression, or else nil.
+*/
+func foldedLike(pred *expression.ILike, key expression.Expression) expression.Function {
+	var operand expression.Expression
+
+	switch key := key.(type) {
+	case *expression.Lower:
+		operand = key.Operand()
+	case *expression.Upper:
+		operand = key.Operand()
+	default:
+		return nil
+	}
+
+	if !operand.EquivalentTo(pred.First()) {
+		return nil
+	}
+
+	return pred.FoldedLike(key.(expression.Function))
+}
+
 func likeSpans(pred expression.LikeFunction) SargSpans {
 	range2 := plan.NewRange2(expression.EMPTY_STRING_EXPR, expression.EMPTY_ARRAY_EXPR, datastore.LOW)
 
@@ -0,0 +1,64 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+)
+
+func TestSargLikeVariants(t *testing.T) {
+	cases := []struct {
+		pred  string
+		key   string
+		spans string
+	}{
+		{`x ilike "AbC%"`, `lower(x)`, `[{"exact":true,"range":[{"high":"\"abd\"","inclusion":1,"low":"\"abc\""}]}]`},
+		{`x ilike "AbC%"`, `upper(x)`, `[{"exact":true,"range":[{"high":"\"ABD\"","inclusion":1,"low":"\"ABC\""}]}]`},
+		{`x ilike "a!%b%" escape "!"`, `lower(x)`, `[{"exact":true,"range":[{"high":"\"a%c\"","inclusion":1,"low":"\"a%b\""}]}]`},
+		{`x like "a!%b%" escape "!"`, `x`, `[{"exact":true,"range":[{"high":"\"a%c\"","inclusion":1,"low":"\"a%b\""}]}]`},
+		{`x similar to "abc%"`, `x`, `[{"exact":true,"range":[{"high":"\"abd\"","inclusion":1,"low":"\"abc\""}]}]`},
+		{`x similar to "ab(c|d)%"`, `x`, `[{"range":[{"high":"\"ac\"","inclusion":1,"low":"\"ab\""}]}]`},
+		{`x ilike "AbC%"`, `lower(y)`, ``},
+	}
+
+	for _, c := range cases {
+		pred, err := parser.Parse(c.pred)
+		if err != nil {
+			t.Fatalf("%s: %v", c.pred, err)
+		}
+
+		key, err := parser.Parse(c.key)
+		if err != nil {
+			t.Fatalf("%s: %v", c.key, err)
+		}
+
+		keys := expression.Expressions{key}
+		n, _ := SargableFor(pred, keys)
+		if c.spans == "" {
+			if n != 0 {
+				t.Errorf("%s on %s: expected not sargable", c.pred, c.key)
+			}
+			continue
+		}
+
+		spans, _, err := SargFor(pred, keys, n, false, "")
+		if err != nil {
+			t.Fatalf("%s: %v", c.pred, err)
+		}
+
+		expected := `{"#":"TermSpans","spans":` + c.spans + `}`
+		if spans == nil || spans.String() != expected {
+			t.Errorf("%s on %s: expected spans %s, received %v", c.pred, c.key, expected, spans)
+		}
+	}
+}
@@ -226,6 +226,14 @@ func (this *sargable) VisitFunction(pred expression.Function) (interface{}, erro
 	switch pred := pred.(type) {
 	case *expression.RegexpLike:
 		return this.visitLike(pred)
+	case *expression.LikeEscape:
+		return this.visitLike(pred)
+	case *expression.Similar:
+		return this.visitLike(pred)
+	case *expression.ILike:
+		return this.visitILike(pred)
+	case expression.GeoPredicate:
+		return this.visitGeo(pred)
 	}
 
 	return this.visitDefault(pred)
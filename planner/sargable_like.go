@@ -18,3 +18,9 @@ func (this *sargable) visitLike(pred expression.LikeFunction) (bool, error) {
 			this.defaultSargable(pred),
 		nil
 }
+
+func (this *sargable) visitILike(pred *expression.ILike) (bool, error) {
+	return foldedLike(pred, this.key) != nil ||
+			this.defaultSargable(pred),
+		nil
+}
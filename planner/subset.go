@@ -179,6 +179,10 @@ func (this *subset) VisitFunction(expr expression.Function) (interface{}, error)
 	switch expr := expr.(type) {
 	case *expression.RegexpLike:
 		return this.visitLike(expr)
+	case *expression.LikeEscape:
+		return this.visitLike(expr)
+	case *expression.Similar:
+		return this.visitLike(expr)
 	}
 
 	return this.visitDefault(expr)
@@ -0,0 +1,440 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	atomic "github.com/couchbase/go-couchbase/platform"
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/planner"
+	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/value"
+	"github.com/couchbase/query/views"
+)
+
+// The ad-hoc plan cache holds plans for statements that have not been
+// explicitly prepared.
+// Statements are keyed by their normalized text, in which literals are
+// replaced by markers: if the plan does not depend on the literal values,
+// these are lifted into positional parameters and the same plan is shared
+// by all statements differing only in their literals.
+// If the plan does depend on the literals, the plan is cached for the
+// exact statement text only.
+
+const _ADHOC_PREFIX = "adhoc-"
+
+type adhocCache struct {
+	enabled    atomic.AlignedInt64
+	cache      *util.GenCache
+	unliftable *util.GenCache
+}
+
+type AdhocEntry struct {
+	CacheEntry
+	Literals int // number of literals lifted into parameters, -1 if exact text
+	Hits     int32
+	Misses   int32
+}
+
+var adhocs = &adhocCache{}
+
+// init ad-hoc plan cache
+
+func AdhocsInit(limit int, enabled bool) {
+	adhocs.cache = util.NewGenCache(limit)
+	adhocs.unliftable = util.NewGenCache(limit)
+	AdhocsSetEnabled(enabled)
+}
+
+// configure ad-hoc plan cache
+
+func AdhocsEnabled() bool {
+	return adhocs.cache != nil && atomic.LoadInt64(&adhocs.enabled) > 0
+}
+
+func AdhocsSetEnabled(enabled bool) {
+	var val int64
+
+	if enabled {
+		val = 1
+	}
+	atomic.StoreInt64(&adhocs.enabled, val)
+}
+
+func AdhocsLimit() int {
+	if adhocs.cache == nil {
+		return 0
+	}
+	return adhocs.cache.Limit()
+}
+
+func AdhocsSetLimit(limit int) {
+	if adhocs.cache == nil {
+		return
+	}
+	adhocs.cache.SetLimit(limit)
+	adhocs.unliftable.SetLimit(limit)
+}
+
+func CountAdhocs() int {
+	if adhocs.cache == nil {
+		return 0
+	}
+	return adhocs.cache.Size()
+}
+
+func NameAdhocs() []string {
+	if adhocs.cache == nil {
+		return []string{}
+	}
+	return adhocs.cache.Names()
+}
+
+func AdhocsForeach(nonBlocking func(string, *AdhocEntry) bool,
+	blocking func() bool) {
+	if adhocs.cache == nil {
+		return
+	}
+	dummyF := func(id string, r interface{}) bool {
+		return nonBlocking(id, r.(*AdhocEntry))
+	}
+	adhocs.cache.ForEach(dummyF, blocking)
+}
+
+func AdhocDo(name string, f func(*AdhocEntry)) bool {
+	var process func(interface{}) = nil
+
+	if adhocs.cache == nil {
+		return false
+	}
+	if f != nil {
+		process = func(entry interface{}) {
+			ae := entry.(*AdhocEntry)
+			f(ae)
+		}
+	}
+	return adhocs.cache.Get(name, process) != nil
+}
+
+func DeleteAdhoc(name string) errors.Error {
+	if adhocs.cache != nil && adhocs.cache.Delete(name, nil) {
+		return nil
+	}
+	return errors.NewNoSuchPreparedError(name)
+}
+
+// only DML statements are considered for caching
+func adhocStatement(stmt algebra.Statement) bool {
+	switch stmt.Type() {
+	case "SELECT", "INSERT", "UPSERT", "UPDATE", "DELETE", "MERGE":
+		return true
+	}
+	return false
+}
+
+// a statement can use the cache if it is DML and does not carry parameters
+// of its own: lifted literals must be the only parameters in the plan
+func AdhocCacheable(stmt algebra.Statement, normalized *n1ql.NormalizedText,
+	namedArgs map[string]value.Value, positionalArgs value.Values) bool {
+	return AdhocsEnabled() && normalized != nil && !normalized.HasParams &&
+		len(namedArgs) == 0 && len(positionalArgs) == 0 && adhocStatement(stmt)
+}
+
+// plans inline views: a different set of views needs a different plan
+// the kinds of the literals are part of the name of lifted plans, since the
+// plan for a string may not suit a number
+func adhocName(text string, kinds string, namespace string, indexApiVersion int, featureControls uint64) string {
+	h := sha1.New()
+
+	fmt.Fprintf(h, "%s\n%d\n%d\n%d\n%s\n%s", namespace, indexApiVersion, featureControls, views.Version(), kinds, text)
+	return _ADHOC_PREFIX + hex.EncodeToString(h.Sum(nil))
+}
+
+// Look for a cached plan for the statement.
+// On success, returns the plan and the values of the lifted literals, which
+// are to be used as positional arguments.
+// The literals are collected from the parsed statement, which is left
+// untouched for planning should the cached plan not be usable.
+func GetAdhoc(stmt algebra.Statement, text string, normalized *n1ql.NormalizedText,
+	namespace string, indexApiVersion int, featureControls uint64) (*plan.Prepared, value.Values) {
+	var args value.Values
+
+	name := adhocName(normalized.Text, normalized.Kinds, namespace, indexApiVersion, featureControls)
+	if normalized.Literals > 0 && adhocs.unliftable.Get(name, nil) != nil {
+		name = adhocName(text, "", namespace, indexApiVersion, featureControls)
+	}
+	ae, ok := adhocs.cache.Use(name, nil).(*AdhocEntry)
+	if !ok {
+		return nil, nil
+	}
+
+	if ae.Literals < 0 && ae.Prepared.Text() != text {
+		return nil, nil
+	}
+
+	if !ae.verify() {
+		return nil, nil
+	}
+
+	if ae.Literals > 0 {
+		var err error
+
+		args, err = liftLiterals(stmt, false)
+		if err != nil || len(args) != ae.Literals {
+			return nil, nil
+		}
+	}
+	atomic.AddInt32(&ae.Uses, 1)
+	atomic.AddInt32(&ae.Hits, 1)
+
+	// see preparedCache.get()
+	ae.LastUse = time.Now()
+	return ae.Prepared, args
+}
+
+// Cache a newly built plan for the statement.
+// If the statement has literals, see if a plan with the literals lifted into
+// parameters is equivalent: if so, cache that, otherwise cache the original
+// plan for the exact statement text.
+func AddAdhoc(prepared *plan.Prepared, text string, normalized *n1ql.NormalizedText,
+	namespace string, indexApiVersion int, featureControls uint64,
+	store, systemstore datastore.Datastore) {
+
+	name := adhocName(normalized.Text, normalized.Kinds, namespace, indexApiVersion, featureControls)
+	literals := 0
+	if normalized.Literals > 0 {
+		if adhocs.unliftable.Get(name, nil) == nil {
+			lifted, args := liftPlan(prepared, text, normalized, namespace, indexApiVersion,
+				featureControls, store, systemstore)
+			if lifted != nil {
+				prepared = lifted
+				literals = len(args)
+			}
+		}
+		if literals == 0 {
+			adhocs.unliftable.Add(true, name, nil)
+			name = adhocName(text, "", namespace, indexApiVersion, featureControls)
+			literals = -1
+		}
+	}
+
+	prepared.SetName(name)
+	prepared.SetIndexApiVersion(indexApiVersion)
+	prepared.SetFeatureControls(featureControls)
+
+	ae := &AdhocEntry{
+		CacheEntry: CacheEntry{
+			Prepared:       prepared,
+			MinServiceTime: math.MaxUint64,
+			MinRequestTime: math.MaxUint64,
+		},
+		Literals: literals,
+		Misses:   1,
+	}
+	adhocs.cache.Add(ae, name, func(entry interface{}) util.Operation {
+
+		// the old plan is stale, amend it and keep the stats
+		oldEntry := entry.(*AdhocEntry)
+		oldEntry.Lock()
+		oldEntry.Prepared = prepared
+		oldEntry.Literals = literals
+		oldEntry.populated = false
+		oldEntry.Unlock()
+		atomic.AddInt32(&oldEntry.Misses, 1)
+		return util.AMEND
+	})
+}
+
+// build the plan for the statement with its literals lifted, and check it
+// against the plan for the original statement
+// the planner rewrites the statements it plans, so the literals are lifted
+// from a fresh copy of the statement
+func liftPlan(prepared *plan.Prepared, text string, normalized *n1ql.NormalizedText,
+	namespace string, indexApiVersion int, featureControls uint64,
+	store, systemstore datastore.Datastore) (*plan.Prepared, value.Values) {
+
+	stmt, err := n1ql.ParseStatement(text)
+	if err != nil {
+		return nil, nil
+	}
+	args, err := liftLiterals(stmt, true)
+
+	// some literals are not part of expressions
+	if err != nil || len(args) != normalized.Literals {
+		return nil, nil
+	}
+	lifted, err := planner.BuildPrepared(stmt, store, systemstore, namespace, false,
+		nil, nil, indexApiVersion, featureControls)
+	if err != nil || !samePlan(prepared, lifted, args) {
+		return nil, nil
+	}
+	lifted.SetType(stmt.Type())
+	lifted.SetText(normalized.Text)
+	return lifted, args
+}
+
+// the plans are the same if they only differ in expressions, and
+// substituting the lifted parameters with their values in the expressions
+// of the lifted plan yields those of the original plan
+func samePlan(prepared, lifted *plan.Prepared, args value.Values) bool {
+	sig1, err := json.Marshal(prepared.Signature())
+	if err != nil {
+		return false
+	}
+	sig2, err := json.Marshal(lifted.Signature())
+	if err != nil || !bytes.Equal(sig1, sig2) {
+		return false
+	}
+	plan1, err := decodePlan(prepared.Operator)
+	if err != nil {
+		return false
+	}
+	plan2, err := decodePlan(lifted.Operator)
+	if err != nil {
+		return false
+	}
+	return sameOperator(plan1, plan2, newParameterSetter(args))
+}
+
+func decodePlan(op plan.Operator) (interface{}, error) {
+	var rv interface{}
+
+	data, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&rv)
+	return rv, err
+}
+
+// compare the decoded operators field by field
+// strings that differ must both be expressions
+func sameOperator(op1, op2 interface{}, setter *parameterSetter) bool {
+	switch op1 := op1.(type) {
+	case map[string]interface{}:
+		other, ok := op2.(map[string]interface{})
+		if !ok || len(op1) != len(other) {
+			return false
+		}
+		for k, v := range op1 {
+			w, ok := other[k]
+			if !ok || !sameOperator(v, w, setter) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		other, ok := op2.([]interface{})
+		if !ok || len(op1) != len(other) {
+			return false
+		}
+		for i, v := range op1 {
+			if !sameOperator(v, other[i], setter) {
+				return false
+			}
+		}
+		return true
+	case string:
+		other, ok := op2.(string)
+		if !ok {
+			return false
+		}
+		return op1 == other || sameExpression(op1, other, setter)
+	default:
+		return reflect.DeepEqual(op1, op2)
+	}
+}
+
+func sameExpression(text1, text2 string, setter *parameterSetter) bool {
+	expr1, err := n1ql.ParseExpression(text1)
+	if err != nil {
+		return false
+	}
+	expr2, err := n1ql.ParseExpression(text2)
+	if err != nil {
+		return false
+	}
+	expr2, err = setter.Map(expr2)
+	return err == nil && expr1.EquivalentTo(expr2)
+}
+
+// replaces positional parameters with the values of the lifted literals
+type parameterSetter struct {
+	expression.MapperBase
+
+	values value.Values
+}
+
+func newParameterSetter(values value.Values) *parameterSetter {
+	rv := &parameterSetter{values: values}
+	rv.SetMapper(rv)
+	return rv
+}
+
+func (this *parameterSetter) VisitPositionalParameter(expr expression.PositionalParameter) (interface{}, error) {
+	pos := expr.Position()
+	if pos < 1 || pos > len(this.values) {
+		return expr, nil
+	}
+	return expression.NewConstant(this.values[pos-1]), nil
+}
+
+// return the values of the string and numeric constants of the statement,
+// in the order in which they are lifted
+// if lift is set, the constants are replaced with positional parameters,
+// otherwise the statement is left as it is
+func liftLiterals(stmt algebra.Statement, lift bool) (value.Values, error) {
+	lifter := newLiteralLifter(lift)
+	err := stmt.MapExpressions(lifter)
+	if err != nil {
+		return nil, err
+	}
+	return lifter.values, nil
+}
+
+type literalLifter struct {
+	expression.MapperBase
+
+	lift   bool
+	values value.Values
+}
+
+func newLiteralLifter(lift bool) *literalLifter {
+	rv := &literalLifter{lift: lift}
+	rv.SetMapper(rv)
+	return rv
+}
+
+func (this *literalLifter) VisitConstant(expr *expression.Constant) (interface{}, error) {
+	val := expr.Value()
+	switch val.Type() {
+	case value.STRING, value.NUMBER:
+		this.values = append(this.values, val)
+		if this.lift {
+			return algebra.NewPositionalParameter(len(this.values)), nil
+		}
+	}
+	return expr, nil
+}
@@ -0,0 +1,167 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/distributed"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/metastore"
+	"github.com/couchbase/query/plan"
+)
+
+// Prepared statements are persisted, so that they survive restarts.
+// They are distributed to the other nodes as they are prepared, rather
+// than through the store.
+
+const (
+	_PRIME_RETRIES  = 12
+	_PRIME_INTERVAL = 5 * time.Second
+)
+
+type storeEntry struct {
+	Name            string `json:"name"`
+	EncodedPlan     string `json:"encoded_plan"`
+	Statement       string `json:"statement"`
+	Type            string `json:"reqType"`
+	IndexApiVersion int    `json:"indexApiVersion"`
+	FeatureControls uint64 `json:"featureControls"`
+}
+
+var persisted = metastore.NewStore("prepareds", errors.NewPreparedStoreError, nil)
+
+// init prepared statement store
+// the spec is one of dir:<path>, keyspace:[<namespace>:]<keyspace> or none
+
+func PreparedsPersistInit(spec string, ds datastore.Datastore, ns string) errors.Error {
+	return persisted.Init(spec, ds, ns)
+}
+
+// reload persisted statements into the cache
+// plans are only decoded here: verification, and reprepare if required,
+// happens when they are first executed
+
+func PreparedsLoad() {
+	if !persisted.Persisted() {
+		return
+	}
+	count := 0
+	err := persisted.Load(func(key string, data []byte) {
+		entry := &storeEntry{}
+		err1 := json.Unmarshal(data, entry)
+		if err1 != nil {
+			logging.Infof("cannot decode prepared statement %v: %v", key, err1)
+			return
+		}
+		prepared, err := decodePrepared(entry.EncodedPlan)
+
+		// the plan refers to objects that no longer exist, so we have to
+		// plan again now
+		if err != nil {
+			prepared = plan.NewPrepared(nil, nil)
+			prepared.SetName(entry.Name)
+			prepared.SetText(entry.Statement)
+			prepared.SetType(entry.Type)
+			prepared.SetIndexApiVersion(entry.IndexApiVersion)
+			prepared.SetFeatureControls(entry.FeatureControls)
+			prepared, err = reprepare(prepared, nil)
+			if err != nil {
+				logging.Infof("failed to reload prepared statement %v: %v", entry.Name, err)
+				return
+			}
+		}
+		if prepared.Name() != entry.Name {
+			logging.Infof("failed to reload prepared statement %v: name mismatch", entry.Name)
+			return
+		}
+		prepareds.add(prepared, false, func(ce *CacheEntry) bool {
+			return false
+		})
+		count++
+	})
+	if err != nil {
+		logging.Errorf("failed to reload prepared statements: %v", err)
+	}
+	logging.Infof("reloaded %v prepared statements", count)
+}
+
+// fetch prepared statements not yet known to this node from the other
+// nodes in the cluster
+// this is done in the background, as cluster membership may not have
+// been established yet
+
+func PreparedsRemotePrime() {
+	go func() {
+		for i := 0; i < _PRIME_RETRIES; i++ {
+			if distributed.RemoteAccess().WhoAmI() != "" {
+				remotePrime()
+				return
+			}
+			time.Sleep(_PRIME_INTERVAL)
+		}
+	}()
+}
+
+func remotePrime() {
+	count := 0
+	distributed.RemoteAccess().GetRemoteKeys([]string{}, "prepareds", func(id string) bool {
+		node, name := distributed.RemoteAccess().SplitKey(id)
+		if prepareds.cache.Get(name, nil) != nil {
+			return true
+		}
+		distributed.RemoteAccess().GetRemoteDoc(node, name, "prepareds", "GET",
+			func(doc map[string]interface{}) {
+				encoded_plan, ok := doc["encoded_plan"].(string)
+				if ok {
+					_, err := DecodePrepared(name, encoded_plan, false, false, nil)
+					if err == nil {
+						count++
+					}
+				}
+			},
+			func(warn errors.Error) {
+			}, distributed.NO_CREDS, "")
+		return true
+	}, func(warn errors.Error) {
+		logging.Infof("failed to fetch remote prepared statements: %v", warn)
+	})
+	logging.Infof("fetched %v prepared statements from the cluster", count)
+}
+
+func persistPrepared(prepared *plan.Prepared) {
+	if !persisted.Persisted() || prepared.Name() == "" {
+		return
+	}
+	err := persisted.Save(prepared.Name(), &storeEntry{
+		Name:            prepared.Name(),
+		EncodedPlan:     prepared.EncodedPlan(),
+		Statement:       prepared.Text(),
+		Type:            prepared.Type(),
+		IndexApiVersion: prepared.IndexApiVersion(),
+		FeatureControls: prepared.FeatureControls(),
+	})
+	if err != nil {
+		logging.Infof("failed to persist prepared statement %v: %v", prepared.Name(), err)
+	}
+}
+
+func unpersistPrepared(name string) {
+	if !persisted.Persisted() {
+		return
+	}
+	err := persisted.Remove(name)
+	if err != nil {
+		logging.Infof("failed to remove persisted prepared statement %v: %v", name, err)
+	}
+}
@@ -184,6 +184,7 @@ func AddPrepared(prepared *plan.Prepared) errors.Error {
 		return errors.NewPreparedNameError(
 			fmt.Sprintf("duplicate name: %s", prepared.Name()))
 	} else {
+		persistPrepared(prepared)
 		distributePrepared(prepared.Name(), prepared.EncodedPlan())
 		return nil
 	}
@@ -191,6 +192,7 @@ func AddPrepared(prepared *plan.Prepared) errors.Error {
 
 func DeletePrepared(name string) errors.Error {
 	if prepareds.cache.Delete(name, nil) {
+		unpersistPrepared(name)
 		return nil
 	}
 	return errors.NewNoSuchPreparedError(name)
@@ -222,41 +224,7 @@ func GetPrepared(prepared_stmt value.Value, options uint32, phaseTime *time.Dura
 				func(warn errors.Error) {
 				}, distributed.NO_CREDS, "")
 		} else if prepared != nil && verify {
-			var good bool
-
-			// things have already been set up
-			// take the short way home
-			if ce.populated {
-
-				// note that it's fine to check and repopulate without a lock
-				// since the structure of the plan tree won't change, nor the
-				// keyspaces and indexers, the worse that is going to happen is
-				// two requests amending the same counter
-				good = prepared.MetadataCheck()
-
-				// counters have changed. fetch new values
-				if !good {
-					good = prepared.Verify()
-				}
-			} else {
-
-				// we have to proceed under a lock to avoid multiple
-				// requests populating metadata counters at the same time
-				ce.Lock()
-
-				// check again, somebody might have done it in the interim
-				if ce.populated {
-					good = true
-				} else {
-
-					// nada - have to go the long way
-					good = prepared.Verify()
-					if good {
-						ce.populated = true
-					}
-				}
-				ce.Unlock()
-			}
+			good := ce.verify()
 
 			// after all this, it did not work out!
 			// here we are going to accept multiple requests creating a new
@@ -295,6 +263,46 @@ func GetPrepared(prepared_stmt value.Value, options uint32, phaseTime *time.Dura
 	}
 }
 
+// check that the plan of an entry is still valid
+func (this *CacheEntry) verify() bool {
+	var good bool
+
+	// things have already been set up
+	// take the short way home
+	if this.populated {
+
+		// note that it's fine to check and repopulate without a lock
+		// since the structure of the plan tree won't change, nor the
+		// keyspaces and indexers, the worse that is going to happen is
+		// two requests amending the same counter
+		good = this.Prepared.MetadataCheck()
+
+		// counters have changed. fetch new values
+		if !good {
+			good = this.Prepared.Verify()
+		}
+	} else {
+
+		// we have to proceed under a lock to avoid multiple
+		// requests populating metadata counters at the same time
+		this.Lock()
+
+		// check again, somebody might have done it in the interim
+		if this.populated {
+			good = true
+		} else {
+
+			// nada - have to go the long way
+			good = this.Prepared.Verify()
+			if good {
+				this.populated = true
+			}
+		}
+		this.Unlock()
+	}
+	return good
+}
+
 func RecordPreparedMetrics(prepared *plan.Prepared, requestTime, serviceTime time.Duration) {
 	if prepared == nil {
 		return
@@ -324,26 +332,10 @@ func RecordPreparedMetrics(prepared *plan.Prepared, requestTime, serviceTime tim
 func DecodePrepared(prepared_name string, prepared_stmt string, track bool, distribute bool, phaseTime *time.Duration) (*plan.Prepared, errors.Error) {
 	added := true
 
-	decoded, err := base64.StdEncoding.DecodeString(prepared_stmt)
+	prepared, err := decodePrepared(prepared_stmt)
 	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
-	}
-	var buf bytes.Buffer
-	buf.Write(decoded)
-	reader, err := gzip.NewReader(&buf)
-	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
+		return nil, err
 	}
-	prepared_bytes, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
-	}
-	prepared, err := unmarshalPrepared(prepared_bytes)
-	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
-	}
-
-	prepared.SetEncodedPlan(prepared_stmt)
 
 	// MB-19509 we now have to check that the encoded plan matches
 	// the prepared statement named in the rest API
@@ -397,6 +389,7 @@ func DecodePrepared(prepared_name string, prepared_stmt string, track bool, dist
 		})
 
 	if added {
+		persistPrepared(prepared)
 		if distribute {
 			distributePrepared(prepared.Name(), prepared_stmt)
 		}
@@ -406,6 +399,30 @@ func DecodePrepared(prepared_name string, prepared_stmt string, track bool, dist
 	}
 }
 
+func decodePrepared(prepared_stmt string) (*plan.Prepared, errors.Error) {
+	decoded, err := base64.StdEncoding.DecodeString(prepared_stmt)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+	var buf bytes.Buffer
+	buf.Write(decoded)
+	reader, err := gzip.NewReader(&buf)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+	prepared_bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+	prepared, err := unmarshalPrepared(prepared_bytes)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+
+	prepared.SetEncodedPlan(prepared_stmt)
+	return prepared, nil
+}
+
 func unmarshalPrepared(bytes []byte) (*plan.Prepared, errors.Error) {
 	prepared := plan.NewPrepared(nil, nil)
 	err := prepared.UnmarshalJSON(bytes)
@@ -0,0 +1,263 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Text is analyzed into terms: it is split into words at anything
+// other than letters and digits, and the words are lower cased and
+// stemmed. Documents and queries are analyzed in the same way, so
+// that "Running" in a query matches "runs" in a document.
+
+func analyze(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = Stem(strings.ToLower(word))
+	}
+
+	return words
+}
+
+// Stem returns the stem of a lower case English word, following the
+// Porter stemming algorithm. Words that are not made of ASCII letters,
+// and words of up to two letters, are their own stem.
+
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word)}
+	s.step1ab()
+	if len(s.b) > 2 {
+		s.step1c()
+		s.step2()
+		s.step3()
+		s.step4()
+		s.step5()
+	}
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+// true if b[i] is a consonant
+func (this *stemmer) cons(i int) bool {
+	switch this.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !this.cons(i-1)
+	}
+	return true
+}
+
+// the number of vowel-consonant sequences in b[:n]
+func (this *stemmer) measure(n int) int {
+	m := 0
+	i := 0
+	for i < n && this.cons(i) {
+		i++
+	}
+
+	for i < n {
+		for i < n && !this.cons(i) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		m++
+		for i < n && this.cons(i) {
+			i++
+		}
+	}
+	return m
+}
+
+// true if b[:n] contains a vowel
+func (this *stemmer) vowelIn(n int) bool {
+	for i := 0; i < n; i++ {
+		if !this.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// true if b[:n] ends with a double consonant
+func (this *stemmer) doubleCons(n int) bool {
+	return n >= 2 && this.b[n-1] == this.b[n-2] && this.cons(n-1)
+}
+
+// true if b[:n] ends with consonant-vowel-consonant, where the last
+// consonant is not w, x or y
+func (this *stemmer) cvc(n int) bool {
+	if n < 3 || !this.cons(n-1) || this.cons(n-2) || !this.cons(n-3) {
+		return false
+	}
+
+	switch this.b[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func (this *stemmer) ends(suffix string) bool {
+	return len(this.b) >= len(suffix) && string(this.b[len(this.b)-len(suffix):]) == suffix
+}
+
+func (this *stemmer) replace(suffix, repl string) {
+	this.b = append(this.b[:len(this.b)-len(suffix)], repl...)
+}
+
+// replace the longest of the suffixes that the word ends with, if the
+// measure of the stem is above min
+func (this *stemmer) replaceLongest(suffixes [][2]string, min int) {
+	best := -1
+	for i, s := range suffixes {
+		if this.ends(s[0]) && (best < 0 || len(s[0]) > len(suffixes[best][0])) {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		return
+	}
+
+	suffix := suffixes[best][0]
+	stem := len(this.b) - len(suffix)
+	if this.measure(stem) <= min {
+		return
+	}
+
+	if suffix == "ion" && (stem == 0 || (this.b[stem-1] != 's' && this.b[stem-1] != 't')) {
+		return
+	}
+
+	this.replace(suffix, suffixes[best][1])
+}
+
+// plurals and -ed or -ing
+func (this *stemmer) step1ab() {
+	switch {
+	case this.ends("sses"):
+		this.replace("sses", "ss")
+	case this.ends("ies"):
+		this.replace("ies", "i")
+	case this.ends("ss"):
+	case this.ends("s"):
+		this.replace("s", "")
+	}
+
+	if this.ends("eed") {
+		if this.measure(len(this.b)-3) > 0 {
+			this.replace("eed", "ee")
+		}
+		return
+	}
+
+	switch {
+	case this.ends("ed") && this.vowelIn(len(this.b)-2):
+		this.replace("ed", "")
+	case this.ends("ing") && this.vowelIn(len(this.b)-3):
+		this.replace("ing", "")
+	default:
+		return
+	}
+
+	n := len(this.b)
+	switch {
+	case this.ends("at"), this.ends("bl"), this.ends("iz"):
+		this.b = append(this.b, 'e')
+	case this.doubleCons(n):
+		switch this.b[n-1] {
+		case 'l', 's', 'z':
+		default:
+			this.b = this.b[:n-1]
+		}
+	case this.measure(n) == 1 && this.cvc(n):
+		this.b = append(this.b, 'e')
+	}
+}
+
+// terminal y to i, when there is another vowel in the stem
+func (this *stemmer) step1c() {
+	if this.ends("y") && this.vowelIn(len(this.b)-1) {
+		this.b[len(this.b)-1] = 'i'
+	}
+}
+
+var _STEP2 = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+// double suffixes to single ones
+func (this *stemmer) step2() {
+	this.replaceLongest(_STEP2, 0)
+}
+
+var _STEP3 = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+// -ic-, -full, -ness
+func (this *stemmer) step3() {
+	this.replaceLongest(_STEP3, 0)
+}
+
+var _STEP4 = [][2]string{
+	{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""},
+	{"able", ""}, {"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""},
+	{"ent", ""}, {"ion", ""}, {"ou", ""}, {"ism", ""}, {"ate", ""},
+	{"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+}
+
+// -ant, -ence and the like, in longer words
+func (this *stemmer) step4() {
+	this.replaceLongest(_STEP4, 1)
+}
+
+// final -e, and -ll
+func (this *stemmer) step5() {
+	n := len(this.b)
+	if this.b[n-1] == 'e' {
+		m := this.measure(n - 1)
+		if m > 1 || (m == 1 && !this.cvc(n-1)) {
+			this.b = this.b[:n-1]
+			n--
+		}
+	}
+
+	if this.b[n-1] == 'l' && this.doubleCons(n) && this.measure(n) > 1 {
+		this.b = this.b[:n-1]
+	}
+}
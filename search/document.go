@@ -0,0 +1,77 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"github.com/couchbase/query/value"
+)
+
+// A document is the text of a value: the terms of its strings, with
+// the path of the field that holds them and their position in the
+// field. Array elements belong to the field of the array; phrases do
+// not span elements.
+
+// the gap in positions between the strings of a field
+const _POSITION_GAP = 100
+
+type occurrence struct {
+	field    string
+	position int
+}
+
+type Document struct {
+	terms  map[string][]occurrence
+	length int
+}
+
+func NewDocument(v value.Value) *Document {
+	rv := &Document{terms: make(map[string][]occurrence)}
+	rv.add("", v, make(map[string]int))
+	return rv
+}
+
+func (this *Document) add(field string, v value.Value, next map[string]int) {
+	switch v.Type() {
+	case value.STRING:
+		position := next[field]
+		for _, term := range analyze(v.Actual().(string)) {
+			this.terms[term] = append(this.terms[term], occurrence{field, position})
+			position++
+			this.length++
+		}
+		next[field] = position + _POSITION_GAP
+	case value.ARRAY:
+		for _, e := range v.Actual().([]interface{}) {
+			this.add(field, value.NewValue(e), next)
+		}
+	case value.OBJECT:
+		for name, f := range v.Fields() {
+			if field != "" {
+				name = field + "." + name
+			}
+			this.add(name, value.NewValue(f), next)
+		}
+	}
+}
+
+// the number of terms
+func (this *Document) Length() int {
+	return this.length
+}
+
+func (this *Document) occurrences(term string) []occurrence {
+	return this.terms[term]
+}
+
+func (this *Document) dictionary(f func(term string)) {
+	for term, _ := range this.terms {
+		f(term)
+	}
+}
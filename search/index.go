@@ -0,0 +1,186 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// An index is an inverted index of documents by key. Hits are scored
+// with BM25.
+
+const (
+	_K1 = 1.2
+	_B  = 0.75
+)
+
+type Hit struct {
+	Key   string
+	Score float64
+}
+
+type Index struct {
+	sync.RWMutex
+	postings map[string]map[string][]occurrence
+	lengths  map[string]int
+	total    int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[string][]occurrence),
+		lengths:  make(map[string]int),
+	}
+}
+
+// Add indexes a document, replacing the one with the same key.
+
+func (this *Index) Add(key string, doc *Document) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.remove(key)
+	for term, occurrences := range doc.terms {
+		postings, ok := this.postings[term]
+		if !ok {
+			postings = make(map[string][]occurrence)
+			this.postings[term] = postings
+		}
+		postings[key] = occurrences
+	}
+	this.lengths[key] = doc.length
+	this.total += doc.length
+}
+
+func (this *Index) Remove(key string) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.remove(key)
+}
+
+func (this *Index) remove(key string) {
+	length, ok := this.lengths[key]
+	if !ok {
+		return
+	}
+
+	for term, postings := range this.postings {
+		if _, ok := postings[key]; ok {
+			delete(postings, key)
+			if len(postings) == 0 {
+				delete(this.postings, term)
+			}
+		}
+	}
+	delete(this.lengths, key)
+	this.total -= length
+}
+
+// the number of documents
+func (this *Index) Count() int {
+	this.RLock()
+	defer this.RUnlock()
+
+	return len(this.lengths)
+}
+
+// Search returns the documents that match the query, by descending
+// score.
+
+func (this *Index) Search(q *Query) []Hit {
+	this.RLock()
+	defer this.RUnlock()
+
+	n := float64(len(this.lengths))
+	if n == 0 {
+		return nil
+	}
+	average := float64(this.total) / n
+
+	idf := func(term string) float64 {
+		df := float64(len(this.postings[term]))
+		return math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+
+	dictionary := func(f func(term string)) {
+		for term, _ := range this.postings {
+			f(term)
+		}
+	}
+
+	hits := []Hit{}
+	for key, _ := range this.candidates(q, dictionary) {
+		terms := func(term string) []occurrence {
+			return this.postings[term][key]
+		}
+
+		length := float64(this.lengths[key])
+		weight := func(terms []string, count int) float64 {
+			if count == 0 {
+				return 0
+			}
+
+			w := 0.0
+			for _, term := range terms {
+				w += idf(term)
+			}
+			tf := float64(count)
+			return w * tf * (_K1 + 1) / (tf + _K1*(1-_B+_B*length/average))
+		}
+
+		score := q.score(terms, dictionary, weight)
+		if score >= 0 {
+			hits = append(hits, Hit{key, score})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key < hits[j].Key
+	})
+	return hits
+}
+
+// the documents that contain a term of the required clauses, or of
+// any clause if none are required
+func (this *Index) candidates(q *Query, dictionary func(f func(term string))) map[string]bool {
+	must := false
+	for _, c := range q.clauses {
+		if c.occur == _MUST {
+			must = true
+			break
+		}
+	}
+
+	rv := make(map[string]bool)
+	for _, c := range q.clauses {
+		if c.occur == _MUST_NOT || (must && c.occur != _MUST) {
+			continue
+		}
+
+		add := func(term string) {
+			for key, _ := range this.postings[term] {
+				rv[key] = true
+			}
+		}
+
+		if len(c.terms) > 1 || (c.fuzziness == 0 && !c.prefix) {
+			add(c.terms[0])
+		} else {
+			c.expand(dictionary, add)
+		}
+	}
+	return rv
+}
@@ -0,0 +1,347 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A query is a list of clauses separated by spaces. A clause is a word,
+// a "quoted phrase", a word~ or word~2 that also matches words one or
+// two edits away, or a prefix* that matches the words that start with
+// it. A clause may be restricted to a field, as in title:word, and
+// may be required with + or excluded with -. A document matches if it
+// matches all the required clauses and none of the excluded ones, and
+// at least one of the others if nothing is required.
+
+const MAX_FUZZINESS = 2
+
+type occur int
+
+const (
+	_SHOULD = occur(iota)
+	_MUST
+	_MUST_NOT
+)
+
+type Query struct {
+	text    string
+	clauses []*clause
+}
+
+type clause struct {
+	occur     occur
+	field     string
+	terms     []string // more than one for a phrase
+	fuzziness int
+	prefix    bool
+}
+
+func Parse(text string) (*Query, error) {
+	rv := &Query{text: text}
+	s := text
+
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+
+		c := &clause{}
+		switch s[0] {
+		case '+':
+			c.occur = _MUST
+			s = s[1:]
+		case '-':
+			c.occur = _MUST_NOT
+			s = s[1:]
+		}
+
+		// field
+		if i := strings.IndexAny(s, ": \t\r\n\""); i > 0 && s[i] == ':' {
+			c.field = s[:i]
+			s = s[i+1:]
+		}
+
+		var word string
+		if strings.HasPrefix(s, "\"") {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated phrase in %s", text)
+			}
+			word = s[1 : end+1]
+			s = s[end+2:]
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			word = s[:end]
+			s = s[end:]
+
+			if i := strings.LastIndexByte(word, '~'); i >= 0 {
+				c.fuzziness = 1
+				if i < len(word)-1 {
+					f, err := strconv.Atoi(word[i+1:])
+					if err != nil || f < 0 || f > MAX_FUZZINESS {
+						return nil, fmt.Errorf("invalid fuzziness in %s", word)
+					}
+					c.fuzziness = f
+				}
+				word = word[:i]
+			} else if strings.HasSuffix(word, "*") {
+				c.prefix = true
+				word = strings.TrimRight(word, "*")
+			}
+		}
+
+		if c.prefix {
+			// prefixes are matched as typed, since stems may be longer
+			c.terms = []string{strings.ToLower(word)}
+			if c.terms[0] == "" || strings.IndexFunc(c.terms[0], func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			}) >= 0 {
+				return nil, fmt.Errorf("invalid prefix in %s", text)
+			}
+		} else {
+			c.terms = analyze(word)
+		}
+
+		if len(c.terms) == 0 {
+			if word == "" {
+				return nil, fmt.Errorf("empty clause in %s", text)
+			}
+			continue
+		}
+
+		if len(c.terms) > 1 && c.fuzziness > 0 {
+			return nil, fmt.Errorf("phrases cannot be fuzzy in %s", text)
+		}
+
+		rv.clauses = append(rv.clauses, c)
+	}
+
+	if len(rv.clauses) == 0 {
+		return nil, fmt.Errorf("no terms in %s", text)
+	}
+
+	return rv, nil
+}
+
+func (this *Query) String() string {
+	return this.text
+}
+
+// Matches returns true if the document matches the query.
+
+func (this *Query) Matches(doc *Document) bool {
+	return this.score(doc.occurrences, doc.dictionary, nil) >= 0
+}
+
+// The score of a document, or -1 if it does not match. terms returns
+// the occurrences of a term in the document, and dictionary enumerates
+// the terms that fuzzy and prefix clauses may match. weight returns the
+// score of a number of occurrences of a term, or of a phrase; the score
+// is the number of matching clauses if weight is nil.
+
+func (this *Query) score(terms func(term string) []occurrence, dictionary func(f func(term string)),
+	weight func(terms []string, count int) float64) float64 {
+	score := 0.0
+	should := false
+	must := false
+
+	for _, c := range this.clauses {
+		s := c.score(terms, dictionary, weight)
+		switch c.occur {
+		case _MUST:
+			if s <= 0 {
+				return -1
+			}
+			must = true
+		case _MUST_NOT:
+			if s > 0 {
+				return -1
+			}
+			continue
+		default:
+			if s > 0 {
+				should = true
+			}
+		}
+		score += s
+	}
+
+	if !must && !should {
+		return -1
+	}
+
+	return score
+}
+
+func (this *clause) score(terms func(term string) []occurrence, dictionary func(f func(term string)),
+	weight func(terms []string, count int) float64) float64 {
+	if weight == nil {
+		weight = func(terms []string, count int) float64 {
+			if count > 0 {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if len(this.terms) > 1 {
+		return weight(this.terms, this.phrases(terms))
+	}
+
+	if this.fuzziness == 0 && !this.prefix {
+		return weight(this.terms, this.count(terms(this.terms[0])))
+	}
+
+	score := 0.0
+	this.expand(dictionary, func(term string) {
+		s := weight([]string{term}, this.count(terms(term)))
+		if term != this.terms[0] {
+			// near matches are worth less
+			s /= 2
+		}
+		score += s
+	})
+	return score
+}
+
+// enumerate the terms of the dictionary that the single term clause
+// matches
+func (this *clause) expand(dictionary func(f func(term string)), f func(term string)) {
+	term := this.terms[0]
+	dictionary(func(t string) {
+		if this.prefix {
+			if strings.HasPrefix(t, term) {
+				f(t)
+			}
+		} else if editDistance(term, t, this.fuzziness) <= this.fuzziness {
+			f(t)
+		}
+	})
+}
+
+// the number of occurrences in the field of the clause
+func (this *clause) count(occurrences []occurrence) int {
+	if this.field == "" {
+		return len(occurrences)
+	}
+
+	n := 0
+	for _, o := range occurrences {
+		if this.inField(o.field) {
+			n++
+		}
+	}
+	return n
+}
+
+func (this *clause) inField(field string) bool {
+	return this.field == "" || field == this.field ||
+		(strings.HasPrefix(field, this.field) && field[len(this.field)] == '.')
+}
+
+// the number of occurrences of the phrase, as consecutive terms in
+// the same field
+func (this *clause) phrases(terms func(term string) []occurrence) int {
+	type position struct {
+		field    string
+		position int
+	}
+
+	var starts map[position]bool
+	for i, term := range this.terms {
+		next := make(map[position]bool)
+		for _, o := range terms(term) {
+			if !this.inField(o.field) {
+				continue
+			}
+
+			p := position{o.field, o.position - i}
+			if i == 0 || starts[p] {
+				next[p] = true
+			}
+		}
+
+		if len(next) == 0 {
+			return 0
+		}
+		starts = next
+	}
+
+	return len(starts)
+}
+
+// the Levenshtein distance between a and b, or max+1 if it exceeds max
+func editDistance(a, b string, max int) int {
+	if a == b {
+		return 0
+	}
+
+	ra := []rune(a)
+	rb := []rune(b)
+	if abs(len(ra)-len(rb)) > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		least := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minimum(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if curr[j] < least {
+				least = curr[j]
+			}
+		}
+
+		if least > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+
+	if prev[len(rb)] > max {
+		return max + 1
+	}
+	return prev[len(rb)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func minimum(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,164 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestStem(t *testing.T) {
+	stems := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"running":        "run",
+		"hopping":        "hop",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"connections":    "connect",
+		"generalization": "gener",
+		"hopeful":        "hope",
+		"adjustment":     "adjust",
+		"controll":       "control",
+		"as":             "as",
+		"café":           "café",
+	}
+
+	for word, stem := range stems {
+		if s := Stem(word); s != stem {
+			t.Errorf("Stem(%s) is %s, expected %s", word, s, stem)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, q := range []string{"", "  ", "\"open", "word~3", "\"two words\"~", "*", "+"} {
+		if _, err := Parse(q); err == nil {
+			t.Errorf("Expected an error for query %q", q)
+		}
+	}
+
+	q, err := Parse("+title:\"Quick Foxes\" -lazy jump~ dog*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*clause{
+		&clause{occur: _MUST, field: "title", terms: []string{"quick", "fox"}},
+		&clause{occur: _MUST_NOT, terms: []string{"lazi"}},
+		&clause{terms: []string{"jump"}, fuzziness: 1},
+		&clause{terms: []string{"dog"}, prefix: true},
+	}
+	if !reflect.DeepEqual(q.clauses, expected) {
+		t.Errorf("Unexpected clauses %v", q.clauses)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	doc := NewDocument(value.NewValue(map[string]interface{}{
+		"title": "The Quick Brown Fox",
+		"body":  "jumps over the lazy dogs",
+		"tags":  []interface{}{"animals", "stories"},
+		"author": map[string]interface{}{
+			"name": "Aesop",
+		},
+	}))
+
+	matches := map[string]bool{
+		"fox":                        true,
+		"foxes":                      true,
+		"cat":                        false,
+		"cat fox":                    true,
+		"+cat fox":                   false,
+		"-dog fox":                   false,
+		"title:fox":                  true,
+		"body:fox":                   false,
+		"author:aesop":               true,
+		"author.name:aesop":          true,
+		"\"quick brown\"":            true,
+		"\"brown quick\"":            false,
+		"\"animals stories\"":        false,
+		"jumped":                     true,
+		"qick~":                      true,
+		"qck~":                       false,
+		"qck~2":                      true,
+		"laz*":                       true,
+		"title:laz*":                 false,
+		"+\"lazy dog\" +title:brown": true,
+	}
+
+	for text, expected := range matches {
+		q, err := Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if q.Matches(doc) != expected {
+			t.Errorf("Query %s expected to match %v", text, expected)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	index := NewIndex()
+	docs := map[string]string{
+		"a": "the quick brown fox",
+		"b": "the lazy brown dog",
+		"c": "a fox, a fox, a brown fox",
+		"d": "nothing to see here",
+	}
+
+	for key, text := range docs {
+		index.Add(key, NewDocument(value.NewValue(text)))
+	}
+
+	keys := func(hits []Hit) []string {
+		rv := []string{}
+		for _, hit := range hits {
+			rv = append(rv, hit.Key)
+		}
+		return rv
+	}
+
+	searches := map[string][]string{
+		"fox":           []string{"c", "a"},
+		"brown":         []string{"a", "b", "c"},
+		"brown -fox":    []string{"b"},
+		"+brown +dog":   []string{"b"},
+		"cat":           []string{},
+		"\"brown fox\"": []string{"a", "c"},
+	}
+
+	for text, expected := range searches {
+		q, err := Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if k := keys(index.Search(q)); !reflect.DeepEqual(k, expected) {
+			t.Errorf("Search %s returned %v, expected %v", text, k, expected)
+		}
+	}
+
+	index.Remove("c")
+	index.Add("a", NewDocument(value.NewValue("a slow red fox")))
+	if index.Count() != 3 {
+		t.Errorf("Expected 3 documents, found %d", index.Count())
+	}
+
+	q, _ := Parse("fox quick")
+	if k := keys(index.Search(q)); !reflect.DeepEqual(k, []string{"a"}) {
+		t.Errorf("Search after update returned %v", k)
+	}
+}
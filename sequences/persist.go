@@ -0,0 +1,95 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package sequences
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/metastore"
+)
+
+// Sequences are persisted, so that they survive restarts, and sent to
+// the other query nodes when they are created, altered or dropped. The
+// end of the reserved numbers is only kept in the store: nodes must
+// share a keyspace store not to reserve the same numbers.
+
+var persisted = metastore.NewStore("sequences", errors.NewSequenceError, applySequence)
+
+// init sequence store
+// the spec is one of dir:<path>, keyspace:[<namespace>:]<keyspace> or none
+
+func SequencesPersistInit(spec string, ds datastore.Datastore, ns string) errors.Error {
+	return persisted.Init(spec, ds, ns)
+}
+
+func unpersistSequence(key string) {
+	err := persisted.Remove(key)
+	if err != nil {
+		logging.Infof("failed to remove persisted sequence %v: %v", key, err)
+	}
+}
+
+func encodeSequence(sequence *Sequence) ([]byte, errors.Error) {
+	data, err := json.Marshal(sequence)
+	if err != nil {
+		return nil, errors.NewSequenceError(err, "cannot encode "+sequence.Key())
+	}
+	return data, nil
+}
+
+func decodeSequence(data []byte) (*Sequence, errors.Error) {
+	sequence := &Sequence{}
+	err := json.Unmarshal(data, sequence)
+	if err != nil {
+		return nil, errors.NewSequenceError(err, "cannot decode sequence")
+	}
+	return sequence, nil
+}
+
+// a sequence was created, altered or dropped on another node
+// the numbers reserved by this node are given up, as ALTER does
+
+func applySequence(key string, data []byte) errors.Error {
+	var sequence *Sequence
+
+	if data != nil {
+		var err errors.Error
+
+		sequence, err = decodeSequence(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	sequences.Lock()
+	e, ok := sequences.sequences[key]
+	switch {
+	case sequence == nil:
+		delete(sequences.sequences, key)
+	case !ok:
+		sequences.sequences[key] = &entry{sequence: sequence}
+	}
+	sequences.Unlock()
+
+	if ok {
+		e.Lock()
+		if sequence == nil {
+			e.dropped = true
+		} else {
+			e.sequence = sequence
+			e.left = 0
+		}
+		e.Unlock()
+	}
+	return nil
+}
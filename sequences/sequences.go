@@ -0,0 +1,535 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package sequences
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/value"
+)
+
+// A sequence hands out numbers from its start, stepping by its
+// increment between its minimum and maximum, and wrapping around if
+// it cycles.
+// Each node reserves a block of cache numbers at a time, by advancing
+// the end of the reserved blocks in the store before handing any of
+// them out: numbers are never handed out twice, but the unused part of
+// a block is skipped after a restart. Nodes only avoid each other's
+// blocks if they share a keyspace store whose datastore checks CAS on
+// update, as couchbase does.
+
+const (
+	_DEF_START     = 0
+	_DEF_INCREMENT = 1
+	_DEF_CACHE     = 50
+)
+
+// option names, as found in the options object of CREATE and ALTER
+const (
+	OPT_START     = "start"
+	OPT_INCREMENT = "increment"
+	OPT_MIN       = "min"
+	OPT_MAX       = "max"
+	OPT_CACHE     = "cache"
+	OPT_CYCLE     = "cycle"
+	OPT_RESTART   = "restart"
+)
+
+// numbers are encoded as strings: a keyspace store does not keep
+// integers beyond the precision of a float, such as the default bounds
+
+type Sequence struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Start     int64  `json:"start,string"`
+	Increment int64  `json:"increment,string"`
+	Min       int64  `json:"min,string"`
+	Max       int64  `json:"max,string"`
+	Cache     int64  `json:"cache,string"`
+	Cycle     bool   `json:"cycle"`
+
+	// the first number not reserved by any node or, once there are
+	// no more numbers, the last one
+	Base      int64 `json:"base,string"`
+	Exhausted bool  `json:"exhausted"`
+}
+
+func (this *Sequence) Key() string {
+	return key(this.Namespace, this.Name)
+}
+
+// create a sequence from the options of CREATE SEQUENCE
+
+func NewSequence(namespace, name string, options value.Value) (*Sequence, errors.Error) {
+	rv := &Sequence{
+		Namespace: namespace,
+		Name:      name,
+		Start:     _DEF_START,
+		Increment: _DEF_INCREMENT,
+		Min:       math.MinInt64,
+		Max:       math.MaxInt64,
+		Cache:     _DEF_CACHE,
+	}
+
+	restart, err := rv.apply(options)
+	if err != nil {
+		return nil, err
+	}
+	if restart != nil {
+		return nil, errors.NewSequenceError(nil, "RESTART is only allowed in ALTER SEQUENCE")
+	}
+
+	rv.Base = rv.Start
+	err = rv.validate()
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// apply the options, returning the number to restart from, if any
+
+func (this *Sequence) apply(options value.Value) (*int64, errors.Error) {
+	if options == nil {
+		return nil, nil
+	}
+
+	fields, ok := options.Actual().(map[string]interface{})
+	if !ok {
+		return nil, errors.NewSequenceError(nil, "the options must be an object")
+	}
+
+	var restart *int64
+	for name := range fields {
+		val, _ := options.Field(name)
+
+		switch name {
+		case OPT_START, OPT_INCREMENT, OPT_MIN, OPT_MAX, OPT_CACHE:
+			n, ok := integer(val)
+			if !ok {
+				return nil, errors.NewSequenceError(nil, fmt.Sprintf("%s must be an integer", name))
+			}
+			switch name {
+			case OPT_START:
+				this.Start = n
+			case OPT_INCREMENT:
+				this.Increment = n
+			case OPT_MIN:
+				this.Min = n
+			case OPT_MAX:
+				this.Max = n
+			case OPT_CACHE:
+				this.Cache = n
+			}
+		case OPT_CYCLE:
+			b, ok := val.Actual().(bool)
+			if !ok {
+				return nil, errors.NewSequenceError(nil, "cycle must be a boolean")
+			}
+			this.Cycle = b
+		case OPT_RESTART:
+			// true restarts from the start
+			if b, ok := val.Actual().(bool); ok {
+				if b {
+					restart = &this.Start
+				}
+				continue
+			}
+			n, ok := integer(val)
+			if !ok {
+				return nil, errors.NewSequenceError(nil, "restart must be an integer or a boolean")
+			}
+			restart = &n
+		default:
+			return nil, errors.NewSequenceError(nil, "unknown option "+name)
+		}
+	}
+
+	if restart != nil {
+		this.Base = *restart
+		this.Exhausted = false
+	}
+	return restart, nil
+}
+
+func (this *Sequence) validate() errors.Error {
+	switch {
+	case this.Increment == 0:
+		return errors.NewSequenceError(nil, "the increment cannot be 0")
+	case this.Min >= this.Max:
+		return errors.NewSequenceError(nil, "the minimum must be less than the maximum")
+	case this.Start < this.Min || this.Start > this.Max:
+		return errors.NewSequenceError(nil, fmt.Sprintf("the start %d is out of range", this.Start))
+	case this.Cache < 1:
+		return errors.NewSequenceError(nil, "the cache must be at least 1")
+	case this.Base < this.Min || this.Base > this.Max:
+		return errors.NewSequenceError(nil, fmt.Sprintf("the next number %d is out of range", this.Base))
+	}
+	return nil
+}
+
+// the number following v, if there is one
+
+func (this *Sequence) step(v int64) (int64, bool) {
+	// the distance to the bound always fits in a uint64
+	if this.Increment > 0 {
+		if uint64(this.Max-v) >= uint64(this.Increment) {
+			return v + this.Increment, true
+		}
+		return this.Min, this.Cycle
+	} else {
+		if uint64(v-this.Min) >= uint64(-this.Increment) {
+			return v + this.Increment, true
+		}
+		return this.Max, this.Cycle
+	}
+}
+
+func integer(val value.Value) (int64, bool) {
+	switch n := val.Actual().(type) {
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// the node state of a sequence
+
+type entry struct {
+	sync.Mutex
+	sequence *Sequence
+	dropped  bool
+
+	// the numbers reserved by this node
+	next int64
+	left int64
+
+	// the number last handed out by this node
+	prev    int64
+	hasPrev bool
+}
+
+type sequenceCache struct {
+	sync.RWMutex
+	sequences map[string]*entry
+}
+
+var sequences = &sequenceCache{sequences: make(map[string]*entry)}
+
+func key(namespace, name string) string {
+	return namespace + ":" + name
+}
+
+func getEntry(namespace, name string) (*entry, errors.Error) {
+	sequences.RLock()
+	defer sequences.RUnlock()
+
+	k := key(namespace, name)
+	e, ok := sequences.sequences[k]
+	if !ok {
+		return nil, errors.NewNoSuchSequenceError(k)
+	}
+	return e, nil
+}
+
+func AddSequence(sequence *Sequence) errors.Error {
+	sequences.Lock()
+	defer sequences.Unlock()
+
+	k := sequence.Key()
+	if _, ok := sequences.sequences[k]; ok {
+		return errors.NewSequenceAlreadyExistsError(k)
+	}
+
+	// another node may have created it in a shared store
+	err := persisted.Modify(k, true, func(data []byte) ([]byte, errors.Error) {
+		if data != nil {
+			return nil, errors.NewSequenceAlreadyExistsError(k)
+		}
+		return encodeSequence(sequence)
+	})
+	if err != nil {
+		return err
+	}
+	sequences.sequences[k] = &entry{sequence: sequence}
+	return nil
+}
+
+// apply the options of ALTER SEQUENCE
+// the numbers reserved by this node are given up, so that the new
+// options take effect immediately
+
+func AlterSequence(namespace, name string, options value.Value) errors.Error {
+	e, err := getEntry(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.dropped {
+		return errors.NewNoSuchSequenceError(key(namespace, name))
+	}
+
+	var sequence *Sequence
+	err = persisted.Modify(e.sequence.Key(), true, func(data []byte) ([]byte, errors.Error) {
+		current, err := e.current(data)
+		if err != nil {
+			return nil, err
+		}
+		_, err = current.apply(options)
+		if err == nil {
+			err = current.validate()
+		}
+		if err != nil {
+			return nil, err
+		}
+		sequence = current
+		return encodeSequence(current)
+	})
+	if err != nil {
+		return err
+	}
+
+	e.sequence = sequence
+	e.left = 0
+	return nil
+}
+
+func DropSequence(namespace, name string) errors.Error {
+	sequences.Lock()
+	k := key(namespace, name)
+	e, ok := sequences.sequences[k]
+	if ok {
+		delete(sequences.sequences, k)
+	}
+	sequences.Unlock()
+
+	if !ok {
+		return errors.NewNoSuchSequenceError(k)
+	}
+
+	// wait for any reservation in progress, so that it doesn't
+	// persist the sequence again
+	e.Lock()
+	e.dropped = true
+	e.Unlock()
+	unpersistSequence(k)
+	return nil
+}
+
+// returns nil if there is no such sequence
+
+func GetSequence(namespace, name string) *Sequence {
+	e, err := getEntry(namespace, name)
+	if err != nil {
+		return nil
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	rv := *e.sequence
+	return &rv
+}
+
+// hand out the next number, reserving a new block if needed
+
+func NextValue(namespace, name string) (int64, errors.Error) {
+	e, err := getEntry(namespace, name)
+	if err != nil {
+		return 0, err
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.dropped {
+		return 0, errors.NewNoSuchSequenceError(key(namespace, name))
+	}
+
+	if e.left == 0 {
+		err = e.reserve()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	rv := e.next
+	e.left--
+	if e.left > 0 {
+		e.next, _ = e.sequence.step(rv)
+	}
+	e.prev = rv
+	e.hasPrev = true
+	return rv, nil
+}
+
+// the number last handed out by this node
+
+func PrevValue(namespace, name string) (int64, errors.Error) {
+	e, err := getEntry(namespace, name)
+	if err != nil {
+		return 0, err
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.dropped {
+		return 0, errors.NewNoSuchSequenceError(key(namespace, name))
+	}
+	if !e.hasPrev {
+		return 0, errors.NewSequenceError(nil, "no value has been generated for "+e.sequence.Key())
+	}
+	return e.prev, nil
+}
+
+// the sequence as found in the store, or as known to this node if it
+// is not persisted
+// a sequence missing from the store has been dropped by another node
+
+func (this *entry) current(data []byte) (*Sequence, errors.Error) {
+	if data != nil {
+		return decodeSequence(data)
+	}
+	if persisted.Persisted() {
+		return nil, errors.NewNoSuchSequenceError(this.sequence.Key())
+	}
+	rv := *this.sequence
+	return &rv, nil
+}
+
+// reserve the next block of numbers, advancing its end in the store
+// before any of them is handed out
+
+func (this *entry) reserve() errors.Error {
+	var sequence *Sequence
+	var first, n int64
+
+	err := persisted.Modify(this.sequence.Key(), false, func(data []byte) ([]byte, errors.Error) {
+		current, err := this.current(data)
+		if err != nil {
+			return nil, err
+		}
+		first, n, err = current.reserve()
+		if err != nil {
+			return nil, err
+		}
+		sequence = current
+		return encodeSequence(current)
+	})
+	if err != nil {
+		return err
+	}
+
+	this.sequence = sequence
+	this.next = first
+	this.left = n
+	return nil
+}
+
+// move the base past the next block of numbers, returning the first
+// number of the block and its size
+
+func (this *Sequence) reserve() (int64, int64, errors.Error) {
+	first := this.Base
+	if this.Exhausted {
+		next, ok := this.step(first)
+		if !ok {
+			return 0, 0, errors.NewSequenceError(nil, this.Key()+" has reached its limit")
+		}
+		first = next
+	}
+
+	last := first
+	n := int64(1)
+	for n < this.Cache {
+		next, ok := this.step(last)
+		if !ok {
+			break
+		}
+		last = next
+		n++
+	}
+
+	next, ok := this.step(last)
+	if ok {
+		this.Base, this.Exhausted = next, false
+	} else {
+		this.Base, this.Exhausted = last, true
+	}
+	return first, n, nil
+}
+
+func CountSequences() int {
+	sequences.RLock()
+	defer sequences.RUnlock()
+	return len(sequences.sequences)
+}
+
+// apply f to a copy of each sequence, in key order, until it returns
+// false
+
+func SequencesForeach(f func(key string, sequence *Sequence) bool) {
+	sequences.RLock()
+	list := make([]*entry, 0, len(sequences.sequences))
+	for _, e := range sequences.sequences {
+		list = append(list, e)
+	}
+	sequences.RUnlock()
+
+	copies := make([]*Sequence, 0, len(list))
+	for _, e := range list {
+		e.Lock()
+		rv := *e.sequence
+		e.Unlock()
+		copies = append(copies, &rv)
+	}
+
+	sort.Slice(copies, func(i, j int) bool { return copies[i].Key() < copies[j].Key() })
+	for _, sequence := range copies {
+		if !f(sequence.Key(), sequence) {
+			return
+		}
+	}
+}
+
+func SequencesLoad() {
+	if !persisted.Persisted() {
+		return
+	}
+
+	count := 0
+	err := persisted.Load(func(key string, data []byte) {
+		sequence, err := decodeSequence(data)
+		if err != nil {
+			logging.Infof("cannot decode sequence %v: %v", key, err)
+			return
+		}
+		sequences.Lock()
+		sequences.sequences[sequence.Key()] = &entry{sequence: sequence}
+		sequences.Unlock()
+		count++
+	})
+	if err != nil {
+		logging.Errorf("failed to reload sequences: %v", err)
+	}
+	logging.Infof("reloaded %v sequences", count)
+}
@@ -33,9 +33,14 @@ import (
 	"github.com/couchbase/query/logging"
 	log_resolver "github.com/couchbase/query/logging/resolver"
 	"github.com/couchbase/query/prepareds"
+	"github.com/couchbase/query/sequences"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/server/http"
+	"github.com/couchbase/query/server/pgwire"
+	"github.com/couchbase/query/triggers"
 	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/validation"
+	"github.com/couchbase/query/views"
 )
 
 var DATASTORE = flag.String("datastore", "", "Datastore address (http://URL or dir:PATH or mock:)")
@@ -59,6 +64,7 @@ var HTTP_ADDR = flag.String("http", ":8093", "HTTP service address")
 var HTTPS_ADDR = flag.String("https", ":18093", "HTTPS service address")
 var CERT_FILE = flag.String("certfile", "", "HTTPS certificate file")
 var KEY_FILE = flag.String("keyfile", "", "HTTPS private key file")
+var PGWIRE_ADDR = flag.String("pgwire", "", "PostgreSQL protocol service address; leave empty to disable")
 var IPv6 = flag.Bool("ipv6", false, "Query is IPv6 compliant")
 
 // The ssl_minimum_protocol flag is currently provided but is unused.
@@ -84,8 +90,16 @@ var MEM_PROFILE = flag.String("memprofile", "", "write memory profile to this fi
 // Monitoring API
 var COMPLETED_THRESHOLD = flag.Int("completed-threshold", 1000, "cache completed query lasting longer than this many milliseconds")
 var COMPLETED_LIMIT = flag.Int("completed-limit", 4000, "maximum number of completed requests")
+var COMPLETED_LOG_FILE = flag.String("completed-log-file", "", "file the completed requests are also written to")
 
 var PREPARED_LIMIT = flag.Int("prepared-limit", 16384, "maximum number of prepared statements")
+var PREPARED_STORE = flag.String("prepared-store", "dir:prepareds", "store for persisted prepared statements: dir:<path>, keyspace:[<namespace>:]<keyspace> or none")
+var VIEW_STORE = flag.String("view-store", "dir:views", "store for view definitions: dir:<path>, keyspace:[<namespace>:]<keyspace> or none")
+var SEQUENCE_STORE = flag.String("sequence-store", "dir:sequences", "store for sequences: dir:<path>, keyspace:[<namespace>:]<keyspace> or none; nodes must share a keyspace store")
+var VALIDATION_STORE = flag.String("validation-store", "dir:validations", "store for keyspace validation schemas: dir:<path>, keyspace:[<namespace>:]<keyspace> or none")
+var TRIGGER_STORE = flag.String("trigger-store", "dir:triggers", "store for triggers: dir:<path>, keyspace:[<namespace>:]<keyspace> or none")
+var ADHOC_PLANS = flag.Bool("adhoc-plans", false, "cache plans for ad-hoc statements")
+var ADHOC_LIMIT = flag.Int("adhoc-limit", 4096, "maximum number of cached ad-hoc plans")
 
 // GOGC
 var _GOGC_PERCENT = 200
@@ -188,6 +202,12 @@ func main() {
 
 	// Start the completed requests log
 	server.RequestsInit(*COMPLETED_THRESHOLD, *COMPLETED_LIMIT)
+	err = server.RequestsSetLogFile(*COMPLETED_LOG_FILE)
+	if err != nil {
+		logging.Errorp("Could not open completed requests log",
+			logging.Pair{"error", err},
+		)
+	}
 
 	// Initialized the prepared statement cache
 	if *PREPARED_LIMIT <= 0 {
@@ -197,6 +217,14 @@ func main() {
 	}
 	prepareds.PreparedsInit(*PREPARED_LIMIT)
 
+	// Initialize the ad-hoc plan cache
+	if *ADHOC_LIMIT <= 0 {
+		logging.Errorp("Ignoring invalid ad-hoc plan cache size",
+			logging.Pair{"value", *ADHOC_LIMIT})
+		*ADHOC_LIMIT = 4096
+	}
+	prepareds.AdhocsInit(*ADHOC_LIMIT, *ADHOC_PLANS)
+
 	numProcs := runtime.GOMAXPROCS(0)
 	channel := make(server.RequestChannel, *REQUEST_CAP*numProcs)
 	plusChannel := make(server.RequestChannel, *REQUEST_CAP*numProcs)
@@ -219,6 +247,58 @@ func main() {
 	datastore_package.SetSystemstore(server.Systemstore())
 	prepareds.PreparedsReprepareInit(datastore, sys, *NAMESPACE)
 
+	// Reload persisted prepared statements, and fetch those we don't
+	// know about from the rest of the cluster
+	err = prepareds.PreparedsPersistInit(*PREPARED_STORE, datastore, *NAMESPACE)
+	if err != nil {
+		logging.Errorp("Could not open prepared statement store",
+			logging.Pair{"error", err},
+		)
+	} else {
+		prepareds.PreparedsLoad()
+	}
+	prepareds.PreparedsRemotePrime()
+
+	// Reload view definitions
+	err = views.ViewsPersistInit(*VIEW_STORE, datastore, *NAMESPACE)
+	if err != nil {
+		logging.Errorp("Could not open view store",
+			logging.Pair{"error", err},
+		)
+	} else {
+		views.ViewsLoad()
+	}
+
+	// Reload sequences
+	err = sequences.SequencesPersistInit(*SEQUENCE_STORE, datastore, *NAMESPACE)
+	if err != nil {
+		logging.Errorp("Could not open sequence store",
+			logging.Pair{"error", err},
+		)
+	} else {
+		sequences.SequencesLoad()
+	}
+
+	// Reload validation schemas
+	err = validation.ValidationsPersistInit(*VALIDATION_STORE, datastore, *NAMESPACE)
+	if err != nil {
+		logging.Errorp("Could not open validation store",
+			logging.Pair{"error", err},
+		)
+	} else {
+		validation.ValidationsLoad()
+	}
+
+	// Reload triggers
+	err = triggers.TriggersPersistInit(*TRIGGER_STORE, datastore, *NAMESPACE)
+	if err != nil {
+		logging.Errorp("Could not open trigger store",
+			logging.Pair{"error", err},
+		)
+	} else {
+		triggers.TriggersLoad()
+	}
+
 	server.SetCpuProfile(*CPU_PROFILE)
 	server.SetKeepAlive(*KEEP_ALIVE_LENGTH)
 	server.SetMemProfile(*MEM_PROFILE)
@@ -273,6 +353,21 @@ func main() {
 			os.Exit(1)
 		}
 	}
+
+	// Create PostgreSQL protocol endpoint
+	if *PGWIRE_ADDR != "" {
+		pgEndpoint, er := pgwire.NewPgEndpoint(server, *PGWIRE_ADDR, *CERT_FILE, *KEY_FILE)
+		if er == nil {
+			er = pgEndpoint.Listen()
+		}
+		if er != nil {
+			logging.Errorp("cbq-engine exiting with error",
+				logging.Pair{"error", er},
+				logging.Pair{"PGWIRE_ADDR", *PGWIRE_ADDR},
+			)
+			os.Exit(1)
+		}
+	}
 	signalCatcher(server, endpoint)
 }
 
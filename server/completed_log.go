@@ -0,0 +1,173 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+)
+
+// The completed requests log can also be written to a file, one JSON
+// document per line, so that it survives the cache and restarts.
+// Entries are written in the background: if the writer falls behind,
+// entries are dropped rather than holding up requests.
+// When the file exceeds its maximum size, it is moved to <file>.1 and
+// a new one is started.
+
+const (
+	_LOG_QUEUE    = 1024
+	_LOG_MAX_SIZE = 64 * 1024 * 1024
+)
+
+type requestFile struct {
+	sync.RWMutex
+	path    string
+	entries chan []byte
+}
+
+var requestLogFile = &requestFile{}
+
+func RequestsLogFile() string {
+	requestLogFile.RLock()
+	defer requestLogFile.RUnlock()
+	return requestLogFile.path
+}
+
+// an empty path stops writing to file
+func RequestsSetLogFile(path string) errors.Error {
+	requestLogFile.Lock()
+	defer requestLogFile.Unlock()
+	if path == requestLogFile.path {
+		return nil
+	}
+	if requestLogFile.entries != nil {
+		close(requestLogFile.entries)
+		requestLogFile.entries = nil
+	}
+	requestLogFile.path = ""
+	if path == "" {
+		return nil
+	}
+
+	file, size, err := openRequestsLog(path)
+	if err != nil {
+		return errors.NewCompletedLogError(err, path)
+	}
+	entries := make(chan []byte, _LOG_QUEUE)
+	requestLogFile.path = path
+	requestLogFile.entries = entries
+	go writeRequestsLog(path, file, size, entries)
+	return nil
+}
+
+func openRequestsLog(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+func writeRequestsLog(path string, file *os.File, size int64, entries chan []byte) {
+	for entry := range entries {
+		if size+int64(len(entry)) > _LOG_MAX_SIZE && size > 0 {
+			var err error
+
+			file.Close()
+			err = os.Rename(path, path+".1")
+			if err == nil {
+				file, size, err = openRequestsLog(path)
+			}
+			if err != nil {
+
+				// entries are queued without blocking, so they will
+				// just be dropped from now on
+				logging.Errorf("Cannot rotate completed requests log %v: %v", path, err)
+				return
+			}
+		}
+		n, err := file.Write(entry)
+		size += int64(n)
+		if err != nil {
+			logging.Errorf("Cannot write completed requests log %v: %v", path, err)
+		}
+	}
+	file.Close()
+}
+
+func (this *requestFile) write(re *RequestLogEntry) {
+	this.RLock()
+	defer this.RUnlock()
+	if this.entries == nil {
+		return
+	}
+	bytes, err := json.Marshal(re.fileEntry())
+	if err != nil {
+		return
+	}
+	select {
+	case this.entries <- append(bytes, '\n'):
+	default:
+	}
+}
+
+// timings and arguments are left out: they are large, and the latter
+// may be sensitive
+func (this *RequestLogEntry) fileEntry() map[string]interface{} {
+	rv := map[string]interface{}{
+		"requestId":       this.RequestId,
+		"state":           this.State,
+		"scanConsistency": this.ScanConsistency,
+		"requestTime":     this.Time.String(),
+		"elapsedTime":     this.ElapsedTime.String(),
+		"serviceTime":     this.ServiceTime.String(),
+		"resultCount":     this.ResultCount,
+		"resultSize":      this.ResultSize,
+		"errorCount":      this.ErrorCount,
+	}
+	if this.ClientId != "" {
+		rv["clientContextID"] = this.ClientId
+	}
+	if this.Statement != "" {
+		rv["statement"] = this.Statement
+	}
+	if this.PreparedName != "" {
+		rv["preparedName"] = this.PreparedName
+		rv["preparedText"] = this.PreparedText
+	}
+	if this.PhaseCounts != nil {
+		rv["phaseCounts"] = this.PhaseCounts
+	}
+	if this.PhaseOperators != nil {
+		rv["phaseOperators"] = this.PhaseOperators
+	}
+	if this.PhaseTimes != nil {
+		rv["phaseTimes"] = this.PhaseTimes
+	}
+	if this.Users != "" {
+		rv["users"] = this.Users
+	}
+	if this.RemoteAddr != "" {
+		rv["remoteAddr"] = this.RemoteAddr
+	}
+	if this.UserAgent != "" {
+		rv["userAgent"] = this.UserAgent
+	}
+	return rv
+}
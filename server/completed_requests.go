@@ -19,7 +19,11 @@
 package server
 
 import (
+	"math"
+	"math/rand"
 	"net/http"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -62,12 +66,22 @@ type qualifier interface {
 	unique() bool
 	condition() interface{}
 	isCondition(c interface{}) bool
-	evaluate(request *BaseRequest) bool
+	evaluate(info *requestInfo) bool
+}
+
+// what the qualifiers get to examine
+type requestInfo struct {
+	request    *BaseRequest
+	req        *http.Request
+	resultSize int
+	errorCount int
+	users      []string
 }
 
 type RequestLog struct {
 	sync.RWMutex
 	qualifiers []qualifier
+	and        bool // all qualifiers have to be satisfied, rather than any
 
 	cache *util.GenCache
 }
@@ -86,6 +100,7 @@ func RequestsInit(threshold int, limit int) {
 	if err == nil {
 		requestLog.qualifiers = []qualifier{q}
 	}
+	requestLog.and = false
 
 	requestLog.cache = util.NewGenCache(limit)
 }
@@ -111,16 +126,12 @@ func RequestsAddQualifier(name string, condition interface{}) errors.Error {
 			return errors.NewCompletedQualifierExists(name)
 		}
 	}
-	switch name {
-	case "threshold":
-		q, err = newTimeThreshold(condition)
-	default:
-		return errors.NewCompletedQualifierUnknown(name)
-	}
-	if q != nil {
-		requestLog.qualifiers = append(requestLog.qualifiers, q)
+	q, err = newQualifier(name, condition)
+	if err != nil {
+		return err
 	}
-	return err
+	requestLog.qualifiers = append(requestLog.qualifiers, q)
+	return nil
 }
 
 func RequestsUpdateQualifier(name string, condition interface{}) errors.Error {
@@ -129,24 +140,45 @@ func RequestsUpdateQualifier(name string, condition interface{}) errors.Error {
 
 	requestLog.Lock()
 	defer requestLog.Unlock()
-	for i, q := range requestLog.qualifiers {
-		if q.name() == name {
-			if !q.unique() {
-				return errors.NewCompletedQualifierNotUnique(name)
-			}
+	for _, q := range requestLog.qualifiers {
+		if q.name() == name && !q.unique() {
+			return errors.NewCompletedQualifierNotUnique(name)
+		}
+	}
+
+	// an invalid condition leaves the current one in place
+	q, err = newQualifier(name, condition)
+	if err != nil {
+		return err
+	}
+	for i, old := range requestLog.qualifiers {
+		if old.name() == name {
 			requestLog.qualifiers = append(requestLog.qualifiers[:i], requestLog.qualifiers[i+1:]...)
+			break
 		}
 	}
+	requestLog.qualifiers = append(requestLog.qualifiers, q)
+	return nil
+}
+
+func newQualifier(name string, condition interface{}) (qualifier, errors.Error) {
 	switch name {
 	case "threshold":
-		q, err = newTimeThreshold(condition)
-	default:
-		return errors.NewCompletedQualifierUnknown(name)
+		return newTimeThreshold(condition)
+	case "error":
+		return newErrorQualifier(condition)
+	case "user":
+		return newUserQualifier(condition)
+	case "client":
+		return newClientQualifier(condition)
+	case "statement":
+		return newStatementQualifier(condition)
+	case "size":
+		return newSizeQualifier(condition)
+	case "sample":
+		return newSampleQualifier(condition)
 	}
-	if q != nil {
-		requestLog.qualifiers = append(requestLog.qualifiers, q)
-	}
-	return err
+	return nil, errors.NewCompletedQualifierUnknown(name)
 }
 
 func RequestsRemoveQualifier(name string, condition interface{}) errors.Error {
@@ -191,6 +223,83 @@ func RequestsGetQualifiers() (qualifiers []struct {
 	return
 }
 
+// qualifiers are either or'ed (the default) or and'ed together
+
+func RequestsLogic() string {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+	if requestLog.and {
+		return "and"
+	}
+	return "or"
+}
+
+func RequestsSetLogic(logic string) errors.Error {
+	requestLog.Lock()
+	defer requestLog.Unlock()
+	switch strings.ToLower(logic) {
+	case "and":
+		requestLog.and = true
+	case "or":
+		requestLog.and = false
+	default:
+		return errors.NewCompletedQualifierInvalidArgument("logic", logic)
+	}
+	return nil
+}
+
+// the qualifiers as a settings object:
+// unique qualifiers map to their condition, the others to a list of
+// conditions, and "logic" to how they are combined
+
+func RequestsQualifiers() map[string]interface{} {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+	rv := make(map[string]interface{}, len(requestLog.qualifiers)+1)
+	for _, q := range requestLog.qualifiers {
+		if q.unique() {
+			rv[q.name()] = q.condition()
+		} else {
+			conds, _ := rv[q.name()].([]interface{})
+			rv[q.name()] = append(conds, q.condition())
+		}
+	}
+	if requestLog.and {
+		rv["logic"] = "and"
+	} else {
+		rv["logic"] = "or"
+	}
+	return rv
+}
+
+// change the qualifiers from a settings object:
+// "+name" adds a qualifier, "-name" removes it, a plain name replaces a
+// unique qualifier (null removes it) and "logic" sets how they are combined
+
+func RequestsSetQualifiers(settings map[string]interface{}) errors.Error {
+	var err errors.Error
+
+	for name, condition := range settings {
+		switch {
+		case name == "logic":
+			logic, _ := condition.(string)
+			err = RequestsSetLogic(logic)
+		case strings.HasPrefix(name, "+"):
+			err = RequestsAddQualifier(name[1:], condition)
+		case strings.HasPrefix(name, "-"):
+			err = RequestsRemoveQualifier(name[1:], condition)
+		case condition == nil:
+			err = RequestsRemoveQualifier(name, nil)
+		default:
+			err = RequestsUpdateQualifier(name, condition)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // completed requests operations
 
 func RequestEntry(id string) *RequestLogEntry {
@@ -244,11 +353,18 @@ func LogRequest(request_time time.Duration, service_time time.Duration,
 	requestLog.RLock()
 	defer requestLog.RUnlock()
 
-	// apply all the qualifiers until one is satisfied
+	// apply all the qualifiers until one is satisfied, or, if they
+	// are and'ed, until one is not
 	doLog := false
+	info := &requestInfo{
+		request:    request,
+		req:        req,
+		resultSize: result_size,
+		errorCount: error_count,
+	}
 	for _, q := range requestLog.qualifiers {
-		doLog = q.evaluate(request)
-		if doLog {
+		doLog = q.evaluate(info)
+		if doLog != requestLog.and {
 			break
 		}
 	}
@@ -323,6 +439,7 @@ func LogRequest(request_time time.Duration, service_time time.Duration,
 		re.UserAgent = userAgent
 	}
 
+	requestLogFile.write(re)
 	requestLog.cache.Add(re, id, nil)
 }
 
@@ -334,9 +451,9 @@ type timeThreshold struct {
 }
 
 func newTimeThreshold(c interface{}) (*timeThreshold, errors.Error) {
-	switch c.(type) {
-	case int:
-		return &timeThreshold{threshold: time.Duration(c.(int))}, nil
+	threshold, ok := intCondition(c)
+	if ok {
+		return &timeThreshold{threshold: time.Duration(threshold)}, nil
 	}
 	return nil, errors.NewCompletedQualifierInvalidArgument("threshold", c)
 }
@@ -349,19 +466,18 @@ func (this *timeThreshold) unique() bool {
 	return true
 }
 
+// milliseconds, as accepted by newTimeThreshold
 func (this *timeThreshold) condition() interface{} {
-	return this.threshold
+	return int(this.threshold)
 }
 
 func (this *timeThreshold) isCondition(c interface{}) bool {
-	switch c.(type) {
-	case int:
-		return time.Duration(c.(int)) == this.threshold
-	}
-	return false
+	threshold, ok := intCondition(c)
+	return ok && time.Duration(threshold) == this.threshold
 }
 
-func (this *timeThreshold) evaluate(request *BaseRequest) bool {
+func (this *timeThreshold) evaluate(info *requestInfo) bool {
+	request := info.request
 
 	// negative threshold means log nothing
 	// zero threshold means log everything (no threshold)
@@ -372,3 +488,288 @@ func (this *timeThreshold) evaluate(request *BaseRequest) bool {
 	}
 	return true
 }
+
+// 2- errors: any error, or a specific error code
+type errorQualifier struct {
+	code int32 // zero for any error
+}
+
+func newErrorQualifier(c interface{}) (*errorQualifier, errors.Error) {
+	switch c := c.(type) {
+	case bool:
+		if c {
+			return &errorQualifier{}, nil
+		}
+	default:
+		code, ok := intCondition(c)
+		if ok && code > 0 {
+			return &errorQualifier{code: int32(code)}, nil
+		}
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("error", c)
+}
+
+func (this *errorQualifier) name() string {
+	return "error"
+}
+
+func (this *errorQualifier) unique() bool {
+	return false
+}
+
+func (this *errorQualifier) condition() interface{} {
+	if this.code == 0 {
+		return true
+	}
+	return int(this.code)
+}
+
+func (this *errorQualifier) isCondition(c interface{}) bool {
+	switch c := c.(type) {
+	case bool:
+		return c && this.code == 0
+	}
+	code, ok := intCondition(c)
+	return ok && int32(code) == this.code
+}
+
+func (this *errorQualifier) evaluate(info *requestInfo) bool {
+	codes := info.request.ErrorCodes()
+	if this.code == 0 {
+		return info.errorCount > 0 || len(codes) > 0
+	}
+	for _, code := range codes {
+		if code == this.code {
+			return true
+		}
+	}
+	return false
+}
+
+// 3- user
+type userQualifier struct {
+	user string
+}
+
+func newUserQualifier(c interface{}) (*userQualifier, errors.Error) {
+	user, ok := c.(string)
+	if ok && user != "" {
+		return &userQualifier{user: user}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("user", c)
+}
+
+func (this *userQualifier) name() string {
+	return "user"
+}
+
+func (this *userQualifier) unique() bool {
+	return false
+}
+
+func (this *userQualifier) condition() interface{} {
+	return this.user
+}
+
+func (this *userQualifier) isCondition(c interface{}) bool {
+	user, ok := c.(string)
+	return ok && user == this.user
+}
+
+func (this *userQualifier) evaluate(info *requestInfo) bool {
+
+	// the credentials string is only worked out once for all the
+	// user qualifiers
+	if info.users == nil {
+		info.users = strings.Split(datastore.CredsString(info.request.Credentials(), info.req), ",")
+	}
+	for _, user := range info.users {
+
+		// users may or may not be qualified by their domain
+		if user == this.user || user[strings.IndexByte(user, ':')+1:] == this.user {
+			return true
+		}
+	}
+	return false
+}
+
+// 4- client context id pattern
+type clientQualifier struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newClientQualifier(c interface{}) (*clientQualifier, errors.Error) {
+	pattern, re := regexpCondition(c)
+	if re == nil {
+		return nil, errors.NewCompletedQualifierInvalidArgument("client", c)
+	}
+	return &clientQualifier{pattern: pattern, re: re}, nil
+}
+
+func (this *clientQualifier) name() string {
+	return "client"
+}
+
+func (this *clientQualifier) unique() bool {
+	return false
+}
+
+func (this *clientQualifier) condition() interface{} {
+	return this.pattern
+}
+
+func (this *clientQualifier) isCondition(c interface{}) bool {
+	pattern, ok := c.(string)
+	return ok && pattern == this.pattern
+}
+
+func (this *clientQualifier) evaluate(info *requestInfo) bool {
+	return this.re.MatchString(info.request.ClientID().String())
+}
+
+// 5- statement pattern, matched against the statement or the prepared text
+type statementQualifier struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newStatementQualifier(c interface{}) (*statementQualifier, errors.Error) {
+	pattern, re := regexpCondition(c)
+	if re == nil {
+		return nil, errors.NewCompletedQualifierInvalidArgument("statement", c)
+	}
+	return &statementQualifier{pattern: pattern, re: re}, nil
+}
+
+func (this *statementQualifier) name() string {
+	return "statement"
+}
+
+func (this *statementQualifier) unique() bool {
+	return false
+}
+
+func (this *statementQualifier) condition() interface{} {
+	return this.pattern
+}
+
+func (this *statementQualifier) isCondition(c interface{}) bool {
+	pattern, ok := c.(string)
+	return ok && pattern == this.pattern
+}
+
+func (this *statementQualifier) evaluate(info *requestInfo) bool {
+	stmt := info.request.Statement()
+	if stmt != "" && this.re.MatchString(stmt) {
+		return true
+	}
+	prepared := info.request.Prepared()
+	return prepared != nil && this.re.MatchString(prepared.Text())
+}
+
+// 6- result size
+type sizeQualifier struct {
+	size int
+}
+
+func newSizeQualifier(c interface{}) (*sizeQualifier, errors.Error) {
+	size, ok := intCondition(c)
+	if ok && size >= 0 {
+		return &sizeQualifier{size: size}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("size", c)
+}
+
+func (this *sizeQualifier) name() string {
+	return "size"
+}
+
+func (this *sizeQualifier) unique() bool {
+	return true
+}
+
+func (this *sizeQualifier) condition() interface{} {
+	return this.size
+}
+
+func (this *sizeQualifier) isCondition(c interface{}) bool {
+	size, ok := intCondition(c)
+	return ok && size == this.size
+}
+
+func (this *sizeQualifier) evaluate(info *requestInfo) bool {
+	return info.resultSize >= this.size
+}
+
+// 7- sampling: log this fraction of the requests
+type sampleQualifier struct {
+	rate float64
+}
+
+func newSampleQualifier(c interface{}) (*sampleQualifier, errors.Error) {
+	var rate float64
+	var ok bool
+
+	switch c := c.(type) {
+	case float64:
+		rate, ok = c, true
+	case int:
+		rate, ok = float64(c), true
+	}
+	if ok && rate >= 0.0 && rate <= 1.0 {
+		return &sampleQualifier{rate: rate}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("sample", c)
+}
+
+func (this *sampleQualifier) name() string {
+	return "sample"
+}
+
+func (this *sampleQualifier) unique() bool {
+	return true
+}
+
+func (this *sampleQualifier) condition() interface{} {
+	return this.rate
+}
+
+func (this *sampleQualifier) isCondition(c interface{}) bool {
+	switch c := c.(type) {
+	case float64:
+		return c == this.rate
+	case int:
+		return float64(c) == this.rate
+	}
+	return false
+}
+
+func (this *sampleQualifier) evaluate(info *requestInfo) bool {
+	return rand.Float64() < this.rate
+}
+
+// conditions come from go code as ints, and from REST calls as floats
+func intCondition(c interface{}) (int, bool) {
+	switch c := c.(type) {
+	case int:
+		return c, true
+	case float64:
+		if c == math.Trunc(c) {
+			return int(c), true
+		}
+	}
+	return 0, false
+}
+
+func regexpCondition(c interface{}) (string, *regexp.Regexp) {
+	pattern, ok := c.(string)
+	if !ok {
+		return "", nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", nil
+	}
+	return pattern, re
+}
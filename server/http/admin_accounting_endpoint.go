@@ -21,6 +21,7 @@ import (
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/metastore"
 	"github.com/couchbase/query/prepareds"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/value"
@@ -34,6 +35,7 @@ const (
 	requestsPrefix   = adminPrefix + "/active_requests"
 	completedsPrefix = adminPrefix + "/completed_requests"
 	indexesPrefix    = adminPrefix + "/indexes"
+	metadataPrefix   = adminPrefix + "/metadata"
 	expvarsRoute     = "/debug/vars"
 )
 
@@ -83,6 +85,9 @@ func (this *HttpEndpoint) registerAccountingHandlers() {
 	completedIndexHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doCompletedIndex)
 	}
+	metadataHandler := func(w http.ResponseWriter, req *http.Request) {
+		this.wrapAPI(w, req, doMetadata)
+	}
 	routeMap := map[string]struct {
 		handler handlerFunc
 		methods []string
@@ -94,11 +99,12 @@ func (this *HttpEndpoint) registerAccountingHandlers() {
 		preparedsPrefix + "/{name}":           {handler: preparedHandler, methods: []string{"GET", "POST", "DELETE", "PUT"}},
 		requestsPrefix:                        {handler: requestsHandler, methods: []string{"GET"}},
 		requestsPrefix + "/{request}":         {handler: requestHandler, methods: []string{"GET", "POST", "DELETE"}},
-		completedsPrefix:                      {handler: completedsHandler, methods: []string{"GET"}},
+		completedsPrefix:                      {handler: completedsHandler, methods: []string{"GET", "POST"}},
 		completedsPrefix + "/{request}":       {handler: completedHandler, methods: []string{"GET", "POST", "DELETE"}},
 		indexesPrefix + "/prepareds":          {handler: preparedIndexHandler, methods: []string{"GET"}},
 		indexesPrefix + "/active_requests":    {handler: requestIndexHandler, methods: []string{"GET"}},
 		indexesPrefix + "/completed_requests": {handler: completedIndexHandler, methods: []string{"GET"}},
+		metadataPrefix + "/{kind}/{name}":     {handler: metadataHandler, methods: []string{"PUT", "DELETE"}},
 	}
 
 	for route, h := range routeMap {
@@ -256,7 +262,7 @@ func doPrepared(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 			return nil, err
 		}
 		err = prepareds.DeletePrepared(name)
-		if err != nil {
+		if err != nil && prepareds.DeleteAdhoc(name) != nil {
 			return nil, err
 		}
 		return true, nil
@@ -299,7 +305,7 @@ func doPrepared(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 
 		var itemMap map[string]interface{}
 
-		prepareds.PreparedDo(name, func(entry *prepareds.CacheEntry) {
+		snapshot := func(entry *prepareds.CacheEntry) {
 			itemMap = map[string]interface{}{
 				"name":            name,
 				"uses":            entry.Uses,
@@ -322,13 +328,67 @@ func doPrepared(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 				itemMap["maxElapsedTime"] = time.Duration(entry.MaxRequestTime).String()
 				itemMap["maxServiceTime"] = time.Duration(entry.MaxServiceTime).String()
 			}
-		})
+		}
+
+		prepareds.PreparedDo(name, snapshot)
+		if itemMap == nil {
+			prepareds.AdhocDo(name, func(entry *prepareds.AdhocEntry) {
+				snapshot(&entry.CacheEntry)
+				itemMap["adhoc"] = true
+				itemMap["hits"] = entry.Hits
+				itemMap["misses"] = entry.Misses
+			})
+		}
 		return itemMap, nil
 	} else {
 		return nil, errors.NewServiceErrorHttpMethod(req.Method)
 	}
 }
 
+// changes to views, sequences, validations and triggers made on other nodes
+
+func doMetadata(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
+	vars := mux.Vars(req)
+	kind := vars["kind"]
+	name := vars["name"]
+
+	af.EventTypeId = audit.API_ADMIN_METADATA
+	af.Name = kind + "/" + name
+
+	switch req.Method {
+	case "PUT":
+		body, err1 := ioutil.ReadAll(req.Body)
+		defer req.Body.Close()
+
+		// http.BasicAuth eats the body, so verify credentials after getting the body.
+		err := verifyCredentialsFromRequest(kind, req, af)
+		if err != nil {
+			return nil, err
+		}
+
+		if err1 != nil {
+			return nil, errors.NewAdminBodyError(err1)
+		}
+		err = metastore.Apply(kind, name, body)
+		if err != nil {
+			return nil, err
+		}
+		return "", nil
+	case "DELETE":
+		err := verifyCredentialsFromRequest(kind, req, af)
+		if err != nil {
+			return nil, err
+		}
+		err = metastore.Apply(kind, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		return true, nil
+	default:
+		return nil, errors.NewServiceErrorHttpMethod(req.Method)
+	}
+}
+
 func doPrepareds(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
 	af.EventTypeId = audit.API_ADMIN_PREPAREDS
 	switch req.Method {
@@ -338,7 +398,7 @@ func doPrepareds(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Reques
 			return nil, err
 		}
 
-		numPrepareds := prepareds.CountPrepareds()
+		numPrepareds := prepareds.CountPrepareds() + prepareds.CountAdhocs()
 		data := make([]map[string]interface{}, numPrepareds)
 		i := 0
 
@@ -361,7 +421,16 @@ func doPrepareds(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Reques
 		}
 
 		prepareds.PreparedsForeach(snapshot, nil)
-		return data, nil
+		prepareds.AdhocsForeach(func(name string, d *prepareds.AdhocEntry) bool {
+			if !snapshot(name, &d.CacheEntry) {
+				return false
+			}
+			data[i-1]["adhoc"] = true
+			data[i-1]["hits"] = d.Hits
+			data[i-1]["misses"] = d.Misses
+			return true
+		}, nil)
+		return data[:i], nil
 
 	default:
 		return nil, errors.NewServiceErrorHttpMethod(req.Method)
@@ -643,6 +712,26 @@ func doCompletedRequests(endpoint *HttpEndpoint, w http.ResponseWriter, req *htt
 		return nil, err
 	}
 
+	// POST changes the qualifiers, see server.RequestsSetQualifiers()
+	if req.Method == "POST" {
+		var qualifiers map[string]interface{}
+
+		decoder, err := getJsonDecoder(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		e := decoder.Decode(&qualifiers)
+		if e != nil {
+			return nil, errors.NewAdminDecodingError(e)
+		}
+		af.Values = qualifiers
+		err = server.RequestsSetQualifiers(qualifiers)
+		if err != nil {
+			return nil, err
+		}
+		return server.RequestsQualifiers(), nil
+	}
+
 	numRequests := server.RequestsCount()
 	requests := make([]map[string]interface{}, numRequests)
 	i := 0
@@ -693,7 +782,7 @@ func doCompletedRequests(endpoint *HttpEndpoint, w http.ResponseWriter, req *htt
 
 func doPreparedIndex(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
 	af.EventTypeId = audit.API_ADMIN_INDEXES_PREPAREDS
-	return prepareds.NamePrepareds(), nil
+	return append(prepareds.NamePrepareds(), prepareds.NameAdhocs()...), nil
 }
 
 func doRequestIndex(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
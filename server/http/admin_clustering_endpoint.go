@@ -373,7 +373,11 @@ func fillSettings(settings map[string]interface{}, srvr *server.Server) map[stri
 	threshold, _ := server.RequestsGetQualifier("threshold")
 	settings[paramSettings.CMPTHRESHOLD] = threshold
 	settings[paramSettings.CMPLIMIT] = server.RequestsLimit()
+	settings[paramSettings.CMPQUALIFIERS] = server.RequestsQualifiers()
+	settings[paramSettings.CMPLOGFILE] = server.RequestsLogFile()
 	settings[paramSettings.PRPLIMIT] = prepareds.PreparedsLimit()
+	settings[paramSettings.ADHOCPLANS] = prepareds.AdhocsEnabled()
+	settings[paramSettings.ADHOCLIMIT] = prepareds.AdhocsLimit()
 	settings[paramSettings.PRETTY] = srvr.Pretty()
 	settings[paramSettings.MAXINDEXAPI] = srvr.MaxIndexAPI()
 	settings[paramSettings.N1QLFEATCTRL] = util.GetN1qlFeatureControl()
@@ -104,7 +104,11 @@ func (this *HttpEndpoint) ListenTLS() error {
 // we respond with a timeout status.
 func (this *HttpEndpoint) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 	request := newHttpRequest(resp, req, this.bufpool, this.server.RequestSizeCap())
+	this.serveRequest(request)
+}
 
+// queue the request and wait for it to complete
+func (this *HttpEndpoint) serveRequest(request *httpRequest) {
 	this.actives.Put(request)
 	defer this.actives.Delete(request.Id().String(), false)
 
@@ -123,7 +127,7 @@ func (this *HttpEndpoint) ServeHTTP(resp http.ResponseWriter, req *http.Request)
 			<-request.CloseNotify()
 		default:
 			// Buffer is full.
-			resp.WriteHeader(http.StatusServiceUnavailable)
+			this.queueFull(request)
 		}
 	} else {
 		select {
@@ -132,11 +136,23 @@ func (this *HttpEndpoint) ServeHTTP(resp http.ResponseWriter, req *http.Request)
 			<-request.CloseNotify()
 		default:
 			// Buffer is full.
-			resp.WriteHeader(http.StatusServiceUnavailable)
+			this.queueFull(request)
 		}
 	}
 }
 
+func (this *HttpEndpoint) queueFull(request *httpRequest) {
+
+	// streamed responses have to say why they are being turned down
+	if request.stream != nil {
+		request.Fail(errors.NewServiceErrorQueueFull())
+		request.setHttpCode(http.StatusServiceUnavailable)
+		request.Failed(this.server)
+	} else {
+		request.resp.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
 func (this *HttpEndpoint) Close() error {
 	return this.closeListener(this.listener)
 }
@@ -160,6 +176,9 @@ func (this *HttpEndpoint) registerHandlers(staticPath string) {
 	this.mux.Handle(servicePrefix, this).
 		Methods("GET", "POST")
 
+	this.mux.HandleFunc(websocketPrefix, this.serveWebSocket).
+		Methods("GET")
+
 	// TODO: Deprecate (remove) this binding
 	this.mux.Handle("/query", this).
 		Methods("GET", "POST")
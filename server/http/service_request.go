@@ -41,6 +41,9 @@ type httpRequest struct {
 	req             *http.Request
 	httpCloseNotify <-chan bool
 	writer          responseDataManager
+	stream          responseStream // non nil for streamed responses
+	progress        time.Duration  // interval between progress messages when streaming
+	warningsSeen    map[string]bool
 	httpRespCode    int
 	resultCount     int
 	resultSize      int
@@ -319,7 +322,23 @@ func newHttpRequest(resp http.ResponseWriter, req *http.Request, bp BufferPool,
 
 	rv.SetTimeout(timeout)
 
-	rv.writer = NewBufferedWriter(rv, bp)
+	if stream, ok := resp.(responseStream); ok {
+		rv.stream = stream
+	} else if isEventStream(req) {
+		rv.stream = newEventStream(rv)
+	} else {
+		rv.writer = NewBufferedWriter(rv, bp)
+	}
+
+	if rv.stream != nil {
+		rv.progress = _PROGRESS_INTERVAL
+		if err == nil {
+			param, err = httpArgs.getString(PROGRESS_INTERVAL, "")
+			if err == nil && param != "" {
+				rv.progress, err = newDuration(param)
+			}
+		}
+	}
 
 	// Abort if client closes connection; alternatively, return when request completes.
 	rv.httpCloseNotify = resp.(http.CloseNotifier).CloseNotify()
@@ -408,6 +427,7 @@ const ( // Request argument names
 	CONTROLS          = "controls"
 	N1QL_FEAT_CTRL    = "n1ql_feat_ctrl"
 	MAX_INDEX_API     = "max_index_api"
+	PROGRESS_INTERVAL = "progress_interval"
 )
 
 var _PARAMETERS = []string{
@@ -438,6 +458,7 @@ var _PARAMETERS = []string{
 	CONTROLS,
 	N1QL_FEAT_CTRL,
 	MAX_INDEX_API,
+	PROGRESS_INTERVAL,
 }
 
 func isValidParameter(a string) bool {
@@ -702,6 +723,11 @@ func contentNegotiation(resp http.ResponseWriter, req *http.Request) errors.Erro
 		return nil
 	}
 	desiredContent := accept[0]
+	// streamed response
+	if strings.HasPrefix(desiredContent, eventStreamType) {
+		resp.Header().Set("Content-Type", eventStreamType)
+		return nil
+	}
 	// media type must be application/json at least
 	if !strings.HasPrefix(desiredContent, acceptType) {
 		return errors.NewServiceErrorMediaType(desiredContent)
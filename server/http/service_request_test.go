@@ -10,9 +10,12 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -21,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	acct_resolver "github.com/couchbase/query/accounting/resolver"
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/datastore/resolver"
 	"github.com/couchbase/query/errors"
@@ -458,3 +462,258 @@ func doJsonEncodedPost(payload map[string]interface{}) (*http.Response, error) {
 
 	return res, nil
 }
+
+func TestEventStream(t *testing.T) {
+	payload := url.Values{}
+	payload.Set("statement", "select 1 as one")
+	payload.Set("client_context_id", "sse")
+
+	u, _ := url.ParseRequestURI(test_server.URL())
+	u.Path = "/"
+	req, err := http.NewRequest("POST", u.String(), bytes.NewBufferString(payload.Encode()))
+	if err != nil {
+		t.Fatalf("Unexpected error in HTTP request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", eventStreamType)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error in HTTP request: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.Header.Get("Content-Type") != eventStreamType {
+		t.Errorf("Expected content type %v, actual %v", eventStreamType, res.Header.Get("Content-Type"))
+	}
+
+	// collect event names and data
+	var events []string
+	var data []map[string]interface{}
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			events = append(events, line[len("event: "):])
+		} else if strings.HasPrefix(line, "data: ") {
+			var msg map[string]interface{}
+			json.Unmarshal([]byte(line[len("data: "):]), &msg)
+			data = append(data, msg)
+		}
+	}
+	if strings.Join(events, ",") != "start,result,end" {
+		t.Fatalf("Expected events start,result,end, actual %v", events)
+	}
+	if data[0]["clientContextID"] != "sse" {
+		t.Errorf("Expected client context id sse, actual %v", data[0]["clientContextID"])
+	}
+	if r, ok := data[1]["result"].(map[string]interface{}); !ok || r["one"] != 1.0 {
+		t.Errorf("Expected result {\"one\": 1}, actual %v", data[1]["result"])
+	}
+	if data[2]["status"] != "success" {
+		t.Errorf("Expected status success, actual %v", data[2]["status"])
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	acctstore, err := acct_resolver.NewAcctstore("stub:")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	channel := make(server.RequestChannel, 10)
+	plusChannel := make(server.RequestChannel, 10)
+	srvr, err := server.NewServer(test_server.query_server.Datastore(), nil, nil, acctstore, "default",
+		false, channel, plusChannel, 4, 4, 0, 0, false, false, false, true, server.ProfOff, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	srvr.SetRequestSizeCap(1 << 16)
+	go srvr.Serve()
+	server.RequestsInit(0, 8)
+	endpoint := &HttpEndpoint{
+		server:  srvr,
+		bufpool: NewSyncPool(1024),
+		actives: NewActiveRequests(),
+	}
+	ws_server := httptest.NewServer(http.HandlerFunc(endpoint.serveWebSocket))
+	defer ws_server.Close()
+
+	conn, e := dialWebSocket(ws_server.URL)
+	if e != nil {
+		t.Fatalf("Unexpected error opening WebSocket: %v", e)
+	}
+	defer conn.Close()
+
+	receive := func() map[string]interface{} {
+		var msg map[string]interface{}
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		_, data, e := conn.ReadMessage()
+		if e == nil {
+			e = json.Unmarshal(data, &msg)
+		}
+		if e != nil {
+			t.Fatalf("Unexpected error reading message: %v", e)
+		}
+		return msg
+	}
+	send := func(msg map[string]interface{}) {
+		data, _ := json.Marshal(msg)
+		e := conn.WriteMessage(wsText, data)
+		if e != nil {
+			t.Fatalf("Unexpected error sending message: %v", e)
+		}
+	}
+
+	// a short request
+	send(map[string]interface{}{"statement": "select $1 as one", "args": []interface{}{1}})
+	msg := receive()
+	if msg["type"] != "start" {
+		t.Fatalf("Expected start message, actual %v", msg)
+	}
+	id := msg["requestID"]
+	msg = receive()
+	if r, ok := msg["result"].(map[string]interface{}); msg["type"] != "result" || msg["requestID"] != id ||
+		!ok || r["one"] != 1.0 {
+		t.Errorf("Expected result {\"one\": 1}, actual %v", msg)
+	}
+	msg = receive()
+	if msg["type"] != "end" || msg["status"] != "success" {
+		t.Errorf("Expected successful end message, actual %v", msg)
+	}
+
+	// errors
+	send(map[string]interface{}{"statement": "select from"})
+	for _, typ := range []string{"start", "error", "end"} {
+		msg = receive()
+		if msg["type"] != typ {
+			t.Errorf("Expected %v message, actual %v", typ, msg)
+		}
+	}
+	send(map[string]interface{}{"type": "nonsense"})
+	msg = receive()
+	if msg["type"] != "error" || msg["code"] != 1180.0 {
+		t.Errorf("Expected protocol error, actual %v", msg)
+	}
+
+	// progress and cancel
+	send(map[string]interface{}{"statement": "select raw j from p0:b0 b use keys " +
+		"array to_string(i) for i in array_range(0, 10000) end unnest array_range(0, 1000) j",
+		"client_context_id": "long", "progress_interval": "1ms"})
+	msg = receive()
+	if msg["type"] != "start" {
+		t.Fatalf("Expected start message, actual %v", msg)
+	}
+	send(map[string]interface{}{"type": "cancel", "client_context_id": "long"})
+	for msg["type"] != "end" {
+		msg = receive()
+		if msg["type"] == "progress" {
+			if _, ok := msg["elapsedTime"]; !ok {
+				t.Errorf("Expected elapsed time in progress message, actual %v", msg)
+			}
+		}
+	}
+	if msg["status"] != string(server.STOPPED) {
+		t.Errorf("Expected status %v, actual %v", server.STOPPED, msg["status"])
+	}
+}
+
+// open a WebSocket to a test server
+func dialWebSocket(serverURL string) (*websocketConn, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	req, _ := http.NewRequest("GET", serverURL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", _WS_VERSION)
+	req.Header.Set("Sec-WebSocket-Key", key)
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err == nil && (resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key)) {
+		err = fmt.Errorf("handshake failed: %v", resp.Status)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &websocketConn{
+		conn:   conn,
+		reader: reader,
+		writer: bufio.NewWriter(conn),
+		client: true,
+	}, nil
+}
+
+func TestWebSocketFrames(t *testing.T) {
+	c1, c2 := net.Pipe()
+	client := &websocketConn{conn: c1, reader: bufio.NewReader(c1), writer: bufio.NewWriter(c1), client: true}
+	srv := &websocketConn{conn: c2, reader: bufio.NewReader(c2), writer: bufio.NewWriter(c2)}
+	srv.SetReadLimit(1 << 10)
+	defer c1.Close()
+	defer c2.Close()
+
+	// masked with a zero key, which leaves the payload as it is
+	frame := func(fin bool, opcode int, payload string) []byte {
+		b0 := byte(opcode)
+		if fin {
+			b0 |= 0x80
+		}
+		return append([]byte{b0, 0x80 | byte(len(payload)), 0, 0, 0, 0}, payload...)
+	}
+
+	// what the server sends back
+	type reply struct {
+		opcode  int
+		payload []byte
+	}
+	replies := make(chan reply, 4)
+	go func() {
+		for {
+			_, opcode, payload, err := client.readFrame()
+			if err != nil {
+				close(replies)
+				return
+			}
+			replies <- reply{opcode, payload}
+		}
+	}()
+
+	// a fragmented message with a ping in between, then one over the limit
+	go func() {
+		c1.Write(frame(false, wsText, "hello, "))
+		c1.Write(frame(true, wsPing, "ping"))
+		c1.Write(frame(true, wsContinuation, "world"))
+		c1.Write([]byte{0x80 | wsBinary, 0x80 | 126, 0xff, 0xff})
+	}()
+
+	msgType, data, err := srv.ReadMessage()
+	if err != nil || msgType != wsText || string(data) != "hello, world" {
+		t.Errorf("Expected text message \"hello, world\", actual %v %q %v", msgType, data, err)
+	}
+	r := <-replies
+	if r.opcode != wsPong || string(r.payload) != "ping" {
+		t.Errorf("Expected pong, actual %v %q", r.opcode, r.payload)
+	}
+
+	_, _, err = srv.ReadMessage()
+	if wsErr, ok := err.(*wsError); !ok || wsErr.status != wsCloseTooBig {
+		t.Errorf("Expected message too big error, actual %v", err)
+	}
+	r = <-replies
+	if r.opcode != wsClose || len(r.payload) != 2 || int(r.payload[0])<<8|int(r.payload[1]) != wsCloseTooBig {
+		t.Errorf("Expected close frame with status %v, actual %v %v", wsCloseTooBig, r.opcode, r.payload)
+	}
+}
@@ -98,6 +98,11 @@ func (this *httpRequest) setHttpCode(httpRespCode int) {
 }
 
 func (this *httpRequest) Failed(srvr *server.Server) {
+	if this.stream != nil {
+		this.failedStream(srvr)
+		return
+	}
+
 	defer this.stopAndClose(server.FATAL)
 
 	prefix, indent := this.prettyStrings(srvr.Pretty(), false)
@@ -125,6 +130,11 @@ func (this *httpRequest) markTimeOfCompletion() {
 func (this *httpRequest) Execute(srvr *server.Server, signature value.Value, stopNotify execution.Operator) {
 	this.NotifyStop(stopNotify)
 
+	if this.stream != nil {
+		this.executeStream(srvr, signature)
+		return
+	}
+
 	prefix, indent := this.prettyStrings(srvr.Pretty(), false)
 
 	this.setHttpCode(http.StatusOK)
@@ -341,6 +351,7 @@ loop:
 				}
 				ok = this.writeError(err, this.errorCount, prefix, indent)
 				this.errorCount++
+				this.AddErrorCode(err.Code())
 			}
 		default:
 			break loop
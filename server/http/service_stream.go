@@ -0,0 +1,361 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/server"
+	"github.com/couchbase/query/value"
+)
+
+// Streamed responses.
+// Rather than a single JSON document, the response is a sequence of
+// messages, each a JSON object with a "type" and a "requestID" field:
+//
+//	start:    clientContextID, signature
+//	result:   result
+//	error:    code, msg
+//	warning:  code, msg
+//	progress: elapsedTime, resultCount, mutationCount, scanCount, phaseCounts
+//	end:      status, metrics, profile
+//
+// Messages are sent as soon as they are available, rather than buffered.
+// Streams are delivered either as Server-Sent Events, for requests accepting
+// text/event-stream, or over a WebSocket connection.
+
+const eventStreamType = "text/event-stream"
+
+const _PROGRESS_INTERVAL = time.Second
+
+// responseStream is implemented by destinations of streamed responses
+type responseStream interface {
+	send(msg map[string]interface{}) bool // push a message to the client
+	noMoreData()                          // action to take when the response is complete
+}
+
+func isEventStream(req *http.Request) bool {
+	accept := req.Header["Accept"]
+	return accept != nil && strings.HasPrefix(accept[0], eventStreamType)
+}
+
+func (this *httpRequest) sendMessage(typ string, msg map[string]interface{}) bool {
+	if msg == nil {
+		msg = make(map[string]interface{}, 2)
+	}
+	msg["type"] = typ
+	msg["requestID"] = this.Id().String()
+	return this.stream.send(msg)
+}
+
+func (this *httpRequest) executeStream(srvr *server.Server, signature value.Value) {
+	this.setHttpCode(http.StatusOK)
+	this.sendStart(srvr.Signature(), signature)
+	stopped := this.streamResults()
+
+	this.markTimeOfCompletion()
+
+	this.sendEnd(srvr)
+	this.stream.noMoreData()
+	if stopped {
+		this.Close()
+	} else {
+		this.stopAndClose(server.COMPLETED)
+	}
+}
+
+func (this *httpRequest) failedStream(srvr *server.Server) {
+	defer this.stopAndClose(server.FATAL)
+
+	this.sendStart(false, nil)
+
+	this.markTimeOfCompletion()
+
+	this.sendEnd(srvr)
+	this.stream.noMoreData()
+}
+
+func (this *httpRequest) sendStart(server_flag bool, signature value.Value) bool {
+	msg := make(map[string]interface{}, 4)
+	if this.ClientID().IsValid() {
+		msg["clientContextID"] = this.ClientID().String()
+	}
+	s := this.Signature()
+	if signature != nil && s != value.FALSE && (s != value.NONE || server_flag) {
+		msg["signature"] = signature
+	}
+	return this.sendMessage("start", msg)
+}
+
+// returns true if the request has already been stopped
+// (eg through timeout, cancel or delete)
+func (this *httpRequest) streamResults() bool {
+	var item value.Value
+	var buf bytes.Buffer
+	var progress <-chan time.Time
+
+	if this.progress > 0 {
+		ticker := time.NewTicker(this.progress)
+		defer ticker.Stop()
+		progress = ticker.C
+	}
+
+	ok := true
+	for ok {
+		select {
+		case <-this.StopExecute():
+			this.SetState(server.STOPPED)
+			return true
+		case <-this.httpCloseNotify:
+			this.SetState(server.CLOSED)
+			return false
+		default:
+		}
+
+		if !this.sendErrors() {
+			this.SetState(server.CLOSED)
+			return false
+		}
+
+		select {
+		case item, ok = <-this.Results():
+			if this.Halted() {
+				return true
+			}
+
+			if ok && !this.sendResult(item, &buf) {
+				return false
+			}
+		case <-progress:
+			if !this.sendProgress() {
+				this.SetState(server.CLOSED)
+				return false
+			}
+		case <-this.StopExecute():
+			this.SetState(server.STOPPED)
+			return true
+		case <-this.httpCloseNotify:
+			this.SetState(server.CLOSED)
+			return false
+		}
+	}
+
+	this.SetState(server.COMPLETED)
+	return false
+}
+
+func (this *httpRequest) sendResult(item value.Value, buf *bytes.Buffer) bool {
+	buf.Reset()
+	err := item.WriteJSON(buf, "", "")
+
+	// item won't be used past this point
+	item.Recycle()
+
+	if err != nil {
+		this.Errors() <- errors.NewServiceErrorInvalidJSON(err)
+		this.SetState(server.FATAL)
+		return false
+	}
+
+	// the message is marshalled before send returns, so no need to copy
+	result := json.RawMessage(bytes.TrimSpace(buf.Bytes()))
+	if !this.sendMessage("result", map[string]interface{}{"result": result}) {
+		this.SetState(server.CLOSED)
+		return false
+	}
+	this.resultSize += len(result)
+	this.resultCount++
+	return true
+}
+
+func (this *httpRequest) sendProgress() bool {
+	msg := map[string]interface{}{
+		"elapsedTime": time.Since(this.RequestTime()).String(),
+		"resultCount": this.resultCount,
+	}
+	if this.MutationCount() > 0 {
+		msg["mutationCount"] = this.MutationCount()
+	}
+	phaseCounts := this.FmtPhaseCounts()
+	if phaseCounts != nil {
+		var scanCount uint64
+
+		for _, phase := range []execution.Phases{execution.INDEX_SCAN, execution.PRIMARY_SCAN} {
+			count, _ := phaseCounts[phase.String()].(uint64)
+			scanCount += count
+		}
+		msg["scanCount"] = scanCount
+		msg["phaseCounts"] = phaseCounts
+	}
+	return this.sendMessage("progress", msg)
+}
+
+// send whatever errors and warnings have been produced so far
+func (this *httpRequest) sendErrors() bool {
+	var err errors.Error
+
+	ok := true
+	for ok {
+		select {
+		case err = <-this.Errors():
+			if this.errorCount == 0 && this.State() != server.FATAL {
+				this.setHttpCode(mapErrorToHttpResponse(err, http.StatusOK))
+			}
+			ok = this.sendError("error", err)
+			this.errorCount++
+			this.AddErrorCode(err.Code())
+		case err = <-this.Warnings():
+			if err.OnceOnly() && this.warningsSeen[err.Error()] {
+				continue
+			}
+			ok = this.sendError("warning", err)
+			this.warningCount++
+			if this.warningsSeen == nil {
+				this.warningsSeen = make(map[string]bool)
+			}
+			this.warningsSeen[err.Error()] = true
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func (this *httpRequest) sendError(typ string, err errors.Error) bool {
+	return this.sendMessage(typ, map[string]interface{}{
+		"code": err.Code(),
+		"msg":  err.Error(),
+	})
+}
+
+func (this *httpRequest) sendEnd(srvr *server.Server) bool {
+	if !this.sendErrors() {
+		return false
+	}
+
+	msg := map[string]interface{}{
+		"status": this.EventStatus(),
+	}
+	m := this.Metrics()
+	if m == value.TRUE || (m == value.NONE && srvr.Metrics()) {
+		metrics := map[string]interface{}{
+			"elapsedTime":   this.elapsedTime.String(),
+			"executionTime": this.executionTime.String(),
+			"resultCount":   this.resultCount,
+			"resultSize":    this.resultSize,
+		}
+		if this.MutationCount() > 0 {
+			metrics["mutationCount"] = this.MutationCount()
+		}
+		if this.SortCount() > 0 {
+			metrics["sortCount"] = this.SortCount()
+		}
+		if this.errorCount > 0 {
+			metrics["errorCount"] = this.errorCount
+		}
+		if this.warningCount > 0 {
+			metrics["warningCount"] = this.warningCount
+		}
+		msg["metrics"] = metrics
+	}
+	p := this.Profile()
+	if p == server.ProfUnset {
+		p = srvr.Profile()
+	}
+	if p != server.ProfOff {
+		profile := make(map[string]interface{}, 4)
+		if phaseTimes := this.FmtPhaseTimes(); phaseTimes != nil {
+			profile["phaseTimes"] = phaseTimes
+		}
+		if phaseCounts := this.FmtPhaseCounts(); phaseCounts != nil {
+			profile["phaseCounts"] = phaseCounts
+		}
+		if phaseOperators := this.FmtPhaseOperators(); phaseOperators != nil {
+			profile["phaseOperators"] = phaseOperators
+		}
+		if p == server.ProfOn {
+			if timings := this.GetTimings(); timings != nil {
+				profile["executionTimings"] = timings
+			}
+		}
+		msg["profile"] = profile
+	}
+	return this.sendMessage("end", msg)
+}
+
+// eventStream is an implementation of responseStream that sends
+// each message as a Server-Sent Event, named after the message type.
+type eventStream struct {
+	sync.Mutex
+	req    *httpRequest
+	closed bool
+	header bool // headers required
+}
+
+func newEventStream(r *httpRequest) *eventStream {
+	return &eventStream{
+		req:    r,
+		header: true,
+	}
+}
+
+func (this *eventStream) send(msg map[string]interface{}) bool {
+	bytes, err := json.Marshal(msg)
+	if err != nil {
+		return false
+	}
+
+	this.Lock()
+	defer this.Unlock()
+
+	if this.closed {
+		return false
+	}
+
+	w := this.req.resp
+	this.writeHeader()
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg["type"], bytes)
+	if err != nil {
+		return false
+	}
+	w.(http.Flusher).Flush()
+	return true
+}
+
+func (this *eventStream) writeHeader() {
+	if this.header {
+		h := this.req.resp.Header()
+		h.Set("Content-Type", eventStreamType)
+		h.Set("Cache-Control", "no-cache")
+		this.req.resp.WriteHeader(this.req.httpCode())
+		this.header = false
+	}
+}
+
+func (this *eventStream) noMoreData() {
+	this.Lock()
+	defer this.Unlock()
+
+	if this.closed {
+		return
+	}
+
+	this.writeHeader()
+	this.req.req.Body.Close()
+	this.closed = true
+}
@@ -0,0 +1,228 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/server"
+)
+
+// WebSocket connections.
+// Each text message sent by the client is a JSON object, either a request,
+// with the same fields as a JSON encoded request to the query service, or
+// a cancel message:
+//
+//	{ "type": "cancel", "requestID": "..." }
+//	{ "type": "cancel", "client_context_id": "..." }
+//	{ "type": "cancel" }
+//
+// which stops the matching requests, or all the requests on the connection.
+// Requests run concurrently, and the response to each is streamed back
+// as a sequence of messages tagged with its request id.
+// Requests are authenticated with the credentials in the request, or those
+// used to open the connection.
+
+const (
+	websocketPrefix = servicePrefix + "/ws"
+)
+
+type wsConn struct {
+	sync.Mutex
+	endpoint    *HttpEndpoint
+	conn        *websocketConn
+	req         *http.Request // the upgrade request
+	closeNotify chan bool
+	requests    map[string]*httpRequest
+}
+
+func (this *HttpEndpoint) serveWebSocket(resp http.ResponseWriter, req *http.Request) {
+	conn, err := upgradeWebSocket(resp, req)
+	if err != nil {
+
+		// the client has already been replied to
+		logging.Infop("HttpEndpoint: websocket upgrade", logging.Pair{"error", err})
+		return
+	}
+	c := &wsConn{
+		endpoint:    this,
+		conn:        conn,
+		req:         req,
+		closeNotify: make(chan bool),
+		requests:    make(map[string]*httpRequest),
+	}
+	c.serve()
+}
+
+func (this *wsConn) serve() {
+	var wg sync.WaitGroup
+
+	defer func() {
+
+		// let outstanding requests know that the client has gone away
+		close(this.closeNotify)
+		this.conn.Close()
+		wg.Wait()
+	}()
+
+	this.conn.SetReadLimit(int64(this.endpoint.server.RequestSizeCap()))
+	for {
+		msgType, data, err := this.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if msgType != wsText {
+			continue
+		}
+
+		var msg map[string]json.RawMessage
+
+		err = json.Unmarshal(data, &msg)
+		if err != nil {
+			this.sendError(errors.NewServiceErrorProtocol("messages must be JSON objects"))
+			continue
+		}
+
+		var typ string
+
+		if t, ok := msg["type"]; ok {
+			json.Unmarshal(t, &typ)
+			delete(msg, "type")
+		}
+		switch typ {
+		case "", "request":
+			request := this.newRequest(msg)
+			if request != nil {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer this.remove(request)
+					this.endpoint.serveRequest(request)
+				}()
+			}
+		case "cancel":
+			var id, clientId string
+
+			json.Unmarshal(msg["requestID"], &id)
+			json.Unmarshal(msg[CLIENT_CONTEXT_ID], &clientId)
+			this.cancel(id, clientId)
+		default:
+			this.sendError(errors.NewServiceErrorProtocol("unknown message type " + typ))
+		}
+	}
+}
+
+// each request is processed as if it had been sent as an HTTP POST with
+// a JSON body
+func (this *wsConn) newRequest(msg map[string]json.RawMessage) *httpRequest {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		this.sendError(errors.NewServiceErrorProtocol(err.Error()))
+		return nil
+	}
+
+	req := new(http.Request)
+	*req = *this.req
+	req.Method = "POST"
+	req.Header = make(http.Header, len(this.req.Header))
+	for k, v := range this.req.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Del("Accept")
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Form = nil
+	req.PostForm = nil
+
+	resp := &wsResponse{
+		conn:   this,
+		header: make(http.Header),
+	}
+	request := newHttpRequest(resp, req, this.endpoint.bufpool, this.endpoint.server.RequestSizeCap())
+	this.Lock()
+	this.requests[request.Id().String()] = request
+	this.Unlock()
+	return request
+}
+
+func (this *wsConn) remove(request *httpRequest) {
+	this.Lock()
+	delete(this.requests, request.Id().String())
+	this.Unlock()
+}
+
+// stop the operator tree of the requests matching either id,
+// or of all requests if none is specified
+func (this *wsConn) cancel(id, clientId string) {
+	this.Lock()
+	defer this.Unlock()
+	for reqId, request := range this.requests {
+		if (id == "" && clientId == "") || reqId == id ||
+			(clientId != "" && request.ClientID().String() == clientId) {
+			request.Stop(server.STOPPED)
+		}
+	}
+}
+
+func (this *wsConn) write(msg interface{}) bool {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return false
+	}
+
+	// the connection serializes the writes, under a lock separate from
+	// the request map lock, so that cancelling is not held up by slow
+	// clients
+	return this.conn.WriteMessage(wsText, data) == nil
+}
+
+func (this *wsConn) sendError(err errors.Error) bool {
+	return this.write(map[string]interface{}{
+		"type": "error",
+		"code": err.Code(),
+		"msg":  err.Error(),
+	})
+}
+
+// wsResponse stands in for the http.ResponseWriter of requests sent over
+// a WebSocket, and streams their response back
+type wsResponse struct {
+	conn   *wsConn
+	header http.Header
+}
+
+func (this *wsResponse) Header() http.Header {
+	return this.header
+}
+
+func (this *wsResponse) Write(b []byte) (int, error) {
+	return 0, http.ErrHijacked
+}
+
+func (this *wsResponse) WriteHeader(int) {
+}
+
+func (this *wsResponse) CloseNotify() <-chan bool {
+	return this.conn.closeNotify
+}
+
+func (this *wsResponse) send(msg map[string]interface{}) bool {
+	return this.conn.write(msg)
+}
+
+func (this *wsResponse) noMoreData() {
+}
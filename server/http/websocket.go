@@ -0,0 +1,334 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The WebSocket protocol (RFC 6455), as far as the query service needs it:
+// the opening handshake, text and binary messages, possibly fragmented,
+// and the ping, pong and close control frames.
+// Neither extensions nor subprotocols are supported.
+
+const (
+	_WS_ACCEPT_GUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	_WS_VERSION     = "13"
+
+	_WS_MAX_CONTROL = 125
+)
+
+// frame opcodes
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// close status codes
+const (
+	wsCloseNormal   = 1000
+	wsCloseProtocol = 1002
+	wsCloseTooBig   = 1009
+)
+
+type wsError struct {
+	status int
+	msg    string
+}
+
+func (this *wsError) Error() string {
+	return fmt.Sprintf("websocket: %s (%d)", this.msg, this.status)
+}
+
+type websocketConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	writer    *bufio.Writer
+	client    bool  // the client end masks the frames it sends
+	readLimit int64 // maximum message size, zero for no limit
+
+	writeLock sync.Mutex
+	closed    bool
+}
+
+// complete the opening handshake and take over the connection
+// on failure, the client has already been sent an error response
+func upgradeWebSocket(resp http.ResponseWriter, req *http.Request) (*websocketConn, error) {
+	fail := func(status int, msg string) (*websocketConn, error) {
+		resp.Header().Set("Sec-WebSocket-Version", _WS_VERSION)
+		http.Error(resp, msg, status)
+		return nil, fmt.Errorf("websocket: %s", msg)
+	}
+
+	if req.Method != "GET" {
+		return fail(http.StatusMethodNotAllowed, "the opening handshake must be a GET")
+	}
+	if !headerHasToken(req.Header, "Connection", "upgrade") ||
+		!headerHasToken(req.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "not a websocket upgrade request")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != _WS_VERSION {
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := resp.(http.Hijacker)
+	if !ok {
+		return fail(http.StatusInternalServerError, "the connection cannot be taken over")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n\r\n")
+	err = rw.Flush()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &websocketConn{
+		conn:   conn,
+		reader: rw.Reader,
+		writer: rw.Writer,
+	}, nil
+}
+
+func websocketAccept(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+_WS_ACCEPT_GUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// whether a comma separated header lists the token
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (this *websocketConn) SetReadLimit(limit int64) {
+	this.readLimit = limit
+}
+
+func (this *websocketConn) SetReadDeadline(t time.Time) error {
+	return this.conn.SetReadDeadline(t)
+}
+
+// returns the next text or binary message, answering control frames
+// as they come
+// returns io.EOF once the peer has closed the connection
+func (this *websocketConn) ReadMessage() (int, []byte, error) {
+	var msgType int
+	var msg []byte
+
+	for {
+		fin, opcode, payload, err := this.readFrame()
+		if err != nil {
+			if wsErr, ok := err.(*wsError); ok {
+				this.writeClose(wsErr.status)
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case wsPing:
+			err = this.writeFrame(wsPong, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			this.writeClose(wsCloseNormal)
+			return 0, nil, io.EOF
+		case wsText, wsBinary:
+			if msgType != 0 {
+				err = &wsError{wsCloseProtocol, "expected a continuation frame"}
+			}
+			msgType = opcode
+		case wsContinuation:
+			if msgType == 0 {
+				err = &wsError{wsCloseProtocol, "unexpected continuation frame"}
+			}
+		default:
+			err = &wsError{wsCloseProtocol, fmt.Sprintf("unknown opcode %d", opcode)}
+		}
+		if err == nil && this.readLimit > 0 && int64(len(msg)+len(payload)) > this.readLimit {
+			err = &wsError{wsCloseTooBig, "message too big"}
+		}
+		if err != nil {
+			this.writeClose(err.(*wsError).status)
+			return 0, nil, err
+		}
+
+		msg = append(msg, payload...)
+		if fin {
+			return msgType, msg, nil
+		}
+	}
+}
+
+func (this *websocketConn) readFrame() (bool, int, []byte, error) {
+	var header [8]byte
+
+	_, err := io.ReadFull(this.reader, header[:2])
+	if err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, &wsError{wsCloseProtocol, "no extension was negotiated"}
+	}
+	if masked == this.client {
+		return false, 0, nil, &wsError{wsCloseProtocol, "invalid frame masking"}
+	}
+
+	switch length {
+	case 126:
+		_, err = io.ReadFull(this.reader, header[:2])
+		length = uint64(binary.BigEndian.Uint16(header[:2]))
+	case 127:
+		_, err = io.ReadFull(this.reader, header[:8])
+		length = binary.BigEndian.Uint64(header[:8])
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if opcode >= wsClose && (!fin || length > _WS_MAX_CONTROL) {
+		return false, 0, nil, &wsError{wsCloseProtocol, "invalid control frame"}
+	}
+	if this.readLimit > 0 && length > uint64(this.readLimit) {
+		return false, 0, nil, &wsError{wsCloseTooBig, "message too big"}
+	}
+
+	var mask [4]byte
+	if masked {
+		_, err = io.ReadFull(this.reader, mask[:])
+		if err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(this.reader, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// send a message in a single frame
+func (this *websocketConn) WriteMessage(msgType int, data []byte) error {
+	return this.writeFrame(msgType, data)
+}
+
+func (this *websocketConn) writeFrame(opcode int, payload []byte) error {
+	var header [14]byte
+
+	this.writeLock.Lock()
+	defer this.writeLock.Unlock()
+
+	if this.closed {
+		return io.ErrClosedPipe
+	}
+	if opcode == wsClose {
+		this.closed = true
+	}
+
+	header[0] = 0x80 | byte(opcode)
+	n := 2
+	length := len(payload)
+	switch {
+	case length <= _WS_MAX_CONTROL:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+		n += 2
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+		n += 8
+	}
+	if this.client {
+		var mask [4]byte
+
+		_, err := rand.Read(mask[:])
+		if err != nil {
+			return err
+		}
+		header[1] |= 0x80
+		copy(header[n:], mask[:])
+		n += 4
+		masked := make([]byte, length)
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+
+	_, err := this.writer.Write(header[:n])
+	if err == nil {
+		_, err = this.writer.Write(payload)
+	}
+	if err == nil {
+		err = this.writer.Flush()
+	}
+	return err
+}
+
+func (this *websocketConn) writeClose(status int) {
+	var payload [2]byte
+
+	binary.BigEndian.PutUint16(payload[:], uint16(status))
+	this.writeFrame(wsClose, payload[:])
+}
+
+// send a close frame, if not already sent, and drop the connection
+func (this *websocketConn) Close() error {
+	this.writeClose(wsCloseNormal)
+	return this.conn.Close()
+}
@@ -0,0 +1,749 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package pgwire
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/planner"
+	"github.com/couchbase/query/server"
+	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/value"
+)
+
+// a statement parsed through the extended query protocol
+type pgStatement struct {
+	prepared *plan.Prepared // planned for the connection alone
+	types    []int32        // parameter types, as declared by the client
+	columns  []*column
+}
+
+// a statement bound to its parameters
+type pgPortal struct {
+	statement *pgStatement
+	args      value.Values
+}
+
+type pgConn struct {
+	sync.Mutex
+	endpoint   *PgEndpoint
+	conn       net.Conn
+	reader     *bufio.Reader
+	writer     *bufio.Writer
+	out        outMessage
+	pid        uint32
+	key        uint32
+	creds      auth.Credentials
+	namespace  string
+	userAgent  string
+	statements map[string]*pgStatement
+	portals    map[string]*pgPortal
+	failed     bool       // skip extended protocol messages until the next Sync
+	current    *pgRequest // the request being executed, for cancel requests
+}
+
+func newPgConn(endpoint *PgEndpoint, conn net.Conn) *pgConn {
+	return &pgConn{
+		endpoint:   endpoint,
+		conn:       conn,
+		reader:     bufio.NewReader(conn),
+		writer:     bufio.NewWriter(conn),
+		statements: make(map[string]*pgStatement),
+		portals:    make(map[string]*pgPortal),
+	}
+}
+
+func (this *pgConn) serve() {
+	defer this.conn.Close()
+
+	if !this.startup() {
+		this.writer.Flush()
+		return
+	}
+	defer this.endpoint.unregister(this.pid)
+	defer this.closeStatements()
+
+	for {
+		typ, msg, err := readMessage(this.reader, true)
+		if err != nil {
+			return
+		}
+
+		// after an error, the extended protocol ignores everything
+		// up to the next Sync
+		if this.failed && typ != _MSG_SYNC {
+			continue
+		}
+		switch typ {
+		case _MSG_QUERY:
+			this.simpleQuery(msg)
+		case _MSG_PARSE:
+			this.parse(msg)
+		case _MSG_BIND:
+			this.bind(msg)
+		case _MSG_DESCRIBE:
+			this.describe(msg)
+		case _MSG_EXECUTE:
+			this.execute(msg)
+		case _MSG_CLOSE:
+			this.close(msg)
+		case _MSG_SYNC:
+			this.failed = false
+			this.sendReadyForQuery()
+		case _MSG_FLUSH:
+		case _MSG_TERMINATE:
+			return
+		default:
+			this.sendError(errors.NewServiceErrorProtocol("unknown message type " + string(typ)))
+			this.sendReadyForQuery()
+		}
+		if this.reader.Buffered() == 0 && this.writer.Flush() != nil {
+			return
+		}
+	}
+}
+
+// startup and authentication
+// returns false if the connection is not to be used for queries
+func (this *pgConn) startup() bool {
+	var params map[string]string
+
+	for params == nil {
+		_, msg, err := readMessage(this.reader, false)
+		if err != nil {
+			return false
+		}
+		switch msg.int32() {
+		case _SSL_REQUEST:
+			_, isTLS := this.conn.(*tls.Conn)
+			if this.endpoint.tlsConfig == nil || isTLS {
+				this.writer.WriteByte('N')
+				this.writer.Flush()
+				continue
+			}
+			this.writer.WriteByte('S')
+			this.writer.Flush()
+			this.conn = tls.Server(this.conn, this.endpoint.tlsConfig)
+			this.reader = bufio.NewReader(this.conn)
+			this.writer = bufio.NewWriter(this.conn)
+		case _CANCEL_REQUEST:
+			pid := uint32(msg.int32())
+			key := uint32(msg.int32())
+			if msg.err == nil {
+				this.endpoint.cancel(pid, key)
+			}
+			return false
+		case _PROTOCOL_VERSION:
+			params = make(map[string]string)
+			for msg.err == nil && len(msg.buf) > 1 {
+				name := msg.string()
+				params[name] = msg.string()
+			}
+		default:
+			this.sendError(errors.NewServiceErrorProtocol("unsupported protocol version"))
+			return false
+		}
+	}
+
+	// the password is requested in clear text, and should be protected
+	// by TLS
+	user := params["user"]
+	if user != "" {
+		this.out.start(_MSG_AUTHENTICATION)
+		this.out.int32(_AUTH_CLEARTEXT)
+		this.send()
+		this.writer.Flush()
+		typ, msg, err := readMessage(this.reader, true)
+		if err != nil {
+			return false
+		}
+		if typ != _MSG_PASSWORD {
+			this.sendError(errors.NewServiceErrorProtocol("password expected"))
+			return false
+		}
+		this.creds = auth.Credentials{user: msg.string()}
+
+		// datastores that authenticate return the users they authenticated
+		users, err1 := this.endpoint.server.Datastore().Authorize(nil, this.creds, nil)
+		if err1 == nil && users != nil && len(users) == 0 {
+			err1 = errors.NewDatastoreInvalidUsernamePassword()
+		}
+		if err1 != nil {
+			this.sendError(err1)
+			this.writer.Flush()
+			return false
+		}
+	}
+
+	// the database is used as namespace, if there is one by that name
+	database := params["database"]
+	if database != "" {
+		_, err := this.endpoint.server.Datastore().NamespaceByName(database)
+		if err == nil {
+			this.namespace = database
+		}
+	}
+	this.userAgent = params["application_name"]
+
+	this.pid = this.endpoint.register(this)
+	var key [4]byte
+	rand.Read(key[:])
+	this.key = binary.BigEndian.Uint32(key[:])
+
+	this.out.start(_MSG_AUTHENTICATION)
+	this.out.int32(_AUTH_OK)
+	this.send()
+	this.sendParameterStatus("server_version", "9.6.0")
+	this.sendParameterStatus("server_encoding", "UTF8")
+	this.sendParameterStatus("client_encoding", "UTF8")
+	this.sendParameterStatus("DateStyle", "ISO, MDY")
+	this.sendParameterStatus("integer_datetimes", "on")
+	this.sendParameterStatus("standard_conforming_strings", "on")
+	this.sendParameterStatus("application_name", this.userAgent)
+	this.out.start(_MSG_BACKEND_KEY_DATA)
+	this.out.int32(int32(this.pid))
+	this.out.int32(int32(this.key))
+	this.send()
+	this.sendReadyForQuery()
+	return this.writer.Flush() == nil
+}
+
+// simple query protocol
+
+func (this *pgConn) simpleQuery(msg *inMessage) {
+	text := trimStatement(msg.string())
+	if msg.err != nil {
+		this.sendError(msg.err)
+	} else if text == "" {
+		this.out.start(_MSG_EMPTY_QUERY)
+		this.send()
+	} else {
+		request := newPgRequest(this, text, nil, nil)
+		request.describe = true
+		this.run(request)
+		if this.report(request) {
+			this.sendCommandComplete(request.commandTag())
+		}
+	}
+	this.sendReadyForQuery()
+}
+
+// extended query protocol
+
+// statements belong to the connection: they are planned here rather
+// than in the prepared statement cache, which is shared by the cluster
+func (this *pgConn) parse(msg *inMessage) {
+	name := msg.string()
+	text := trimStatement(msg.string())
+	n := int(msg.int16())
+	types := make([]int32, 0, n)
+	for i := 0; i < n; i++ {
+		types = append(types, msg.int32())
+	}
+	if msg.err != nil {
+		this.fail(msg.err)
+		return
+	}
+
+	this.closeStatement(name)
+	prepared, err := this.plan(text)
+	if err != nil {
+		this.fail(err)
+		return
+	}
+	this.statements[name] = &pgStatement{
+		prepared: prepared,
+		types:    types,
+		columns:  signatureColumns(prepared.Signature(), prepared.Text()),
+	}
+	this.out.start(_MSG_PARSE_COMPLETE)
+	this.send()
+}
+
+func (this *pgConn) bind(msg *inMessage) {
+	portal := msg.string()
+	name := msg.string()
+	n := int(msg.int16())
+	formats := make([]int16, 0, n)
+	for i := 0; i < n; i++ {
+		formats = append(formats, msg.int16())
+	}
+	n = int(msg.int16())
+	params := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		l := msg.int32()
+		if l < 0 {
+			params = append(params, nil)
+		} else {
+			params = append(params, msg.bytes(int(l)))
+		}
+	}
+	n = int(msg.int16())
+	for i := 0; i < n; i++ {
+		if msg.int16() != _FORMAT_TEXT {
+			this.fail(errors.NewServiceErrorNotImplemented("result format", "binary"))
+			return
+		}
+	}
+	if msg.err != nil {
+		this.fail(msg.err)
+		return
+	}
+
+	statement, ok := this.statements[name]
+	if !ok {
+		this.fail(errors.NewNoSuchPreparedError(name))
+		return
+	}
+
+	// a single format applies to all parameters
+	args := make(value.Values, len(params))
+	for i, param := range params {
+		format := int16(_FORMAT_TEXT)
+		if len(formats) == 1 {
+			format = formats[0]
+		} else if i < len(formats) {
+			format = formats[i]
+		}
+		oid := int32(_OID_UNKNOWN)
+		if i < len(statement.types) {
+			oid = statement.types[i]
+		}
+		arg, err := paramValue(param, oid, format)
+		if err != nil {
+			this.fail(err)
+			return
+		}
+		args[i] = arg
+	}
+	this.portals[portal] = &pgPortal{statement: statement, args: args}
+	this.out.start(_MSG_BIND_COMPLETE)
+	this.send()
+}
+
+func (this *pgConn) describe(msg *inMessage) {
+	var statement *pgStatement
+
+	what := msg.byte()
+	name := msg.string()
+	if msg.err != nil {
+		this.fail(msg.err)
+		return
+	}
+	switch what {
+	case 'S':
+		statement = this.statements[name]
+		if statement == nil {
+			this.fail(errors.NewNoSuchPreparedError(name))
+			return
+		}
+		this.out.start(_MSG_PARAMETER_DESC)
+		this.out.int16(int16(len(statement.types)))
+		for _, oid := range statement.types {
+			this.out.int32(oid)
+		}
+		this.send()
+	case 'P':
+		portal := this.portals[name]
+		if portal == nil {
+			this.fail(errors.NewServiceErrorProtocol("unknown portal " + name))
+			return
+		}
+		statement = portal.statement
+	default:
+		this.fail(errors.NewServiceErrorProtocol("invalid describe"))
+		return
+	}
+	if len(statement.columns) == 0 {
+		this.out.start(_MSG_NO_DATA)
+		this.send()
+	} else {
+		this.sendRowDescription(statement.columns)
+	}
+}
+
+// the row limit is not supported: portals are always run to completion
+func (this *pgConn) execute(msg *inMessage) {
+	name := msg.string()
+	msg.int32()
+	if msg.err != nil {
+		this.fail(msg.err)
+		return
+	}
+	portal := this.portals[name]
+	if portal == nil {
+		this.fail(errors.NewServiceErrorProtocol("unknown portal " + name))
+		return
+	}
+
+	prepared, err := this.prepared(portal.statement)
+	if err != nil {
+		this.fail(err)
+		return
+	}
+	request := newPgRequest(this, "", prepared, portal.args)
+	request.columns = portal.statement.columns
+	this.run(request)
+	if this.report(request) {
+		this.sendCommandComplete(request.commandTag())
+	} else {
+		this.failed = true
+	}
+}
+
+func (this *pgConn) close(msg *inMessage) {
+	what := msg.byte()
+	name := msg.string()
+	if msg.err != nil {
+		this.fail(msg.err)
+		return
+	}
+	switch what {
+	case 'S':
+		this.closeStatement(name)
+	case 'P':
+		delete(this.portals, name)
+	default:
+		this.fail(errors.NewServiceErrorProtocol("invalid close"))
+		return
+	}
+	this.out.start(_MSG_CLOSE_COMPLETE)
+	this.send()
+}
+
+func (this *pgConn) plan(text string) (*plan.Prepared, errors.Error) {
+	stmt, err := n1ql.ParseStatement(text)
+	if err != nil {
+		return nil, errors.NewParseSyntaxError(err, "")
+	}
+	switch stmt.(type) {
+	case *algebra.Prepare, *algebra.Execute:
+		return nil, errors.NewServiceErrorNotImplemented("extended query protocol", stmt.Type())
+	}
+
+	srvr := this.endpoint.server
+	prepared, err := planner.BuildPrepared(stmt, srvr.Datastore(), srvr.Systemstore(), this.namespace,
+		false, nil, nil, util.GetMaxIndexAPI(), util.GetN1qlFeatureControl())
+	if err != nil {
+		return nil, errors.NewPlanError(err, "")
+	}
+	prepared.SetText(text)
+	prepared.SetType(stmt.Type())
+	prepared.SetIndexApiVersion(util.GetMaxIndexAPI())
+	prepared.SetFeatureControls(util.GetN1qlFeatureControl())
+	return prepared, nil
+}
+
+// statements are planned again if the metadata they depend on has changed
+func (this *pgConn) prepared(statement *pgStatement) (*plan.Prepared, errors.Error) {
+	prepared := statement.prepared
+	if prepared.MetadataCheck() && prepared.Verify() {
+		return prepared, nil
+	}
+	prepared, err := this.plan(prepared.Text())
+	if err != nil {
+		return nil, err
+	}
+	statement.prepared = prepared
+	return prepared, nil
+}
+
+func (this *pgConn) closeStatement(name string) {
+	delete(this.statements, name)
+}
+
+func (this *pgConn) closeStatements() {
+	for name := range this.statements {
+		this.closeStatement(name)
+	}
+}
+
+// request execution
+
+func (this *pgConn) run(request *pgRequest) {
+	this.Lock()
+	this.current = request
+	this.Unlock()
+
+	select {
+	case this.endpoint.server.Channel() <- request:
+		// Wait until the request exits.
+		<-request.CloseNotify()
+	default:
+		// Buffer is full.
+		request.Fail(errors.NewServiceErrorQueueFull())
+	}
+
+	this.Lock()
+	this.current = nil
+	this.Unlock()
+}
+
+func (this *pgConn) cancel() {
+	this.Lock()
+	defer this.Unlock()
+	if this.current != nil {
+		this.current.Stop(server.STOPPED)
+	}
+}
+
+// send errors and warnings
+// returns true if the request was successful
+func (this *pgConn) report(request *pgRequest) bool {
+	defer this.endpoint.doStats(request)
+
+	ok := true
+	for ok {
+		select {
+		case err := <-request.Warnings():
+			this.sendNotice(err)
+			request.warningCount++
+		default:
+			ok = false
+		}
+	}
+
+	// the protocol only allows one error
+	ok = true
+	for ok {
+		select {
+		case err := <-request.Errors():
+			if request.errorCount == 0 {
+				this.sendError(err)
+			}
+			request.AddErrorCode(err.Code())
+			request.errorCount++
+		default:
+			ok = false
+		}
+	}
+	if request.errorCount == 0 && request.State() != server.COMPLETED {
+		err := errors.NewServiceErrorProtocol("canceling statement due to user request")
+		this.sendError(err)
+		request.AddErrorCode(err.Code())
+		request.errorCount++
+	}
+	return request.errorCount == 0
+}
+
+// messages
+
+func (this *pgConn) send() {
+	this.writer.Write(this.out.finish())
+}
+
+func (this *pgConn) fail(err errors.Error) {
+	this.sendError(err)
+	this.failed = true
+}
+
+func (this *pgConn) sendReadyForQuery() {
+	this.out.start(_MSG_READY_FOR_QUERY)
+	this.out.byte('I')
+	this.send()
+}
+
+func (this *pgConn) sendParameterStatus(name, value string) {
+	this.out.start(_MSG_PARAMETER_STATUS)
+	this.out.string(name)
+	this.out.string(value)
+	this.send()
+}
+
+func (this *pgConn) sendCommandComplete(tag string) {
+	this.out.start(_MSG_COMMAND_COMPLETE)
+	this.out.string(tag)
+	this.send()
+}
+
+func (this *pgConn) sendRowDescription(columns []*column) {
+	if len(columns) == 0 {
+		return
+	}
+	this.out.start(_MSG_ROW_DESCRIPTION)
+	this.out.int16(int16(len(columns)))
+	for _, col := range columns {
+		this.out.string(col.name)
+		this.out.int32(0) // table
+		this.out.int16(0) // attribute
+		this.out.int32(col.oid)
+		if col.oid == _OID_BOOL {
+			this.out.int16(1)
+		} else {
+			this.out.int16(-1)
+		}
+		this.out.int32(-1) // type modifier
+		this.out.int16(_FORMAT_TEXT)
+	}
+	this.send()
+}
+
+// returns the size of the row
+func (this *pgConn) sendDataRow(columns []*column, item value.Value) int {
+	size := 0
+
+	// just in case there was no signature
+	if len(columns) == 0 {
+		columns = []*column{&column{name: "?column?", oid: _OID_JSON}}
+	}
+	this.out.start(_MSG_DATA_ROW)
+	this.out.int16(int16(len(columns)))
+	for _, col := range columns {
+		val := item
+		if col.field {
+			val, _ = item.Field(col.name)
+		}
+		var bytes []byte
+		if val != nil {
+			bytes = textValue(val)
+		}
+		if bytes == nil {
+			this.out.int32(-1)
+		} else {
+			this.out.int32(int32(len(bytes)))
+			this.out.bytes(bytes)
+			size += len(bytes)
+		}
+	}
+	this.send()
+	item.Recycle()
+	return size
+}
+
+func (this *pgConn) sendError(err errors.Error) {
+	this.sendErrorMessage(_MSG_ERROR_RESPONSE, "ERROR", err)
+}
+
+func (this *pgConn) sendNotice(err errors.Error) {
+	this.sendErrorMessage(_MSG_NOTICE_RESPONSE, "WARNING", err)
+}
+
+func (this *pgConn) sendErrorMessage(typ byte, severity string, err errors.Error) {
+	this.out.start(typ)
+	this.out.byte('S')
+	this.out.string(severity)
+	this.out.byte('V')
+	this.out.string(severity)
+	this.out.byte('C')
+	this.out.string(sqlState(err))
+	this.out.byte('M')
+	this.out.string(err.Error())
+	this.out.byte('D')
+	this.out.string("N1QL error code " + strconv.Itoa(int(err.Code())))
+	this.out.byte(0)
+	this.send()
+}
+
+// map N1QL error codes onto SQLSTATE codes
+func sqlState(err errors.Error) string {
+	code := err.Code()
+	switch {
+	case code == 1000:
+		return "25006" // read_only_sql_transaction
+	case code == 1020:
+		return "0A000" // feature_not_supported
+	case code == 1080:
+		return "57014" // query_canceled
+	case code == 1180:
+		return "08P01" // protocol_violation
+	case code == 1190:
+		return "53300" // too_many_connections
+	case code >= 1030 && code < 1100:
+		return "22023" // invalid_parameter_value
+	case code >= 3000 && code < 4000:
+		return "42601" // syntax_error
+	case code == errors.NO_SUCH_PREPARED:
+		return "26000" // invalid_sql_statement_name
+	case code >= 4000 && code < 5000:
+		return "42000" // syntax_error_or_access_rule_violation
+	case code >= errors.DS_AUTH_ERROR && code < errors.DS_AUTH_ERROR+1000:
+		return "28000" // invalid_authorization_specification
+	}
+	return "XX000" // internal_error
+}
+
+// parameters
+
+func paramValue(param []byte, oid int32, format int16) (value.Value, errors.Error) {
+	if param == nil {
+		return value.NULL_VALUE, nil
+	}
+
+	if format == _FORMAT_BINARY {
+		switch {
+		case oid == _OID_BOOL && len(param) == 1:
+			return value.NewValue(param[0] != 0), nil
+		case oid == _OID_INT2 && len(param) == 2:
+			return value.NewValue(int64(int16(binary.BigEndian.Uint16(param)))), nil
+		case oid == _OID_INT4 && len(param) == 4:
+			return value.NewValue(int64(int32(binary.BigEndian.Uint32(param)))), nil
+		case oid == _OID_INT8 && len(param) == 8:
+			return value.NewValue(int64(binary.BigEndian.Uint64(param))), nil
+		case oid == _OID_FLOAT4 && len(param) == 4:
+			return value.NewValue(float64(math.Float32frombits(binary.BigEndian.Uint32(param)))), nil
+		case oid == _OID_FLOAT8 && len(param) == 8:
+			return value.NewValue(math.Float64frombits(binary.BigEndian.Uint64(param))), nil
+		case oid == _OID_TEXT || oid == _OID_VARCHAR:
+			return value.NewValue(string(param)), nil
+		}
+		return nil, errors.NewServiceErrorNotImplemented("parameter format", "binary")
+	}
+
+	text := string(param)
+	switch oid {
+	case _OID_BOOL:
+		switch strings.ToLower(text) {
+		case "t", "true", "y", "yes", "on", "1":
+			return value.TRUE_VALUE, nil
+		case "f", "false", "n", "no", "off", "0":
+			return value.FALSE_VALUE, nil
+		}
+	case _OID_INT2, _OID_INT4, _OID_INT8, _OID_OID:
+		i, err := strconv.ParseInt(text, 10, 64)
+		if err == nil {
+			return value.NewValue(i), nil
+		}
+	case _OID_FLOAT4, _OID_FLOAT8, _OID_NUMERIC:
+		i, err := strconv.ParseInt(text, 10, 64)
+		if err == nil {
+			return value.NewValue(i), nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err == nil {
+			return value.NewValue(f), nil
+		}
+	case _OID_JSON, _OID_JSONB:
+		val := value.NewValue(param)
+		if val.Type() != value.BINARY {
+			return val, nil
+		}
+	default:
+		return value.NewValue(text), nil
+	}
+	return nil, errors.NewServiceErrorBadValue(nil, "parameter "+text)
+}
+
+// statements may or may not come with a terminating semicolon
+func trimStatement(text string) string {
+	return strings.TrimRight(strings.TrimSpace(text), "; \t\r\n")
+}
@@ -0,0 +1,152 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package pgwire accepts connections from clients speaking the PostgreSQL
+frontend/backend protocol (version 3.0), so that BI tools and drivers that
+only know that protocol can run N1QL statements.
+
+Both the simple and the extended query protocols are supported.
+Statements parsed through the extended protocol are planned for the
+connection alone, outside of the prepared statement cache, and bind
+parameters are passed as positional arguments.
+Passwords are checked against the datastore before the connection is
+accepted.
+Results are returned as text columns, derived from the statement signature.
+*/
+package pgwire
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/couchbase/cbauth"
+	"github.com/couchbase/query/accounting"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/prepareds"
+	"github.com/couchbase/query/server"
+)
+
+type PgEndpoint struct {
+	sync.Mutex
+	server    *server.Server
+	addr      string
+	tlsConfig *tls.Config
+	listener  net.Listener
+	conns     map[uint32]*pgConn
+	lastPid   uint32
+}
+
+func NewPgEndpoint(srv *server.Server, addr, certFile, keyFile string) (*PgEndpoint, error) {
+	rv := &PgEndpoint{
+		server: srv,
+		addr:   addr,
+		conns:  make(map[uint32]*pgConn),
+	}
+
+	// clients may request TLS, if we have a certificate
+	if certFile != "" && keyFile != "" {
+		tlsCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		rv.tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{tlsCert},
+			ClientAuth:   tls.NoClientCert,
+			MinVersion:   cbauth.MinTLSVersion(),
+			CipherSuites: cbauth.CipherSuites(),
+		}
+	}
+	return rv, nil
+}
+
+func (this *PgEndpoint) Listen() error {
+	ln, err := net.Listen("tcp", this.addr)
+	if err == nil {
+		this.listener = ln
+		go this.serve(ln)
+		logging.Infop("PgEndpoint: Listen", logging.Pair{"Address", ln.Addr()})
+	}
+	return err
+}
+
+func (this *PgEndpoint) Addr() net.Addr {
+	if this.listener == nil {
+		return nil
+	}
+	return this.listener.Addr()
+}
+
+func (this *PgEndpoint) Close() error {
+	var err error
+	if this.listener != nil {
+		err = this.listener.Close()
+		logging.Infop("PgEndpoint: close listener ", logging.Pair{"Address", this.listener.Addr()}, logging.Pair{"err", err})
+	}
+	return err
+}
+
+func (this *PgEndpoint) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go newPgConn(this, conn).serve()
+	}
+}
+
+// connections are registered so that they can be found by cancel requests,
+// which come in on a connection of their own
+
+func (this *PgEndpoint) register(conn *pgConn) uint32 {
+	this.Lock()
+	defer this.Unlock()
+	for {
+		this.lastPid++
+		if _, ok := this.conns[this.lastPid]; !ok && this.lastPid != 0 {
+			break
+		}
+	}
+	this.conns[this.lastPid] = conn
+	return this.lastPid
+}
+
+func (this *PgEndpoint) unregister(pid uint32) {
+	this.Lock()
+	defer this.Unlock()
+	delete(this.conns, pid)
+}
+
+func (this *PgEndpoint) cancel(pid, key uint32) {
+	this.Lock()
+	conn, ok := this.conns[pid]
+	this.Unlock()
+	if ok && conn.key == key {
+		conn.cancel()
+	}
+}
+
+func (this *PgEndpoint) doStats(request *pgRequest) {
+	service_time := time.Since(request.ServiceTime())
+	request_time := time.Since(request.RequestTime())
+	acctstore := this.server.AccountingStore()
+	prepared := request.Prepared() != nil
+
+	prepareds.RecordPreparedMetrics(request.Prepared(), request_time, service_time)
+	accounting.RecordMetrics(acctstore, request_time, service_time, request.resultCount,
+		request.resultSize, request.errorCount, request.warningCount, request.Type(),
+		prepared, (request.State() != server.COMPLETED),
+		string(request.ScanConsistency()))
+
+	request.CompleteRequest(request_time, service_time, request.resultCount,
+		request.resultSize, request.errorCount, nil, this.server)
+}
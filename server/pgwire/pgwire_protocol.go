@@ -0,0 +1,218 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package pgwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/couchbase/query/errors"
+)
+
+// protocol constants
+
+const (
+	_PROTOCOL_VERSION = 196608   // 3.0
+	_SSL_REQUEST      = 80877103 // 1234.5679
+	_CANCEL_REQUEST   = 80877102 // 1234.5678
+
+	_MAX_MESSAGE = 1 << 30
+)
+
+// frontend messages
+const (
+	_MSG_BIND      = 'B'
+	_MSG_CLOSE     = 'C'
+	_MSG_DESCRIBE  = 'D'
+	_MSG_EXECUTE   = 'E'
+	_MSG_FLUSH     = 'H'
+	_MSG_PARSE     = 'P'
+	_MSG_PASSWORD  = 'p'
+	_MSG_QUERY     = 'Q'
+	_MSG_SYNC      = 'S'
+	_MSG_TERMINATE = 'X'
+)
+
+// backend messages
+const (
+	_MSG_AUTHENTICATION   = 'R'
+	_MSG_BACKEND_KEY_DATA = 'K'
+	_MSG_BIND_COMPLETE    = '2'
+	_MSG_CLOSE_COMPLETE   = '3'
+	_MSG_COMMAND_COMPLETE = 'C'
+	_MSG_DATA_ROW         = 'D'
+	_MSG_EMPTY_QUERY      = 'I'
+	_MSG_ERROR_RESPONSE   = 'E'
+	_MSG_NO_DATA          = 'n'
+	_MSG_NOTICE_RESPONSE  = 'N'
+	_MSG_PARAMETER_DESC   = 't'
+	_MSG_PARAMETER_STATUS = 'S'
+	_MSG_PARSE_COMPLETE   = '1'
+	_MSG_READY_FOR_QUERY  = 'Z'
+	_MSG_ROW_DESCRIPTION  = 'T'
+)
+
+const (
+	_AUTH_OK        = 0
+	_AUTH_CLEARTEXT = 3
+)
+
+// type oids
+const (
+	_OID_UNKNOWN = 0
+	_OID_BOOL    = 16
+	_OID_BYTEA   = 17
+	_OID_INT8    = 20
+	_OID_INT2    = 21
+	_OID_INT4    = 23
+	_OID_TEXT    = 25
+	_OID_OID     = 26
+	_OID_JSON    = 114
+	_OID_FLOAT4  = 700
+	_OID_FLOAT8  = 701
+	_OID_VARCHAR = 1043
+	_OID_NUMERIC = 1700
+	_OID_JSONB   = 3802
+)
+
+const (
+	_FORMAT_TEXT   = 0
+	_FORMAT_BINARY = 1
+)
+
+// incoming message
+
+type inMessage struct {
+	buf []byte
+	err errors.Error
+}
+
+func (this *inMessage) fail() {
+	if this.err == nil {
+		this.err = errors.NewServiceErrorProtocol("message too short")
+	}
+	this.buf = nil
+}
+
+func (this *inMessage) byte() byte {
+	if len(this.buf) < 1 {
+		this.fail()
+		return 0
+	}
+	rv := this.buf[0]
+	this.buf = this.buf[1:]
+	return rv
+}
+
+func (this *inMessage) int16() int16 {
+	if len(this.buf) < 2 {
+		this.fail()
+		return 0
+	}
+	rv := int16(binary.BigEndian.Uint16(this.buf))
+	this.buf = this.buf[2:]
+	return rv
+}
+
+func (this *inMessage) int32() int32 {
+	if len(this.buf) < 4 {
+		this.fail()
+		return 0
+	}
+	rv := int32(binary.BigEndian.Uint32(this.buf))
+	this.buf = this.buf[4:]
+	return rv
+}
+
+func (this *inMessage) string() string {
+	i := bytes.IndexByte(this.buf, 0)
+	if i < 0 {
+		this.fail()
+		return ""
+	}
+	rv := string(this.buf[:i])
+	this.buf = this.buf[i+1:]
+	return rv
+}
+
+func (this *inMessage) bytes(n int) []byte {
+	if n < 0 || len(this.buf) < n {
+		this.fail()
+		return nil
+	}
+	rv := this.buf[:n]
+	this.buf = this.buf[n:]
+	return rv
+}
+
+func readMessage(r io.Reader, typed bool) (byte, *inMessage, error) {
+	var typ byte
+	var header [5]byte
+
+	h := header[1:]
+	if typed {
+		h = header[:]
+	}
+	_, err := io.ReadFull(r, h)
+	if err != nil {
+		return 0, nil, err
+	}
+	if typed {
+		typ = header[0]
+	}
+	l := int(binary.BigEndian.Uint32(header[1:]))
+	if l < 4 || l > _MAX_MESSAGE {
+		return 0, nil, fmt.Errorf("invalid message length %v", l)
+	}
+	buf := make([]byte, l-4)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, &inMessage{buf: buf}, nil
+}
+
+// outgoing message
+
+type outMessage struct {
+	buf []byte
+}
+
+func (this *outMessage) start(typ byte) {
+	this.buf = append(this.buf[:0], typ, 0, 0, 0, 0)
+}
+
+func (this *outMessage) byte(b byte) {
+	this.buf = append(this.buf, b)
+}
+
+func (this *outMessage) int16(i int16) {
+	this.buf = append(this.buf, byte(i>>8), byte(i))
+}
+
+func (this *outMessage) int32(i int32) {
+	this.buf = append(this.buf, byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+}
+
+func (this *outMessage) string(s string) {
+	this.buf = append(this.buf, s...)
+	this.buf = append(this.buf, 0)
+}
+
+func (this *outMessage) bytes(b []byte) {
+	this.buf = append(this.buf, b...)
+}
+
+func (this *outMessage) finish() []byte {
+	binary.BigEndian.PutUint32(this.buf[1:], uint32(len(this.buf)-1))
+	return this.buf
+}
@@ -0,0 +1,307 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package pgwire
+
+import (
+	http_base "net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/parser/n1ql"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/server"
+	"github.com/couchbase/query/server/http"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+// pgRequest implements server.Request for statements coming in on a
+// PostgreSQL protocol connection.
+// Results are written straight to the connection, which is waiting for the
+// request to close before carrying on.
+
+type pgRequest struct {
+	server.BaseRequest
+	conn         *pgConn
+	columns      []*column // nil: work them out from the signature
+	describe     bool      // send a row description ahead of the rows
+	resultCount  int
+	resultSize   int
+	errorCount   int
+	warningCount int
+}
+
+func newPgRequest(conn *pgConn, statement string, prepared *plan.Prepared,
+	args value.Values) *pgRequest {
+	rv := &pgRequest{
+		conn: conn,
+	}
+	server.NewBaseRequest(&rv.BaseRequest, statement, prepared, nil, args, conn.namespace,
+		0, 0, 0, 0, value.NONE, value.FALSE, value.TRUE, value.FALSE, &scanConfigImpl{}, "",
+		conn.creds, conn.conn.RemoteAddr().String(), conn.userAgent)
+	return rv
+}
+
+func (this *pgRequest) OriginalHttpRequest() *http_base.Request {
+	return nil
+}
+
+func (this *pgRequest) Output() execution.Output {
+	return this
+}
+
+func (this *pgRequest) Fail(err errors.Error) {
+	defer this.Stop(server.FATAL)
+
+	this.Error(err)
+}
+
+func (this *pgRequest) Failed(srvr *server.Server) {
+	this.stopAndClose(server.FATAL)
+}
+
+func (this *pgRequest) Expire(state server.State, timeout time.Duration) {
+	this.Error(errors.NewTimeoutError(timeout))
+	this.Stop(state)
+}
+
+func (this *pgRequest) stopAndClose(state server.State) {
+	this.Stop(state)
+	this.Close()
+}
+
+func (this *pgRequest) Execute(srvr *server.Server, signature value.Value, stopNotify execution.Operator) {
+	this.NotifyStop(stopNotify)
+
+	if this.columns == nil {
+		text := this.Statement()
+		if prepared := this.Prepared(); prepared != nil {
+			text = prepared.Text()
+		}
+		this.columns = signatureColumns(signature, text)
+	}
+	if this.describe {
+		this.conn.sendRowDescription(this.columns)
+	}
+
+	stopped := this.writeResults()
+	if stopped {
+		this.Close()
+	} else {
+		this.stopAndClose(server.COMPLETED)
+	}
+}
+
+// returns true if the request has already been stopped
+// (eg through timeout or cancel)
+func (this *pgRequest) writeResults() bool {
+	var item value.Value
+
+	ok := true
+	for ok {
+		select {
+		case <-this.StopExecute():
+			this.SetState(server.STOPPED)
+			return true
+		default:
+		}
+
+		select {
+		case item, ok = <-this.Results():
+			if this.Halted() {
+				return true
+			}
+			if ok {
+				this.resultSize += this.conn.sendDataRow(this.columns, item)
+				this.resultCount++
+			}
+		case <-this.StopExecute():
+			this.SetState(server.STOPPED)
+			return true
+		}
+	}
+
+	this.SetState(server.COMPLETED)
+	return false
+}
+
+// the tag sent with CommandComplete
+func (this *pgRequest) commandTag() string {
+	mutations := this.MutationCount()
+	switch this.Type() {
+	case "SELECT":
+		return "SELECT " + strconv.Itoa(this.resultCount)
+	case "INSERT", "UPSERT":
+		return "INSERT 0 " + strconv.FormatUint(mutations, 10)
+	case "UPDATE", "DELETE", "MERGE":
+		return this.Type() + " " + strconv.FormatUint(mutations, 10)
+	case "":
+		if this.IsPrepare() {
+			return "PREPARE"
+		}
+		return "OK"
+	}
+	return strings.Replace(this.Type(), "_", " ", -1)
+}
+
+type scanConfigImpl struct {
+}
+
+func (this *scanConfigImpl) ScanConsistency() datastore.ScanConsistency {
+	return datastore.UNBOUNDED
+}
+
+func (this *scanConfigImpl) ScanWait() time.Duration {
+	return 0
+}
+
+func (this *scanConfigImpl) ScanVectorSource() timestamp.ScanVectorSource {
+	return &http.ZeroScanVectorSource{}
+}
+
+// result columns
+
+type column struct {
+	name  string
+	oid   int32
+	field bool // the column is a field of the result, rather than the result itself
+}
+
+// Columns are derived from the signature.
+// Projections of named terms yield a column per term, in the order in
+// which they appear in the statement.
+// Anything else (SELECT *, RAW projections, statements without a signature
+// of their own) yields a single column holding the whole result.
+
+func signatureColumns(signature value.Value, text string) []*column {
+	if signature == nil || signature.Type() == value.MISSING || signature.Type() == value.NULL {
+		return nil
+	}
+
+	fields, ok := signature.Actual().(map[string]interface{})
+	if !ok {
+		t, _ := signature.Actual().(string)
+		return []*column{&column{name: "?column?", oid: typeOid(t)}}
+	}
+	if _, ok := fields["*"]; ok || len(fields) == 0 {
+		return []*column{&column{name: "?column?", oid: _OID_JSON}}
+	}
+
+	names := termNames(text)
+	done := make(map[string]bool, len(fields))
+	columns := make([]*column, 0, len(fields))
+	for _, name := range names {
+		t, ok := fields[name]
+		if ok && !done[name] {
+			s, _ := t.(string)
+			columns = append(columns, &column{name: name, oid: typeOid(s), field: true})
+			done[name] = true
+		}
+	}
+
+	// anything we could not find in the statement goes in name order
+	if len(columns) < len(fields) {
+		rest := make([]string, 0, len(fields)-len(columns))
+		for name := range fields {
+			if !done[name] {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		for _, name := range rest {
+			s, _ := fields[name].(string)
+			columns = append(columns, &column{name: name, oid: typeOid(s), field: true})
+		}
+	}
+	return columns
+}
+
+// the names of the result terms, in statement order
+func termNames(text string) []string {
+	var terms algebra.ResultTerms
+
+	stmt, err := n1ql.ParseStatement(text)
+	if err != nil {
+		return nil
+	}
+	if prepare, ok := stmt.(*algebra.Prepare); ok {
+		stmt = prepare.Statement()
+	}
+	switch stmt := stmt.(type) {
+	case *algebra.Select:
+		terms = stmt.Subresult().ResultTerms()
+	case *algebra.Insert:
+		if stmt.Returning() != nil {
+			terms = stmt.Returning().Terms()
+		}
+	case *algebra.Upsert:
+		if stmt.Returning() != nil {
+			terms = stmt.Returning().Terms()
+		}
+	case *algebra.Update:
+		if stmt.Returning() != nil {
+			terms = stmt.Returning().Terms()
+		}
+	case *algebra.Delete:
+		if stmt.Returning() != nil {
+			terms = stmt.Returning().Terms()
+		}
+	case *algebra.Merge:
+		if stmt.Returning() != nil {
+			terms = stmt.Returning().Terms()
+		}
+	}
+	names := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !term.Star() {
+			names = append(names, term.Alias())
+		}
+	}
+	return names
+}
+
+func typeOid(t string) int32 {
+	switch t {
+	case value.BOOLEAN.String():
+		return _OID_BOOL
+	case value.NUMBER.String():
+		return _OID_NUMERIC
+	case value.STRING.String():
+		return _OID_TEXT
+	}
+	return _OID_JSON
+}
+
+// the text representation of a value
+// returns nil for NULL and MISSING
+func textValue(val value.Value) []byte {
+	switch val.Type() {
+	case value.MISSING, value.NULL:
+		return nil
+	case value.STRING:
+		return []byte(val.Actual().(string))
+	case value.BOOLEAN:
+		if val.Truth() {
+			return []byte{'t'}
+		}
+		return []byte{'f'}
+	}
+	bytes, err := val.MarshalJSON()
+	if err != nil {
+		return nil
+	}
+	return bytes
+}
@@ -0,0 +1,280 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package pgwire
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+
+	acct_resolver "github.com/couchbase/query/accounting/resolver"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/datastore/resolver"
+	"github.com/couchbase/query/logging"
+	log_resolver "github.com/couchbase/query/logging/resolver"
+	"github.com/couchbase/query/prepareds"
+	"github.com/couchbase/query/server"
+)
+
+var test_endpoint *PgEndpoint
+
+func init() {
+	logger, _ := log_resolver.NewLogger("golog")
+	if logger == nil {
+		fmt.Printf("Unable to create logger")
+		os.Exit(1)
+	}
+	logging.SetLogger(logger)
+
+	store, err := resolver.NewDatastore("mock:")
+	if err != nil {
+		logging.Errorp(err.Error())
+		os.Exit(1)
+	}
+	datastore.SetDatastore(store)
+	acctstore, err := acct_resolver.NewAcctstore("stub:")
+	if err != nil {
+		logging.Errorp(err.Error())
+		os.Exit(1)
+	}
+	channel := make(server.RequestChannel, 10)
+	plusChannel := make(server.RequestChannel, 10)
+	srv, err := server.NewServer(store, nil, nil, acctstore, "default",
+		false, channel, plusChannel, 4, 4, 0, 0, false, false, false, true, server.ProfOff, false)
+	if err != nil {
+		logging.Errorp(err.Error())
+		os.Exit(1)
+	}
+	srv.SetKeepAlive(1 << 10)
+	server.RequestsInit(0, 8)
+	prepareds.PreparedsInit(1024)
+	prepareds.PreparedsReprepareInit(store, nil, "default")
+	go srv.Serve()
+
+	var er error
+	test_endpoint, er = NewPgEndpoint(srv, "127.0.0.1:0", "", "")
+	if er == nil {
+		er = test_endpoint.Listen()
+	}
+	if er != nil {
+		logging.Errorp(er.Error())
+		os.Exit(1)
+	}
+}
+
+// a bare bones client
+
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	out    outMessage
+}
+
+type testMessage struct {
+	typ    byte
+	fields []string
+}
+
+func newTestClient(t *testing.T) *testClient {
+	conn, err := net.Dial("tcp", test_endpoint.Addr().String())
+	if err != nil {
+		t.Fatalf("cannot connect: %v", err)
+	}
+	rv := &testClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+
+	// startup messages have no type
+	rv.out.start(0)
+	rv.out.int32(_PROTOCOL_VERSION)
+	rv.out.string("user")
+	rv.out.string("tester")
+	rv.out.byte(0)
+	buf := rv.out.finish()
+	buf[4] = byte(len(buf) - 1)
+	rv.conn.Write(buf[1:])
+
+	rv.expect(_MSG_AUTHENTICATION)
+	rv.out.start(_MSG_PASSWORD)
+	rv.out.string("secret")
+	rv.send()
+	for {
+		msg := rv.receive()
+		if msg.typ == _MSG_READY_FOR_QUERY {
+			break
+		}
+		if msg.typ == _MSG_ERROR_RESPONSE {
+			t.Fatalf("startup failed: %v", msg.fields)
+		}
+	}
+	return rv
+}
+
+func (this *testClient) send() {
+	this.conn.Write(this.out.finish())
+}
+
+// decode the messages the tests care about
+func (this *testClient) receive() *testMessage {
+	typ, msg, err := readMessage(this.reader, true)
+	if err != nil {
+		this.t.Fatalf("cannot read message: %v", err)
+	}
+	rv := &testMessage{typ: typ}
+	switch typ {
+	case _MSG_ROW_DESCRIPTION:
+		n := int(msg.int16())
+		for i := 0; i < n; i++ {
+			rv.fields = append(rv.fields, msg.string())
+			msg.int32()
+			msg.int16()
+			rv.fields = append(rv.fields, fmt.Sprint(msg.int32()))
+			msg.int16()
+			msg.int32()
+			msg.int16()
+		}
+	case _MSG_DATA_ROW:
+		n := int(msg.int16())
+		for i := 0; i < n; i++ {
+			l := msg.int32()
+			if l < 0 {
+				rv.fields = append(rv.fields, "NULL")
+			} else {
+				rv.fields = append(rv.fields, string(msg.bytes(int(l))))
+			}
+		}
+	case _MSG_ERROR_RESPONSE, _MSG_NOTICE_RESPONSE:
+		for {
+			code := msg.byte()
+			if code == 0 || msg.err != nil {
+				break
+			}
+			field := msg.string()
+			if code == 'C' {
+				rv.fields = append(rv.fields, field)
+			}
+		}
+	case _MSG_COMMAND_COMPLETE:
+		rv.fields = append(rv.fields, msg.string())
+	}
+	return rv
+}
+
+func (this *testClient) expect(typ byte, fields ...string) {
+	msg := this.receive()
+	if msg.typ != typ {
+		this.t.Fatalf("expected message %c, got %c %v", typ, msg.typ, msg.fields)
+	}
+	if len(fields) > 0 && !reflect.DeepEqual(fields, msg.fields) {
+		this.t.Errorf("expected %c %v, got %v", typ, fields, msg.fields)
+	}
+}
+
+func TestSimpleQuery(t *testing.T) {
+	client := newTestClient(t)
+	defer client.conn.Close()
+
+	client.out.start(_MSG_QUERY)
+	client.out.string("SELECT 1 AS one, \"a\" AS two, true AS three;")
+	client.send()
+	client.expect(_MSG_ROW_DESCRIPTION, "one", "1700", "two", "25", "three", "16")
+	client.expect(_MSG_DATA_ROW, "1", "a", "t")
+	client.expect(_MSG_COMMAND_COMPLETE, "SELECT 1")
+	client.expect(_MSG_READY_FOR_QUERY)
+
+	client.out.start(_MSG_QUERY)
+	client.out.string("SELECT FROM WHERE")
+	client.send()
+	client.expect(_MSG_ERROR_RESPONSE, "42601")
+	client.expect(_MSG_READY_FOR_QUERY)
+
+	client.out.start(_MSG_QUERY)
+	client.out.string("  ")
+	client.send()
+	client.expect(_MSG_EMPTY_QUERY)
+	client.expect(_MSG_READY_FOR_QUERY)
+}
+
+func TestExtendedQuery(t *testing.T) {
+	client := newTestClient(t)
+	defer client.conn.Close()
+
+	prepared := prepareds.CountPrepareds()
+	client.out.start(_MSG_PARSE)
+	client.out.string("s1")
+	client.out.string("SELECT $1 + 1 AS n, $2 AS s")
+	client.out.int16(1)
+	client.out.int32(_OID_INT4)
+	client.send()
+	client.out.start(_MSG_BIND)
+	client.out.string("")
+	client.out.string("s1")
+	client.out.int16(0)
+	client.out.int16(2)
+	client.out.int32(2)
+	client.out.bytes([]byte("41"))
+	client.out.int32(5)
+	client.out.bytes([]byte("hello"))
+	client.out.int16(0)
+	client.send()
+	client.out.start(_MSG_DESCRIBE)
+	client.out.byte('P')
+	client.out.string("")
+	client.send()
+	client.out.start(_MSG_EXECUTE)
+	client.out.string("")
+	client.out.int32(0)
+	client.send()
+	client.out.start(_MSG_SYNC)
+	client.send()
+
+	client.expect(_MSG_PARSE_COMPLETE)
+	client.expect(_MSG_BIND_COMPLETE)
+	client.expect(_MSG_ROW_DESCRIPTION, "n", "1700", "s", "114")
+	client.expect(_MSG_DATA_ROW, "42", "hello")
+	client.expect(_MSG_COMMAND_COMPLETE, "SELECT 1")
+	client.expect(_MSG_READY_FOR_QUERY)
+
+	if prepareds.CountPrepareds() != prepared {
+		t.Errorf("expected statement to stay out of the prepared statement cache")
+	}
+
+	// errors skip everything up to Sync
+	client.out.start(_MSG_BIND)
+	client.out.string("")
+	client.out.string("nosuch")
+	client.out.int16(0)
+	client.out.int16(0)
+	client.out.int16(0)
+	client.send()
+	client.out.start(_MSG_EXECUTE)
+	client.out.string("")
+	client.out.int32(0)
+	client.send()
+	client.out.start(_MSG_SYNC)
+	client.send()
+	client.expect(_MSG_ERROR_RESPONSE, "26000")
+	client.expect(_MSG_READY_FOR_QUERY)
+
+	client.out.start(_MSG_CLOSE)
+	client.out.byte('S')
+	client.out.string("s1")
+	client.send()
+	client.out.start(_MSG_SYNC)
+	client.send()
+	client.expect(_MSG_CLOSE_COMPLETE)
+	client.expect(_MSG_READY_FOR_QUERY)
+
+	if prepareds.CountPrepareds() != prepared {
+		t.Errorf("expected the prepared statement cache to be unchanged")
+	}
+}
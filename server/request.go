@@ -199,6 +199,7 @@ type BaseRequest struct {
 	state           State
 	results         value.ValueChannel
 	errors          errors.ErrorChannel
+	errorCodes      []int32 // codes of the errors returned to the client
 	warnings        errors.ErrorChannel
 	closeNotify     chan bool          // implement http.CloseNotifier
 	stopResult      chan bool          // stop consuming results
@@ -490,6 +491,19 @@ func (this *BaseRequest) Error(err errors.Error) {
 	}
 }
 
+// record the code of an error that has been returned to the client
+func (this *BaseRequest) AddErrorCode(code int32) {
+	this.Lock()
+	this.errorCodes = append(this.errorCodes, code)
+	this.Unlock()
+}
+
+func (this *BaseRequest) ErrorCodes() []int32 {
+	this.RLock()
+	defer this.RUnlock()
+	return this.errorCodes
+}
+
 func (this *BaseRequest) Warning(wrn errors.Error) {
 	select {
 	case this.warnings <- wrn:
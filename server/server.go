@@ -500,7 +500,7 @@ func (this *Server) serviceRequest(request Request) {
 		namespace = this.namespace
 	}
 
-	prepared, err := this.getPrepared(request, namespace)
+	prepared, positionalArgs, err := this.getPrepared(request, namespace)
 	if err != nil {
 		request.Fail(err)
 	}
@@ -523,7 +523,7 @@ func (this *Server) serviceRequest(request Request) {
 
 	context := execution.NewContext(request.Id().String(), this.datastore, this.systemstore, namespace,
 		this.readonly, maxParallelism, request.ScanCap(), request.PipelineCap(), request.PipelineBatch(),
-		request.NamedArgs(), request.PositionalArgs(), request.Credentials(), request.ScanConsistency(),
+		request.NamedArgs(), positionalArgs, request.Credentials(), request.ScanConsistency(),
 		request.ScanVectorSource(), request.Output(), request.OriginalHttpRequest(),
 		prepared, request.IndexApiVersion(), request.FeatureControls())
 
@@ -569,14 +569,24 @@ func (this *Server) serviceRequest(request Request) {
 	request.Output().AddPhaseTime(execution.RUN, time.Since(run))
 }
 
-func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepared, errors.Error) {
+// returns the plan for the request, and the positional arguments to execute it with
+func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepared, value.Values, errors.Error) {
+	var stmt algebra.Statement
+	var normalized *n1ql.NormalizedText
+	var err error
+
+	positionalArgs := request.PositionalArgs()
 	prepared := request.Prepared()
 	if prepared == nil {
 		parse := time.Now()
-		stmt, err := n1ql.ParseStatement(request.Statement())
+		if prepareds.AdhocsEnabled() {
+			stmt, normalized, err = n1ql.ParseNormalizedStatement(request.Statement())
+		} else {
+			stmt, err = n1ql.ParseStatement(request.Statement())
+		}
 		request.Output().AddPhaseTime(execution.PARSE, time.Since(parse))
 		if err != nil {
-			return nil, errors.NewParseSyntaxError(err, "")
+			return nil, nil, errors.NewParseSyntaxError(err, "")
 		}
 
 		isprepare := false
@@ -586,19 +596,38 @@ func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepar
 
 		prep := time.Now()
 		namedArgs := request.NamedArgs()
-		positionalArgs := request.PositionalArgs()
+
+		// only plan statements that are not in the ad-hoc plan cache
+		adhoc := prepareds.AdhocCacheable(stmt, normalized, namedArgs, positionalArgs)
+		if adhoc {
+			var args value.Values
+
+			prepared, args = prepareds.GetAdhoc(stmt, request.Statement(), normalized, namespace,
+				request.IndexApiVersion(), request.FeatureControls())
+			if prepared != nil {
+				request.Output().AddPhaseTime(execution.PLAN, time.Since(prep))
+				this.acctstore.MetricRegistry().Counter(accounting.ADHOC_HITS).Inc(1)
+				request.SetType(prepared.Type())
+				if logging.LogLevel() >= logging.DEBUG {
+					logExplain(prepared)
+				}
+				return prepared, args, nil
+			}
+			this.acctstore.MetricRegistry().Counter(accounting.ADHOC_MISSES).Inc(1)
+		}
 
 		// No args for a prepared statement - should we throw an error?
+		planArgs := positionalArgs
 		if isprepare {
 			namedArgs = nil
-			positionalArgs = nil
+			planArgs = nil
 		}
 
 		prepared, err = planner.BuildPrepared(stmt, this.datastore, this.systemstore, namespace, false,
-			namedArgs, positionalArgs, request.IndexApiVersion(), request.FeatureControls())
+			namedArgs, planArgs, request.IndexApiVersion(), request.FeatureControls())
 		request.Output().AddPhaseTime(execution.PLAN, time.Since(prep))
 		if err != nil {
-			return nil, errors.NewPlanError(err, "")
+			return nil, nil, errors.NewPlanError(err, "")
 		}
 
 		// EXECUTE doesn't get a plan. Get the plan from the cache.
@@ -615,7 +644,7 @@ func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepar
 					request.Output().AddPhaseTime(execution.REPREPARE, reprepTime)
 				}
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				request.SetPrepared(prepared)
 
@@ -642,6 +671,12 @@ func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepar
 			// text for the benefit of context.Recover(): we can
 			// output the text in case of crashes
 			prepared.SetText(request.Statement())
+
+			if adhoc {
+				prepared.SetType(stmt.Type())
+				prepareds.AddAdhoc(prepared, request.Statement(), normalized, namespace,
+					request.IndexApiVersion(), request.FeatureControls(), this.datastore, this.systemstore)
+			}
 		}
 	} else {
 
@@ -654,7 +689,7 @@ func (this *Server) getPrepared(request Request, namespace string) (*plan.Prepar
 		logExplain(prepared)
 	}
 
-	return prepared, nil
+	return prepared, positionalArgs, nil
 }
 
 func logExplain(prepared *plan.Prepared) {
@@ -81,10 +81,32 @@ var _SETTERS = map[string]Setter{
 		value, _ := o.(float64)
 		RequestsSetLimit(int(value))
 	},
+	paramSettings.CMPQUALIFIERS: func(s *Server, o interface{}) {
+		value, _ := o.(map[string]interface{})
+		err := RequestsSetQualifiers(value)
+		if err != nil {
+			logging.Infof("Could not set completed requests qualifiers: %v", err)
+		}
+	},
+	paramSettings.CMPLOGFILE: func(s *Server, o interface{}) {
+		value, _ := o.(string)
+		err := RequestsSetLogFile(value)
+		if err != nil {
+			logging.Infof("Could not set completed requests log file: %v", err)
+		}
+	},
 	paramSettings.PRPLIMIT: func(s *Server, o interface{}) {
 		value, _ := o.(float64)
 		prepareds.PreparedsSetLimit(int(value))
 	},
+	paramSettings.ADHOCPLANS: func(s *Server, o interface{}) {
+		value, _ := o.(bool)
+		prepareds.AdhocsSetEnabled(value)
+	},
+	paramSettings.ADHOCLIMIT: func(s *Server, o interface{}) {
+		value, _ := o.(float64)
+		prepareds.AdhocsSetLimit(int(value))
+	},
 	paramSettings.PRETTY: func(s *Server, o interface{}) {
 		value, _ := o.(bool)
 		s.SetPretty(value)
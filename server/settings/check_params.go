@@ -10,6 +10,8 @@
 package settings
 
 import (
+	"strings"
+
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/logging"
 )
@@ -29,7 +31,11 @@ const (
 	TIMEOUTSETTING  = "timeout"
 	CMPTHRESHOLD    = "completed-threshold"
 	CMPLIMIT        = "completed-limit"
+	CMPQUALIFIERS   = "completed"
+	CMPLOGFILE      = "completed-log-file"
 	PRPLIMIT        = "prepared-limit"
+	ADHOCPLANS      = "adhoc-plans"
+	ADHOCLIMIT      = "adhoc-limit"
 	PRETTY          = "pretty"
 	MAXINDEXAPI     = "max-index-api"
 	PROFILE         = "profile"
@@ -54,7 +60,11 @@ var CHECKERS = map[string]Checker{
 	TIMEOUTSETTING:  checkNumber,
 	CMPTHRESHOLD:    checkNumber,
 	CMPLIMIT:        checkNumber,
+	CMPQUALIFIERS:   checkCompleted,
+	CMPLOGFILE:      checkString,
 	PRPLIMIT:        checkPositiveInteger,
+	ADHOCPLANS:      checkBool,
+	ADHOCLIMIT:      checkPositiveInteger,
 	PRETTY:          checkBool,
 	MAXINDEXAPI:     checkNumber,
 	PROFILE:         checkProfileAdmin,
@@ -93,3 +103,29 @@ func checkLogLevel(val interface{}) (bool, errors.Error) {
 	_, ok := logging.ParseLevel(level)
 	return ok, nil
 }
+
+// the completed requests qualifiers are fully checked when they are
+// set: here we only check that names and logic make sense
+func checkCompleted(val interface{}) (bool, errors.Error) {
+	qualifiers, ok := val.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	for name, condition := range qualifiers {
+		if name == "logic" {
+			logic, _ := condition.(string)
+			logic = strings.ToLower(logic)
+			if logic != "and" && logic != "or" {
+				return false, errors.NewCompletedQualifierInvalidArgument(name, condition)
+			}
+			continue
+		}
+		name = strings.TrimLeft(name, "+-")
+		switch name {
+		case "threshold", "error", "user", "client", "statement", "size", "sample":
+		default:
+			return false, errors.NewCompletedQualifierUnknown(name)
+		}
+	}
+	return true, nil
+}
@@ -129,7 +129,8 @@ func (this *MockQuery) writeResult(item value.Value) bool {
 
 	this.resultCount++
 
-	var resultLine map[string]interface{}
+	// RAW projections return values that are not objects
+	var resultLine interface{}
 	json.Unmarshal(bytes, &resultLine)
 
 	this.response.results = append(this.response.results, resultLine)
@@ -167,6 +168,24 @@ func (this *MockServer) doStats(request *MockQuery) {
 }
 
 func Run(mockServer *MockServer, p bool, q string) ([]interface{}, []errors.Error, errors.Error) {
+	results, warnings, err, _ := run(mockServer, p, q)
+	return results, warnings, err
+}
+
+// RunErrors also returns the first error raised while executing the statement,
+// which does not fail the request
+func RunErrors(mockServer *MockServer, p bool, q string) ([]interface{}, []errors.Error, errors.Error) {
+	results, warnings, err, query := run(mockServer, p, q)
+	if err == nil && query != nil {
+		select {
+		case err = <-query.Errors():
+		default:
+		}
+	}
+	return results, warnings, err
+}
+
+func run(mockServer *MockServer, p bool, q string) ([]interface{}, []errors.Error, errors.Error, *MockQuery) {
 	var metrics value.Tristate
 	scanConfiguration := &scanConfigImpl{}
 
@@ -192,12 +211,17 @@ func Run(mockServer *MockServer, p bool, q string) ([]interface{}, []errors.Erro
 		<-query.CloseNotify()
 	default:
 		// Timeout.
-		return nil, nil, errors.NewError(nil, "Query timed out")
+		return nil, nil, errors.NewError(nil, "Query timed out"), nil
 	}
 
 	// wait till all the results are ready
 	<-mr.done
-	return mr.results, mr.warnings, mr.err
+
+	// warnings are not part of the results
+	for warnings := query.Warnings(); len(warnings) > 0; {
+		mr.warnings = append(mr.warnings, <-warnings)
+	}
+	return mr.results, mr.warnings, mr.err, query
 }
 
 func Start(site, pool string) *MockServer {
@@ -13,11 +13,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/metastore"
+	"github.com/couchbase/query/prepareds"
+	"github.com/couchbase/query/sequences"
+	"github.com/couchbase/query/server"
 
 	// For now we can't use go_json for unmarshalling
 	// as it returns a map in a different order than
@@ -31,6 +39,37 @@ func start() *MockServer {
 	return Start("dir:.", "json")
 }
 
+// Creates a keyspace of the default namespace holding docs, by key,
+// and returns the function that removes it.
+func newKeyspace(t *testing.T, name string, docs map[string]string) func() {
+	dir := filepath.Join("./json/default", name)
+	err := os.Mkdir(dir, 0755)
+	if err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+
+	for key, doc := range docs {
+		err = ioutil.WriteFile(filepath.Join(dir, key+".json"), []byte(doc), 0644)
+		if err != nil {
+			os.RemoveAll(dir)
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	return func() { os.RemoveAll(dir) }
+}
+
+// Returns a function that runs a statement, reporting any error, including
+// those raised during execution, and returns its results.
+func runner(t *testing.T, qc *MockServer) func(string) []interface{} {
+	return func(q string) []interface{} {
+		r, _, err := RunErrors(qc, true, q)
+		if err != nil {
+			t.Errorf("did not expect err %s for %s", err.Error(), q)
+		}
+		return r
+	}
+}
+
 func TestSyntaxErr(t *testing.T) {
 	qc := start()
 
@@ -206,6 +245,1253 @@ func TestSimpleSelect(t *testing.T) {
 	}
 }
 
+func TestExplainAnalyze(t *testing.T) {
+	qc := start()
+
+	r, _, err := Run(qc, true, "explain analyze select id from default:game where score > 5 order by id limit 2")
+	if err != nil {
+		t.Errorf("did not expect err %s", err.Error())
+	}
+	if len(r) != 1 {
+		t.Fatalf("expected 1 result, got %v", r)
+	}
+
+	explain := r[0].(map[string]interface{})
+	stats, _ := explain["#stats"].(map[string]interface{})
+	if stats["#resultCount"] != float64(2) {
+		t.Errorf("expected 2 results, got %v", stats["#resultCount"])
+	}
+	if _, ok := explain["results"]; ok {
+		t.Errorf("did not expect results without WITH RESULTS")
+	}
+
+	plan, _ := explain["plan"].(map[string]interface{})
+	if _, ok := plan["#stats"]; !ok {
+		t.Errorf("expected operator statistics in plan %v", plan)
+	}
+
+	found := make(map[string]bool)
+	operatorStats(plan, found)
+	for _, stat := range []string{"#itemsScanned", "#itemsFetched", "#itemsHeld", "usedMemory"} {
+		if !found[stat] {
+			t.Errorf("expected operator statistic %v in plan %v", stat, plan)
+		}
+	}
+
+	r, _, err = Run(qc, true, "explain analyze with results select id from default:game where score > 5 order by id limit 2")
+	if err != nil {
+		t.Errorf("did not expect err %s", err.Error())
+	}
+	if len(r) != 1 {
+		t.Fatalf("expected 1 result, got %v", r)
+	}
+
+	results, _ := r[0].(map[string]interface{})["results"].([]interface{})
+	if len(results) != 2 || results[0].(map[string]interface{})["id"] != "damien" {
+		t.Errorf("unexpected results %v", results)
+	}
+}
+
+// the names of the statistics of the operators of an analyzed plan
+func operatorStats(op interface{}, found map[string]bool) {
+	switch op := op.(type) {
+	case map[string]interface{}:
+		for name, v := range op {
+			if stats, ok := v.(map[string]interface{}); ok && name == "#stats" {
+				for stat := range stats {
+					found[stat] = true
+				}
+			} else {
+				operatorStats(v, found)
+			}
+		}
+	case []interface{}:
+		for _, v := range op {
+			operatorStats(v, found)
+		}
+	}
+}
+
+func TestOptimizerHints(t *testing.T) {
+	qc := start()
+
+	r, _, err := Run(qc, true, "explain select /*+ NO_INDEX(g) USE_HASH(h) USE_NL(x) ORDERED INDEX(g idx) FOO */ g.id "+
+		"from default:game g join default:game h on g.id = h.id")
+	if err != nil {
+		t.Errorf("did not expect err %s", err.Error())
+	}
+	if len(r) != 1 {
+		t.Fatalf("expected 1 result, got %v", r)
+	}
+
+	hints, _ := r[0].(map[string]interface{})["optimizer_hints"].(map[string]interface{})
+	followed, _ := hints["hints_followed"].([]interface{})
+	if len(followed) != 3 || followed[0] != "NO_INDEX(g)" || followed[1] != "USE_HASH(h)" || followed[2] != "ORDERED" {
+		t.Errorf("unexpected hints followed %v", hints["hints_followed"])
+	}
+	notFollowed, _ := hints["hints_not_followed"].([]interface{})
+	if len(notFollowed) != 1 || notFollowed[0].(map[string]interface{})["hint"] != "USE_NL(x)" {
+		t.Errorf("unexpected hints not followed %v", hints["hints_not_followed"])
+	}
+	invalid, _ := hints["invalid_hints"].([]interface{})
+	if len(invalid) != 2 {
+		t.Errorf("unexpected invalid hints %v", hints["invalid_hints"])
+	}
+
+	r, _, err = Run(qc, true, "explain select id from default:game")
+	if err != nil {
+		t.Errorf("did not expect err %s", err.Error())
+	}
+	if _, ok := r[0].(map[string]interface{})["optimizer_hints"]; ok {
+		t.Errorf("did not expect optimizer hints without hints")
+	}
+
+	// hints do not change the results, and are only recognized after the keyword
+	for _, q := range []string{
+		"select /*+ USE_HASH(h) */ g.id from default:game g join default:game h on g.id = h.id order by g.id",
+		"select /*+ INDEX(g #primary) */ g.id from default:game g where g.id > 'a' order by g.id",
+		"select /*+ FOO(*/ id from /*+ NO_INDEX(game) */ default:game order by id",
+	} {
+		r, _, err = Run(qc, true, q)
+		if err != nil {
+			t.Errorf("did not expect err %s for %s", err.Error(), q)
+		}
+		if len(r) != 5 || r[0].(map[string]interface{})["id"] != "damien" {
+			t.Errorf("unexpected results %v for %s", r, q)
+		}
+	}
+}
+
+func TestAdhocPlans(t *testing.T) {
+	qc := start()
+
+	prepareds.AdhocsInit(16, true)
+	defer prepareds.AdhocsInit(16, false)
+
+	// every statement must get the rows for its own literals
+	orders := []struct{ id, custId string }{
+		{"1200", "abc"}, {"1234", "bbb"}, {"1235", "ccc"}, {"1236", "ccc"},
+	}
+	for _, order := range orders {
+		r, _, err := Run(qc, true, "select custId from default:orders where id = \""+order.id+"\" and 1 = 1")
+		if err != nil {
+			t.Errorf("did not expect err %s", err.Error())
+		}
+		if len(r) != 1 {
+			t.Errorf("expected 1 result for order %v, got %v", order.id, len(r))
+		} else if custId := r[0].(map[string]interface{})["custId"]; custId != order.custId {
+			t.Errorf("expected customer %v for order %v, got %v", order.custId, order.id, custId)
+		}
+	}
+
+	r, _, err := Run(qc, true, "select name, hits, misses from system:prepareds where adhoc = true and statement like \"%orders%\"")
+	if err != nil {
+		t.Errorf("did not expect err %s", err.Error())
+	}
+	if len(r) != 1 {
+		t.Errorf("expected 1 ad-hoc plan, got %v", r)
+	} else {
+		hits := r[0].(map[string]interface{})["hits"]
+		if hits != float64(3) {
+			t.Errorf("expected 3 hits, got %v", hits)
+		}
+	}
+
+	// a number in place of a string does not share the plan
+	r, _, err = Run(qc, true, "select custId from default:orders where id = 1200 and 1 = 1")
+	if err != nil {
+		t.Errorf("did not expect err %s", err.Error())
+	}
+	if len(r) != 0 {
+		t.Errorf("expected no results for a numeric id, got %v", r)
+	}
+}
+
+func TestPersistedPrepareds(t *testing.T) {
+	qc := start()
+
+	dir, err := ioutil.TempDir("", "prepareds")
+	if err != nil {
+		t.Fatalf("TempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer prepareds.PreparedsPersistInit("none", nil, "")
+
+	perr := prepareds.PreparedsPersistInit("dir:"+dir, qc.dstore, "default")
+	if perr != nil {
+		t.Fatalf("did not expect err %s", perr.Error())
+	}
+
+	_, _, perr = Run(qc, true, "prepare persisted from select custId from default:orders where id = \"1200\"")
+	if perr != nil {
+		t.Errorf("did not expect err %s", perr.Error())
+	}
+	fileInfos, _ := ioutil.ReadDir(dir)
+	if len(fileInfos) != 1 {
+		t.Errorf("expected 1 persisted statement, got %v", len(fileInfos))
+	}
+
+	// simulate a restart
+	prepareds.PreparedsInit(1024)
+	prepareds.PreparedsLoad()
+
+	r, _, perr := Run(qc, true, "execute persisted")
+	if perr != nil {
+		t.Errorf("did not expect err %s", perr.Error())
+	}
+	if len(r) != 1 {
+		t.Errorf("expected 1 result, got %v", len(r))
+	}
+
+	perr = prepareds.DeletePrepared("persisted")
+	if perr != nil {
+		t.Errorf("did not expect err %s", perr.Error())
+	}
+	fileInfos, _ = ioutil.ReadDir(dir)
+	if len(fileInfos) != 0 {
+		t.Errorf("expected no persisted statements, got %v", len(fileInfos))
+	}
+}
+
+// setQualifiers replaces the completed request qualifiers, as returned
+// by server.RequestsQualifiers()
+func setQualifiers(t *testing.T, qualifiers map[string]interface{}) {
+	for name, condition := range server.RequestsQualifiers() {
+		if conds, ok := condition.([]interface{}); ok {
+			for _, c := range conds {
+				server.RequestsRemoveQualifier(name, c)
+			}
+		} else if name != "logic" {
+			server.RequestsRemoveQualifier(name, nil)
+		}
+	}
+
+	for name, condition := range qualifiers {
+		var err errors.Error
+		if conds, ok := condition.([]interface{}); ok {
+			for _, c := range conds {
+				err = server.RequestsAddQualifier(name, c)
+			}
+		} else if name == "logic" {
+			logic, _ := condition.(string)
+			err = server.RequestsSetLogic(logic)
+		} else {
+			err = server.RequestsAddQualifier(name, condition)
+		}
+		if err != nil {
+			t.Errorf("could not restore qualifier %s: %s", name, err.Error())
+		}
+	}
+}
+
+func TestCompletedQualifiers(t *testing.T) {
+	qc := start()
+
+	original := server.RequestsQualifiers()
+	defer setQualifiers(t, original)
+
+	completed := func() (orders, others int) {
+		server.RequestsForeach(func(id string, entry *server.RequestLogEntry) bool {
+			if strings.Contains(entry.Statement, "orders") {
+				orders++
+			} else {
+				others++
+			}
+			return true
+		}, nil)
+		return
+	}
+	runBoth := func() {
+		Run(qc, true, "select custId from default:orders where id = \"1200\"")
+		Run(qc, true, "select 1")
+	}
+
+	// statement pattern only
+	err := server.RequestsSetQualifiers(map[string]interface{}{
+		"threshold":  nil,
+		"+statement": "ord.rs",
+	})
+	if err != nil {
+		t.Fatalf("did not expect err %s", err.Error())
+	}
+	runBoth()
+	if orders, others := completed(); orders != 1 || others != 0 {
+		t.Errorf("expected 1 and 0 completed requests, got %v and %v", orders, others)
+	}
+
+	// and'ed with a sample rate that never qualifies
+	err = server.RequestsSetQualifiers(map[string]interface{}{
+		"sample": 0.0,
+		"logic":  "and",
+	})
+	if err != nil {
+		t.Fatalf("did not expect err %s", err.Error())
+	}
+	runBoth()
+	if orders, others := completed(); orders != 1 || others != 0 {
+		t.Errorf("expected 1 and 0 completed requests, got %v and %v", orders, others)
+	}
+
+	// or'ed, all requests qualify
+	err = server.RequestsSetQualifiers(map[string]interface{}{
+		"sample": 1.0,
+		"logic":  "or",
+	})
+	if err != nil {
+		t.Fatalf("did not expect err %s", err.Error())
+	}
+	runBoth()
+	if orders, others := completed(); orders != 2 || others != 1 {
+		t.Errorf("expected 2 and 1 completed requests, got %v and %v", orders, others)
+	}
+
+	qualifiers := server.RequestsQualifiers()
+	if !reflect.DeepEqual(qualifiers, map[string]interface{}{
+		"statement": []interface{}{"ord.rs"},
+		"sample":    1.0,
+		"logic":     "or",
+	}) {
+		t.Errorf("unexpected qualifiers %v", qualifiers)
+	}
+
+	err = server.RequestsSetQualifiers(map[string]interface{}{"+bogus": true})
+	if err == nil {
+		t.Errorf("expected unknown qualifier error")
+	}
+	err = server.RequestsSetQualifiers(map[string]interface{}{"+statement": "("})
+	if err == nil {
+		t.Errorf("expected invalid argument error")
+	}
+	err = server.RequestsSetQualifiers(map[string]interface{}{"sample": 2.0})
+	if err == nil {
+		t.Errorf("expected invalid argument error")
+	}
+
+	// invalid conditions leave the qualifiers as they were
+	if after := server.RequestsQualifiers(); !reflect.DeepEqual(after, qualifiers) {
+		t.Errorf("expected qualifiers %v, got %v", qualifiers, after)
+	}
+	runBoth()
+	if orders, others := completed(); orders != 3 || others != 2 {
+		t.Errorf("expected 3 and 2 completed requests, got %v and %v", orders, others)
+	}
+
+	// the log is also written to file
+	file, ferr := ioutil.TempFile("", "completed")
+	if ferr != nil {
+		t.Fatalf("TempFile failed: %v", ferr)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	err = server.RequestsSetLogFile(file.Name())
+	if err != nil {
+		t.Fatalf("did not expect err %s", err.Error())
+	}
+	runBoth()
+	server.RequestsSetLogFile("")
+
+	// the file is written in the background
+	var lines []string
+	for i := 0; i < 100 && len(lines) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		bytes, _ := ioutil.ReadFile(file.Name())
+		lines = strings.Split(strings.TrimSpace(string(bytes)), "\n")
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 logged requests, got %v", len(lines))
+	}
+	var entry map[string]interface{}
+	ferr = json.Unmarshal([]byte(lines[0]), &entry)
+	if ferr != nil || !strings.Contains(fmt.Sprint(entry["statement"]), "orders") {
+		t.Errorf("unexpected logged request %v %v", lines[0], ferr)
+	}
+}
+
+func TestMergeOn(t *testing.T) {
+	defer newKeyspace(t, "mergetarget", map[string]string{
+		"t1": `{"code": "a", "qty": 1}`,
+		"t2": `{"code": "b", "qty": 2}`,
+		"t3": `{"code": "c", "qty": 3}`,
+	})()
+
+	qc := start()
+	targets := func() []interface{} {
+		r, _, err := Run(qc, true, "select meta(t).id, t.* from default:mergetarget t order by meta(t).id")
+		if err != nil {
+			t.Fatalf("did not expect err %s", err.Error())
+		}
+		return r
+	}
+
+	_, _, qerr := Run(qc, true, "merge into default:mergetarget t "+
+		"using [{\"code\": \"a\", \"qty\": 10}, {\"code\": \"d\", \"qty\": 4}] s on t.code = s.code "+
+		"when matched then update set t.qty = s.qty "+
+		"when not matched then insert (key \"t\" || to_string(s.qty), value s) "+
+		"when not matched by source and t.qty > 2 then delete")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+	expected := []interface{}{
+		map[string]interface{}{"id": "t1", "code": "a", "qty": 10.0},
+		map[string]interface{}{"id": "t2", "code": "b", "qty": 2.0},
+		map[string]interface{}{"id": "t4", "code": "d", "qty": 4.0},
+	}
+	if r := targets(); !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// a matched clause with a condition claims the objects that satisfy it
+	_, _, qerr = Run(qc, true, "merge into default:mergetarget t "+
+		"using [{\"code\": \"a\", \"keep\": true}, {\"code\": \"b\"}] s on t.code = s.code "+
+		"when matched and s.keep then update set t.kept = true "+
+		"when matched then delete")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+	expected = []interface{}{
+		map[string]interface{}{"id": "t1", "code": "a", "qty": 10.0, "kept": true},
+		map[string]interface{}{"id": "t4", "code": "d", "qty": 4.0},
+	}
+	if r := targets(); !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	_, _, qerr = RunErrors(qc, true, "merge into default:mergetarget t "+
+		"using [{\"code\": \"d\"}, {\"code\": \"d\"}] s on t.code = s.code "+
+		"when matched then update set t.qty = 0")
+	if qerr == nil || qerr.Code() != 5130 {
+		t.Errorf("expected multiple match error, got %v", qerr)
+	}
+
+	_, _, qerr = Run(qc, true, "merge into default:mergetarget t "+
+		"using [{\"code\": \"e\"}] s on t.code = s.code "+
+		"when not matched then insert s")
+	if qerr == nil {
+		t.Errorf("expected error for INSERT without a key")
+	}
+}
+
+func TestUpdateFromDeleteUsing(t *testing.T) {
+	docs := map[string]map[string]string{
+		"mutatetarget": {
+			"t1": `{"code": "a", "qty": 1}`,
+			"t2": `{"code": "b", "qty": 2}`,
+			"t3": `{"code": "c", "qty": 3}`,
+		},
+		"mutatesource": {
+			"s1": `{"code": "a", "tid": "t3"}`,
+			"s2": `{"code": "a"}`,
+			"s3": `{"code": "b", "qty": 20}`,
+		},
+	}
+	for ks, keys := range docs {
+		defer newKeyspace(t, ks, keys)()
+	}
+
+	qc := start()
+	targets := func() []interface{} {
+		r, _, err := Run(qc, true, "select meta(t).id, t.* from default:mutatetarget t order by meta(t).id")
+		if err != nil {
+			t.Fatalf("did not expect err %s", err.Error())
+		}
+		return r
+	}
+
+	// t1 is joined to both s1 and s2, but is only updated once
+	r, _, qerr := RunErrors(qc, true, "update default:mutatetarget t set t.hits = ifmissing(t.hits, 0) + 1 "+
+		"from default:mutatesource s where t.code = s.code returning meta(t).id")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+	if len(r) != 2 {
+		t.Errorf("expected 2 updated documents, got %v", r)
+	}
+
+	_, _, qerr = RunErrors(qc, true, "update default:mutatetarget t set t.qty = s.qty "+
+		"from default:mutatesource s where t.code = s.code and s.qty is not missing")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+
+	_, _, qerr = RunErrors(qc, true, "update default:mutatetarget t set t.tagged = true "+
+		"from default:mutatesource s where meta(t).id = s.tid")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"id": "t1", "code": "a", "qty": 1.0, "hits": 1.0},
+		map[string]interface{}{"id": "t2", "code": "b", "qty": 20.0, "hits": 1.0},
+		map[string]interface{}{"id": "t3", "code": "c", "qty": 3.0, "tagged": true},
+	}
+	if r := targets(); !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// a document joined to several objects counts once towards the LIMIT
+	r, _, qerr = RunErrors(qc, true, "update default:mutatetarget t set t.limited = true "+
+		"from default:mutatesource s where t.code = s.code limit 2 returning meta(t).id")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+	if len(r) != 2 {
+		t.Errorf("expected 2 updated documents, got %v", r)
+	}
+
+	r, _, qerr = RunErrors(qc, true, "delete from default:mutatetarget t "+
+		"using [{\"code\": \"c\"}, {\"code\": \"c\"}] s where t.code = s.code returning meta(t).id")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+	expected = []interface{}{map[string]interface{}{"id": "t3"}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	_, _, qerr = RunErrors(qc, true, "delete from default:mutatetarget t "+
+		"using default:mutatesource s join [{\"code\": \"b\"}] x on s.code = x.code where t.code = s.code")
+	if qerr != nil {
+		t.Errorf("did not expect err %s", qerr.Error())
+	}
+	if r := targets(); len(r) != 1 {
+		t.Errorf("expected 1 remaining document, got %v", r)
+	}
+
+	_, _, qerr = Run(qc, true, "update default:mutatetarget t use keys \"t1\" set t.qty = 0 "+
+		"from default:mutatesource s where t.code = s.code")
+	if qerr == nil {
+		t.Errorf("expected error for USE KEYS with FROM")
+	}
+}
+
+func TestViews(t *testing.T) {
+	defer newKeyspace(t, "viewsource", map[string]string{
+		"s1": `{"code": "a", "qty": 1}`,
+		"s2": `{"code": "b", "qty": 2}`,
+		"s3": `{"code": "c", "qty": 3}`,
+	})()
+	defer newKeyspace(t, "viewtarget", nil)()
+
+	qc := start()
+	run := runner(t, qc)
+
+	run("create view default:highscores as select g.* from default:game g where g.score > 9")
+	defer Run(qc, true, "drop view default:highscores")
+
+	r := run("select meta(h).id from default:highscores h where h.score < 100 order by meta(h).id")
+	expected := []interface{}{map[string]interface{}{"id": "damien"}, map[string]interface{}{"id": "dustin"}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// the view is merged into the query: its filter applies to the keyspace
+	r = run("explain select h.id from default:highscores h where h.score < 100")
+	plan, _ := json.Marshal(r)
+	if !strings.Contains(string(plan), `"keyspace":"game"`) ||
+		!strings.Contains(string(plan), "(((`h`.`score`) \\u003c 100) and (9 \\u003c (`h`.`score`)))") {
+		t.Errorf("unexpected plan %s", plan)
+	}
+
+	// other views are evaluated as subqueries
+	run("create view default:scorecounts as select g.score, count(*) c from default:game g group by g.score")
+	defer Run(qc, true, "drop view default:scorecounts")
+
+	r = run("select s.score, s.c from default:scorecounts s where s.c > 1")
+	expected = []interface{}{map[string]interface{}{"score": float64(10), "c": float64(2)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run("select name, `materialized` from system:views order by name")
+	expected = []interface{}{
+		map[string]interface{}{"name": "highscores", "materialized": false},
+		map[string]interface{}{"name": "scorecounts", "materialized": false},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	for _, q := range []string{
+		"create view default:highscores as select 1",
+		"create view default:game as select 1",
+		"create materialized view default:nosuchkeyspace as select 1",
+		"refresh materialized view default:highscores",
+		"drop view default:nosuchview",
+	} {
+		_, _, err := Run(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+
+	// materialized views
+	contents := func() []interface{} {
+		return run("select meta(v).id, v.qty from default:viewtarget v order by meta(v).id")
+	}
+
+	run("create materialized view default:viewtarget as select s.qty from default:viewsource s where s.qty > 1")
+	defer Run(qc, true, "drop materialized view default:viewtarget")
+
+	expected = []interface{}{
+		map[string]interface{}{"id": "s2", "qty": float64(2)},
+		map[string]interface{}{"id": "s3", "qty": float64(3)},
+	}
+	if r := contents(); !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	run("update default:viewsource s set qty = 5 where s.code in [\"a\", \"b\"]")
+	run("delete from default:viewsource s where s.code = \"c\"")
+	run("refresh materialized view default:viewtarget incremental")
+
+	expected = []interface{}{
+		map[string]interface{}{"id": "s1", "qty": float64(5)},
+		map[string]interface{}{"id": "s2", "qty": float64(5)},
+	}
+	if r := contents(); !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run("select incremental, lastRefresh is valued as refreshed from system:views where name = \"viewtarget\"")
+	expected = []interface{}{map[string]interface{}{"incremental": true, "refreshed": true}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	_, _, err := Run(qc, true, "drop view default:viewtarget")
+	if err == nil {
+		t.Errorf("expected error for DROP VIEW of a materialized view")
+	}
+}
+
+func TestSequences(t *testing.T) {
+	defer newKeyspace(t, "ordernumbers", nil)()
+
+	qc := start()
+	run := runner(t, qc)
+
+	run("create sequence default:orderno start with 100 increment by 10 cache 2")
+	defer Run(qc, true, "drop sequence default:orderno")
+
+	run("insert into default:ordernumbers (key, value) values " +
+		"(to_string(next value for default:orderno), {\"no\": prev value for default:orderno})")
+	run("insert into default:ordernumbers (key, value) values " +
+		"(to_string(next value for default:orderno), {\"no\": prev value for default:orderno})")
+
+	r := run("select meta(o).id, o.no from default:ordernumbers o order by o.no")
+	expected := []interface{}{
+		map[string]interface{}{"id": "100", "no": float64(100)},
+		map[string]interface{}{"id": "110", "no": float64(110)},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// the first block is used up: the next one starts at 120
+	r = run("select next value for default:orderno as n")
+	expected = []interface{}{map[string]interface{}{"n": float64(120)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run("select name, `increment`, cache, nextBlock from system:sequences where name = \"orderno\"")
+	expected = []interface{}{map[string]interface{}{
+		"name": "orderno", "increment": float64(10), "cache": float64(2), "nextBlock": float64(140)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	run("alter sequence default:orderno restart with 1 increment by 1 no cache")
+	r = run("select next value for default:orderno as a, next value for default:orderno as b")
+	if len(r) != 1 || r[0].(map[string]interface{})["a"] == r[0].(map[string]interface{})["b"] {
+		t.Errorf("expected two different numbers, got %v", r)
+	}
+
+	// cycling sequences wrap around; others run out
+	run("create sequence default:cycling minvalue 1 maxvalue 2 start with 1 cycle")
+	defer Run(qc, true, "drop sequence default:cycling")
+	run("create sequence default:bounded start with 1 maxvalue 2")
+	defer Run(qc, true, "drop sequence default:bounded")
+
+	var numbers []interface{}
+	for i := 0; i < 3; i++ {
+		r = run("select next value for default:cycling as n")
+		if len(r) == 1 {
+			numbers = append(numbers, r[0].(map[string]interface{})["n"])
+		}
+	}
+	expected = []interface{}{float64(1), float64(2), float64(1)}
+	if !reflect.DeepEqual(numbers, expected) {
+		t.Errorf("expected %v, got %v", expected, numbers)
+	}
+
+	run("select next value for default:bounded")
+	run("select next value for default:bounded")
+
+	for _, q := range []string{
+		"select next value for default:bounded",
+		"select next value for default:nosuchsequence",
+		"create sequence default:orderno",
+		"create sequence default:badincrement increment by 0",
+		"create sequence default:badstart start with 5 maxvalue 2",
+		"create sequence default:badrestart restart with 1",
+		"create sequence default:duplicate cache 5 cache 6",
+		"alter sequence default:orderno increment by 0",
+		"drop sequence default:nosuchsequence",
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
+// nodes sharing a keyspace store reserve blocks from the end stored
+// there, wherever it was last advanced
+func TestSequencesSharedStore(t *testing.T) {
+	defer newKeyspace(t, "sequencestore", nil)()
+
+	qc := start()
+	run := runner(t, qc)
+
+	serr := sequences.SequencesPersistInit("keyspace:default:sequencestore", qc.dstore, "default")
+	if serr != nil {
+		t.Fatalf("did not expect err %v", serr)
+	}
+	defer sequences.SequencesPersistInit("none", nil, "")
+
+	run("create sequence default:shared start with 100 increment by 10 cache 2")
+	defer Run(qc, true, "drop sequence default:shared")
+
+	r := run("select next value for default:shared as a, next value for default:shared as b")
+	expected := []interface{}{map[string]interface{}{"a": float64(100), "b": float64(110)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// another node reserves the next block: this one must read the
+	// sequence back from the store
+	run("update default:sequencestore set base = \"1000\" where meta().id = \"sequences::default:shared\"")
+
+	r = run("select next value for default:shared as n")
+	expected = []interface{}{map[string]interface{}{"n": float64(1000)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// numbers are kept as strings, so that the default bounds survive
+	r = run("select s.base, s.`max` from default:sequencestore s")
+	expected = []interface{}{map[string]interface{}{"base": "1020", "max": "9223372036854775807"}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	defer newKeyspace(t, "accounts", nil)()
+
+	qc := start()
+	run := runner(t, qc)
+
+	// documents written before the schema is set are not checked
+	run(`insert into default:accounts (key, value) values ("old", {"name": "old", "balance": -1})`)
+
+	run(`alter keyspace default:accounts set validation {"type": "object", "required": ["name", "balance"],
+		"properties": {"name": {"type": "string", "pattern": "^[a-z]+$"}, "balance": {"type": "number", "minimum": 0},
+		"tags": {"type": "array", "items": {"enum": ["gold", "silver"]}}}}`)
+	defer Run(qc, true, "alter keyspace default:accounts unset validation")
+
+	run(`insert into default:accounts (key, value) values ("good", {"name": "good", "balance": 10})`)
+
+	for _, q := range []string{
+		`insert into default:accounts (key, value) values ("bad", {"name": "bad"})`,
+		`upsert into default:accounts (key, value) values ("bad", {"name": "Bad", "balance": 1})`,
+		`update default:accounts a set a.balance = -5 where meta(a).id = "good"`,
+		`update default:accounts a set a.tags = ["bronze"] where meta(a).id = "good"`,
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+
+	r := run("select raw meta(a).id from default:accounts a order by meta(a).id")
+	expected := []interface{}{"good", "old"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run("validate keyspace default:accounts")
+	expected = []interface{}{map[string]interface{}{"id": "old", "violations": []interface{}{
+		map[string]interface{}{"path": "$.balance", "message": "must be >= 0"}}}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`validate default:accounts with {"required": ["tags"]} where name = "good"`)
+	expected = []interface{}{map[string]interface{}{"id": "good", "violations": []interface{}{
+		map[string]interface{}{"path": "$.tags", "message": "is required"}}}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`select raw validate({"a": 1.5, "b": "x"}, {"properties": {"a": {"type": "integer"}, "b": {"maxLength": 0}}})`)
+	expected = []interface{}{[]interface{}{
+		map[string]interface{}{"path": "$.a", "message": "expected integer, found number"},
+		map[string]interface{}{"path": "$.b", "message": "must be at most 0 characters long"},
+	}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// lists in the schema hold values, such as those of type and enum
+	r = run(`select raw validate("x", {"type": ["number", "null"], "enum": [1, null]})`)
+	expected = []interface{}{[]interface{}{
+		map[string]interface{}{"path": "$", "message": "expected number or null, found string"},
+	}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// in warn mode, documents are written, with a warning
+	run(`alter keyspace default:accounts set validation {"required": ["name"]} with {"mode": "warn"}`)
+	_, warnings, err := Run(qc, true, `insert into default:accounts (key, value) values ("warned", {"balance": 1})`)
+	if err != nil || len(warnings) != 1 {
+		t.Errorf("expected one warning, got %v and error %v", warnings, err)
+	}
+	r = run(`select raw meta(a).id from default:accounts a where meta(a).id = "warned"`)
+	if len(r) != 1 {
+		t.Errorf("expected the document to be written, got %v", r)
+	}
+
+	// a validation set on another node applies here too
+	merr := metastore.Apply("validations", "default:accounts",
+		[]byte(`{"namespace": "default", "keyspace": "accounts", "schema": {"required": ["owner"]}, "mode": "strict"}`))
+	if merr != nil {
+		t.Errorf("did not expect err %v", merr)
+	}
+	_, _, err = RunErrors(qc, true, `insert into default:accounts (key, value) values ("remote", {"name": "remote"})`)
+	if err == nil {
+		t.Errorf("expected the remote validation to reject the document")
+	}
+	merr = metastore.Apply("validations", "default:accounts", nil)
+	if merr != nil {
+		t.Errorf("did not expect err %v", merr)
+	}
+	run(`insert into default:accounts (key, value) values ("remote", {"name": "remote"})`)
+
+	for _, q := range []string{
+		`alter keyspace default:accounts set validation {"type": "text"}`,
+		`alter keyspace default:accounts set validation {"minimum": "zero"}`,
+		`alter keyspace default:accounts set validation {} with {"mode": "lenient"}`,
+		`alter keyspace default:accounts set validation "object"`,
+		`alter keyspace default:nosuchkeyspace set validation {}`,
+		`alter keyspace default:orders unset validation`,
+		`validate keyspace default:orders`,
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	for _, ks := range []string{"items", "audit"} {
+		defer newKeyspace(t, ks, nil)()
+	}
+
+	qc := start()
+	run := runner(t, qc)
+
+	// planned before the keyspace has DELETE triggers
+	run(`prepare delete_e from delete from default:items i where meta(i).id = "e"`)
+
+	run(`create trigger audit_insert after insert on default:items for each row
+		execute insert into default:audit (key, value) values ("insert-" || meta(new).id, {"op": "insert", "new": new})`)
+	defer Run(qc, true, "drop trigger audit_insert on default:items")
+	run(`create trigger audit_update after update on default:items for each row when old.qty != new.qty
+		execute insert into default:audit (key, value) values ("update-" || meta(new).id, {"op": "update", "old": old.qty, "new": new.qty})`)
+	defer Run(qc, true, "drop trigger audit_update on default:items")
+	run(`create trigger audit_delete before delete on default:items for each row
+		execute upsert into default:audit (key, value) values ("delete-" || meta(old).id, {"op": "delete", "old": old})`)
+	defer Run(qc, true, "drop trigger audit_delete on default:items")
+
+	run(`insert into default:items (key, value) values ("a", {"qty": 1}), ("b", {"qty": 2})`)
+	run(`update default:items i set i.qty = 5 where meta(i).id = "a"`)
+	run(`update default:items i set i.note = "same" where meta(i).id = "b"`)
+	run(`delete from default:items i where meta(i).id = "b"`)
+
+	r := run("select meta(a).id, a.* from default:audit a order by meta(a).id")
+	expected := []interface{}{
+		map[string]interface{}{"id": "delete-b", "op": "delete", "old": map[string]interface{}{"qty": 2.0, "note": "same"}},
+		map[string]interface{}{"id": "insert-a", "op": "insert", "new": map[string]interface{}{"qty": 1.0}},
+		map[string]interface{}{"id": "insert-b", "op": "insert", "new": map[string]interface{}{"qty": 2.0}},
+		map[string]interface{}{"id": "update-a", "op": "update", "old": 1.0, "new": 5.0},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// UPSERT fires the UPDATE triggers of the documents it replaces
+	run(`delete from default:audit a where meta(a).id = "update-a"`)
+	run(`upsert into default:items (key, value) values ("a", {"qty": 7}), ("d", {"qty": 4})`)
+	r = run(`select meta(a).id, a.* from default:audit a where meta(a).id in ["update-a", "insert-d"] order by meta(a).id`)
+	expected = []interface{}{
+		map[string]interface{}{"id": "insert-d", "op": "insert", "new": map[string]interface{}{"qty": 4.0}},
+		map[string]interface{}{"id": "update-a", "op": "update", "old": 5.0, "new": 7.0},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// a DELETE planned before the triggers existed does not fetch the
+	// document, but its triggers fire all the same
+	run(`insert into default:items (key, value) values ("e", {"qty": 6})`)
+	run("execute delete_e")
+	r = run(`select raw meta(a).id from default:audit a where meta(a).id = "delete-e"`)
+	expected = []interface{}{"delete-e"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// a failing BEFORE trigger aborts the statement before the write
+	run(`create trigger fail before insert on default:items for each row
+		execute insert into default:audit (key, value) values ("insert-a", {})`)
+	_, _, err := RunErrors(qc, true, `insert into default:items (key, value) values ("c", {"qty": 3})`)
+	if err == nil {
+		t.Errorf("expected the trigger to fail")
+	}
+	run("drop trigger fail on default:items")
+	r = run(`select raw meta(i).id from default:items i where meta(i).id = "c"`)
+	if len(r) != 0 {
+		t.Errorf("expected the document not to be written, got %v", r)
+	}
+
+	// triggers that fire themselves stop at the maximum depth
+	run(`create trigger again after insert on default:audit for each row
+		execute insert into default:audit (key, value) values (meta(new).id || "+", {})`)
+	_, _, err = RunErrors(qc, true, `insert into default:audit (key, value) values ("loop", {})`)
+	if err == nil {
+		t.Errorf("expected the trigger to exceed the maximum depth")
+	}
+	run("drop trigger again on default:audit")
+
+	// a trigger created on another node fires here too
+	merr := metastore.Apply("triggers", "default:items.remote", []byte(`{"namespace": "default", "keyspace": "items",
+		"name": "remote", "timing": "after", "event": "delete", "body": "delete from default:audit where meta().id = \"insert-a\""}`))
+	if merr != nil {
+		t.Errorf("did not expect err %v", merr)
+	}
+	run(`delete from default:items i where meta(i).id = "a"`)
+	merr = metastore.Apply("triggers", "default:items.remote", nil)
+	if merr != nil {
+		t.Errorf("did not expect err %v", merr)
+	}
+	r = run(`select raw meta(a).id from default:audit a where meta(a).id = "insert-a"`)
+	if len(r) != 0 {
+		t.Errorf("expected the remote trigger to fire, got %v", r)
+	}
+
+	for _, q := range []string{
+		`create trigger audit_insert after insert on default:items for each row execute delete from default:audit`,
+		`create trigger x after insert on default:items for each statement execute delete from default:audit`,
+		`create trigger x instead insert on default:items for each row execute delete from default:audit`,
+		`create trigger x after insert on default:items for each row execute select 1`,
+		`create trigger x after insert on default:items for each row when x > 1 execute delete from default:audit`,
+		`create trigger x after insert on default:nosuchkeyspace for each row execute delete from default:audit`,
+		`drop trigger nosuchtrigger on default:items`,
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	defer newKeyspace(t, "articles", nil)()
+
+	qc := start()
+	run := runner(t, qc)
+
+	run(`insert into default:articles (key, value) values
+		("a1", {"title": "Running a database", "body": "Indexes make queries fast", "tags": ["databases"]}),
+		("a2", {"title": "The quick brown fox", "body": "A fox jumps over the lazy dog", "tags": ["animals", "stories"]}),
+		("a3", {"title": "Foxes and dogs", "body": "Foxes, foxes and more foxes", "tags": ["animals"]})`)
+
+	// without an index, SEARCH() is a filter
+	r := run(`select raw meta(a).id from default:articles a where search(a, "fox") order by meta(a).id`)
+	expected := []interface{}{"a2", "a3"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	run("create index articles_text on default:articles(self) using fts")
+	run("create index articles_title on default:articles(title) using fts")
+	defer Run(qc, true, "drop index default:articles.articles_text using fts")
+	defer Run(qc, true, "drop index default:articles.articles_title using fts")
+
+	r = run(`explain select meta(a).id from default:articles a where search(a, "fox")`)
+	if !strings.Contains(fmt.Sprint(r), "IndexFtsSearch") || !strings.Contains(fmt.Sprint(r), "articles_text") {
+		t.Errorf("expected a search of articles_text, got %v", r)
+	}
+
+	r = run(`explain select meta(a).id from default:articles a where search(a.title, "fox")`)
+	if !strings.Contains(fmt.Sprint(r), "articles_title") {
+		t.Errorf("expected a search of articles_title, got %v", r)
+	}
+
+	searches := map[string][]interface{}{
+		`search(a, "fox")`:                         []interface{}{"a3", "a2"},
+		`search(a, "+fox -lazy")`:                  []interface{}{"a3"},
+		`search(a, "\"lazy dog\"")`:                []interface{}{"a2"},
+		`search(a, "\"dog lazy\"")`:                []interface{}{},
+		`search(a, "databse~")`:                    []interface{}{"a1"},
+		`search(a, "run")`:                         []interface{}{"a1"},
+		`search(a, "tags:anim*")`:                  []interface{}{"a3", "a2"},
+		`search(a.title, "dogs")`:                  []interface{}{"a3"},
+		`search(a, "fox") and a.title like "The%"`: []interface{}{"a2"},
+	}
+
+	for pred, ids := range searches {
+		r = run("select raw meta(a).id from default:articles a where " + pred +
+			" order by search_score(a) desc, meta(a).id")
+		if !reflect.DeepEqual(r, ids) {
+			t.Errorf("expected %v for %s, got %v", ids, pred, r)
+		}
+	}
+
+	// the index follows changes to the keyspace
+	run(`upsert into default:articles (key, value) values ("a4", {"title": "A fox in the snow"})`)
+	run(`delete from default:articles where meta().id = "a3"`)
+	r = run(`select raw meta(a).id from default:articles a where search(a.title, "fox") order by meta(a).id`)
+	expected = []interface{}{"a2", "a4"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`select search_meta().id, search_score() > 0 as scored from default:articles a
+		where search(a, "database", {"index": "articles_text"})`)
+	expected = []interface{}{map[string]interface{}{"id": "a1", "scored": true}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`select raw [search("The Lazy Dogs", "dog"), search({"a": ["x", "y z"]}, "\"y z\""),
+		search({"a": ["x", "y"]}, "\"x y\""), search("x", 1), search_score()]`)
+	expected = []interface{}{[]interface{}{true, true, false, nil, nil}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	for _, q := range []string{
+		`select 1 from default:articles a where search(a, "\"open")`,
+		`select raw search("x", "word~3")`,
+		`create index articles_text on default:articles(self) using fts`,
+		`create index articles_where on default:articles(self) where title is not null using fts`,
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
+func TestVectorSearch(t *testing.T) {
+	defer newKeyspace(t, "points", nil)()
+
+	qc := start()
+	run := runner(t, qc)
+
+	run(`insert into default:points (key, value) values
+		("p1", {"embedding": [1, 0]}),
+		("p2", {"embedding": [0, 1]}),
+		("p3", {"embedding": [1, 1]}),
+		("p4", {"embedding": [-1, 0]}),
+		("p5", {"embedding": [0.9, 0.1]})`)
+
+	r := run(`select raw [vector_distance([3, 4], [0, 0]) = 5, vector_distance([1, 0], [0, 1], "cosine") = 1,
+		vector_distance([1, 2], [3, 4], "dot") = -11, vector_magnitude([3, 4]) = 5,
+		vector_normalize([3, 4]) = [0.6, 0.8], vector_normalize([0, 0]), vector_distance([1], [1, 2]),
+		vector_distance([1], [1], "manhattan")]`)
+	expected := []interface{}{[]interface{}{true, true, true, true, true, nil, nil, nil}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	nearest := `select raw meta(p).id from default:points p
+		order by vector_distance(p.embedding, [1, 0], "cosine") limit 2`
+
+	// without an index, the distances are sorted
+	r = run(nearest)
+	expected = []interface{}{"p1", "p5"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	run(`create index points_cosine on default:points(embedding) using vector with {"metric": "cosine"}`)
+	run("create index points_l2 on default:points(embedding) using vector")
+	defer Run(qc, true, "drop index default:points.points_cosine using vector")
+	defer Run(qc, true, "drop index default:points.points_l2 using vector")
+
+	r = run("explain " + nearest)
+	if !strings.Contains(fmt.Sprint(r), "IndexVectorScan") || !strings.Contains(fmt.Sprint(r), "points_cosine") {
+		t.Errorf("expected a vector scan of points_cosine, got %v", r)
+	}
+
+	r = run(`explain select meta(p).id from default:points p
+		order by vector_distance([1, 0], p.embedding) limit 1`)
+	if !strings.Contains(fmt.Sprint(r), "points_l2") {
+		t.Errorf("expected a vector scan of points_l2, got %v", r)
+	}
+
+	r = run(`explain select meta(p).id from default:points p
+		order by vector_distance(p.embedding, [1, 0], "dot") limit 1`)
+	if strings.Contains(fmt.Sprint(r), "IndexVectorScan") {
+		t.Errorf("did not expect a vector scan, got %v", r)
+	}
+
+	r = run(nearest)
+	expected = []interface{}{"p1", "p5"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`select raw meta(p).id from default:points p
+		order by vector_distance(p.embedding, [1, 0]) limit 2 offset 1`)
+	expected = []interface{}{"p5", "p3"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// a predicate could filter out the nearest neighbours, so all the
+	// documents are searched
+	filtered := `select raw meta(p).id from default:points p where p.embedding[1] > 0.5
+		order by vector_distance(p.embedding, [1, 0], "cosine") limit 2`
+	r = run("explain " + filtered)
+	if strings.Contains(fmt.Sprint(r), "IndexVectorScan") {
+		t.Errorf("did not expect a vector scan, got %v", r)
+	}
+	r = run(filtered)
+	expected = []interface{}{"p3", "p2"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// the index follows changes to the keyspace
+	run(`upsert into default:points (key, value) values ("p6", {"embedding": [2, 0.01]})`)
+	run(`delete from default:points where meta().id = "p1"`)
+	r = run(nearest)
+	expected = []interface{}{"p6", "p5"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	for _, q := range []string{
+		`create index points_bad on default:points(embedding) using vector with {"metric": "manhattan"}`,
+		`create index points_where on default:points(embedding) where embedding is not null using vector`,
+		`create index points_keys on default:points(embedding, name) using vector`,
+		`create index points_l2 on default:points(embedding) using vector`,
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
+func TestPivot(t *testing.T) {
+	for _, ks := range []string{"sales", "quarters"} {
+		defer newKeyspace(t, ks, nil)()
+	}
+
+	qc := start()
+	run := runner(t, qc)
+
+	run(`insert into default:sales (key, value) values
+		("e1", {"region": "east", "quarter": "Q1", "amount": 10}),
+		("e2", {"region": "east", "quarter": "Q1", "amount": 5}),
+		("e3", {"region": "east", "quarter": "Q2", "amount": 7}),
+		("w1", {"region": "west", "quarter": "Q2", "amount": 3}),
+		("w2", {"region": "west", "quarter": "Q3", "amount": 100})`)
+
+	run(`insert into default:quarters (key, value) values
+		("east", {"region": "east", "q1": 10, "q2": 20, "q3": null}),
+		("west", {"region": "west", "q1": 5})`)
+
+	r := run(`select s.region, p.Q1, p.Q2 from default:sales s
+		pivot (sum(s.amount) for s.quarter in ("Q1", "Q2")) as p
+		group by s.region order by s.region`)
+	expected := []interface{}{
+		map[string]interface{}{"region": "east", "Q1": float64(15), "Q2": float64(7)},
+		map[string]interface{}{"region": "west", "Q1": nil, "Q2": float64(3)},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	// without GROUP BY, all the rows are pivoted
+	r = run(`select raw p from default:sales s pivot (count(*) for quarter in ("Q1", "Q2", "Q3")) as p`)
+	expected = []interface{}{map[string]interface{}{"Q1": float64(2), "Q2": float64(2), "Q3": float64(1)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`explain select raw p from default:sales s pivot (max(s.amount) for s.quarter in ("Q1")) as p`)
+	if !strings.Contains(fmt.Sprint(r), "InitialGroup") {
+		t.Errorf("expected grouping, got %v", r)
+	}
+
+	r = run(`select q.region, quarter, amount from default:quarters q
+		unpivot (amount for quarter in (q.q1, q.q2, q.q3)) order by q.region, quarter`)
+	expected = []interface{}{
+		map[string]interface{}{"region": "east", "quarter": "q1", "amount": float64(10)},
+		map[string]interface{}{"region": "east", "quarter": "q2", "amount": float64(20)},
+		map[string]interface{}{"region": "west", "quarter": "q1", "amount": float64(5)},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`explain select amount from default:quarters q unpivot (amount for quarter in (q1, q2))`)
+	if !strings.Contains(fmt.Sprint(r), "Unnest") {
+		t.Errorf("expected an unnest, got %v", r)
+	}
+
+	// unpivoted rows can be filtered, and pivoted back
+	r = run(`select raw p from default:quarters q unpivot (amount for quarter in (q.q1, q.q2))
+		pivot (sum(amount) for quarter in ("q1", "q2")) as p`)
+	expected = []interface{}{map[string]interface{}{"q1": float64(15), "q2": float64(20)}}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	r = run(`select raw meta(q).id from default:quarters q
+		unpivot (amount for quarter in (q.q1, q.q2)) where quarter = "q2"`)
+	expected = []interface{}{"east"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %v, got %v", expected, r)
+	}
+
+	for _, q := range []string{
+		`select raw p from default:sales s pivot (s.amount for s.quarter in ("Q1")) as p`,
+		`select raw p from default:sales s pivot (sum(s.amount) for s.quarter in (s.region)) as p`,
+		`select raw s from default:sales s pivot (sum(s.amount) for s.quarter in ("Q1")) as s`,
+		`select s.amount from default:sales s pivot (sum(s.amount) for s.quarter in ("Q1")) as p`,
+		`select amount from default:quarters q unpivot (amount for amount in (q.q1, q.q2))`,
+		`select amount from default:quarters q unpivot (amount for quarter in (q.q1 + 1))`,
+		`update default:sales set amount = 0 from default:sales t
+			pivot (sum(t.amount) for t.quarter in ("Q1")) as p`,
+	} {
+		_, _, err := RunErrors(qc, true, q)
+		if err == nil {
+			t.Errorf("expected error for %s", q)
+		}
+	}
+}
+
 func TestAllCaseFiles(t *testing.T) {
 	qc := start()
 	matches, err := filepath.Glob("json/default/cases/case_*.json")
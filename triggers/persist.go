@@ -0,0 +1,88 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package triggers
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/metastore"
+)
+
+// Triggers are persisted, so that they survive restarts, and sent to
+// the other query nodes, so that they fire whichever node mutates the
+// keyspace.
+
+var persisted = metastore.NewStore("triggers", errors.NewTriggerError, applyTrigger)
+
+// init trigger store
+// the spec is one of dir:<path>, keyspace:[<namespace>:]<keyspace> or none
+
+func TriggersPersistInit(spec string, ds datastore.Datastore, ns string) errors.Error {
+	return persisted.Init(spec, ds, ns)
+}
+
+func persistTrigger(trigger *Trigger) errors.Error {
+	return persisted.Save(trigger.Key(), trigger)
+}
+
+func unpersistTrigger(key string) {
+	err := persisted.Remove(key)
+	if err != nil {
+		logging.Infof("failed to remove persisted trigger %v: %v", key, err)
+	}
+}
+
+func decodeTrigger(data []byte) (*Trigger, errors.Error) {
+	trigger := &Trigger{}
+	err := json.Unmarshal(data, trigger)
+	if err != nil {
+		return nil, errors.NewTriggerError(err, "cannot decode trigger")
+	}
+	return trigger, nil
+}
+
+// a trigger was created or dropped on another node
+
+func applyTrigger(key string, data []byte) errors.Error {
+	var trigger *Trigger
+
+	if data != nil {
+		var err errors.Error
+
+		trigger, err = decodeTrigger(data)
+		if err == nil {
+			err = trigger.compile()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	triggers.Lock()
+	defer triggers.Unlock()
+
+	_, ok := triggers.triggers[key]
+	if trigger == nil {
+		if ok {
+			delete(triggers.triggers, key)
+			atomic.AddInt32(&count, -1)
+		}
+		return nil
+	}
+	if !ok {
+		atomic.AddInt32(&count, 1)
+	}
+	triggers.triggers[key] = trigger
+	return nil
+}
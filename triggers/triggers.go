@@ -0,0 +1,260 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package triggers
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/parser/n1ql"
+)
+
+// A trigger runs a DML statement, its body, for each document that an
+// INSERT, UPDATE or DELETE of a keyspace mutates, just before or just
+// after the document is written. UPSERT fires the UPDATE triggers of
+// the documents it replaces and the INSERT triggers of the others, and
+// MERGE fires the triggers of each of its actions. The body, and the optional
+// WHEN condition, see the document before and after the mutation as
+// OLD and NEW, which are MISSING for inserts and deletes respectively.
+// Any error of a trigger aborts the statement that fired it; documents
+// already written stay written.
+
+const (
+	BEFORE = "before"
+	AFTER  = "after"
+
+	INSERT = "insert"
+	UPDATE = "update"
+	DELETE = "delete"
+)
+
+// the deepest that triggers can fire each other
+const MAX_DEPTH = 16
+
+type Trigger struct {
+	Namespace string `json:"namespace"`
+	Keyspace  string `json:"keyspace"`
+	Name      string `json:"name"`
+	Timing    string `json:"timing"`
+	Event     string `json:"event"`
+	When      string `json:"when,omitempty"`
+	Body      string `json:"body"`
+
+	condition expression.Expression
+}
+
+func (this *Trigger) Key() string {
+	return key(this.Namespace, this.Keyspace, this.Name)
+}
+
+// returns nil if the trigger has no WHEN condition
+
+func (this *Trigger) Condition() expression.Expression {
+	return this.condition
+}
+
+// parse the body
+// the planner rewrites the statements it plans, so every use of the
+// trigger gets its own copy
+
+func (this *Trigger) Statement() (algebra.Statement, errors.Error) {
+	stmt, err := n1ql.ParseTriggerStatement(this.Body)
+	if err != nil {
+		return nil, errors.NewTriggerError(err, "cannot parse the body of "+this.Key())
+	}
+
+	switch stmt.(type) {
+	case *algebra.Insert, *algebra.Upsert, *algebra.Update, *algebra.Delete, *algebra.Merge:
+		return stmt, nil
+	default:
+		return nil, errors.NewTriggerError(nil, "the body of "+this.Key()+" is not a DML statement")
+	}
+}
+
+func NewTrigger(namespace, keyspace, name, timing, event, when, body string) (*Trigger, errors.Error) {
+	switch timing {
+	case BEFORE, AFTER:
+	default:
+		return nil, errors.NewTriggerError(nil, "invalid timing "+timing)
+	}
+
+	switch event {
+	case INSERT, UPDATE, DELETE:
+	default:
+		return nil, errors.NewTriggerError(nil, "invalid event "+event)
+	}
+
+	rv := &Trigger{
+		Namespace: namespace,
+		Keyspace:  keyspace,
+		Name:      name,
+		Timing:    timing,
+		Event:     event,
+		When:      when,
+		Body:      body,
+	}
+
+	err := rv.compile()
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (this *Trigger) compile() errors.Error {
+	this.condition = nil
+	if this.When != "" {
+		condition, err := n1ql.ParseTriggerExpression(this.When)
+		if err != nil {
+			return errors.NewTriggerError(err, "cannot parse the condition of "+this.Key())
+		}
+		this.condition = condition
+	}
+
+	_, err := this.Statement()
+	return err
+}
+
+type triggerCache struct {
+	sync.RWMutex
+	triggers map[string]*Trigger
+}
+
+var triggers = &triggerCache{triggers: make(map[string]*Trigger)}
+
+// the number of triggers, read without locking by the mutation
+// operators, so that keyspaces without triggers pay nothing
+var count int32
+
+func key(namespace, keyspace, name string) string {
+	return namespace + ":" + keyspace + "." + name
+}
+
+func AddTrigger(trigger *Trigger) errors.Error {
+	triggers.Lock()
+	defer triggers.Unlock()
+
+	k := trigger.Key()
+	if _, ok := triggers.triggers[k]; ok {
+		return errors.NewTriggerAlreadyExistsError(k)
+	}
+
+	err := persistTrigger(trigger)
+	if err != nil {
+		return err
+	}
+
+	triggers.triggers[k] = trigger
+	atomic.AddInt32(&count, 1)
+	return nil
+}
+
+func DropTrigger(namespace, keyspace, name string) errors.Error {
+	triggers.Lock()
+	defer triggers.Unlock()
+
+	k := key(namespace, keyspace, name)
+	if _, ok := triggers.triggers[k]; !ok {
+		return errors.NewNoSuchTriggerError(k)
+	}
+	delete(triggers.triggers, k)
+	atomic.AddInt32(&count, -1)
+	unpersistTrigger(k)
+	return nil
+}
+
+// returns nil if there is no such trigger
+
+func GetTrigger(namespace, keyspace, name string) *Trigger {
+	triggers.RLock()
+	defer triggers.RUnlock()
+	return triggers.triggers[key(namespace, keyspace, name)]
+}
+
+// the triggers of a keyspace for an event, in name order, which is the
+// order in which they fire
+
+func KeyspaceTriggers(namespace, keyspace, timing, event string) []*Trigger {
+	if atomic.LoadInt32(&count) == 0 {
+		return nil
+	}
+
+	var rv []*Trigger
+	triggers.RLock()
+	for _, trigger := range triggers.triggers {
+		if trigger.Namespace == namespace && trigger.Keyspace == keyspace &&
+			trigger.Timing == timing && trigger.Event == event {
+			rv = append(rv, trigger)
+		}
+	}
+	triggers.RUnlock()
+
+	sort.Slice(rv, func(i, j int) bool { return rv[i].Name < rv[j].Name })
+	return rv
+}
+
+func CountTriggers() int {
+	return int(atomic.LoadInt32(&count))
+}
+
+// apply f to each trigger, in key order, until it returns false
+
+func TriggersForeach(f func(key string, trigger *Trigger) bool) {
+	triggers.RLock()
+	list := make([]*Trigger, 0, len(triggers.triggers))
+	for _, trigger := range triggers.triggers {
+		list = append(list, trigger)
+	}
+	triggers.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key() < list[j].Key() })
+	for _, trigger := range list {
+		if !f(trigger.Key(), trigger) {
+			return
+		}
+	}
+}
+
+func TriggersLoad() {
+	if !persisted.Persisted() {
+		return
+	}
+
+	n := 0
+	err := persisted.Load(func(key string, data []byte) {
+		trigger, err := decodeTrigger(data)
+		if err != nil {
+			logging.Infof("cannot decode trigger %v: %v", key, err)
+			return
+		}
+		err = trigger.compile()
+		if err != nil {
+			logging.Errorf("failed to reload trigger %v: %v", trigger.Key(), err)
+			return
+		}
+
+		triggers.Lock()
+		if _, ok := triggers.triggers[trigger.Key()]; !ok {
+			atomic.AddInt32(&count, 1)
+		}
+		triggers.triggers[trigger.Key()] = trigger
+		triggers.Unlock()
+		n++
+	})
+	if err != nil {
+		logging.Errorf("failed to reload triggers: %v", err)
+	}
+	logging.Infof("reloaded %v triggers", n)
+}
@@ -0,0 +1,47 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package validation
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+// schemas, as used by the VALIDATE function
+
+type schemas struct{}
+
+func init() {
+	expression.SetSchemas(schemas{})
+}
+
+func (this schemas) Compile(schema value.Value) (expression.Schema, error) {
+	rv, err := NewSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (this schemas) Keyspace(namespace, keyspace string) expression.Schema {
+	rv := GetValidation(namespace, keyspace)
+	if rv == nil {
+		return nil
+	}
+	return rv
+}
+
+func (this *Schema) Violations(item value.Value) value.Value {
+	return this.Validate(item).Value()
+}
+
+func (this *Validation) Violations(item value.Value) value.Value {
+	return this.Validate(item).Value()
+}
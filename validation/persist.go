@@ -0,0 +1,88 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/metastore"
+)
+
+// Validations are persisted, one per keyspace, so that they survive
+// restarts, and sent to the other query nodes, so that every node
+// validates the documents it writes.
+
+var persisted = metastore.NewStore("validations", errors.NewValidationError, applyValidation)
+
+// init validation store
+// the spec is one of dir:<path>, keyspace:[<namespace>:]<keyspace> or none
+
+func ValidationsPersistInit(spec string, ds datastore.Datastore, ns string) errors.Error {
+	return persisted.Init(spec, ds, ns)
+}
+
+func persistValidation(validation *Validation) errors.Error {
+	return persisted.Save(validation.Key(), validation)
+}
+
+func unpersistValidation(key string) {
+	err := persisted.Remove(key)
+	if err != nil {
+		logging.Infof("failed to remove persisted validation %v: %v", key, err)
+	}
+}
+
+func decodeValidation(data []byte) (*Validation, errors.Error) {
+	validation := &Validation{}
+	err := json.Unmarshal(data, validation)
+	if err != nil {
+		return nil, errors.NewValidationError(err, "cannot decode validation")
+	}
+	return validation, nil
+}
+
+// a validation was set or unset on another node
+
+func applyValidation(key string, data []byte) errors.Error {
+	var validation *Validation
+
+	if data != nil {
+		var err errors.Error
+
+		validation, err = decodeValidation(data)
+		if err == nil {
+			err = validation.compile()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	validations.Lock()
+	defer validations.Unlock()
+
+	_, ok := validations.validations[key]
+	if validation == nil {
+		if ok {
+			delete(validations.validations, key)
+			atomic.AddInt32(&count, -1)
+		}
+		return nil
+	}
+	if !ok {
+		atomic.AddInt32(&count, 1)
+	}
+	validations.validations[key] = validation
+	return nil
+}
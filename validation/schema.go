@@ -0,0 +1,506 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+// A schema is compiled from a subset of JSON Schema draft-07: type,
+// enum, const, required, properties, additionalProperties, items,
+// minProperties and maxProperties, minItems and maxItems, uniqueItems,
+// pattern, minLength and maxLength, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum and multipleOf. Other keywords, such as $schema,
+// title or description, are ignored, as the specification requires.
+
+type Schema struct {
+	types []string
+	enum  []value.Value
+	cnst  value.Value
+
+	// objects
+	required      []string
+	properties    map[string]*Schema
+	additional    *Schema
+	noAdditional  bool
+	minProperties int64
+	maxProperties int64
+
+	// arrays
+	items       *Schema
+	tuple       []*Schema
+	minItems    int64
+	maxItems    int64
+	uniqueItems bool
+
+	// strings
+	pattern   *regexp.Regexp
+	minLength int64
+	maxLength int64
+
+	// numbers
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+}
+
+var _TYPES = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"number":  true,
+	"integer": true,
+	"string":  true,
+}
+
+func NewSchema(schema value.Value) (*Schema, errors.Error) {
+	return compile(schema, "$")
+}
+
+func compile(schema value.Value, path string) (*Schema, errors.Error) {
+	fields, ok := schema.Actual().(map[string]interface{})
+	if !ok {
+		return nil, schemaError(path, "a schema must be an object")
+	}
+
+	rv := &Schema{
+		minProperties: -1,
+		maxProperties: -1,
+		minItems:      -1,
+		maxItems:      -1,
+		minLength:     -1,
+		maxLength:     -1,
+	}
+
+	var err errors.Error
+	for name := range fields {
+		val, _ := schema.Field(name)
+		kpath := path + "." + name
+
+		switch name {
+		case "type":
+			rv.types, err = compileTypes(val, kpath)
+		case "enum":
+			list, ok := val.Actual().([]interface{})
+			if !ok || len(list) == 0 {
+				return nil, schemaError(kpath, "must be a non-empty array")
+			}
+			rv.enum = make([]value.Value, len(list))
+			for i := range list {
+				rv.enum[i], _ = val.Index(i)
+			}
+		case "const":
+			rv.cnst = val
+		case "required":
+			rv.required, err = compileStrings(val, kpath)
+		case "properties":
+			props, ok := val.Actual().(map[string]interface{})
+			if !ok {
+				return nil, schemaError(kpath, "must be an object")
+			}
+			rv.properties = make(map[string]*Schema, len(props))
+			for prop := range props {
+				pval, _ := val.Field(prop)
+				rv.properties[prop], err = compile(pval, kpath+"."+prop)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case "additionalProperties":
+			if b, ok := val.Actual().(bool); ok {
+				rv.noAdditional = !b
+			} else {
+				rv.additional, err = compile(val, kpath)
+			}
+		case "items":
+			if list, ok := val.Actual().([]interface{}); ok {
+				rv.tuple = make([]*Schema, len(list))
+				for i := range list {
+					ival, _ := val.Index(i)
+					rv.tuple[i], err = compile(ival, kpath+"["+strconv.Itoa(i)+"]")
+					if err != nil {
+						return nil, err
+					}
+				}
+			} else {
+				rv.items, err = compile(val, kpath)
+			}
+		case "minProperties":
+			rv.minProperties, err = compileCount(val, kpath)
+		case "maxProperties":
+			rv.maxProperties, err = compileCount(val, kpath)
+		case "minItems":
+			rv.minItems, err = compileCount(val, kpath)
+		case "maxItems":
+			rv.maxItems, err = compileCount(val, kpath)
+		case "uniqueItems":
+			rv.uniqueItems, ok = val.Actual().(bool)
+			if !ok {
+				return nil, schemaError(kpath, "must be a boolean")
+			}
+		case "pattern":
+			s, ok := val.Actual().(string)
+			if !ok {
+				return nil, schemaError(kpath, "must be a string")
+			}
+			var er error
+			rv.pattern, er = regexp.Compile(s)
+			if er != nil {
+				return nil, schemaError(kpath, er.Error())
+			}
+		case "minLength":
+			rv.minLength, err = compileCount(val, kpath)
+		case "maxLength":
+			rv.maxLength, err = compileCount(val, kpath)
+		case "minimum":
+			rv.minimum, err = compileNumber(val, kpath)
+		case "maximum":
+			rv.maximum, err = compileNumber(val, kpath)
+		case "exclusiveMinimum":
+			rv.exclusiveMinimum, err = compileNumber(val, kpath)
+		case "exclusiveMaximum":
+			rv.exclusiveMaximum, err = compileNumber(val, kpath)
+		case "multipleOf":
+			rv.multipleOf, err = compileNumber(val, kpath)
+			if err == nil && *rv.multipleOf <= 0 {
+				return nil, schemaError(kpath, "must be greater than 0")
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rv, nil
+}
+
+func compileTypes(val value.Value, path string) ([]string, errors.Error) {
+	var types []string
+	switch t := val.Actual().(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		var err errors.Error
+		types, err = compileStrings(val, path)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, schemaError(path, "must be a string or an array of strings")
+	}
+
+	for _, t := range types {
+		if !_TYPES[t] {
+			return nil, schemaError(path, "unknown type "+t)
+		}
+	}
+	return types, nil
+}
+
+func compileStrings(val value.Value, path string) ([]string, errors.Error) {
+	list, ok := val.Actual().([]interface{})
+	if !ok {
+		return nil, schemaError(path, "must be an array of strings")
+	}
+	rv := make([]string, len(list))
+	for i, v := range list {
+
+		// elements may themselves be values
+		rv[i], ok = value.NewValue(v).Actual().(string)
+		if !ok {
+			return nil, schemaError(path, "must be an array of strings")
+		}
+	}
+	return rv, nil
+}
+
+func compileCount(val value.Value, path string) (int64, errors.Error) {
+	n, ok := number(val)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return 0, schemaError(path, "must be a non-negative integer")
+	}
+	return int64(n), nil
+}
+
+func compileNumber(val value.Value, path string) (*float64, errors.Error) {
+	n, ok := number(val)
+	if !ok {
+		return nil, schemaError(path, "must be a number")
+	}
+	return &n, nil
+}
+
+func number(val value.Value) (float64, bool) {
+	switch n := val.Actual().(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func schemaError(path, msg string) errors.Error {
+	return errors.NewValidationError(nil, fmt.Sprintf("invalid schema at %s: %s", path, msg))
+}
+
+// A violation of a schema by a document, at the given path.
+
+type Violation struct {
+	Path    string
+	Message string
+}
+
+type Violations []*Violation
+
+func (this Violations) String() string {
+	var buf strings.Builder
+	for i, v := range this {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(v.Path)
+		buf.WriteString(": ")
+		buf.WriteString(v.Message)
+	}
+	return buf.String()
+}
+
+// an array of {"path": ..., "message": ...} objects
+
+func (this Violations) Value() value.Value {
+	rv := make([]interface{}, len(this))
+	for i, v := range this {
+		rv[i] = map[string]interface{}{
+			"path":    v.Path,
+			"message": v.Message,
+		}
+	}
+	return value.NewValue(rv)
+}
+
+// returns the violations of the schema by the document, with their
+// paths from $, the document itself
+
+func (this *Schema) Validate(doc value.Value) Violations {
+	return this.validate(doc, "$", nil)
+}
+
+func (this *Schema) validate(val value.Value, path string, violations Violations) Violations {
+	add := func(msg string) {
+		violations = append(violations, &Violation{Path: path, Message: msg})
+	}
+
+	if this.types != nil && !this.hasType(val) {
+		add(fmt.Sprintf("expected %s, found %s", strings.Join(this.types, " or "), typeName(val)))
+
+		// the other keywords would only repeat the type mismatch
+		return violations
+	}
+
+	if this.cnst != nil && !val.EquivalentTo(this.cnst) {
+		add("must be " + this.cnst.String())
+	}
+
+	if this.enum != nil {
+		found := false
+		for _, e := range this.enum {
+			if val.EquivalentTo(e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of the values of the enum")
+		}
+	}
+
+	switch val.Type() {
+	case value.OBJECT:
+		violations = this.validateObject(val, path, violations)
+	case value.ARRAY:
+		violations = this.validateArray(val, path, violations)
+	case value.STRING:
+		s := val.Actual().(string)
+		length := int64(utf8.RuneCountInString(s))
+		if this.minLength >= 0 && length < this.minLength {
+			add(fmt.Sprintf("must be at least %d characters long", this.minLength))
+		}
+		if this.maxLength >= 0 && length > this.maxLength {
+			add(fmt.Sprintf("must be at most %d characters long", this.maxLength))
+		}
+		if this.pattern != nil && !this.pattern.MatchString(s) {
+			add("must match the pattern " + this.pattern.String())
+		}
+	case value.NUMBER:
+		n, _ := number(val)
+		if this.minimum != nil && n < *this.minimum {
+			add(fmt.Sprintf("must be >= %v", *this.minimum))
+		}
+		if this.maximum != nil && n > *this.maximum {
+			add(fmt.Sprintf("must be <= %v", *this.maximum))
+		}
+		if this.exclusiveMinimum != nil && n <= *this.exclusiveMinimum {
+			add(fmt.Sprintf("must be > %v", *this.exclusiveMinimum))
+		}
+		if this.exclusiveMaximum != nil && n >= *this.exclusiveMaximum {
+			add(fmt.Sprintf("must be < %v", *this.exclusiveMaximum))
+		}
+		if this.multipleOf != nil {
+			q := n / *this.multipleOf
+			if math.IsInf(q, 0) || q != math.Trunc(q) {
+				add(fmt.Sprintf("must be a multiple of %v", *this.multipleOf))
+			}
+		}
+	}
+
+	return violations
+}
+
+func (this *Schema) validateObject(val value.Value, path string, violations Violations) Violations {
+	fields := val.Fields()
+
+	for _, name := range this.required {
+		if _, ok := fields[name]; !ok {
+			violations = append(violations, &Violation{Path: fieldPath(path, name), Message: "is required"})
+		}
+	}
+
+	count := int64(len(fields))
+	if this.minProperties >= 0 && count < this.minProperties {
+		violations = append(violations, &Violation{Path: path,
+			Message: fmt.Sprintf("must have at least %d properties", this.minProperties)})
+	}
+	if this.maxProperties >= 0 && count > this.maxProperties {
+		violations = append(violations, &Violation{Path: path,
+			Message: fmt.Sprintf("must have at most %d properties", this.maxProperties)})
+	}
+
+	if this.properties == nil && this.additional == nil && !this.noAdditional {
+		return violations
+	}
+
+	// in name order, so that violations are reported consistently
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fval, _ := val.Field(name)
+		fpath := fieldPath(path, name)
+		if prop, ok := this.properties[name]; ok {
+			violations = prop.validate(fval, fpath, violations)
+		} else if this.noAdditional {
+			violations = append(violations, &Violation{Path: fpath, Message: "is not allowed"})
+		} else if this.additional != nil {
+			violations = this.additional.validate(fval, fpath, violations)
+		}
+	}
+	return violations
+}
+
+func (this *Schema) validateArray(val value.Value, path string, violations Violations) Violations {
+	list := val.Actual().([]interface{})
+
+	count := int64(len(list))
+	if this.minItems >= 0 && count < this.minItems {
+		violations = append(violations, &Violation{Path: path,
+			Message: fmt.Sprintf("must have at least %d items", this.minItems)})
+	}
+	if this.maxItems >= 0 && count > this.maxItems {
+		violations = append(violations, &Violation{Path: path,
+			Message: fmt.Sprintf("must have at most %d items", this.maxItems)})
+	}
+
+	for i := range list {
+		item, _ := val.Index(i)
+		ipath := path + "[" + strconv.Itoa(i) + "]"
+
+		if this.uniqueItems {
+			for j := 0; j < i; j++ {
+				prev, _ := val.Index(j)
+				if item.EquivalentTo(prev) {
+					violations = append(violations, &Violation{Path: ipath,
+						Message: fmt.Sprintf("duplicates item %d", j)})
+					break
+				}
+			}
+		}
+
+		if this.items != nil {
+			violations = this.items.validate(item, ipath, violations)
+		} else if i < len(this.tuple) {
+			violations = this.tuple[i].validate(item, ipath, violations)
+		}
+	}
+	return violations
+}
+
+func (this *Schema) hasType(val value.Value) bool {
+	name := typeName(val)
+	for _, t := range this.types {
+		if t == name || (t == "number" && name == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// the JSON Schema type of the value; whole numbers are integers
+
+func typeName(val value.Value) string {
+	switch val.Type() {
+	case value.NULL:
+		return "null"
+	case value.BOOLEAN:
+		return "boolean"
+	case value.OBJECT:
+		return "object"
+	case value.ARRAY:
+		return "array"
+	case value.STRING:
+		return "string"
+	case value.NUMBER:
+		n, _ := number(val)
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return "integer"
+		}
+		return "number"
+	case value.MISSING:
+		return "missing"
+	}
+	return "binary"
+}
+
+// $.name, or $["odd name"] for names that are not identifiers
+
+func fieldPath(path, name string) string {
+	if _IDENTIFIER.MatchString(name) {
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
+
+var _IDENTIFIER = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
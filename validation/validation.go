@@ -0,0 +1,218 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package validation
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/value"
+)
+
+// A validation constrains the documents written to a keyspace by
+// INSERT, UPSERT, UPDATE and MERGE to those matching a schema. In strict
+// mode, documents that do not match are rejected; in warn mode, they are
+// written, and a warning is returned for each.
+
+const (
+	MODE_STRICT = "strict"
+	MODE_WARN   = "warn"
+)
+
+// option names, as found in the WITH clause of ALTER KEYSPACE
+const (
+	OPT_MODE = "mode"
+)
+
+type Validation struct {
+	Namespace  string      `json:"namespace"`
+	Keyspace   string      `json:"keyspace"`
+	Definition interface{} `json:"schema"`
+	Mode       string      `json:"mode"`
+
+	schema *Schema
+}
+
+func (this *Validation) Key() string {
+	return key(this.Namespace, this.Keyspace)
+}
+
+func (this *Validation) Strict() bool {
+	return this.Mode != MODE_WARN
+}
+
+func (this *Validation) Validate(doc value.Value) Violations {
+	return this.schema.Validate(doc)
+}
+
+// create a validation from the schema and the options of ALTER KEYSPACE
+
+func NewValidation(namespace, keyspace string, schema, options value.Value) (*Validation, errors.Error) {
+	rv := &Validation{
+		Namespace:  namespace,
+		Keyspace:   keyspace,
+		Definition: schema.Actual(),
+		Mode:       MODE_STRICT,
+	}
+
+	if options != nil {
+		fields, ok := options.Actual().(map[string]interface{})
+		if !ok {
+			return nil, errors.NewValidationError(nil, "the options must be an object")
+		}
+
+		for name := range fields {
+			val, _ := options.Field(name)
+
+			switch name {
+			case OPT_MODE:
+				mode, _ := val.Actual().(string)
+				if mode != MODE_STRICT && mode != MODE_WARN {
+					return nil, errors.NewValidationError(nil, "mode must be "+MODE_STRICT+" or "+MODE_WARN)
+				}
+				rv.Mode = mode
+			default:
+				return nil, errors.NewValidationError(nil, "unknown option "+name)
+			}
+		}
+	}
+
+	err := rv.compile()
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (this *Validation) compile() errors.Error {
+	schema, err := NewSchema(value.NewValue(this.Definition))
+	if err != nil {
+		return err
+	}
+	this.schema = schema
+	return nil
+}
+
+type validationCache struct {
+	sync.RWMutex
+	validations map[string]*Validation
+}
+
+var validations = &validationCache{validations: make(map[string]*Validation)}
+
+// the number of validations, read without locking by the mutation
+// operators, so that keyspaces without validation pay nothing
+var count int32
+
+func key(namespace, keyspace string) string {
+	return namespace + ":" + keyspace
+}
+
+// set the validation of a keyspace, replacing any existing one
+
+func SetValidation(validation *Validation) errors.Error {
+	validations.Lock()
+	defer validations.Unlock()
+
+	err := persistValidation(validation)
+	if err != nil {
+		return err
+	}
+
+	k := validation.Key()
+	if _, ok := validations.validations[k]; !ok {
+		atomic.AddInt32(&count, 1)
+	}
+	validations.validations[k] = validation
+	return nil
+}
+
+func UnsetValidation(namespace, keyspace string) errors.Error {
+	validations.Lock()
+	defer validations.Unlock()
+
+	k := key(namespace, keyspace)
+	if _, ok := validations.validations[k]; !ok {
+		return errors.NewNoSuchValidationError(k)
+	}
+	delete(validations.validations, k)
+	atomic.AddInt32(&count, -1)
+	unpersistValidation(k)
+	return nil
+}
+
+// returns nil if the keyspace has no validation
+
+func GetValidation(namespace, keyspace string) *Validation {
+	if atomic.LoadInt32(&count) == 0 {
+		return nil
+	}
+
+	validations.RLock()
+	defer validations.RUnlock()
+	return validations.validations[key(namespace, keyspace)]
+}
+
+func CountValidations() int {
+	return int(atomic.LoadInt32(&count))
+}
+
+// apply f to each validation, in key order, until it returns false
+
+func ValidationsForeach(f func(key string, validation *Validation) bool) {
+	validations.RLock()
+	list := make([]*Validation, 0, len(validations.validations))
+	for _, validation := range validations.validations {
+		list = append(list, validation)
+	}
+	validations.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key() < list[j].Key() })
+	for _, validation := range list {
+		if !f(validation.Key(), validation) {
+			return
+		}
+	}
+}
+
+func ValidationsLoad() {
+	if !persisted.Persisted() {
+		return
+	}
+
+	n := 0
+	err := persisted.Load(func(key string, data []byte) {
+		validation, err := decodeValidation(data)
+		if err != nil {
+			logging.Infof("cannot decode validation %v: %v", key, err)
+			return
+		}
+		err = validation.compile()
+		if err != nil {
+			logging.Errorf("failed to reload validation of %v: %v", validation.Key(), err)
+			return
+		}
+
+		validations.Lock()
+		if _, ok := validations.validations[validation.Key()]; !ok {
+			atomic.AddInt32(&count, 1)
+		}
+		validations.validations[validation.Key()] = validation
+		validations.Unlock()
+		n++
+	})
+	if err != nil {
+		logging.Errorf("failed to reload validations: %v", err)
+	}
+	logging.Infof("reloaded %v validations", n)
+}
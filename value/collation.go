@@ -0,0 +1,306 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package value
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Collation orders values like Collate(), except for strings, which
+it compares by its own rules. It is given by name in a COLLATE
+clause:
+
+	binary       the default N1QL collation, by code point
+	nocase       by code point, ignoring case
+	unicode      by base letter, then accents, then case, so that
+	             "apple" < "Äpfel" < "banana"
+	<locale>     as unicode, with the alphabet of the language of the
+	             locale, such as "sv_SE" where Ä sorts after Z
+
+The unicode and locale collations followed by "_ci", such as
+"unicode_ci" or "de_ci", ignore case.
+*/
+type Collation interface {
+	Name() string
+	Collate(v1, v2 Value) int
+}
+
+/*
+Returns the collation of the given name, or nil for the binary
+collation.
+*/
+func NewCollation(name string) (Collation, error) {
+	lname := strings.ToLower(name)
+	switch lname {
+	case "binary":
+		return nil, nil
+	case "nocase":
+		return &nocaseCollation{}, nil
+	}
+
+	ignoreCase := strings.HasSuffix(lname, "_ci")
+	if ignoreCase {
+		lname = lname[0 : len(lname)-3]
+	}
+
+	if lname != "unicode" && !_LOCALE.MatchString(lname) {
+		return nil, fmt.Errorf("Unknown collation %s.", name)
+	}
+
+	lang := lname
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[0:i]
+	}
+
+	return &unicodeCollation{
+		name:       name,
+		ignoreCase: ignoreCase,
+		tailoring:  _TAILORINGS[lang],
+	}, nil
+}
+
+var _LOCALE = regexp.MustCompile("^[a-z]{2,3}([-_][a-z0-9]{2,8})*$")
+
+/*
+Compares the strings by code point, ignoring case.
+*/
+type nocaseCollation struct {
+}
+
+func (this *nocaseCollation) Name() string {
+	return "nocase"
+}
+
+func (this *nocaseCollation) Collate(v1, v2 Value) int {
+	s1, s2, ok := collationStrings(v1, v2)
+	if !ok {
+		return v1.Collate(v2)
+	}
+
+	for s1 != "" && s2 != "" {
+		r1, n1 := utf8.DecodeRuneInString(s1)
+		r2, n2 := utf8.DecodeRuneInString(s2)
+		s1, s2 = s1[n1:], s2[n2:]
+
+		r1, r2 = unicode.ToLower(r1), unicode.ToLower(r2)
+		if r1 != r2 {
+			return int(r1 - r2)
+		}
+	}
+
+	return len(s1) - len(s2)
+}
+
+/*
+Compares the strings on three levels: by base letter, then by
+accent, then by case, so that accented and capital letters sort
+next to their base letters.
+*/
+type unicodeCollation struct {
+	name       string
+	ignoreCase bool
+	tailoring  map[rune]uint64
+}
+
+func (this *unicodeCollation) Name() string {
+	return this.name
+}
+
+func (this *unicodeCollation) Collate(v1, v2 Value) int {
+	s1, s2, ok := collationStrings(v1, v2)
+	if !ok {
+		return v1.Collate(v2)
+	}
+
+	k1 := this.key(s1)
+	k2 := this.key(s2)
+
+	if c := compareWeights(k1.primary, k2.primary); c != 0 {
+		return c
+	}
+
+	if c := compareWeights(k1.secondary, k2.secondary); c != 0 {
+		return c
+	}
+
+	if this.ignoreCase {
+		return 0
+	}
+
+	return compareWeights(k1.tertiary, k2.tertiary)
+}
+
+type collationKey struct {
+	primary   []uint64
+	secondary []uint64
+	tertiary  []uint64
+}
+
+/*
+Letters sort after digits, which sort after all other characters.
+*/
+const (
+	_OTHER_CLASS  = uint64(0)
+	_DIGIT_CLASS  = uint64(1) << 32
+	_LETTER_CLASS = uint64(2) << 32
+)
+
+func (this *unicodeCollation) key(s string) *collationKey {
+	key := &collationKey{
+		primary:   make([]uint64, 0, len(s)),
+		secondary: make([]uint64, 0, len(s)),
+		tertiary:  make([]uint64, 0, len(s)),
+	}
+
+	for _, r := range s {
+		// Combining marks are accents of the preceding letter
+		if unicode.Is(unicode.Mn, r) {
+			if n := len(key.secondary); n > 0 {
+				key.secondary[n-1] = key.secondary[n-1]<<16 | uint64(r)
+			}
+			continue
+		}
+
+		upper := uint64(0)
+		if unicode.IsUpper(r) {
+			upper = 1
+		}
+
+		lower := unicode.ToLower(r)
+
+		if w, ok := this.tailoring[lower]; ok {
+			key.primary = append(key.primary, w)
+			key.secondary = append(key.secondary, 0)
+			key.tertiary = append(key.tertiary, upper)
+			continue
+		}
+
+		base, ok := _BASE_LETTERS[lower]
+		accent := uint64(0)
+		if ok {
+			accent = uint64(lower)
+		} else {
+			base = string(lower)
+		}
+
+		for _, b := range base {
+			key.primary = append(key.primary, primaryWeight(b))
+			key.secondary = append(key.secondary, accent)
+			key.tertiary = append(key.tertiary, upper)
+		}
+	}
+
+	return key
+}
+
+func primaryWeight(r rune) uint64 {
+	switch {
+	case unicode.IsLetter(r):
+		return _LETTER_CLASS | uint64(r)<<8
+	case unicode.IsDigit(r):
+		return _DIGIT_CLASS | uint64(r)<<8
+	default:
+		return _OTHER_CLASS | uint64(r)<<8
+	}
+}
+
+func compareWeights(w1, w2 []uint64) int {
+	for i := 0; i < len(w1) && i < len(w2); i++ {
+		if w1[i] < w2[i] {
+			return -1
+		} else if w1[i] > w2[i] {
+			return 1
+		}
+	}
+
+	return len(w1) - len(w2)
+}
+
+func collationStrings(v1, v2 Value) (string, string, bool) {
+	if v1.Type() != STRING || v2.Type() != STRING {
+		return "", "", false
+	}
+
+	s1, _ := v1.Actual().(string)
+	s2, _ := v2.Actual().(string)
+	return s1, s2, true
+}
+
+/*
+The base letters of the lower case accented Latin letters, and the
+expansions of the ligatures.
+*/
+var _BASE_LETTERS = func() map[rune]string {
+	letters := map[string]string{
+		"a":  "àáâãäåāăą",
+		"c":  "çćĉċč",
+		"d":  "ďđð",
+		"e":  "èéêëēĕėęě",
+		"g":  "ĝğġģ",
+		"h":  "ĥħ",
+		"i":  "ìíîïĩīĭįı",
+		"j":  "ĵ",
+		"k":  "ķ",
+		"l":  "ĺļľŀł",
+		"n":  "ñńņňŉ",
+		"o":  "òóôõöøōŏő",
+		"r":  "ŕŗř",
+		"s":  "śŝşšſ",
+		"t":  "ţťŧ",
+		"u":  "ùúûüũūŭůűų",
+		"w":  "ŵ",
+		"y":  "ýÿŷ",
+		"z":  "źżž",
+		"ae": "æ",
+		"oe": "œ",
+		"ss": "ß",
+		"th": "þ",
+	}
+
+	rv := make(map[rune]string, 128)
+	for base, accented := range letters {
+		for _, r := range accented {
+			rv[r] = base
+		}
+	}
+
+	return rv
+}()
+
+/*
+The letters that some languages sort as separate letters of their
+alphabet, after the given letter.
+*/
+var _TAILORINGS = func() map[string]map[rune]uint64 {
+	tailor := func(after rune, letters string) map[rune]uint64 {
+		rv := make(map[rune]uint64, len(letters))
+		i := uint64(1)
+		for _, r := range letters {
+			rv[r] = primaryWeight(after) + i
+			i++
+		}
+		return rv
+	}
+
+	return map[string]map[rune]uint64{
+		"da": tailor('z', "æøå"),
+		"es": tailor('n', "ñ"),
+		"fi": tailor('z', "åäö"),
+		"nb": tailor('z', "æøå"),
+		"nn": tailor('z', "æøå"),
+		"no": tailor('z', "æøå"),
+		"sv": tailor('z', "åäö"),
+	}
+}()
@@ -0,0 +1,75 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package value
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCollations(t *testing.T) {
+	words := []string{"banana", "Äpfel", "apple", "Zebra", "ähnlich", "Apple", "zoo", "Ärger", "10", "ñu", "nube", "Straße", "strasse"}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"binary", []string{"10", "Apple", "Straße", "Zebra", "apple", "banana", "nube", "strasse", "zoo", "Äpfel", "Ärger", "ähnlich", "ñu"}},
+		{"nocase", []string{"10", "apple", "Apple", "banana", "nube", "strasse", "Straße", "Zebra", "zoo", "ähnlich", "Äpfel", "Ärger", "ñu"}},
+		{"unicode", []string{"10", "ähnlich", "Äpfel", "apple", "Apple", "Ärger", "banana", "ñu", "nube", "strasse", "Straße", "Zebra", "zoo"}},
+		{"de_DE", []string{"10", "ähnlich", "Äpfel", "apple", "Apple", "Ärger", "banana", "ñu", "nube", "strasse", "Straße", "Zebra", "zoo"}},
+		{"es", []string{"10", "ähnlich", "Äpfel", "apple", "Apple", "Ärger", "banana", "nube", "ñu", "strasse", "Straße", "Zebra", "zoo"}},
+		{"sv-SE", []string{"10", "apple", "Apple", "banana", "ñu", "nube", "strasse", "Straße", "Zebra", "zoo", "ähnlich", "Äpfel", "Ärger"}},
+	}
+
+	for _, test := range tests {
+		collation, err := NewCollation(test.name)
+		if err != nil {
+			t.Fatalf("Unexpected error for collation %s: %v", test.name, err)
+		}
+
+		actual := append([]string(nil), words...)
+		sort.SliceStable(actual, func(i, j int) bool {
+			v1, v2 := NewValue(actual[i]), NewValue(actual[j])
+			if collation == nil {
+				return v1.Collate(v2) < 0
+			}
+			return collation.Collate(v1, v2) < 0
+		})
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Collation %s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestCollationIgnoreCase(t *testing.T) {
+	for _, name := range []string{"nocase", "unicode_ci", "fr_ci"} {
+		collation, err := NewCollation(name)
+		if err != nil {
+			t.Fatalf("Unexpected error for collation %s: %v", name, err)
+		}
+
+		if c := collation.Collate(NewValue("Apple"), NewValue("aPPLE")); c != 0 {
+			t.Errorf("Collation %s: expected Apple = aPPLE, got %d", name, c)
+		}
+
+		if c := collation.Collate(NewValue(nil), NewValue("a")); c >= 0 {
+			t.Errorf("Collation %s: expected null < a, got %d", name, c)
+		}
+	}
+
+	for _, name := range []string{"", "nocase_ci", "en US"} {
+		if _, err := NewCollation(name); err == nil {
+			t.Errorf("Expected error for collation %q", name)
+		}
+	}
+}
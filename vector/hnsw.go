@@ -0,0 +1,346 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package vector
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// An index is a hierarchical navigable small world graph of vectors
+// by key. Each vector is linked to its nearest neighbours, on a random
+// number of layers that are sparser the higher they are; a search
+// descends the layers greedily towards the query, and explores the
+// neighbourhood it reaches on the bottom layer. The search is
+// approximate: the larger efSearch, the closer to exact.
+
+const (
+	DEFAULT_M               = 16
+	DEFAULT_EF_CONSTRUCTION = 100
+	DEFAULT_EF_SEARCH       = 64
+)
+
+type Hit struct {
+	Key      string
+	Distance float64
+}
+
+type Index struct {
+	sync.RWMutex
+	metric         Metric
+	m              int
+	efConstruction int
+	efSearch       int
+	dimension      int
+	nodes          map[string]*node
+	entry          *node
+	random         *rand.Rand
+}
+
+type node struct {
+	key     string
+	vector  []float64
+	friends [][]*node // by layer
+}
+
+func NewIndex(metric Metric, m, efConstruction, efSearch int) *Index {
+	if m < 2 {
+		m = DEFAULT_M
+	}
+	if efConstruction < m {
+		efConstruction = DEFAULT_EF_CONSTRUCTION
+	}
+	if efSearch <= 0 {
+		efSearch = DEFAULT_EF_SEARCH
+	}
+
+	return &Index{
+		metric:         metric,
+		m:              m,
+		efConstruction: efConstruction,
+		efSearch:       efSearch,
+		nodes:          make(map[string]*node),
+		random:         rand.New(rand.NewSource(1)),
+	}
+}
+
+func (this *Index) Metric() Metric {
+	return this.metric
+}
+
+// the number of vectors
+func (this *Index) Count() int {
+	this.RLock()
+	defer this.RUnlock()
+
+	return len(this.nodes)
+}
+
+// Add indexes a vector, replacing the one with the same key. Vectors
+// must have the dimension of the first one added; false is returned
+// for those that do not.
+
+func (this *Index) Add(key string, vector []float64) bool {
+	this.Lock()
+	defer this.Unlock()
+
+	this.remove(key)
+	if len(vector) == 0 || (this.dimension > 0 && len(vector) != this.dimension) {
+		return false
+	}
+	this.dimension = len(vector)
+
+	level := int(math.Floor(-math.Log(1-this.random.Float64()) / math.Log(float64(this.m))))
+	n := &node{key: key, vector: vector, friends: make([][]*node, level+1)}
+	this.nodes[key] = n
+
+	if this.entry == nil {
+		this.entry = n
+		return true
+	}
+
+	top := this.entry.level()
+	ep := this.entry
+	for l := top; l > level; l-- {
+		ep = this.greedy(vector, ep, l)
+	}
+
+	for l := minimum(level, top); l >= 0; l-- {
+		candidates := this.searchLayer(vector, ep, this.efConstruction, l)
+		n.friends[l] = this.nearest(vector, candidates, this.maxFriends(l))
+		for _, f := range n.friends[l] {
+			f.friends[l] = append(f.friends[l], n)
+			if len(f.friends[l]) > this.maxFriends(l) {
+				f.friends[l] = this.nearest(f.vector, f.friends[l], this.maxFriends(l))
+			}
+		}
+		ep = candidates[0]
+	}
+
+	if level > top {
+		this.entry = n
+	}
+	return true
+}
+
+func (this *Index) Remove(key string) {
+	this.Lock()
+	defer this.Unlock()
+
+	this.remove(key)
+}
+
+func (this *Index) remove(key string) {
+	n, ok := this.nodes[key]
+	if !ok {
+		return
+	}
+	delete(this.nodes, key)
+
+	// link the nodes that linked to the node to its neighbours instead
+	for _, o := range this.nodes {
+		for l := 0; l < len(o.friends) && l < len(n.friends); l++ {
+			if !contains(o.friends[l], n) {
+				continue
+			}
+
+			candidates := make([]*node, 0, len(o.friends[l])+len(n.friends[l]))
+			for _, c := range o.friends[l] {
+				if c != n {
+					candidates = append(candidates, c)
+				}
+			}
+			for _, c := range n.friends[l] {
+				if c != o && !contains(candidates, c) {
+					candidates = append(candidates, c)
+				}
+			}
+			o.friends[l] = this.nearest(o.vector, candidates, this.maxFriends(l))
+		}
+	}
+
+	if this.entry == n {
+		this.entry = nil
+		for _, o := range this.nodes {
+			if this.entry == nil || o.level() > this.entry.level() ||
+				(o.level() == this.entry.level() && o.key < this.entry.key) {
+				this.entry = o
+			}
+		}
+	}
+
+	if len(this.nodes) == 0 {
+		this.dimension = 0
+	}
+}
+
+// Search returns the k nearest vectors to the query, by ascending
+// distance. Nothing is returned for a query of another dimension.
+
+func (this *Index) Search(query []float64, k int) []Hit {
+	this.RLock()
+	defer this.RUnlock()
+
+	if this.entry == nil || k <= 0 || len(query) != this.dimension {
+		return nil
+	}
+
+	ep := this.entry
+	for l := ep.level(); l > 0; l-- {
+		ep = this.greedy(query, ep, l)
+	}
+
+	ef := this.efSearch
+	if ef < k {
+		ef = k
+	}
+
+	candidates := this.searchLayer(query, ep, ef, 0)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	hits := make([]Hit, len(candidates))
+	for i, c := range candidates {
+		hits[i] = Hit{c.key, this.metric.Distance(query, c.vector)}
+	}
+	return hits
+}
+
+func (this *Index) maxFriends(level int) int {
+	if level == 0 {
+		return 2 * this.m
+	}
+	return this.m
+}
+
+// the node nearest to the query reached from ep on a layer
+func (this *Index) greedy(query []float64, ep *node, level int) *node {
+	d := this.metric.Distance(query, ep.vector)
+	for changed := true; changed; {
+		changed = false
+		for _, f := range ep.friends[level] {
+			if fd := this.metric.Distance(query, f.vector); fd < d {
+				ep, d, changed = f, fd, true
+			}
+		}
+	}
+	return ep
+}
+
+// the ef nearest nodes to the query found from ep on a layer, by
+// ascending distance
+func (this *Index) searchLayer(query []float64, ep *node, ef int, level int) []*node {
+	visited := map[*node]bool{ep: true}
+	d := this.metric.Distance(query, ep.vector)
+	candidates := &queue{{ep, d}}
+	results := &queue{{ep, -d}} // farthest first
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(item)
+		if c.distance > -(*results)[0].distance && results.Len() >= ef {
+			break
+		}
+
+		for _, f := range c.node.friends[level] {
+			if visited[f] {
+				continue
+			}
+			visited[f] = true
+
+			fd := this.metric.Distance(query, f.vector)
+			if results.Len() < ef || fd < -(*results)[0].distance {
+				heap.Push(candidates, item{f, fd})
+				heap.Push(results, item{f, -fd})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	rv := make([]*node, results.Len())
+	for i := len(rv) - 1; i >= 0; i-- {
+		rv[i] = heap.Pop(results).(item).node
+	}
+	return rv
+}
+
+// the n nodes nearest to the vector, by ascending distance
+func (this *Index) nearest(vector []float64, nodes []*node, n int) []*node {
+	distances := make(map[*node]float64, len(nodes))
+	for _, o := range nodes {
+		distances[o] = this.metric.Distance(vector, o.vector)
+	}
+
+	rv := append(make([]*node, 0, len(nodes)), nodes...)
+	sort.Slice(rv, func(i, j int) bool {
+		if distances[rv[i]] != distances[rv[j]] {
+			return distances[rv[i]] < distances[rv[j]]
+		}
+		return rv[i].key < rv[j].key
+	})
+
+	if len(rv) > n {
+		rv = rv[:n]
+	}
+	return rv
+}
+
+func (this *node) level() int {
+	return len(this.friends) - 1
+}
+
+func contains(nodes []*node, n *node) bool {
+	for _, o := range nodes {
+		if o == n {
+			return true
+		}
+	}
+	return false
+}
+
+func minimum(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// a min heap of nodes by distance
+type item struct {
+	node     *node
+	distance float64
+}
+
+type queue []item
+
+func (this queue) Len() int { return len(this) }
+
+func (this queue) Less(i, j int) bool {
+	if this[i].distance != this[j].distance {
+		return this[i].distance < this[j].distance
+	}
+	return this[i].node.key < this[j].node.key
+}
+
+func (this queue) Swap(i, j int) { this[i], this[j] = this[j], this[i] }
+
+func (this *queue) Push(x interface{}) { *this = append(*this, x.(item)) }
+
+func (this *queue) Pop() interface{} {
+	old := *this
+	x := old[len(old)-1]
+	*this = old[:len(old)-1]
+	return x
+}
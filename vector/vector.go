@@ -0,0 +1,130 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package vector provides the distances between vectors of numbers, and
+approximate nearest neighbour indexes of vectors.
+*/
+package vector
+
+import (
+	"math"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+// A metric is a distance between vectors: the smaller the distance,
+// the more similar the vectors.
+type Metric int
+
+const (
+	L2     = Metric(iota) // euclidean distance
+	COSINE                // 1 - the cosine of the angle between the vectors
+	DOT                   // the negated dot product
+)
+
+const DEFAULT_METRIC = L2
+
+var metrics = map[string]Metric{
+	"l2":        L2,
+	"euclidean": L2,
+	"cosine":    COSINE,
+	"dot":       DOT,
+}
+
+func ParseMetric(name string) (Metric, bool) {
+	m, ok := metrics[strings.ToLower(name)]
+	return m, ok
+}
+
+func (this Metric) String() string {
+	switch this {
+	case COSINE:
+		return "cosine"
+	case DOT:
+		return "dot"
+	default:
+		return "l2"
+	}
+}
+
+// Distance returns the distance between vectors of the same length.
+// The cosine distance from a zero vector is 1.
+
+func (this Metric) Distance(a, b []float64) float64 {
+	switch this {
+	case COSINE:
+		ma := Magnitude(a)
+		mb := Magnitude(b)
+		if ma == 0 || mb == 0 {
+			return 1
+		}
+		return 1 - dot(a, b)/(ma*mb)
+	case DOT:
+		return -dot(a, b)
+	default:
+		d := 0.0
+		for i, x := range a {
+			d += (x - b[i]) * (x - b[i])
+		}
+		return math.Sqrt(d)
+	}
+}
+
+func Magnitude(a []float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// Normalize returns the vector scaled to a magnitude of 1, or nil for
+// a zero vector.
+
+func Normalize(a []float64) []float64 {
+	m := Magnitude(a)
+	if m == 0 {
+		return nil
+	}
+
+	rv := make([]float64, len(a))
+	for i, x := range a {
+		rv[i] = x / m
+	}
+	return rv
+}
+
+func dot(a, b []float64) float64 {
+	d := 0.0
+	for i, x := range a {
+		d += x * b[i]
+	}
+	return d
+}
+
+// FromValue returns the numbers of a non-empty array of numbers.
+
+func FromValue(v value.Value) ([]float64, bool) {
+	if v.Type() != value.ARRAY {
+		return nil, false
+	}
+
+	actual := v.Actual().([]interface{})
+	if len(actual) == 0 {
+		return nil, false
+	}
+
+	rv := make([]float64, len(actual))
+	for i, a := range actual {
+		e := value.NewValue(a)
+		if e.Type() != value.NUMBER {
+			return nil, false
+		}
+		rv[i] = e.Actual().(float64)
+	}
+	return rv, true
+}
@@ -0,0 +1,134 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package vector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	a := []float64{1, 0}
+	b := []float64{0, 2}
+
+	distances := map[Metric]float64{
+		L2:     math.Sqrt(5),
+		COSINE: 1,
+		DOT:    0,
+	}
+
+	for m, expected := range distances {
+		if d := m.Distance(a, b); math.Abs(d-expected) > 1e-9 {
+			t.Errorf("%s distance is %v, expected %v", m, d, expected)
+		}
+	}
+
+	if d := COSINE.Distance([]float64{1, 1}, []float64{3, 3}); math.Abs(d) > 1e-9 {
+		t.Errorf("cosine distance of parallel vectors is %v", d)
+	}
+
+	if d := DOT.Distance([]float64{1, 2}, []float64{3, 4}); d != -11 {
+		t.Errorf("dot distance is %v", d)
+	}
+
+	if n := Normalize([]float64{3, 4}); n[0] != 0.6 || n[1] != 0.8 {
+		t.Errorf("normalized vector is %v", n)
+	}
+
+	if Normalize([]float64{0, 0}) != nil {
+		t.Errorf("zero vector was normalized")
+	}
+
+	if m, ok := ParseMetric("Euclidean"); !ok || m != L2 {
+		t.Errorf("euclidean is not l2")
+	}
+
+	if _, ok := ParseMetric("manhattan"); ok {
+		t.Errorf("manhattan is not a metric")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	for _, metric := range []Metric{L2, COSINE, DOT} {
+		vectors := make(map[string][]float64)
+		index := NewIndex(metric, 8, 64, 64)
+		for i := 0; i < 500; i++ {
+			key := fmt.Sprintf("v%03d", i)
+			v := []float64{random.Float64(), random.Float64(), random.Float64(), random.Float64()}
+			vectors[key] = v
+			if !index.Add(key, v) {
+				t.Fatalf("vector %s was not added", key)
+			}
+		}
+
+		if index.Add("bad", []float64{1, 2}) {
+			t.Errorf("vector of another dimension was added")
+		}
+
+		// remove some, and replace others
+		for i := 0; i < 500; i += 5 {
+			key := fmt.Sprintf("v%03d", i)
+			index.Remove(key)
+			delete(vectors, key)
+		}
+		for i := 1; i < 500; i += 7 {
+			key := fmt.Sprintf("v%03d", i)
+			if _, ok := vectors[key]; ok {
+				v := []float64{random.Float64(), random.Float64(), random.Float64(), random.Float64()}
+				vectors[key] = v
+				index.Add(key, v)
+			}
+		}
+
+		if index.Count() != len(vectors) {
+			t.Errorf("index has %d vectors, expected %d", index.Count(), len(vectors))
+		}
+
+		found := 0
+		for q := 0; q < 20; q++ {
+			query := []float64{random.Float64(), random.Float64(), random.Float64(), random.Float64()}
+
+			exact := make([]Hit, 0, len(vectors))
+			for key, v := range vectors {
+				exact = append(exact, Hit{key, metric.Distance(query, v)})
+			}
+			sort.Slice(exact, func(i, j int) bool { return exact[i].Distance < exact[j].Distance })
+
+			hits := index.Search(query, 10)
+			if len(hits) != 10 {
+				t.Fatalf("%s search returned %d hits", metric, len(hits))
+			}
+
+			for i := 1; i < len(hits); i++ {
+				if hits[i].Distance < hits[i-1].Distance {
+					t.Errorf("%s hits are not by distance: %v", metric, hits)
+				}
+			}
+
+			top := make(map[string]bool)
+			for _, e := range exact[:10] {
+				top[e.Key] = true
+			}
+			for _, h := range hits {
+				if top[h.Key] {
+					found++
+				}
+			}
+		}
+
+		if recall := float64(found) / 200; recall < 0.9 {
+			t.Errorf("%s recall is %v", metric, recall)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package views
+
+import (
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+)
+
+// The field holding the key of the source document in the results of
+// a keyed definition.
+const KEY_FIELD = "#viewkey"
+
+// A materialized view can be refreshed incrementally if each of its
+// rows derives from exactly one document of a single keyspace: the
+// row is then stored under the key of that document, and only rows
+// that have changed need to be written.
+
+func Incremental(sel *algebra.Select, namespace string) bool {
+	if sel.Order() != nil || sel.Limit() != nil || sel.Offset() != nil {
+		return false
+	}
+
+	sub, ok := sel.Subresult().(*algebra.Subselect)
+	if !ok || sub.Group() != nil || sub.Projection().Distinct() || sub.Projection().Raw() {
+		return false
+	}
+
+	term := sourceTerm(sub.From())
+	if term == nil {
+		return false
+	}
+
+	// a view is inlined, and its rows do not carry a key
+	ns := term.Namespace()
+	if ns == "" {
+		ns = namespace
+	}
+	if GetView(ns, term.Keyspace()) != nil {
+		return false
+	}
+
+	for _, rt := range sub.Projection().Terms() {
+		if rt.Modifiers() != nil || hasAggregate(rt.Expression()) {
+			return false
+		}
+	}
+	return true
+}
+
+// the text of an incremental definition, with the key of the source
+// document added to the projection
+
+func KeyedText(sel *algebra.Select) string {
+	sub := sel.Subresult().(*algebra.Subselect)
+	alias := sourceTerm(sub.From()).Alias()
+
+	text := sub.String()
+	proj := sub.Projection().String()
+	i := strings.Index(text, proj)
+	return text[:i] + "meta(`" + alias + "`).id as `" + KEY_FIELD + "`, " + text[i:]
+}
+
+func sourceTerm(from algebra.FromTerm) *algebra.KeyspaceTerm {
+	switch from := from.(type) {
+	case *algebra.KeyspaceTerm:
+		return from
+	case *algebra.ExpressionTerm:
+		if from.IsKeyspace() {
+			return from.KeyspaceTerm()
+		}
+	}
+	return nil
+}
+
+func hasAggregate(expr expression.Expression) bool {
+	if expr == nil {
+		return false
+	}
+	if _, ok := expr.(algebra.Aggregate); ok {
+		return true
+	}
+	if _, ok := expr.(*algebra.Subquery); ok {
+		return false
+	}
+	for _, child := range expr.Children() {
+		if hasAggregate(child) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package views
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/metastore"
+)
+
+// View definitions are persisted, so that they survive restarts, and
+// sent to the other query nodes, so that every node sees the same views.
+
+var persisted = metastore.NewStore("views", errors.NewViewError, applyView)
+
+// init view store
+// the spec is one of dir:<path>, keyspace:[<namespace>:]<keyspace> or none
+
+func ViewsPersistInit(spec string, ds datastore.Datastore, ns string) errors.Error {
+	return persisted.Init(spec, ds, ns)
+}
+
+func persistView(view *View) {
+	err := persisted.Save(view.Key(), view)
+	if err != nil {
+		logging.Infof("failed to persist view %v: %v", view.Key(), err)
+	}
+}
+
+func unpersistView(key string) {
+	err := persisted.Remove(key)
+	if err != nil {
+		logging.Infof("failed to remove persisted view %v: %v", key, err)
+	}
+}
+
+func decodeView(data []byte) (*View, errors.Error) {
+	view := &View{}
+	err := json.Unmarshal(data, view)
+	if err != nil {
+		return nil, errors.NewViewError(err, "cannot decode view")
+	}
+	return view, nil
+}
+
+// a view was created, refreshed or dropped on another node
+
+func applyView(key string, data []byte) errors.Error {
+	views.Lock()
+	defer views.Unlock()
+
+	if data == nil {
+		delete(views.views, key)
+	} else {
+		view, err := decodeView(data)
+		if err != nil {
+			return err
+		}
+		views.views[key] = view
+	}
+	atomic.AddUint64(&version, 1)
+	return nil
+}
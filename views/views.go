@@ -0,0 +1,205 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package views
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/parser/n1ql"
+)
+
+// A view is a named query. Plain views are inlined by the planner
+// wherever they are referenced; materialized views are backed by the
+// keyspace of the same name, populated by running the query.
+
+type View struct {
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	Text         string    `json:"definition"`
+	Materialized bool      `json:"materialized"`
+	Incremental  bool      `json:"incremental"`
+	LastRefresh  time.Time `json:"lastRefresh"`
+}
+
+func (this *View) Key() string {
+	return key(this.Namespace, this.Name)
+}
+
+// parse the definition
+// the planner rewrites the statements it plans, so every use of the
+// view gets its own copy
+
+func (this *View) Definition() (*algebra.Select, errors.Error) {
+	stmt, err := n1ql.ParseStatement(this.Text)
+	if err != nil {
+		return nil, errors.NewViewError(err, "cannot parse the definition of "+this.Key())
+	}
+	sel, ok := stmt.(*algebra.Select)
+	if !ok {
+		return nil, errors.NewViewError(nil, "the definition of "+this.Key()+" is not a SELECT")
+	}
+	return sel, nil
+}
+
+// the definition with the key of the source document of each row
+// added, for incremental definitions
+
+func (this *View) KeyedDefinition() (*algebra.Select, errors.Error) {
+	sel, err := this.Definition()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, er := n1ql.ParseStatement(KeyedText(sel))
+	if er != nil {
+		return nil, errors.NewViewError(er, "cannot parse the definition of "+this.Key())
+	}
+	return stmt.(*algebra.Select), nil
+}
+
+// the query returning the key and value of the documents stored in a
+// materialized view
+
+func (this *View) Contents() (*algebra.Select, errors.Error) {
+	text := "select meta(`v`).id as `id`, `v` as `doc` from `" + this.Namespace + "`:`" + this.Name + "` as `v`"
+	stmt, err := n1ql.ParseStatement(text)
+	if err != nil {
+		return nil, errors.NewViewError(err, "cannot read the contents of "+this.Key())
+	}
+	return stmt.(*algebra.Select), nil
+}
+
+type viewCache struct {
+	sync.RWMutex
+	views map[string]*View
+}
+
+var views = &viewCache{views: make(map[string]*View)}
+
+// bumped whenever a view is created or dropped, so that cached plans
+// that may have inlined views are not reused
+var version uint64
+
+func Version() uint64 {
+	return atomic.LoadUint64(&version)
+}
+
+func key(namespace, name string) string {
+	return namespace + ":" + name
+}
+
+func AddView(view *View) errors.Error {
+	views.Lock()
+	defer views.Unlock()
+
+	k := view.Key()
+	if _, ok := views.views[k]; ok {
+		return errors.NewViewAlreadyExistsError(k)
+	}
+	views.views[k] = view
+	atomic.AddUint64(&version, 1)
+	persistView(view)
+	return nil
+}
+
+func DropView(namespace, name string) (*View, errors.Error) {
+	views.Lock()
+	defer views.Unlock()
+
+	k := key(namespace, name)
+	view, ok := views.views[k]
+	if !ok {
+		return nil, errors.NewNoSuchViewError(k)
+	}
+	delete(views.views, k)
+	atomic.AddUint64(&version, 1)
+	unpersistView(k)
+	return view, nil
+}
+
+// returns nil if there is no such view
+
+func GetView(namespace, name string) *View {
+	views.RLock()
+	defer views.RUnlock()
+
+	view, ok := views.views[key(namespace, name)]
+	if !ok {
+		return nil
+	}
+	rv := *view
+	return &rv
+}
+
+func SetRefreshed(namespace, name string, refreshed time.Time) {
+	views.Lock()
+	defer views.Unlock()
+
+	view, ok := views.views[key(namespace, name)]
+	if !ok {
+		return
+	}
+	view.LastRefresh = refreshed
+	persistView(view)
+}
+
+func CountViews() int {
+	views.RLock()
+	defer views.RUnlock()
+	return len(views.views)
+}
+
+// apply f to a copy of each view, in key order, until it returns false
+
+func ViewsForeach(f func(key string, view *View) bool) {
+	views.RLock()
+	list := make([]*View, 0, len(views.views))
+	for _, view := range views.views {
+		rv := *view
+		list = append(list, &rv)
+	}
+	views.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key() < list[j].Key() })
+	for _, view := range list {
+		if !f(view.Key(), view) {
+			return
+		}
+	}
+}
+
+func ViewsLoad() {
+	if !persisted.Persisted() {
+		return
+	}
+
+	count := 0
+	err := persisted.Load(func(key string, data []byte) {
+		view, err := decodeView(data)
+		if err != nil {
+			logging.Infof("cannot decode view %v: %v", key, err)
+			return
+		}
+		views.Lock()
+		views.views[view.Key()] = view
+		views.Unlock()
+		count++
+	})
+	if err != nil {
+		logging.Errorf("failed to reload views: %v", err)
+	}
+	logging.Infof("reloaded %v views", count)
+}